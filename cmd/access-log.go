@@ -0,0 +1,263 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	humanize "github.com/dustin/go-humanize"
+)
+
+const (
+	// Interval between two writes of the pending access logs.
+	accessLogFlushInterval = 5 * time.Minute
+
+	// Pending access logs of a target are written early once they
+	// exceed this size.
+	accessLogMaxBufferSize = 5 * humanize.MiByte
+
+	// Time format of the access log lines.
+	accessLogTimeFormat = "02/Jan/2006:15:04:05 -0700"
+
+	// Time format of the names of the access log objects.
+	accessLogObjectTimeFormat = "2006-01-02-15-04-05"
+)
+
+// Resource types of the access log operations by subresource, other
+// requests operate on the bucket or object itself.
+var accessLogSubresources = []struct {
+	query, resource string
+}{
+	{"acl", "ACL"},
+	{"cors", "CORS"},
+	{"delete", "MULTI_OBJECT_DELETE"},
+	{"encryption", "ENCRYPTION"},
+	{"events", "NOTIFICATION_EVENTS"},
+	{"legal-hold", "LEGAL_HOLD"},
+	{"lifecycle", "LIFECYCLE"},
+	{"location", "LOCATION"},
+	{"logging", "LOGGING_STATUS"},
+	{"notification", "NOTIFICATION"},
+	{"object-lock", "OBJECT_LOCK_CONFIGURATION"},
+	{"partNumber", "PART"},
+	{"policy", "BUCKETPOLICY"},
+	{"replication", "REPLICATION"},
+	{"retention", "RETENTION"},
+	{"tagging", "TAGGING"},
+	{"uploadId", "UPLOAD"},
+	{"uploads", "UPLOADS"},
+	{"versioning", "VERSIONING"},
+	{"versions", "BUCKETVERSIONS"},
+	{"website", "WEBSITE"},
+}
+
+// getAccessLogOperation - returns the operation of a request in the
+// REST.<method>.<resource> form of S3 server access logs.
+func getAccessLogOperation(r *http.Request, object string) string {
+	method := r.Method
+	if method == httpPUT && r.Header.Get("X-Amz-Copy-Source") != "" {
+		method = "COPY"
+	}
+	resource := "BUCKET"
+	if object != "" {
+		resource = "OBJECT"
+	}
+	query := r.URL.Query()
+	for _, subresource := range accessLogSubresources {
+		if _, ok := query[subresource.query]; ok {
+			resource = subresource.resource
+			break
+		}
+	}
+	return "REST." + method + "." + resource
+}
+
+// accessLogField - returns the value of an access log field, "-" if
+// the value is unknown.
+func accessLogField(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+// accessLogEntry - a single request in a server access log.
+type accessLogEntry struct {
+	Bucket     string
+	Time       time.Time
+	RemoteIP   string
+	Requester  string
+	RequestID  string
+	Operation  string
+	Key        string
+	RequestURI string
+	Status     int
+	BytesSent  int64
+	TotalTime  time.Duration
+	Referrer   string
+	UserAgent  string
+	VersionID  string
+}
+
+// newAccessLogEntry - returns the access log entry of a served request.
+func newAccessLogEntry(r *http.Request, w *httpResponseRecorder, bucket, object string, start time.Time, totalTime time.Duration) accessLogEntry {
+	remoteIP, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		remoteIP = r.RemoteAddr
+	}
+	status := w.respStatusCode
+	if status == 0 {
+		status = http.StatusOK
+	}
+	return accessLogEntry{
+		Bucket:     bucket,
+		Time:       start,
+		RemoteIP:   remoteIP,
+		Requester:  getReqAccessKey(r),
+		RequestID:  w.Header().Get(responseRequestIDKey),
+		Operation:  getAccessLogOperation(r, object),
+		Key:        object,
+		RequestURI: r.Method + " " + r.URL.RequestURI() + " " + r.Proto,
+		Status:     status,
+		BytesSent:  w.bytesWritten,
+		TotalTime:  totalTime,
+		Referrer:   r.Referer(),
+		UserAgent:  r.UserAgent(),
+		VersionID:  w.Header().Get("x-amz-version-id"),
+	}
+}
+
+// String - formats an access log entry as a line of an S3 server
+// access log. The bucket owner, error code, object size and turn
+// around time are not known and always reported as "-".
+func (e accessLogEntry) String() string {
+	return fmt.Sprintf("- %s [%s] %s %s %s %s %s %q %d - %d - %d - %q %q %s\n",
+		e.Bucket, e.Time.Format(accessLogTimeFormat), accessLogField(e.RemoteIP),
+		accessLogField(e.Requester), accessLogField(e.RequestID), e.Operation,
+		accessLogField(e.Key), e.RequestURI, e.Status, e.BytesSent,
+		int64(e.TotalTime/time.Millisecond), accessLogField(e.Referrer),
+		accessLogField(e.UserAgent), accessLogField(e.VersionID))
+}
+
+// accessLogTarget - the bucket and prefix access logs are written to.
+type accessLogTarget struct {
+	Bucket string
+	Prefix string
+}
+
+// Variable represents the access logs waiting to be written.
+var globalAccessLogs *accessLogs
+
+// accessLogs - access log lines of all logged buckets, batched by
+// target and periodically written as log objects.
+type accessLogs struct {
+	sync.Mutex
+	objAPI ObjectLayer
+
+	// Pending access log lines by target.
+	buffers map[accessLogTarget]*bytes.Buffer
+}
+
+// newAccessLogs - returns an empty batch of access logs written to
+// the object layer.
+func newAccessLogs(objAPI ObjectLayer) *accessLogs {
+	return &accessLogs{
+		objAPI:  objAPI,
+		buffers: make(map[accessLogTarget]*bytes.Buffer),
+	}
+}
+
+// startAccessLogger - starts the background routine which writes the
+// pending access logs once every interval, and once more when the
+// server stops.
+func startAccessLogger(objAPI ObjectLayer) {
+	globalAccessLogs = newAccessLogs(objAPI)
+	go func() {
+		ticker := time.NewTicker(accessLogFlushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				globalAccessLogs.flush()
+			case <-globalServiceDoneCh:
+				globalAccessLogs.flush()
+				return
+			}
+		}
+	}()
+}
+
+// logRequest - records a served request if access logging is enabled
+// for its bucket.
+func (l *accessLogs) logRequest(r *http.Request, w *httpResponseRecorder, start time.Time, totalTime time.Duration) {
+	if l == nil {
+		return
+	}
+	bucket, object := getRequestBucketObject(r)
+	if bucket == "" {
+		return
+	}
+	lcfg := globalBucketLogging.GetBucketLogging(bucket)
+	if lcfg == nil {
+		return
+	}
+	target := accessLogTarget{lcfg.TargetBucket, lcfg.TargetPrefix}
+	line := newAccessLogEntry(r, w, bucket, object, start, totalTime).String()
+
+	l.Lock()
+	defer l.Unlock()
+	buffer, ok := l.buffers[target]
+	if !ok {
+		buffer = &bytes.Buffer{}
+		l.buffers[target] = buffer
+	}
+	buffer.WriteString(line)
+	if buffer.Len() >= accessLogMaxBufferSize {
+		delete(l.buffers, target)
+		go l.writeLogObject(target, buffer.Bytes())
+	}
+}
+
+// flush - writes the pending access logs of all targets.
+func (l *accessLogs) flush() {
+	if l == nil {
+		return
+	}
+	l.Lock()
+	buffers := l.buffers
+	l.buffers = make(map[accessLogTarget]*bytes.Buffer)
+	l.Unlock()
+
+	for target, buffer := range buffers {
+		l.writeLogObject(target, buffer.Bytes())
+	}
+}
+
+// writeLogObject - writes access log lines as a new log object of the
+// target, the lines are dropped if the object can not be written.
+func (l *accessLogs) writeLogObject(target accessLogTarget, data []byte) {
+	now := UTCNow()
+	object := target.Prefix + now.Format(accessLogObjectTimeFormat) + "-" + mustGetRequestID(now)
+	metadata := map[string]string{"content-type": "text/plain"}
+	_, err := l.objAPI.PutObject(target.Bucket, object, int64(len(data)), bytes.NewReader(data), metadata, "")
+	errorIf(err, "Unable to write access log %s.", pathJoin(target.Bucket, object))
+}
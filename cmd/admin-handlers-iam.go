@@ -0,0 +1,279 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+)
+
+// Maximum size of IAM admin request bodies.
+const maxIAMRequestSize = maxAccessPolicySize
+
+// addUserReq - secret key and status of a user to add.
+type addUserReq struct {
+	SecretKey string `json:"secretKey"`
+	Status    string `json:"status"`
+}
+
+// updateGroupMembersReq - users to add to or remove from a group.
+type updateGroupMembersReq struct {
+	Members  []string `json:"members"`
+	IsRemove bool     `json:"isRemove"`
+}
+
+// validateIAMAdminRequest - verifies that the object layer and IAM are
+// initialized and that the request is signed with the server credential.
+func validateIAMAdminRequest(r *http.Request) (ObjectLayer, APIErrorCode) {
+	objectAPI := newObjectLayerFn()
+	if objectAPI == nil || globalIAMSys == nil {
+		return nil, ErrServerNotInitialized
+	}
+
+	// Validate request signature.
+	if adminAPIErr := checkRequestAuthType(r, "", "", ""); adminAPIErr != ErrNone {
+		return nil, adminAPIErr
+	}
+	return objectAPI, ErrNone
+}
+
+// writeIAMResponseJSON - writes the value as JSON response.
+func writeIAMResponseJSON(w http.ResponseWriter, r *http.Request, v interface{}) {
+	jsonBytes, err := json.Marshal(v)
+	if err != nil {
+		errorIf(err, "Failed to marshal IAM response into json.")
+		writeErrorResponse(w, ErrInternalError, r.URL)
+		return
+	}
+	writeSuccessResponseJSON(w, jsonBytes)
+}
+
+// AddUserHandler - PUT /?iam&accessKey=<accessKey>
+// - x-minio-operation = add-user
+// ----------
+// Adds a user or updates the secret key and status of an existing
+// user, the request body is a JSON object with secretKey and status.
+func (adminAPI adminAPIHandlers) AddUserHandler(w http.ResponseWriter, r *http.Request) {
+	objectAPI, adminAPIErr := validateIAMAdminRequest(r)
+	if adminAPIErr != ErrNone {
+		writeErrorResponse(w, adminAPIErr, r.URL)
+		return
+	}
+
+	var req addUserReq
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxIAMRequestSize)).Decode(&req); err != nil {
+		writeErrorResponse(w, ErrAdminInvalidArgument, r.URL)
+		return
+	}
+
+	accessKey := r.URL.Query().Get("accessKey")
+	if err := globalIAMSys.SetUser(objectAPI, accessKey, req.SecretKey, req.Status); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+	writeSuccessResponseHeadersOnly(w)
+}
+
+// RemoveUserHandler - POST /?iam&accessKey=<accessKey>
+// - x-minio-operation = remove-user
+// ----------
+// Removes a user along with its group memberships.
+func (adminAPI adminAPIHandlers) RemoveUserHandler(w http.ResponseWriter, r *http.Request) {
+	objectAPI, adminAPIErr := validateIAMAdminRequest(r)
+	if adminAPIErr != ErrNone {
+		writeErrorResponse(w, adminAPIErr, r.URL)
+		return
+	}
+
+	if err := globalIAMSys.RemoveUser(objectAPI, r.URL.Query().Get("accessKey")); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+	writeSuccessResponseHeadersOnly(w)
+}
+
+// SetUserStatusHandler - POST /?iam&accessKey=<accessKey>&status=<status>
+// - x-minio-operation = set-user-status
+// ----------
+// Enables or disables a user, disabled users can not sign requests.
+func (adminAPI adminAPIHandlers) SetUserStatusHandler(w http.ResponseWriter, r *http.Request) {
+	objectAPI, adminAPIErr := validateIAMAdminRequest(r)
+	if adminAPIErr != ErrNone {
+		writeErrorResponse(w, adminAPIErr, r.URL)
+		return
+	}
+
+	query := r.URL.Query()
+	if err := globalIAMSys.SetUserStatus(objectAPI, query.Get("accessKey"), query.Get("status")); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+	writeSuccessResponseHeadersOnly(w)
+}
+
+// ListUsersHandler - GET /?iam
+// - x-minio-operation = list-users
+// ----------
+// Lists all users with their status, policy and groups.
+func (adminAPI adminAPIHandlers) ListUsersHandler(w http.ResponseWriter, r *http.Request) {
+	if _, adminAPIErr := validateIAMAdminRequest(r); adminAPIErr != ErrNone {
+		writeErrorResponse(w, adminAPIErr, r.URL)
+		return
+	}
+	writeIAMResponseJSON(w, r, globalIAMSys.ListUsers())
+}
+
+// AddPolicyHandler - PUT /?iam&policyName=<name>
+// - x-minio-operation = add-policy
+// ----------
+// Adds or replaces a custom policy, the request body is the policy.
+func (adminAPI adminAPIHandlers) AddPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	objectAPI, adminAPIErr := validateIAMAdminRequest(r)
+	if adminAPIErr != ErrNone {
+		writeErrorResponse(w, adminAPIErr, r.URL)
+		return
+	}
+
+	// Error out if Content-Length is beyond allowed size.
+	if r.ContentLength > maxIAMRequestSize {
+		writeErrorResponse(w, ErrEntityTooLarge, r.URL)
+		return
+	}
+
+	policy, err := parseIAMPolicy(io.LimitReader(r.Body, maxIAMRequestSize))
+	if err != nil {
+		errorIf(err, "Unable to parse IAM policy.")
+		writeErrorResponse(w, ErrMalformedPolicy, r.URL)
+		return
+	}
+
+	if err = globalIAMSys.SetPolicy(objectAPI, r.URL.Query().Get("policyName"), policy); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+	writeSuccessResponseHeadersOnly(w)
+}
+
+// RemovePolicyHandler - POST /?iam&policyName=<name>
+// - x-minio-operation = remove-policy
+// ----------
+// Removes a custom policy and detaches it from all users and groups.
+func (adminAPI adminAPIHandlers) RemovePolicyHandler(w http.ResponseWriter, r *http.Request) {
+	objectAPI, adminAPIErr := validateIAMAdminRequest(r)
+	if adminAPIErr != ErrNone {
+		writeErrorResponse(w, adminAPIErr, r.URL)
+		return
+	}
+
+	if err := globalIAMSys.RemovePolicy(objectAPI, r.URL.Query().Get("policyName")); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+	writeSuccessResponseHeadersOnly(w)
+}
+
+// ListPoliciesHandler - GET /?iam
+// - x-minio-operation = list-policies
+// ----------
+// Lists all canned and custom policies.
+func (adminAPI adminAPIHandlers) ListPoliciesHandler(w http.ResponseWriter, r *http.Request) {
+	if _, adminAPIErr := validateIAMAdminRequest(r); adminAPIErr != ErrNone {
+		writeErrorResponse(w, adminAPIErr, r.URL)
+		return
+	}
+	writeIAMResponseJSON(w, r, globalIAMSys.ListPolicies())
+}
+
+// SetUserPolicyHandler - POST /?iam&accessKey=<accessKey>&policyName=<name>
+// - x-minio-operation = set-user-policy
+// ----------
+// Attaches the policy to the user, an empty policy name detaches
+// the current policy of the user.
+func (adminAPI adminAPIHandlers) SetUserPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	objectAPI, adminAPIErr := validateIAMAdminRequest(r)
+	if adminAPIErr != ErrNone {
+		writeErrorResponse(w, adminAPIErr, r.URL)
+		return
+	}
+
+	query := r.URL.Query()
+	if err := globalIAMSys.SetUserPolicy(objectAPI, query.Get("accessKey"), query.Get("policyName")); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+	writeSuccessResponseHeadersOnly(w)
+}
+
+// SetGroupPolicyHandler - POST /?iam&group=<group>&policyName=<name>
+// - x-minio-operation = set-group-policy
+// ----------
+// Attaches the policy to the group, an empty policy name detaches
+// the current policy of the group.
+func (adminAPI adminAPIHandlers) SetGroupPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	objectAPI, adminAPIErr := validateIAMAdminRequest(r)
+	if adminAPIErr != ErrNone {
+		writeErrorResponse(w, adminAPIErr, r.URL)
+		return
+	}
+
+	query := r.URL.Query()
+	if err := globalIAMSys.SetGroupPolicy(objectAPI, query.Get("group"), query.Get("policyName")); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+	writeSuccessResponseHeadersOnly(w)
+}
+
+// UpdateGroupMembersHandler - PUT /?iam&group=<group>
+// - x-minio-operation = update-group-members
+// ----------
+// Adds users to a group or removes them from it, the request body is
+// a JSON object with members and isRemove. Groups are created with
+// their first member and removed with their last member.
+func (adminAPI adminAPIHandlers) UpdateGroupMembersHandler(w http.ResponseWriter, r *http.Request) {
+	objectAPI, adminAPIErr := validateIAMAdminRequest(r)
+	if adminAPIErr != ErrNone {
+		writeErrorResponse(w, adminAPIErr, r.URL)
+		return
+	}
+
+	var req updateGroupMembersReq
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxIAMRequestSize)).Decode(&req); err != nil {
+		writeErrorResponse(w, ErrAdminInvalidArgument, r.URL)
+		return
+	}
+
+	err := globalIAMSys.UpdateGroupMembers(objectAPI, r.URL.Query().Get("group"), req.Members, req.IsRemove)
+	if err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+	writeSuccessResponseHeadersOnly(w)
+}
+
+// ListGroupsHandler - GET /?iam
+// - x-minio-operation = list-groups
+// ----------
+// Lists all groups with their members and policy.
+func (adminAPI adminAPIHandlers) ListGroupsHandler(w http.ResponseWriter, r *http.Request) {
+	if _, adminAPIErr := validateIAMAdminRequest(r); adminAPIErr != ErrNone {
+		writeErrorResponse(w, adminAPIErr, r.URL)
+		return
+	}
+	writeIAMResponseJSON(w, r, globalIAMSys.ListGroups())
+}
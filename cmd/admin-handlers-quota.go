@@ -0,0 +1,118 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+)
+
+// validateQuotaAdminRequest - verifies that the object layer is
+// initialized, that the request is signed with the server credential
+// and that the bucket of the request exists.
+func validateQuotaAdminRequest(r *http.Request) (ObjectLayer, string, APIErrorCode) {
+	objectAPI := newObjectLayerFn()
+	if objectAPI == nil || globalBucketQuota == nil {
+		return nil, "", ErrServerNotInitialized
+	}
+
+	// Validate request signature.
+	if adminAPIErr := checkRequestAuthType(r, "", "", ""); adminAPIErr != ErrNone {
+		return nil, "", adminAPIErr
+	}
+
+	bucket := r.URL.Query().Get("bucket")
+	if _, err := objectAPI.GetBucketInfo(bucket); err != nil {
+		return nil, "", toAPIErrorCode(err)
+	}
+	return objectAPI, bucket, ErrNone
+}
+
+// SetBucketQuotaHandler - PUT /?quota&bucket=<bucket>
+// - x-minio-operation = set-bucket-quota
+// ----------
+// Sets the quota of a bucket, the request body is a JSON object with
+// quota in bytes, maxObjects and quotatype either hard or fifo.
+func (adminAPI adminAPIHandlers) SetBucketQuotaHandler(w http.ResponseWriter, r *http.Request) {
+	objectAPI, bucket, adminAPIErr := validateQuotaAdminRequest(r)
+	if adminAPIErr != ErrNone {
+		writeErrorResponse(w, adminAPIErr, r.URL)
+		return
+	}
+
+	qcfg, err := parseBucketQuota(io.LimitReader(r.Body, maxBucketQuotaSize))
+	if err != nil {
+		errorIf(err, "Unable to parse bucket quota.")
+		writeErrorResponse(w, ErrAdminInvalidArgument, r.URL)
+		return
+	}
+
+	if err = persistAndNotifyBucketQuota(bucket, qcfg, objectAPI); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+	writeSuccessResponseHeadersOnly(w)
+}
+
+// GetBucketQuotaHandler - GET /?quota&bucket=<bucket>
+// - x-minio-operation = get-bucket-quota
+// ----------
+// Returns the quota of a bucket along with its current usage as seen
+// by this server.
+func (adminAPI adminAPIHandlers) GetBucketQuotaHandler(w http.ResponseWriter, r *http.Request) {
+	_, bucket, adminAPIErr := validateQuotaAdminRequest(r)
+	if adminAPIErr != ErrNone {
+		writeErrorResponse(w, adminAPIErr, r.URL)
+		return
+	}
+
+	qcfg := globalBucketQuota.GetBucketQuota(bucket)
+	if qcfg == nil {
+		writeErrorResponse(w, ErrAdminNoSuchQuotaConfiguration, r.URL)
+		return
+	}
+
+	jsonBytes, err := json.Marshal(bucketQuotaInfo{
+		bucketQuotaConfiguration: *qcfg,
+		Usage:                    globalBucketQuota.GetBucketUsage(bucket),
+	})
+	if err != nil {
+		errorIf(err, "Failed to marshal bucket quota into json.")
+		writeErrorResponse(w, ErrInternalError, r.URL)
+		return
+	}
+	writeSuccessResponseJSON(w, jsonBytes)
+}
+
+// RemoveBucketQuotaHandler - POST /?quota&bucket=<bucket>
+// - x-minio-operation = remove-bucket-quota
+// ----------
+// Removes the quota of a bucket.
+func (adminAPI adminAPIHandlers) RemoveBucketQuotaHandler(w http.ResponseWriter, r *http.Request) {
+	objectAPI, bucket, adminAPIErr := validateQuotaAdminRequest(r)
+	if adminAPIErr != ErrNone {
+		writeErrorResponse(w, adminAPIErr, r.URL)
+		return
+	}
+
+	if err := persistAndNotifyBucketQuota(bucket, nil, objectAPI); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+	writeSuccessResponseHeadersOnly(w)
+}
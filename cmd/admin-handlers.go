@@ -28,6 +28,8 @@ import (
 	"strconv"
 	"sync"
 	"time"
+
+	"github.com/Sirupsen/logrus"
 )
 
 const (
@@ -52,6 +54,10 @@ const (
 	mgmtUploadIDMarker mgmtQueryKey = "upload-id-marker"
 	mgmtMaxUploads     mgmtQueryKey = "max-uploads"
 	mgmtUploadID       mgmtQueryKey = "upload-id"
+	mgmtNode           mgmtQueryKey = "node"
+	mgmtLogLevel       mgmtQueryKey = "level"
+	mgmtLogLimit       mgmtQueryKey = "limit"
+	mgmtHealToken      mgmtQueryKey = "token"
 )
 
 // ServerVersion - server version
@@ -299,6 +305,250 @@ func (adminAPI adminAPIHandlers) ServerInfoHandler(w http.ResponseWriter, r *htt
 	writeSuccessResponseJSON(w, jsonBytes)
 }
 
+// DataUsageInfoHandler - GET /?datausage
+// ----------
+// Get the data usage of all buckets, as saved by the last crawl of the
+// background data usage crawler.
+func (adminAPI adminAPIHandlers) DataUsageInfoHandler(w http.ResponseWriter, r *http.Request) {
+	objectAPI := newObjectLayerFn()
+	if objectAPI == nil {
+		writeErrorResponse(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	// Authenticate request
+	adminAPIErr := checkRequestAuthType(r, "", "", "")
+	if adminAPIErr != ErrNone {
+		writeErrorResponse(w, adminAPIErr, r.URL)
+		return
+	}
+
+	dataUsage, err := readDataUsageInfo(objectAPI)
+	if err != nil {
+		errorIf(err, "Unable to read data usage.")
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	// Marshal API response
+	jsonBytes, err := json.Marshal(dataUsage)
+	if err != nil {
+		writeErrorResponse(w, ErrInternalError, r.URL)
+		errorIf(err, "Failed to marshal data usage into json.")
+		return
+	}
+
+	writeSuccessResponseJSON(w, jsonBytes)
+}
+
+// ScrubStatsHandler - GET /?scrub
+// ----------
+// Get the progress of the running scrub cycle, or the stats of the
+// last cycle, as saved by the background scrubber.
+func (adminAPI adminAPIHandlers) ScrubStatsHandler(w http.ResponseWriter, r *http.Request) {
+	objectAPI := newObjectLayerFn()
+	if objectAPI == nil {
+		writeErrorResponse(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	// Authenticate request
+	adminAPIErr := checkRequestAuthType(r, "", "", "")
+	if adminAPIErr != ErrNone {
+		writeErrorResponse(w, adminAPIErr, r.URL)
+		return
+	}
+
+	// Objects are only scrubbed in single node XL and distributed
+	// XL setup.
+	if !globalIsXL {
+		writeErrorResponse(w, ErrNotImplemented, r.URL)
+		return
+	}
+
+	stats, err := readScrubStats(objectAPI)
+	if err != nil {
+		errorIf(err, "Unable to read scrub stats.")
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	// Marshal API response
+	jsonBytes, err := json.Marshal(stats)
+	if err != nil {
+		writeErrorResponse(w, ErrInternalError, r.URL)
+		errorIf(err, "Failed to marshal scrub stats into json.")
+		return
+	}
+
+	writeSuccessResponseJSON(w, jsonBytes)
+}
+
+// TraceHandler - GET /?trace[&body=true]
+// ----------
+// Streams the API calls handled by all servers as JSON, one call per
+// line, until the client disconnects. The request and response bodies
+// of S3 calls are included when body is true.
+func (adminAPI adminAPIHandlers) TraceHandler(w http.ResponseWriter, r *http.Request) {
+	// Authenticate request
+	adminAPIErr := checkRequestAuthType(r, "", "", "")
+	if adminAPIErr != ErrNone {
+		writeErrorResponse(w, adminAPIErr, r.URL)
+		return
+	}
+
+	withBody := r.URL.Query().Get("body") == "true"
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+	traceCh := tracePeers(globalAdminPeers, withBody, doneCh)
+
+	// Add all common headers.
+	setCommonHeaders(w)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.(http.Flusher).Flush()
+
+	enc := json.NewEncoder(w)
+	for {
+		select {
+		case trace, ok := <-traceCh:
+			if !ok {
+				return
+			}
+			if err := enc.Encode(trace); err != nil {
+				return
+			}
+		case <-time.After(globalSNSConnAlive):
+			// Write whitespace to keep the connection active,
+			// this also detects clients which disconnected.
+			if _, err := w.Write([]byte(" ")); err != nil {
+				return
+			}
+		}
+		w.(http.Flusher).Flush()
+	}
+}
+
+// validateLogsQueryParams - Validates query params for get/stream logs
+// management APIs, returns the peers selected by node and the minimum
+// level of the log entries.
+func validateLogsQueryParams(vars url.Values) (adminPeers, logrus.Level, APIErrorCode) {
+	peers := globalAdminPeers
+	if node := vars.Get(string(mgmtNode)); node != "" {
+		peers = nil
+		for _, peer := range globalAdminPeers {
+			if peer.addr == node {
+				peers = append(peers, peer)
+			}
+		}
+		if len(peers) == 0 {
+			return nil, 0, ErrAdminInvalidArgument
+		}
+	}
+
+	// All entries are returned by default.
+	level := logrus.DebugLevel
+	if levelStr := vars.Get(string(mgmtLogLevel)); levelStr != "" {
+		var err error
+		if level, err = logrus.ParseLevel(levelStr); err != nil {
+			return nil, 0, ErrAdminInvalidArgument
+		}
+	}
+
+	return peers, level, ErrNone
+}
+
+// GetLogsHandler - GET /?logs[&node=host:port][&level=error][&limit=100]
+// - node, level and limit are optional query parameters
+// HTTP header x-minio-operation: get
+// ---------
+// Returns the recent log entries kept in memory by every server, or
+// by the given node. Only entries at or above level are returned, up
+// to limit most recent entries per server.
+func (adminAPI adminAPIHandlers) GetLogsHandler(w http.ResponseWriter, r *http.Request) {
+	adminAPIErr := checkRequestAuthType(r, "", "", "")
+	if adminAPIErr != ErrNone {
+		writeErrorResponse(w, adminAPIErr, r.URL)
+		return
+	}
+
+	vars := r.URL.Query()
+	peers, level, adminAPIErr := validateLogsQueryParams(vars)
+	if adminAPIErr != ErrNone {
+		writeErrorResponse(w, adminAPIErr, r.URL)
+		return
+	}
+	var limit int
+	if limitStr := vars.Get(string(mgmtLogLimit)); limitStr != "" {
+		var err error
+		if limit, err = strconv.Atoi(limitStr); err != nil || limit < 0 {
+			writeErrorResponse(w, ErrAdminInvalidArgument, r.URL)
+			return
+		}
+	}
+
+	// Marshal API response
+	jsonBytes, err := json.Marshal(getPeerLogs(peers, level, limit))
+	if err != nil {
+		writeErrorResponse(w, ErrInternalError, r.URL)
+		errorIf(err, "Failed to marshal logs into json.")
+		return
+	}
+
+	writeSuccessResponseJSON(w, jsonBytes)
+}
+
+// StreamLogsHandler - GET /?logs[&node=host:port][&level=error]
+// - node and level are optional query parameters
+// HTTP header x-minio-operation: stream
+// ---------
+// Streams the log entries added on every server, or on the given
+// node, as JSON, one entry per line, until the client disconnects.
+// Only entries at or above level are streamed.
+func (adminAPI adminAPIHandlers) StreamLogsHandler(w http.ResponseWriter, r *http.Request) {
+	adminAPIErr := checkRequestAuthType(r, "", "", "")
+	if adminAPIErr != ErrNone {
+		writeErrorResponse(w, adminAPIErr, r.URL)
+		return
+	}
+
+	peers, level, adminAPIErr := validateLogsQueryParams(r.URL.Query())
+	if adminAPIErr != ErrNone {
+		writeErrorResponse(w, adminAPIErr, r.URL)
+		return
+	}
+
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+	logCh := streamPeerLogs(peers, level, doneCh)
+
+	// Add all common headers.
+	setCommonHeaders(w)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.(http.Flusher).Flush()
+
+	enc := json.NewEncoder(w)
+	for {
+		select {
+		case entry, ok := <-logCh:
+			if !ok {
+				return
+			}
+			if err := enc.Encode(entry); err != nil {
+				return
+			}
+		case <-time.After(globalSNSConnAlive):
+			// Write whitespace to keep the connection active,
+			// this also detects clients which disconnected.
+			if _, err := w.Write([]byte(" ")); err != nil {
+				return
+			}
+		}
+		w.(http.Flusher).Flush()
+	}
+}
+
 // validateLockQueryParams - Validates query params for list/clear locks management APIs.
 func validateLockQueryParams(vars url.Values) (string, string, time.Duration, APIErrorCode) {
 	bucket := vars.Get(string(mgmtBucket))
@@ -833,7 +1083,7 @@ func (adminAPI adminAPIHandlers) HealFormatHandler(w http.ResponseWriter, r *htt
 	}
 
 	// Heal format.json on available storage.
-	err = healFormatXL(bootstrapDisks)
+	err = healFormatXL(globalEndpoints, bootstrapDisks)
 	if err != nil {
 		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
 		return
@@ -861,6 +1111,192 @@ func (adminAPI adminAPIHandlers) HealFormatHandler(w http.ResponseWriter, r *htt
 	writeSuccessResponseHeadersOnly(w)
 }
 
+// writeHealSequenceState - writes the state of a heal sequence as
+// JSON response.
+func writeHealSequenceState(w http.ResponseWriter, r *http.Request, state HealSequenceState) {
+	jsonBytes, err := json.Marshal(state)
+	if err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	// Return 200 on success.
+	writeSuccessResponseJSON(w, jsonBytes)
+}
+
+// StartHealSequenceHandler - POST /?heal&bucket=mybucket&prefix=myprefix&dry-run
+// - x-minio-operation = start-sequence
+// - bucket and prefix are optional query parameters, a prefix requires a bucket
+// Starts healing all objects under the prefix of the bucket, or all
+// buckets, in background on this server. The response carries the
+// token of the heal sequence.
+func (adminAPI adminAPIHandlers) StartHealSequenceHandler(w http.ResponseWriter, r *http.Request) {
+	// Get object layer instance.
+	objLayer := newObjectLayerFn()
+	if objLayer == nil {
+		writeErrorResponse(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	// Validate request signature.
+	adminAPIErr := checkRequestAuthType(r, "", "", "")
+	if adminAPIErr != ErrNone {
+		writeErrorResponse(w, adminAPIErr, r.URL)
+		return
+	}
+
+	// Heal sequences are only applicable to single node XL and
+	// distributed XL setup.
+	if !globalIsXL {
+		writeErrorResponse(w, ErrNotImplemented, r.URL)
+		return
+	}
+
+	vars := r.URL.Query()
+	bucket := vars.Get(string(mgmtBucket))
+	prefix := vars.Get(string(mgmtPrefix))
+	if bucket == "" && prefix != "" {
+		writeErrorResponse(w, ErrAdminInvalidArgument, r.URL)
+		return
+	}
+	if bucket != "" {
+		if err := checkBucketExist(bucket, objLayer); err != nil {
+			writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+			return
+		}
+	}
+	if !IsValidObjectPrefix(prefix) {
+		writeErrorResponse(w, ErrInvalidObjectName, r.URL)
+		return
+	}
+
+	// if dry-run is present in query-params, then only perform validations and return success.
+	if isDryRun(vars) {
+		writeSuccessResponseHeadersOnly(w)
+		return
+	}
+
+	state, err := startHealSequence(objLayer, newHealSequenceState(bucket, prefix))
+	if err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+	writeHealSequenceState(w, r, state)
+}
+
+// getHealSequenceState - returns the state of the heal sequence with
+// the given token, stopping it first with stop. Sequences which don't
+// run on any server are stopped by saving them as stopped, so that
+// they are not resumed.
+func getHealSequenceState(objLayer ObjectLayer, token string, stop bool) (HealSequenceState, error) {
+	if state, found := findPeerHealSequence(globalAdminPeers, token, stop); found {
+		return state, nil
+	}
+
+	state, err := readHealSequenceState(objLayer, token)
+	if err != nil {
+		return state, err
+	}
+	if stop && state.Status == healSequenceRunning {
+		state.Status = healSequenceStopped
+		state.LastUpdate = UTCNow()
+		if err = saveHealSequenceState(objLayer, state); err != nil {
+			return state, err
+		}
+	}
+	return state, nil
+}
+
+// healSequenceStateHandler - writes the state of the heal sequence
+// whose token is given as query parameter, stopping it first with stop.
+func healSequenceStateHandler(w http.ResponseWriter, r *http.Request, stop bool) {
+	// Get object layer instance.
+	objLayer := newObjectLayerFn()
+	if objLayer == nil {
+		writeErrorResponse(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	// Validate request signature.
+	adminAPIErr := checkRequestAuthType(r, "", "", "")
+	if adminAPIErr != ErrNone {
+		writeErrorResponse(w, adminAPIErr, r.URL)
+		return
+	}
+
+	token := r.URL.Query().Get(string(mgmtHealToken))
+	if !isValidHealSequenceToken(token) {
+		writeErrorResponse(w, ErrAdminInvalidArgument, r.URL)
+		return
+	}
+
+	state, err := getHealSequenceState(objLayer, token, stop)
+	if err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+	writeHealSequenceState(w, r, state)
+}
+
+// HealSequenceStatusHandler - GET /?heal&token=mytoken
+// - x-minio-operation = sequence-status
+// - token is mandatory query parameter
+// Returns the progress of a heal sequence, running on any server.
+func (adminAPI adminAPIHandlers) HealSequenceStatusHandler(w http.ResponseWriter, r *http.Request) {
+	healSequenceStateHandler(w, r, false)
+}
+
+// StopHealSequenceHandler - POST /?heal&token=mytoken
+// - x-minio-operation = stop-sequence
+// - token is mandatory query parameter
+// Stops a heal sequence and returns its final progress. Stopped
+// sequences are not resumed.
+func (adminAPI adminAPIHandlers) StopHealSequenceHandler(w http.ResponseWriter, r *http.Request) {
+	healSequenceStateHandler(w, r, true)
+}
+
+// HealingDisksHandler - GET /?heal
+// - x-minio-operation = disks
+// Returns the freshly formatted disks being healed in background, with
+// the progress of the heal sequences healing them.
+func (adminAPI adminAPIHandlers) HealingDisksHandler(w http.ResponseWriter, r *http.Request) {
+	// Get object layer instance.
+	objLayer := newObjectLayerFn()
+	if objLayer == nil {
+		writeErrorResponse(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	// Validate request signature.
+	adminAPIErr := checkRequestAuthType(r, "", "", "")
+	if adminAPIErr != ErrNone {
+		writeErrorResponse(w, adminAPIErr, r.URL)
+		return
+	}
+
+	// Disks are only healed in single node XL and distributed XL
+	// setup.
+	if !globalIsXL {
+		writeErrorResponse(w, ErrNotImplemented, r.URL)
+		return
+	}
+
+	healingDisks, err := getHealingDisks(objLayer, globalEndpoints)
+	if err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	jsonBytes, err := json.Marshal(healingDisks)
+	if err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	// Return 200 on success.
+	writeSuccessResponseJSON(w, jsonBytes)
+}
+
 // GetConfigHandler - GET /?config
 // - x-minio-operation = get
 // Get config.json of this minio setup.
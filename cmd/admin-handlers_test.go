@@ -1624,3 +1624,105 @@ func TestNewHealResult(t *testing.T) {
 		}
 	}
 }
+
+// TestIAMHandlers - test for IAM management REST APIs.
+func TestIAMHandlers(t *testing.T) {
+	adminTestBed, err := prepareAdminXLTestBed()
+	if err != nil {
+		t.Fatal("Failed to initialize a single node XL backend for admin handler tests.")
+	}
+	defer adminTestBed.TearDown()
+
+	// IAM changes are loaded by all peers, here only the local node.
+	defer func(peers s3Peers) { globalS3Peers = peers }(globalS3Peers)
+	globalS3Peers = makeS3Peers(EndpointList{})
+
+	mkIAMQuery := func(params ...string) url.Values {
+		queryVal := url.Values{}
+		queryVal.Set("iam", "")
+		for i := 0; i+1 < len(params); i += 2 {
+			queryVal.Set(params[i], params[i+1])
+		}
+		return queryVal
+	}
+
+	testCases := []struct {
+		queryVal           url.Values
+		operation          string
+		method             string
+		body               string
+		expectedStatusCode int
+	}{
+		// Add a user.
+		{mkIAMQuery("accessKey", "reader"), "add-user", http.MethodPut,
+			`{"secretKey":"reader123","status":"enabled"}`, http.StatusOK},
+		// Secret key too short.
+		{mkIAMQuery("accessKey", "writer"), "add-user", http.MethodPut,
+			`{"secretKey":"short","status":"enabled"}`, http.StatusBadRequest},
+		// Malformed body.
+		{mkIAMQuery("accessKey", "writer"), "add-user", http.MethodPut, `{`, http.StatusBadRequest},
+		// Add a custom policy.
+		{mkIAMQuery("policyName", "uploads"), "add-policy", http.MethodPut,
+			`{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":["s3:PutObject"],"Resource":["arn:aws:s3:::uploads/*"]}]}`,
+			http.StatusOK},
+		// Canned policies can not be replaced.
+		{mkIAMQuery("policyName", "readonly"), "add-policy", http.MethodPut,
+			`{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":["s3:PutObject"],"Resource":["arn:aws:s3:::*"]}]}`,
+			http.StatusBadRequest},
+		// Malformed policy.
+		{mkIAMQuery("policyName", "broken"), "add-policy", http.MethodPut, `{}`, http.StatusBadRequest},
+		// Attach policies.
+		{mkIAMQuery("accessKey", "reader", "policyName", "readonly"), "set-user-policy", http.MethodPost, "", http.StatusOK},
+		{mkIAMQuery("accessKey", "unknown", "policyName", "readonly"), "set-user-policy", http.MethodPost, "", http.StatusNotFound},
+		{mkIAMQuery("accessKey", "reader", "policyName", "unknown"), "set-user-policy", http.MethodPost, "", http.StatusNotFound},
+		// Groups.
+		{mkIAMQuery("group", "uploaders"), "update-group-members", http.MethodPut,
+			`{"members":["reader"],"isRemove":false}`, http.StatusOK},
+		{mkIAMQuery("group", "uploaders"), "update-group-members", http.MethodPut,
+			`{"members":["unknown"],"isRemove":false}`, http.StatusNotFound},
+		{mkIAMQuery("group", "uploaders", "policyName", "uploads"), "set-group-policy", http.MethodPost, "", http.StatusOK},
+		{mkIAMQuery("group", "unknown", "policyName", "uploads"), "set-group-policy", http.MethodPost, "", http.StatusNotFound},
+		// User status.
+		{mkIAMQuery("accessKey", "reader", "status", "disabled"), "set-user-status", http.MethodPost, "", http.StatusOK},
+		{mkIAMQuery("accessKey", "reader", "status", "unknown"), "set-user-status", http.MethodPost, "", http.StatusBadRequest},
+		// Listing.
+		{mkIAMQuery(), "list-users", http.MethodGet, "", http.StatusOK},
+		{mkIAMQuery(), "list-groups", http.MethodGet, "", http.StatusOK},
+		{mkIAMQuery(), "list-policies", http.MethodGet, "", http.StatusOK},
+	}
+
+	for i, testCase := range testCases {
+		body := []byte(testCase.body)
+		req, err := buildAdminRequest(testCase.queryVal, testCase.operation, testCase.method,
+			int64(len(body)), bytes.NewReader(body))
+		if err != nil {
+			t.Fatalf("Test %d: Failed to construct %s request - %v", i+1, testCase.operation, err)
+		}
+		rec := httptest.NewRecorder()
+		adminTestBed.mux.ServeHTTP(rec, req)
+		if rec.Code != testCase.expectedStatusCode {
+			t.Errorf("Test %d: Expected status %d, got %d: %s", i+1, testCase.expectedStatusCode, rec.Code, rec.Body.String())
+		}
+	}
+
+	expectedUsers := map[string]iamUserInfo{
+		"reader": {Status: iamUserDisabled, Policy: "readonly", MemberOf: []string{"uploaders"}},
+	}
+	if users := globalIAMSys.ListUsers(); !reflect.DeepEqual(users, expectedUsers) {
+		t.Errorf("Expected users %v, got %v", expectedUsers, users)
+	}
+
+	// Removing a policy detaches it.
+	req, err := buildAdminRequest(mkIAMQuery("policyName", "uploads"), "remove-policy", http.MethodPost, 0, nil)
+	if err != nil {
+		t.Fatalf("Failed to construct remove-policy request - %v", err)
+	}
+	rec := httptest.NewRecorder()
+	adminTestBed.mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected to succeed but failed with %d", rec.Code)
+	}
+	if groups := globalIAMSys.ListGroups(); groups["uploaders"].Policy != "" {
+		t.Errorf("Expected policy to be detached, got %s", groups["uploaders"].Policy)
+	}
+}
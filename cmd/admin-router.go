@@ -41,6 +41,19 @@ func registerAdminRouter(mux *router.Router) {
 
 	// Info operations
 	adminRouter.Methods("GET").Queries("info", "").HandlerFunc(adminAPI.ServerInfoHandler)
+	// Data usage of all buckets
+	adminRouter.Methods("GET").Queries("datausage", "").HandlerFunc(adminAPI.DataUsageInfoHandler)
+	// Stats of the background scrubber
+	adminRouter.Methods("GET").Queries("scrub", "").HandlerFunc(adminAPI.ScrubStatsHandler)
+	// Stream the API calls handled by all servers
+	adminRouter.Methods("GET").Queries("trace", "").HandlerFunc(adminAPI.TraceHandler)
+
+	/// Log operations
+
+	// Get recent logs
+	adminRouter.Methods("GET").Queries("logs", "").Headers(minioAdminOpHeader, "get").HandlerFunc(adminAPI.GetLogsHandler)
+	// Stream logs
+	adminRouter.Methods("GET").Queries("logs", "").Headers(minioAdminOpHeader, "stream").HandlerFunc(adminAPI.StreamLogsHandler)
 
 	/// Lock operations
 
@@ -67,10 +80,53 @@ func registerAdminRouter(mux *router.Router) {
 	// Heal Uploads.
 	adminRouter.Methods("POST").Queries("heal", "").Headers(minioAdminOpHeader, "upload").HandlerFunc(adminAPI.HealUploadHandler)
 
+	// Start heal sequence.
+	adminRouter.Methods("POST").Queries("heal", "").Headers(minioAdminOpHeader, "start-sequence").HandlerFunc(adminAPI.StartHealSequenceHandler)
+	// Get heal sequence status.
+	adminRouter.Methods("GET").Queries("heal", "").Headers(minioAdminOpHeader, "sequence-status").HandlerFunc(adminAPI.HealSequenceStatusHandler)
+	// Stop heal sequence.
+	adminRouter.Methods("POST").Queries("heal", "").Headers(minioAdminOpHeader, "stop-sequence").HandlerFunc(adminAPI.StopHealSequenceHandler)
+	// List disks being healed.
+	adminRouter.Methods("GET").Queries("heal", "").Headers(minioAdminOpHeader, "disks").HandlerFunc(adminAPI.HealingDisksHandler)
+
 	/// Config operations
 
 	// Get config
 	adminRouter.Methods("GET").Queries("config", "").Headers(minioAdminOpHeader, "get").HandlerFunc(adminAPI.GetConfigHandler)
 	// Set Config
 	adminRouter.Methods("PUT").Queries("config", "").Headers(minioAdminOpHeader, "set").HandlerFunc(adminAPI.SetConfigHandler)
+
+	/// IAM operations
+
+	// Add or update user
+	adminRouter.Methods("PUT").Queries("iam", "").Headers(minioAdminOpHeader, "add-user").HandlerFunc(adminAPI.AddUserHandler)
+	// Remove user
+	adminRouter.Methods("POST").Queries("iam", "").Headers(minioAdminOpHeader, "remove-user").HandlerFunc(adminAPI.RemoveUserHandler)
+	// Enable or disable user
+	adminRouter.Methods("POST").Queries("iam", "").Headers(minioAdminOpHeader, "set-user-status").HandlerFunc(adminAPI.SetUserStatusHandler)
+	// List users
+	adminRouter.Methods("GET").Queries("iam", "").Headers(minioAdminOpHeader, "list-users").HandlerFunc(adminAPI.ListUsersHandler)
+	// Add or replace policy
+	adminRouter.Methods("PUT").Queries("iam", "").Headers(minioAdminOpHeader, "add-policy").HandlerFunc(adminAPI.AddPolicyHandler)
+	// Remove policy
+	adminRouter.Methods("POST").Queries("iam", "").Headers(minioAdminOpHeader, "remove-policy").HandlerFunc(adminAPI.RemovePolicyHandler)
+	// List policies
+	adminRouter.Methods("GET").Queries("iam", "").Headers(minioAdminOpHeader, "list-policies").HandlerFunc(adminAPI.ListPoliciesHandler)
+	// Attach policy to user
+	adminRouter.Methods("POST").Queries("iam", "").Headers(minioAdminOpHeader, "set-user-policy").HandlerFunc(adminAPI.SetUserPolicyHandler)
+	// Attach policy to group
+	adminRouter.Methods("POST").Queries("iam", "").Headers(minioAdminOpHeader, "set-group-policy").HandlerFunc(adminAPI.SetGroupPolicyHandler)
+	// Add or remove group members
+	adminRouter.Methods("PUT").Queries("iam", "").Headers(minioAdminOpHeader, "update-group-members").HandlerFunc(adminAPI.UpdateGroupMembersHandler)
+	// List groups
+	adminRouter.Methods("GET").Queries("iam", "").Headers(minioAdminOpHeader, "list-groups").HandlerFunc(adminAPI.ListGroupsHandler)
+
+	/// Quota operations
+
+	// Set bucket quota
+	adminRouter.Methods("PUT").Queries("quota", "").Headers(minioAdminOpHeader, "set-bucket-quota").HandlerFunc(adminAPI.SetBucketQuotaHandler)
+	// Get bucket quota and usage
+	adminRouter.Methods("GET").Queries("quota", "").Headers(minioAdminOpHeader, "get-bucket-quota").HandlerFunc(adminAPI.GetBucketQuotaHandler)
+	// Remove bucket quota
+	adminRouter.Methods("POST").Queries("quota", "").Headers(minioAdminOpHeader, "remove-bucket-quota").HandlerFunc(adminAPI.RemoveBucketQuotaHandler)
 }
@@ -29,6 +29,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/Sirupsen/logrus"
 	"github.com/minio/minio-go/pkg/set"
 )
 
@@ -41,6 +42,9 @@ const (
 	getConfigRPC      = "Admin.GetConfig"
 	writeTmpConfigRPC = "Admin.WriteTmpConfig"
 	commitConfigRPC   = "Admin.CommitConfig"
+	traceRPC          = "Admin.Trace"
+	getLogsRPC        = "Admin.GetLogs"
+	healSequenceRPC   = "Admin.HealSequence"
 )
 
 // localAdminClient - represents admin operation to be executed locally.
@@ -63,6 +67,9 @@ type adminCmdRunner interface {
 	GetConfig() ([]byte, error)
 	WriteTmpConfig(tmpFileName string, configBytes []byte) error
 	CommitConfig(tmpFileName string) error
+	Trace(subscriptionID string, withBody bool) ([]TraceInfo, error)
+	GetLogs(since int64, level logrus.Level, wait bool) ([]LogEntry, uint64, error)
+	HealSequence(token string, stop bool) (HealSequenceState, bool, error)
 }
 
 // Restart - Sends a message over channel to the go-routine
@@ -225,6 +232,67 @@ func (rc remoteAdminClient) CommitConfig(tmpFileName string) error {
 	return nil
 }
 
+// Trace - returns the API calls handled by this server since the last
+// poll of the subscription.
+func (lc localAdminClient) Trace(subscriptionID string, withBody bool) ([]TraceInfo, error) {
+	return globalTraceSubscriptions.poll(subscriptionID, withBody), nil
+}
+
+// Trace - returns the API calls handled by the remote server since
+// the last poll of the subscription.
+func (rc remoteAdminClient) Trace(subscriptionID string, withBody bool) ([]TraceInfo, error) {
+	args := TraceArgs{
+		SubscriptionID: subscriptionID,
+		WithBody:       withBody,
+	}
+	reply := TraceReply{}
+	if err := rc.Call(traceRPC, &args, &reply); err != nil {
+		return nil, err
+	}
+	return reply.Traces, nil
+}
+
+// GetLogs - returns the recent log entries of this server.
+func (lc localAdminClient) GetLogs(since int64, level logrus.Level, wait bool) ([]LogEntry, uint64, error) {
+	entries, lastSeq := getLogs(since, level, wait)
+	return entries, lastSeq, nil
+}
+
+// GetLogs - returns the recent log entries of the remote server.
+func (rc remoteAdminClient) GetLogs(since int64, level logrus.Level, wait bool) ([]LogEntry, uint64, error) {
+	args := GetLogsArgs{
+		Since: since,
+		Level: level,
+		Wait:  wait,
+	}
+	reply := GetLogsReply{}
+	if err := rc.Call(getLogsRPC, &args, &reply); err != nil {
+		return nil, 0, err
+	}
+	return reply.Entries, reply.LastSeq, nil
+}
+
+// HealSequence - returns the state of a heal sequence running on this
+// server, stopping it first with stop.
+func (lc localAdminClient) HealSequence(token string, stop bool) (HealSequenceState, bool, error) {
+	state, found := getHealSequence(token, stop)
+	return state, found, nil
+}
+
+// HealSequence - returns the state of a heal sequence running on the
+// remote server, stopping it first with stop.
+func (rc remoteAdminClient) HealSequence(token string, stop bool) (HealSequenceState, bool, error) {
+	args := HealSequenceArgs{
+		Token: token,
+		Stop:  stop,
+	}
+	reply := HealSequenceReply{}
+	if err := rc.Call(healSequenceRPC, &args, &reply); err != nil {
+		return HealSequenceState{}, false, err
+	}
+	return reply.State, reply.Found, nil
+}
+
 // adminPeer - represents an entity that implements Restart methods.
 type adminPeer struct {
 	addr      string
@@ -623,3 +691,177 @@ func commitConfigPeers(peers adminPeers, tmpFileName string) []error {
 	// Return errors (if any) received during rename.
 	return errs
 }
+
+// tracePeers - streams the API calls handled by all peers until
+// doneCh is closed. Each peer is polled with the same subscription
+// ID, which keeps the traces published between two polls buffered on
+// the peer.
+func tracePeers(peers adminPeers, withBody bool, doneCh <-chan struct{}) <-chan TraceInfo {
+	traceCh := make(chan TraceInfo)
+	subscriptionID := mustGetUUID()
+
+	var wg sync.WaitGroup
+	for _, peer := range peers {
+		wg.Add(1)
+		go func(peer adminPeer) {
+			defer wg.Done()
+			var failed bool
+			for {
+				traces, err := peer.cmdRunner.Trace(subscriptionID, withBody)
+				if err != nil {
+					// Log only the first of consecutive failures
+					// and retry after the poll timeout.
+					if !failed {
+						errorIf(err, "Unable to get traces from %s.", peer.addr)
+					}
+					failed = true
+					select {
+					case <-doneCh:
+						return
+					case <-time.After(tracePollTimeout):
+					}
+					continue
+				}
+				failed = false
+				for _, trace := range traces {
+					trace.NodeName = peer.addr
+					select {
+					case traceCh <- trace:
+					case <-doneCh:
+						return
+					}
+				}
+				select {
+				case <-doneCh:
+					return
+				default:
+				}
+			}
+		}(peer)
+	}
+
+	go func() {
+		wg.Wait()
+		close(traceCh)
+	}()
+	return traceCh
+}
+
+// ServerLogs - the recent log entries of a server.
+type ServerLogs struct {
+	Error   string     `json:"error,omitempty"`
+	Addr    string     `json:"addr"`
+	Entries []LogEntry `json:"entries"`
+}
+
+// getPeerLogs - returns the buffered log entries at or above level of
+// all peers, up to limit most recent entries per peer when limit is
+// positive.
+func getPeerLogs(peers adminPeers, level logrus.Level, limit int) []ServerLogs {
+	serversLogs := make([]ServerLogs, len(peers))
+	var wg sync.WaitGroup
+	for i, peer := range peers {
+		wg.Add(1)
+		go func(idx int, peer adminPeer) {
+			defer wg.Done()
+			serversLogs[idx].Addr = peer.addr
+			entries, _, err := peer.cmdRunner.GetLogs(0, level, false)
+			if err != nil {
+				serversLogs[idx].Error = err.Error()
+				return
+			}
+			if limit > 0 && len(entries) > limit {
+				entries = entries[len(entries)-limit:]
+			}
+			for j := range entries {
+				entries[j].Node = peer.addr
+			}
+			serversLogs[idx].Entries = entries
+		}(i, peer)
+	}
+	wg.Wait()
+	return serversLogs
+}
+
+// streamPeerLogs - streams the log entries at or above level added on
+// all peers until doneCh is closed.
+func streamPeerLogs(peers adminPeers, level logrus.Level, doneCh <-chan struct{}) <-chan LogEntry {
+	logCh := make(chan LogEntry)
+
+	var wg sync.WaitGroup
+	for _, peer := range peers {
+		wg.Add(1)
+		go func(peer adminPeer) {
+			defer wg.Done()
+			// Start with the entries added from now on.
+			since := int64(-1)
+			var failed bool
+			for {
+				entries, lastSeq, err := peer.cmdRunner.GetLogs(since, level, true)
+				if err != nil {
+					// Log only the first of consecutive failures
+					// and retry after the poll timeout.
+					if !failed {
+						errorIf(err, "Unable to get logs from %s.", peer.addr)
+					}
+					failed = true
+					select {
+					case <-doneCh:
+						return
+					case <-time.After(logPollTimeout):
+					}
+					continue
+				}
+				failed = false
+				since = int64(lastSeq)
+				for _, entry := range entries {
+					entry.Node = peer.addr
+					select {
+					case logCh <- entry:
+					case <-doneCh:
+						return
+					}
+				}
+				select {
+				case <-doneCh:
+					return
+				default:
+				}
+			}
+		}(peer)
+	}
+
+	go func() {
+		wg.Wait()
+		close(logCh)
+	}()
+	return logCh
+}
+
+// findPeerHealSequence - returns the state of a heal sequence running
+// on any of the peers, stopping it first with stop. Sequences running
+// on unreachable peers are not found.
+func findPeerHealSequence(peers adminPeers, token string, stop bool) (HealSequenceState, bool) {
+	var mu sync.Mutex
+	var state HealSequenceState
+	var found bool
+	var wg sync.WaitGroup
+	for _, peer := range peers {
+		wg.Add(1)
+		go func(peer adminPeer) {
+			defer wg.Done()
+			peerState, peerFound, err := peer.cmdRunner.HealSequence(token, stop)
+			if err != nil {
+				errorIf(err, "Unable to get heal sequence %s from %s.", token, peer.addr)
+				return
+			}
+			if peerFound {
+				mu.Lock()
+				state, found = peerState, true
+				mu.Unlock()
+			}
+		}(peer)
+	}
+	wg.Wait()
+	return state, found
+}
@@ -25,6 +25,7 @@ import (
 	"path/filepath"
 	"time"
 
+	"github.com/Sirupsen/logrus"
 	router "github.com/gorilla/mux"
 )
 
@@ -231,6 +232,82 @@ func (s *adminCmd) CommitConfig(cArgs *CommitConfigArgs, cReply *CommitConfigRep
 	return err
 }
 
+// TraceArgs - wraps the subscription of a trace poll.
+type TraceArgs struct {
+	AuthRPCArgs
+	SubscriptionID string
+	WithBody       bool
+}
+
+// TraceReply - wraps the traces returned by a trace poll.
+type TraceReply struct {
+	AuthRPCReply
+	Traces []TraceInfo
+}
+
+// Trace - returns the API calls handled by this server since the
+// last poll of the subscription.
+func (s *adminCmd) Trace(args *TraceArgs, reply *TraceReply) error {
+	if err := args.IsAuthenticated(); err != nil {
+		return err
+	}
+
+	reply.Traces = globalTraceSubscriptions.poll(args.SubscriptionID, args.WithBody)
+	return nil
+}
+
+// GetLogsArgs - wraps the sequence number and level of log entries
+// to return.
+type GetLogsArgs struct {
+	AuthRPCArgs
+	Since int64
+	Level logrus.Level
+	Wait  bool
+}
+
+// GetLogsReply - wraps the log entries of a server.
+type GetLogsReply struct {
+	AuthRPCReply
+	Entries []LogEntry
+	LastSeq uint64
+}
+
+// GetLogs - returns the recent log entries of this server.
+func (s *adminCmd) GetLogs(args *GetLogsArgs, reply *GetLogsReply) error {
+	if err := args.IsAuthenticated(); err != nil {
+		return err
+	}
+
+	reply.Entries, reply.LastSeq = getLogs(args.Since, args.Level, args.Wait)
+	return nil
+}
+
+// HealSequenceArgs - wraps the token of a heal sequence.
+type HealSequenceArgs struct {
+	AuthRPCArgs
+	Token string
+	Stop  bool
+}
+
+// HealSequenceReply - wraps the state of a heal sequence, if it runs
+// on this server.
+type HealSequenceReply struct {
+	AuthRPCReply
+	State HealSequenceState
+	Found bool
+}
+
+// HealSequence - returns the state of a heal sequence running on this
+// server, stopping it first when asked to.
+func (s *adminCmd) HealSequence(args *HealSequenceArgs, reply *HealSequenceReply) error {
+	if err := args.IsAuthenticated(); err != nil {
+		return err
+	}
+
+	reply.State, reply.Found = getHealSequence(args.Token, args.Stop)
+	return nil
+}
+
 // registerAdminRPCRouter - registers RPC methods for service status,
 // stop and restart commands.
 func registerAdminRPCRouter(mux *router.Router) error {
@@ -116,6 +116,42 @@ const (
 	ErrInvalidDuration
 	ErrNotSupported
 	ErrBucketAlreadyExists
+	ErrNoSuchVersion
+	ErrInvalidVersionID
+	ErrIllegalVersioningConfiguration
+	ErrNoSuchLifecycleConfiguration
+	ErrNoSuchCORSConfiguration
+	ErrCORSNotAllowed
+	ErrNoSuchWebsiteConfiguration
+	ErrReplicationConfigurationNotFound
+	ErrInvalidTargetBucketForLogging
+	ErrInvalidTag
+	ErrNoSuchTagSet
+	ErrInvalidTaggingDirective
+	ErrInsecureSSECustomerRequest
+	ErrSSEMultipartEncrypted
+	ErrSSEEncryptedObject
+	ErrInvalidEncryptionParameters
+	ErrInvalidSSECustomerAlgorithm
+	ErrInvalidSSECustomerKey
+	ErrMissingSSECustomerKey
+	ErrMissingSSECustomerKeyMD5
+	ErrSSECustomerKeyMD5Mismatch
+	ErrInvalidSSECustomerParameters
+	ErrInvalidEncryptionMethod
+	ErrIncompatibleEncryptionMethod
+	ErrKMSNotConfigured
+	ErrNoSuchBucketEncryptionConfiguration
+	ErrObjectLocked
+	ErrNoSuchObjectLockConfiguration
+	ErrNoSuchObjectRetention
+	ErrObjectLockNotConfigured
+	ErrInvalidBucketState
+	ErrIncompleteRetention
+	ErrInvalidRetentionMode
+	ErrInvalidRetainUntilDate
+	ErrInvalidLegalHoldStatus
+	ErrInvalidStorageClass
 	// Add new error codes here.
 
 	// Bucket notification related errors.
@@ -131,6 +167,8 @@ const (
 
 	// S3 extended errors.
 	ErrContentSHA256Mismatch
+	ErrInvalidToken
+	ErrExpiredToken
 
 	// Add new extended error codes here.
 
@@ -143,6 +181,7 @@ const (
 	ErrInvalidObjectName
 	ErrInvalidResourceName
 	ErrServerNotInitialized
+	ErrBucketQuotaExceeded
 	// Add new extended error codes here.
 	// Please open a https://github.com/minio/minio/issues before adding
 	// new error codes here.
@@ -150,6 +189,13 @@ const (
 	ErrAdminInvalidAccessKey
 	ErrAdminInvalidSecretKey
 	ErrAdminConfigNoQuorum
+	ErrAdminNoSuchUser
+	ErrAdminNoSuchGroup
+	ErrAdminNoSuchPolicy
+	ErrAdminInvalidArgument
+	ErrAdminNoSuchQuotaConfiguration
+	ErrAdminNoSuchHealSequence
+	ErrAdminHealSequenceRunning
 	ErrInsecureClientRequest
 )
 
@@ -562,12 +608,203 @@ var errorCodeResponse = map[APIErrorCode]APIError{
 		HTTPStatusCode: http.StatusBadRequest,
 	},
 
+	ErrNoSuchVersion: {
+		Code:           "NoSuchVersion",
+		Description:    "The specified version does not exist.",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrInvalidVersionID: {
+		Code:           "InvalidArgument",
+		Description:    "Invalid version id specified",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrIllegalVersioningConfiguration: {
+		Code:           "IllegalVersioningConfigurationException",
+		Description:    "The versioning configuration specified in the request is invalid.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrNoSuchLifecycleConfiguration: {
+		Code:           "NoSuchLifecycleConfiguration",
+		Description:    "The lifecycle configuration does not exist",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrNoSuchCORSConfiguration: {
+		Code:           "NoSuchCORSConfiguration",
+		Description:    "The CORS configuration does not exist",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrCORSNotAllowed: {
+		Code:           "AccessForbidden",
+		Description:    "CORSResponse: This CORS request is not allowed. This is usually because the evaluation of Origin, request method / Access-Control-Request-Method or Access-Control-Request-Headers are not whitelisted by the resource's CORS spec.",
+		HTTPStatusCode: http.StatusForbidden,
+	},
+	ErrNoSuchWebsiteConfiguration: {
+		Code:           "NoSuchWebsiteConfiguration",
+		Description:    "The specified bucket does not have a website configuration",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrReplicationConfigurationNotFound: {
+		Code:           "ReplicationConfigurationNotFoundError",
+		Description:    "The replication configuration was not found",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrInvalidTargetBucketForLogging: {
+		Code:           "InvalidTargetBucketForLogging",
+		Description:    "The target bucket for logging does not exist",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidTag: {
+		Code:           "InvalidTag",
+		Description:    "The tag provided was not a valid tag. This error can occur if the tag did not pass input validation.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrNoSuchTagSet: {
+		Code:           "NoSuchTagSet",
+		Description:    "The TagSet does not exist",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrInvalidTaggingDirective: {
+		Code:           "InvalidArgument",
+		Description:    "Unknown tagging directive.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInsecureSSECustomerRequest: {
+		Code:           "InvalidRequest",
+		Description:    "Requests specifying Server Side Encryption with Customer provided keys must be made over a secure connection.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrSSEMultipartEncrypted: {
+		Code:           "InvalidRequest",
+		Description:    "The multipart upload initiate requested encryption. Subsequent part requests must include the appropriate encryption parameters.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrSSEEncryptedObject: {
+		Code:           "InvalidRequest",
+		Description:    "The object was stored using a form of Server Side Encryption. The correct parameters must be provided to retrieve the object.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidEncryptionParameters: {
+		Code:           "InvalidRequest",
+		Description:    "The encryption parameters are not applicable to this object.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidSSECustomerAlgorithm: {
+		Code:           "InvalidArgument",
+		Description:    "Requests specifying Server Side Encryption with Customer provided keys must provide a valid encryption algorithm.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidSSECustomerKey: {
+		Code:           "InvalidArgument",
+		Description:    "The secret key was invalid for the specified algorithm.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrMissingSSECustomerKey: {
+		Code:           "InvalidArgument",
+		Description:    "Requests specifying Server Side Encryption with Customer provided keys must provide an appropriate secret key.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrMissingSSECustomerKeyMD5: {
+		Code:           "InvalidArgument",
+		Description:    "Requests specifying Server Side Encryption with Customer provided keys must provide the client calculated MD5 of the secret key.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrSSECustomerKeyMD5Mismatch: {
+		Code:           "InvalidArgument",
+		Description:    "The calculated MD5 hash of the key did not match the hash that was provided.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidSSECustomerParameters: {
+		Code:           "AccessDenied",
+		Description:    "The provided encryption parameters did not match the ones used originally.",
+		HTTPStatusCode: http.StatusForbidden,
+	},
+	ErrInvalidEncryptionMethod: {
+		Code:           "InvalidArgument",
+		Description:    "The encryption method specified is not supported.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrIncompatibleEncryptionMethod: {
+		Code:           "InvalidArgument",
+		Description:    "Server side encryption specified with both SSE-C and SSE-S3 headers.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrKMSNotConfigured: {
+		Code:           "NotImplemented",
+		Description:    "Server side encryption specified but KMS is not configured.",
+		HTTPStatusCode: http.StatusNotImplemented,
+	},
+	ErrNoSuchBucketEncryptionConfiguration: {
+		Code:           "ServerSideEncryptionConfigurationNotFoundError",
+		Description:    "The server side encryption configuration was not found.",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrObjectLocked: {
+		Code:           "AccessDenied",
+		Description:    "Access Denied because object protected by object lock.",
+		HTTPStatusCode: http.StatusForbidden,
+	},
+	ErrNoSuchObjectLockConfiguration: {
+		Code:           "ObjectLockConfigurationNotFoundError",
+		Description:    "Object Lock configuration does not exist for this bucket",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrNoSuchObjectRetention: {
+		Code:           "NoSuchObjectLockConfiguration",
+		Description:    "The specified object does not have a ObjectLock configuration",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrObjectLockNotConfigured: {
+		Code:           "InvalidRequest",
+		Description:    "Bucket is missing ObjectLockConfiguration",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidBucketState: {
+		Code:           "InvalidBucketState",
+		Description:    "Object Lock requires versioning to be enabled on the bucket.",
+		HTTPStatusCode: http.StatusConflict,
+	},
+	ErrIncompleteRetention: {
+		Code:           "InvalidArgument",
+		Description:    "x-amz-object-lock-retain-until-date and x-amz-object-lock-mode must both be supplied",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidRetentionMode: {
+		Code:           "InvalidArgument",
+		Description:    "Unknown wormMode directive.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidRetainUntilDate: {
+		Code:           "InvalidArgument",
+		Description:    "The retain until date must be in the future and in ISO 8601 format.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidLegalHoldStatus: {
+		Code:           "InvalidArgument",
+		Description:    "Legal Hold must be either of 'ON' or 'OFF'",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidStorageClass: {
+		Code:           "InvalidStorageClass",
+		Description:    "The storage class you specified is not valid.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+
 	/// S3 extensions.
 	ErrContentSHA256Mismatch: {
 		Code:           "XAmzContentSHA256Mismatch",
 		Description:    "The provided 'x-amz-content-sha256' header does not match what was computed.",
 		HTTPStatusCode: http.StatusBadRequest,
 	},
+	ErrInvalidToken: {
+		Code:           "InvalidToken",
+		Description:    "The provided token is malformed or otherwise invalid.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrExpiredToken: {
+		Code:           "ExpiredToken",
+		Description:    "The provided token has expired.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
 
 	/// Minio extensions.
 	ErrStorageFull: {
@@ -605,6 +842,11 @@ var errorCodeResponse = map[APIErrorCode]APIError{
 		Description:    "Resource name contains bad components such as \"..\" or \".\".",
 		HTTPStatusCode: http.StatusBadRequest,
 	},
+	ErrBucketQuotaExceeded: {
+		Code:           "XMinioBucketQuotaExceeded",
+		Description:    "Bucket quota exceeded.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
 	ErrServerNotInitialized: {
 		Code:           "XMinioServerNotInitialized",
 		Description:    "Server not initialized, please try again.",
@@ -625,6 +867,41 @@ var errorCodeResponse = map[APIErrorCode]APIError{
 		Description:    "Configuration update failed because server quorum was not met",
 		HTTPStatusCode: http.StatusServiceUnavailable,
 	},
+	ErrAdminNoSuchUser: {
+		Code:           "XMinioAdminNoSuchUser",
+		Description:    "The specified user does not exist.",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrAdminNoSuchGroup: {
+		Code:           "XMinioAdminNoSuchGroup",
+		Description:    "The specified group does not exist.",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrAdminNoSuchPolicy: {
+		Code:           "XMinioAdminNoSuchPolicy",
+		Description:    "The specified policy does not exist.",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrAdminInvalidArgument: {
+		Code:           "XMinioAdminInvalidArgument",
+		Description:    "Invalid arguments specified.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrAdminNoSuchQuotaConfiguration: {
+		Code:           "XMinioAdminNoSuchQuotaConfiguration",
+		Description:    "The quota configuration does not exist.",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrAdminNoSuchHealSequence: {
+		Code:           "XMinioAdminNoSuchHealSequence",
+		Description:    "The specified heal sequence does not exist.",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrAdminHealSequenceRunning: {
+		Code:           "XMinioAdminHealSequenceRunning",
+		Description:    "A heal sequence is already running on the bucket and prefix.",
+		HTTPStatusCode: http.StatusConflict,
+	},
 	ErrInsecureClientRequest: {
 		Code:           "XMinioInsecureClientRequest",
 		Description:    "Cannot respond to plain-text request from TLS-encrypted server",
@@ -657,6 +934,64 @@ func toAPIErrorCode(err error) (apiErr APIErrorCode) {
 		apiErr = ErrAdminInvalidAccessKey
 	case errInvalidSecretKeyLength:
 		apiErr = ErrAdminInvalidSecretKey
+	case errInsecureSSERequest:
+		apiErr = ErrInsecureSSECustomerRequest
+	case errEncryptedMultipartUpload:
+		apiErr = ErrSSEMultipartEncrypted
+	case errEncryptedObject:
+		apiErr = ErrSSEEncryptedObject
+	case errEncryptionParamsNotApplicable:
+		apiErr = ErrInvalidEncryptionParameters
+	case errInvalidSSEAlgorithm:
+		apiErr = ErrInvalidSSECustomerAlgorithm
+	case errInvalidSSEKey:
+		apiErr = ErrInvalidSSECustomerKey
+	case errMissingSSEKey:
+		apiErr = ErrMissingSSECustomerKey
+	case errMissingSSEKeyMD5:
+		apiErr = ErrMissingSSECustomerKeyMD5
+	case errSSEKeyMD5Mismatch:
+		apiErr = ErrSSECustomerKeyMD5Mismatch
+	case errSSEKeyMismatch:
+		apiErr = ErrInvalidSSECustomerParameters
+	case errInvalidEncryptionMethod:
+		apiErr = ErrInvalidEncryptionMethod
+	case errIncompatibleEncryptionMethod:
+		apiErr = ErrIncompatibleEncryptionMethod
+	case errKMSNotConfigured:
+		apiErr = ErrKMSNotConfigured
+	case errTooManyTags, errInvalidTagKey, errInvalidTagValue, errDuplicateTagKey:
+		apiErr = ErrInvalidTag
+	case errInvalidTaggingDirective:
+		apiErr = ErrInvalidTaggingDirective
+	case errIncompleteRetention:
+		apiErr = ErrIncompleteRetention
+	case errInvalidRetentionMode:
+		apiErr = ErrInvalidRetentionMode
+	case errInvalidRetainUntilDate:
+		apiErr = ErrInvalidRetainUntilDate
+	case errInvalidLegalHoldStatus:
+		apiErr = ErrInvalidLegalHoldStatus
+	case errInvalidStorageClass:
+		apiErr = ErrInvalidStorageClass
+	case errObjectLockNotConfigured:
+		apiErr = ErrObjectLockNotConfigured
+	case errNoSuchUser:
+		apiErr = ErrAdminNoSuchUser
+	case errNoSuchGroup:
+		apiErr = ErrAdminNoSuchGroup
+	case errNoSuchPolicy:
+		apiErr = ErrAdminNoSuchPolicy
+	case errInvalidUserStatus, errInvalidIAMName, errIAMReservedAccessKey, errCannedPolicy:
+		apiErr = ErrAdminInvalidArgument
+	case errInvalidBucketQuota:
+		apiErr = ErrAdminInvalidArgument
+	case errNoSuchBucketQuota:
+		apiErr = ErrAdminNoSuchQuotaConfiguration
+	case errNoSuchHealSequence:
+		apiErr = ErrAdminNoSuchHealSequence
+	case errHealSequenceRunning:
+		apiErr = ErrAdminHealSequenceRunning
 	}
 
 	if apiErr != ErrNone {
@@ -691,6 +1026,12 @@ func toAPIErrorCode(err error) (apiErr APIErrorCode) {
 		apiErr = ErrBucketAlreadyOwnedByYou
 	case ObjectNotFound:
 		apiErr = ErrNoSuchKey
+	case VersionNotFound:
+		apiErr = ErrNoSuchVersion
+	case MethodNotAllowed:
+		apiErr = ErrMethodNotAllowed
+	case ObjectLocked:
+		apiErr = ErrObjectLocked
 	case ObjectNameInvalid:
 		apiErr = ErrInvalidObjectName
 	case InvalidUploadID:
@@ -70,11 +70,27 @@ func setObjectHeaders(w http.ResponseWriter, objInfo ObjectInfo, contentRange *h
 		w.Header().Set("ETag", "\""+objInfo.ETag+"\"")
 	}
 
-	// Set all other user defined metadata.
+	// Set all other user defined metadata, reserved
+	// metadata is for internal use only.
 	for k, v := range objInfo.UserDefined {
+		if isReservedMetadata(k) {
+			continue
+		}
 		w.Header().Set(k, v)
 	}
 
+	// Set the number of tags if the object has any.
+	setTaggingCountHeader(w, objInfo.UserDefined)
+
+	// Set the object lock settings if the object has any.
+	setObjectLockHeaders(w, objInfo.UserDefined)
+
+	// Set the replication status if the object is replicated.
+	setReplicationStatusHeader(w, objInfo.UserDefined)
+
+	// Set version headers if available.
+	setVersionHeaders(w, objInfo)
+
 	// for providing ranged content
 	if contentRange != nil && contentRange.offsetBegin > -1 {
 		// Override content-length
@@ -83,3 +99,14 @@ func setObjectHeaders(w http.ResponseWriter, objInfo ObjectInfo, contentRange *h
 		w.WriteHeader(http.StatusPartialContent)
 	}
 }
+
+// Write object version headers, version ID is only reported for
+// objects with a version or in buckets which are versioned.
+func setVersionHeaders(w http.ResponseWriter, objInfo ObjectInfo) {
+	if objInfo.VersionID != "" || getBucketVersioningStatus(objInfo.Bucket) != "" {
+		w.Header().Set("x-amz-version-id", toVersionID(objInfo.VersionID))
+	}
+	if objInfo.DeleteMarker {
+		w.Header().Set("x-amz-delete-marker", "true")
+	}
+}
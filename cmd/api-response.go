@@ -99,6 +99,31 @@ type ListObjectsV2Response struct {
 	EncodingType string `xml:"EncodingType,omitempty"`
 }
 
+// ListVersionsResponse - format for list object versions response.
+type ListVersionsResponse struct {
+	XMLName xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ ListVersionsResult" json:"-"`
+
+	Name            string
+	Prefix          string
+	KeyMarker       string
+	VersionIDMarker string `xml:"VersionIdMarker"`
+
+	// When response is truncated, use these values as key-marker and
+	// version-id-marker in the subsequent request.
+	NextKeyMarker       string `xml:"NextKeyMarker,omitempty"`
+	NextVersionIDMarker string `xml:"NextVersionIdMarker,omitempty"`
+
+	MaxKeys   int
+	Delimiter string `xml:"Delimiter,omitempty"`
+	// A flag that indicates whether or not ListObjectVersions returned
+	// all of the results that satisfied the search criteria.
+	IsTruncated bool
+
+	Versions       []ObjectVersion       `xml:"Version"`
+	DeleteMarkers  []DeleteMarkerVersion `xml:"DeleteMarker"`
+	CommonPrefixes []CommonPrefix
+}
+
 // Part container for part metadata.
 type Part struct {
 	PartNumber   int
@@ -202,6 +227,33 @@ type Object struct {
 	HealObjectInfo *HealObjectInfo `xml:"HealObjectInfo,omitempty"`
 }
 
+// ObjectVersion container for object version metadata.
+type ObjectVersion struct {
+	Key          string
+	VersionID    string `xml:"VersionId"`
+	IsLatest     bool
+	LastModified string // time string of format "2006-01-02T15:04:05.000Z"
+	ETag         string
+	Size         int64
+
+	// Owner of the object.
+	Owner Owner
+
+	// The class of storage used to store the object.
+	StorageClass string
+}
+
+// DeleteMarkerVersion container for delete marker metadata.
+type DeleteMarkerVersion struct {
+	Key          string
+	VersionID    string `xml:"VersionId"`
+	IsLatest     bool
+	LastModified string // time string of format "2006-01-02T15:04:05.000Z"
+
+	// Owner of the delete marker.
+	Owner Owner
+}
+
 // CopyObjectResponse container returns ETag and LastModified of the successfully copied object
 type CopyObjectResponse struct {
 	XMLName      xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ CopyObjectResult" json:"-"`
@@ -275,8 +327,12 @@ func getLocation(r *http.Request) string {
 	return path.Clean(r.URL.Path) // Clean any trailing slashes.
 }
 
-// getObjectLocation gets the relative URL for an object
-func getObjectLocation(bucketName string, key string) string {
+// getObjectLocation gets the relative URL for an object, addressed in
+// the same style as the request.
+func getObjectLocation(r *http.Request, bucketName string, key string) string {
+	if _, ok := getVirtualHostBucket(r.Host); ok {
+		return "/" + key
+	}
 	return "/" + bucketName + "/" + key
 }
 
@@ -321,7 +377,7 @@ func generateListObjectsV1Response(bucket, prefix, marker, delimiter string, max
 			content.ETag = "\"" + object.ETag + "\""
 		}
 		content.Size = object.Size
-		content.StorageClass = globalMinioDefaultStorageClass
+		content.StorageClass = getStorageClass(object.UserDefined)
 		content.Owner = owner
 		// object.HealObjectInfo is non-empty only when resp is constructed in ListObjectsHeal.
 		content.HealObjectInfo = object.HealObjectInfo
@@ -347,6 +403,64 @@ func generateListObjectsV1Response(bucket, prefix, marker, delimiter string, max
 	return data
 }
 
+// generates an ListObjectVersions response for the said bucket with other enumerated options.
+func generateListVersionsResponse(bucket, prefix, keyMarker, versionIDMarker, delimiter string, maxKeys int, resp ListObjectVersionsInfo) ListVersionsResponse {
+	var versions []ObjectVersion
+	var deleteMarkers []DeleteMarkerVersion
+	var prefixes []CommonPrefix
+	var owner = Owner{}
+	var data = ListVersionsResponse{}
+
+	owner.ID = globalMinioDefaultOwnerID
+	for _, object := range resp.Objects {
+		var content = ObjectVersion{}
+		if object.Name == "" {
+			continue
+		}
+		content.Key = object.Name
+		content.VersionID = toVersionID(object.VersionID)
+		content.IsLatest = object.IsLatest
+		content.LastModified = object.ModTime.UTC().Format(timeFormatAMZLong)
+		content.Owner = owner
+		if object.DeleteMarker {
+			deleteMarkers = append(deleteMarkers, DeleteMarkerVersion{
+				Key:          content.Key,
+				VersionID:    content.VersionID,
+				IsLatest:     content.IsLatest,
+				LastModified: content.LastModified,
+				Owner:        owner,
+			})
+			continue
+		}
+		if object.ETag != "" {
+			content.ETag = "\"" + object.ETag + "\""
+		}
+		content.Size = object.Size
+		content.StorageClass = getStorageClass(object.UserDefined)
+		versions = append(versions, content)
+	}
+	data.Name = bucket
+	data.Versions = versions
+	data.DeleteMarkers = deleteMarkers
+
+	data.Prefix = prefix
+	data.KeyMarker = keyMarker
+	data.VersionIDMarker = versionIDMarker
+	data.Delimiter = delimiter
+	data.MaxKeys = maxKeys
+
+	data.NextKeyMarker = resp.NextKeyMarker
+	data.NextVersionIDMarker = resp.NextVersionIDMarker
+	data.IsTruncated = resp.IsTruncated
+	for _, prefix := range resp.Prefixes {
+		var prefixItem = CommonPrefix{}
+		prefixItem.Prefix = prefix
+		prefixes = append(prefixes, prefixItem)
+	}
+	data.CommonPrefixes = prefixes
+	return data
+}
+
 // generates an ListObjectsV2 response for the said bucket with other enumerated options.
 func generateListObjectsV2Response(bucket, prefix, token, nextToken, startAfter, delimiter string, fetchOwner, isTruncated bool, maxKeys int, objects []ObjectInfo, prefixes []string) ListObjectsV2Response {
 	var contents []Object
@@ -369,7 +483,7 @@ func generateListObjectsV2Response(bucket, prefix, token, nextToken, startAfter,
 			content.ETag = "\"" + object.ETag + "\""
 		}
 		content.Size = object.Size
-		content.StorageClass = globalMinioDefaultStorageClass
+		content.StorageClass = getStorageClass(object.UserDefined)
 		content.Owner = owner
 		contents = append(contents, content)
 	}
@@ -436,7 +550,7 @@ func generateListPartsResponse(partsInfo ListPartsInfo) ListPartsResponse {
 	listPartsResponse.Bucket = partsInfo.Bucket
 	listPartsResponse.Key = partsInfo.Object
 	listPartsResponse.UploadID = partsInfo.UploadID
-	listPartsResponse.StorageClass = globalMinioDefaultStorageClass
+	listPartsResponse.StorageClass = getStorageClass(partsInfo.UserDefined)
 	listPartsResponse.Initiator.ID = globalMinioDefaultOwnerID
 	listPartsResponse.Owner.ID = globalMinioDefaultOwnerID
 
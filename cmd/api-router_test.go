@@ -0,0 +1,117 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	router "github.com/gorilla/mux"
+)
+
+// Wrapper for calling virtual host style tests for both XL and FS.
+func TestVirtualHostStyleRequests(t *testing.T) {
+	// initialize NSLock.
+	initNSLock(false)
+
+	ExecObjectLayerTest(t, testVirtualHostStyleRequests)
+}
+
+// Tests routing of virtual host style requests, mixed with path
+// style requests to the same buckets.
+func testVirtualHostStyleRequests(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	globalDomains = []string{"example.com", "s3.example.com"}
+	defer func() { globalDomains = nil }()
+
+	globalObjLayerMutex.Lock()
+	globalObjectAPI = obj
+	globalObjLayerMutex.Unlock()
+	defer func() {
+		globalObjLayerMutex.Lock()
+		globalObjectAPI = nil
+		globalObjLayerMutex.Unlock()
+	}()
+
+	mux := router.NewRouter().SkipClean(true)
+	registerAPIRouter(mux)
+
+	cred := serverConfig.GetCredential()
+	sendRequest := func(method, urlStr string, data []byte) *httptest.ResponseRecorder {
+		req, err := newTestSignedRequestV4(method, urlStr, int64(len(data)), bytes.NewReader(data),
+			cred.AccessKey, cred.SecretKey)
+		if err != nil {
+			t.Fatalf("%s: %v", instanceType, err)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	bucket := "vhost-bucket"
+	bucketURL := "http://" + bucket + ".s3.example.com"
+	data := []byte("virtual host style")
+
+	testCases := []struct {
+		method       string
+		urlStr       string
+		data         []byte
+		status       int
+		expectedBody string
+	}{
+		// Create the bucket and an object in virtual host style.
+		{"PUT", bucketURL + "/", nil, http.StatusOK, ""},
+		{"PUT", bucketURL + "/dir/object", data, http.StatusOK, ""},
+		// Read the object in both styles.
+		{"GET", bucketURL + "/dir/object", nil, http.StatusOK, string(data)},
+		{"GET", "http://s3.example.com/" + bucket + "/dir/object", nil, http.StatusOK, string(data)},
+		// List the bucket in virtual host style.
+		{"GET", bucketURL + "/?prefix=dir/", nil, http.StatusOK, "<Key>dir/object</Key>"},
+		// Requests to the domain itself are path style.
+		{"GET", "http://s3.example.com/", nil, http.StatusOK, "<Name>" + bucket + "</Name>"},
+		// Any server domain addresses the bucket.
+		{"HEAD", "http://" + bucket + ".example.com/dir/object", nil, http.StatusOK, ""},
+		{"HEAD", "http://other." + bucket + ".example.com/dir/object", nil, http.StatusNotFound, ""},
+	}
+
+	for i, testCase := range testCases {
+		rec := sendRequest(testCase.method, testCase.urlStr, testCase.data)
+		if rec.Code != testCase.status {
+			t.Errorf("%s: Test %d: Expected status %d, got %d", instanceType, i+1, testCase.status, rec.Code)
+			continue
+		}
+		if !strings.Contains(rec.Body.String(), testCase.expectedBody) {
+			t.Errorf("%s: Test %d: Expected body to contain %q, got %q", instanceType, i+1, testCase.expectedBody, rec.Body.String())
+		}
+	}
+
+	// Presigned virtual host style requests.
+	req, err := newTestRequest("GET", bucketURL+"/dir/object", 0, nil)
+	if err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if err = preSignV4(req, cred.AccessKey, cred.SecretKey, 60); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || rec.Body.String() != string(data) {
+		t.Fatalf("%s: Expected presigned request to succeed, got %d %q", instanceType, rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,327 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Version of the audit record format.
+const auditEntryVersion = "1"
+
+// Request headers recorded in the audit records, credentials and
+// signatures are never recorded.
+var auditRequestHeaders = []string{
+	"Content-Length",
+	"Content-Md5",
+	"Content-Type",
+	"Range",
+	"X-Amz-Copy-Source",
+	"X-Amz-Server-Side-Encryption",
+	"X-Amz-Tagging",
+	minioAdminOpHeader,
+}
+
+// Response headers recorded in the audit records.
+var auditResponseHeaders = []string{
+	"Content-Length",
+	"Content-Type",
+	"ETag",
+	"Last-Modified",
+	"X-Amz-Delete-Marker",
+	"X-Amz-Server-Side-Encryption",
+	"X-Amz-Version-Id",
+}
+
+// Admin API operations, selected by a query parameter.
+var auditAdminResources = []string{
+	"config",
+	"datausage",
+	"heal",
+	"iam",
+	"info",
+	"lock",
+	"logs",
+	"quota",
+	"service",
+	"trace",
+}
+
+// Internal RPC services which are not audited.
+var auditIgnoredPaths = []string{
+	adminPath,
+	browserPeerPath,
+	lockServicePath,
+	s3Path,
+	storageRPCPath,
+}
+
+// auditConfig - targets the audit records of all requests are sent to.
+type auditConfig struct {
+	sync.RWMutex
+	Console auditConsoleConfig `json:"console"`
+	File    auditFileConfig    `json:"file"`
+	Webhook auditWebhookConfig `json:"webhook"`
+}
+
+// auditConsoleConfig - writes audit records to the standard output.
+type auditConsoleConfig struct {
+	Enable bool `json:"enable"`
+}
+
+// auditFileConfig - appends audit records to a file.
+type auditFileConfig struct {
+	Enable   bool   `json:"enable"`
+	Filename string `json:"filename"`
+}
+
+// auditWebhookConfig - posts batches of audit records to an HTTP
+// endpoint.
+type auditWebhookConfig struct {
+	Enable    bool   `json:"enable"`
+	Endpoint  string `json:"endpoint"`
+	BatchSize int    `json:"batchSize"`
+}
+
+// newAuditConfig - returns an audit config with all targets disabled.
+func newAuditConfig() *auditConfig {
+	return &auditConfig{
+		Webhook: auditWebhookConfig{BatchSize: defaultAuditWebhookBatchSize},
+	}
+}
+
+// Validate - Check whether audit targets are valid or not.
+func (a *auditConfig) Validate() error {
+	if a == nil {
+		return nil
+	}
+	if file := a.GetFile(); file.Enable && file.Filename == "" {
+		return errors.New("Missing filename for enabled audit file target")
+	}
+	webhook := a.GetWebhook()
+	if webhook.BatchSize < 0 {
+		return errors.New("Invalid batch size for audit webhook target")
+	}
+	if webhook.Enable {
+		if _, err := checkURL(webhook.Endpoint); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetConsole set new audit console target.
+func (a *auditConfig) SetConsole(console auditConsoleConfig) {
+	a.Lock()
+	defer a.Unlock()
+	a.Console = console
+}
+
+// GetConsole get current audit console target.
+func (a *auditConfig) GetConsole() auditConsoleConfig {
+	a.RLock()
+	defer a.RUnlock()
+	return a.Console
+}
+
+// SetFile set new audit file target.
+func (a *auditConfig) SetFile(file auditFileConfig) {
+	a.Lock()
+	defer a.Unlock()
+	a.File = file
+}
+
+// GetFile get current audit file target.
+func (a *auditConfig) GetFile() auditFileConfig {
+	a.RLock()
+	defer a.RUnlock()
+	return a.File
+}
+
+// SetWebhook set new audit webhook target.
+func (a *auditConfig) SetWebhook(webhook auditWebhookConfig) {
+	a.Lock()
+	defer a.Unlock()
+	a.Webhook = webhook
+}
+
+// GetWebhook get current audit webhook target.
+func (a *auditConfig) GetWebhook() auditWebhookConfig {
+	a.RLock()
+	defer a.RUnlock()
+	return a.Webhook
+}
+
+// auditEntry - the audit record of a single request.
+type auditEntry struct {
+	Version     string            `json:"version"`
+	Time        time.Time         `json:"time"`
+	API         string            `json:"api"`
+	Bucket      string            `json:"bucket,omitempty"`
+	Object      string            `json:"object,omitempty"`
+	StatusCode  int               `json:"statusCode"`
+	InputBytes  int64             `json:"rx"`
+	OutputBytes int64             `json:"tx"`
+	Duration    string            `json:"duration"`
+	RemoteHost  string            `json:"remoteHost"`
+	UserAgent   string            `json:"userAgent,omitempty"`
+	AccessKey   string            `json:"accessKey,omitempty"`
+	RequestID   string            `json:"requestID,omitempty"`
+	ReqHeader   map[string]string `json:"requestHeader,omitempty"`
+	RespHeader  map[string]string `json:"responseHeader,omitempty"`
+}
+
+// getAuditHeaders - returns the values of the selected headers which
+// are set.
+func getAuditHeaders(header http.Header, names []string) map[string]string {
+	values := make(map[string]string)
+	for _, name := range names {
+		if value := header.Get(name); value != "" {
+			values[name] = value
+		}
+	}
+	return values
+}
+
+// getAuditAPIName - returns the name of the API a request called, S3
+// API names are the operations of the server access logs.
+func getAuditAPIName(r *http.Request, bucket, object string) string {
+	if bucket == minioReservedBucket {
+		return "WEB." + r.Method
+	}
+	if bucket != "" {
+		return getAccessLogOperation(r, object)
+	}
+	query := r.URL.Query()
+	for _, resource := range auditAdminResources {
+		if _, ok := query[resource]; ok {
+			name := "ADMIN." + r.Method + "." + strings.ToUpper(resource)
+			if op := r.Header.Get(minioAdminOpHeader); op != "" {
+				name += "." + strings.ToUpper(op)
+			}
+			return name
+		}
+	}
+	return "REST." + r.Method + ".SERVICE"
+}
+
+// isAuditIgnored - returns true for internal requests between the
+// servers of a cluster.
+func isAuditIgnored(r *http.Request) bool {
+	for _, path := range auditIgnoredPaths {
+		if hasPrefix(r.URL.Path, minioReservedBucketPath+path) {
+			return true
+		}
+	}
+	return false
+}
+
+// newAuditEntry - returns the audit record of a served request.
+func newAuditEntry(r *http.Request, w *httpResponseRecorder, inputBytes int64, start time.Time, duration time.Duration) auditEntry {
+	bucket, object := getRequestBucketObject(r)
+	remoteHost, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		remoteHost = r.RemoteAddr
+	}
+	status := w.respStatusCode
+	if status == 0 {
+		status = http.StatusOK
+	}
+	return auditEntry{
+		Version:     auditEntryVersion,
+		Time:        start,
+		API:         getAuditAPIName(r, bucket, object),
+		Bucket:      bucket,
+		Object:      object,
+		StatusCode:  status,
+		InputBytes:  inputBytes,
+		OutputBytes: w.bytesWritten,
+		Duration:    duration.String(),
+		RemoteHost:  remoteHost,
+		UserAgent:   r.UserAgent(),
+		AccessKey:   getReqAccessKey(r),
+		RequestID:   w.Header().Get(responseRequestIDKey),
+		ReqHeader:   getAuditHeaders(r.Header, auditRequestHeaders),
+		RespHeader:  getAuditHeaders(w.Header(), auditResponseHeaders),
+	}
+}
+
+// auditTarget - a destination of audit records.
+type auditTarget interface {
+	// Send - sends a single JSON encoded audit record.
+	Send(record []byte) error
+	String() string
+}
+
+// Variable represents the audit logger of all requests.
+var globalAuditLogger *auditLogger
+
+// auditLogger - sends the audit records of all requests to the
+// enabled targets.
+type auditLogger struct {
+	targets []auditTarget
+}
+
+// initAuditLogger - initializes the enabled audit targets, the audit
+// logger is left unset when no target is enabled.
+func initAuditLogger(config *auditConfig) error {
+	if config == nil {
+		return nil
+	}
+	var targets []auditTarget
+	if config.GetConsole().Enable {
+		targets = append(targets, newAuditConsoleTarget())
+	}
+	if file := config.GetFile(); file.Enable {
+		target, err := newAuditFileTarget(file.Filename)
+		if err != nil {
+			return err
+		}
+		targets = append(targets, target)
+	}
+	if webhook := config.GetWebhook(); webhook.Enable {
+		target := newAuditWebhookTarget(webhook.Endpoint, webhook.BatchSize)
+		go target.start(globalServiceDoneCh)
+		targets = append(targets, target)
+	}
+	if len(targets) > 0 {
+		globalAuditLogger = &auditLogger{targets: targets}
+	}
+	return nil
+}
+
+// logRequest - sends the audit record of a served request to all
+// targets.
+func (l *auditLogger) logRequest(r *http.Request, w *httpResponseRecorder, inputBytes int64, start time.Time, duration time.Duration) {
+	if l == nil || isAuditIgnored(r) {
+		return
+	}
+	record, err := json.Marshal(newAuditEntry(r, w, inputBytes, start, duration))
+	if err != nil {
+		errorIf(err, "Unable to marshal audit record.")
+		return
+	}
+	for _, target := range l.targets {
+		errorIf(target.Send(record), "Unable to send audit record to %s.", target)
+	}
+}
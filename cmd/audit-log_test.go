@@ -0,0 +1,205 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Tests the API names reported in audit records.
+func TestAuditAPIName(t *testing.T) {
+	testCases := []struct {
+		method  string
+		urlStr  string
+		adminOp string
+		apiName string
+	}{
+		{"GET", "/", "", "REST.GET.SERVICE"},
+		{"PUT", "/bucket", "", "REST.PUT.BUCKET"},
+		{"GET", "/bucket/object", "", "REST.GET.OBJECT"},
+		{"GET", "/bucket?versioning", "", "REST.GET.VERSIONING"},
+		{"GET", "/?info", "", "ADMIN.GET.INFO"},
+		{"POST", "/?service", "restart", "ADMIN.POST.SERVICE.RESTART"},
+		{"POST", "/minio/webrpc", "", "WEB.POST"},
+	}
+	for i, testCase := range testCases {
+		req, err := http.NewRequest(testCase.method, testCase.urlStr, nil)
+		if err != nil {
+			t.Fatalf("Test %d: %s", i+1, err)
+		}
+		if testCase.adminOp != "" {
+			req.Header.Set(minioAdminOpHeader, testCase.adminOp)
+		}
+		bucket, object := getRequestBucketObject(req)
+		if apiName := getAuditAPIName(req, bucket, object); apiName != testCase.apiName {
+			t.Errorf("Test %d: Expected API name %s, got %s", i+1, testCase.apiName, apiName)
+		}
+	}
+}
+
+// Tests that internal RPC requests are not audited.
+func TestIsAuditIgnored(t *testing.T) {
+	testCases := []struct {
+		urlStr  string
+		ignored bool
+	}{
+		{"/bucket/object", false},
+		{"/minio/webrpc", false},
+		{"/minio/lock/export", true},
+		{"/minio/storage/export", true},
+		{"/minio/s3/remote", true},
+		{"/minio/admin", true},
+	}
+	for i, testCase := range testCases {
+		req, err := http.NewRequest("POST", testCase.urlStr, nil)
+		if err != nil {
+			t.Fatalf("Test %d: %s", i+1, err)
+		}
+		if ignored := isAuditIgnored(req); ignored != testCase.ignored {
+			t.Errorf("Test %d: Expected ignored to be %t, got %t", i+1, testCase.ignored, ignored)
+		}
+	}
+}
+
+// Tests audit records written to a file and posted to a webhook.
+func TestAuditLogger(t *testing.T) {
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatalf("Init Test config failed")
+	}
+	defer removeAll(rootPath)
+	defer func(l *auditLogger) { globalAuditLogger = l }(globalAuditLogger)
+
+	// Webhook which fails the first post of audit records.
+	var posts int
+	batchCh := make(chan []byte, 10)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		posts++
+		if posts == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		batch, _ := ioutil.ReadAll(r.Body)
+		batchCh <- batch
+	}))
+	defer webhook.Close()
+
+	config := newAuditConfig()
+	config.SetFile(auditFileConfig{Enable: true, Filename: filepath.Join(rootPath, "audit.log")})
+	config.SetWebhook(auditWebhookConfig{Enable: true, Endpoint: webhook.URL, BatchSize: 2})
+	if err = config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	globalAuditLogger = nil
+	if err = initAuditLogger(config); err != nil {
+		t.Fatal(err)
+	}
+	if globalAuditLogger == nil || len(globalAuditLogger.targets) != 2 {
+		t.Fatalf("Expected audit logger with 2 targets, got %v", globalAuditLogger)
+	}
+
+	// Replace the started webhook target by one retrying quickly.
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+	webhookTarget := newAuditWebhookTarget(webhook.URL, 2)
+	webhookTarget.retryUnit = time.Millisecond
+	webhookTarget.retryCap = 10 * time.Millisecond
+	webhookTarget.flushInterval = time.Hour
+	go webhookTarget.start(doneCh)
+	globalAuditLogger.targets[1] = webhookTarget
+
+	handler := setHTTPStatsHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ioutil.ReadAll(r.Body)
+		w.Header().Set(responseRequestIDKey, "REQUESTID")
+		w.Header().Set("ETag", "\"etag\"")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("hello, world"))
+	}))
+	sendRequest := func(method, urlStr, body string) {
+		req, rerr := newTestSignedRequestV4(method, urlStr, int64(len(body)), strings.NewReader(body),
+			globalActiveCred.AccessKey, globalActiveCred.SecretKey)
+		if rerr != nil {
+			t.Fatal(rerr)
+		}
+		req.RemoteAddr = "192.168.1.1:1234"
+		req.Header.Set("Content-Type", "text/plain")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	sendRequest("PUT", "http://localhost:9000/bucket/object", "audited")
+	sendRequest("POST", "http://localhost:9000/minio/lock/export", "ignored")
+	sendRequest("GET", "http://localhost:9000/bucket/object", "")
+
+	data, err := ioutil.ReadFile(filepath.Join(rootPath, "audit.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 audit records, got %q", lines)
+	}
+	var entry auditEntry
+	if err = json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry.API != "REST.PUT.OBJECT" || entry.Bucket != "bucket" || entry.Object != "object" ||
+		entry.StatusCode != http.StatusCreated || entry.InputBytes != 7 || entry.OutputBytes != 12 ||
+		entry.RemoteHost != "192.168.1.1" || entry.AccessKey != globalActiveCred.AccessKey ||
+		entry.RequestID != "REQUESTID" || entry.ReqHeader["Content-Type"] != "text/plain" ||
+		entry.RespHeader["ETag"] != "\"etag\"" {
+		t.Errorf("Unexpected audit record %s", lines[0])
+	}
+	if _, ok := entry.ReqHeader["Authorization"]; ok {
+		t.Errorf("Expected no credentials in audit record %s", lines[0])
+	}
+
+	// The webhook receives both records in one batch after a retry.
+	select {
+	case batch := <-batchCh:
+		if !bytes.Equal(batch, data) {
+			t.Errorf("Expected webhook batch %q, got %q", data, batch)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the audit webhook batch")
+	}
+	if posts != 2 {
+		t.Errorf("Expected 2 posts to the audit webhook, got %d", posts)
+	}
+
+	// The audit file is appended to, never truncated.
+	config.SetWebhook(auditWebhookConfig{})
+	globalAuditLogger = nil
+	if err = initAuditLogger(config); err != nil {
+		t.Fatal(err)
+	}
+	sendRequest("GET", "http://localhost:9000/bucket/object", "")
+	fi, err := os.Stat(filepath.Join(rootPath, "audit.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Size() <= int64(len(data)) {
+		t.Errorf("Expected audit file to grow beyond %d bytes, got %d", len(data), fi.Size())
+	}
+}
@@ -0,0 +1,213 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	// Default number of audit records posted to a webhook at once.
+	defaultAuditWebhookBatchSize = 100
+
+	// Interval after which audit records are posted to a webhook even
+	// if the batch is not full.
+	auditWebhookFlushInterval = time.Second
+
+	// Maximum number of audit records waiting to be posted to a
+	// webhook, records are dropped once the queue is full.
+	auditWebhookQueueSize = 10000
+)
+
+// errAuditQueueFull - audit records are sent faster than the target
+// accepts them.
+var errAuditQueueFull = errors.New("Audit record queue is full, record dropped")
+
+// auditWriterTarget - writes one audit record per line to a writer.
+type auditWriterTarget struct {
+	sync.Mutex
+	name   string
+	writer io.Writer
+}
+
+// newAuditConsoleTarget - returns a target writing audit records to
+// the standard output.
+func newAuditConsoleTarget() *auditWriterTarget {
+	return &auditWriterTarget{name: "console", writer: os.Stdout}
+}
+
+// newAuditFileTarget - returns a target appending audit records to a
+// file, the file is created if it does not exist.
+func newAuditFileTarget(filename string) (*auditWriterTarget, error) {
+	file, err := os.OpenFile(filename, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0640)
+	if err != nil {
+		return nil, err
+	}
+	return &auditWriterTarget{name: "file:" + filename, writer: file}, nil
+}
+
+// Send - writes the audit record and a newline with a single write, so
+// that concurrent records are never interleaved.
+func (t *auditWriterTarget) Send(record []byte) error {
+	line := make([]byte, 0, len(record)+1)
+	line = append(append(line, record...), '\n')
+
+	t.Lock()
+	defer t.Unlock()
+	_, err := t.writer.Write(line)
+	return err
+}
+
+// String - represents the target as string.
+func (t *auditWriterTarget) String() string {
+	return t.name
+}
+
+// auditWebhookTarget - posts audit records to an HTTP endpoint in
+// batches of newline delimited JSON records, failed posts are retried
+// with exponential backoff.
+type auditWebhookTarget struct {
+	endpoint      string
+	batchSize     int
+	client        *http.Client
+	recordCh      chan []byte
+	flushInterval time.Duration
+	retryUnit     time.Duration
+	retryCap      time.Duration
+}
+
+// newAuditWebhookTarget - returns a webhook target which is started
+// separately.
+func newAuditWebhookTarget(endpoint string, batchSize int) *auditWebhookTarget {
+	if batchSize <= 0 {
+		batchSize = defaultAuditWebhookBatchSize
+	}
+	return &auditWebhookTarget{
+		endpoint:  endpoint,
+		batchSize: batchSize,
+		client: &http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{RootCAs: globalRootCAs},
+				DialContext: (&net.Dialer{
+					Timeout:   5 * time.Second,
+					KeepAlive: 5 * time.Second,
+				}).DialContext,
+				TLSHandshakeTimeout:   3 * time.Second,
+				ResponseHeaderTimeout: 3 * time.Second,
+				ExpectContinueTimeout: 2 * time.Second,
+			},
+		},
+		recordCh:      make(chan []byte, auditWebhookQueueSize),
+		flushInterval: auditWebhookFlushInterval,
+		retryUnit:     defaultRetryUnit,
+		retryCap:      defaultRetryCap,
+	}
+}
+
+// Send - queues the audit record for the next batch.
+func (t *auditWebhookTarget) Send(record []byte) error {
+	select {
+	case t.recordCh <- record:
+		return nil
+	default:
+		return errAuditQueueFull
+	}
+}
+
+// String - represents the target as string.
+func (t *auditWebhookTarget) String() string {
+	return "webhook:" + t.endpoint
+}
+
+// start - posts the queued audit records whenever a batch is full or
+// the flush interval elapsed, until doneCh is closed.
+func (t *auditWebhookTarget) start(doneCh chan struct{}) {
+	ticker := time.NewTicker(t.flushInterval)
+	defer ticker.Stop()
+
+	var batch bytes.Buffer
+	var count int
+	flush := func() {
+		if count == 0 {
+			return
+		}
+		t.post(batch.Bytes(), doneCh)
+		batch.Reset()
+		count = 0
+	}
+	for {
+		select {
+		case record := <-t.recordCh:
+			batch.Write(record)
+			batch.WriteByte('\n')
+			if count++; count >= t.batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-doneCh:
+			flush()
+			return
+		}
+	}
+}
+
+// post - posts a batch of audit records until the endpoint accepts
+// them or doneCh is closed.
+func (t *auditWebhookTarget) post(batch []byte, doneCh chan struct{}) {
+	var err error
+	for range newRetryTimer(t.retryUnit, t.retryCap, doneCh) {
+		if err = t.postOnce(batch); err == nil {
+			return
+		}
+		errorIf(err, "Unable to post audit records to %s, retrying.", t.endpoint)
+	}
+}
+
+// postOnce - posts a batch of audit records once.
+func (t *auditWebhookTarget) postOnce(batch []byte) error {
+	req, err := http.NewRequest(httpPOST, t.endpoint, bytes.NewReader(batch))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	req.Header.Set("User-Agent", globalServerUserAgent)
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+
+	for _, status := range successStatus {
+		if resp.StatusCode == status {
+			return nil
+		}
+	}
+	return fmt.Errorf("Unexpected response from audit webhook %s: (%s)", t.endpoint, resp.Status)
+}
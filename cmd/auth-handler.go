@@ -105,33 +105,149 @@ func getRequestAuthType(r *http.Request) authType {
 	return authTypeUnknown
 }
 
+// checkRequestAuthType - verifies the signature of the request and that
+// the policy action is allowed for the signer, or for anonymous requests
+// by the bucket policy. Requests without policy action are only allowed
+// for the server credential.
 func checkRequestAuthType(r *http.Request, bucket, policyAction, region string) APIErrorCode {
 	reqAuthType := getRequestAuthType(r)
 
 	switch reqAuthType {
+	case authTypePresignedV2, authTypeSignedV2, authTypeSigned, authTypePresigned:
+		if s3Error := checkRequestSignature(r, region); s3Error != ErrNone {
+			return s3Error
+		}
+		return isReqActionAllowed(r, policyAction)
+	}
+
+	// Only actions which may be granted by bucket policies are
+	// allowed for anonymous requests.
+	if reqAuthType == authTypeAnonymous && supportedActionMap.Contains(policyAction) {
+		// http://docs.aws.amazon.com/AmazonS3/latest/dev/using-with-s3-actions.html
+		return enforceBucketPolicy(bucket, policyAction, getResource(r.URL.Path, r.Host),
+			r.Referer(), r.URL.Query(), getRequestTags(r.Header))
+	}
+
+	// By default return ErrAccessDenied
+	return ErrAccessDenied
+}
+
+// checkRequestSignature - verifies the signature of a signed request,
+// actions of the signer are left to be allowed by the caller.
+func checkRequestSignature(r *http.Request, region string) APIErrorCode {
+	var s3Error APIErrorCode
+	switch getRequestAuthType(r) {
 	case authTypePresignedV2, authTypeSignedV2:
 		// Signature V2 validation.
-		s3Error := isReqAuthenticatedV2(r)
-		if s3Error != ErrNone {
-			errorIf(errSignatureMismatch, "%s", dumpRequest(r))
-		}
-		return s3Error
+		s3Error = isReqAuthenticatedV2(r)
 	case authTypeSigned, authTypePresigned:
-		s3Error := isReqAuthenticated(r, region)
+		s3Error = isReqAuthenticated(r, region)
+	default:
+		return ErrAccessDenied
+	}
+	if s3Error != ErrNone {
+		errorIf(errSignatureMismatch, "%s", dumpRequest(r))
+	}
+	return s3Error
+}
+
+// getReqAccessKey - returns the access key of a signed request, the
+// signature of the request is expected to be verified already.
+func getReqAccessKey(r *http.Request) string {
+	switch getRequestAuthType(r) {
+	case authTypeSignedV2:
+		v2Auth := strings.TrimPrefix(r.Header.Get("Authorization"), signV2Algorithm)
+		return strings.Split(strings.TrimSpace(v2Auth), ":")[0]
+	case authTypePresignedV2:
+		return r.URL.Query().Get("AWSAccessKeyId")
+	case authTypeSigned, authTypeStreamingSigned:
+		if signV4Values, s3Error := parseSignV4(r.Header.Get("Authorization")); s3Error == ErrNone {
+			return signV4Values.Credential.accessKey
+		}
+	case authTypePresigned:
+		if pSignValues, s3Error := parsePreSignV4(r.URL.Query()); s3Error == ErrNone {
+			return pSignValues.Credential.accessKey
+		}
+	}
+	return ""
+}
+
+// getReqSessionToken - returns the session token of a request signed
+// with temporary credentials, only signature V4 supports them.
+func getReqSessionToken(r *http.Request) string {
+	switch getRequestAuthType(r) {
+	case authTypeSigned, authTypeStreamingSigned:
+		return r.Header.Get(amzSecurityToken)
+	case authTypePresigned:
+		return r.URL.Query().Get(amzSecurityToken)
+	}
+	return ""
+}
+
+// isReqActionAllowed - verifies that the signer of a verified request
+// is allowed the policy action on the resource of the request.
+func isReqActionAllowed(r *http.Request, policyAction string) APIErrorCode {
+	return isActionAllowed(r, getReqAccessKey(r), getReqSessionToken(r), policyAction,
+		getResource(r.URL.Path, r.Host))
+}
+
+// isActionAllowed - verifies that the access key is allowed the policy
+// action on the resource, the server credential is allowed all actions.
+// Actions of other users are checked against their IAM policies, the
+// actions of temporary credentials are further limited by the policy
+// of their session.
+func isActionAllowed(r *http.Request, accessKey, sessionToken, policyAction, resource string) APIErrorCode {
+	if sessionToken == "" && accessKey == serverConfig.GetCredential().AccessKey {
+		return ErrNone
+	}
+	if policyAction == "" {
+		return ErrAccessDenied
+	}
+
+	referer, queryParams, requestTags := r.Referer(), r.URL.Query(), getRequestTags(r.Header)
+	if sessionToken != "" {
+		claims, s3Error := parseSessionToken(accessKey, sessionToken)
 		if s3Error != ErrNone {
-			errorIf(errSignatureMismatch, "%s", dumpRequest(r))
+			return s3Error
+		}
+		if claims.Policy != nil && !isIAMStatementsAllowed(claims.Policy.Statements, policyAction,
+			resource, referer, queryParams, requestTags) {
+			return ErrAccessDenied
 		}
-		return s3Error
+		accessKey = claims.Subject
 	}
 
-	if reqAuthType == authTypeAnonymous && policyAction != "" {
-		// http://docs.aws.amazon.com/AmazonS3/latest/dev/using-with-s3-actions.html
-		return enforceBucketPolicy(bucket, policyAction, r.URL.Path,
-			r.Referer(), r.URL.Query())
+	if !globalIAMSys.IsAllowed(accessKey, policyAction, resource, referer, queryParams, requestTags) {
+		return ErrAccessDenied
 	}
+	return ErrNone
+}
 
-	// By default return ErrAccessDenied
-	return ErrAccessDenied
+// getVersionAction - returns the policy action for an object request,
+// requests on a specific version of an object need the version action.
+func getVersionAction(policyAction, versionID string) string {
+	if versionID == "" {
+		return policyAction
+	}
+	switch policyAction {
+	case "s3:GetObject":
+		return "s3:GetObjectVersion"
+	case "s3:DeleteObject":
+		return "s3:DeleteObjectVersion"
+	}
+	return policyAction
+}
+
+// isCopySourceAllowed - verifies that the source object of a copy
+// request may be read, by the policies of the signer or for anonymous
+// requests by the policy of the source bucket.
+func isCopySourceAllowed(r *http.Request, srcBucket, srcObject, srcVersionID string) APIErrorCode {
+	resource := pathJoin(slashSeparator, srcBucket, srcObject)
+	policyAction := getVersionAction("s3:GetObject", srcVersionID)
+	if getRequestAuthType(r) == authTypeAnonymous {
+		return enforceBucketPolicy(srcBucket, policyAction, resource, r.Referer(), r.URL.Query(), nil)
+	}
+	return isActionAllowed(r, getReqAccessKey(r), getReqSessionToken(r), policyAction, resource)
 }
 
 // Verify if request has valid AWS Signature Version '2'.
@@ -146,7 +262,7 @@ func reqSignatureV4Verify(r *http.Request, region string) (s3Error APIErrorCode)
 	sha256sum := getContentSha256Cksum(r)
 	switch {
 	case isRequestSignatureV4(r):
-		return doesSignatureMatch(sha256sum, r, region)
+		return doesSignatureMatch(sha256sum, r, region, serviceS3)
 	case isRequestPresignedSignatureV4(r):
 		return doesPresignedSignatureMatch(sha256sum, r, region)
 	default:
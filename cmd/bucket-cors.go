@@ -0,0 +1,333 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/minio/minio/pkg/wildcard"
+)
+
+const (
+	// Bucket CORS config name.
+	bucketCorsConfig = "cors.xml"
+
+	// Maximum number of rules in a CORS configuration.
+	maxCorsRules = 100
+)
+
+// Bucket CORS configuration errors.
+var (
+	errNoSuchCorsConfig     = errors.New("The CORS configuration does not exist")
+	errCorsNoRules          = errors.New("CORS configuration should have at least one rule")
+	errCorsTooManyRules     = errors.New("CORS configuration should have at most 100 rules")
+	errCorsInvalidRule      = errors.New("CORS rule should have at least one allowed origin and one allowed method")
+	errCorsInvalidMethod    = errors.New("CORS rule allowed method should be one of GET, PUT, HEAD, POST or DELETE")
+	errCorsInvalidWildcard  = errors.New("CORS rule allowed origins and headers can contain at most one wildcard")
+	errCorsInvalidMaxAge    = errors.New("CORS rule max age should not be negative")
+	errCorsInvalidRuleIDLen = errors.New("CORS rule ID should be at most 255 characters")
+)
+
+// List of methods allowed in CORS rules.
+var corsAllowedMethods = map[string]bool{
+	httpGET:    true,
+	httpPUT:    true,
+	httpHEAD:   true,
+	httpPOST:   true,
+	httpDELETE: true,
+}
+
+// corsRule - a single CORS rule, granting cross-origin requests
+// from the allowed origins with the allowed methods and headers.
+type corsRule struct {
+	ID             string   `xml:"ID,omitempty"`
+	AllowedOrigins []string `xml:"AllowedOrigin"`
+	AllowedMethods []string `xml:"AllowedMethod"`
+	AllowedHeaders []string `xml:"AllowedHeader,omitempty"`
+	ExposeHeaders  []string `xml:"ExposeHeader,omitempty"`
+	MaxAgeSeconds  int      `xml:"MaxAgeSeconds,omitempty"`
+}
+
+// Validate - validates a CORS rule.
+func (c corsRule) Validate() error {
+	if len(c.ID) > 255 {
+		return errCorsInvalidRuleIDLen
+	}
+	if len(c.AllowedOrigins) == 0 || len(c.AllowedMethods) == 0 {
+		return errCorsInvalidRule
+	}
+	for _, method := range c.AllowedMethods {
+		if !corsAllowedMethods[method] {
+			return errCorsInvalidMethod
+		}
+	}
+	for _, patterns := range [][]string{c.AllowedOrigins, c.AllowedHeaders} {
+		for _, pattern := range patterns {
+			if strings.Count(pattern, "*") > 1 {
+				return errCorsInvalidWildcard
+			}
+		}
+	}
+	if c.MaxAgeSeconds < 0 {
+		return errCorsInvalidMaxAge
+	}
+	return nil
+}
+
+// matchOrigin - returns the allowed origin pattern matching the origin.
+func (c corsRule) matchOrigin(origin string) (pattern string, ok bool) {
+	for _, pattern = range c.AllowedOrigins {
+		if wildcard.MatchSimple(pattern, origin) {
+			return pattern, true
+		}
+	}
+	return "", false
+}
+
+// allowsMethod - returns true if the method is allowed by the rule.
+func (c corsRule) allowsMethod(method string) bool {
+	for _, allowedMethod := range c.AllowedMethods {
+		if allowedMethod == method {
+			return true
+		}
+	}
+	return false
+}
+
+// allowsHeaders - returns true if all headers are allowed by the rule,
+// header names are compared case insensitively.
+func (c corsRule) allowsHeaders(headers []string) bool {
+	for _, header := range headers {
+		allowed := false
+		for _, pattern := range c.AllowedHeaders {
+			if wildcard.MatchSimple(strings.ToLower(pattern), strings.ToLower(header)) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return false
+		}
+	}
+	return true
+}
+
+// corsConfiguration - represents the bucket CORS configuration
+// as sent by the PutBucketCors API.
+type corsConfiguration struct {
+	XMLName xml.Name   `xml:"CORSConfiguration"`
+	Rules   []corsRule `xml:"CORSRule"`
+}
+
+// Validate - validates CORS configuration.
+func (c corsConfiguration) Validate() error {
+	if len(c.Rules) == 0 {
+		return errCorsNoRules
+	}
+	if len(c.Rules) > maxCorsRules {
+		return errCorsTooManyRules
+	}
+	for _, rule := range c.Rules {
+		if err := rule.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findRule - returns the first rule allowing a cross-origin request
+// from origin with the method and headers, the returned pattern is the
+// allowed origin of the rule matching origin.
+func (c *corsConfiguration) findRule(origin, method string, headers []string) (rule *corsRule, pattern string) {
+	if c == nil {
+		return nil, ""
+	}
+	for i := range c.Rules {
+		pattern, ok := c.Rules[i].matchOrigin(origin)
+		if ok && c.Rules[i].allowsMethod(method) && c.Rules[i].allowsHeaders(headers) {
+			return &c.Rules[i], pattern
+		}
+	}
+	return nil, ""
+}
+
+// Variable represents bucket CORS configs in memory.
+var globalBucketCors *bucketCors
+
+// Global bucket CORS config list, consulted on each
+// cross-origin request to a bucket.
+type bucketCors struct {
+	rwMutex *sync.RWMutex
+
+	// Collection of 'bucket' CORS configs.
+	bucketCorsConfigs map[string]*corsConfiguration
+}
+
+// GetBucketCors - fetch CORS config for a given bucket.
+func (bc *bucketCors) GetBucketCors(bucket string) *corsConfiguration {
+	if bc == nil {
+		return nil
+	}
+	bc.rwMutex.RLock()
+	defer bc.rwMutex.RUnlock()
+	return bc.bucketCorsConfigs[bucket]
+}
+
+// SetBucketCors - set a new CORS config for a bucket, a nil
+// config removes any previous CORS config.
+func (bc *bucketCors) SetBucketCors(bucket string, ccfg *corsConfiguration) {
+	if bc == nil {
+		return
+	}
+	bc.rwMutex.Lock()
+	defer bc.rwMutex.Unlock()
+	if ccfg == nil {
+		delete(bc.bucketCorsConfigs, bucket)
+		return
+	}
+	bc.bucketCorsConfigs[bucket] = ccfg
+}
+
+// Intialize all bucket CORS configs.
+func initBucketCors(objAPI ObjectLayer) error {
+	if objAPI == nil {
+		return errInvalidArgument
+	}
+
+	// List buckets to proceed loading all CORS configuration.
+	buckets, err := objAPI.ListBuckets()
+	errorIf(err, "Unable to list buckets.")
+	if err != nil {
+		return errorCause(err)
+	}
+
+	configs := make(map[string]*corsConfiguration)
+	for _, bucket := range buckets {
+		ccfg, cErr := readBucketCors(bucket.Name, objAPI)
+		if cErr != nil {
+			// Ignore missing configs and disks which are not found.
+			if cErr == errNoSuchCorsConfig || isErrIgnored(cErr, errDiskNotFound) {
+				continue
+			}
+			return cErr
+		}
+		configs[bucket.Name] = ccfg
+	}
+
+	// Populate global bucket collection.
+	globalBucketCors = &bucketCors{
+		rwMutex:           &sync.RWMutex{},
+		bucketCorsConfigs: configs,
+	}
+
+	// Success.
+	return nil
+}
+
+// readBucketCors - reads bucket CORS config for an input bucket,
+// returns errNoSuchCorsConfig if the config is not found.
+func readBucketCors(bucket string, objAPI ObjectLayer) (*corsConfiguration, error) {
+	corsPath := pathJoin(bucketConfigPrefix, bucket, bucketCorsConfig)
+
+	// Acquire a read lock on CORS config before reading.
+	objLock := globalNSMutex.NewNSLock(minioMetaBucket, corsPath)
+	objLock.RLock()
+	defer objLock.RUnlock()
+
+	var buffer bytes.Buffer
+	err := objAPI.GetObject(minioMetaBucket, corsPath, 0, -1, &buffer)
+	if err != nil {
+		if isErrObjectNotFound(err) || isErrIncompleteBody(err) {
+			return nil, errNoSuchCorsConfig
+		}
+		errorIf(err, "Unable to load CORS config for the bucket %s.", bucket)
+		return nil, errorCause(err)
+	}
+
+	return parseBucketCors(&buffer)
+}
+
+// parseBucketCors - parses and validates CORS config.
+func parseBucketCors(reader io.Reader) (*corsConfiguration, error) {
+	ccfg := &corsConfiguration{}
+	if err := xml.NewDecoder(reader).Decode(ccfg); err != nil {
+		return nil, err
+	}
+	if err := ccfg.Validate(); err != nil {
+		return nil, err
+	}
+	return ccfg, nil
+}
+
+// writeBucketCors - save a bucket CORS config that is assumed
+// to be validated.
+func writeBucketCors(bucket string, objAPI ObjectLayer, ccfg *corsConfiguration) error {
+	buf, err := xml.Marshal(ccfg)
+	if err != nil {
+		errorIf(err, "Unable to marshal CORS config '%v' to XML", *ccfg)
+		return err
+	}
+	corsPath := pathJoin(bucketConfigPrefix, bucket, bucketCorsConfig)
+	// Acquire a write lock on CORS config before modifying.
+	objLock := globalNSMutex.NewNSLock(minioMetaBucket, corsPath)
+	objLock.Lock()
+	defer objLock.Unlock()
+	if _, err = objAPI.PutObject(minioMetaBucket, corsPath, int64(len(buf)), bytes.NewReader(buf), nil, ""); err != nil {
+		errorIf(err, "Unable to set CORS for the bucket %s", bucket)
+		return errorCause(err)
+	}
+	return nil
+}
+
+// removeBucketCors - removes any previously written CORS config.
+func removeBucketCors(bucket string, objAPI ObjectLayer) error {
+	corsPath := pathJoin(bucketConfigPrefix, bucket, bucketCorsConfig)
+	// Acquire a write lock on CORS config before modifying.
+	objLock := globalNSMutex.NewNSLock(minioMetaBucket, corsPath)
+	objLock.Lock()
+	defer objLock.Unlock()
+	if err := objAPI.DeleteObject(minioMetaBucket, corsPath); err != nil {
+		if isErrObjectNotFound(err) {
+			return errNoSuchCorsConfig
+		}
+		return errorCause(err)
+	}
+	return nil
+}
+
+// persistAndNotifyBucketCors - persists the CORS config and notifies
+// all nodes in the cluster about the change, a nil config removes the
+// persisted config. In-memory state is updated in response to the
+// notification.
+func persistAndNotifyBucketCors(bucket string, ccfg *corsConfiguration, objAPI ObjectLayer) error {
+	if ccfg == nil {
+		if err := removeBucketCors(bucket, objAPI); err != nil && err != errNoSuchCorsConfig {
+			return err
+		}
+	} else if err := writeBucketCors(bucket, objAPI, ccfg); err != nil {
+		return err
+	}
+
+	// Notify all peers (including self) to update in-memory state
+	S3PeersUpdateBucketCors(bucket, ccfg)
+	return nil
+}
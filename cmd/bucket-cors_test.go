@@ -0,0 +1,113 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+// Tests parsing and validation of bucket CORS configs.
+func TestParseBucketCors(t *testing.T) {
+	testCases := []struct {
+		config      string
+		expectedErr error
+	}{
+		// Single rule with all elements.
+		{`<CORSConfiguration><CORSRule><ID>web</ID><AllowedOrigin>https://*.example.com</AllowedOrigin><AllowedMethod>GET</AllowedMethod><AllowedMethod>PUT</AllowedMethod><AllowedHeader>*</AllowedHeader><ExposeHeader>ETag</ExposeHeader><MaxAgeSeconds>3000</MaxAgeSeconds></CORSRule></CORSConfiguration>`, nil},
+		// Any origin.
+		{`<CORSConfiguration><CORSRule><AllowedOrigin>*</AllowedOrigin><AllowedMethod>GET</AllowedMethod></CORSRule></CORSConfiguration>`, nil},
+		// No rules.
+		{`<CORSConfiguration></CORSConfiguration>`, errCorsNoRules},
+		// No allowed origin.
+		{`<CORSConfiguration><CORSRule><AllowedMethod>GET</AllowedMethod></CORSRule></CORSConfiguration>`, errCorsInvalidRule},
+		// No allowed method.
+		{`<CORSConfiguration><CORSRule><AllowedOrigin>*</AllowedOrigin></CORSRule></CORSConfiguration>`, errCorsInvalidRule},
+		// Unsupported method.
+		{`<CORSConfiguration><CORSRule><AllowedOrigin>*</AllowedOrigin><AllowedMethod>PATCH</AllowedMethod></CORSRule></CORSConfiguration>`, errCorsInvalidMethod},
+		// Multiple wildcards in an origin.
+		{`<CORSConfiguration><CORSRule><AllowedOrigin>https://*.*.com</AllowedOrigin><AllowedMethod>GET</AllowedMethod></CORSRule></CORSConfiguration>`, errCorsInvalidWildcard},
+		// Multiple wildcards in a header.
+		{`<CORSConfiguration><CORSRule><AllowedOrigin>*</AllowedOrigin><AllowedMethod>GET</AllowedMethod><AllowedHeader>x-*-*</AllowedHeader></CORSRule></CORSConfiguration>`, errCorsInvalidWildcard},
+		// Negative max age.
+		{`<CORSConfiguration><CORSRule><AllowedOrigin>*</AllowedOrigin><AllowedMethod>GET</AllowedMethod><MaxAgeSeconds>-1</MaxAgeSeconds></CORSRule></CORSConfiguration>`, errCorsInvalidMaxAge},
+		// Too many rules.
+		{`<CORSConfiguration>` + strings.Repeat(`<CORSRule><AllowedOrigin>*</AllowedOrigin><AllowedMethod>GET</AllowedMethod></CORSRule>`, maxCorsRules+1) + `</CORSConfiguration>`, errCorsTooManyRules},
+	}
+
+	for i, testCase := range testCases {
+		_, err := parseBucketCors(strings.NewReader(testCase.config))
+		if err != testCase.expectedErr {
+			t.Errorf("Test %d: Expected error %v, got %v", i+1, testCase.expectedErr, err)
+		}
+	}
+}
+
+// Tests finding the rule allowing a cross-origin request.
+func TestCorsFindRule(t *testing.T) {
+	ccfg := &corsConfiguration{
+		Rules: []corsRule{
+			{
+				AllowedOrigins: []string{"https://*.example.com"},
+				AllowedMethods: []string{httpGET, httpPUT},
+				AllowedHeaders: []string{"Content-*", "x-amz-date"},
+			},
+			{
+				AllowedOrigins: []string{"*"},
+				AllowedMethods: []string{httpGET},
+			},
+		},
+	}
+
+	testCases := []struct {
+		origin  string
+		method  string
+		headers []string
+		rule    int
+		pattern string
+	}{
+		{"https://www.example.com", httpPUT, []string{"content-type", "X-Amz-Date"}, 0, "https://*.example.com"},
+		{"https://www.example.com", httpGET, nil, 0, "https://*.example.com"},
+		{"https://www.example.com", httpPUT, []string{"Authorization"}, -1, ""},
+		{"https://www.example.com", httpGET, []string{"Authorization"}, -1, ""},
+		{"https://www.example.org", httpGET, nil, 1, "*"},
+		{"https://www.example.org", httpPUT, nil, -1, ""},
+		{"http://www.example.com", httpDELETE, nil, -1, ""},
+	}
+
+	for i, testCase := range testCases {
+		rule, pattern := ccfg.findRule(testCase.origin, testCase.method, testCase.headers)
+		if testCase.rule == -1 {
+			if rule != nil {
+				t.Errorf("Test %d: Expected no matching rule, got %v", i+1, *rule)
+			}
+			continue
+		}
+		if rule != &ccfg.Rules[testCase.rule] {
+			t.Errorf("Test %d: Expected rule %d, got %v", i+1, testCase.rule, rule)
+		}
+		if pattern != testCase.pattern {
+			t.Errorf("Test %d: Expected pattern %s, got %s", i+1, testCase.pattern, pattern)
+		}
+	}
+
+	// Buckets without CORS configuration do not allow any request.
+	var noCfg *corsConfiguration
+	if rule, _ := noCfg.findRule("https://www.example.com", httpGET, nil); rule != nil {
+		t.Errorf("Expected no matching rule, got %v", *rule)
+	}
+}
@@ -0,0 +1,238 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"sync"
+)
+
+// Bucket encryption config name.
+const bucketEncryptionConfig = "encryption.xml"
+
+// Bucket encryption configuration errors.
+var (
+	errNoSuchEncryptionConfig      = errors.New("The bucket encryption configuration does not exist")
+	errEncryptionInvalidRules      = errors.New("Bucket encryption configuration should have exactly one rule")
+	errEncryptionInvalidAlgorithm  = errors.New("Bucket encryption algorithm must be AES256")
+	errEncryptionKMSKeyUnsupported = errors.New("Bucket encryption does not support a KMS master key ID")
+)
+
+// encryptionByDefault - the server side encryption applied to objects
+// stored without any encryption parameters.
+type encryptionByDefault struct {
+	SSEAlgorithm   string `xml:"SSEAlgorithm"`
+	KMSMasterKeyID string `xml:"KMSMasterKeyID,omitempty"`
+}
+
+// encryptionRule - a single bucket encryption rule.
+type encryptionRule struct {
+	ApplySSEByDefault encryptionByDefault `xml:"ApplyServerSideEncryptionByDefault"`
+}
+
+// bucketEncryptionConfiguration - represents the bucket encryption
+// configuration as sent by the PutBucketEncryption API.
+type bucketEncryptionConfiguration struct {
+	XMLName xml.Name         `xml:"ServerSideEncryptionConfiguration"`
+	Rules   []encryptionRule `xml:"Rule"`
+}
+
+// Validate - validates bucket encryption configuration, only SSE-S3
+// with the master key of the configured KMS is supported.
+func (e bucketEncryptionConfiguration) Validate() error {
+	if len(e.Rules) != 1 {
+		return errEncryptionInvalidRules
+	}
+	if e.Rules[0].ApplySSEByDefault.SSEAlgorithm != SSEAlgorithmAES256 {
+		return errEncryptionInvalidAlgorithm
+	}
+	if e.Rules[0].ApplySSEByDefault.KMSMasterKeyID != "" {
+		return errEncryptionKMSKeyUnsupported
+	}
+	return nil
+}
+
+// Variable represents bucket encryption configs in memory.
+var globalBucketEncryption *bucketEncryption
+
+// Global bucket encryption config list, consulted on
+// each object upload without encryption parameters.
+type bucketEncryption struct {
+	rwMutex *sync.RWMutex
+
+	// Collection of 'bucket' encryption configs.
+	bucketEncryptionConfigs map[string]*bucketEncryptionConfiguration
+}
+
+// GetBucketEncryption - fetch encryption config for a given bucket.
+func (be *bucketEncryption) GetBucketEncryption(bucket string) *bucketEncryptionConfiguration {
+	if be == nil {
+		return nil
+	}
+	be.rwMutex.RLock()
+	defer be.rwMutex.RUnlock()
+	return be.bucketEncryptionConfigs[bucket]
+}
+
+// SetBucketEncryption - set a new encryption config for a bucket,
+// a nil config removes any previous encryption config.
+func (be *bucketEncryption) SetBucketEncryption(bucket string, ecfg *bucketEncryptionConfiguration) {
+	if be == nil {
+		return
+	}
+	be.rwMutex.Lock()
+	defer be.rwMutex.Unlock()
+	if ecfg == nil {
+		delete(be.bucketEncryptionConfigs, bucket)
+		return
+	}
+	be.bucketEncryptionConfigs[bucket] = ecfg
+}
+
+// isBucketEncryptionEnabled - returns true if objects written into the
+// bucket are encrypted by default.
+func isBucketEncryptionEnabled(bucket string) bool {
+	return globalBucketEncryption.GetBucketEncryption(bucket) != nil
+}
+
+// Intialize all bucket encryption configs.
+func initBucketEncryption(objAPI ObjectLayer) error {
+	if objAPI == nil {
+		return errInvalidArgument
+	}
+
+	// List buckets to proceed loading all encryption configuration.
+	buckets, err := objAPI.ListBuckets()
+	errorIf(err, "Unable to list buckets.")
+	if err != nil {
+		return errorCause(err)
+	}
+
+	configs := make(map[string]*bucketEncryptionConfiguration)
+	for _, bucket := range buckets {
+		ecfg, eErr := readBucketEncryption(bucket.Name, objAPI)
+		if eErr != nil {
+			// Ignore missing configs and disks which are not found.
+			if eErr == errNoSuchEncryptionConfig || isErrIgnored(eErr, errDiskNotFound) {
+				continue
+			}
+			return eErr
+		}
+		configs[bucket.Name] = ecfg
+	}
+
+	// Populate global bucket collection.
+	globalBucketEncryption = &bucketEncryption{
+		rwMutex:                 &sync.RWMutex{},
+		bucketEncryptionConfigs: configs,
+	}
+
+	// Success.
+	return nil
+}
+
+// readBucketEncryption - reads bucket encryption config for an input bucket,
+// returns errNoSuchEncryptionConfig if the config is not found.
+func readBucketEncryption(bucket string, objAPI ObjectLayer) (*bucketEncryptionConfiguration, error) {
+	encryptionPath := pathJoin(bucketConfigPrefix, bucket, bucketEncryptionConfig)
+
+	// Acquire a read lock on encryption config before reading.
+	objLock := globalNSMutex.NewNSLock(minioMetaBucket, encryptionPath)
+	objLock.RLock()
+	defer objLock.RUnlock()
+
+	var buffer bytes.Buffer
+	err := objAPI.GetObject(minioMetaBucket, encryptionPath, 0, -1, &buffer)
+	if err != nil {
+		if isErrObjectNotFound(err) || isErrIncompleteBody(err) {
+			return nil, errNoSuchEncryptionConfig
+		}
+		errorIf(err, "Unable to load encryption config for the bucket %s.", bucket)
+		return nil, errorCause(err)
+	}
+
+	return parseBucketEncryption(&buffer)
+}
+
+// parseBucketEncryption - parses and validates encryption config.
+func parseBucketEncryption(reader io.Reader) (*bucketEncryptionConfiguration, error) {
+	ecfg := &bucketEncryptionConfiguration{}
+	if err := xml.NewDecoder(reader).Decode(ecfg); err != nil {
+		return nil, err
+	}
+	if err := ecfg.Validate(); err != nil {
+		return nil, err
+	}
+	return ecfg, nil
+}
+
+// writeBucketEncryption - save a bucket encryption config that is
+// assumed to be validated.
+func writeBucketEncryption(bucket string, objAPI ObjectLayer, ecfg *bucketEncryptionConfiguration) error {
+	buf, err := xml.Marshal(ecfg)
+	if err != nil {
+		errorIf(err, "Unable to marshal encryption config '%v' to XML", *ecfg)
+		return err
+	}
+	encryptionPath := pathJoin(bucketConfigPrefix, bucket, bucketEncryptionConfig)
+	// Acquire a write lock on encryption config before modifying.
+	objLock := globalNSMutex.NewNSLock(minioMetaBucket, encryptionPath)
+	objLock.Lock()
+	defer objLock.Unlock()
+	if _, err = objAPI.PutObject(minioMetaBucket, encryptionPath, int64(len(buf)), bytes.NewReader(buf), nil, ""); err != nil {
+		errorIf(err, "Unable to set encryption for the bucket %s", bucket)
+		return errorCause(err)
+	}
+	return nil
+}
+
+// removeBucketEncryption - removes any previously written encryption config.
+func removeBucketEncryption(bucket string, objAPI ObjectLayer) error {
+	encryptionPath := pathJoin(bucketConfigPrefix, bucket, bucketEncryptionConfig)
+	// Acquire a write lock on encryption config before modifying.
+	objLock := globalNSMutex.NewNSLock(minioMetaBucket, encryptionPath)
+	objLock.Lock()
+	defer objLock.Unlock()
+	if err := objAPI.DeleteObject(minioMetaBucket, encryptionPath); err != nil {
+		if isErrObjectNotFound(err) {
+			return errNoSuchEncryptionConfig
+		}
+		return errorCause(err)
+	}
+	return nil
+}
+
+// persistAndNotifyBucketEncryption - persists the encryption config and
+// notifies all nodes in the cluster about the change, a nil config
+// removes the persisted config. In-memory state is updated in response
+// to the notification.
+func persistAndNotifyBucketEncryption(bucket string, ecfg *bucketEncryptionConfiguration, objAPI ObjectLayer) error {
+	if ecfg == nil {
+		if err := removeBucketEncryption(bucket, objAPI); err != nil && err != errNoSuchEncryptionConfig {
+			return err
+		}
+	} else if err := writeBucketEncryption(bucket, objAPI, ecfg); err != nil {
+		return err
+	}
+
+	// Notify all peers (including self) to update in-memory state
+	S3PeersUpdateBucketEncryption(bucket, ecfg)
+	return nil
+}
@@ -150,3 +150,52 @@ func (api objectAPIHandlers) ListObjectsV1Handler(w http.ResponseWriter, r *http
 	// Write success response.
 	writeSuccessResponseXML(w, encodeResponse(response))
 }
+
+// ListObjectVersionsHandler - GET Bucket Object versions.
+// --------------------------
+// This implementation of the GET operation returns metadata about all
+// the versions of objects in a bucket, including delete markers. You
+// can use the request parameters as selection criteria to return
+// metadata about a subset of all the object versions.
+//
+func (api objectAPIHandlers) ListObjectVersionsHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+
+	objectAPI := api.ObjectAPI()
+	if objectAPI == nil {
+		writeErrorResponse(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	if s3Error := checkRequestAuthType(r, bucket, "s3:ListBucketVersions", serverConfig.GetRegion()); s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	// Extract all the listObjectVersions query params to their native values.
+	prefix, keyMarker, versionIDMarker, delimiter, maxKeys, _ := getListObjectVersionsArgs(r.URL.Query())
+
+	// Validate all the query params before beginning to serve the request.
+	if s3Error := validateListObjectsArgs(prefix, keyMarker, delimiter, maxKeys); s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	// Version ID marker is only valid along with a key marker.
+	if versionIDMarker != "" && keyMarker == "" {
+		writeErrorResponse(w, ErrInvalidVersionID, r.URL)
+		return
+	}
+
+	listVersionsInfo, err := objectAPI.ListObjectVersions(bucket, prefix, keyMarker, versionIDMarker, delimiter, maxKeys)
+	if err != nil {
+		errorIf(err, "Unable to list object versions.")
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+	response := generateListVersionsResponse(bucket, prefix, keyMarker, versionIDMarker, delimiter, maxKeys, listVersionsInfo)
+
+	// Write success response.
+	writeSuccessResponseXML(w, encodeResponse(response))
+}
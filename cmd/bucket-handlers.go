@@ -33,7 +33,7 @@ import (
 
 // http://docs.aws.amazon.com/AmazonS3/latest/dev/using-with-s3-actions.html
 // Enforces bucket policies for a bucket for a given tatusaction.
-func enforceBucketPolicy(bucket, action, resource, referer string, queryParams url.Values) (s3Error APIErrorCode) {
+func enforceBucketPolicy(bucket, action, resource, referer string, queryParams url.Values, requestTags map[string]string) (s3Error APIErrorCode) {
 	// Verify if bucket actually exists
 	if err := checkBucketExist(bucket, newObjectLayerFn()); err != nil {
 		err = errorCause(err)
@@ -64,8 +64,25 @@ func enforceBucketPolicy(bucket, action, resource, referer string, queryParams u
 	arn := bucketARNPrefix + strings.TrimSuffix(strings.TrimPrefix(resource, "/"), "/")
 
 	// Get conditions for policy verification.
+	conditionKeyMap := getPolicyConditions(resource, referer, queryParams, requestTags, *policy)
+
+	// Validate action, resource and conditions with current policy statements.
+	if !bucketPolicyEvalStatements(action, arn, conditionKeyMap, policy.Statements) {
+		return ErrAccessDenied
+	}
+	return ErrNone
+}
+
+// getPolicyConditions - returns the condition keys of a request on the
+// resource, the tags of an existing object are only looked up if the
+// policy has conditions on them.
+func getPolicyConditions(resource, referer string, queryParams url.Values, requestTags map[string]string, policy bucketPolicy) map[string]set.StringSet {
 	conditionKeyMap := make(map[string]set.StringSet)
 	for queryParam := range queryParams {
+		// Object tags are never taken from the query.
+		if _, _, ok := tagConditionKey("s3:" + queryParam); ok {
+			continue
+		}
 		conditionKeyMap[queryParam] = set.CreateStringSet(queryParams.Get(queryParam))
 	}
 
@@ -74,11 +91,22 @@ func enforceBucketPolicy(bucket, action, resource, referer string, queryParams u
 		conditionKeyMap["referer"] = set.CreateStringSet(referer)
 	}
 
-	// Validate action, resource and conditions with current policy statements.
-	if !bucketPolicyEvalStatements(action, arn, conditionKeyMap, policy.Statements) {
-		return ErrAccessDenied
+	// Add the tags of the request and of the existing object, the
+	// object is only looked up if the policy has conditions on its tags.
+	for key, value := range requestTags {
+		conditionKeyMap[strings.TrimPrefix(requestObjectTagConditionPrefix, "s3:")+key] = set.CreateStringSet(value)
 	}
-	return ErrNone
+	if policy.hasConditionKeyPrefix(existingObjectTagConditionPrefix) {
+		if bucket, object := path2BucketAndObject(resource); object != "" {
+			objInfo, err := getObjectVersionInfo(newObjectLayerFn(), bucket, object, queryParams.Get("versionId"))
+			if err == nil && !objInfo.DeleteMarker {
+				for key, value := range getObjectTags(objInfo.UserDefined) {
+					conditionKeyMap[strings.TrimPrefix(existingObjectTagConditionPrefix, "s3:")+key] = set.CreateStringSet(value)
+				}
+			}
+		}
+	}
+	return conditionKeyMap
 }
 
 // Check if the action is allowed on the bucket/prefix.
@@ -201,11 +229,11 @@ func (api objectAPIHandlers) ListBucketsHandler(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	// ListBuckets does not have any bucket action.
-	s3Error := checkRequestAuthType(r, "", "", globalMinioDefaultRegion)
+	// ListBuckets is not allowed by bucket policies, only by IAM policies.
+	s3Error := checkRequestAuthType(r, "", "s3:ListAllMyBuckets", globalMinioDefaultRegion)
 	if s3Error == ErrInvalidRegion {
 		// Clients like boto3 send listBuckets() call signed with region that is configured.
-		s3Error = checkRequestAuthType(r, "", "", serverConfig.GetRegion())
+		s3Error = checkRequestAuthType(r, "", "s3:ListAllMyBuckets", serverConfig.GetRegion())
 	}
 	if s3Error != ErrNone {
 		writeErrorResponse(w, s3Error, r.URL)
@@ -238,7 +266,17 @@ func (api objectAPIHandlers) DeleteMultipleObjectsHandler(w http.ResponseWriter,
 		return
 	}
 
-	if s3Error := checkRequestAuthType(r, bucket, "s3:DeleteObject", serverConfig.GetRegion()); s3Error != ErrNone {
+	// Anonymous requests are allowed by the bucket policy, signed
+	// requests are allowed by the policies of the signer on every
+	// object to be deleted.
+	isAnonymous := getRequestAuthType(r) == authTypeAnonymous
+	var s3Error APIErrorCode
+	if isAnonymous {
+		s3Error = checkRequestAuthType(r, bucket, "s3:DeleteObject", serverConfig.GetRegion())
+	} else {
+		s3Error = checkRequestSignature(r, serverConfig.GetRegion())
+	}
+	if s3Error != ErrNone {
 		writeErrorResponse(w, s3Error, r.URL)
 		return
 	}
@@ -279,7 +317,23 @@ func (api objectAPIHandlers) DeleteMultipleObjectsHandler(w http.ResponseWriter,
 	var dErrs = make([]error, len(deleteObjects.Objects))
 
 	// Delete all requested objects in parallel.
+	accessKey, sessionToken := getReqAccessKey(r), getReqSessionToken(r)
 	for index, object := range deleteObjects.Objects {
+		// Deleting a specific version requires the version action.
+		policyAction := getVersionAction("s3:DeleteObject", object.VersionID)
+		resource := pathJoin(slashSeparator, bucket, object.ObjectName)
+		if isAnonymous {
+			s3Error = ErrNone
+			if object.VersionID != "" {
+				s3Error = enforceBucketPolicy(bucket, policyAction, resource, r.Referer(), r.URL.Query(), nil)
+			}
+		} else {
+			s3Error = isActionAllowed(r, accessKey, sessionToken, policyAction, resource)
+		}
+		if s3Error != ErrNone {
+			dErrs[index] = PrefixAccessDenied{Bucket: bucket, Object: object.ObjectName}
+			continue
+		}
 		wg.Add(1)
 		go func(i int, obj ObjectIdentifier) {
 			objectLock := globalNSMutex.NewNSLock(bucket, obj.ObjectName)
@@ -287,10 +341,19 @@ func (api objectAPIHandlers) DeleteMultipleObjectsHandler(w http.ResponseWriter,
 			defer objectLock.Unlock()
 			defer wg.Done()
 
-			dErr := objectAPI.DeleteObject(bucket, obj.ObjectName)
+			removed := removedObjectVersion(objectAPI, bucket, obj.ObjectName, obj.VersionID)
+			var dErr error
+			if obj.VersionID != "" {
+				_, dErr = objectAPI.DeleteObjectVersion(bucket, obj.ObjectName, obj.VersionID, false)
+			} else {
+				dErr = objectAPI.DeleteObject(bucket, obj.ObjectName)
+			}
 			if dErr != nil {
 				dErrs[i] = dErr
+				return
 			}
+			updateBucketUsage(objectAPI, bucket, removed, ObjectInfo{})
+			scheduleDeleteReplication(bucket, obj.ObjectName, obj.VersionID)
 		}(index, object)
 	}
 	wg.Wait()
@@ -311,7 +374,9 @@ func (api objectAPIHandlers) DeleteMultipleObjectsHandler(w http.ResponseWriter,
 			deletedObjects = append(deletedObjects, object)
 			continue
 		}
-		errorIf(err, "Unable to delete object. %s", object.ObjectName)
+		if _, ok := err.(PrefixAccessDenied); !ok {
+			errorIf(err, "Unable to delete object. %s", object.ObjectName)
+		}
 		// Error during delete should be collected separately.
 		deleteErrors = append(deleteErrors, DeleteError{
 			Code:    errorCodeResponse[toAPIErrorCode(err)].Code,
@@ -360,11 +425,11 @@ func (api objectAPIHandlers) PutBucketHandler(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	// PutBucket does not have any bucket action.
-	s3Error := checkRequestAuthType(r, "", "", globalMinioDefaultRegion)
+	// PutBucket is not allowed by bucket policies, only by IAM policies.
+	s3Error := checkRequestAuthType(r, "", "s3:CreateBucket", globalMinioDefaultRegion)
 	if s3Error == ErrInvalidRegion {
 		// Clients like boto3 send putBucket() call signed with region that is configured.
-		s3Error = checkRequestAuthType(r, "", "", serverConfig.GetRegion())
+		s3Error = checkRequestAuthType(r, "", "s3:CreateBucket", serverConfig.GetRegion())
 	}
 	if s3Error != ErrNone {
 		writeErrorResponse(w, s3Error, r.URL)
@@ -400,6 +465,19 @@ func (api objectAPIHandlers) PutBucketHandler(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	// Object lock requires versioning, which is enabled along with it.
+	if strings.EqualFold(r.Header.Get(AmzBucketObjectLockEnabled), "true") {
+		err = persistAndNotifyBucketVersioning(bucket, &versioningConfiguration{Status: versioningEnabled}, objectAPI)
+		if err == nil {
+			err = persistAndNotifyBucketObjectLock(bucket, &objectLockConfiguration{ObjectLockEnabled: objectLockEnabled}, objectAPI)
+		}
+		if err != nil {
+			errorIf(err, "Unable to enable object lock on the bucket.")
+			writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+			return
+		}
+	}
+
 	// Make sure to add Location information here only for bucket
 	w.Header().Set("Location", getLocation(r))
 
@@ -489,6 +567,14 @@ func (api objectAPIHandlers) PostPolicyBucketHandler(w http.ResponseWriter, r *h
 		return
 	}
 
+	// Verify that the signer may upload the object.
+	apiErr = isActionAllowed(r, getPolicyAccessKey(formValues), getPolicySessionToken(formValues),
+		"s3:PutObject", slashSeparator+bucket+slashSeparator+object)
+	if apiErr != ErrNone {
+		writeErrorResponse(w, apiErr, r.URL)
+		return
+	}
+
 	policyBytes, err := base64.StdEncoding.DecodeString(formValues.Get("Policy"))
 	if err != nil {
 		writeErrorResponse(w, ErrMalformedPOSTRequest, r.URL)
@@ -531,21 +617,38 @@ func (api objectAPIHandlers) PostPolicyBucketHandler(w http.ResponseWriter, r *h
 		writeErrorResponse(w, ErrInternalError, r.URL)
 		return
 	}
+	if err = extractObjectLockFromHeader(formValues, bucket, metadata); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
 	sha256sum := ""
 
+	// Mark the object for replication if the bucket is replicated.
+	setReplicationMetadata(bucket, object, metadata)
+
 	objectLock := globalNSMutex.NewNSLock(bucket, object)
 	objectLock.Lock()
 	defer objectLock.Unlock()
 
+	// Writes exceeding the hard quota of the bucket are rejected.
+	quota, s3Error := enforceBucketQuota(objectAPI, bucket, object, fileSize)
+	if s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+	defer quota.release()
+
 	objInfo, err := objectAPI.PutObject(bucket, object, fileSize, fileBody, metadata, sha256sum)
 	if err != nil {
 		errorIf(err, "Unable to create object.")
 		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
 		return
 	}
+	updateBucketUsage(objectAPI, bucket, quota.removed, objInfo)
+	scheduleObjectReplication(objInfo)
 
 	w.Header().Set("ETag", `"`+objInfo.ETag+`"`)
-	w.Header().Set("Location", getObjectLocation(bucket, object))
+	w.Header().Set("Location", getObjectLocation(r, bucket, object))
 
 	// Get host and port from Request.RemoteAddr.
 	host, port, err := net.SplitHostPort(r.RemoteAddr)
@@ -578,7 +681,7 @@ func (api objectAPIHandlers) PostPolicyBucketHandler(w http.ResponseWriter, r *h
 			Bucket:   objInfo.Bucket,
 			Key:      objInfo.Name,
 			ETag:     `"` + objInfo.ETag + `"`,
-			Location: getObjectLocation(objInfo.Bucket, objInfo.Name),
+			Location: getObjectLocation(r, objInfo.Bucket, objInfo.Name),
 		})
 		writeResponse(w, http.StatusCreated, resp, "application/xml")
 	case "200":
@@ -630,8 +733,8 @@ func (api objectAPIHandlers) DeleteBucketHandler(w http.ResponseWriter, r *http.
 		return
 	}
 
-	// DeleteBucket does not have any bucket action.
-	if s3Error := checkRequestAuthType(r, "", "", serverConfig.GetRegion()); s3Error != ErrNone {
+	// DeleteBucket is not allowed by bucket policies, only by IAM policies.
+	if s3Error := checkRequestAuthType(r, "", "s3:DeleteBucket", serverConfig.GetRegion()); s3Error != ErrNone {
 		writeErrorResponse(w, s3Error, r.URL)
 		return
 	}
@@ -668,6 +771,60 @@ func (api objectAPIHandlers) DeleteBucketHandler(w http.ResponseWriter, r *http.
 	// Notify all peers (including self) to update in-memory state
 	S3PeersUpdateBucketListener(bucket, []listenerConfig{})
 
+	// Delete versioning config, if present - ignore any errors.
+	_ = removeBucketVersioning(bucket, objectAPI)
+
+	// Notify all peers (including self) to update in-memory state
+	S3PeersUpdateBucketVersioning(bucket, nil)
+
+	// Delete lifecycle config, if present - ignore any errors.
+	_ = removeBucketLifecycle(bucket, objectAPI)
+
+	// Delete encryption config, if present - ignore any errors.
+	_ = removeBucketEncryption(bucket, objectAPI)
+
+	// Notify all peers (including self) to update in-memory state
+	S3PeersUpdateBucketEncryption(bucket, nil)
+
+	// Delete CORS config, if present - ignore any errors.
+	_ = removeBucketCors(bucket, objectAPI)
+
+	// Notify all peers (including self) to update in-memory state
+	S3PeersUpdateBucketCors(bucket, nil)
+
+	// Delete website config, if present - ignore any errors.
+	_ = removeBucketWebsite(bucket, objectAPI)
+
+	// Notify all peers (including self) to update in-memory state
+	S3PeersUpdateBucketWebsite(bucket, nil)
+
+	// Delete tagging config, if present - ignore any errors.
+	_ = removeBucketTagging(bucket, objectAPI)
+
+	// Delete object lock config, if present - ignore any errors.
+	_ = removeBucketObjectLock(bucket, objectAPI)
+
+	// Notify all peers (including self) to update in-memory state
+	S3PeersUpdateBucketObjectLock(bucket, nil)
+
+	// Delete quota config, if present - ignore any errors.
+	_ = removeBucketQuota(bucket, objectAPI)
+
+	// Notify all peers (including self) to update in-memory state
+	S3PeersUpdateBucketQuota(bucket, nil)
+
+	// Delete replication config, if present - ignore any errors.
+	_ = removeBucketReplication(bucket, objectAPI)
+
+	// Notify all peers (including self) to update in-memory state
+	S3PeersUpdateBucketReplication(bucket, nil)
+
+	// Delete logging config, if present - ignore any errors.
+	_ = removeBucketLogging(bucket, objectAPI)
+
+	// Notify all peers (including self) to update in-memory state
+	S3PeersUpdateBucketLogging(bucket, nil)
+
 	// Write success response.
 	writeSuccessNoContent(w)
 }
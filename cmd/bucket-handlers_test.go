@@ -645,7 +645,7 @@ func testAPIDeleteMultipleObjectsHandler(obj ObjectLayer, instanceType, bucketNa
 
 	getObjectIdentifierList := func(objectNames []string) (objectIdentifierList []ObjectIdentifier) {
 		for _, objectName := range objectNames {
-			objectIdentifierList = append(objectIdentifierList, ObjectIdentifier{objectName})
+			objectIdentifierList = append(objectIdentifierList, ObjectIdentifier{ObjectName: objectName})
 		}
 
 		return objectIdentifierList
@@ -0,0 +1,242 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/rand"
+	"time"
+)
+
+const (
+	// Interval between two lifecycle enforcement passes.
+	lifecycleEnforceInterval = 24 * time.Hour
+
+	// Lock held by the node running a lifecycle enforcement pass, so
+	// that only one node in a distributed setup works at a time.
+	lifecycleEnforceLock = "lifecycle.lock"
+
+	// Time of the last lifecycle enforcement pass, saved in the minio
+	// meta bucket.
+	lifecycleEnforceObjName = "lifecycle.json"
+)
+
+// lifecycleEnforceInfo - state of the lifecycle enforcement passes
+// shared by all nodes.
+type lifecycleEnforceInfo struct {
+	LastRun time.Time `json:"lastRun"`
+}
+
+// startLifecycleEnforcer - starts the background routine which applies
+// the lifecycle rules of all buckets once every interval.
+func startLifecycleEnforcer(objAPI ObjectLayer) {
+	go func() {
+		// Initialize a new ticker with a day between each ticks.
+		ticker := time.NewTicker(lifecycleEnforceInterval)
+		defer ticker.Stop()
+
+		// Start with random sleep time, so as to avoid "synchronous checks" between servers
+		time.Sleep(time.Duration(rand.Float64() * float64(time.Minute)))
+		for {
+			errorIf(runLifecycleEnforcer(objAPI), "Unable to enforce bucket lifecycle.")
+			select {
+			case <-ticker.C:
+			case <-globalServiceDoneCh:
+				return
+			}
+		}
+	}()
+}
+
+// runLifecycleEnforcer - applies the lifecycle rules of all buckets
+// unless another node has already done so during the current interval.
+func runLifecycleEnforcer(objAPI ObjectLayer) error {
+	enforceLock := globalNSMutex.NewNSLock(minioMetaBucket, lifecycleEnforceLock)
+	enforceLock.Lock()
+	defer enforceLock.Unlock()
+
+	prevInfo, err := readLifecycleEnforceInfo(objAPI)
+	if err != nil {
+		return err
+	}
+	if UTCNow().Sub(prevInfo.LastRun) < lifecycleEnforceInterval/2 {
+		return nil
+	}
+
+	now := UTCNow()
+	enforceLifecycle(objAPI, now)
+	return saveLifecycleEnforceInfo(objAPI, lifecycleEnforceInfo{LastRun: now})
+}
+
+// readLifecycleEnforceInfo - reads the state saved by the last
+// enforcement pass, an empty state is returned if no pass has
+// completed yet.
+func readLifecycleEnforceInfo(objAPI ObjectLayer) (lifecycleEnforceInfo, error) {
+	var info lifecycleEnforceInfo
+
+	var buffer bytes.Buffer
+	if err := objAPI.GetObject(minioMetaBucket, lifecycleEnforceObjName, 0, -1, &buffer); err != nil {
+		if isErrObjectNotFound(err) || isErrIncompleteBody(err) {
+			return info, nil
+		}
+		return info, errorCause(err)
+	}
+
+	err := json.Unmarshal(buffer.Bytes(), &info)
+	return info, err
+}
+
+// saveLifecycleEnforceInfo - saves the state of an enforcement pass.
+func saveLifecycleEnforceInfo(objAPI ObjectLayer, info lifecycleEnforceInfo) error {
+	buf, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+
+	if _, err = objAPI.PutObject(minioMetaBucket, lifecycleEnforceObjName, int64(len(buf)), bytes.NewReader(buf), nil, ""); err != nil {
+		return errorCause(err)
+	}
+	return nil
+}
+
+// enforceLifecycle - applies the lifecycle rules of all buckets
+// which have a lifecycle configuration, as of now.
+func enforceLifecycle(objAPI ObjectLayer, now time.Time) {
+	buckets, err := objAPI.ListBuckets()
+	if err != nil {
+		errorIf(err, "Unable to list buckets for lifecycle enforcement.")
+		return
+	}
+
+	for _, bucket := range buckets {
+		lcfg, err := readBucketLifecycle(bucket.Name, objAPI)
+		if err != nil {
+			if err != errNoSuchLifecycleConfig {
+				errorIf(err, "Unable to read lifecycle config for the bucket %s.", bucket.Name)
+			}
+			continue
+		}
+		for _, rule := range lcfg.Rules {
+			if rule.Status != lifecycleRuleEnabled {
+				continue
+			}
+			if rule.Expiration != nil {
+				errorIf(expireObjects(objAPI, bucket.Name, rule, now),
+					"Unable to expire objects in the bucket %s.", bucket.Name)
+			}
+			if rule.AbortIncompleteMultipartUpload != nil {
+				errorIf(abortStaleUploads(objAPI, bucket.Name, rule, now),
+					"Unable to abort incomplete uploads in the bucket %s.", bucket.Name)
+			}
+		}
+	}
+}
+
+// expireObjects - removes all objects matching the rule filter which
+// have expired, in versioned buckets a delete marker is added instead.
+func expireObjects(objAPI ObjectLayer, bucket string, rule lifecycleRule, now time.Time) error {
+	prefix := rule.KeyPrefix()
+	marker := ""
+	for {
+		result, err := objAPI.ListObjects(bucket, prefix, marker, "", maxObjectList)
+		if err != nil {
+			return err
+		}
+		for _, objInfo := range result.Objects {
+			if objInfo.IsDir || !rule.IsExpired(objInfo.ModTime, now) {
+				continue
+			}
+			if err = expireObject(objAPI, bucket, rule, objInfo, now); err != nil {
+				errorIf(err, "Unable to expire object %s.", pathJoin(bucket, objInfo.Name))
+			}
+		}
+		if !result.IsTruncated {
+			return nil
+		}
+		marker = result.NextMarker
+	}
+}
+
+// expireObject - removes a single object listed as expired, unless it
+// was overwritten since it was listed or doesn't have the tags of the
+// rule.
+func expireObject(objAPI ObjectLayer, bucket string, rule lifecycleRule, listed ObjectInfo, now time.Time) error {
+	object := listed.Name
+
+	// Acquire a write lock before deleting the object.
+	objectLock := globalNSMutex.NewNSLock(bucket, object)
+	objectLock.Lock()
+	defer objectLock.Unlock()
+
+	// Listings have no user metadata and the object may have been
+	// overwritten since it was listed, it is read again under the
+	// lock.
+	info, err := objAPI.GetObjectInfo(bucket, object)
+	if err != nil {
+		if isErrObjectNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if !info.ModTime.Equal(listed.ModTime) || info.ETag != listed.ETag {
+		return nil
+	}
+	if !rule.IsExpired(info.ModTime, now) || !rule.MatchTags(getObjectTags(info.UserDefined)) {
+		return nil
+	}
+
+	removed := removedObjectVersion(objAPI, bucket, object, "")
+	objInfo, err := objAPI.DeleteObjectVersion(bucket, object, "", false)
+	if err != nil {
+		return err
+	}
+	updateBucketUsage(objAPI, bucket, removed, ObjectInfo{})
+
+	// Notify object deleted event.
+	eventNotify(eventData{
+		Type:    ObjectRemovedDelete,
+		Bucket:  bucket,
+		ObjInfo: objInfo,
+	})
+	return nil
+}
+
+// abortStaleUploads - aborts all multipart uploads under the rule
+// prefix which were initiated too long ago.
+func abortStaleUploads(objAPI ObjectLayer, bucket string, rule lifecycleRule, now time.Time) error {
+	prefix := rule.KeyPrefix()
+	keyMarker, uploadIDMarker := "", ""
+	for {
+		result, err := objAPI.ListMultipartUploads(bucket, prefix, keyMarker, uploadIDMarker, "", maxUploadsList)
+		if err != nil {
+			return err
+		}
+		for _, upload := range result.Uploads {
+			if !rule.IsUploadStale(upload.Initiated, now) {
+				continue
+			}
+			if err = objAPI.AbortMultipartUpload(bucket, upload.Object, upload.UploadID); err != nil {
+				errorIf(err, "Unable to abort upload %s of %s.", upload.UploadID, pathJoin(bucket, upload.Object))
+			}
+		}
+		if !result.IsTruncated {
+			return nil
+		}
+		keyMarker, uploadIDMarker = result.NextKeyMarker, result.NextUploadIDMarker
+	}
+}
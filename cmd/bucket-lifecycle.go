@@ -0,0 +1,315 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"time"
+)
+
+const (
+	// Bucket lifecycle config name.
+	bucketLifecycleConfig = "lifecycle.xml"
+
+	// Maximum number of rules in a lifecycle configuration.
+	maxLifecycleRules = 1000
+
+	// Maximum length of a lifecycle rule ID.
+	maxLifecycleRuleIDLen = 255
+
+	// Lifecycle rule status values as defined by S3.
+	lifecycleRuleEnabled  = "Enabled"
+	lifecycleRuleDisabled = "Disabled"
+)
+
+// Lifecycle configuration errors.
+var (
+	errNoSuchLifecycleConfig       = errors.New("The bucket lifecycle configuration does not exist")
+	errLifecycleNoRules            = errors.New("Lifecycle configuration should have at least one rule")
+	errLifecycleTooManyRules       = errors.New("Lifecycle configuration allows a maximum of 1000 rules")
+	errLifecycleInvalidRuleID      = errors.New("Lifecycle rule ID must be at most 255 characters")
+	errLifecycleDuplicateRuleID    = errors.New("Lifecycle rule ID must be unique")
+	errLifecycleInvalidStatus      = errors.New("Lifecycle rule status must be Enabled or Disabled")
+	errLifecycleNoAction           = errors.New("Lifecycle rule should have at least one action")
+	errLifecycleInvalidExpiration  = errors.New("Lifecycle expiration should have exactly one of Days or Date")
+	errLifecycleInvalidDays        = errors.New("Lifecycle expiration days must be a positive integer")
+	errLifecycleInvalidDate        = errors.New("Lifecycle expiration date must be at midnight UTC")
+	errLifecycleInvalidAbortUpload = errors.New("Lifecycle DaysAfterInitiation must be a positive integer")
+	errLifecycleInvalidFilter      = errors.New("Lifecycle filter should have at most one of Prefix, Tag or And")
+	errLifecycleAbortUploadTags    = errors.New("Lifecycle AbortIncompleteMultipartUpload cannot be specified with tags")
+)
+
+// lifecycleFilter - object filter of a lifecycle rule, selecting
+// objects by key prefix, by a tag or by both through And.
+type lifecycleFilter struct {
+	Prefix string        `xml:"Prefix,omitempty"`
+	Tag    *tag          `xml:"Tag"`
+	And    *lifecycleAnd `xml:"And"`
+}
+
+// lifecycleAnd - selects objects matching a key prefix and all tags.
+type lifecycleAnd struct {
+	Prefix string `xml:"Prefix,omitempty"`
+	Tags   []tag  `xml:"Tag"`
+}
+
+// Validate - validates a lifecycle filter.
+func (f lifecycleFilter) Validate() error {
+	filters := 0
+	for _, set := range []bool{f.Prefix != "", f.Tag != nil, f.And != nil} {
+		if set {
+			filters++
+		}
+	}
+	if filters > 1 {
+		return errLifecycleInvalidFilter
+	}
+	if f.Tag != nil {
+		return f.Tag.Validate()
+	}
+	if f.And != nil {
+		return validateTags(f.And.Tags, maxObjectTags)
+	}
+	return nil
+}
+
+// lifecycleExpiration - expires current objects either a number of
+// days after their creation or at a specific date.
+type lifecycleExpiration struct {
+	Days int        `xml:"Days,omitempty"`
+	Date *time.Time `xml:"Date,omitempty"`
+}
+
+// lifecycleAbortIncompleteMultipartUpload - aborts multipart uploads
+// which were not completed within a number of days after initiation.
+type lifecycleAbortIncompleteMultipartUpload struct {
+	DaysAfterInitiation int `xml:"DaysAfterInitiation"`
+}
+
+// lifecycleRule - a single lifecycle rule, the key prefix may be
+// specified either directly or through a filter.
+type lifecycleRule struct {
+	ID                             string                                   `xml:"ID,omitempty"`
+	Prefix                         *string                                  `xml:"Prefix"`
+	Filter                         *lifecycleFilter                         `xml:"Filter"`
+	Status                         string                                   `xml:"Status"`
+	Expiration                     *lifecycleExpiration                     `xml:"Expiration"`
+	AbortIncompleteMultipartUpload *lifecycleAbortIncompleteMultipartUpload `xml:"AbortIncompleteMultipartUpload"`
+}
+
+// KeyPrefix - returns the object key prefix the rule applies to.
+func (r lifecycleRule) KeyPrefix() string {
+	if r.Filter != nil {
+		if r.Filter.And != nil {
+			return r.Filter.And.Prefix
+		}
+		return r.Filter.Prefix
+	}
+	if r.Prefix != nil {
+		return *r.Prefix
+	}
+	return ""
+}
+
+// Tags - returns the tags an object must have for the rule to
+// apply to it.
+func (r lifecycleRule) Tags() []tag {
+	switch {
+	case r.Filter == nil:
+		return nil
+	case r.Filter.Tag != nil:
+		return []tag{*r.Filter.Tag}
+	case r.Filter.And != nil:
+		return r.Filter.And.Tags
+	}
+	return nil
+}
+
+// MatchTags - returns true if the object tags include all tags of
+// the rule.
+func (r lifecycleRule) MatchTags(tags map[string]string) bool {
+	for _, t := range r.Tags() {
+		if value, ok := tags[t.Key]; !ok || value != t.Value {
+			return false
+		}
+	}
+	return true
+}
+
+// IsExpired - returns true if an object created at modTime has
+// expired according to this rule at the given time.
+func (r lifecycleRule) IsExpired(modTime, now time.Time) bool {
+	if r.Status != lifecycleRuleEnabled || r.Expiration == nil {
+		return false
+	}
+	if r.Expiration.Date != nil {
+		return !now.Before(*r.Expiration.Date)
+	}
+	return now.Sub(modTime) >= time.Duration(r.Expiration.Days)*24*time.Hour
+}
+
+// IsUploadStale - returns true if a multipart upload initiated at
+// the given time should be aborted according to this rule.
+func (r lifecycleRule) IsUploadStale(initiated, now time.Time) bool {
+	if r.Status != lifecycleRuleEnabled || r.AbortIncompleteMultipartUpload == nil {
+		return false
+	}
+	days := r.AbortIncompleteMultipartUpload.DaysAfterInitiation
+	return now.Sub(initiated) >= time.Duration(days)*24*time.Hour
+}
+
+// Validate - validates a lifecycle rule.
+func (r lifecycleRule) Validate() error {
+	if len(r.ID) > maxLifecycleRuleIDLen {
+		return errLifecycleInvalidRuleID
+	}
+	if r.Status != lifecycleRuleEnabled && r.Status != lifecycleRuleDisabled {
+		return errLifecycleInvalidStatus
+	}
+	if r.Expiration == nil && r.AbortIncompleteMultipartUpload == nil {
+		return errLifecycleNoAction
+	}
+	if r.Expiration != nil {
+		if (r.Expiration.Days == 0) == (r.Expiration.Date == nil) {
+			return errLifecycleInvalidExpiration
+		}
+		if r.Expiration.Days < 0 {
+			return errLifecycleInvalidDays
+		}
+		if date := r.Expiration.Date; date != nil && !date.Equal(date.UTC().Truncate(24*time.Hour)) {
+			return errLifecycleInvalidDate
+		}
+	}
+	if r.AbortIncompleteMultipartUpload != nil && r.AbortIncompleteMultipartUpload.DaysAfterInitiation <= 0 {
+		return errLifecycleInvalidAbortUpload
+	}
+	if r.Filter != nil {
+		if err := r.Filter.Validate(); err != nil {
+			return err
+		}
+	}
+	// Multipart uploads have no tags until they are completed.
+	if r.AbortIncompleteMultipartUpload != nil && len(r.Tags()) > 0 {
+		return errLifecycleAbortUploadTags
+	}
+	return nil
+}
+
+// lifecycleConfiguration - represents the bucket lifecycle
+// configuration as sent by the PutBucketLifecycle API.
+type lifecycleConfiguration struct {
+	XMLName xml.Name        `xml:"LifecycleConfiguration"`
+	Rules   []lifecycleRule `xml:"Rule"`
+}
+
+// Validate - validates lifecycle configuration.
+func (l lifecycleConfiguration) Validate() error {
+	if len(l.Rules) == 0 {
+		return errLifecycleNoRules
+	}
+	if len(l.Rules) > maxLifecycleRules {
+		return errLifecycleTooManyRules
+	}
+	ruleIDs := make(map[string]struct{})
+	for _, rule := range l.Rules {
+		if err := rule.Validate(); err != nil {
+			return err
+		}
+		if rule.ID == "" {
+			continue
+		}
+		if _, ok := ruleIDs[rule.ID]; ok {
+			return errLifecycleDuplicateRuleID
+		}
+		ruleIDs[rule.ID] = struct{}{}
+	}
+	return nil
+}
+
+// readBucketLifecycle - reads bucket lifecycle config for an input bucket,
+// returns errNoSuchLifecycleConfig if the config is not found.
+func readBucketLifecycle(bucket string, objAPI ObjectLayer) (*lifecycleConfiguration, error) {
+	lifecyclePath := pathJoin(bucketConfigPrefix, bucket, bucketLifecycleConfig)
+
+	// Acquire a read lock on lifecycle config before reading.
+	objLock := globalNSMutex.NewNSLock(minioMetaBucket, lifecyclePath)
+	objLock.RLock()
+	defer objLock.RUnlock()
+
+	var buffer bytes.Buffer
+	err := objAPI.GetObject(minioMetaBucket, lifecyclePath, 0, -1, &buffer)
+	if err != nil {
+		if isErrObjectNotFound(err) || isErrIncompleteBody(err) {
+			return nil, errNoSuchLifecycleConfig
+		}
+		errorIf(err, "Unable to load lifecycle config for the bucket %s.", bucket)
+		return nil, errorCause(err)
+	}
+
+	return parseBucketLifecycle(&buffer)
+}
+
+// parseBucketLifecycle - parses and validates lifecycle config.
+func parseBucketLifecycle(reader io.Reader) (*lifecycleConfiguration, error) {
+	lcfg := &lifecycleConfiguration{}
+	if err := xml.NewDecoder(reader).Decode(lcfg); err != nil {
+		return nil, err
+	}
+	if err := lcfg.Validate(); err != nil {
+		return nil, err
+	}
+	return lcfg, nil
+}
+
+// writeBucketLifecycle - save a bucket lifecycle config that is
+// assumed to be validated.
+func writeBucketLifecycle(bucket string, objAPI ObjectLayer, lcfg *lifecycleConfiguration) error {
+	buf, err := xml.Marshal(lcfg)
+	if err != nil {
+		errorIf(err, "Unable to marshal lifecycle config '%v' to XML", *lcfg)
+		return err
+	}
+	lifecyclePath := pathJoin(bucketConfigPrefix, bucket, bucketLifecycleConfig)
+	// Acquire a write lock on lifecycle config before modifying.
+	objLock := globalNSMutex.NewNSLock(minioMetaBucket, lifecyclePath)
+	objLock.Lock()
+	defer objLock.Unlock()
+	if _, err = objAPI.PutObject(minioMetaBucket, lifecyclePath, int64(len(buf)), bytes.NewReader(buf), nil, ""); err != nil {
+		errorIf(err, "Unable to set lifecycle for the bucket %s", bucket)
+		return errorCause(err)
+	}
+	return nil
+}
+
+// removeBucketLifecycle - removes any previously written lifecycle config.
+func removeBucketLifecycle(bucket string, objAPI ObjectLayer) error {
+	lifecyclePath := pathJoin(bucketConfigPrefix, bucket, bucketLifecycleConfig)
+	// Acquire a write lock on lifecycle config before modifying.
+	objLock := globalNSMutex.NewNSLock(minioMetaBucket, lifecyclePath)
+	objLock.Lock()
+	defer objLock.Unlock()
+	if err := objAPI.DeleteObject(minioMetaBucket, lifecyclePath); err != nil {
+		if isErrObjectNotFound(err) {
+			return errNoSuchLifecycleConfig
+		}
+		return errorCause(err)
+	}
+	return nil
+}
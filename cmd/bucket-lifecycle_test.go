@@ -0,0 +1,300 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Tests parsing and validation of bucket lifecycle configs.
+func TestParseBucketLifecycle(t *testing.T) {
+	testCases := []struct {
+		config      string
+		expectedErr error
+	}{
+		// Expiration in days with a filter.
+		{`<LifecycleConfiguration><Rule><ID>logs</ID><Filter><Prefix>logs/</Prefix></Filter><Status>Enabled</Status><Expiration><Days>30</Days></Expiration></Rule></LifecycleConfiguration>`, nil},
+		// Expiration at date with legacy prefix.
+		{`<LifecycleConfiguration><Rule><Prefix>tmp/</Prefix><Status>Disabled</Status><Expiration><Date>2017-12-31T00:00:00.000Z</Date></Expiration></Rule></LifecycleConfiguration>`, nil},
+		// Abort incomplete uploads only.
+		{`<LifecycleConfiguration><Rule><Status>Enabled</Status><AbortIncompleteMultipartUpload><DaysAfterInitiation>7</DaysAfterInitiation></AbortIncompleteMultipartUpload></Rule></LifecycleConfiguration>`, nil},
+		// No rules.
+		{`<LifecycleConfiguration></LifecycleConfiguration>`, errLifecycleNoRules},
+		// Invalid status.
+		{`<LifecycleConfiguration><Rule><Status>On</Status><Expiration><Days>1</Days></Expiration></Rule></LifecycleConfiguration>`, errLifecycleInvalidStatus},
+		// No action.
+		{`<LifecycleConfiguration><Rule><Status>Enabled</Status></Rule></LifecycleConfiguration>`, errLifecycleNoAction},
+		// Both days and date.
+		{`<LifecycleConfiguration><Rule><Status>Enabled</Status><Expiration><Days>1</Days><Date>2017-12-31T00:00:00Z</Date></Expiration></Rule></LifecycleConfiguration>`, errLifecycleInvalidExpiration},
+		// Negative days.
+		{`<LifecycleConfiguration><Rule><Status>Enabled</Status><Expiration><Days>-1</Days></Expiration></Rule></LifecycleConfiguration>`, errLifecycleInvalidDays},
+		// Date not at midnight.
+		{`<LifecycleConfiguration><Rule><Status>Enabled</Status><Expiration><Date>2017-12-31T10:00:00Z</Date></Expiration></Rule></LifecycleConfiguration>`, errLifecycleInvalidDate},
+		// Invalid abort days.
+		{`<LifecycleConfiguration><Rule><Status>Enabled</Status><AbortIncompleteMultipartUpload><DaysAfterInitiation>0</DaysAfterInitiation></AbortIncompleteMultipartUpload></Rule></LifecycleConfiguration>`, errLifecycleInvalidAbortUpload},
+		// Expiration of objects with a tag.
+		{`<LifecycleConfiguration><Rule><Filter><Tag><Key>retention</Key><Value>short</Value></Tag></Filter><Status>Enabled</Status><Expiration><Days>30</Days></Expiration></Rule></LifecycleConfiguration>`, nil},
+		// Expiration of objects with a prefix and tags.
+		{`<LifecycleConfiguration><Rule><Filter><And><Prefix>logs/</Prefix><Tag><Key>project</Key><Value>alpha</Value></Tag><Tag><Key>retention</Key><Value>short</Value></Tag></And></Filter><Status>Enabled</Status><Expiration><Days>30</Days></Expiration></Rule></LifecycleConfiguration>`, nil},
+		// Filter with both prefix and tag.
+		{`<LifecycleConfiguration><Rule><Filter><Prefix>logs/</Prefix><Tag><Key>retention</Key><Value>short</Value></Tag></Filter><Status>Enabled</Status><Expiration><Days>30</Days></Expiration></Rule></LifecycleConfiguration>`, errLifecycleInvalidFilter},
+		// Filter with an invalid tag.
+		{`<LifecycleConfiguration><Rule><Filter><Tag><Key>aws:retention</Key><Value>short</Value></Tag></Filter><Status>Enabled</Status><Expiration><Days>30</Days></Expiration></Rule></LifecycleConfiguration>`, errInvalidTagKey},
+		// Filter with duplicate tags.
+		{`<LifecycleConfiguration><Rule><Filter><And><Tag><Key>a</Key><Value>1</Value></Tag><Tag><Key>a</Key><Value>2</Value></Tag></And></Filter><Status>Enabled</Status><Expiration><Days>30</Days></Expiration></Rule></LifecycleConfiguration>`, errDuplicateTagKey},
+		// Abort incomplete uploads with a tag.
+		{`<LifecycleConfiguration><Rule><Filter><Tag><Key>retention</Key><Value>short</Value></Tag></Filter><Status>Enabled</Status><AbortIncompleteMultipartUpload><DaysAfterInitiation>7</DaysAfterInitiation></AbortIncompleteMultipartUpload></Rule></LifecycleConfiguration>`, errLifecycleAbortUploadTags},
+		// Duplicate rule IDs.
+		{`<LifecycleConfiguration><Rule><ID>a</ID><Status>Enabled</Status><Expiration><Days>1</Days></Expiration></Rule><Rule><ID>a</ID><Status>Enabled</Status><Expiration><Days>2</Days></Expiration></Rule></LifecycleConfiguration>`, errLifecycleDuplicateRuleID},
+	}
+
+	for i, testCase := range testCases {
+		_, err := parseBucketLifecycle(strings.NewReader(testCase.config))
+		if err != testCase.expectedErr {
+			t.Errorf("Test %d: Expected error %v, got %v", i+1, testCase.expectedErr, err)
+		}
+	}
+}
+
+// Wrapper for calling lifecycle enforcement tests for both XL and FS.
+func TestEnforceLifecycle(t *testing.T) {
+	ExecObjectLayerTest(t, testEnforceLifecycle)
+}
+
+// Tests that expired objects and stale uploads are removed.
+func testEnforceLifecycle(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	bucket := "lifecycle-bucket"
+	if err := obj.MakeBucketWithLocation(bucket, ""); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	for _, object := range []string{"logs/a", "logs/b", "data/c"} {
+		if _, err := obj.PutObject(bucket, object, 1, bytes.NewReader([]byte("a")), nil, ""); err != nil {
+			t.Fatalf("%s: %s", instanceType, err)
+		}
+	}
+	uploadID, err := obj.NewMultipartUpload(bucket, "data/d", nil)
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+
+	lcfg, err := parseBucketLifecycle(strings.NewReader(`<LifecycleConfiguration>
+<Rule><Filter><Prefix>logs/</Prefix></Filter><Status>Enabled</Status><Expiration><Days>2</Days></Expiration></Rule>
+<Rule><Prefix>data/</Prefix><Status>Enabled</Status><AbortIncompleteMultipartUpload><DaysAfterInitiation>1</DaysAfterInitiation></AbortIncompleteMultipartUpload></Rule>
+</LifecycleConfiguration>`))
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if err = writeBucketLifecycle(bucket, obj, lcfg); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+
+	// Nothing has expired yet.
+	enforceLifecycle(obj, UTCNow().Add(24*time.Hour))
+	result, err := obj.ListObjects(bucket, "", "", "", 1000)
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if len(result.Objects) != 3 {
+		t.Fatalf("%s: Expected 3 objects, got %d", instanceType, len(result.Objects))
+	}
+	if _, err = obj.ListObjectParts(bucket, "data/d", uploadID, 0, 1000); err == nil {
+		t.Fatalf("%s: Expected upload to be aborted", instanceType)
+	}
+
+	// Objects under `logs/` have expired.
+	enforceLifecycle(obj, UTCNow().Add(3*24*time.Hour))
+	result, err = obj.ListObjects(bucket, "", "", "", 1000)
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if len(result.Objects) != 1 || result.Objects[0].Name != "data/c" {
+		t.Fatalf("%s: Expected only `data/c` to remain, got %v", instanceType, result.Objects)
+	}
+}
+
+// Wrapper for calling lifecycle enforcer tests for both XL and FS.
+func TestRunLifecycleEnforcer(t *testing.T) {
+	ExecObjectLayerTest(t, testRunLifecycleEnforcer)
+}
+
+// Tests that a recent enforcement pass, possibly of another node,
+// is not repeated.
+func testRunLifecycleEnforcer(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	info, err := readLifecycleEnforceInfo(obj)
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if !info.LastRun.IsZero() {
+		t.Fatalf("%s: Expected no saved enforcement pass, got %v", instanceType, info)
+	}
+
+	if err = runLifecycleEnforcer(obj); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if info, err = readLifecycleEnforceInfo(obj); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if info.LastRun.IsZero() {
+		t.Fatalf("%s: Expected enforcement pass to be saved", instanceType)
+	}
+
+	if err = runLifecycleEnforcer(obj); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	lastInfo, err := readLifecycleEnforceInfo(obj)
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if !lastInfo.LastRun.Equal(info.LastRun) {
+		t.Fatalf("%s: Expected recent enforcement pass to be kept, got %v", instanceType, lastInfo)
+	}
+
+	// A pass older than the interval is repeated.
+	info.LastRun = UTCNow().Add(-lifecycleEnforceInterval)
+	if err = saveLifecycleEnforceInfo(obj, info); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if err = runLifecycleEnforcer(obj); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if lastInfo, err = readLifecycleEnforceInfo(obj); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if !lastInfo.LastRun.After(info.LastRun) {
+		t.Fatalf("%s: Expected stale enforcement pass to be repeated, got %v", instanceType, lastInfo)
+	}
+}
+
+// Wrapper for calling lifecycle tag filter tests for both XL and FS.
+func TestEnforceLifecycleTags(t *testing.T) {
+	ExecObjectLayerTest(t, testEnforceLifecycleTags)
+}
+
+// Tests that only expired objects with the tags of a rule are removed.
+func testEnforceLifecycleTags(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	bucket := "lifecycle-bucket"
+	if err := obj.MakeBucketWithLocation(bucket, ""); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	objects := map[string]string{
+		"logs/a": "project=alpha&retention=short",
+		"logs/b": "project=alpha&retention=long",
+		"logs/c": "",
+		"data/d": "project=alpha&retention=short",
+	}
+	for object, tags := range objects {
+		metadata := map[string]string{}
+		if tags != "" {
+			metadata[ObjectTagging] = tags
+		}
+		if _, err := obj.PutObject(bucket, object, 1, bytes.NewReader([]byte("a")), metadata, ""); err != nil {
+			t.Fatalf("%s: %s", instanceType, err)
+		}
+	}
+
+	lcfg, err := parseBucketLifecycle(strings.NewReader(`<LifecycleConfiguration>
+<Rule><Filter><And><Prefix>logs/</Prefix><Tag><Key>retention</Key><Value>short</Value></Tag></And></Filter><Status>Enabled</Status><Expiration><Days>1</Days></Expiration></Rule>
+</LifecycleConfiguration>`))
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if err = writeBucketLifecycle(bucket, obj, lcfg); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+
+	enforceLifecycle(obj, UTCNow().Add(2*24*time.Hour))
+	result, err := obj.ListObjects(bucket, "", "", "", 1000)
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	var names []string
+	for _, objInfo := range result.Objects {
+		names = append(names, objInfo.Name)
+	}
+	if strings.Join(names, ",") != "data/d,logs/b,logs/c" {
+		t.Fatalf("%s: Expected only `logs/a` to expire, got %v", instanceType, names)
+	}
+}
+
+// Wrapper for calling overwritten object expiry tests for both XL and FS.
+func TestExpireOverwrittenObject(t *testing.T) {
+	ExecObjectLayerTest(t, testExpireOverwrittenObject)
+}
+
+// Tests that objects overwritten after being listed as expired, or
+// whose tags no longer match the rule, are not removed.
+func testExpireOverwrittenObject(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	bucket := "lifecycle-bucket"
+	if err := obj.MakeBucketWithLocation(bucket, ""); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	lcfg, err := parseBucketLifecycle(strings.NewReader(`<LifecycleConfiguration>
+<Rule><Filter><And><Prefix>logs/</Prefix><Tag><Key>retention</Key><Value>short</Value></Tag></And></Filter><Status>Enabled</Status><Expiration><Days>1</Days></Expiration></Rule>
+</LifecycleConfiguration>`))
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	rule := lcfg.Rules[0]
+	now := UTCNow().Add(2 * 24 * time.Hour)
+
+	putObject := func(data, tags string) ObjectInfo {
+		objInfo, perr := obj.PutObject(bucket, "logs/a", int64(len(data)), bytes.NewReader([]byte(data)),
+			map[string]string{ObjectTagging: tags}, "")
+		if perr != nil {
+			t.Fatalf("%s: %s", instanceType, perr)
+		}
+		return objInfo
+	}
+	getData := func() string {
+		var buf bytes.Buffer
+		if gerr := obj.GetObject(bucket, "logs/a", 0, -1, &buf); gerr != nil {
+			t.Fatalf("%s: %s", instanceType, gerr)
+		}
+		return buf.String()
+	}
+
+	// Overwritten with new data.
+	listed := putObject("a", "retention=short")
+	putObject("bb", "retention=short")
+	if err = expireObject(obj, bucket, rule, listed, now); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if data := getData(); data != "bb" {
+		t.Fatalf("%s: Expected overwritten object to be kept, got %q", instanceType, data)
+	}
+
+	// Tags no longer matching the rule.
+	listed = putObject("c", "retention=long")
+	if err = expireObject(obj, bucket, rule, listed, now); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if data := getData(); data != "c" {
+		t.Fatalf("%s: Expected object without the rule tags to be kept, got %q", instanceType, data)
+	}
+
+	// Unchanged objects expire.
+	listed = putObject("d", "retention=short")
+	if err = expireObject(obj, bucket, rule, listed, now); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if _, err = obj.GetObjectInfo(bucket, "logs/a"); !isErrObjectNotFound(err) {
+		t.Fatalf("%s: Expected object to expire, got %v", instanceType, err)
+	}
+}
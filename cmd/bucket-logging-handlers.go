@@ -0,0 +1,143 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"encoding/xml"
+	"io"
+	"net/http"
+
+	humanize "github.com/dustin/go-humanize"
+	mux "github.com/gorilla/mux"
+)
+
+// maximum supported logging configuration size.
+const maxLoggingConfigSize = 20 * humanize.KiByte
+
+// PutBucketLoggingHandler - This implementation of the PUT
+// operation uses the logging subresource to enable or disable the
+// server access logs of an existing bucket.
+func (api objectAPIHandlers) PutBucketLoggingHandler(w http.ResponseWriter, r *http.Request) {
+	objectAPI := api.ObjectAPI()
+	if objectAPI == nil {
+		writeErrorResponse(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	if s3Error := checkRequestAuthType(r, "", "s3:PutBucketLogging", serverConfig.GetRegion()); s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+
+	_, err := objectAPI.GetBucketInfo(bucket)
+	if err != nil {
+		errorIf(err, "Unable to find bucket info.")
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	// If Content-Length is unknown or zero, deny the request.
+	// PutBucketLogging always needs a Content-Length.
+	if r.ContentLength == -1 || r.ContentLength == 0 {
+		writeErrorResponse(w, ErrMissingContentLength, r.URL)
+		return
+	}
+
+	// If Content-Length is greater than maximum allowed config size.
+	if r.ContentLength > maxLoggingConfigSize {
+		writeErrorResponse(w, ErrEntityTooLarge, r.URL)
+		return
+	}
+
+	status, err := parseBucketLogging(io.LimitReader(r.Body, r.ContentLength))
+	if err != nil {
+		errorIf(err, "Unable to parse logging configuration XML.")
+		writeErrorResponse(w, ErrMalformedXML, r.URL)
+		return
+	}
+
+	// Access logs are only written to existing buckets, which the
+	// requester may write the log objects to.
+	if lcfg := status.LoggingEnabled; lcfg != nil {
+		if _, err = objectAPI.GetBucketInfo(lcfg.TargetBucket); err != nil {
+			writeErrorResponse(w, ErrInvalidTargetBucketForLogging, r.URL)
+			return
+		}
+		// Log objects may have any name under the target prefix.
+		resource := slashSeparator + lcfg.TargetBucket + slashSeparator + lcfg.TargetPrefix + "*"
+		if isActionAllowed(r, getReqAccessKey(r), getReqSessionToken(r), "s3:PutObject", resource) != ErrNone {
+			writeErrorResponse(w, ErrInvalidTargetBucketForLogging, r.URL)
+			return
+		}
+	}
+
+	if err = persistAndNotifyBucketLogging(bucket, status.LoggingEnabled, objectAPI); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	// Success.
+	writeSuccessResponseHeadersOnly(w)
+}
+
+// GetBucketLoggingHandler - This implementation of the GET
+// operation uses the logging subresource to return the logging
+// status of a bucket, which is empty when logging is disabled.
+func (api objectAPIHandlers) GetBucketLoggingHandler(w http.ResponseWriter, r *http.Request) {
+	objectAPI := api.ObjectAPI()
+	if objectAPI == nil {
+		writeErrorResponse(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	if s3Error := checkRequestAuthType(r, "", "s3:GetBucketLogging", serverConfig.GetRegion()); s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+
+	_, err := objectAPI.GetBucketInfo(bucket)
+	if err != nil {
+		errorIf(err, "Unable to find bucket info.")
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	status := bucketLoggingStatus{}
+	status.LoggingEnabled, err = readBucketLogging(bucket, objectAPI)
+	if err != nil && err != errNoSuchLoggingConfig {
+		errorIf(err, "Unable to read logging configuration.")
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	loggingBytes, err := xml.Marshal(status)
+	if err != nil {
+		// For any marshalling failure.
+		errorIf(err, "Unable to marshal logging configuration into XML.")
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	// Success.
+	writeSuccessResponseXML(w, loggingBytes)
+}
@@ -0,0 +1,238 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"sync"
+)
+
+const (
+	// Bucket logging config name.
+	bucketLoggingConfig = "logging.xml"
+
+	// Maximum length of the prefix of the access log objects.
+	maxLoggingTargetPrefixLength = 512
+)
+
+// Bucket logging configuration errors.
+var (
+	errNoSuchLoggingConfig        = errors.New("The logging configuration does not exist")
+	errLoggingInvalidTargetBucket = errors.New("Logging target bucket should be a valid bucket name")
+	errLoggingInvalidTargetPrefix = errors.New("Logging target prefix should be at most 512 characters long")
+)
+
+// loggingEnabled - the bucket and prefix the access logs of a bucket
+// are written to.
+type loggingEnabled struct {
+	TargetBucket string `xml:"TargetBucket"`
+	TargetPrefix string `xml:"TargetPrefix"`
+}
+
+// bucketLoggingStatus - represents the bucket logging configuration
+// as sent by the PutBucketLogging API, logging is disabled when it
+// has no LoggingEnabled element.
+type bucketLoggingStatus struct {
+	XMLName        xml.Name        `xml:"BucketLoggingStatus"`
+	LoggingEnabled *loggingEnabled `xml:"LoggingEnabled,omitempty"`
+}
+
+// Validate - validates bucket logging configuration.
+func (s bucketLoggingStatus) Validate() error {
+	if s.LoggingEnabled == nil {
+		return nil
+	}
+	if !IsValidBucketName(s.LoggingEnabled.TargetBucket) {
+		return errLoggingInvalidTargetBucket
+	}
+	if len(s.LoggingEnabled.TargetPrefix) > maxLoggingTargetPrefixLength {
+		return errLoggingInvalidTargetPrefix
+	}
+	return nil
+}
+
+// Variable represents bucket logging configs in memory.
+var globalBucketLogging *bucketLogging
+
+// Global bucket logging config list, consulted on each request.
+type bucketLogging struct {
+	rwMutex *sync.RWMutex
+
+	// Collection of 'bucket' logging configs.
+	bucketLoggingConfigs map[string]*loggingEnabled
+}
+
+// GetBucketLogging - fetch logging config for a given bucket, nil if
+// access logging is disabled.
+func (bl *bucketLogging) GetBucketLogging(bucket string) *loggingEnabled {
+	if bl == nil {
+		return nil
+	}
+	bl.rwMutex.RLock()
+	defer bl.rwMutex.RUnlock()
+	return bl.bucketLoggingConfigs[bucket]
+}
+
+// SetBucketLogging - set a new logging config for a bucket, a nil
+// config disables access logging of the bucket.
+func (bl *bucketLogging) SetBucketLogging(bucket string, lcfg *loggingEnabled) {
+	if bl == nil {
+		return
+	}
+	bl.rwMutex.Lock()
+	defer bl.rwMutex.Unlock()
+	if lcfg == nil {
+		delete(bl.bucketLoggingConfigs, bucket)
+		return
+	}
+	bl.bucketLoggingConfigs[bucket] = lcfg
+}
+
+// Intialize all bucket logging configs.
+func initBucketLogging(objAPI ObjectLayer) error {
+	if objAPI == nil {
+		return errInvalidArgument
+	}
+
+	// List buckets to proceed loading all logging configuration.
+	buckets, err := objAPI.ListBuckets()
+	errorIf(err, "Unable to list buckets.")
+	if err != nil {
+		return errorCause(err)
+	}
+
+	configs := make(map[string]*loggingEnabled)
+	for _, bucket := range buckets {
+		lcfg, lErr := readBucketLogging(bucket.Name, objAPI)
+		if lErr != nil {
+			// Ignore missing configs and disks which are not found.
+			if lErr == errNoSuchLoggingConfig || isErrIgnored(lErr, errDiskNotFound) {
+				continue
+			}
+			return lErr
+		}
+		configs[bucket.Name] = lcfg
+	}
+
+	// Populate global bucket collection.
+	globalBucketLogging = &bucketLogging{
+		rwMutex:              &sync.RWMutex{},
+		bucketLoggingConfigs: configs,
+	}
+
+	// Success.
+	return nil
+}
+
+// readBucketLogging - reads bucket logging config for an input bucket,
+// returns errNoSuchLoggingConfig if the config is not found.
+func readBucketLogging(bucket string, objAPI ObjectLayer) (*loggingEnabled, error) {
+	loggingPath := pathJoin(bucketConfigPrefix, bucket, bucketLoggingConfig)
+
+	// Acquire a read lock on logging config before reading.
+	objLock := globalNSMutex.NewNSLock(minioMetaBucket, loggingPath)
+	objLock.RLock()
+	defer objLock.RUnlock()
+
+	var buffer bytes.Buffer
+	err := objAPI.GetObject(minioMetaBucket, loggingPath, 0, -1, &buffer)
+	if err != nil {
+		if isErrObjectNotFound(err) || isErrIncompleteBody(err) {
+			return nil, errNoSuchLoggingConfig
+		}
+		errorIf(err, "Unable to load logging config for the bucket %s.", bucket)
+		return nil, errorCause(err)
+	}
+
+	status, err := parseBucketLogging(&buffer)
+	if err != nil {
+		return nil, err
+	}
+	if status.LoggingEnabled == nil {
+		return nil, errNoSuchLoggingConfig
+	}
+	return status.LoggingEnabled, nil
+}
+
+// parseBucketLogging - parses and validates logging config.
+func parseBucketLogging(reader io.Reader) (*bucketLoggingStatus, error) {
+	status := &bucketLoggingStatus{}
+	if err := xml.NewDecoder(reader).Decode(status); err != nil {
+		return nil, err
+	}
+	if err := status.Validate(); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// writeBucketLogging - save a bucket logging config that is assumed
+// to be validated.
+func writeBucketLogging(bucket string, objAPI ObjectLayer, lcfg *loggingEnabled) error {
+	buf, err := xml.Marshal(bucketLoggingStatus{LoggingEnabled: lcfg})
+	if err != nil {
+		errorIf(err, "Unable to marshal logging config '%v' to XML", *lcfg)
+		return err
+	}
+	loggingPath := pathJoin(bucketConfigPrefix, bucket, bucketLoggingConfig)
+	// Acquire a write lock on logging config before modifying.
+	objLock := globalNSMutex.NewNSLock(minioMetaBucket, loggingPath)
+	objLock.Lock()
+	defer objLock.Unlock()
+	if _, err = objAPI.PutObject(minioMetaBucket, loggingPath, int64(len(buf)), bytes.NewReader(buf), nil, ""); err != nil {
+		errorIf(err, "Unable to set logging for the bucket %s", bucket)
+		return errorCause(err)
+	}
+	return nil
+}
+
+// removeBucketLogging - removes any previously written logging config.
+func removeBucketLogging(bucket string, objAPI ObjectLayer) error {
+	loggingPath := pathJoin(bucketConfigPrefix, bucket, bucketLoggingConfig)
+	// Acquire a write lock on logging config before modifying.
+	objLock := globalNSMutex.NewNSLock(minioMetaBucket, loggingPath)
+	objLock.Lock()
+	defer objLock.Unlock()
+	if err := objAPI.DeleteObject(minioMetaBucket, loggingPath); err != nil {
+		if isErrObjectNotFound(err) {
+			return errNoSuchLoggingConfig
+		}
+		return errorCause(err)
+	}
+	return nil
+}
+
+// persistAndNotifyBucketLogging - persists the logging config and
+// notifies all nodes in the cluster about the change, a nil config
+// removes the persisted config. In-memory state is updated in response
+// to the notification.
+func persistAndNotifyBucketLogging(bucket string, lcfg *loggingEnabled, objAPI ObjectLayer) error {
+	if lcfg == nil {
+		if err := removeBucketLogging(bucket, objAPI); err != nil && err != errNoSuchLoggingConfig {
+			return err
+		}
+	} else if err := writeBucketLogging(bucket, objAPI, lcfg); err != nil {
+		return err
+	}
+
+	// Notify all peers (including self) to update in-memory state
+	S3PeersUpdateBucketLogging(bucket, lcfg)
+	return nil
+}
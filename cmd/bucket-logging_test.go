@@ -0,0 +1,252 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Tests parsing and validation of bucket logging configs.
+func TestParseBucketLogging(t *testing.T) {
+	testCases := []struct {
+		config      string
+		expectedErr error
+		enabled     bool
+	}{
+		// Logging enabled.
+		{`<BucketLoggingStatus><LoggingEnabled><TargetBucket>logs</TargetBucket><TargetPrefix>photos/</TargetPrefix></LoggingEnabled></BucketLoggingStatus>`, nil, true},
+		// Logging enabled with the S3 namespace.
+		{`<BucketLoggingStatus xmlns="http://doc.s3.amazonaws.com/2006-03-01"><LoggingEnabled><TargetBucket>logs</TargetBucket></LoggingEnabled></BucketLoggingStatus>`, nil, true},
+		// Logging disabled.
+		{`<BucketLoggingStatus></BucketLoggingStatus>`, nil, false},
+		// Invalid target bucket.
+		{`<BucketLoggingStatus><LoggingEnabled><TargetBucket>Logs_</TargetBucket></LoggingEnabled></BucketLoggingStatus>`, errLoggingInvalidTargetBucket, false},
+		// Target prefix too long.
+		{`<BucketLoggingStatus><LoggingEnabled><TargetBucket>logs</TargetBucket><TargetPrefix>` + strings.Repeat("a", maxLoggingTargetPrefixLength+1) + `</TargetPrefix></LoggingEnabled></BucketLoggingStatus>`, errLoggingInvalidTargetPrefix, false},
+	}
+
+	for i, testCase := range testCases {
+		status, err := parseBucketLogging(strings.NewReader(testCase.config))
+		if err != testCase.expectedErr {
+			t.Errorf("Test %d: Expected error %v, got %v", i+1, testCase.expectedErr, err)
+			continue
+		}
+		if err == nil && (status.LoggingEnabled != nil) != testCase.enabled {
+			t.Errorf("Test %d: Expected logging enabled to be %t, got %v", i+1, testCase.enabled, status.LoggingEnabled)
+		}
+	}
+}
+
+// Tests the operations of requests reported in access logs.
+func TestAccessLogOperation(t *testing.T) {
+	testCases := []struct {
+		method    string
+		urlStr    string
+		copy      bool
+		object    string
+		operation string
+	}{
+		{"GET", "/bucket/object", false, "object", "REST.GET.OBJECT"},
+		{"PUT", "/bucket/object", false, "object", "REST.PUT.OBJECT"},
+		{"PUT", "/bucket/object", true, "object", "REST.COPY.OBJECT"},
+		{"PUT", "/bucket/object?partNumber=1&uploadId=abc", false, "object", "REST.PUT.PART"},
+		{"PUT", "/bucket/object?partNumber=1&uploadId=abc", true, "object", "REST.COPY.PART"},
+		{"POST", "/bucket/object?uploadId=abc", false, "object", "REST.POST.UPLOAD"},
+		{"GET", "/bucket", false, "", "REST.GET.BUCKET"},
+		{"GET", "/bucket?logging", false, "", "REST.GET.LOGGING_STATUS"},
+		{"POST", "/bucket?delete", false, "", "REST.POST.MULTI_OBJECT_DELETE"},
+		{"DELETE", "/bucket/object?tagging", false, "object", "REST.DELETE.TAGGING"},
+	}
+	for i, testCase := range testCases {
+		req, err := http.NewRequest(testCase.method, testCase.urlStr, nil)
+		if err != nil {
+			t.Fatalf("Test %d: %s", i+1, err)
+		}
+		if testCase.copy {
+			req.Header.Set("X-Amz-Copy-Source", "/bucket/source")
+		}
+		if operation := getAccessLogOperation(req, testCase.object); operation != testCase.operation {
+			t.Errorf("Test %d: Expected operation %s, got %s", i+1, testCase.operation, operation)
+		}
+	}
+}
+
+// Wrapper for calling bucket logging tests for both XL and FS.
+func TestBucketLogging(t *testing.T) {
+	ExecObjectLayerAPITest(t, testAPIBucketLogging, []string{"BucketLogging", "PutObject", "GetObject"})
+}
+
+// Tests enabling access logging and recording requests in log objects
+// of the target bucket.
+func testAPIBucketLogging(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials credential, t *testing.T) {
+	defer func(peers s3Peers) { globalS3Peers = peers }(globalS3Peers)
+	globalS3Peers = makeS3Peers(EndpointList{})
+	if err := initBucketLogging(obj); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	defer func(logs *accessLogs) { globalAccessLogs = logs }(globalAccessLogs)
+	globalAccessLogs = newAccessLogs(obj)
+	apiRouter = setHTTPStatsHandler(apiRouter)
+
+	targetBucket := "logs"
+	if err := obj.MakeBucketWithLocation(targetBucket, ""); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+
+	// sendRequest - sends a signed request and returns the response.
+	sendRequest := func(method, urlStr, body string) *httptest.ResponseRecorder {
+		req, err := newTestSignedRequestV4(method, urlStr, int64(len(body)), strings.NewReader(body),
+			credentials.AccessKey, credentials.SecretKey)
+		if err != nil {
+			t.Fatalf("%s: Failed to create HTTP request: %v", instanceType, err)
+		}
+		rec := httptest.NewRecorder()
+		apiRouter.ServeHTTP(rec, req)
+		return rec
+	}
+	loggingURL := makeTestTargetURL("", bucketName, "", map[string][]string{"logging": {""}})
+	// getLoggingStatus - returns the logging status of the bucket.
+	getLoggingStatus := func() bucketLoggingStatus {
+		rec := sendRequest("GET", loggingURL, "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, rec.Code)
+		}
+		var status bucketLoggingStatus
+		if err := xml.Unmarshal(rec.Body.Bytes(), &status); err != nil {
+			t.Fatalf("%s: %v", instanceType, err)
+		}
+		return status
+	}
+
+	// Logging is disabled by default.
+	if status := getLoggingStatus(); status.LoggingEnabled != nil {
+		t.Fatalf("%s: Expected logging to be disabled, got %v", instanceType, *status.LoggingEnabled)
+	}
+
+	// Access logs are only written to existing buckets.
+	config := `<BucketLoggingStatus><LoggingEnabled><TargetBucket>missing</TargetBucket></LoggingEnabled></BucketLoggingStatus>`
+	if rec := sendRequest("PUT", loggingURL, config); rec.Code != http.StatusBadRequest {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusBadRequest, rec.Code)
+	}
+
+	config = `<BucketLoggingStatus><LoggingEnabled><TargetBucket>logs</TargetBucket><TargetPrefix>access/</TargetPrefix></LoggingEnabled></BucketLoggingStatus>`
+	if rec := sendRequest("PUT", loggingURL, config); rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, rec.Code)
+	}
+	if status := getLoggingStatus(); status.LoggingEnabled == nil || *status.LoggingEnabled != (loggingEnabled{"logs", "access/"}) {
+		t.Fatalf("%s: Expected logging to be enabled, got %v", instanceType, status.LoggingEnabled)
+	}
+
+	// Requests are recorded, including failed ones.
+	if rec := sendRequest("PUT", getPutObjectURL("", bucketName, "object"), "hello"); rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, rec.Code)
+	}
+	if rec := sendRequest("GET", getGetObjectURL("", bucketName, "object"), ""); rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, rec.Code)
+	}
+	if rec := sendRequest("GET", getGetObjectURL("", bucketName, "missing"), ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusNotFound, rec.Code)
+	}
+	globalAccessLogs.flush()
+
+	result, err := obj.ListObjects(targetBucket, "access/", "", "", 10)
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if len(result.Objects) != 1 {
+		t.Fatalf("%s: Expected 1 access log object, got %d", instanceType, len(result.Objects))
+	}
+	var buffer bytes.Buffer
+	if err = obj.GetObject(targetBucket, result.Objects[0].Name, 0, -1, &buffer); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	lines := strings.Split(strings.TrimSuffix(buffer.String(), "\n"), "\n")
+	expectedLines := []string{
+		" REST.PUT.LOGGING_STATUS - \"PUT /" + bucketName + "/?logging= HTTP/1.1\" 200 - 0 - ",
+		" REST.GET.LOGGING_STATUS - \"GET /" + bucketName + "/?logging= HTTP/1.1\" 200 - ",
+		" REST.PUT.OBJECT object \"PUT /" + bucketName + "/object HTTP/1.1\" 200 - 0 - ",
+		" REST.GET.OBJECT object \"GET /" + bucketName + "/object HTTP/1.1\" 200 - 5 - ",
+		" REST.GET.OBJECT missing \"GET /" + bucketName + "/missing HTTP/1.1\" 404 - ",
+	}
+	if len(lines) != len(expectedLines) {
+		t.Fatalf("%s: Expected %d access log lines, got %q", instanceType, len(expectedLines), lines)
+	}
+	for i, expected := range expectedLines {
+		if !strings.HasPrefix(lines[i], "- "+bucketName+" [") || !strings.Contains(lines[i], " "+credentials.AccessKey+" ") ||
+			!strings.Contains(lines[i], expected) {
+			t.Errorf("%s: Expected access log line with %q, got %q", instanceType, expected, lines[i])
+		}
+	}
+
+	// Requests are no longer recorded once logging is disabled.
+	if rec := sendRequest("PUT", loggingURL, `<BucketLoggingStatus></BucketLoggingStatus>`); rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, rec.Code)
+	}
+	if status := getLoggingStatus(); status.LoggingEnabled != nil {
+		t.Fatalf("%s: Expected logging to be disabled, got %v", instanceType, *status.LoggingEnabled)
+	}
+	if rec := sendRequest("GET", getGetObjectURL("", bucketName, "object"), ""); rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, rec.Code)
+	}
+	globalAccessLogs.flush()
+	if result, err = obj.ListObjects(targetBucket, "access/", "", "", 10); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if len(result.Objects) != 1 {
+		t.Fatalf("%s: Expected no new access log object, got %d objects", instanceType, len(result.Objects))
+	}
+
+	// Users may only log to target buckets they are allowed to write.
+	loggingPolicy, err := parseIAMPolicy(strings.NewReader(`{"Version":"2012-10-17","Statement":[` +
+		`{"Effect":"Allow","Action":["s3:PutBucketLogging"],"Resource":["arn:aws:s3:::` + bucketName + `"]},` +
+		`{"Effect":"Allow","Action":["s3:PutObject"],"Resource":["arn:aws:s3:::` + targetBucket + `/own/*"]}]}`))
+	if err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	defer func(sys *iamSys) { globalIAMSys = sys }(globalIAMSys)
+	iamConfig := newIAMConfig()
+	iamConfig.Users["logger"] = iamUser{SecretKey: "logger123", Status: iamUserEnabled, Policy: "logging"}
+	iamConfig.Policies["logging"] = loggingPolicy
+	globalIAMSys = &iamSys{config: iamConfig}
+	testCases := []struct {
+		config string
+		status int
+	}{
+		{`<BucketLoggingStatus><LoggingEnabled><TargetBucket>logs</TargetBucket><TargetPrefix>own/</TargetPrefix></LoggingEnabled></BucketLoggingStatus>`, http.StatusOK},
+		{`<BucketLoggingStatus><LoggingEnabled><TargetBucket>logs</TargetBucket><TargetPrefix>other/</TargetPrefix></LoggingEnabled></BucketLoggingStatus>`, http.StatusBadRequest},
+		{`<BucketLoggingStatus><LoggingEnabled><TargetBucket>logs</TargetBucket></LoggingEnabled></BucketLoggingStatus>`, http.StatusBadRequest},
+		{`<BucketLoggingStatus><LoggingEnabled><TargetBucket>` + bucketName + `</TargetBucket><TargetPrefix>own/</TargetPrefix></LoggingEnabled></BucketLoggingStatus>`, http.StatusBadRequest},
+	}
+	for i, testCase := range testCases {
+		req, rerr := newTestSignedRequestV4("PUT", loggingURL, int64(len(testCase.config)),
+			strings.NewReader(testCase.config), "logger", "logger123")
+		if rerr != nil {
+			t.Fatalf("%s: Failed to create HTTP request: %v", instanceType, rerr)
+		}
+		rec := httptest.NewRecorder()
+		apiRouter.ServeHTTP(rec, req)
+		if rec.Code != testCase.status {
+			t.Errorf("%s: Test %d: Expected status %d, got %d", instanceType, i+1, testCase.status, rec.Code)
+		}
+	}
+}
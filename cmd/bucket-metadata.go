@@ -30,6 +30,36 @@ type BucketMetaState interface {
 	// Updates bucket policy
 	UpdateBucketPolicy(args *SetBucketPolicyPeerArgs) error
 
+	// Updates bucket versioning
+	UpdateBucketVersioning(args *SetBucketVersioningPeerArgs) error
+
+	// Updates bucket encryption
+	UpdateBucketEncryption(args *SetBucketEncryptionPeerArgs) error
+
+	// Updates bucket CORS
+	UpdateBucketCors(args *SetBucketCorsPeerArgs) error
+
+	// Updates bucket website
+	UpdateBucketWebsite(args *SetBucketWebsitePeerArgs) error
+
+	// Updates bucket object lock
+	UpdateBucketObjectLock(args *SetBucketObjectLockPeerArgs) error
+
+	// Updates bucket quota config and recomputes bucket usage
+	UpdateBucketQuota(args *SetBucketQuotaPeerArgs) error
+
+	// Adds to bucket usage
+	UpdateBucketUsage(args *SetBucketUsagePeerArgs) error
+
+	// Updates bucket replication
+	UpdateBucketReplication(args *SetBucketReplicationPeerArgs) error
+
+	// Updates bucket logging
+	UpdateBucketLogging(args *SetBucketLoggingPeerArgs) error
+
+	// Reloads IAM config
+	LoadIAM(args *LoadIAMPeerArgs) error
+
 	// Sends event
 	SendEvent(args *EventArgs) error
 }
@@ -88,6 +118,145 @@ func (lc *localBucketMetaState) UpdateBucketPolicy(args *SetBucketPolicyPeerArgs
 	return globalBucketPolicies.SetBucketPolicy(args.Bucket, pCh)
 }
 
+// localBucketMetaState.UpdateBucketVersioning - updates in-memory global bucket
+// versioning info.
+func (lc *localBucketMetaState) UpdateBucketVersioning(args *SetBucketVersioningPeerArgs) error {
+	// check if object layer is available.
+	objAPI := lc.ObjectAPI()
+	if objAPI == nil {
+		return errServerNotInitialized
+	}
+
+	globalBucketVersioning.SetBucketVersioning(args.Bucket, args.VCfg)
+	return nil
+}
+
+// localBucketMetaState.UpdateBucketEncryption - updates in-memory global bucket
+// encryption info.
+func (lc *localBucketMetaState) UpdateBucketEncryption(args *SetBucketEncryptionPeerArgs) error {
+	// check if object layer is available.
+	objAPI := lc.ObjectAPI()
+	if objAPI == nil {
+		return errServerNotInitialized
+	}
+
+	globalBucketEncryption.SetBucketEncryption(args.Bucket, args.ECfg)
+	return nil
+}
+
+// localBucketMetaState.UpdateBucketCors - updates in-memory global bucket
+// CORS info.
+func (lc *localBucketMetaState) UpdateBucketCors(args *SetBucketCorsPeerArgs) error {
+	// check if object layer is available.
+	objAPI := lc.ObjectAPI()
+	if objAPI == nil {
+		return errServerNotInitialized
+	}
+
+	globalBucketCors.SetBucketCors(args.Bucket, args.CCfg)
+	return nil
+}
+
+// localBucketMetaState.UpdateBucketWebsite - updates in-memory global
+// bucket website info.
+func (lc *localBucketMetaState) UpdateBucketWebsite(args *SetBucketWebsitePeerArgs) error {
+	// check if object layer is available.
+	objAPI := lc.ObjectAPI()
+	if objAPI == nil {
+		return errServerNotInitialized
+	}
+
+	globalBucketWebsite.SetBucketWebsite(args.Bucket, args.WCfg)
+	return nil
+}
+
+// localBucketMetaState.UpdateBucketObjectLock - updates in-memory global
+// bucket object lock info.
+func (lc *localBucketMetaState) UpdateBucketObjectLock(args *SetBucketObjectLockPeerArgs) error {
+	// check if object layer is available.
+	objAPI := lc.ObjectAPI()
+	if objAPI == nil {
+		return errServerNotInitialized
+	}
+
+	globalBucketObjectLock.SetBucketObjectLock(args.Bucket, args.LCfg)
+	return nil
+}
+
+// localBucketMetaState.UpdateBucketReplication - updates in-memory
+// global bucket replication info.
+func (lc *localBucketMetaState) UpdateBucketReplication(args *SetBucketReplicationPeerArgs) error {
+	// check if object layer is available.
+	objAPI := lc.ObjectAPI()
+	if objAPI == nil {
+		return errServerNotInitialized
+	}
+
+	globalBucketReplication.SetBucketReplication(args.Bucket, args.RCfg)
+	return nil
+}
+
+// localBucketMetaState.UpdateBucketLogging - updates in-memory global
+// bucket logging info.
+func (lc *localBucketMetaState) UpdateBucketLogging(args *SetBucketLoggingPeerArgs) error {
+	// check if object layer is available.
+	objAPI := lc.ObjectAPI()
+	if objAPI == nil {
+		return errServerNotInitialized
+	}
+
+	globalBucketLogging.SetBucketLogging(args.Bucket, args.LCfg)
+	return nil
+}
+
+// localBucketMetaState.UpdateBucketQuota - updates in-memory global
+// bucket quota info, the usage of the bucket is computed anew.
+func (lc *localBucketMetaState) UpdateBucketQuota(args *SetBucketQuotaPeerArgs) error {
+	// check if object layer is available.
+	objAPI := lc.ObjectAPI()
+	if objAPI == nil {
+		return errServerNotInitialized
+	}
+
+	if args.QCfg == nil {
+		globalBucketQuota.SetBucketQuota(args.Bucket, nil, bucketUsage{})
+		return nil
+	}
+	usage, err := computeBucketUsage(objAPI, args.Bucket)
+	if err != nil {
+		return err
+	}
+	globalBucketQuota.SetBucketQuota(args.Bucket, args.QCfg, usage)
+	return nil
+}
+
+// localBucketMetaState.UpdateBucketUsage - adds the usage change of
+// an object written or deleted by a peer to the in-memory bucket usage.
+func (lc *localBucketMetaState) UpdateBucketUsage(args *SetBucketUsagePeerArgs) error {
+	// check if object layer is available.
+	objAPI := lc.ObjectAPI()
+	if objAPI == nil {
+		return errServerNotInitialized
+	}
+
+	globalBucketQuota.addBucketUsage(args.Bucket, args.Size, args.Objects)
+	return nil
+}
+
+// localBucketMetaState.LoadIAM - reloads in-memory global IAM config.
+func (lc *localBucketMetaState) LoadIAM(args *LoadIAMPeerArgs) error {
+	// check if object layer is available.
+	objAPI := lc.ObjectAPI()
+	if objAPI == nil {
+		return errServerNotInitialized
+	}
+
+	if globalIAMSys == nil {
+		return errServerNotInitialized
+	}
+	return globalIAMSys.Load(objAPI)
+}
+
 // localBucketMetaState.SendEvent - sends event to local event notifier via
 // `globalEventNotifier`
 func (lc *localBucketMetaState) SendEvent(args *EventArgs) error {
@@ -126,6 +295,76 @@ func (rc *remoteBucketMetaState) UpdateBucketPolicy(args *SetBucketPolicyPeerArg
 	return rc.Call("S3.SetBucketPolicyPeer", args, &reply)
 }
 
+// remoteBucketMetaState.UpdateBucketVersioning - sends bucket versioning change
+// to remote peer via RPC call.
+func (rc *remoteBucketMetaState) UpdateBucketVersioning(args *SetBucketVersioningPeerArgs) error {
+	reply := AuthRPCReply{}
+	return rc.Call("S3.SetBucketVersioningPeer", args, &reply)
+}
+
+// remoteBucketMetaState.UpdateBucketEncryption - sends bucket encryption change
+// to remote peer via RPC call.
+func (rc *remoteBucketMetaState) UpdateBucketEncryption(args *SetBucketEncryptionPeerArgs) error {
+	reply := AuthRPCReply{}
+	return rc.Call("S3.SetBucketEncryptionPeer", args, &reply)
+}
+
+// remoteBucketMetaState.UpdateBucketCors - sends bucket CORS change to
+// remote peer via RPC call.
+func (rc *remoteBucketMetaState) UpdateBucketCors(args *SetBucketCorsPeerArgs) error {
+	reply := AuthRPCReply{}
+	return rc.Call("S3.SetBucketCorsPeer", args, &reply)
+}
+
+// remoteBucketMetaState.UpdateBucketWebsite - sends bucket website change
+// to remote peer via RPC call.
+func (rc *remoteBucketMetaState) UpdateBucketWebsite(args *SetBucketWebsitePeerArgs) error {
+	reply := AuthRPCReply{}
+	return rc.Call("S3.SetBucketWebsitePeer", args, &reply)
+}
+
+// remoteBucketMetaState.UpdateBucketObjectLock - sends bucket object lock
+// change to remote peer via RPC call.
+func (rc *remoteBucketMetaState) UpdateBucketObjectLock(args *SetBucketObjectLockPeerArgs) error {
+	reply := AuthRPCReply{}
+	return rc.Call("S3.SetBucketObjectLockPeer", args, &reply)
+}
+
+// remoteBucketMetaState.UpdateBucketQuota - sends bucket quota change
+// to remote peer via RPC call.
+func (rc *remoteBucketMetaState) UpdateBucketQuota(args *SetBucketQuotaPeerArgs) error {
+	reply := AuthRPCReply{}
+	return rc.Call("S3.SetBucketQuotaPeer", args, &reply)
+}
+
+// remoteBucketMetaState.UpdateBucketUsage - sends bucket usage change
+// to remote peer via RPC call.
+func (rc *remoteBucketMetaState) UpdateBucketUsage(args *SetBucketUsagePeerArgs) error {
+	reply := AuthRPCReply{}
+	return rc.Call("S3.SetBucketUsagePeer", args, &reply)
+}
+
+// remoteBucketMetaState.UpdateBucketReplication - sends bucket
+// replication change to remote peer via RPC call.
+func (rc *remoteBucketMetaState) UpdateBucketReplication(args *SetBucketReplicationPeerArgs) error {
+	reply := AuthRPCReply{}
+	return rc.Call("S3.SetBucketReplicationPeer", args, &reply)
+}
+
+// remoteBucketMetaState.UpdateBucketLogging - sends bucket logging
+// change to remote peer via RPC call.
+func (rc *remoteBucketMetaState) UpdateBucketLogging(args *SetBucketLoggingPeerArgs) error {
+	reply := AuthRPCReply{}
+	return rc.Call("S3.SetBucketLoggingPeer", args, &reply)
+}
+
+// remoteBucketMetaState.LoadIAM - sends IAM config reload to remote
+// peer via RPC call.
+func (rc *remoteBucketMetaState) LoadIAM(args *LoadIAMPeerArgs) error {
+	reply := AuthRPCReply{}
+	return rc.Call("S3.LoadIAMPeer", args, &reply)
+}
+
 // remoteBucketMetaState.SendEvent - sends event for bucket listener to remote
 // peer via RPC call.
 func (rc *remoteBucketMetaState) SendEvent(args *EventArgs) error {
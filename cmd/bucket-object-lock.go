@@ -0,0 +1,258 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+const (
+	// Bucket object lock config name.
+	bucketObjectLockConfig = "object-lock.xml"
+
+	// Object lock status of a bucket, object lock can only be enabled.
+	objectLockEnabled = "Enabled"
+)
+
+// errInvalidObjectLockConfig - object lock config is not enabled or its
+// default retention is invalid.
+var errInvalidObjectLockConfig = errors.New("Invalid object lock configuration")
+
+// defaultRetention - retention applied to new objects which are written
+// without retention settings.
+type defaultRetention struct {
+	Mode  string `xml:"Mode"`
+	Days  int    `xml:"Days,omitempty"`
+	Years int    `xml:"Years,omitempty"`
+}
+
+// objectLockRule - rule of the object lock configuration.
+type objectLockRule struct {
+	DefaultRetention defaultRetention `xml:"DefaultRetention"`
+}
+
+// objectLockConfiguration - represents the bucket object lock
+// configuration as sent by the PutObjectLockConfiguration API.
+type objectLockConfiguration struct {
+	XMLName           xml.Name        `xml:"ObjectLockConfiguration"`
+	ObjectLockEnabled string          `xml:"ObjectLockEnabled"`
+	Rule              *objectLockRule `xml:"Rule,omitempty"`
+}
+
+// Validate - validates object lock configuration, the default retention
+// is either set in days or in years.
+func (c objectLockConfiguration) Validate() error {
+	if c.ObjectLockEnabled != objectLockEnabled {
+		return errInvalidObjectLockConfig
+	}
+	if c.Rule == nil {
+		return nil
+	}
+	retention := c.Rule.DefaultRetention
+	if !isValidRetentionMode(retention.Mode) {
+		return errInvalidObjectLockConfig
+	}
+	if retention.Days < 0 || retention.Years < 0 || (retention.Days > 0) == (retention.Years > 0) {
+		return errInvalidObjectLockConfig
+	}
+	return nil
+}
+
+// defaultObjectLock - returns the object lock settings of new objects
+// written at the given time, without explicit retention settings.
+func (c objectLockConfiguration) defaultObjectLock(now time.Time) objectLock {
+	if c.Rule == nil {
+		return objectLock{}
+	}
+	retention := c.Rule.DefaultRetention
+	return objectLock{
+		Mode:            retention.Mode,
+		RetainUntilDate: now.AddDate(retention.Years, 0, retention.Days),
+	}
+}
+
+// Variable represents bucket object lock configs in memory.
+var globalBucketObjectLock *bucketObjectLock
+
+// Global bucket object lock config list, consulted whenever new objects
+// are written and by the object layer before removing data.
+type bucketObjectLock struct {
+	rwMutex *sync.RWMutex
+
+	// Collection of 'bucket' object lock configs.
+	bucketObjectLockConfigs map[string]*objectLockConfiguration
+}
+
+// GetBucketObjectLock - fetch object lock config for a given bucket.
+func (bl *bucketObjectLock) GetBucketObjectLock(bucket string) *objectLockConfiguration {
+	if bl == nil {
+		return nil
+	}
+	bl.rwMutex.RLock()
+	defer bl.rwMutex.RUnlock()
+	return bl.bucketObjectLockConfigs[bucket]
+}
+
+// SetBucketObjectLock - set a new object lock config for a bucket,
+// a nil config removes any previous object lock config.
+func (bl *bucketObjectLock) SetBucketObjectLock(bucket string, lcfg *objectLockConfiguration) {
+	if bl == nil {
+		return
+	}
+	bl.rwMutex.Lock()
+	defer bl.rwMutex.Unlock()
+	if lcfg == nil {
+		delete(bl.bucketObjectLockConfigs, bucket)
+		return
+	}
+	bl.bucketObjectLockConfigs[bucket] = lcfg
+}
+
+// isBucketObjectLockEnabled - returns true if object lock is enabled
+// on the bucket.
+func isBucketObjectLockEnabled(bucket string) bool {
+	return globalBucketObjectLock.GetBucketObjectLock(bucket) != nil
+}
+
+// Intialize all bucket object lock configs.
+func initBucketObjectLock(objAPI ObjectLayer) error {
+	if objAPI == nil {
+		return errInvalidArgument
+	}
+
+	// List buckets to proceed loading all object lock configuration.
+	buckets, err := objAPI.ListBuckets()
+	errorIf(err, "Unable to list buckets.")
+	if err != nil {
+		return errorCause(err)
+	}
+
+	configs := make(map[string]*objectLockConfiguration)
+	for _, bucket := range buckets {
+		lcfg, lErr := readBucketObjectLock(bucket.Name, objAPI)
+		if lErr != nil {
+			// Ignore missing configs and disks which are not found.
+			if lErr == errNoSuchObjectLockConfig || isErrIgnored(lErr, errDiskNotFound) {
+				continue
+			}
+			return lErr
+		}
+		configs[bucket.Name] = lcfg
+	}
+
+	// Populate global bucket collection.
+	globalBucketObjectLock = &bucketObjectLock{
+		rwMutex:                 &sync.RWMutex{},
+		bucketObjectLockConfigs: configs,
+	}
+
+	// Success.
+	return nil
+}
+
+// errNoSuchObjectLockConfig - object lock was never enabled on the bucket.
+var errNoSuchObjectLockConfig = errors.New("The bucket object lock configuration does not exist")
+
+// readBucketObjectLock - reads bucket object lock config for an input
+// bucket, returns errNoSuchObjectLockConfig if the config is not found.
+func readBucketObjectLock(bucket string, objAPI ObjectLayer) (*objectLockConfiguration, error) {
+	objectLockPath := pathJoin(bucketConfigPrefix, bucket, bucketObjectLockConfig)
+
+	// Acquire a read lock on object lock config before reading.
+	objLock := globalNSMutex.NewNSLock(minioMetaBucket, objectLockPath)
+	objLock.RLock()
+	defer objLock.RUnlock()
+
+	var buffer bytes.Buffer
+	err := objAPI.GetObject(minioMetaBucket, objectLockPath, 0, -1, &buffer)
+	if err != nil {
+		if isErrObjectNotFound(err) || isErrIncompleteBody(err) {
+			return nil, errNoSuchObjectLockConfig
+		}
+		errorIf(err, "Unable to load object lock config for the bucket %s.", bucket)
+		return nil, errorCause(err)
+	}
+
+	return parseBucketObjectLock(&buffer)
+}
+
+// parseBucketObjectLock - parses and validates object lock config.
+func parseBucketObjectLock(reader io.Reader) (*objectLockConfiguration, error) {
+	lcfg := &objectLockConfiguration{}
+	if err := xml.NewDecoder(reader).Decode(lcfg); err != nil {
+		return nil, err
+	}
+	if err := lcfg.Validate(); err != nil {
+		return nil, err
+	}
+	return lcfg, nil
+}
+
+// writeBucketObjectLock - save a bucket object lock config that is
+// assumed to be validated.
+func writeBucketObjectLock(bucket string, objAPI ObjectLayer, lcfg *objectLockConfiguration) error {
+	buf, err := xml.Marshal(lcfg)
+	if err != nil {
+		errorIf(err, "Unable to marshal object lock config '%v' to XML", *lcfg)
+		return err
+	}
+	objectLockPath := pathJoin(bucketConfigPrefix, bucket, bucketObjectLockConfig)
+	// Acquire a write lock on object lock config before modifying.
+	objLock := globalNSMutex.NewNSLock(minioMetaBucket, objectLockPath)
+	objLock.Lock()
+	defer objLock.Unlock()
+	if _, err = objAPI.PutObject(minioMetaBucket, objectLockPath, int64(len(buf)), bytes.NewReader(buf), nil, ""); err != nil {
+		errorIf(err, "Unable to set object lock for the bucket %s", bucket)
+		return errorCause(err)
+	}
+	return nil
+}
+
+// removeBucketObjectLock - removes any previously written object lock
+// config, only done when the bucket itself is removed.
+func removeBucketObjectLock(bucket string, objAPI ObjectLayer) error {
+	objectLockPath := pathJoin(bucketConfigPrefix, bucket, bucketObjectLockConfig)
+	// Acquire a write lock on object lock config before modifying.
+	objLock := globalNSMutex.NewNSLock(minioMetaBucket, objectLockPath)
+	objLock.Lock()
+	defer objLock.Unlock()
+	if err := objAPI.DeleteObject(minioMetaBucket, objectLockPath); err != nil {
+		if isErrObjectNotFound(err) {
+			return errNoSuchObjectLockConfig
+		}
+		return errorCause(err)
+	}
+	return nil
+}
+
+// persistAndNotifyBucketObjectLock - persists the object lock config and
+// notifies all nodes in the cluster about the change. In-memory state is
+// updated in response to the notification.
+func persistAndNotifyBucketObjectLock(bucket string, lcfg *objectLockConfiguration, objAPI ObjectLayer) error {
+	if err := writeBucketObjectLock(bucket, objAPI, lcfg); err != nil {
+		return err
+	}
+
+	// Notify all peers (including self) to update in-memory state
+	S3PeersUpdateBucketObjectLock(bucket, lcfg)
+	return nil
+}
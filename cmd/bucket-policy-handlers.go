@@ -102,11 +102,16 @@ func bucketPolicyConditionMatch(conditions map[string]set.StringSet, statement p
 	// - s3:prefix
 	// - s3:max-keys
 	// - s3:aws-Referer
+	// - s3:ExistingObjectTag/<key>
+	// - s3:RequestObjectTag/<key>
 
 	// The following loop evaluates the logical AND of all the
 	// conditions in the statement. Note: we can break out of the
 	// loop if and only if a condition evaluates to false.
 	for condition, conditionKeyVal := range statement.Conditions {
+		if !bucketPolicyTagConditionMatch(condition, conditionKeyVal, conditions) {
+			return false
+		}
 		prefixConditon := conditionKeyVal["s3:prefix"]
 		maxKeyCondition := conditionKeyVal["s3:max-keys"]
 		if condition == "StringEquals" {
@@ -165,6 +170,44 @@ func bucketPolicyConditionMatch(conditions map[string]set.StringSet, statement p
 	return true
 }
 
+// Verify if the object tags in conditions match the tag condition keys
+// of a policy statement condition. The tags are looked up in conditions
+// by the condition key without the `s3:` prefix, e.g. the tag `project`
+// of the object for `s3:ExistingObjectTag/project` is looked up as
+// `ExistingObjectTag/project`. Missing tags never equal nor are like
+// any value.
+func bucketPolicyTagConditionMatch(condition string, conditionKeyVal map[string]set.StringSet, conditions map[string]set.StringSet) bool {
+	for key, values := range conditionKeyVal {
+		if _, _, ok := tagConditionKey(key); !ok {
+			continue
+		}
+		tagValue, found := "", false
+		for value := range conditions[strings.TrimPrefix(key, "s3:")] {
+			tagValue, found = value, true
+		}
+
+		switch condition {
+		case "StringEquals":
+			if !found || !values.Contains(tagValue) {
+				return false
+			}
+		case "StringNotEquals":
+			if found && values.Contains(tagValue) {
+				return false
+			}
+		case "StringLike":
+			if !found || values.FuncMatch(wildcard.MatchSimple, tagValue).IsEmpty() {
+				return false
+			}
+		case "StringNotLike":
+			if found && !values.FuncMatch(wildcard.MatchSimple, tagValue).IsEmpty() {
+				return false
+			}
+		}
+	}
+	return true
+}
+
 // PutBucketPolicyHandler - PUT Bucket policy
 // -----------------
 // This implementation of the PUT operation uses the policy
@@ -176,7 +219,7 @@ func (api objectAPIHandlers) PutBucketPolicyHandler(w http.ResponseWriter, r *ht
 		return
 	}
 
-	if s3Error := checkRequestAuthType(r, "", "", serverConfig.GetRegion()); s3Error != ErrNone {
+	if s3Error := checkRequestAuthType(r, "", "s3:PutBucketPolicy", serverConfig.GetRegion()); s3Error != ErrNone {
 		writeErrorResponse(w, s3Error, r.URL)
 		return
 	}
@@ -235,7 +278,7 @@ func (api objectAPIHandlers) DeleteBucketPolicyHandler(w http.ResponseWriter, r
 		return
 	}
 
-	if s3Error := checkRequestAuthType(r, "", "", serverConfig.GetRegion()); s3Error != ErrNone {
+	if s3Error := checkRequestAuthType(r, "", "s3:DeleteBucketPolicy", serverConfig.GetRegion()); s3Error != ErrNone {
 		writeErrorResponse(w, s3Error, r.URL)
 		return
 	}
@@ -278,7 +321,7 @@ func (api objectAPIHandlers) GetBucketPolicyHandler(w http.ResponseWriter, r *ht
 		return
 	}
 
-	if s3Error := checkRequestAuthType(r, "", "", serverConfig.GetRegion()); s3Error != ErrNone {
+	if s3Error := checkRequestAuthType(r, "", "s3:GetBucketPolicy", serverConfig.GetRegion()); s3Error != ErrNone {
 		writeErrorResponse(w, s3Error, r.URL)
 		return
 	}
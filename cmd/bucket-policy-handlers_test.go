@@ -970,6 +970,55 @@ func TestBucketPolicyConditionMatch(t *testing.T) {
 			condition:          getInnerMap("referer", "http://somethingelse.com/"),
 			expectedMatch:      true,
 		},
+		// Test case - 13.
+		// StringEquals condition on an object tag matches.
+		{
+			statementCondition: getStatementWithCondition("StringEquals", "s3:ExistingObjectTag/project", "alpha"),
+			condition:          getInnerMap("ExistingObjectTag/project", "alpha"),
+			expectedMatch:      true,
+		},
+		// Test case - 14.
+		// StringEquals condition on an object tag doesn't match.
+		{
+			statementCondition: getStatementWithCondition("StringEquals", "s3:ExistingObjectTag/project", "alpha"),
+			condition:          getInnerMap("ExistingObjectTag/project", "beta"),
+			expectedMatch:      false,
+		},
+		// Test case - 15.
+		// StringEquals condition on a missing object tag doesn't match.
+		{
+			statementCondition: getStatementWithCondition("StringEquals", "s3:ExistingObjectTag/project", "alpha"),
+			condition:          getInnerMap("ExistingObjectTag/retention", "alpha"),
+			expectedMatch:      false,
+		},
+		// Test case - 16.
+		// StringNotEquals condition on a missing request tag matches.
+		{
+			statementCondition: getStatementWithCondition("StringNotEquals", "s3:RequestObjectTag/project", "alpha"),
+			condition:          getInnerMap("prefix", "Asia/"),
+			expectedMatch:      true,
+		},
+		// Test case - 17.
+		// StringNotEquals condition on a request tag doesn't match.
+		{
+			statementCondition: getStatementWithCondition("StringNotEquals", "s3:RequestObjectTag/project", "alpha"),
+			condition:          getInnerMap("RequestObjectTag/project", "alpha"),
+			expectedMatch:      false,
+		},
+		// Test case - 18.
+		// StringLike condition on a request tag matches.
+		{
+			statementCondition: getStatementWithCondition("StringLike", "s3:RequestObjectTag/retention", "short-*"),
+			condition:          getInnerMap("RequestObjectTag/retention", "short-30d"),
+			expectedMatch:      true,
+		},
+		// Test case - 19.
+		// StringNotLike condition on an object tag doesn't match.
+		{
+			statementCondition: getStatementWithCondition("StringNotLike", "s3:ExistingObjectTag/retention", "short-*"),
+			condition:          getInnerMap("ExistingObjectTag/retention", "short-30d"),
+			expectedMatch:      false,
+		},
 	}
 
 	for i, tc := range testCases {
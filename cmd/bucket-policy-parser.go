@@ -29,15 +29,29 @@ import (
 	"github.com/minio/minio-go/pkg/set"
 )
 
+// Prefixes of the condition keys on object tags, the tag key follows
+// the prefix, e.g. `s3:ExistingObjectTag/project`.
+const (
+	existingObjectTagConditionPrefix = "s3:ExistingObjectTag/"
+	requestObjectTagConditionPrefix  = "s3:RequestObjectTag/"
+)
+
 var conditionKeyActionMap = map[string]set.StringSet{
 	"s3:prefix":   set.CreateStringSet("s3:ListBucket"),
 	"s3:max-keys": set.CreateStringSet("s3:ListBucket"),
+	"s3:ExistingObjectTag": set.CreateStringSet("s3:GetObject", "s3:GetObjectVersion",
+		"s3:GetObjectTagging", "s3:PutObjectTagging", "s3:DeleteObjectTagging"),
+	"s3:RequestObjectTag": set.CreateStringSet("s3:PutObject", "s3:PutObjectTagging"),
 }
 
 // supportedActionMap - lists all the actions supported by minio.
 var supportedActionMap = set.CreateStringSet("*", "s3:*", "s3:GetObject",
 	"s3:ListBucket", "s3:PutObject", "s3:GetBucketLocation", "s3:DeleteObject",
-	"s3:AbortMultipartUpload", "s3:ListBucketMultipartUploads", "s3:ListMultipartUploadParts")
+	"s3:AbortMultipartUpload", "s3:ListBucketMultipartUploads", "s3:ListMultipartUploadParts",
+	"s3:ListBucketVersions", "s3:GetObjectVersion", "s3:DeleteObjectVersion",
+	"s3:GetObjectTagging", "s3:PutObjectTagging", "s3:DeleteObjectTagging",
+	"s3:GetObjectRetention", "s3:PutObjectRetention", "s3:GetObjectLegalHold",
+	"s3:PutObjectLegalHold", "s3:BypassGovernanceRetention")
 
 // supported Conditions type.
 var supportedConditionsType = set.CreateStringSet("StringEquals", "StringNotEquals", "StringLike", "StringNotLike")
@@ -46,6 +60,17 @@ var supportedConditionsType = set.CreateStringSet("StringEquals", "StringNotEqua
 // supported keys for the conditions.
 var supportedConditionsKey = set.CreateStringSet("s3:prefix", "s3:max-keys", "aws:Referer")
 
+// tagConditionKey - returns the name of a condition key on object tags
+// without the tag key and the tag key, ok is false for other keys.
+func tagConditionKey(key string) (name, tagKey string, ok bool) {
+	for _, prefix := range []string{existingObjectTagConditionPrefix, requestObjectTagConditionPrefix} {
+		if strings.HasPrefix(key, prefix) {
+			return strings.TrimSuffix(prefix, "/"), strings.TrimPrefix(key, prefix), true
+		}
+	}
+	return "", "", false
+}
+
 // supportedEffectMap - supported effects.
 var supportedEffectMap = set.CreateStringSet("Allow", "Deny")
 
@@ -65,6 +90,21 @@ type bucketPolicy struct {
 	Statements []policyStatement `json:"Statement"`
 }
 
+// hasConditionKeyPrefix - returns true if any statement has a
+// condition key with the given prefix.
+func (b bucketPolicy) hasConditionKeyPrefix(prefix string) bool {
+	for _, statement := range b.Statements {
+		for _, conditionKeyVal := range statement.Conditions {
+			for key := range conditionKeyVal {
+				if strings.HasPrefix(key, prefix) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
 // Stringer implementation for the bucket policies.
 func (b bucketPolicy) String() string {
 	bbytes, err := json.Marshal(&b)
@@ -196,12 +236,19 @@ func isValidConditions(actions set.StringSet, conditions map[string]map[string]s
 			return err
 		}
 		for key, value := range conditions[conditionType] {
-			if !supportedConditionsKey.Contains(key) {
+			actionKey := key
+			if name, tagKey, ok := tagConditionKey(key); ok {
+				if tagKey == "" {
+					err = fmt.Errorf("Missing tag key in condition key '%s', please validate your policy document", key)
+					return err
+				}
+				actionKey = name
+			} else if !supportedConditionsKey.Contains(key) {
 				err = fmt.Errorf("Unsupported condition key '%s', please validate your policy document", conditionType)
 				return err
 			}
 
-			compatibleActions := conditionKeyActionMap[key]
+			compatibleActions := conditionKeyActionMap[actionKey]
 			if !compatibleActions.IsEmpty() &&
 				compatibleActions.Intersection(actions).IsEmpty() {
 				err = fmt.Errorf("Unsupported condition key %s for the given actions %s, "+
@@ -225,6 +272,7 @@ var invalidPrefixActions = set.StringSet{
 	"s3:GetBucketLocation":          {},
 	"s3:ListBucket":                 {},
 	"s3:ListBucketMultipartUploads": {},
+	"s3:ListBucketVersions":         {},
 	// Add actions which do not honor prefixes.
 }
 
@@ -431,6 +431,9 @@ func TestIsValidConditions(t *testing.T) {
 		generateConditions("StringEquals", "s3:max-keys", "100"),
 		generateConditions("StringNotEquals", "s3:prefix", "Asia/"),
 		generateConditions("StringNotEquals", "s3:max-keys", "100"),
+		generateConditions("StringEquals", "s3:ExistingObjectTag/project", "alpha"),
+		generateConditions("StringLike", "s3:RequestObjectTag/project", "alpha-*"),
+		generateConditions("StringEquals", "s3:ExistingObjectTag/", "alpha"),
 	}
 
 	getObjectActionSet := set.CreateStringSet("s3:GetObject")
@@ -483,6 +486,19 @@ func TestIsValidConditions(t *testing.T) {
 		{roBucketActionSet, testConditions[11], nil, true},
 		// Test case - 13.
 		{getObjectActionSet, testConditions[11], maxKeysConditionErr, false},
+		// Test case - 14.
+		// Conditions on existing object tags.
+		{getObjectActionSet, testConditions[14], nil, true},
+		// Test case - 15.
+		// Conditions on request tags do not apply to reads.
+		{getObjectActionSet, testConditions[15], fmt.Errorf("Unsupported condition key %s for the given actions %s, "+
+			"please validate your policy document", "s3:RequestObjectTag/project", getObjectActionSet), false},
+		// Test case - 16.
+		{set.CreateStringSet("s3:PutObject"), testConditions[15], nil, true},
+		// Test case - 17.
+		// Tag condition keys need a tag key.
+		{getObjectActionSet, testConditions[16], fmt.Errorf("Missing tag key in condition key " +
+			"'s3:ExistingObjectTag/', please validate your policy document"), false},
 	}
 	for i, testCase := range testCases {
 		actualErr := isValidConditions(testCase.inputActions, testCase.inputCondition)
@@ -0,0 +1,650 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+	"sync"
+)
+
+const (
+	// Bucket quota config name.
+	bucketQuotaConfig = "quota.json"
+
+	// Quota types, writes exceeding a hard quota are rejected while a
+	// FIFO quota evicts the oldest object versions to make room.
+	hardQuota = "hard"
+	fifoQuota = "fifo"
+
+	// Maximum supported size of bucket quota configs.
+	maxBucketQuotaSize = 1024
+)
+
+// errInvalidBucketQuota - quota type is unknown or no limit is set.
+var errInvalidBucketQuota = errors.New("Invalid bucket quota configuration")
+
+// bucketQuotaConfiguration - quota of a bucket, limits the total size and
+// optionally the number of object versions stored in the bucket.
+type bucketQuotaConfiguration struct {
+	Quota      int64  `json:"quota"`
+	MaxObjects int64  `json:"maxObjects,omitempty"`
+	Type       string `json:"quotatype"`
+}
+
+// Validate - validates the bucket quota, at least one limit is set.
+func (q bucketQuotaConfiguration) Validate() error {
+	if q.Type != hardQuota && q.Type != fifoQuota {
+		return errInvalidBucketQuota
+	}
+	if q.Quota < 0 || q.MaxObjects < 0 || (q.Quota == 0 && q.MaxObjects == 0) {
+		return errInvalidBucketQuota
+	}
+	return nil
+}
+
+// isExceeded - returns true if the usage exceeds any limit of the quota.
+func (q bucketQuotaConfiguration) isExceeded(usage bucketUsage) bool {
+	return (q.Quota > 0 && usage.Size > q.Quota) || (q.MaxObjects > 0 && usage.Objects > q.MaxObjects)
+}
+
+// bucketUsage - total size and number of the object versions stored
+// in a bucket, delete markers are not counted.
+type bucketUsage struct {
+	Size    int64 `json:"size"`
+	Objects int64 `json:"objects"`
+}
+
+// bucketQuotaInfo - quota and current usage of a bucket as returned
+// by the admin API.
+type bucketQuotaInfo struct {
+	bucketQuotaConfiguration
+	Usage bucketUsage `json:"usage"`
+}
+
+// Variable represents bucket quota configs in memory.
+var globalBucketQuota *bucketQuotas
+
+// Global bucket quota config list along with the usage of these
+// buckets, which is maintained by the writes and deletes served by all
+// servers so that the quota can be enforced without listing the bucket.
+type bucketQuotas struct {
+	rwMutex *sync.RWMutex
+
+	// Collection of 'bucket' quota configs.
+	bucketQuotaConfigs map[string]*bucketQuotaConfiguration
+
+	// Usage of the buckets with a quota.
+	bucketUsages map[string]bucketUsage
+
+	// Usage reserved by the writes in flight on this server.
+	reservedUsages map[string]bucketUsage
+
+	// Buckets whose oldest object versions are being evicted.
+	evicting map[string]bool
+}
+
+// GetBucketQuota - fetch quota config for a given bucket.
+func (bq *bucketQuotas) GetBucketQuota(bucket string) *bucketQuotaConfiguration {
+	if bq == nil {
+		return nil
+	}
+	bq.rwMutex.RLock()
+	defer bq.rwMutex.RUnlock()
+	return bq.bucketQuotaConfigs[bucket]
+}
+
+// GetBucketUsage - fetch the usage of a bucket with a quota.
+func (bq *bucketQuotas) GetBucketUsage(bucket string) bucketUsage {
+	if bq == nil {
+		return bucketUsage{}
+	}
+	bq.rwMutex.RLock()
+	defer bq.rwMutex.RUnlock()
+	return bq.bucketUsages[bucket]
+}
+
+// SetBucketQuota - set a new quota config for a bucket along with the
+// usage of the bucket, a nil config removes any previous quota config.
+func (bq *bucketQuotas) SetBucketQuota(bucket string, qcfg *bucketQuotaConfiguration, usage bucketUsage) {
+	if bq == nil {
+		return
+	}
+	bq.rwMutex.Lock()
+	defer bq.rwMutex.Unlock()
+	if qcfg == nil {
+		delete(bq.bucketQuotaConfigs, bucket)
+		delete(bq.bucketUsages, bucket)
+		return
+	}
+	bq.bucketQuotaConfigs[bucket] = qcfg
+	bq.bucketUsages[bucket] = usage
+}
+
+// setBucketUsage - replaces the usage of a bucket with a quota.
+func (bq *bucketQuotas) setBucketUsage(bucket string, usage bucketUsage) {
+	if bq == nil {
+		return
+	}
+	bq.rwMutex.Lock()
+	defer bq.rwMutex.Unlock()
+	if _, ok := bq.bucketQuotaConfigs[bucket]; ok {
+		bq.bucketUsages[bucket] = usage
+	}
+}
+
+// reserveBucketUsage - reserves the usage change of a write if the
+// bucket usage along with the usage reserved by other writes in flight
+// does not exceed the hard quota of the bucket. Returns false if the
+// write exceeds the quota.
+func (bq *bucketQuotas) reserveBucketUsage(bucket string, size, objects int64) bool {
+	bq.rwMutex.Lock()
+	defer bq.rwMutex.Unlock()
+	qcfg, ok := bq.bucketQuotaConfigs[bucket]
+	if !ok {
+		return true
+	}
+	usage, reserved := bq.bucketUsages[bucket], bq.reservedUsages[bucket]
+	switch qcfg.Type {
+	case fifoQuota:
+		// Evicting all other object versions would not make room.
+		if qcfg.Quota > 0 && size > qcfg.Quota {
+			return false
+		}
+	default:
+		usage.Size += reserved.Size + size
+		usage.Objects += reserved.Objects + objects
+		if qcfg.isExceeded(usage) {
+			return false
+		}
+	}
+	// Writes shrinking the bucket only free space once they are done.
+	if size > 0 {
+		reserved.Size += size
+	}
+	if objects > 0 {
+		reserved.Objects += objects
+	}
+	bq.reservedUsages[bucket] = reserved
+	return true
+}
+
+// releaseBucketUsage - releases the usage reserved by a write.
+func (bq *bucketQuotas) releaseBucketUsage(bucket string, size, objects int64) {
+	bq.rwMutex.Lock()
+	defer bq.rwMutex.Unlock()
+	reserved := bq.reservedUsages[bucket]
+	if size > 0 {
+		reserved.Size -= size
+	}
+	if objects > 0 {
+		reserved.Objects -= objects
+	}
+	if reserved.Size <= 0 && reserved.Objects <= 0 {
+		delete(bq.reservedUsages, bucket)
+		return
+	}
+	bq.reservedUsages[bucket] = reserved
+}
+
+// addBucketUsage - adds to the usage of a bucket with a quota.
+func (bq *bucketQuotas) addBucketUsage(bucket string, size, objects int64) {
+	if bq == nil {
+		return
+	}
+	bq.rwMutex.Lock()
+	defer bq.rwMutex.Unlock()
+	if _, ok := bq.bucketQuotaConfigs[bucket]; !ok {
+		return
+	}
+	usage := bq.bucketUsages[bucket]
+	usage.Size += size
+	usage.Objects += objects
+	// Usage changes of writes in flight while the usage was computed
+	// may be accounted for twice, never report negative usage.
+	if usage.Size < 0 {
+		usage.Size = 0
+	}
+	if usage.Objects < 0 {
+		usage.Objects = 0
+	}
+	bq.bucketUsages[bucket] = usage
+}
+
+// startEvicting - returns true if the bucket exceeds a FIFO quota and
+// no eviction of its object versions is in progress, the caller is
+// then expected to evict and call doneEvicting.
+func (bq *bucketQuotas) startEvicting(bucket string) bool {
+	if bq == nil {
+		return false
+	}
+	bq.rwMutex.Lock()
+	defer bq.rwMutex.Unlock()
+	qcfg, ok := bq.bucketQuotaConfigs[bucket]
+	if !ok || qcfg.Type != fifoQuota || !qcfg.isExceeded(bq.bucketUsages[bucket]) || bq.evicting[bucket] {
+		return false
+	}
+	bq.evicting[bucket] = true
+	return true
+}
+
+// doneEvicting - marks the eviction of object versions of the bucket
+// as done.
+func (bq *bucketQuotas) doneEvicting(bucket string) {
+	bq.rwMutex.Lock()
+	defer bq.rwMutex.Unlock()
+	delete(bq.evicting, bucket)
+}
+
+// isBucketQuotaEnabled - returns true if the bucket has a quota.
+func isBucketQuotaEnabled(bucket string) bool {
+	return globalBucketQuota.GetBucketQuota(bucket) != nil
+}
+
+// computeBucketUsage - computes the usage of a bucket by listing all
+// its object versions.
+func computeBucketUsage(objAPI ObjectLayer, bucket string) (usage bucketUsage, err error) {
+	keyMarker, versionIDMarker := "", ""
+	for {
+		result, err := objAPI.ListObjectVersions(bucket, "", keyMarker, versionIDMarker, "", maxObjectList)
+		if err != nil {
+			return usage, err
+		}
+		for _, objInfo := range result.Objects {
+			if objInfo.DeleteMarker {
+				continue
+			}
+			usage.Size += objInfo.Size
+			usage.Objects++
+		}
+		if !result.IsTruncated {
+			return usage, nil
+		}
+		keyMarker, versionIDMarker = result.NextKeyMarker, result.NextVersionIDMarker
+	}
+}
+
+// Intialize all bucket quota configs, the usage of buckets with a
+// quota is computed here and maintained from then on. Usage changes
+// sent to peers may be lost, hence the usage is recomputed by
+// refreshBucketUsages once every data usage crawl.
+func initBucketQuota(objAPI ObjectLayer) error {
+	if objAPI == nil {
+		return errInvalidArgument
+	}
+
+	// List buckets to proceed loading all quota configuration.
+	buckets, err := objAPI.ListBuckets()
+	errorIf(err, "Unable to list buckets.")
+	if err != nil {
+		return errorCause(err)
+	}
+
+	configs := make(map[string]*bucketQuotaConfiguration)
+	usages := make(map[string]bucketUsage)
+	for _, bucket := range buckets {
+		qcfg, qErr := readBucketQuota(bucket.Name, objAPI)
+		if qErr != nil {
+			// Ignore missing configs and disks which are not found.
+			if qErr == errNoSuchBucketQuota || isErrIgnored(qErr, errDiskNotFound) {
+				continue
+			}
+			return qErr
+		}
+		usage, uErr := computeBucketUsage(objAPI, bucket.Name)
+		if uErr != nil {
+			errorIf(uErr, "Unable to compute usage of the bucket %s.", bucket.Name)
+			return errorCause(uErr)
+		}
+		configs[bucket.Name] = qcfg
+		usages[bucket.Name] = usage
+	}
+
+	// Populate global bucket collection.
+	globalBucketQuota = &bucketQuotas{
+		rwMutex:            &sync.RWMutex{},
+		bucketQuotaConfigs: configs,
+		bucketUsages:       usages,
+		reservedUsages:     make(map[string]bucketUsage),
+		evicting:           make(map[string]bool),
+	}
+
+	// Success.
+	return nil
+}
+
+// refreshBucketUsages - recomputes the usage of all buckets with a
+// quota, correcting any drift of the usage maintained by the writes
+// and deletes served by this server and its peers.
+func refreshBucketUsages(objAPI ObjectLayer) error {
+	if globalBucketQuota == nil {
+		return nil
+	}
+	globalBucketQuota.rwMutex.RLock()
+	buckets := make([]string, 0, len(globalBucketQuota.bucketQuotaConfigs))
+	for bucket := range globalBucketQuota.bucketQuotaConfigs {
+		buckets = append(buckets, bucket)
+	}
+	globalBucketQuota.rwMutex.RUnlock()
+
+	for _, bucket := range buckets {
+		usage, err := computeBucketUsage(objAPI, bucket)
+		if err != nil {
+			// Buckets removed in the meantime are skipped.
+			if _, ok := errorCause(err).(BucketNotFound); ok {
+				continue
+			}
+			return errorCause(err)
+		}
+		globalBucketQuota.setBucketUsage(bucket, usage)
+	}
+	return nil
+}
+
+// errNoSuchBucketQuota - bucket has no quota.
+var errNoSuchBucketQuota = errors.New("The bucket quota configuration does not exist")
+
+// errBucketQuotaExceeded - write exceeds the hard quota of a bucket.
+var errBucketQuotaExceeded = errors.New("Bucket quota exceeded")
+
+// readBucketQuota - reads bucket quota config for an input bucket,
+// returns errNoSuchBucketQuota if the config is not found.
+func readBucketQuota(bucket string, objAPI ObjectLayer) (*bucketQuotaConfiguration, error) {
+	quotaPath := pathJoin(bucketConfigPrefix, bucket, bucketQuotaConfig)
+
+	// Acquire a read lock on quota config before reading.
+	objLock := globalNSMutex.NewNSLock(minioMetaBucket, quotaPath)
+	objLock.RLock()
+	defer objLock.RUnlock()
+
+	var buffer bytes.Buffer
+	err := objAPI.GetObject(minioMetaBucket, quotaPath, 0, -1, &buffer)
+	if err != nil {
+		if isErrObjectNotFound(err) || isErrIncompleteBody(err) {
+			return nil, errNoSuchBucketQuota
+		}
+		errorIf(err, "Unable to load quota config for the bucket %s.", bucket)
+		return nil, errorCause(err)
+	}
+
+	return parseBucketQuota(&buffer)
+}
+
+// parseBucketQuota - parses and validates bucket quota config.
+func parseBucketQuota(reader io.Reader) (*bucketQuotaConfiguration, error) {
+	qcfg := &bucketQuotaConfiguration{}
+	if err := json.NewDecoder(reader).Decode(qcfg); err != nil {
+		return nil, err
+	}
+	if err := qcfg.Validate(); err != nil {
+		return nil, err
+	}
+	return qcfg, nil
+}
+
+// writeBucketQuota - save a bucket quota config that is assumed to be
+// validated.
+func writeBucketQuota(bucket string, objAPI ObjectLayer, qcfg *bucketQuotaConfiguration) error {
+	buf, err := json.Marshal(qcfg)
+	if err != nil {
+		errorIf(err, "Unable to marshal bucket quota '%v' to JSON", *qcfg)
+		return err
+	}
+	quotaPath := pathJoin(bucketConfigPrefix, bucket, bucketQuotaConfig)
+	// Acquire a write lock on quota config before modifying.
+	objLock := globalNSMutex.NewNSLock(minioMetaBucket, quotaPath)
+	objLock.Lock()
+	defer objLock.Unlock()
+	if _, err = objAPI.PutObject(minioMetaBucket, quotaPath, int64(len(buf)), bytes.NewReader(buf), nil, ""); err != nil {
+		errorIf(err, "Unable to set quota for the bucket %s", bucket)
+		return errorCause(err)
+	}
+	return nil
+}
+
+// removeBucketQuota - removes any previously written bucket quota
+// config, returns errNoSuchBucketQuota if no quota config was written.
+func removeBucketQuota(bucket string, objAPI ObjectLayer) error {
+	quotaPath := pathJoin(bucketConfigPrefix, bucket, bucketQuotaConfig)
+	// Acquire a write lock on quota config before modifying.
+	objLock := globalNSMutex.NewNSLock(minioMetaBucket, quotaPath)
+	objLock.Lock()
+	defer objLock.Unlock()
+	if err := objAPI.DeleteObject(minioMetaBucket, quotaPath); err != nil {
+		if isErrObjectNotFound(err) {
+			return errNoSuchBucketQuota
+		}
+		return errorCause(err)
+	}
+	return nil
+}
+
+// persistAndNotifyBucketQuota - persists the quota config and notifies
+// all nodes in the cluster about the change. In-memory state along
+// with the usage of the bucket is updated in response to the
+// notification.
+func persistAndNotifyBucketQuota(bucket string, qcfg *bucketQuotaConfiguration, objAPI ObjectLayer) error {
+	if qcfg == nil {
+		if err := removeBucketQuota(bucket, objAPI); err != nil {
+			return err
+		}
+	} else if err := writeBucketQuota(bucket, objAPI, qcfg); err != nil {
+		return err
+	}
+
+	// Notify all peers (including self) to update in-memory state
+	S3PeersUpdateBucketQuota(bucket, qcfg)
+	return nil
+}
+
+// removedObjectVersion - returns the object version which is removed
+// from the bucket by deleting versionID, or by writing the object if
+// versionID is empty. Overwrites and deletes remove the `null` version
+// unless versioning is enabled. Returns an empty object info if no
+// object version is removed, or if the bucket has no quota and hence
+// its usage is not maintained.
+func removedObjectVersion(objAPI ObjectLayer, bucket, object, versionID string) ObjectInfo {
+	if !isBucketQuotaEnabled(bucket) {
+		return ObjectInfo{}
+	}
+	if versionID == "" {
+		if getBucketVersioningStatus(bucket) == versioningEnabled {
+			return ObjectInfo{}
+		}
+		versionID = nullVersionID
+	}
+	objInfo, err := objAPI.GetObjectVersionInfo(bucket, object, versionID)
+	if err != nil || objInfo.DeleteMarker {
+		return ObjectInfo{}
+	}
+	return objInfo
+}
+
+// bucketQuotaReservation - usage reserved for a write by
+// enforceBucketQuota along with the object version replaced by the
+// write.
+type bucketQuotaReservation struct {
+	bucket  string
+	size    int64
+	objects int64
+	removed ObjectInfo
+}
+
+// release - releases the reserved usage once the write is done, or
+// has failed. Successful writes are expected to be accounted for with
+// updateBucketUsage before.
+func (q *bucketQuotaReservation) release() {
+	if q.bucket == "" {
+		return
+	}
+	globalBucketQuota.releaseBucketUsage(q.bucket, q.size, q.objects)
+	q.bucket = ""
+}
+
+// enforceBucketQuota - returns ErrBucketQuotaExceeded if writing size
+// bytes to the object exceeds the hard quota of the bucket, otherwise
+// the usage change of the write is reserved so that concurrent writes
+// can not exceed the quota together. The caller must release the
+// returned reservation, and should account for the replaced object
+// version along with the written object with updateBucketUsage.
+func enforceBucketQuota(objAPI ObjectLayer, bucket, object string, size int64) (*bucketQuotaReservation, APIErrorCode) {
+	quota := &bucketQuotaReservation{}
+	if !isBucketQuotaEnabled(bucket) {
+		return quota, ErrNone
+	}
+
+	// The object version replaced by the write is accounted for.
+	quota.removed = removedObjectVersion(objAPI, bucket, object, "")
+	quota.size = size - quota.removed.Size
+	if quota.removed.Name == "" {
+		quota.objects = 1
+	}
+	if !globalBucketQuota.reserveBucketUsage(bucket, quota.size, quota.objects) {
+		return quota, ErrBucketQuotaExceeded
+	}
+	quota.bucket = bucket
+	return quota, ErrNone
+}
+
+// getCompletedPartsSize - returns the size of the object written by
+// completing a multipart upload with the given parts.
+func getCompletedPartsSize(objAPI ObjectLayer, bucket, object, uploadID string, parts []completePart) (size int64, err error) {
+	completed := make(map[int]bool, len(parts))
+	for _, part := range parts {
+		completed[part.PartNumber] = true
+	}
+	partNumberMarker := 0
+	for {
+		result, err := objAPI.ListObjectParts(bucket, object, uploadID, partNumberMarker, maxPartsList)
+		if err != nil {
+			return 0, err
+		}
+		for _, part := range result.Parts {
+			if completed[part.PartNumber] {
+				size += part.Size
+			}
+		}
+		if !result.IsTruncated {
+			return size, nil
+		}
+		partNumberMarker = result.NextPartNumberMarker
+	}
+}
+
+// addAndNotifyBucketUsage - adds to the usage of a bucket with a quota
+// and notifies all other peers of the change. Changes lost on the way
+// to a peer are corrected by refreshBucketUsages.
+func addAndNotifyBucketUsage(bucket string, size, objects int64) {
+	if !isBucketQuotaEnabled(bucket) || (size == 0 && objects == 0) {
+		return
+	}
+	globalBucketQuota.addBucketUsage(bucket, size, objects)
+	go S3PeersUpdateBucketUsage(bucket, size, objects)
+}
+
+// updateBucketUsage - accounts for an object version removed from the
+// bucket and an object version written to it, either may be empty.
+// Buckets exceeding their FIFO quota have their oldest object versions
+// evicted in the background.
+func updateBucketUsage(objAPI ObjectLayer, bucket string, removed, written ObjectInfo) {
+	var size, objects int64
+	if removed.Name != "" {
+		size -= removed.Size
+		objects--
+	}
+	if written.Name != "" && !written.DeleteMarker {
+		size += written.Size
+		objects++
+	}
+	addAndNotifyBucketUsage(bucket, size, objects)
+	if globalBucketQuota.startEvicting(bucket) {
+		go evictBucketObjects(objAPI, bucket)
+	}
+}
+
+// Sorts object versions, oldest version first.
+type byOldestVersion []ObjectInfo
+
+func (v byOldestVersion) Len() int           { return len(v) }
+func (v byOldestVersion) Swap(i, j int)      { v[i], v[j] = v[j], v[i] }
+func (v byOldestVersion) Less(i, j int) bool { return v[i].ModTime.Before(v[j].ModTime) }
+
+// evictBucketObjects - removes the oldest object versions of a bucket
+// until it no longer exceeds its FIFO quota. Versions protected by
+// object lock are skipped.
+func evictBucketObjects(objAPI ObjectLayer, bucket string) {
+	defer globalBucketQuota.doneEvicting(bucket)
+
+	var versions []ObjectInfo
+	keyMarker, versionIDMarker := "", ""
+	for {
+		result, err := objAPI.ListObjectVersions(bucket, "", keyMarker, versionIDMarker, "", maxObjectList)
+		if err != nil {
+			errorIf(err, "Unable to list object versions of the bucket %s.", bucket)
+			return
+		}
+		for _, objInfo := range result.Objects {
+			if !objInfo.DeleteMarker {
+				objInfo.Bucket = bucket
+				versions = append(versions, objInfo)
+			}
+		}
+		if !result.IsTruncated {
+			break
+		}
+		keyMarker, versionIDMarker = result.NextKeyMarker, result.NextVersionIDMarker
+	}
+	sort.Sort(byOldestVersion(versions))
+
+	for _, version := range versions {
+		qcfg := globalBucketQuota.GetBucketQuota(bucket)
+		if qcfg == nil || qcfg.Type != fifoQuota || !qcfg.isExceeded(globalBucketQuota.GetBucketUsage(bucket)) {
+			return
+		}
+		if err := evictObjectVersion(objAPI, version); err != nil {
+			if !isObjectLocked(err) && !isErrObjectNotFound(err) {
+				errorIf(err, "Unable to evict object %s.", pathJoin(bucket, version.Name))
+			}
+		}
+	}
+}
+
+// evictObjectVersion - removes a single object version evicted by the
+// FIFO quota of its bucket.
+func evictObjectVersion(objAPI ObjectLayer, version ObjectInfo) error {
+	// Acquire a write lock before deleting the object.
+	objectLock := globalNSMutex.NewNSLock(version.Bucket, version.Name)
+	objectLock.Lock()
+	defer objectLock.Unlock()
+
+	objInfo, err := objAPI.DeleteObjectVersion(version.Bucket, version.Name, toVersionID(version.VersionID), false)
+	if err != nil {
+		return err
+	}
+	addAndNotifyBucketUsage(version.Bucket, -objInfo.Size, -1)
+
+	// Notify object deleted event.
+	eventNotify(eventData{
+		Type:    ObjectRemovedDelete,
+		Bucket:  version.Bucket,
+		ObjInfo: objInfo,
+	})
+	return nil
+}
@@ -0,0 +1,382 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Tests validating bucket quota configs.
+func TestParseBucketQuota(t *testing.T) {
+	testCases := []struct {
+		config      string
+		expectedErr error
+	}{
+		{`{"quota":1048576,"quotatype":"hard"}`, nil},
+		{`{"maxObjects":100,"quotatype":"fifo"}`, nil},
+		{`{"quota":1048576,"maxObjects":100,"quotatype":"hard"}`, nil},
+		// No limit set.
+		{`{"quotatype":"hard"}`, errInvalidBucketQuota},
+		// Unknown quota type.
+		{`{"quota":1048576,"quotatype":"soft"}`, errInvalidBucketQuota},
+		// Negative limit.
+		{`{"quota":-1,"maxObjects":100,"quotatype":"hard"}`, errInvalidBucketQuota},
+	}
+	for i, testCase := range testCases {
+		if _, err := parseBucketQuota(strings.NewReader(testCase.config)); err != testCase.expectedErr {
+			t.Errorf("Test %d: Expected %v, got %v", i+1, testCase.expectedErr, err)
+		}
+	}
+}
+
+// putObjectWithQuota - writes an object the way the object handlers do,
+// enforcing the bucket quota and maintaining the bucket usage.
+func putObjectWithQuota(obj ObjectLayer, bucket, object, data string) (ObjectInfo, APIErrorCode) {
+	quota, s3Error := enforceBucketQuota(obj, bucket, object, int64(len(data)))
+	if s3Error != ErrNone {
+		return ObjectInfo{}, s3Error
+	}
+	defer quota.release()
+	objInfo, err := obj.PutObject(bucket, object, int64(len(data)), strings.NewReader(data), nil, "")
+	if err != nil {
+		return objInfo, toAPIErrorCode(err)
+	}
+	updateBucketUsage(obj, bucket, quota.removed, objInfo)
+	return objInfo, ErrNone
+}
+
+// Wrapper for calling hard quota tests for both XL and FS.
+func TestBucketHardQuota(t *testing.T) {
+	initNSLock(false)
+	ExecObjectLayerTest(t, testBucketHardQuota)
+}
+
+// Tests that writes exceeding a hard quota are rejected and that the
+// bucket usage accounts for overwrites and deletes.
+func testBucketHardQuota(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	bucket := "quota-bucket"
+	if err := obj.MakeBucketWithLocation(bucket, ""); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if _, err := obj.PutObject(bucket, "existing", 4, bytes.NewReader([]byte("data")), nil, ""); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if err := initBucketVersioning(obj); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if err := initBucketQuota(obj); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+
+	// Usage of existing objects is computed when the quota is set.
+	usage, err := computeBucketUsage(obj, bucket)
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if usage != (bucketUsage{Size: 4, Objects: 1}) {
+		t.Fatalf("%s: Expected usage of 4 bytes in 1 object, got %v", instanceType, usage)
+	}
+	globalBucketQuota.SetBucketQuota(bucket, &bucketQuotaConfiguration{Quota: 10, MaxObjects: 2, Type: hardQuota}, usage)
+
+	testCases := []struct {
+		object, data    string
+		expectedErr     APIErrorCode
+		expectedSize    int64
+		expectedObjects int64
+	}{
+		{"object", "hello", ErrNone, 9, 2},
+		// Quota is exceeded.
+		{"large", "hi", ErrBucketQuotaExceeded, 9, 2},
+		// Overwrites only account for the difference in size.
+		{"object", "hello!", ErrNone, 10, 2},
+		{"object", "hello!!", ErrBucketQuotaExceeded, 10, 2},
+		{"existing", "da", ErrNone, 8, 2},
+		// Object count is exceeded.
+		{"small", "a", ErrBucketQuotaExceeded, 8, 2},
+	}
+	for i, testCase := range testCases {
+		if _, s3Error := putObjectWithQuota(obj, bucket, testCase.object, testCase.data); s3Error != testCase.expectedErr {
+			t.Fatalf("%s: Test %d: Expected %v, got %v", instanceType, i+1, testCase.expectedErr, s3Error)
+		}
+		usage = globalBucketQuota.GetBucketUsage(bucket)
+		if usage.Size != testCase.expectedSize || usage.Objects != testCase.expectedObjects {
+			t.Fatalf("%s: Test %d: Expected usage of %d bytes in %d objects, got %v", instanceType, i+1,
+				testCase.expectedSize, testCase.expectedObjects, usage)
+		}
+	}
+
+	// Deletes free up the quota.
+	removed := removedObjectVersion(obj, bucket, "object", "")
+	if _, err = obj.DeleteObjectVersion(bucket, "object", "", false); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	updateBucketUsage(obj, bucket, removed, ObjectInfo{})
+	if usage = globalBucketQuota.GetBucketUsage(bucket); usage != (bucketUsage{Size: 2, Objects: 1}) {
+		t.Fatalf("%s: Expected usage of 2 bytes in 1 object, got %v", instanceType, usage)
+	}
+	if _, s3Error := putObjectWithQuota(obj, bucket, "small", "a"); s3Error != ErrNone {
+		t.Fatalf("%s: Expected write to succeed, got %v", instanceType, s3Error)
+	}
+
+	// Deletes of the object handlers in unversioned buckets free up
+	// the quota too.
+	if _, s3Error := putObjectWithQuota(obj, bucket, "other", "b"); s3Error != ErrBucketQuotaExceeded {
+		t.Fatalf("%s: Expected %v, got %v", instanceType, ErrBucketQuotaExceeded, s3Error)
+	}
+	req, err := newTestRequest("DELETE", getDeleteObjectURL("", bucket, "small"), 0, nil)
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if err = deleteObject(obj, bucket, "small", req); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if usage = globalBucketQuota.GetBucketUsage(bucket); usage != (bucketUsage{Size: 2, Objects: 1}) {
+		t.Fatalf("%s: Expected usage of 2 bytes in 1 object, got %v", instanceType, usage)
+	}
+	if _, s3Error := putObjectWithQuota(obj, bucket, "other", "b"); s3Error != ErrNone {
+		t.Fatalf("%s: Expected write to succeed, got %v", instanceType, s3Error)
+	}
+
+	// Writes in flight reserve their usage until they are done.
+	quota, s3Error := enforceBucketQuota(obj, bucket, "existing", 6)
+	if s3Error != ErrNone {
+		t.Fatalf("%s: Expected write to succeed, got %v", instanceType, s3Error)
+	}
+	if _, s3Error = enforceBucketQuota(obj, bucket, "other", 5); s3Error != ErrBucketQuotaExceeded {
+		t.Fatalf("%s: Expected %v, got %v", instanceType, ErrBucketQuotaExceeded, s3Error)
+	}
+	quota.release()
+	if quota, s3Error = enforceBucketQuota(obj, bucket, "other", 5); s3Error != ErrNone {
+		t.Fatalf("%s: Expected write to succeed, got %v", instanceType, s3Error)
+	}
+	quota.release()
+	if usage = globalBucketQuota.GetBucketUsage(bucket); usage != (bucketUsage{Size: 3, Objects: 2}) {
+		t.Fatalf("%s: Expected usage of 3 bytes in 2 objects, got %v", instanceType, usage)
+	}
+
+	// Drifted usage is corrected by recomputing it.
+	globalBucketQuota.setBucketUsage(bucket, bucketUsage{Size: 100, Objects: 10})
+	if err = refreshBucketUsages(obj); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if usage = globalBucketQuota.GetBucketUsage(bucket); usage != (bucketUsage{Size: 3, Objects: 2}) {
+		t.Fatalf("%s: Expected usage of 3 bytes in 2 objects, got %v", instanceType, usage)
+	}
+}
+
+// Wrapper for calling FIFO quota tests for both XL and FS.
+func TestBucketFIFOQuota(t *testing.T) {
+	initNSLock(false)
+	ExecObjectLayerTest(t, testBucketFIFOQuota)
+}
+
+// Tests that the oldest objects are evicted from buckets exceeding
+// their FIFO quota.
+func testBucketFIFOQuota(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	bucket := "fifo-bucket"
+	if err := obj.MakeBucketWithLocation(bucket, ""); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if err := initBucketVersioning(obj); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if err := initBucketQuota(obj); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	for _, object := range []string{"c-oldest", "b-older", "a-newest"} {
+		if _, err := obj.PutObject(bucket, object, 5, bytes.NewReader([]byte("hello")), nil, ""); err != nil {
+			t.Fatalf("%s: %s", instanceType, err)
+		}
+		// Separate the modification times of the objects.
+		time.Sleep(10 * time.Millisecond)
+	}
+	usage, err := computeBucketUsage(obj, bucket)
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	qcfg := &bucketQuotaConfiguration{Quota: 10, Type: fifoQuota}
+	globalBucketQuota.SetBucketQuota(bucket, qcfg, usage)
+
+	// Writes larger than the quota can not be made room for.
+	if _, s3Error := enforceBucketQuota(obj, bucket, "large", 11); s3Error != ErrBucketQuotaExceeded {
+		t.Fatalf("%s: Expected %v, got %v", instanceType, ErrBucketQuotaExceeded, s3Error)
+	}
+	if _, s3Error := enforceBucketQuota(obj, bucket, "small", 5); s3Error != ErrNone {
+		t.Fatalf("%s: Expected write to be allowed, got %v", instanceType, s3Error)
+	}
+
+	evictBucketObjects(obj, bucket)
+
+	if usage = globalBucketQuota.GetBucketUsage(bucket); qcfg.isExceeded(usage) {
+		t.Fatalf("%s: Expected usage within quota, got %v", instanceType, usage)
+	}
+	if _, err = obj.GetObjectInfo(bucket, "c-oldest"); !isErrObjectNotFound(err) {
+		t.Fatalf("%s: Expected oldest object to be evicted, got %v", instanceType, err)
+	}
+	for _, object := range []string{"b-older", "a-newest"} {
+		if _, err = obj.GetObjectInfo(bucket, object); err != nil {
+			t.Fatalf("%s: Expected %s to be kept, got %v", instanceType, object, err)
+		}
+	}
+}
+
+// Wrapper for calling encrypted upload quota tests for both XL and FS.
+func TestBucketQuotaEncryptedPut(t *testing.T) {
+	ExecObjectLayerAPITest(t, testBucketQuotaEncryptedPut, []string{"PutObject"})
+}
+
+// Tests that encrypted uploads are checked against the quota at the
+// size they are stored and accounted at.
+func testBucketQuotaEncryptedPut(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials credential, t *testing.T) {
+	defer func(isSSL bool) { globalIsSSL = isSSL }(globalIsSSL)
+	globalIsSSL = true
+	if err := initBucketQuota(obj); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	usage, err := computeBucketUsage(obj, bucketName)
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+
+	data := "encrypted"
+	encryptedSize := sseEncryptedSize(int64(len(data)))
+	putObject := func(object string) *httptest.ResponseRecorder {
+		req, rerr := newTestSignedRequestV4("PUT", getPutObjectURL("", bucketName, object), int64(len(data)),
+			strings.NewReader(data), credentials.AccessKey, credentials.SecretKey)
+		if rerr != nil {
+			t.Fatalf("%s: %s", instanceType, rerr)
+		}
+		for k, v := range newSSECustomerHeader(bytes.Repeat([]byte{1}, SSECustomerKeySize)) {
+			req.Header[k] = v
+		}
+		rec := httptest.NewRecorder()
+		apiRouter.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// The plaintext fits the quota, the stored object does not.
+	qcfg := &bucketQuotaConfiguration{Quota: usage.Size + encryptedSize - 1, Type: hardQuota}
+	globalBucketQuota.SetBucketQuota(bucketName, qcfg, usage)
+	if rec := putObject("small"); rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "XMinioBucketQuotaExceeded") {
+		t.Fatalf("%s: Expected quota to be exceeded, got %d %s", instanceType, rec.Code, rec.Body.String())
+	}
+
+	qcfg = &bucketQuotaConfiguration{Quota: usage.Size + encryptedSize, Type: hardQuota}
+	globalBucketQuota.SetBucketQuota(bucketName, qcfg, usage)
+	if rec := putObject("small"); rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, rec.Code)
+	}
+	if newUsage := globalBucketQuota.GetBucketUsage(bucketName); newUsage.Size != usage.Size+encryptedSize {
+		t.Fatalf("%s: Expected usage of %d bytes, got %v", instanceType, usage.Size+encryptedSize, newUsage)
+	}
+}
+
+// TestBucketQuotaHandlers - tests for the bucket quota management REST APIs.
+func TestBucketQuotaHandlers(t *testing.T) {
+	adminTestBed, err := prepareAdminXLTestBed()
+	if err != nil {
+		t.Fatal("Failed to initialize a single node XL backend for admin handler tests.")
+	}
+	defer adminTestBed.TearDown()
+
+	// Quota changes are loaded by all peers, here only the local node.
+	defer func(peers s3Peers) { globalS3Peers = peers }(globalS3Peers)
+	globalS3Peers = makeS3Peers(EndpointList{})
+
+	bucket := "mybucket"
+	if err = adminTestBed.objLayer.MakeBucketWithLocation(bucket, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = adminTestBed.objLayer.PutObject(bucket, "object", 5, bytes.NewReader([]byte("hello")), nil, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err = initBucketVersioning(adminTestBed.objLayer); err != nil {
+		t.Fatal(err)
+	}
+	if err = initBucketQuota(adminTestBed.objLayer); err != nil {
+		t.Fatal(err)
+	}
+
+	mkQuotaQuery := func(bucket string) url.Values {
+		queryVal := url.Values{}
+		queryVal.Set("quota", "")
+		queryVal.Set("bucket", bucket)
+		return queryVal
+	}
+
+	testCases := []struct {
+		bucket             string
+		operation          string
+		method             string
+		body               string
+		expectedStatusCode int
+	}{
+		{bucket, "get-bucket-quota", http.MethodGet, "", http.StatusNotFound},
+		{bucket, "set-bucket-quota", http.MethodPut, `{"quota":1048576,"quotatype":"hard"}`, http.StatusOK},
+		{bucket, "set-bucket-quota", http.MethodPut, `{"quota":1048576,"quotatype":"soft"}`, http.StatusBadRequest},
+		{"unknown", "set-bucket-quota", http.MethodPut, `{"quota":1048576,"quotatype":"hard"}`, http.StatusNotFound},
+		{bucket, "get-bucket-quota", http.MethodGet, "", http.StatusOK},
+	}
+
+	var rec *httptest.ResponseRecorder
+	for i, testCase := range testCases {
+		body := []byte(testCase.body)
+		req, err := buildAdminRequest(mkQuotaQuery(testCase.bucket), testCase.operation, testCase.method,
+			int64(len(body)), bytes.NewReader(body))
+		if err != nil {
+			t.Fatalf("Test %d: Failed to construct %s request - %v", i+1, testCase.operation, err)
+		}
+		rec = httptest.NewRecorder()
+		adminTestBed.mux.ServeHTTP(rec, req)
+		if rec.Code != testCase.expectedStatusCode {
+			t.Errorf("Test %d: Expected status %d, got %d: %s", i+1, testCase.expectedStatusCode, rec.Code, rec.Body.String())
+		}
+	}
+
+	var info bucketQuotaInfo
+	if err = json.Unmarshal(rec.Body.Bytes(), &info); err != nil {
+		t.Fatal(err)
+	}
+	expectedInfo := bucketQuotaInfo{
+		bucketQuotaConfiguration: bucketQuotaConfiguration{Quota: 1048576, Type: hardQuota},
+		Usage:                    bucketUsage{Size: 5, Objects: 1},
+	}
+	if info != expectedInfo {
+		t.Errorf("Expected %v, got %v", expectedInfo, info)
+	}
+
+	req, err := buildAdminRequest(mkQuotaQuery(bucket), "remove-bucket-quota", http.MethodPost, 0, nil)
+	if err != nil {
+		t.Fatalf("Failed to construct remove-bucket-quota request - %v", err)
+	}
+	rec = httptest.NewRecorder()
+	adminTestBed.mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected to succeed but failed with %d", rec.Code)
+	}
+	if isBucketQuotaEnabled(bucket) {
+		t.Errorf("Expected bucket quota to be removed")
+	}
+}
@@ -0,0 +1,364 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/url"
+	"strings"
+	"sync"
+
+	minio "github.com/minio/minio-go"
+)
+
+const (
+	// Bucket replication config name.
+	bucketReplicationConfig = "replication.xml"
+
+	// Maximum number of rules in a replication configuration.
+	maxReplicationRules = 1000
+
+	// Maximum length of a replication rule ID.
+	maxReplicationRuleIDLength = 255
+
+	// Prefix of the ARN of a replication destination bucket.
+	replicationBucketARNPrefix = "arn:aws:s3:::"
+
+	// Replication rule status values.
+	replicationRuleEnabled  = "Enabled"
+	replicationRuleDisabled = "Disabled"
+)
+
+// Bucket replication configuration errors.
+var (
+	errNoSuchReplicationConfig         = errors.New("The replication configuration does not exist")
+	errReplicationNoRules              = errors.New("Replication configuration should have at least one rule")
+	errReplicationTooManyRules         = errors.New("Replication configuration should have at most 1000 rules")
+	errReplicationInvalidRuleID        = errors.New("Replication rule ID should be unique and at most 255 characters long")
+	errReplicationInvalidRuleStatus    = errors.New("Replication rule status should be Enabled or Disabled")
+	errReplicationOverlappingPrefixes  = errors.New("Replication rule prefixes should not overlap")
+	errReplicationInvalidBucket        = errors.New("Replication destination bucket should be an ARN of the form arn:aws:s3:::bucket")
+	errReplicationInvalidEndpoint      = errors.New("Replication destination endpoint should be an http or https URL without a path")
+	errReplicationMissingCredentials   = errors.New("Replication destination should have an access key and a secret key")
+	errReplicationMultipleDestinations = errors.New("Replication rules should all have the same destination")
+)
+
+// replicationDestination - the bucket objects are replicated to, the
+// endpoint and credentials of the remote server are Minio extensions.
+type replicationDestination struct {
+	Bucket    string `xml:"Bucket"`
+	Endpoint  string `xml:"Endpoint"`
+	AccessKey string `xml:"AccessKey"`
+	SecretKey string `xml:"SecretKey,omitempty"`
+}
+
+// BucketName - returns the name of the destination bucket.
+func (d replicationDestination) BucketName() string {
+	return strings.TrimPrefix(d.Bucket, replicationBucketARNPrefix)
+}
+
+// Validate - validates a replication destination.
+func (d replicationDestination) Validate() error {
+	if !strings.HasPrefix(d.Bucket, replicationBucketARNPrefix) || !IsValidBucketName(d.BucketName()) {
+		return errReplicationInvalidBucket
+	}
+	u, err := url.Parse(d.Endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" ||
+		(u.Path != "" && u.Path != "/") || u.RawQuery != "" {
+		return errReplicationInvalidEndpoint
+	}
+	if d.AccessKey == "" || d.SecretKey == "" {
+		return errReplicationMissingCredentials
+	}
+	return nil
+}
+
+// replicationRule - replicates the objects under its prefix.
+type replicationRule struct {
+	ID          string                 `xml:"ID,omitempty"`
+	Status      string                 `xml:"Status"`
+	Prefix      string                 `xml:"Prefix"`
+	Destination replicationDestination `xml:"Destination"`
+}
+
+// replicationConfiguration - represents the bucket replication
+// configuration as sent by the PutBucketReplication API.
+type replicationConfiguration struct {
+	XMLName xml.Name          `xml:"ReplicationConfiguration"`
+	Role    string            `xml:"Role,omitempty"`
+	Rules   []replicationRule `xml:"Rule"`
+}
+
+// Validate - validates replication configuration.
+func (c replicationConfiguration) Validate() error {
+	if len(c.Rules) == 0 {
+		return errReplicationNoRules
+	}
+	if len(c.Rules) > maxReplicationRules {
+		return errReplicationTooManyRules
+	}
+	ids := make(map[string]bool, len(c.Rules))
+	for i, rule := range c.Rules {
+		if len(rule.ID) > maxReplicationRuleIDLength || (rule.ID != "" && ids[rule.ID]) {
+			return errReplicationInvalidRuleID
+		}
+		ids[rule.ID] = true
+		if rule.Status != replicationRuleEnabled && rule.Status != replicationRuleDisabled {
+			return errReplicationInvalidRuleStatus
+		}
+		if err := rule.Destination.Validate(); err != nil {
+			return err
+		}
+		if rule.Destination != c.Rules[0].Destination {
+			return errReplicationMultipleDestinations
+		}
+		for _, other := range c.Rules[:i] {
+			if strings.HasPrefix(rule.Prefix, other.Prefix) || strings.HasPrefix(other.Prefix, rule.Prefix) {
+				return errReplicationOverlappingPrefixes
+			}
+		}
+	}
+	return nil
+}
+
+// Destination - returns the destination of an object, nil if the
+// object is not replicated.
+func (c replicationConfiguration) Destination(object string) *replicationDestination {
+	for i := range c.Rules {
+		rule := &c.Rules[i]
+		if rule.Status == replicationRuleEnabled && strings.HasPrefix(object, rule.Prefix) {
+			return &rule.Destination
+		}
+	}
+	return nil
+}
+
+// newReplicationClient - returns a client of the remote server of a
+// validated replication destination.
+func newReplicationClient(d replicationDestination) (*minio.Client, error) {
+	u, err := url.Parse(d.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	return minio.New(u.Host, d.AccessKey, d.SecretKey, u.Scheme == "https")
+}
+
+// Variable represents bucket replication configs in memory.
+var globalBucketReplication *bucketReplication
+
+// Global bucket replication config list, consulted on each object
+// write and delete.
+type bucketReplication struct {
+	rwMutex *sync.RWMutex
+
+	// Collection of 'bucket' replication configs.
+	bucketReplicationConfigs map[string]*replicationConfiguration
+
+	// Clients of the remote servers by 'bucket', created on first use.
+	clients map[string]*minio.Client
+}
+
+// GetBucketReplication - fetch replication config for a given bucket.
+func (br *bucketReplication) GetBucketReplication(bucket string) *replicationConfiguration {
+	if br == nil {
+		return nil
+	}
+	br.rwMutex.RLock()
+	defer br.rwMutex.RUnlock()
+	return br.bucketReplicationConfigs[bucket]
+}
+
+// SetBucketReplication - set a new replication config for a bucket, a
+// nil config removes any previous replication config.
+func (br *bucketReplication) SetBucketReplication(bucket string, rcfg *replicationConfiguration) {
+	if br == nil {
+		return
+	}
+	br.rwMutex.Lock()
+	defer br.rwMutex.Unlock()
+	delete(br.clients, bucket)
+	if rcfg == nil {
+		delete(br.bucketReplicationConfigs, bucket)
+		return
+	}
+	br.bucketReplicationConfigs[bucket] = rcfg
+}
+
+// GetReplicationTarget - returns the destination of an object along
+// with a client of its remote server, the destination is nil if the
+// object is not replicated.
+func (br *bucketReplication) GetReplicationTarget(bucket, object string) (*replicationDestination, *minio.Client, error) {
+	if br == nil {
+		return nil, nil, nil
+	}
+	br.rwMutex.Lock()
+	defer br.rwMutex.Unlock()
+	rcfg := br.bucketReplicationConfigs[bucket]
+	if rcfg == nil {
+		return nil, nil, nil
+	}
+	dest := rcfg.Destination(object)
+	if dest == nil {
+		return nil, nil, nil
+	}
+	client, ok := br.clients[bucket]
+	if !ok {
+		var err error
+		if client, err = newReplicationClient(*dest); err != nil {
+			return nil, nil, err
+		}
+		br.clients[bucket] = client
+	}
+	return dest, client, nil
+}
+
+// isObjectReplicated - returns true if an object is replicated by an
+// enabled rule of the bucket replication config.
+func isObjectReplicated(bucket, object string) bool {
+	rcfg := globalBucketReplication.GetBucketReplication(bucket)
+	return rcfg != nil && rcfg.Destination(object) != nil
+}
+
+// Intialize all bucket replication configs.
+func initBucketReplication(objAPI ObjectLayer) error {
+	if objAPI == nil {
+		return errInvalidArgument
+	}
+
+	// List buckets to proceed loading all replication configuration.
+	buckets, err := objAPI.ListBuckets()
+	errorIf(err, "Unable to list buckets.")
+	if err != nil {
+		return errorCause(err)
+	}
+
+	configs := make(map[string]*replicationConfiguration)
+	for _, bucket := range buckets {
+		rcfg, rErr := readBucketReplication(bucket.Name, objAPI)
+		if rErr != nil {
+			// Ignore missing configs and disks which are not found.
+			if rErr == errNoSuchReplicationConfig || isErrIgnored(rErr, errDiskNotFound) {
+				continue
+			}
+			return rErr
+		}
+		configs[bucket.Name] = rcfg
+	}
+
+	// Populate global bucket collection.
+	globalBucketReplication = &bucketReplication{
+		rwMutex:                  &sync.RWMutex{},
+		bucketReplicationConfigs: configs,
+		clients:                  make(map[string]*minio.Client),
+	}
+
+	// Success.
+	return nil
+}
+
+// readBucketReplication - reads bucket replication config for an input
+// bucket, returns errNoSuchReplicationConfig if the config is not found.
+func readBucketReplication(bucket string, objAPI ObjectLayer) (*replicationConfiguration, error) {
+	replicationPath := pathJoin(bucketConfigPrefix, bucket, bucketReplicationConfig)
+
+	// Acquire a read lock on replication config before reading.
+	objLock := globalNSMutex.NewNSLock(minioMetaBucket, replicationPath)
+	objLock.RLock()
+	defer objLock.RUnlock()
+
+	var buffer bytes.Buffer
+	err := objAPI.GetObject(minioMetaBucket, replicationPath, 0, -1, &buffer)
+	if err != nil {
+		if isErrObjectNotFound(err) || isErrIncompleteBody(err) {
+			return nil, errNoSuchReplicationConfig
+		}
+		errorIf(err, "Unable to load replication config for the bucket %s.", bucket)
+		return nil, errorCause(err)
+	}
+
+	return parseBucketReplication(&buffer)
+}
+
+// parseBucketReplication - parses and validates replication config.
+func parseBucketReplication(reader io.Reader) (*replicationConfiguration, error) {
+	rcfg := &replicationConfiguration{}
+	if err := xml.NewDecoder(reader).Decode(rcfg); err != nil {
+		return nil, err
+	}
+	if err := rcfg.Validate(); err != nil {
+		return nil, err
+	}
+	return rcfg, nil
+}
+
+// writeBucketReplication - save a bucket replication config that is
+// assumed to be validated.
+func writeBucketReplication(bucket string, objAPI ObjectLayer, rcfg *replicationConfiguration) error {
+	buf, err := xml.Marshal(rcfg)
+	if err != nil {
+		errorIf(err, "Unable to marshal replication config of the bucket %s to XML", bucket)
+		return err
+	}
+	replicationPath := pathJoin(bucketConfigPrefix, bucket, bucketReplicationConfig)
+	// Acquire a write lock on replication config before modifying.
+	objLock := globalNSMutex.NewNSLock(minioMetaBucket, replicationPath)
+	objLock.Lock()
+	defer objLock.Unlock()
+	if _, err = objAPI.PutObject(minioMetaBucket, replicationPath, int64(len(buf)), bytes.NewReader(buf), nil, ""); err != nil {
+		errorIf(err, "Unable to set replication for the bucket %s", bucket)
+		return errorCause(err)
+	}
+	return nil
+}
+
+// removeBucketReplication - removes any previously written replication
+// config.
+func removeBucketReplication(bucket string, objAPI ObjectLayer) error {
+	replicationPath := pathJoin(bucketConfigPrefix, bucket, bucketReplicationConfig)
+	// Acquire a write lock on replication config before modifying.
+	objLock := globalNSMutex.NewNSLock(minioMetaBucket, replicationPath)
+	objLock.Lock()
+	defer objLock.Unlock()
+	if err := objAPI.DeleteObject(minioMetaBucket, replicationPath); err != nil {
+		if isErrObjectNotFound(err) {
+			return errNoSuchReplicationConfig
+		}
+		return errorCause(err)
+	}
+	return nil
+}
+
+// persistAndNotifyBucketReplication - persists the replication config
+// and notifies all nodes in the cluster about the change, a nil config
+// removes the persisted config. In-memory state is updated in response
+// to the notification.
+func persistAndNotifyBucketReplication(bucket string, rcfg *replicationConfiguration, objAPI ObjectLayer) error {
+	if rcfg == nil {
+		if err := removeBucketReplication(bucket, objAPI); err != nil && err != errNoSuchReplicationConfig {
+			return err
+		}
+	} else if err := writeBucketReplication(bucket, objAPI, rcfg); err != nil {
+		return err
+	}
+
+	// Notify all peers (including self) to update in-memory state
+	S3PeersUpdateBucketReplication(bucket, rcfg)
+	return nil
+}
@@ -0,0 +1,320 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	router "github.com/gorilla/mux"
+)
+
+// replicationConfigXML - returns a replication config with a single
+// rule replicating the objects under prefix to endpoint.
+func replicationConfigXML(prefix, endpoint, accessKey, secretKey string) string {
+	return fmt.Sprintf(`<ReplicationConfiguration><Rule><ID>dr</ID><Status>Enabled</Status><Prefix>%s</Prefix>`+
+		`<Destination><Bucket>arn:aws:s3:::replica</Bucket><Endpoint>%s</Endpoint><AccessKey>%s</AccessKey><SecretKey>%s</SecretKey></Destination>`+
+		`</Rule></ReplicationConfiguration>`, prefix, endpoint, accessKey, secretKey)
+}
+
+// Tests parsing and validation of bucket replication configs.
+func TestParseBucketReplication(t *testing.T) {
+	destination := `<Destination><Bucket>arn:aws:s3:::replica</Bucket><Endpoint>https://dr.example.com:9000</Endpoint><AccessKey>minio</AccessKey><SecretKey>minio123</SecretKey></Destination>`
+	otherDestination := `<Destination><Bucket>arn:aws:s3:::other</Bucket><Endpoint>https://dr.example.com:9000</Endpoint><AccessKey>minio</AccessKey><SecretKey>minio123</SecretKey></Destination>`
+	testCases := []struct {
+		config      string
+		expectedErr error
+	}{
+		// Single rule.
+		{replicationConfigXML("photos/", "https://dr.example.com:9000", "minio", "minio123"), nil},
+		// Rules with distinct prefixes.
+		{`<ReplicationConfiguration><Rule><Status>Enabled</Status><Prefix>photos/</Prefix>` + destination + `</Rule><Rule><Status>Disabled</Status><Prefix>docs/</Prefix>` + destination + `</Rule></ReplicationConfiguration>`, nil},
+		// No rules.
+		{`<ReplicationConfiguration></ReplicationConfiguration>`, errReplicationNoRules},
+		// Too many rules.
+		{`<ReplicationConfiguration>` + strings.Repeat(`<Rule><Status>Enabled</Status>`+destination+`</Rule>`, maxReplicationRules+1) + `</ReplicationConfiguration>`, errReplicationTooManyRules},
+		// Duplicate rule IDs.
+		{`<ReplicationConfiguration><Rule><ID>a</ID><Status>Enabled</Status><Prefix>photos/</Prefix>` + destination + `</Rule><Rule><ID>a</ID><Status>Enabled</Status><Prefix>docs/</Prefix>` + destination + `</Rule></ReplicationConfiguration>`, errReplicationInvalidRuleID},
+		// Invalid rule status.
+		{`<ReplicationConfiguration><Rule><Status>On</Status>` + destination + `</Rule></ReplicationConfiguration>`, errReplicationInvalidRuleStatus},
+		// Overlapping prefixes.
+		{`<ReplicationConfiguration><Rule><Status>Enabled</Status><Prefix>photos/</Prefix>` + destination + `</Rule><Rule><Status>Enabled</Status><Prefix>photos/2017/</Prefix>` + destination + `</Rule></ReplicationConfiguration>`, errReplicationOverlappingPrefixes},
+		// Rules with different destinations.
+		{`<ReplicationConfiguration><Rule><Status>Enabled</Status><Prefix>photos/</Prefix>` + destination + `</Rule><Rule><Status>Enabled</Status><Prefix>docs/</Prefix>` + otherDestination + `</Rule></ReplicationConfiguration>`, errReplicationMultipleDestinations},
+		// Destination bucket which is not an ARN.
+		{`<ReplicationConfiguration><Rule><Status>Enabled</Status><Destination><Bucket>replica</Bucket><Endpoint>https://dr.example.com</Endpoint><AccessKey>minio</AccessKey><SecretKey>minio123</SecretKey></Destination></Rule></ReplicationConfiguration>`, errReplicationInvalidBucket},
+		// Endpoint with a path.
+		{replicationConfigXML("", "https://dr.example.com/replica", "minio", "minio123"), errReplicationInvalidEndpoint},
+		// Endpoint without a scheme.
+		{replicationConfigXML("", "dr.example.com:9000", "minio", "minio123"), errReplicationInvalidEndpoint},
+		// Missing secret key.
+		{replicationConfigXML("", "https://dr.example.com", "minio", ""), errReplicationMissingCredentials},
+	}
+
+	for i, testCase := range testCases {
+		_, err := parseBucketReplication(strings.NewReader(testCase.config))
+		if err != testCase.expectedErr {
+			t.Errorf("Test %d: Expected error %v, got %v", i+1, testCase.expectedErr, err)
+		}
+	}
+}
+
+// Tests that only objects under the prefix of enabled rules are replicated.
+func TestReplicationDestination(t *testing.T) {
+	destination := replicationDestination{Bucket: "arn:aws:s3:::replica", Endpoint: "http://localhost:9000", AccessKey: "minio", SecretKey: "minio123"}
+	rcfg := replicationConfiguration{Rules: []replicationRule{
+		{Status: replicationRuleEnabled, Prefix: "photos/", Destination: destination},
+		{Status: replicationRuleDisabled, Prefix: "docs/", Destination: destination},
+	}}
+	testCases := []struct {
+		object     string
+		replicated bool
+	}{
+		{"photos/a.jpg", true},
+		{"photos", false},
+		{"docs/a.txt", false},
+		{"a.txt", false},
+	}
+	for i, testCase := range testCases {
+		if replicated := rcfg.Destination(testCase.object) != nil; replicated != testCase.replicated {
+			t.Errorf("Test %d: Expected %s replicated to be %t, got %t", i+1, testCase.object, testCase.replicated, replicated)
+		}
+	}
+	if bucket := destination.BucketName(); bucket != "replica" {
+		t.Errorf("Expected destination bucket replica, got %s", bucket)
+	}
+}
+
+// Wrapper for calling bucket replication tests for both XL and FS.
+func TestBucketReplication(t *testing.T) {
+	ExecObjectLayerAPITest(t, testAPIBucketReplication,
+		[]string{"BucketReplication", "CopyObject", "PutObject", "HeadObject", "DeleteObject"})
+}
+
+// Tests setting replication configs and replicating object writes and
+// deletes to a second server.
+func testAPIBucketReplication(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials credential, t *testing.T) {
+	defer func(peers s3Peers) { globalS3Peers = peers }(globalS3Peers)
+	globalS3Peers = makeS3Peers(EndpointList{})
+	if err := initBucketReplication(obj); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+
+	// The objects are replicated to a second server.
+	targetObj, targetDir, err := prepareFS()
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	defer os.RemoveAll(targetDir)
+	if err = targetObj.MakeBucketWithLocation("replica", ""); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	targetRouter := router.NewRouter().SkipClean(true)
+	targetAPI := objectAPIHandlers{ObjectAPI: func() ObjectLayer { return targetObj }}
+	registerBucketLevelFunc(targetRouter.PathPrefix("/{bucket}").Subrouter(), targetAPI,
+		"GetBucketLocation", "PutObject", "DeleteObject")
+	targetServer := httptest.NewServer(targetRouter)
+	defer targetServer.Close()
+
+	// Replication tasks are processed by the test.
+	defer func(queue *replicationQueue) { globalReplicationQueue = queue }(globalReplicationQueue)
+	queue := newReplicationQueue(obj)
+	queue.retryUnit, queue.retryCap = time.Millisecond, time.Millisecond
+	globalReplicationQueue = queue
+	processTasks := func(count int) {
+		for i := 0; i < count; i++ {
+			select {
+			case task := <-queue.tasksCh:
+				queue.process(task)
+			default:
+				t.Fatalf("%s: Expected %d replication tasks, got %d", instanceType, count, i)
+			}
+		}
+		if len(queue.tasksCh) != 0 {
+			t.Fatalf("%s: Expected %d replication tasks, got %d", instanceType, count, count+len(queue.tasksCh))
+		}
+	}
+	// dropTasks - drops the queued replication tasks, as a restart does.
+	dropTasks := func() {
+		for len(queue.tasksCh) > 0 {
+			queue.unmarkQueued(<-queue.tasksCh)
+		}
+	}
+
+	// sendRequest - sends a signed request and returns the response.
+	sendRequest := func(method, urlStr, body string, header http.Header) *httptest.ResponseRecorder {
+		req, rerr := newTestSignedRequestV4(method, urlStr, int64(len(body)), strings.NewReader(body),
+			credentials.AccessKey, credentials.SecretKey)
+		if rerr != nil {
+			t.Fatalf("%s: Failed to create HTTP request: %v", instanceType, rerr)
+		}
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		rec := httptest.NewRecorder()
+		apiRouter.ServeHTTP(rec, req)
+		return rec
+	}
+	replicationURL := makeTestTargetURL("", bucketName, "", map[string][]string{"replication": {""}})
+	// getReplicationStatus - returns the replication status of an object.
+	getReplicationStatus := func(object string) string {
+		rec := sendRequest("HEAD", getHeadObjectURL("", bucketName, object), "", nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, rec.Code)
+		}
+		return rec.Header().Get(AmzReplicationStatus)
+	}
+
+	// No replication config is set yet.
+	if rec := sendRequest("GET", replicationURL, "", nil); rec.Code != http.StatusNotFound {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusNotFound, rec.Code)
+	}
+
+	// Invalid configs are rejected.
+	config := replicationConfigXML("photos/", targetServer.URL, credentials.AccessKey, "")
+	if rec := sendRequest("PUT", replicationURL, config, nil); rec.Code != http.StatusBadRequest {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusBadRequest, rec.Code)
+	}
+
+	// Secret keys are not returned.
+	config = replicationConfigXML("photos/", targetServer.URL, credentials.AccessKey, credentials.SecretKey)
+	if rec := sendRequest("PUT", replicationURL, config, nil); rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, rec.Code)
+	}
+	rec := sendRequest("GET", replicationURL, "", nil)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), credentials.AccessKey) ||
+		strings.Contains(rec.Body.String(), credentials.SecretKey) {
+		t.Fatalf("%s: Expected config without secret key, got %d %s", instanceType, rec.Code, rec.Body.String())
+	}
+
+	// Objects under the prefix are replicated along with their metadata.
+	header := http.Header{"X-Amz-Meta-Camera": []string{"dslr"}}
+	if rec = sendRequest("PUT", getPutObjectURL("", bucketName, "photos/a.jpg"), "photo", header); rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, rec.Code)
+	}
+	if rec = sendRequest("PUT", getPutObjectURL("", bucketName, "docs/a.txt"), "doc", nil); rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, rec.Code)
+	}
+	if status := getReplicationStatus("photos/a.jpg"); status != replicationPending {
+		t.Fatalf("%s: Expected replication status %s, got %s", instanceType, replicationPending, status)
+	}
+	if status := getReplicationStatus("docs/a.txt"); status != "" {
+		t.Fatalf("%s: Expected no replication status, got %s", instanceType, status)
+	}
+	processTasks(1)
+	if status := getReplicationStatus("photos/a.jpg"); status != replicationCompleted {
+		t.Fatalf("%s: Expected replication status %s, got %s", instanceType, replicationCompleted, status)
+	}
+	var buffer bytes.Buffer
+	if err = targetObj.GetObject("replica", "photos/a.jpg", 0, -1, &buffer); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if buffer.String() != "photo" {
+		t.Fatalf("%s: Expected replica data photo, got %s", instanceType, buffer.String())
+	}
+	objInfo, err := targetObj.GetObjectInfo("replica", "photos/a.jpg")
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if camera := objInfo.UserDefined["X-Amz-Meta-Camera"]; camera != "dslr" {
+		t.Fatalf("%s: Expected replica metadata to be kept, got %v", instanceType, objInfo.UserDefined)
+	}
+
+	// Copies encrypted with customer provided keys are not replicated.
+	defer func(isSSL bool) { globalIsSSL = isSSL }(globalIsSSL)
+	globalIsSSL = true
+	header = newSSECustomerHeader(bytes.Repeat([]byte{1}, SSECustomerKeySize))
+	header.Set("X-Amz-Copy-Source", "/"+bucketName+"/docs/a.txt")
+	if rec = sendRequest("PUT", getCopyObjectURL("", bucketName, "photos/secret.jpg"), "", header); rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, rec.Code)
+	}
+	processTasks(0)
+	if objInfo, err = obj.GetObjectInfo(bucketName, "photos/secret.jpg"); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if status := objInfo.UserDefined[ObjectReplicationStatus]; status != "" {
+		t.Fatalf("%s: Expected no replication status, got %s", instanceType, status)
+	}
+
+	// Deletes are replicated.
+	if rec = sendRequest("DELETE", getDeleteObjectURL("", bucketName, "photos/a.jpg"), "", nil); rec.Code != http.StatusNoContent {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusNoContent, rec.Code)
+	}
+	processTasks(1)
+	if _, err = targetObj.GetObjectInfo("replica", "photos/a.jpg"); !isErrObjectNotFound(err) {
+		t.Fatalf("%s: Expected replica to be removed, got %v", instanceType, err)
+	}
+
+	// Dropped writes and deletes are queued again by a scan.
+	if rec = sendRequest("PUT", getPutObjectURL("", bucketName, "photos/d.jpg"), "photo", nil); rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, rec.Code)
+	}
+	dropTasks()
+	queue.scan()
+	processTasks(1)
+	if status := getReplicationStatus("photos/d.jpg"); status != replicationCompleted {
+		t.Fatalf("%s: Expected replication status %s, got %s", instanceType, replicationCompleted, status)
+	}
+	if rec = sendRequest("DELETE", getDeleteObjectURL("", bucketName, "photos/d.jpg"), "", nil); rec.Code != http.StatusNoContent {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusNoContent, rec.Code)
+	}
+	dropTasks()
+	queue.scan()
+	processTasks(1)
+	if _, err = targetObj.GetObjectInfo("replica", "photos/d.jpg"); !isErrObjectNotFound(err) {
+		t.Fatalf("%s: Expected replica to be removed, got %v", instanceType, err)
+	}
+	queue.scan()
+	processTasks(0)
+
+	// Objects which can not be replicated are marked failed.
+	unreachableServer := httptest.NewServer(http.NotFoundHandler())
+	unreachableServer.Close()
+	config = replicationConfigXML("photos/", unreachableServer.URL, credentials.AccessKey, credentials.SecretKey)
+	if rec = sendRequest("PUT", replicationURL, config, nil); rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, rec.Code)
+	}
+	if rec = sendRequest("PUT", getPutObjectURL("", bucketName, "photos/b.jpg"), "photo", nil); rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, rec.Code)
+	}
+	processTasks(1)
+	if status := getReplicationStatus("photos/b.jpg"); status != replicationFailed {
+		t.Fatalf("%s: Expected replication status %s, got %s", instanceType, replicationFailed, status)
+	}
+
+	// Objects are no longer replicated once the config is removed.
+	if rec = sendRequest("DELETE", replicationURL, "", nil); rec.Code != http.StatusNoContent {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusNoContent, rec.Code)
+	}
+	if rec = sendRequest("GET", replicationURL, "", nil); rec.Code != http.StatusNotFound {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusNotFound, rec.Code)
+	}
+	if rec = sendRequest("PUT", getPutObjectURL("", bucketName, "photos/c.jpg"), "photo", nil); rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, rec.Code)
+	}
+	processTasks(0)
+	if status := getReplicationStatus("photos/c.jpg"); status != "" {
+		t.Fatalf("%s: Expected no replication status, got %s", instanceType, status)
+	}
+}
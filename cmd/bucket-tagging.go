@@ -0,0 +1,92 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+)
+
+// Bucket tagging config name.
+const bucketTaggingConfig = "tagging.xml"
+
+// errNoSuchBucketTagging - the bucket has no tags.
+var errNoSuchBucketTagging = errors.New("The bucket tag set does not exist")
+
+// Bucket tags are only served by the bucket tagging APIs, unlike
+// other bucket configs they are hence read on demand and not kept
+// in memory.
+
+// readBucketTagging - reads the tag set of a bucket, returns
+// errNoSuchBucketTagging if the bucket has no tags.
+func readBucketTagging(bucket string, objAPI ObjectLayer) (*tagging, error) {
+	taggingPath := pathJoin(bucketConfigPrefix, bucket, bucketTaggingConfig)
+
+	// Acquire a read lock on tagging config before reading.
+	objLock := globalNSMutex.NewNSLock(minioMetaBucket, taggingPath)
+	objLock.RLock()
+	defer objLock.RUnlock()
+
+	var buffer bytes.Buffer
+	err := objAPI.GetObject(minioMetaBucket, taggingPath, 0, -1, &buffer)
+	if err != nil {
+		if isErrObjectNotFound(err) || isErrIncompleteBody(err) {
+			return nil, errNoSuchBucketTagging
+		}
+		errorIf(err, "Unable to load tagging config for the bucket %s.", bucket)
+		return nil, errorCause(err)
+	}
+
+	return parseTagging(&buffer, maxBucketTags)
+}
+
+// writeBucketTagging - save a bucket tag set that is assumed to be
+// validated.
+func writeBucketTagging(bucket string, objAPI ObjectLayer, t *tagging) error {
+	buf, err := xml.Marshal(t)
+	if err != nil {
+		errorIf(err, "Unable to marshal tagging config '%v' to XML", *t)
+		return err
+	}
+	taggingPath := pathJoin(bucketConfigPrefix, bucket, bucketTaggingConfig)
+	// Acquire a write lock on tagging config before modifying.
+	objLock := globalNSMutex.NewNSLock(minioMetaBucket, taggingPath)
+	objLock.Lock()
+	defer objLock.Unlock()
+	if _, err = objAPI.PutObject(minioMetaBucket, taggingPath, int64(len(buf)), bytes.NewReader(buf), nil, ""); err != nil {
+		errorIf(err, "Unable to set tagging for the bucket %s", bucket)
+		return errorCause(err)
+	}
+	return nil
+}
+
+// removeBucketTagging - removes any previously written tag set.
+func removeBucketTagging(bucket string, objAPI ObjectLayer) error {
+	taggingPath := pathJoin(bucketConfigPrefix, bucket, bucketTaggingConfig)
+	// Acquire a write lock on tagging config before modifying.
+	objLock := globalNSMutex.NewNSLock(minioMetaBucket, taggingPath)
+	objLock.Lock()
+	defer objLock.Unlock()
+	if err := objAPI.DeleteObject(minioMetaBucket, taggingPath); err != nil {
+		if isErrObjectNotFound(err) {
+			return errNoSuchBucketTagging
+		}
+		return errorCause(err)
+	}
+	return nil
+}
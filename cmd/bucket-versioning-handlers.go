@@ -0,0 +1,152 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"encoding/xml"
+	"io"
+	"net/http"
+
+	humanize "github.com/dustin/go-humanize"
+	mux "github.com/gorilla/mux"
+)
+
+// maximum supported versioning configuration size.
+const maxVersioningConfigSize = 1 * humanize.KiByte
+
+// GetBucketVersioningHandler - This implementation of the GET
+// operation uses the versioning subresource to return the versioning
+// state of a bucket. Buckets which were never versioned return an
+// empty versioning configuration.
+func (api objectAPIHandlers) GetBucketVersioningHandler(w http.ResponseWriter, r *http.Request) {
+	objectAPI := api.ObjectAPI()
+	if objectAPI == nil {
+		writeErrorResponse(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	if s3Error := checkRequestAuthType(r, "", "s3:GetBucketVersioning", serverConfig.GetRegion()); s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+
+	_, err := objectAPI.GetBucketInfo(bucket)
+	if err != nil {
+		errorIf(err, "Unable to find bucket info.")
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	// Attempt to successfully load versioning config.
+	vcfg, err := readBucketVersioning(bucket, objectAPI)
+	if err != nil && err != errNoSuchVersioningConfig {
+		errorIf(err, "Unable to read versioning configuration.")
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+	// For buckets which were never versioned we write an empty XML.
+	if err == errNoSuchVersioningConfig {
+		// Complies with the s3 behavior in this regard.
+		vcfg = &versioningConfiguration{}
+	}
+	versioningBytes, err := xml.Marshal(vcfg)
+	if err != nil {
+		// For any marshalling failure.
+		errorIf(err, "Unable to marshal versioning configuration into XML.")
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	// Success.
+	writeSuccessResponseXML(w, versioningBytes)
+}
+
+// PutBucketVersioningHandler - This implementation of the PUT
+// operation uses the versioning subresource to set the versioning
+// state of an existing bucket. Once enabled versioning can only be
+// suspended and never removed from the bucket.
+func (api objectAPIHandlers) PutBucketVersioningHandler(w http.ResponseWriter, r *http.Request) {
+	objectAPI := api.ObjectAPI()
+	if objectAPI == nil {
+		writeErrorResponse(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	if s3Error := checkRequestAuthType(r, "", "s3:PutBucketVersioning", serverConfig.GetRegion()); s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+
+	_, err := objectAPI.GetBucketInfo(bucket)
+	if err != nil {
+		errorIf(err, "Unable to find bucket info.")
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	// If Content-Length is unknown or zero, deny the request.
+	// PutBucketVersioning always needs a Content-Length.
+	if r.ContentLength == -1 || r.ContentLength == 0 {
+		writeErrorResponse(w, ErrMissingContentLength, r.URL)
+		return
+	}
+
+	// If Content-Length is greater than maximum allowed config size.
+	if r.ContentLength > maxVersioningConfigSize {
+		writeErrorResponse(w, ErrEntityTooLarge, r.URL)
+		return
+	}
+
+	vcfg, err := parseBucketVersioning(io.LimitReader(r.Body, r.ContentLength))
+	if err != nil {
+		errorIf(err, "Unable to parse versioning configuration XML.")
+		switch err.(type) {
+		case *xml.SyntaxError, xml.UnmarshalError:
+			writeErrorResponse(w, ErrMalformedXML, r.URL)
+		case NotImplemented:
+			writeErrorResponse(w, ErrNotImplemented, r.URL)
+		default:
+			if err == errInvalidVersioningStatus {
+				writeErrorResponse(w, ErrIllegalVersioningConfiguration, r.URL)
+				return
+			}
+			writeErrorResponse(w, ErrMalformedXML, r.URL)
+		}
+		return
+	}
+
+	// Versioning can not be suspended on buckets with object lock.
+	if vcfg.Status != versioningEnabled && isBucketObjectLockEnabled(bucket) {
+		writeErrorResponse(w, ErrInvalidBucketState, r.URL)
+		return
+	}
+
+	// Parse validate and save bucket versioning config.
+	if err = persistAndNotifyBucketVersioning(bucket, vcfg, objectAPI); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	// Success.
+	writeSuccessResponseHeadersOnly(w)
+}
@@ -0,0 +1,244 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"sync"
+)
+
+const (
+	// Bucket versioning config name.
+	bucketVersioningConfig = "versioning.xml"
+
+	// Versioning status values as defined by S3.
+	versioningEnabled   = "Enabled"
+	versioningSuspended = "Suspended"
+
+	// Version ID reported for objects written while versioning was
+	// never enabled or is suspended on the bucket.
+	nullVersionID = "null"
+
+	// Directory holding all the previous versions of an object
+	// inside `.minio.sys/versions/<bucket>/<object>/`.
+	versionsDirName = ".versions"
+)
+
+// errInvalidVersioningStatus - versioning status is neither Enabled nor Suspended.
+var errInvalidVersioningStatus = errors.New("Invalid versioning status")
+
+// versioningConfiguration - represents the bucket versioning
+// configuration as sent by the PutBucketVersioning API.
+type versioningConfiguration struct {
+	XMLName   xml.Name `xml:"VersioningConfiguration"`
+	Status    string   `xml:"Status,omitempty"`
+	MFADelete string   `xml:"MfaDelete,omitempty"`
+}
+
+// Validate - validates versioning configuration.
+func (v versioningConfiguration) Validate() error {
+	if v.Status != versioningEnabled && v.Status != versioningSuspended {
+		return errInvalidVersioningStatus
+	}
+	if v.MFADelete != "" && v.MFADelete != "Disabled" {
+		// MFA delete is not supported.
+		return NotImplemented{}
+	}
+	return nil
+}
+
+// Variable represents bucket versioning status in memory.
+var globalBucketVersioning *bucketVersioning
+
+// Global bucket versioning status list, versioning is consulted
+// by the object layer on each overwrite and delete.
+type bucketVersioning struct {
+	rwMutex *sync.RWMutex
+
+	// Collection of 'bucket' versioning configs.
+	bucketVersioningConfigs map[string]*versioningConfiguration
+}
+
+// GetBucketVersioning - fetch versioning config for a given bucket.
+func (bv *bucketVersioning) GetBucketVersioning(bucket string) *versioningConfiguration {
+	if bv == nil {
+		return nil
+	}
+	bv.rwMutex.RLock()
+	defer bv.rwMutex.RUnlock()
+	return bv.bucketVersioningConfigs[bucket]
+}
+
+// SetBucketVersioning - set a new versioning config for a bucket,
+// a nil config removes any previous versioning config.
+func (bv *bucketVersioning) SetBucketVersioning(bucket string, vcfg *versioningConfiguration) {
+	if bv == nil {
+		return
+	}
+	bv.rwMutex.Lock()
+	defer bv.rwMutex.Unlock()
+	if vcfg == nil {
+		delete(bv.bucketVersioningConfigs, bucket)
+		return
+	}
+	bv.bucketVersioningConfigs[bucket] = vcfg
+}
+
+// getBucketVersioningStatus - returns the versioning status of a bucket,
+// an empty string is returned for buckets which were never versioned.
+func getBucketVersioningStatus(bucket string) string {
+	vcfg := globalBucketVersioning.GetBucketVersioning(bucket)
+	if vcfg == nil {
+		return ""
+	}
+	return vcfg.Status
+}
+
+// newObjectVersionID - generates the version ID for a new object
+// written into a bucket, empty version ID denotes the `null` version.
+func newObjectVersionID(bucket string) string {
+	if getBucketVersioningStatus(bucket) == versioningEnabled {
+		return mustGetUUID()
+	}
+	return ""
+}
+
+// Intialize all bucket versioning configs.
+func initBucketVersioning(objAPI ObjectLayer) error {
+	if objAPI == nil {
+		return errInvalidArgument
+	}
+
+	// List buckets to proceed loading all versioning configuration.
+	buckets, err := objAPI.ListBuckets()
+	errorIf(err, "Unable to list buckets.")
+	if err != nil {
+		return errorCause(err)
+	}
+
+	configs := make(map[string]*versioningConfiguration)
+	for _, bucket := range buckets {
+		vcfg, vErr := readBucketVersioning(bucket.Name, objAPI)
+		if vErr != nil {
+			// Ignore missing configs and disks which are not found.
+			if vErr == errNoSuchVersioningConfig || isErrIgnored(vErr, errDiskNotFound) {
+				continue
+			}
+			return vErr
+		}
+		configs[bucket.Name] = vcfg
+	}
+
+	// Populate global bucket collection.
+	globalBucketVersioning = &bucketVersioning{
+		rwMutex:                 &sync.RWMutex{},
+		bucketVersioningConfigs: configs,
+	}
+
+	// Success.
+	return nil
+}
+
+// errNoSuchVersioningConfig - versioning was never configured on the bucket.
+var errNoSuchVersioningConfig = errors.New("The bucket versioning configuration does not exist")
+
+// readBucketVersioning - reads bucket versioning config for an input bucket,
+// returns errNoSuchVersioningConfig if the config is not found.
+func readBucketVersioning(bucket string, objAPI ObjectLayer) (*versioningConfiguration, error) {
+	versioningPath := pathJoin(bucketConfigPrefix, bucket, bucketVersioningConfig)
+
+	// Acquire a read lock on versioning config before reading.
+	objLock := globalNSMutex.NewNSLock(minioMetaBucket, versioningPath)
+	objLock.RLock()
+	defer objLock.RUnlock()
+
+	var buffer bytes.Buffer
+	err := objAPI.GetObject(minioMetaBucket, versioningPath, 0, -1, &buffer)
+	if err != nil {
+		if isErrObjectNotFound(err) || isErrIncompleteBody(err) {
+			return nil, errNoSuchVersioningConfig
+		}
+		errorIf(err, "Unable to load versioning config for the bucket %s.", bucket)
+		return nil, errorCause(err)
+	}
+
+	return parseBucketVersioning(&buffer)
+}
+
+// parseBucketVersioning - parses and validates versioning config.
+func parseBucketVersioning(reader io.Reader) (*versioningConfiguration, error) {
+	vcfg := &versioningConfiguration{}
+	if err := xml.NewDecoder(reader).Decode(vcfg); err != nil {
+		return nil, err
+	}
+	if err := vcfg.Validate(); err != nil {
+		return nil, err
+	}
+	return vcfg, nil
+}
+
+// writeBucketVersioning - save a bucket versioning config that is
+// assumed to be validated.
+func writeBucketVersioning(bucket string, objAPI ObjectLayer, vcfg *versioningConfiguration) error {
+	buf, err := xml.Marshal(vcfg)
+	if err != nil {
+		errorIf(err, "Unable to marshal versioning config '%v' to XML", *vcfg)
+		return err
+	}
+	versioningPath := pathJoin(bucketConfigPrefix, bucket, bucketVersioningConfig)
+	// Acquire a write lock on versioning config before modifying.
+	objLock := globalNSMutex.NewNSLock(minioMetaBucket, versioningPath)
+	objLock.Lock()
+	defer objLock.Unlock()
+	if _, err = objAPI.PutObject(minioMetaBucket, versioningPath, int64(len(buf)), bytes.NewReader(buf), nil, ""); err != nil {
+		errorIf(err, "Unable to set versioning for the bucket %s", bucket)
+		return errorCause(err)
+	}
+	return nil
+}
+
+// removeBucketVersioning - removes any previously written versioning config.
+func removeBucketVersioning(bucket string, objAPI ObjectLayer) error {
+	versioningPath := pathJoin(bucketConfigPrefix, bucket, bucketVersioningConfig)
+	// Acquire a write lock on versioning config before modifying.
+	objLock := globalNSMutex.NewNSLock(minioMetaBucket, versioningPath)
+	objLock.Lock()
+	defer objLock.Unlock()
+	if err := objAPI.DeleteObject(minioMetaBucket, versioningPath); err != nil {
+		if isErrObjectNotFound(err) {
+			return errNoSuchVersioningConfig
+		}
+		return errorCause(err)
+	}
+	return nil
+}
+
+// persistAndNotifyBucketVersioning - persists the versioning config and
+// notifies all nodes in the cluster about the change. In-memory state is
+// updated in response to the notification.
+func persistAndNotifyBucketVersioning(bucket string, vcfg *versioningConfiguration, objAPI ObjectLayer) error {
+	if err := writeBucketVersioning(bucket, objAPI, vcfg); err != nil {
+		return err
+	}
+
+	// Notify all peers (including self) to update in-memory state
+	S3PeersUpdateBucketVersioning(bucket, vcfg)
+	return nil
+}
@@ -0,0 +1,87 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Tests parsing and validation of bucket versioning configs.
+func TestParseBucketVersioning(t *testing.T) {
+	testCases := []struct {
+		config       string
+		expectedErr  bool
+		expectedStat string
+	}{
+		{`<VersioningConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/"><Status>Enabled</Status></VersioningConfiguration>`, false, versioningEnabled},
+		{`<VersioningConfiguration><Status>Suspended</Status></VersioningConfiguration>`, false, versioningSuspended},
+		{`<VersioningConfiguration><Status>Suspended</Status><MfaDelete>Disabled</MfaDelete></VersioningConfiguration>`, false, versioningSuspended},
+		// MFA delete is not supported.
+		{`<VersioningConfiguration><Status>Enabled</Status><MfaDelete>Enabled</MfaDelete></VersioningConfiguration>`, true, ""},
+		// Invalid status.
+		{`<VersioningConfiguration><Status>Disabled</Status></VersioningConfiguration>`, true, ""},
+		{`<VersioningConfiguration></VersioningConfiguration>`, true, ""},
+		// Malformed XML.
+		{`<VersioningConfiguration><Status>Enabled`, true, ""},
+	}
+
+	for i, testCase := range testCases {
+		vcfg, err := parseBucketVersioning(strings.NewReader(testCase.config))
+		if testCase.expectedErr {
+			if err == nil {
+				t.Errorf("Test %d: Expected an error", i+1)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: Unexpected error %s", i+1, err)
+			continue
+		}
+		if vcfg.Status != testCase.expectedStat {
+			t.Errorf("Test %d: Expected status %s, got %s", i+1, testCase.expectedStat, vcfg.Status)
+		}
+	}
+}
+
+// Tests version IDs of new objects for each versioning status.
+func TestNewObjectVersionID(t *testing.T) {
+	globalBucketVersioning = &bucketVersioning{
+		rwMutex:                 &sync.RWMutex{},
+		bucketVersioningConfigs: make(map[string]*versioningConfiguration),
+	}
+
+	if versionID := newObjectVersionID("bucket"); versionID != "" {
+		t.Fatalf("Expected `null` version for unversioned bucket, got %s", versionID)
+	}
+
+	globalBucketVersioning.SetBucketVersioning("bucket", &versioningConfiguration{Status: versioningEnabled})
+	if versionID := newObjectVersionID("bucket"); versionID == "" {
+		t.Fatal("Expected a version ID for versioned bucket")
+	}
+
+	globalBucketVersioning.SetBucketVersioning("bucket", &versioningConfiguration{Status: versioningSuspended})
+	if versionID := newObjectVersionID("bucket"); versionID != "" {
+		t.Fatalf("Expected `null` version for suspended bucket, got %s", versionID)
+	}
+
+	globalBucketVersioning.SetBucketVersioning("bucket", nil)
+	if status := getBucketVersioningStatus("bucket"); status != "" {
+		t.Fatalf("Expected no versioning status, got %s", status)
+	}
+}
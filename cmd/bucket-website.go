@@ -0,0 +1,368 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// Bucket website config name.
+	bucketWebsiteConfig = "website.xml"
+
+	// Maximum number of routing rules in a website configuration.
+	maxWebsiteRoutingRules = 50
+)
+
+// Bucket website configuration errors.
+var (
+	errNoSuchWebsiteConfig        = errors.New("The website configuration does not exist")
+	errWebsiteNoIndexDocument     = errors.New("Website configuration should have an index document or redirect all requests")
+	errWebsiteInvalidSuffix       = errors.New("Website index document suffix should not be empty nor contain a slash")
+	errWebsiteInvalidErrorKey     = errors.New("Website error document key should not be empty")
+	errWebsiteRedirectAllOnly     = errors.New("Website configuration redirecting all requests should have no other settings")
+	errWebsiteInvalidHostName     = errors.New("Website redirect should have a host name")
+	errWebsiteInvalidProtocol     = errors.New("Website redirect protocol should be http or https")
+	errWebsiteTooManyRoutingRules = errors.New("Website configuration should have at most 50 routing rules")
+	errWebsiteInvalidRedirect     = errors.New("Website routing rule redirect should have at least one setting and not both ReplaceKeyWith and ReplaceKeyPrefixWith")
+	errWebsiteInvalidRedirectCode = errors.New("Website routing rule redirect code should be a 3XX code")
+	errWebsiteInvalidErrorCode    = errors.New("Website routing rule condition error code should be a 4XX or 5XX code")
+)
+
+// websiteIndexDocument - the object served for requests to a directory.
+type websiteIndexDocument struct {
+	Suffix string `xml:"Suffix"`
+}
+
+// websiteErrorDocument - the object served for requests failing with
+// a 4XX error.
+type websiteErrorDocument struct {
+	Key string `xml:"Key"`
+}
+
+// websiteRedirectAllRequestsTo - redirects all requests to another host.
+type websiteRedirectAllRequestsTo struct {
+	HostName string `xml:"HostName"`
+	Protocol string `xml:"Protocol,omitempty"`
+}
+
+// websiteRoutingRuleCondition - condition of a routing rule, matching
+// requests by key prefix and by the error code of the response.
+type websiteRoutingRuleCondition struct {
+	KeyPrefixEquals             string `xml:"KeyPrefixEquals,omitempty"`
+	HTTPErrorCodeReturnedEquals string `xml:"HttpErrorCodeReturnedEquals,omitempty"`
+}
+
+// websiteRedirect - redirect of a routing rule, any unset part of the
+// redirect is taken from the request.
+type websiteRedirect struct {
+	Protocol             string `xml:"Protocol,omitempty"`
+	HostName             string `xml:"HostName,omitempty"`
+	ReplaceKeyPrefixWith string `xml:"ReplaceKeyPrefixWith,omitempty"`
+	ReplaceKeyWith       string `xml:"ReplaceKeyWith,omitempty"`
+	HTTPRedirectCode     string `xml:"HttpRedirectCode,omitempty"`
+}
+
+// websiteRoutingRule - redirects requests matching its condition.
+type websiteRoutingRule struct {
+	Condition *websiteRoutingRuleCondition `xml:"Condition"`
+	Redirect  websiteRedirect              `xml:"Redirect"`
+}
+
+// isValidRedirectProtocol - returns true if the protocol is empty, then
+// the protocol of the request is kept, or http or https.
+func isValidRedirectProtocol(protocol string) bool {
+	return protocol == "" || protocol == "http" || protocol == "https"
+}
+
+// Validate - validates a routing rule.
+func (r websiteRoutingRule) Validate() error {
+	if c := r.Condition; c != nil && c.HTTPErrorCodeReturnedEquals != "" {
+		code, err := strconv.Atoi(c.HTTPErrorCodeReturnedEquals)
+		if err != nil || code < 400 || code > 599 {
+			return errWebsiteInvalidErrorCode
+		}
+	}
+	redirect := r.Redirect
+	if redirect == (websiteRedirect{}) ||
+		(redirect.ReplaceKeyPrefixWith != "" && redirect.ReplaceKeyWith != "") {
+		return errWebsiteInvalidRedirect
+	}
+	if !isValidRedirectProtocol(redirect.Protocol) {
+		return errWebsiteInvalidProtocol
+	}
+	if redirect.HTTPRedirectCode != "" {
+		code, err := strconv.Atoi(redirect.HTTPRedirectCode)
+		if err != nil || code < 300 || code > 399 {
+			return errWebsiteInvalidRedirectCode
+		}
+	}
+	return nil
+}
+
+// Match - returns true if the rule applies to a request for the key,
+// which failed with the status code if it is not zero.
+func (r websiteRoutingRule) Match(key string, status int) bool {
+	if r.Condition == nil {
+		return status == 0
+	}
+	if !strings.HasPrefix(key, r.Condition.KeyPrefixEquals) {
+		return false
+	}
+	if r.Condition.HTTPErrorCodeReturnedEquals == "" {
+		return status == 0
+	}
+	return r.Condition.HTTPErrorCodeReturnedEquals == strconv.Itoa(status)
+}
+
+// RedirectKey - returns the key the request for key is redirected to.
+func (r websiteRoutingRule) RedirectKey(key string) string {
+	switch {
+	case r.Redirect.ReplaceKeyWith != "":
+		return r.Redirect.ReplaceKeyWith
+	case r.Redirect.ReplaceKeyPrefixWith != "":
+		prefix := ""
+		if r.Condition != nil {
+			prefix = r.Condition.KeyPrefixEquals
+		}
+		return r.Redirect.ReplaceKeyPrefixWith + strings.TrimPrefix(key, prefix)
+	}
+	return key
+}
+
+// websiteConfiguration - represents the bucket website configuration
+// as sent by the PutBucketWebsite API.
+type websiteConfiguration struct {
+	XMLName               xml.Name                      `xml:"WebsiteConfiguration"`
+	RedirectAllRequestsTo *websiteRedirectAllRequestsTo `xml:"RedirectAllRequestsTo"`
+	IndexDocument         *websiteIndexDocument         `xml:"IndexDocument"`
+	ErrorDocument         *websiteErrorDocument         `xml:"ErrorDocument"`
+	RoutingRules          []websiteRoutingRule          `xml:"RoutingRules>RoutingRule"`
+}
+
+// Validate - validates website configuration.
+func (c websiteConfiguration) Validate() error {
+	if redirect := c.RedirectAllRequestsTo; redirect != nil {
+		if c.IndexDocument != nil || c.ErrorDocument != nil || len(c.RoutingRules) != 0 {
+			return errWebsiteRedirectAllOnly
+		}
+		if redirect.HostName == "" {
+			return errWebsiteInvalidHostName
+		}
+		if !isValidRedirectProtocol(redirect.Protocol) {
+			return errWebsiteInvalidProtocol
+		}
+		return nil
+	}
+	if c.IndexDocument == nil {
+		return errWebsiteNoIndexDocument
+	}
+	if suffix := c.IndexDocument.Suffix; suffix == "" || strings.Contains(suffix, "/") {
+		return errWebsiteInvalidSuffix
+	}
+	if c.ErrorDocument != nil && c.ErrorDocument.Key == "" {
+		return errWebsiteInvalidErrorKey
+	}
+	if len(c.RoutingRules) > maxWebsiteRoutingRules {
+		return errWebsiteTooManyRoutingRules
+	}
+	for _, rule := range c.RoutingRules {
+		if err := rule.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findRoutingRule - returns the first routing rule matching a request
+// for the key, which failed with the status code if it is not zero.
+func (c websiteConfiguration) findRoutingRule(key string, status int) *websiteRoutingRule {
+	for i := range c.RoutingRules {
+		if c.RoutingRules[i].Match(key, status) {
+			return &c.RoutingRules[i]
+		}
+	}
+	return nil
+}
+
+// Variable represents bucket website configs in memory.
+var globalBucketWebsite *bucketWebsite
+
+// Global bucket website config list, consulted on each
+// website request.
+type bucketWebsite struct {
+	rwMutex *sync.RWMutex
+
+	// Collection of 'bucket' website configs.
+	bucketWebsiteConfigs map[string]*websiteConfiguration
+}
+
+// GetBucketWebsite - fetch website config for a given bucket.
+func (bw *bucketWebsite) GetBucketWebsite(bucket string) *websiteConfiguration {
+	if bw == nil {
+		return nil
+	}
+	bw.rwMutex.RLock()
+	defer bw.rwMutex.RUnlock()
+	return bw.bucketWebsiteConfigs[bucket]
+}
+
+// SetBucketWebsite - set a new website config for a bucket, a nil
+// config removes any previous website config.
+func (bw *bucketWebsite) SetBucketWebsite(bucket string, wcfg *websiteConfiguration) {
+	if bw == nil {
+		return
+	}
+	bw.rwMutex.Lock()
+	defer bw.rwMutex.Unlock()
+	if wcfg == nil {
+		delete(bw.bucketWebsiteConfigs, bucket)
+		return
+	}
+	bw.bucketWebsiteConfigs[bucket] = wcfg
+}
+
+// Intialize all bucket website configs.
+func initBucketWebsite(objAPI ObjectLayer) error {
+	if objAPI == nil {
+		return errInvalidArgument
+	}
+
+	// List buckets to proceed loading all website configuration.
+	buckets, err := objAPI.ListBuckets()
+	errorIf(err, "Unable to list buckets.")
+	if err != nil {
+		return errorCause(err)
+	}
+
+	configs := make(map[string]*websiteConfiguration)
+	for _, bucket := range buckets {
+		wcfg, wErr := readBucketWebsite(bucket.Name, objAPI)
+		if wErr != nil {
+			// Ignore missing configs and disks which are not found.
+			if wErr == errNoSuchWebsiteConfig || isErrIgnored(wErr, errDiskNotFound) {
+				continue
+			}
+			return wErr
+		}
+		configs[bucket.Name] = wcfg
+	}
+
+	// Populate global bucket collection.
+	globalBucketWebsite = &bucketWebsite{
+		rwMutex:              &sync.RWMutex{},
+		bucketWebsiteConfigs: configs,
+	}
+
+	// Success.
+	return nil
+}
+
+// readBucketWebsite - reads bucket website config for an input bucket,
+// returns errNoSuchWebsiteConfig if the config is not found.
+func readBucketWebsite(bucket string, objAPI ObjectLayer) (*websiteConfiguration, error) {
+	websitePath := pathJoin(bucketConfigPrefix, bucket, bucketWebsiteConfig)
+
+	// Acquire a read lock on website config before reading.
+	objLock := globalNSMutex.NewNSLock(minioMetaBucket, websitePath)
+	objLock.RLock()
+	defer objLock.RUnlock()
+
+	var buffer bytes.Buffer
+	err := objAPI.GetObject(minioMetaBucket, websitePath, 0, -1, &buffer)
+	if err != nil {
+		if isErrObjectNotFound(err) || isErrIncompleteBody(err) {
+			return nil, errNoSuchWebsiteConfig
+		}
+		errorIf(err, "Unable to load website config for the bucket %s.", bucket)
+		return nil, errorCause(err)
+	}
+
+	return parseBucketWebsite(&buffer)
+}
+
+// parseBucketWebsite - parses and validates website config.
+func parseBucketWebsite(reader io.Reader) (*websiteConfiguration, error) {
+	wcfg := &websiteConfiguration{}
+	if err := xml.NewDecoder(reader).Decode(wcfg); err != nil {
+		return nil, err
+	}
+	if err := wcfg.Validate(); err != nil {
+		return nil, err
+	}
+	return wcfg, nil
+}
+
+// writeBucketWebsite - save a bucket website config that is assumed
+// to be validated.
+func writeBucketWebsite(bucket string, objAPI ObjectLayer, wcfg *websiteConfiguration) error {
+	buf, err := xml.Marshal(wcfg)
+	if err != nil {
+		errorIf(err, "Unable to marshal website config '%v' to XML", *wcfg)
+		return err
+	}
+	websitePath := pathJoin(bucketConfigPrefix, bucket, bucketWebsiteConfig)
+	// Acquire a write lock on website config before modifying.
+	objLock := globalNSMutex.NewNSLock(minioMetaBucket, websitePath)
+	objLock.Lock()
+	defer objLock.Unlock()
+	if _, err = objAPI.PutObject(minioMetaBucket, websitePath, int64(len(buf)), bytes.NewReader(buf), nil, ""); err != nil {
+		errorIf(err, "Unable to set website for the bucket %s", bucket)
+		return errorCause(err)
+	}
+	return nil
+}
+
+// removeBucketWebsite - removes any previously written website config.
+func removeBucketWebsite(bucket string, objAPI ObjectLayer) error {
+	websitePath := pathJoin(bucketConfigPrefix, bucket, bucketWebsiteConfig)
+	// Acquire a write lock on website config before modifying.
+	objLock := globalNSMutex.NewNSLock(minioMetaBucket, websitePath)
+	objLock.Lock()
+	defer objLock.Unlock()
+	if err := objAPI.DeleteObject(minioMetaBucket, websitePath); err != nil {
+		if isErrObjectNotFound(err) {
+			return errNoSuchWebsiteConfig
+		}
+		return errorCause(err)
+	}
+	return nil
+}
+
+// persistAndNotifyBucketWebsite - persists the website config and
+// notifies all nodes in the cluster about the change, a nil config
+// removes the persisted config. In-memory state is updated in response
+// to the notification.
+func persistAndNotifyBucketWebsite(bucket string, wcfg *websiteConfiguration, objAPI ObjectLayer) error {
+	if wcfg == nil {
+		if err := removeBucketWebsite(bucket, objAPI); err != nil && err != errNoSuchWebsiteConfig {
+			return err
+		}
+	} else if err := writeBucketWebsite(bucket, objAPI, wcfg); err != nil {
+		return err
+	}
+
+	// Notify all peers (including self) to update in-memory state
+	S3PeersUpdateBucketWebsite(bucket, wcfg)
+	return nil
+}
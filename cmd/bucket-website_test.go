@@ -0,0 +1,95 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+// Tests parsing and validation of bucket website configs.
+func TestParseBucketWebsite(t *testing.T) {
+	testCases := []struct {
+		config      string
+		expectedErr error
+	}{
+		// Index and error documents.
+		{`<WebsiteConfiguration><IndexDocument><Suffix>index.html</Suffix></IndexDocument><ErrorDocument><Key>404.html</Key></ErrorDocument></WebsiteConfiguration>`, nil},
+		// Redirect of all requests.
+		{`<WebsiteConfiguration><RedirectAllRequestsTo><HostName>example.com</HostName><Protocol>https</Protocol></RedirectAllRequestsTo></WebsiteConfiguration>`, nil},
+		// Routing rule on error code.
+		{`<WebsiteConfiguration><IndexDocument><Suffix>index.html</Suffix></IndexDocument><RoutingRules><RoutingRule><Condition><HttpErrorCodeReturnedEquals>404</HttpErrorCodeReturnedEquals></Condition><Redirect><ReplaceKeyWith>404.html</ReplaceKeyWith><HttpRedirectCode>302</HttpRedirectCode></Redirect></RoutingRule></RoutingRules></WebsiteConfiguration>`, nil},
+		// No index document.
+		{`<WebsiteConfiguration><ErrorDocument><Key>404.html</Key></ErrorDocument></WebsiteConfiguration>`, errWebsiteNoIndexDocument},
+		// Index document suffix with a slash.
+		{`<WebsiteConfiguration><IndexDocument><Suffix>a/index.html</Suffix></IndexDocument></WebsiteConfiguration>`, errWebsiteInvalidSuffix},
+		// Empty error document key.
+		{`<WebsiteConfiguration><IndexDocument><Suffix>index.html</Suffix></IndexDocument><ErrorDocument><Key></Key></ErrorDocument></WebsiteConfiguration>`, errWebsiteInvalidErrorKey},
+		// Redirect of all requests with other settings.
+		{`<WebsiteConfiguration><RedirectAllRequestsTo><HostName>example.com</HostName></RedirectAllRequestsTo><IndexDocument><Suffix>index.html</Suffix></IndexDocument></WebsiteConfiguration>`, errWebsiteRedirectAllOnly},
+		// Redirect of all requests without host.
+		{`<WebsiteConfiguration><RedirectAllRequestsTo><Protocol>https</Protocol></RedirectAllRequestsTo></WebsiteConfiguration>`, errWebsiteInvalidHostName},
+		// Unsupported protocol.
+		{`<WebsiteConfiguration><RedirectAllRequestsTo><HostName>example.com</HostName><Protocol>ftp</Protocol></RedirectAllRequestsTo></WebsiteConfiguration>`, errWebsiteInvalidProtocol},
+		// Routing rule replacing both key and prefix.
+		{`<WebsiteConfiguration><IndexDocument><Suffix>index.html</Suffix></IndexDocument><RoutingRules><RoutingRule><Redirect><ReplaceKeyWith>a</ReplaceKeyWith><ReplaceKeyPrefixWith>b</ReplaceKeyPrefixWith></Redirect></RoutingRule></RoutingRules></WebsiteConfiguration>`, errWebsiteInvalidRedirect},
+		// Routing rule with a non redirect code.
+		{`<WebsiteConfiguration><IndexDocument><Suffix>index.html</Suffix></IndexDocument><RoutingRules><RoutingRule><Redirect><ReplaceKeyWith>a</ReplaceKeyWith><HttpRedirectCode>200</HttpRedirectCode></Redirect></RoutingRule></RoutingRules></WebsiteConfiguration>`, errWebsiteInvalidRedirectCode},
+		// Routing rule condition on a non error code.
+		{`<WebsiteConfiguration><IndexDocument><Suffix>index.html</Suffix></IndexDocument><RoutingRules><RoutingRule><Condition><HttpErrorCodeReturnedEquals>200</HttpErrorCodeReturnedEquals></Condition><Redirect><ReplaceKeyWith>a</ReplaceKeyWith></Redirect></RoutingRule></RoutingRules></WebsiteConfiguration>`, errWebsiteInvalidErrorCode},
+		// Too many routing rules.
+		{`<WebsiteConfiguration><IndexDocument><Suffix>index.html</Suffix></IndexDocument><RoutingRules>` + strings.Repeat(`<RoutingRule><Redirect><ReplaceKeyWith>a</ReplaceKeyWith></Redirect></RoutingRule>`, maxWebsiteRoutingRules+1) + `</RoutingRules></WebsiteConfiguration>`, errWebsiteTooManyRoutingRules},
+	}
+
+	for i, testCase := range testCases {
+		_, err := parseBucketWebsite(strings.NewReader(testCase.config))
+		if err != testCase.expectedErr {
+			t.Errorf("Test %d: Expected error %v, got %v", i+1, testCase.expectedErr, err)
+		}
+	}
+}
+
+// Tests matching of routing rules and the keys they redirect to.
+func TestWebsiteRoutingRule(t *testing.T) {
+	testCases := []struct {
+		rule        websiteRoutingRule
+		key         string
+		status      int
+		match       bool
+		redirectKey string
+	}{
+		{websiteRoutingRule{&websiteRoutingRuleCondition{KeyPrefixEquals: "docs/"}, websiteRedirect{ReplaceKeyPrefixWith: "documents/"}}, "docs/a.html", 0, true, "documents/a.html"},
+		{websiteRoutingRule{&websiteRoutingRuleCondition{KeyPrefixEquals: "docs/"}, websiteRedirect{ReplaceKeyPrefixWith: "documents/"}}, "img/a.png", 0, false, ""},
+		{websiteRoutingRule{&websiteRoutingRuleCondition{KeyPrefixEquals: "docs/"}, websiteRedirect{ReplaceKeyPrefixWith: "documents/"}}, "docs/a.html", 404, false, ""},
+		{websiteRoutingRule{&websiteRoutingRuleCondition{HTTPErrorCodeReturnedEquals: "404"}, websiteRedirect{ReplaceKeyWith: "404.html"}}, "a.html", 404, true, "404.html"},
+		{websiteRoutingRule{&websiteRoutingRuleCondition{HTTPErrorCodeReturnedEquals: "404"}, websiteRedirect{ReplaceKeyWith: "404.html"}}, "a.html", 0, false, ""},
+		{websiteRoutingRule{nil, websiteRedirect{HostName: "example.com"}}, "a.html", 0, true, "a.html"},
+	}
+
+	for i, testCase := range testCases {
+		if match := testCase.rule.Match(testCase.key, testCase.status); match != testCase.match {
+			t.Errorf("Test %d: Expected match %v, got %v", i+1, testCase.match, match)
+			continue
+		}
+		if !testCase.match {
+			continue
+		}
+		if key := testCase.rule.RedirectKey(testCase.key); key != testCase.redirectKey {
+			t.Errorf("Test %d: Expected redirect key %s, got %s", i+1, testCase.redirectKey, key)
+		}
+	}
+}
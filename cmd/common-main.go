@@ -50,13 +50,15 @@ func enableLoggers() {
 	}
 
 	log.SetConsoleTarget(consoleLogTarget)
+
+	fatalIf(initAuditLogger(serverConfig.Audit), "Unable to initialize audit logger")
 }
 
 func initConfig() {
 	// Config file does not exist, we create it fresh and return upon success.
 	if isFile(getConfigFile()) {
 		fatalIf(migrateConfig(), "Config migration failed.")
-		fatalIf(loadConfig(), "Unable to load config version: '%s'.", v19)
+		fatalIf(loadConfig(), "Unable to load config version: '%s'.", v23)
 	} else {
 		fatalIf(newConfig(), "Unable to initialize minio config for the first time.")
 		log.Println("Created minio configuration file successfully at " + getConfigDir())
@@ -114,4 +116,25 @@ func handleCommonEnvVars() {
 		globalIsEnvBrowser = true
 		globalIsBrowserEnabled = bool(browserFlag)
 	}
+
+	if domains := os.Getenv("MINIO_DOMAIN"); domains != "" {
+		for _, domain := range strings.Split(domains, ",") {
+			domain = strings.ToLower(strings.TrimSpace(domain))
+			if !isValidDomain(domain) {
+				fatalIf(errors.New("invalid domain"), "Unknown value ‘%s’ in MINIO_DOMAIN environment variable.", domain)
+			}
+			globalDomains = append(globalDomains, domain)
+		}
+
+		// domain Envs are set globally.
+		globalIsEnvDomains = true
+	}
+
+	if authType := os.Getenv("MINIO_PROMETHEUS_AUTH_TYPE"); authType != "" {
+		authType = strings.ToLower(authType)
+		if authType != prometheusJWT && authType != prometheusPublic {
+			fatalIf(errors.New("invalid value"), "Unknown value ‘%s’ in MINIO_PROMETHEUS_AUTH_TYPE environment variable.", authType)
+		}
+		globalPrometheusAuthType = authType
+	}
 }
@@ -147,7 +147,31 @@ func migrateConfig() error {
 			return err
 		}
 		fallthrough
-	case v19:
+	case "19":
+		// Migrate version '19' to '20'.
+		if err = migrateV19ToV20(); err != nil {
+			return err
+		}
+		fallthrough
+	case "20":
+		// Migrate version '20' to '21'.
+		if err = migrateV20ToV21(); err != nil {
+			return err
+		}
+		fallthrough
+	case "21":
+		// Migrate version '21' to '22'.
+		if err = migrateV21ToV22(); err != nil {
+			return err
+		}
+		fallthrough
+	case "22":
+		// Migrate version '22' to '23'.
+		if err = migrateV22ToV23(); err != nil {
+			return err
+		}
+		fallthrough
+	case v23:
 		// No migration needed. this always points to current version.
 		err = nil
 	}
@@ -1478,3 +1502,173 @@ func migrateV18ToV19() error {
 	log.Printf(configMigrateMSGTemplate, configFile, cv18.Version, srvConfig.Version)
 	return nil
 }
+
+func migrateV19ToV20() error {
+	configFile := getConfigFile()
+
+	cv19 := &serverConfigV19{}
+	_, err := quick.Load(configFile, cv19)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("Unable to load config version ‘19’. %v", err)
+	}
+	if cv19.Version != "19" {
+		return nil
+	}
+
+	// Copy over fields from V19 into V20 config struct, V19
+	// has no key management service so it is left empty.
+	srvConfig := &serverConfigV20{
+		Logger: &loggers{},
+		Notify: &notifier{},
+	}
+	srvConfig.Version = "20"
+	srvConfig.Credential = cv19.Credential
+	srvConfig.Region = cv19.Region
+	if srvConfig.Region == "" {
+		// Region needs to be set for AWS Signature Version 4.
+		srvConfig.Region = globalMinioDefaultRegion
+	}
+	srvConfig.Browser = cv19.Browser
+	srvConfig.Logger.Console = cv19.Logger.Console
+	srvConfig.Logger.File = cv19.Logger.File
+	srvConfig.Notify = cv19.Notify
+
+	if err = quick.Save(configFile, srvConfig); err != nil {
+		return fmt.Errorf("Failed to migrate config from ‘%s’ to ‘%s’. %v", cv19.Version, srvConfig.Version, err)
+	}
+
+	log.Printf(configMigrateMSGTemplate, configFile, cv19.Version, srvConfig.Version)
+	return nil
+}
+
+func migrateV20ToV21() error {
+	configFile := getConfigFile()
+
+	cv20 := &serverConfigV20{}
+	_, err := quick.Load(configFile, cv20)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("Unable to load config version ‘20’. %v", err)
+	}
+	if cv20.Version != "20" {
+		return nil
+	}
+
+	// Copy over fields from V20 into V21 config struct, V20
+	// has no domains so buckets are only addressed by path.
+	srvConfig := &serverConfigV21{
+		Logger: &loggers{},
+		Notify: &notifier{},
+	}
+	srvConfig.Version = "21"
+	srvConfig.Credential = cv20.Credential
+	srvConfig.Region = cv20.Region
+	if srvConfig.Region == "" {
+		// Region needs to be set for AWS Signature Version 4.
+		srvConfig.Region = globalMinioDefaultRegion
+	}
+	srvConfig.Browser = cv20.Browser
+	srvConfig.Logger.Console = cv20.Logger.Console
+	srvConfig.Logger.File = cv20.Logger.File
+	srvConfig.Notify = cv20.Notify
+	srvConfig.KMS = cv20.KMS
+
+	if err = quick.Save(configFile, srvConfig); err != nil {
+		return fmt.Errorf("Failed to migrate config from ‘%s’ to ‘%s’. %v", cv20.Version, srvConfig.Version, err)
+	}
+
+	log.Printf(configMigrateMSGTemplate, configFile, cv20.Version, srvConfig.Version)
+	return nil
+}
+
+func migrateV21ToV22() error {
+	configFile := getConfigFile()
+
+	cv21 := &serverConfigV21{}
+	_, err := quick.Load(configFile, cv21)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("Unable to load config version ‘21’. %v", err)
+	}
+	if cv21.Version != "21" {
+		return nil
+	}
+
+	// Copy over fields from V21 into V22 config struct, V21
+	// has no audit log targets so auditing stays disabled.
+	srvConfig := &serverConfigV22{
+		Logger: &loggers{},
+		Audit:  newAuditConfig(),
+		Notify: &notifier{},
+	}
+	srvConfig.Version = "22"
+	srvConfig.Credential = cv21.Credential
+	srvConfig.Region = cv21.Region
+	if srvConfig.Region == "" {
+		// Region needs to be set for AWS Signature Version 4.
+		srvConfig.Region = globalMinioDefaultRegion
+	}
+	srvConfig.Browser = cv21.Browser
+	srvConfig.Domains = cv21.Domains
+	srvConfig.Logger.Console = cv21.Logger.Console
+	srvConfig.Logger.File = cv21.Logger.File
+	srvConfig.Notify = cv21.Notify
+	srvConfig.KMS = cv21.KMS
+
+	if err = quick.Save(configFile, srvConfig); err != nil {
+		return fmt.Errorf("Failed to migrate config from ‘%s’ to ‘%s’. %v", cv21.Version, srvConfig.Version, err)
+	}
+
+	log.Printf(configMigrateMSGTemplate, configFile, cv21.Version, srvConfig.Version)
+	return nil
+}
+
+func migrateV22ToV23() error {
+	configFile := getConfigFile()
+
+	cv22 := &serverConfigV22{}
+	_, err := quick.Load(configFile, cv22)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("Unable to load config version ‘22’. %v", err)
+	}
+	if cv22.Version != "22" {
+		return nil
+	}
+
+	// Copy over fields from V22 into V23 config struct, V22 has
+	// no storage classes so objects keep the default parity.
+	srvConfig := &serverConfigV23{
+		Logger: &loggers{},
+		Audit:  newAuditConfig(),
+		Notify: &notifier{},
+	}
+	srvConfig.Version = "23"
+	srvConfig.Credential = cv22.Credential
+	srvConfig.Region = cv22.Region
+	if srvConfig.Region == "" {
+		// Region needs to be set for AWS Signature Version 4.
+		srvConfig.Region = globalMinioDefaultRegion
+	}
+	srvConfig.Browser = cv22.Browser
+	srvConfig.Domains = cv22.Domains
+	srvConfig.Logger.Console = cv22.Logger.Console
+	srvConfig.Logger.File = cv22.Logger.File
+	if cv22.Audit != nil {
+		srvConfig.Audit = cv22.Audit
+	}
+	srvConfig.Notify = cv22.Notify
+	srvConfig.KMS = cv22.KMS
+
+	if err = quick.Save(configFile, srvConfig); err != nil {
+		return fmt.Errorf("Failed to migrate config from ‘%s’ to ‘%s’. %v", cv22.Version, srvConfig.Version, err)
+	}
+
+	log.Printf(configMigrateMSGTemplate, configFile, cv22.Version, srvConfig.Version)
+	return nil
+}
@@ -468,3 +468,92 @@ type serverConfigV18 struct {
 	// Notification queue configuration.
 	Notify *notifier `json:"notify"`
 }
+
+// serverConfigV19 server configuration version '19' which is like
+// version '18' except it adds support for MQTT notifications.
+type serverConfigV19 struct {
+	sync.RWMutex
+	Version string `json:"version"`
+
+	// S3 API configuration.
+	Credential credential  `json:"credential"`
+	Region     string      `json:"region"`
+	Browser    BrowserFlag `json:"browser"`
+
+	// Additional error logging configuration.
+	Logger *loggers `json:"logger"`
+
+	// Notification queue configuration.
+	Notify *notifier `json:"notify"`
+}
+
+// serverConfigV20 server configuration version '20' which is like
+// version '19' except it adds the key management service used for
+// server side encryption with server managed keys.
+type serverConfigV20 struct {
+	sync.RWMutex
+	Version string `json:"version"`
+
+	// S3 API configuration.
+	Credential credential  `json:"credential"`
+	Region     string      `json:"region"`
+	Browser    BrowserFlag `json:"browser"`
+
+	// Additional error logging configuration.
+	Logger *loggers `json:"logger"`
+
+	// Notification queue configuration.
+	Notify *notifier `json:"notify"`
+
+	// Key management service configuration.
+	KMS kmsConfig `json:"kms"`
+}
+
+// serverConfigV21 server configuration version '21' which is like
+// version '20' except it adds the domains of the server, under which
+// buckets are addressed in virtual host style.
+type serverConfigV21 struct {
+	sync.RWMutex
+	Version string `json:"version"`
+
+	// S3 API configuration.
+	Credential credential  `json:"credential"`
+	Region     string      `json:"region"`
+	Browser    BrowserFlag `json:"browser"`
+	Domains    []string    `json:"domains"`
+
+	// Additional error logging configuration.
+	Logger *loggers `json:"logger"`
+
+	// Notification queue configuration.
+	Notify *notifier `json:"notify"`
+
+	// Key management service configuration.
+	KMS kmsConfig `json:"kms"`
+}
+
+// serverConfigV22 server configuration version '22' which is like
+// version '21' except it adds the audit log targets, which receive a
+// record of every request served.
+type serverConfigV22 struct {
+	sync.RWMutex
+	Version string `json:"version"`
+
+	// S3 API configuration.
+	Credential credential  `json:"credential"`
+	Region     string      `json:"region"`
+	Browser    BrowserFlag `json:"browser"`
+	Domains    []string    `json:"domains"`
+
+	// Additional error logging configuration.
+	Logger *loggers `json:"logger"`
+
+	// Audit logging configuration.
+	Audit *auditConfig `json:"audit"`
+
+	// Notification queue configuration.
+	Notify *notifier `json:"notify"`
+
+	// Key management service configuration.
+	KMS kmsConfig `json:"kms"`
+}
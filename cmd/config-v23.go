@@ -27,17 +27,18 @@ import (
 )
 
 // Config version
-const v19 = "19"
+const v23 = "23"
 
 var (
 	// serverConfig server config.
-	serverConfig   *serverConfigV19
+	serverConfig   *serverConfigV23
 	serverConfigMu sync.RWMutex
 )
 
-// serverConfigV19 server configuration version '19' which is like
-// version '18' except it adds support for MQTT notifications.
-type serverConfigV19 struct {
+// serverConfigV23 server configuration version '23' which is like
+// version '22' except it adds the storage classes, which set the parity
+// of objects stored in erasure coded setups.
+type serverConfigV23 struct {
 	sync.RWMutex
 	Version string `json:"version"`
 
@@ -45,16 +46,26 @@ type serverConfigV19 struct {
 	Credential credential  `json:"credential"`
 	Region     string      `json:"region"`
 	Browser    BrowserFlag `json:"browser"`
+	Domains    []string    `json:"domains"`
 
 	// Additional error logging configuration.
 	Logger *loggers `json:"logger"`
 
+	// Audit logging configuration.
+	Audit *auditConfig `json:"audit"`
+
 	// Notification queue configuration.
 	Notify *notifier `json:"notify"`
+
+	// Key management service configuration.
+	KMS kmsConfig `json:"kms"`
+
+	// Storage class configuration.
+	StorageClass storageClassConfig `json:"storageclass"`
 }
 
 // GetVersion get current config version.
-func (s *serverConfigV19) GetVersion() string {
+func (s *serverConfigV23) GetVersion() string {
 	s.RLock()
 	defer s.RUnlock()
 
@@ -62,7 +73,7 @@ func (s *serverConfigV19) GetVersion() string {
 }
 
 // SetRegion set new region.
-func (s *serverConfigV19) SetRegion(region string) {
+func (s *serverConfigV23) SetRegion(region string) {
 	s.Lock()
 	defer s.Unlock()
 
@@ -70,7 +81,7 @@ func (s *serverConfigV19) SetRegion(region string) {
 }
 
 // GetRegion get current region.
-func (s *serverConfigV19) GetRegion() string {
+func (s *serverConfigV23) GetRegion() string {
 	s.RLock()
 	defer s.RUnlock()
 
@@ -78,7 +89,7 @@ func (s *serverConfigV19) GetRegion() string {
 }
 
 // SetCredentials set new credentials.
-func (s *serverConfigV19) SetCredential(creds credential) {
+func (s *serverConfigV23) SetCredential(creds credential) {
 	s.Lock()
 	defer s.Unlock()
 
@@ -87,7 +98,7 @@ func (s *serverConfigV19) SetCredential(creds credential) {
 }
 
 // GetCredentials get current credentials.
-func (s *serverConfigV19) GetCredential() credential {
+func (s *serverConfigV23) GetCredential() credential {
 	s.RLock()
 	defer s.RUnlock()
 
@@ -95,7 +106,7 @@ func (s *serverConfigV19) GetCredential() credential {
 }
 
 // SetBrowser set if browser is enabled.
-func (s *serverConfigV19) SetBrowser(b bool) {
+func (s *serverConfigV23) SetBrowser(b bool) {
 	s.Lock()
 	defer s.Unlock()
 
@@ -104,15 +115,48 @@ func (s *serverConfigV19) SetBrowser(b bool) {
 }
 
 // GetCredentials get current credentials.
-func (s *serverConfigV19) GetBrowser() bool {
+func (s *serverConfigV23) GetBrowser() bool {
 	s.RLock()
 	defer s.RUnlock()
 
 	return bool(s.Browser)
 }
 
+// SetDomains set new server domains.
+func (s *serverConfigV23) SetDomains(domains []string) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.Domains = domains
+}
+
+// GetDomains get current server domains.
+func (s *serverConfigV23) GetDomains() []string {
+	s.RLock()
+	defer s.RUnlock()
+
+	return s.Domains
+}
+
+// SetStorageClass set new storage classes.
+func (s *serverConfigV23) SetStorageClass(standard, rrs storageClass) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.StorageClass.Standard = standard
+	s.StorageClass.RRS = rrs
+}
+
+// GetStorageClass get current storage classes.
+func (s *serverConfigV23) GetStorageClass() (storageClass, storageClass) {
+	s.RLock()
+	defer s.RUnlock()
+
+	return s.StorageClass.Standard, s.StorageClass.RRS
+}
+
 // Save config.
-func (s *serverConfigV19) Save() error {
+func (s *serverConfigV23) Save() error {
 	s.RLock()
 	defer s.RUnlock()
 
@@ -120,13 +164,14 @@ func (s *serverConfigV19) Save() error {
 	return quick.Save(getConfigFile(), s)
 }
 
-func newServerConfigV19() *serverConfigV19 {
-	srvCfg := &serverConfigV19{
-		Version:    v19,
+func newServerConfigV23() *serverConfigV23 {
+	srvCfg := &serverConfigV23{
+		Version:    v23,
 		Credential: mustGetNewCredential(),
 		Region:     globalMinioDefaultRegion,
 		Browser:    true,
 		Logger:     &loggers{},
+		Audit:      newAuditConfig(),
 		Notify:     &notifier{},
 	}
 
@@ -160,7 +205,7 @@ func newServerConfigV19() *serverConfigV19 {
 // found, otherwise use default parameters
 func newConfig() error {
 	// Initialize server config.
-	srvCfg := newServerConfigV19()
+	srvCfg := newServerConfigV23()
 
 	// If env is set override the credentials from config file.
 	if globalIsEnvCreds {
@@ -175,6 +220,14 @@ func newConfig() error {
 		srvCfg.SetRegion(globalServerRegion)
 	}
 
+	if globalIsEnvDomains {
+		srvCfg.SetDomains(globalDomains)
+	}
+
+	if globalIsEnvStorageClass {
+		srvCfg.SetStorageClass(globalStandardStorageClass, globalRRStorageClass)
+	}
+
 	// hold the mutex lock before a new config is assigned.
 	// Save the new config globally.
 	// unlock the mutex.
@@ -238,8 +291,8 @@ func checkDupJSONKeys(json string) error {
 }
 
 // getValidConfig - returns valid server configuration
-func getValidConfig() (*serverConfigV19, error) {
-	srvCfg := &serverConfigV19{
+func getValidConfig() (*serverConfigV23, error) {
+	srvCfg := &serverConfigV23{
 		Region:  globalMinioDefaultRegion,
 		Browser: true,
 	}
@@ -249,8 +302,8 @@ func getValidConfig() (*serverConfigV19, error) {
 		return nil, err
 	}
 
-	if srvCfg.Version != v19 {
-		return nil, fmt.Errorf("configuration version mismatch. Expected: ‘%s’, Got: ‘%s’", v19, srvCfg.Version)
+	if srvCfg.Version != v23 {
+		return nil, fmt.Errorf("configuration version mismatch. Expected: ‘%s’, Got: ‘%s’", v23, srvCfg.Version)
 	}
 
 	// Load config file json and check for duplication json keys
@@ -270,16 +323,38 @@ func getValidConfig() (*serverConfigV19, error) {
 		return nil, errors.New("invalid credential in config file " + configFile)
 	}
 
+	// Validate domains field
+	for _, domain := range srvCfg.Domains {
+		if !isValidDomain(domain) {
+			return nil, fmt.Errorf("invalid domain ‘%s’ in config file %s", domain, configFile)
+		}
+	}
+
 	// Validate logger field
 	if err = srvCfg.Logger.Validate(); err != nil {
 		return nil, err
 	}
 
+	// Validate audit field
+	if err = srvCfg.Audit.Validate(); err != nil {
+		return nil, err
+	}
+
 	// Validate notify field
 	if err = srvCfg.Notify.Validate(); err != nil {
 		return nil, err
 	}
 
+	// Validate kms field
+	if err = srvCfg.KMS.Validate(); err != nil {
+		return nil, err
+	}
+
+	// Validate storage class field
+	if err = srvCfg.StorageClass.Validate(); err != nil {
+		return nil, err
+	}
+
 	return srvCfg, nil
 }
 
@@ -304,6 +379,14 @@ func loadConfig() error {
 		srvCfg.SetRegion(globalServerRegion)
 	}
 
+	if globalIsEnvDomains {
+		srvCfg.SetDomains(globalDomains)
+	}
+
+	if globalIsEnvStorageClass {
+		srvCfg.SetStorageClass(globalStandardStorageClass, globalRRStorageClass)
+	}
+
 	// hold the mutex lock before a new config is assigned.
 	serverConfigMu.Lock()
 	serverConfig = srvCfg
@@ -316,6 +399,12 @@ func loadConfig() error {
 	if !globalIsEnvRegion {
 		globalServerRegion = serverConfig.GetRegion()
 	}
+	if !globalIsEnvDomains {
+		globalDomains = serverConfig.GetDomains()
+	}
+	if !globalIsEnvStorageClass {
+		globalStandardStorageClass, globalRRStorageClass = serverConfig.GetStorageClass()
+	}
 	serverConfigMu.Unlock()
 
 	return nil
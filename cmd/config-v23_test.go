@@ -116,9 +116,23 @@ func TestServerConfig(t *testing.T) {
 	fileLogger.Enable = false
 	serverConfig.Logger.SetFile(fileLogger)
 
+	// Set new audit targets.
+	auditFile := auditFileConfig{Enable: true, Filename: "test-audit-file"}
+	serverConfig.Audit.SetFile(auditFile)
+	if fileCfg := serverConfig.Audit.GetFile(); fileCfg != auditFile {
+		t.Errorf("Expecting audit file config %#v found %#v", auditFile, fileCfg)
+	}
+	auditWebhook := auditWebhookConfig{Enable: true, Endpoint: "http://localhost:8080/audit", BatchSize: 10}
+	serverConfig.Audit.SetWebhook(auditWebhook)
+	if webhookCfg := serverConfig.Audit.GetWebhook(); webhookCfg != auditWebhook {
+		t.Errorf("Expecting audit webhook config %#v found %#v", auditWebhook, webhookCfg)
+	}
+	serverConfig.Audit.SetFile(auditFileConfig{})
+	serverConfig.Audit.SetWebhook(auditWebhookConfig{})
+
 	// Match version.
-	if serverConfig.GetVersion() != v19 {
-		t.Errorf("Expecting version %s found %s", serverConfig.GetVersion(), v19)
+	if serverConfig.GetVersion() != v23 {
+		t.Errorf("Expecting version %s found %s", serverConfig.GetVersion(), v23)
 	}
 
 	// Attempt to save.
@@ -149,6 +163,9 @@ func TestServerConfigWithEnvs(t *testing.T) {
 	os.Setenv("MINIO_REGION", "us-west-1")
 	defer os.Unsetenv("MINIO_REGION")
 
+	os.Setenv("MINIO_DOMAIN", "s3.example.com, S3.example.org")
+	defer os.Unsetenv("MINIO_DOMAIN")
+
 	defer resetGlobalIsEnvs()
 
 	// Get test root.
@@ -178,6 +195,11 @@ func TestServerConfigWithEnvs(t *testing.T) {
 		t.Errorf("Expecting region to be \"us-west-1\" found %v", serverConfig.GetRegion())
 	}
 
+	// Check if serverConfig has
+	if domains := serverConfig.GetDomains(); !reflect.DeepEqual(domains, []string{"s3.example.com", "s3.example.org"}) {
+		t.Errorf("Expecting domains to be [s3.example.com s3.example.org] found %v", domains)
+	}
+
 	// Check if serverConfig has
 	cred := serverConfig.GetCredential()
 
@@ -231,7 +253,7 @@ func TestValidateConfig(t *testing.T) {
 
 	configPath := filepath.Join(rootPath, minioConfigFile)
 
-	v := v19
+	v := v23
 
 	testCases := []struct {
 		configData string
@@ -320,6 +342,24 @@ func TestValidateConfig(t *testing.T) {
 
 		// Test 29 - Test MQTT
 		{`{"version": "` + v + `", "credential": { "accessKey": "minio", "secretKey": "minio123" }, "region": "us-east-1", "browser": "on", "notify": { "mqtt": { "1": { "enable": true, "broker": "",  "topic": "", "qos": 0, "clientId": "", "username": "", "password": ""}}}}`, false},
+
+		// Test 30 - Test valid domains
+		{`{"version": "` + v + `", "credential": { "accessKey": "minio", "secretKey": "minio123" }, "region": "us-east-1", "browser": "on", "domains": ["s3.example.com", "localhost"]}`, true},
+
+		// Test 31 - Test invalid domain
+		{`{"version": "` + v + `", "credential": { "accessKey": "minio", "secretKey": "minio123" }, "region": "us-east-1", "browser": "on", "domains": ["s3.example.com:9000"]}`, false},
+
+		// Test 32 - Test empty filename for audit file target
+		{`{"version": "` + v + `", "credential": { "accessKey": "minio", "secretKey": "minio123" }, "region": "us-east-1", "browser": "on", "audit": { "file": { "enable": true, "filename": "" } }}`, false},
+
+		// Test 33 - Test empty endpoint for audit webhook target
+		{`{"version": "` + v + `", "credential": { "accessKey": "minio", "secretKey": "minio123" }, "region": "us-east-1", "browser": "on", "audit": { "webhook": { "enable": true, "endpoint": "", "batchSize": 100 } }}`, false},
+
+		// Test 34 - Test invalid batch size for audit webhook target
+		{`{"version": "` + v + `", "credential": { "accessKey": "minio", "secretKey": "minio123" }, "region": "us-east-1", "browser": "on", "audit": { "webhook": { "enable": false, "endpoint": "", "batchSize": -1 } }}`, false},
+
+		// Test 35 - Test valid audit targets
+		{`{"version": "` + v + `", "credential": { "accessKey": "minio", "secretKey": "minio123" }, "region": "us-east-1", "browser": "on", "audit": { "console": { "enable": true }, "file": { "enable": true, "filename": "audit.log" }, "webhook": { "enable": true, "endpoint": "http://localhost:8080/audit", "batchSize": 10 } }}`, true},
 	}
 
 	for i, testCase := range testCases {
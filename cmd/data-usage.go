@@ -0,0 +1,260 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"math/rand"
+	"time"
+
+	humanize "github.com/dustin/go-humanize"
+)
+
+const (
+	// Interval between two data usage crawls.
+	dataUsageCrawlInterval = 12 * time.Hour
+
+	// Pause between listing two pages of a bucket, keeps the crawler
+	// from competing with regular requests for the backend.
+	dataUsageCrawlDelay = 100 * time.Millisecond
+
+	// Lock held by the node crawling the data usage, so that only
+	// one node in a distributed setup crawls at a time.
+	dataUsageCrawlLock = "data-usage.lock"
+
+	// Data usage of the last crawl, saved in the minio meta bucket.
+	dataUsageObjName = "data-usage.json"
+)
+
+// objectHistogramInterval - a named range of object sizes, both
+// bounds are inclusive.
+type objectHistogramInterval struct {
+	name       string
+	start, end int64
+}
+
+// Object size ranges reported in the data usage histograms.
+var objectHistogramIntervals = []objectHistogramInterval{
+	{"LESS_THAN_1024_B", 0, humanize.KiByte - 1},
+	{"BETWEEN_1024_B_AND_1_MB", humanize.KiByte, humanize.MiByte - 1},
+	{"BETWEEN_1_MB_AND_10_MB", humanize.MiByte, humanize.MiByte*10 - 1},
+	{"BETWEEN_10_MB_AND_64_MB", humanize.MiByte * 10, humanize.MiByte*64 - 1},
+	{"BETWEEN_64_MB_AND_128_MB", humanize.MiByte * 64, humanize.MiByte*128 - 1},
+	{"BETWEEN_128_MB_AND_512_MB", humanize.MiByte * 128, humanize.MiByte*512 - 1},
+	{"GREATER_THAN_512_MB", humanize.MiByte * 512, math.MaxInt64},
+}
+
+// objectsHistogram - number of objects per size range.
+type objectsHistogram map[string]uint64
+
+// add - counts an object of the given size in its size range.
+func (h objectsHistogram) add(size int64) {
+	for _, interval := range objectHistogramIntervals {
+		if size >= interval.start && size <= interval.end {
+			h[interval.name]++
+			return
+		}
+	}
+}
+
+// bucketUsageInfo - data usage of a single bucket. Sizes and
+// histograms account for all stored versions of the objects.
+type bucketUsageInfo struct {
+	Size                   uint64           `json:"size"`
+	ObjectsCount           uint64           `json:"objectsCount"`
+	VersionsCount          uint64           `json:"versionsCount"`
+	ObjectsSizesHistogram  objectsHistogram `json:"objectsSizesHistogram"`
+	IncompleteUploadsCount uint64           `json:"incompleteUploadsCount"`
+}
+
+// dataUsageInfo - data usage of all buckets as of the last crawl.
+type dataUsageInfo struct {
+	LastUpdate             time.Time                  `json:"lastUpdate"`
+	ObjectsCount           uint64                     `json:"objectsCount"`
+	VersionsCount          uint64                     `json:"versionsCount"`
+	ObjectsTotalSize       uint64                     `json:"objectsTotalSize"`
+	ObjectsSizesHistogram  objectsHistogram           `json:"objectsSizesHistogram"`
+	IncompleteUploadsCount uint64                     `json:"incompleteUploadsCount"`
+	BucketsCount           uint64                     `json:"bucketsCount"`
+	BucketsUsage           map[string]bucketUsageInfo `json:"bucketsUsage"`
+}
+
+// startDataUsageCrawler - starts the background routine which crawls
+// all buckets for their data usage once every interval, the usage of
+// buckets with a quota is recomputed by every node along with it.
+func startDataUsageCrawler(objAPI ObjectLayer) {
+	go func() {
+		ticker := time.NewTicker(dataUsageCrawlInterval)
+		defer ticker.Stop()
+
+		// Start with random sleep time, so as to avoid "synchronous checks" between servers
+		time.Sleep(time.Duration(rand.Float64() * float64(time.Minute)))
+		for {
+			errorIf(runDataUsageCrawl(objAPI), "Unable to crawl data usage.")
+			errorIf(refreshBucketUsages(objAPI), "Unable to refresh bucket usages.")
+			select {
+			case <-ticker.C:
+			case <-globalServiceDoneCh:
+				return
+			}
+		}
+	}()
+}
+
+// runDataUsageCrawl - crawls and saves the data usage unless another
+// node has already done so during the current interval.
+func runDataUsageCrawl(objAPI ObjectLayer) error {
+	crawlLock := globalNSMutex.NewNSLock(minioMetaBucket, dataUsageCrawlLock)
+	crawlLock.Lock()
+	defer crawlLock.Unlock()
+
+	prevInfo, err := readDataUsageInfo(objAPI)
+	if err != nil {
+		return err
+	}
+	if UTCNow().Sub(prevInfo.LastUpdate) < dataUsageCrawlInterval/2 {
+		return nil
+	}
+
+	dataUsage, err := crawlDataUsage(objAPI)
+	if err != nil {
+		return err
+	}
+	return saveDataUsageInfo(objAPI, dataUsage)
+}
+
+// crawlDataUsage - walks all buckets and collects their data usage.
+func crawlDataUsage(objAPI ObjectLayer) (dataUsageInfo, error) {
+	dataUsage := dataUsageInfo{
+		ObjectsSizesHistogram: make(objectsHistogram),
+		BucketsUsage:          make(map[string]bucketUsageInfo),
+	}
+
+	buckets, err := objAPI.ListBuckets()
+	if err != nil {
+		return dataUsage, errorCause(err)
+	}
+
+	for _, bucket := range buckets {
+		usage, err := crawlBucketUsage(objAPI, bucket.Name)
+		if err != nil {
+			// Buckets removed during the crawl are skipped.
+			if _, ok := errorCause(err).(BucketNotFound); ok {
+				continue
+			}
+			return dataUsage, errorCause(err)
+		}
+		dataUsage.BucketsCount++
+		dataUsage.ObjectsCount += usage.ObjectsCount
+		dataUsage.VersionsCount += usage.VersionsCount
+		dataUsage.ObjectsTotalSize += usage.Size
+		dataUsage.IncompleteUploadsCount += usage.IncompleteUploadsCount
+		for name, count := range usage.ObjectsSizesHistogram {
+			dataUsage.ObjectsSizesHistogram[name] += count
+		}
+		dataUsage.BucketsUsage[bucket.Name] = usage
+	}
+
+	dataUsage.LastUpdate = UTCNow()
+	return dataUsage, nil
+}
+
+// crawlBucketUsage - walks all object versions and incomplete uploads
+// of a bucket, pausing between pages.
+func crawlBucketUsage(objAPI ObjectLayer, bucket string) (bucketUsageInfo, error) {
+	usage := bucketUsageInfo{ObjectsSizesHistogram: make(objectsHistogram)}
+
+	keyMarker, versionIDMarker := "", ""
+	for {
+		result, err := objAPI.ListObjectVersions(bucket, "", keyMarker, versionIDMarker, "", maxObjectList)
+		if err != nil {
+			return usage, err
+		}
+		for _, objInfo := range result.Objects {
+			if objInfo.DeleteMarker {
+				continue
+			}
+			if objInfo.IsLatest {
+				usage.ObjectsCount++
+			}
+			usage.VersionsCount++
+			usage.Size += uint64(objInfo.Size)
+			usage.ObjectsSizesHistogram.add(objInfo.Size)
+		}
+		if !result.IsTruncated {
+			break
+		}
+		keyMarker, versionIDMarker = result.NextKeyMarker, result.NextVersionIDMarker
+		time.Sleep(dataUsageCrawlDelay)
+	}
+
+	keyMarker, uploadIDMarker := "", ""
+	for {
+		result, err := objAPI.ListMultipartUploads(bucket, "", keyMarker, uploadIDMarker, "", maxUploadsList)
+		if err != nil {
+			return usage, err
+		}
+		usage.IncompleteUploadsCount += uint64(len(result.Uploads))
+		if !result.IsTruncated {
+			return usage, nil
+		}
+		keyMarker, uploadIDMarker = result.NextKeyMarker, result.NextUploadIDMarker
+		time.Sleep(dataUsageCrawlDelay)
+	}
+}
+
+// readDataUsageInfo - reads the data usage saved by the last crawl,
+// an empty data usage is returned if no crawl has completed yet.
+func readDataUsageInfo(objAPI ObjectLayer) (dataUsageInfo, error) {
+	var dataUsage dataUsageInfo
+
+	// Acquire a read lock on data usage before reading.
+	objLock := globalNSMutex.NewNSLock(minioMetaBucket, dataUsageObjName)
+	objLock.RLock()
+	defer objLock.RUnlock()
+
+	var buffer bytes.Buffer
+	if err := objAPI.GetObject(minioMetaBucket, dataUsageObjName, 0, -1, &buffer); err != nil {
+		if isErrObjectNotFound(err) || isErrIncompleteBody(err) {
+			return dataUsage, nil
+		}
+		return dataUsage, errorCause(err)
+	}
+
+	err := json.Unmarshal(buffer.Bytes(), &dataUsage)
+	return dataUsage, err
+}
+
+// saveDataUsageInfo - saves the data usage of a crawl.
+func saveDataUsageInfo(objAPI ObjectLayer, dataUsage dataUsageInfo) error {
+	buf, err := json.Marshal(dataUsage)
+	if err != nil {
+		return err
+	}
+
+	// Acquire a write lock on data usage before modifying.
+	objLock := globalNSMutex.NewNSLock(minioMetaBucket, dataUsageObjName)
+	objLock.Lock()
+	defer objLock.Unlock()
+
+	if _, err = objAPI.PutObject(minioMetaBucket, dataUsageObjName, int64(len(buf)), bytes.NewReader(buf), nil, ""); err != nil {
+		return errorCause(err)
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	humanize "github.com/dustin/go-humanize"
+)
+
+// Tests counting object sizes in their histogram intervals.
+func TestObjectsHistogram(t *testing.T) {
+	testCases := []struct {
+		size     int64
+		interval string
+	}{
+		{0, "LESS_THAN_1024_B"},
+		{humanize.KiByte - 1, "LESS_THAN_1024_B"},
+		{humanize.KiByte, "BETWEEN_1024_B_AND_1_MB"},
+		{humanize.MiByte, "BETWEEN_1_MB_AND_10_MB"},
+		{humanize.MiByte * 64, "BETWEEN_64_MB_AND_128_MB"},
+		{humanize.MiByte*512 - 1, "BETWEEN_128_MB_AND_512_MB"},
+		{humanize.TiByte, "GREATER_THAN_512_MB"},
+	}
+	for i, testCase := range testCases {
+		h := make(objectsHistogram)
+		h.add(testCase.size)
+		if len(h) != 1 || h[testCase.interval] != 1 {
+			t.Errorf("Test %d: Expected size %d in %s, got %v", i+1, testCase.size, testCase.interval, h)
+		}
+	}
+}
+
+// Wrapper for calling data usage crawler tests for both XL and FS.
+func TestCrawlDataUsage(t *testing.T) {
+	initNSLock(false)
+	ExecObjectLayerTest(t, testCrawlDataUsage)
+}
+
+// Tests that the crawler accounts for all objects, versions and
+// incomplete uploads and that crawls are saved.
+func testCrawlDataUsage(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	bucket, versionedBucket := "bucket", "versioned-bucket"
+	for _, b := range []string{bucket, versionedBucket} {
+		if err := obj.MakeBucketWithLocation(b, ""); err != nil {
+			t.Fatalf("%s: %s", instanceType, err)
+		}
+	}
+	if err := initBucketVersioning(obj); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	globalBucketVersioning.SetBucketVersioning(versionedBucket, &versioningConfiguration{Status: versioningEnabled})
+
+	if _, err := obj.PutObject(bucket, "small", 5, bytes.NewReader([]byte("hello")), nil, ""); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	large := bytes.Repeat([]byte("a"), humanize.KiByte)
+	if _, err := obj.PutObject(bucket, "large", int64(len(large)), bytes.NewReader(large), nil, ""); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if _, err := obj.NewMultipartUpload(bucket, "upload", nil); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	for _, data := range []string{"one", "three"} {
+		if _, err := obj.PutObject(versionedBucket, "object", int64(len(data)), bytes.NewReader([]byte(data)), nil, ""); err != nil {
+			t.Fatalf("%s: %s", instanceType, err)
+		}
+	}
+
+	dataUsage, err := crawlDataUsage(obj)
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+
+	expectedUsage := map[string]bucketUsageInfo{
+		bucket: {
+			Size:                   uint64(5 + len(large)),
+			ObjectsCount:           2,
+			VersionsCount:          2,
+			ObjectsSizesHistogram:  objectsHistogram{"LESS_THAN_1024_B": 1, "BETWEEN_1024_B_AND_1_MB": 1},
+			IncompleteUploadsCount: 1,
+		},
+		versionedBucket: {
+			Size:                  8,
+			ObjectsCount:          1,
+			VersionsCount:         2,
+			ObjectsSizesHistogram: objectsHistogram{"LESS_THAN_1024_B": 2},
+		},
+	}
+	if dataUsage.BucketsCount != 2 || len(dataUsage.BucketsUsage) != 2 {
+		t.Fatalf("%s: Expected usage of 2 buckets, got %v", instanceType, dataUsage.BucketsUsage)
+	}
+	for name, expected := range expectedUsage {
+		usage := dataUsage.BucketsUsage[name]
+		if usage.Size != expected.Size || usage.ObjectsCount != expected.ObjectsCount ||
+			usage.VersionsCount != expected.VersionsCount || usage.IncompleteUploadsCount != expected.IncompleteUploadsCount {
+			t.Errorf("%s: Expected usage %v of %s, got %v", instanceType, expected, name, usage)
+		}
+		for interval, count := range expected.ObjectsSizesHistogram {
+			if usage.ObjectsSizesHistogram[interval] != count {
+				t.Errorf("%s: Expected %d objects %s in %s, got %v", instanceType, count, interval, name, usage.ObjectsSizesHistogram)
+			}
+		}
+	}
+	if dataUsage.ObjectsCount != 3 || dataUsage.VersionsCount != 4 || dataUsage.IncompleteUploadsCount != 1 ||
+		dataUsage.ObjectsTotalSize != uint64(13+len(large)) || dataUsage.ObjectsSizesHistogram["LESS_THAN_1024_B"] != 3 {
+		t.Errorf("%s: Unexpected total data usage %v", instanceType, dataUsage)
+	}
+
+	// No crawl was saved yet.
+	savedUsage, err := readDataUsageInfo(obj)
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if !savedUsage.LastUpdate.IsZero() {
+		t.Fatalf("%s: Expected no saved data usage, got %v", instanceType, savedUsage)
+	}
+
+	if err = runDataUsageCrawl(obj); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if savedUsage, err = readDataUsageInfo(obj); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if savedUsage.LastUpdate.IsZero() || savedUsage.BucketsUsage[bucket].Size != expectedUsage[bucket].Size {
+		t.Fatalf("%s: Expected crawl to be saved, got %v", instanceType, savedUsage)
+	}
+
+	// A recent crawl is not repeated.
+	if _, err = obj.PutObject(bucket, "another", 5, bytes.NewReader([]byte("hello")), nil, ""); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if err = runDataUsageCrawl(obj); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	lastUsage, err := readDataUsageInfo(obj)
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if !lastUsage.LastUpdate.Equal(savedUsage.LastUpdate) {
+		t.Fatalf("%s: Expected recent crawl to be kept, got %v", instanceType, lastUsage)
+	}
+}
+
+// TestDataUsageInfoHandler - tests for the data usage management REST API.
+func TestDataUsageInfoHandler(t *testing.T) {
+	adminTestBed, err := prepareAdminXLTestBed()
+	if err != nil {
+		t.Fatal("Failed to initialize a single node XL backend for admin handler tests.")
+	}
+	defer adminTestBed.TearDown()
+
+	bucket := "mybucket"
+	if err = adminTestBed.objLayer.MakeBucketWithLocation(bucket, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = adminTestBed.objLayer.PutObject(bucket, "object", 5, bytes.NewReader([]byte("hello")), nil, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err = initBucketVersioning(adminTestBed.objLayer); err != nil {
+		t.Fatal(err)
+	}
+
+	getDataUsage := func() dataUsageInfo {
+		queryVal := url.Values{}
+		queryVal.Set("datausage", "")
+		req, err := newTestRequest(http.MethodGet, "/?"+queryVal.Encode(), 0, nil)
+		if err != nil {
+			t.Fatalf("Failed to construct data usage request - %v", err)
+		}
+		cred := serverConfig.GetCredential()
+		if err = signRequestV4(req, cred.AccessKey, cred.SecretKey); err != nil {
+			t.Fatalf("Failed to sign data usage request - %v", err)
+		}
+		rec := httptest.NewRecorder()
+		adminTestBed.mux.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected to succeed but failed with %d", rec.Code)
+		}
+		var dataUsage dataUsageInfo
+		if err = json.Unmarshal(rec.Body.Bytes(), &dataUsage); err != nil {
+			t.Fatalf("Failed to unmarshal data usage - %v", err)
+		}
+		return dataUsage
+	}
+
+	// Nothing is reported before the first crawl.
+	if dataUsage := getDataUsage(); !dataUsage.LastUpdate.IsZero() || dataUsage.BucketsCount != 0 {
+		t.Fatalf("Expected empty data usage, got %v", dataUsage)
+	}
+
+	if err = runDataUsageCrawl(adminTestBed.objLayer); err != nil {
+		t.Fatal(err)
+	}
+	dataUsage := getDataUsage()
+	if dataUsage.BucketsCount != 1 || dataUsage.ObjectsCount != 1 || dataUsage.BucketsUsage[bucket].Size != 5 {
+		t.Errorf("Expected usage of 5 bytes in 1 object of %s, got %v", bucket, dataUsage)
+	}
+}
@@ -0,0 +1,241 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	// Healing marker of a freshly formatted disk, saved in the minio
+	// meta bucket of the disk until all its data is healed.
+	healingMarkerFile = "healing.json"
+
+	// Healing marker tmp file, renamed to the marker once written.
+	healingMarkerFileTmp = "healing.json.tmp"
+
+	// Interval between two checks of the local disks for healing
+	// markers.
+	diskHealCheckInterval = time.Minute
+
+	// Pause after healing each object onto fresh disks, keeps the
+	// heal sequence from competing with regular requests.
+	diskHealObjectDelay = 10 * time.Millisecond
+)
+
+// healingMarker - marks a disk whose data is being healed, the token
+// is the one of the heal sequence healing the disk once started.
+type healingMarker struct {
+	Disk    string    `json:"disk"`
+	Started time.Time `json:"started"`
+	Token   string    `json:"token,omitempty"`
+}
+
+// HealingDisk - a disk being healed in background and the state of the
+// heal sequence healing it, if any.
+type HealingDisk struct {
+	Endpoint string             `json:"endpoint"`
+	Disk     string             `json:"disk"`
+	Started  time.Time          `json:"started"`
+	Sequence *HealSequenceState `json:"sequence,omitempty"`
+}
+
+// loadHealingMarker - reads the healing marker of a disk, returns
+// errFileNotFound when the disk is not being healed.
+func loadHealingMarker(disk StorageAPI) (marker healingMarker, err error) {
+	buf, err := disk.ReadAll(minioMetaBucket, healingMarkerFile)
+	if err != nil {
+		return marker, err
+	}
+	err = json.Unmarshal(buf, &marker)
+	return marker, err
+}
+
+// saveHealingMarker - writes the healing marker of a disk.
+func saveHealingMarker(disk StorageAPI, marker healingMarker) error {
+	markerBytes, err := json.Marshal(marker)
+	if err != nil {
+		return err
+	}
+
+	// Purge any existing temporary file, okay to ignore errors here.
+	disk.DeleteFile(minioMetaBucket, healingMarkerFileTmp)
+
+	if err = disk.AppendFile(minioMetaBucket, healingMarkerFileTmp, markerBytes); err != nil {
+		return err
+	}
+	return disk.RenameFile(minioMetaBucket, healingMarkerFileTmp, minioMetaBucket, healingMarkerFile)
+}
+
+// markDisksForHealing - writes a healing marker on the disks at the
+// given indices, jbod carries the format UUIDs of the disks.
+func markDisksForHealing(disks []StorageAPI, jbod []string, indices []int) error {
+	now := UTCNow()
+	for _, index := range indices {
+		marker := healingMarker{Disk: jbod[index], Started: now}
+		if err := saveHealingMarker(disks[index], marker); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getLocalDisks - returns the disks of the local endpoints, offline
+// disks are left out.
+func getLocalDisks(endpoints EndpointList) []StorageAPI {
+	var disks []StorageAPI
+	for _, endpoint := range endpoints {
+		if !endpoint.IsLocal {
+			continue
+		}
+		disk, err := newStorageAPI(endpoint)
+		if err != nil {
+			continue
+		}
+		disks = append(disks, disk)
+	}
+	return disks
+}
+
+// healMarkedDisks - heals the disks carrying a healing marker. Markers
+// are removed once the heal sequence healing their disk has finished,
+// or was stopped. Disks without a heal sequence, or whose sequence
+// failed or no longer runs on this server, are healed by a new heal
+// sequence of all buckets.
+func healMarkedDisks(objAPI ObjectLayer, disks []StorageAPI) error {
+	node := GetLocalPeer(globalEndpoints)
+
+	var pendingDisks []StorageAPI
+	var pendingMarkers []healingMarker
+	for _, disk := range disks {
+		marker, err := loadHealingMarker(disk)
+		if err == errFileNotFound {
+			continue
+		}
+		if err != nil {
+			errorIf(err, "Unable to read healing marker of %s.", disk)
+			continue
+		}
+
+		if marker.Token != "" {
+			if _, running := getHealSequence(marker.Token, false); running {
+				continue
+			}
+			state, serr := readHealSequenceState(objAPI, marker.Token)
+			switch {
+			case serr == errNoSuchHealSequence:
+				// Lost sequence, healed again below.
+			case serr != nil:
+				errorIf(serr, "Unable to read heal sequence %s.", marker.Token)
+				continue
+			case state.Status == healSequenceFinished || state.Status == healSequenceStopped:
+				if err = disk.DeleteFile(minioMetaBucket, healingMarkerFile); err != nil {
+					errorIf(err, "Unable to remove healing marker of %s.", disk)
+				}
+				continue
+			case state.Status == healSequenceRunning && state.Node != node:
+				// Healed by another server.
+				continue
+			}
+		}
+		pendingDisks = append(pendingDisks, disk)
+		pendingMarkers = append(pendingMarkers, marker)
+	}
+	if len(pendingDisks) == 0 {
+		return nil
+	}
+
+	state := newHealSequenceState("", "")
+	state.Delay = diskHealObjectDelay
+	state, err := startHealSequence(objAPI, state)
+	if err == errHealSequenceRunning {
+		// Retried on the next check.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for index, disk := range pendingDisks {
+		marker := pendingMarkers[index]
+		marker.Token = state.Token
+		if err = saveHealingMarker(disk, marker); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// startDiskHealMonitor - starts the background routine which heals the
+// freshly formatted local disks.
+func startDiskHealMonitor() {
+	if !globalIsXL {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(diskHealCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+			case <-globalServiceDoneCh:
+				return
+			}
+			// The object layer is looked up on every check, as healing
+			// the format of the disks replaces it.
+			objAPI := newObjectLayerFn()
+			if objAPI == nil {
+				continue
+			}
+			errorIf(healMarkedDisks(objAPI, getLocalDisks(globalEndpoints)), "Unable to heal fresh disks.")
+		}
+	}()
+}
+
+// getHealingDisks - returns the disks of all endpoints carrying a
+// healing marker, along with the state of their heal sequence.
+func getHealingDisks(objAPI ObjectLayer, endpoints EndpointList) ([]HealingDisk, error) {
+	disks, err := initStorageDisks(endpoints)
+	if err != nil {
+		return nil, err
+	}
+
+	healingDisks := []HealingDisk{}
+	for index, disk := range disks {
+		if disk == nil {
+			continue
+		}
+		marker, err := loadHealingMarker(disk)
+		if err != nil {
+			// Disks not being healed or offline.
+			continue
+		}
+		healingDisk := HealingDisk{
+			Endpoint: endpoints[index].String(),
+			Disk:     marker.Disk,
+			Started:  marker.Started,
+		}
+		if marker.Token != "" {
+			state, serr := getHealSequenceState(objAPI, marker.Token, false)
+			if serr == nil {
+				healingDisk.Sequence = &state
+			}
+		}
+		healingDisks = append(healingDisks, healingDisk)
+	}
+	return healingDisks, nil
+}
@@ -0,0 +1,186 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	router "github.com/gorilla/mux"
+	"github.com/minio/minio/pkg/madmin"
+)
+
+// Tests healing the disks carrying a healing marker.
+func TestHealMarkedDisks(t *testing.T) {
+	initNSLock(false)
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(rootPath)
+
+	xl, fsDirs := prepareHealSequenceXL(t)
+	defer removeRoots(fsDirs)
+
+	// The first disk lost its data and was never healed, the heal
+	// sequence of the second disk is lost.
+	if err = xl.storageDisks[0].DeleteFile("bucket1", filepath.Join("obj1", xlMetaJSONFile)); err != nil {
+		t.Fatal(err)
+	}
+	lostToken := mustGetUUID()
+	markers := []healingMarker{
+		{Disk: mustGetUUID(), Started: UTCNow()},
+		{Disk: mustGetUUID(), Started: UTCNow(), Token: lostToken},
+	}
+	for index, marker := range markers {
+		if err = saveHealingMarker(xl.storageDisks[index], marker); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	disks := xl.storageDisks[:3]
+	if err = healMarkedDisks(xl, disks); err != nil {
+		t.Fatal(err)
+	}
+
+	// Both disks are healed by a single throttled heal sequence.
+	var token string
+	for index := range markers {
+		marker, err := loadHealingMarker(xl.storageDisks[index])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if marker.Token == "" || marker.Token == lostToken || marker.Disk != markers[index].Disk {
+			t.Fatalf("Disk %d: unexpected healing marker %+v", index, marker)
+		}
+		if token != "" && marker.Token != token {
+			t.Errorf("Expected a single heal sequence, got %s and %s", token, marker.Token)
+		}
+		token = marker.Token
+	}
+	if _, err = loadHealingMarker(xl.storageDisks[2]); err != errFileNotFound {
+		t.Errorf("Expected no healing marker on the third disk, got %v", err)
+	}
+
+	state := waitHealSequence(t, xl, token)
+	if state.Status != healSequenceFinished || state.Bucket != "" || state.Delay != diskHealObjectDelay ||
+		state.ObjectsScanned != 4 || state.ObjectsHealed != 1 {
+		t.Fatalf("Unexpected heal sequence state %+v", state)
+	}
+	if _, err = xl.storageDisks[0].StatFile("bucket1", filepath.Join("obj1", xlMetaJSONFile)); err != nil {
+		t.Errorf("Expected xl.json to be healed, got %v", err)
+	}
+
+	// Markers are kept while the sequence runs, and removed once it
+	// has finished.
+	for i := 0; ; i++ {
+		if _, running := getHealSequence(token, false); !running {
+			break
+		}
+		if i == 500 {
+			t.Fatal("Timed out waiting for the heal sequence")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err = healMarkedDisks(xl, disks); err != nil {
+		t.Fatal(err)
+	}
+	for index := range markers {
+		if _, err = loadHealingMarker(xl.storageDisks[index]); err != errFileNotFound {
+			t.Errorf("Disk %d: expected the healing marker to be removed, got %v", index, err)
+		}
+	}
+}
+
+// Tests listing the disks being healed through the admin API.
+func TestHealingDisksHandler(t *testing.T) {
+	initNSLock(false)
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(rootPath)
+
+	xl, fsDirs := prepareHealSequenceXL(t)
+	defer removeRoots(fsDirs)
+
+	globalObjLayerMutex.Lock()
+	globalObjectAPI = xl
+	globalObjLayerMutex.Unlock()
+	globalIsXL = true
+	globalEndpoints = mustGetNewEndpointList(fsDirs...)
+	defer resetGlobalObjectAPI()
+	defer resetGlobalIsXL()
+	defer resetGlobalEndpoints()
+
+	initGlobalAdminPeers(mustGetNewEndpointList("http://127.0.0.1:9000/d1"))
+	adminRouter := router.NewRouter()
+	registerAdminRouter(adminRouter)
+
+	server := httptest.NewServer(adminRouter)
+	defer server.Close()
+
+	cred := serverConfig.GetCredential()
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	adminClient, err := madmin.New(u.Host, cred.AccessKey, cred.SecretKey, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	disks, err := adminClient.HealingDisks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(disks) != 0 {
+		t.Fatalf("Expected no healing disks, got %+v", disks)
+	}
+
+	// A disk waiting for healing and a disk being healed.
+	state := HealSequenceState{Token: mustGetUUID(), Node: "other:9000", Status: healSequenceRunning, ObjectsScanned: 3}
+	if err = saveHealSequenceState(xl, state); err != nil {
+		t.Fatal(err)
+	}
+	markers := map[int]healingMarker{
+		1: {Disk: mustGetUUID(), Started: UTCNow()},
+		4: {Disk: mustGetUUID(), Started: UTCNow(), Token: state.Token},
+	}
+	for index, marker := range markers {
+		if err = saveHealingMarker(xl.storageDisks[index], marker); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if disks, err = adminClient.HealingDisks(); err != nil {
+		t.Fatal(err)
+	}
+	if len(disks) != 2 {
+		t.Fatalf("Expected 2 healing disks, got %+v", disks)
+	}
+	if disks[0].Endpoint != globalEndpoints[1].String() || disks[0].Disk != markers[1].Disk || disks[0].Sequence != nil {
+		t.Errorf("Unexpected healing disk %+v", disks[0])
+	}
+	if disks[1].Endpoint != globalEndpoints[4].String() || disks[1].Sequence == nil ||
+		disks[1].Sequence.Token != state.Token || disks[1].Sequence.ObjectsScanned != 3 {
+		t.Errorf("Unexpected healing disk %+v", disks[1])
+	}
+}
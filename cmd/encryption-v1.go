@@ -0,0 +1,849 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"io"
+	"net/http"
+	"strings"
+
+	humanize "github.com/dustin/go-humanize"
+)
+
+const (
+	// SSEHeader is the AWS SSE-S3 HTTP header key, it requests server side
+	// encryption with keys managed by the server.
+	SSEHeader = "X-Amz-Server-Side-Encryption"
+	// SSEAlgorithmAES256 is the only valid S3 SSE-S3 encryption algorithm identifier.
+	SSEAlgorithmAES256 = "AES256"
+
+	// SSECustomerAlgorithm is the AWS SSE-C algorithm HTTP header key.
+	SSECustomerAlgorithm = "X-Amz-Server-Side-Encryption-Customer-Algorithm"
+	// SSECustomerKey is the AWS SSE-C encryption key HTTP header key.
+	SSECustomerKey = "X-Amz-Server-Side-Encryption-Customer-Key"
+	// SSECustomerKeyMD5 is the AWS SSE-C encryption key MD5 HTTP header key.
+	SSECustomerKeyMD5 = "X-Amz-Server-Side-Encryption-Customer-Key-MD5"
+
+	// SSECopyCustomerAlgorithm is the AWS SSE-C algorithm HTTP header key for CopyObject API.
+	SSECopyCustomerAlgorithm = "X-Amz-Copy-Source-Server-Side-Encryption-Customer-Algorithm"
+	// SSECopyCustomerKey is the AWS SSE-C encryption key HTTP header key for CopyObject API.
+	SSECopyCustomerKey = "X-Amz-Copy-Source-Server-Side-Encryption-Customer-Key"
+	// SSECopyCustomerKeyMD5 is the AWS SSE-C encryption key MD5 HTTP header key for CopyObject API.
+	SSECopyCustomerKeyMD5 = "X-Amz-Copy-Source-Server-Side-Encryption-Customer-Key-MD5"
+
+	// SSECustomerAlgorithmAES256 the only valid S3 SSE-C encryption algorithm identifier.
+	SSECustomerAlgorithmAES256 = "AES256"
+	// SSECustomerKeySize is the size of valid client provided encryption keys in bytes.
+	SSECustomerKeySize = 32
+)
+
+const (
+	// ServerSideEncryptionIV is a 32 byte randomly generated IV used to derive an
+	// unique key encryption key from the client provided key. The combination of this value
+	// and the client-provided key MUST be unique.
+	ServerSideEncryptionIV = ReservedMetadataPrefix + "Server-Side-Encryption-Iv"
+
+	// ServerSideEncryptionSealAlgorithm identifies a particular algorithm and
+	// implementation used to seal the object key.
+	ServerSideEncryptionSealAlgorithm = ReservedMetadataPrefix + "Server-Side-Encryption-Seal-Algorithm"
+
+	// ServerSideEncryptionSealedKey is the sealed object key. The sealed key
+	// can only be unsealed with the client provided key and the IV.
+	ServerSideEncryptionSealedKey = ReservedMetadataPrefix + "Server-Side-Encryption-Sealed-Key"
+
+	// ServerSideEncryptionKeyMD5 is the MD5 of the client provided key, used
+	// to reject requests with a wrong key before unsealing the object key.
+	ServerSideEncryptionKeyMD5 = ReservedMetadataPrefix + "Server-Side-Encryption-Key-Md5"
+
+	// ServerSideEncryptionKMSKeyID is the ID of the KMS master key which
+	// sealed the data key of an object encrypted with SSE-S3.
+	ServerSideEncryptionKMSKeyID = ReservedMetadataPrefix + "Server-Side-Encryption-S3-Kms-Key-Id"
+
+	// ServerSideEncryptionKMSSealedKey is the data key of an object encrypted
+	// with SSE-S3 sealed by the KMS. The data key seals the object key in
+	// place of a client provided key.
+	ServerSideEncryptionKMSSealedKey = ReservedMetadataPrefix + "Server-Side-Encryption-S3-Kms-Sealed-Key"
+)
+
+const (
+	// ReservedMetadataPrefix is the prefix of a metadata key which
+	// is reserved and for internal use only.
+	ReservedMetadataPrefix = "X-Minio-Internal-"
+
+	// SSESealAlgorithmHmacGCM is the seal algorithm which derives the key
+	// encryption key with HMAC-SHA256 and seals the object key with AES-256-GCM.
+	SSESealAlgorithmHmacGCM = "HMAC-SHA256-AES-GCM"
+)
+
+const (
+	// Size of a plaintext package of an encrypted object.
+	ssePackageSize = 64 * humanize.KiByte
+
+	// Overhead added to every package by the authentication tag.
+	ssePackageOverhead = 16
+
+	// Size of an encrypted package.
+	sseEncryptedPackageSize = ssePackageSize + ssePackageOverhead
+
+	// Size of the random IV preceding the packages of an encrypted part.
+	ssePartIVSize = 32
+)
+
+var (
+	errInsecureSSERequest            = errors.New("Requests specifying Server Side Encryption with Customer provided keys must be made over a secure connection")
+	errEncryptedMultipartUpload      = errors.New("The multipart upload requested encryption, part requests must provide the encryption parameters")
+	errEncryptedObject               = errors.New("The object was stored using a form of Server Side Encryption")
+	errEncryptionParamsNotApplicable = errors.New("The encryption parameters are not applicable to this object")
+	errInvalidSSEAlgorithm           = errors.New("The SSE-C algorithm is not valid")
+	errMissingSSEKey                 = errors.New("The SSE-C request is missing the customer key")
+	errInvalidSSEKey                 = errors.New("The SSE-C client key is invalid")
+	errMissingSSEKeyMD5              = errors.New("The SSE-C request is missing the customer key MD5")
+	errSSEKeyMD5Mismatch             = errors.New("The key MD5 does not match the SSE-C client key")
+	errSSEKeyMismatch                = errors.New("The client key does not match the key used to encrypt the object")
+	errObjectTampered                = errors.New("The requested object was modified and may be compromised")
+	errInvalidEncryptionMethod       = errors.New("The encryption method specified is not supported")
+	errIncompatibleEncryptionMethod  = errors.New("Server side encryption specified with both SSE-C and SSE-S3 headers")
+)
+
+// hasSSECustomerHeader returns true if the given HTTP header
+// contains server-side-encryption with customer provided key fields.
+func hasSSECustomerHeader(header http.Header) bool {
+	return hasAnyHeader(header, SSECustomerAlgorithm, SSECustomerKey, SSECustomerKeyMD5)
+}
+
+// hasSSECopyCustomerHeader returns true if the given HTTP header
+// contains copy source server-side-encryption with customer provided key fields.
+func hasSSECopyCustomerHeader(header http.Header) bool {
+	return hasAnyHeader(header, SSECopyCustomerAlgorithm, SSECopyCustomerKey, SSECopyCustomerKeyMD5)
+}
+
+// hasAnyHeader returns true if any of the keys is set in the HTTP header.
+func hasAnyHeader(header http.Header, keys ...string) bool {
+	for _, key := range keys {
+		if _, ok := header[http.CanonicalHeaderKey(key)]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// hasSSES3Header returns true if the given HTTP header
+// requests server-side-encryption with server managed keys.
+func hasSSES3Header(header http.Header) bool {
+	return hasAnyHeader(header, SSEHeader)
+}
+
+// parseSSECustomerRequest parses the SSE-C header fields of the provided
+// request. It returns the client provided key on success.
+func parseSSECustomerRequest(r *http.Request) (key []byte, err error) {
+	return parseSSECustomerHeader(r.Header, SSECustomerAlgorithm, SSECustomerKey, SSECustomerKeyMD5)
+}
+
+// parseSSECopyCustomerRequest parses the copy source SSE-C header fields
+// of the provided request. It returns the client provided key on success.
+func parseSSECopyCustomerRequest(r *http.Request) (key []byte, err error) {
+	return parseSSECustomerHeader(r.Header, SSECopyCustomerAlgorithm, SSECopyCustomerKey, SSECopyCustomerKeyMD5)
+}
+
+// parseSSECustomerHeader parses and validates the given SSE-C header
+// fields. Customer keys are only accepted over secure connections.
+func parseSSECustomerHeader(header http.Header, algorithmKey, keyKey, keyMD5Key string) (key []byte, err error) {
+	if !globalIsSSL { // minio only supports HTTP or HTTPS requests not both at the same time
+		// we cannot use r.TLS == nil here because Go's http implementation reflects on
+		// the net.Conn and sets the TLS field of http.Request only if it's an tls.Conn.
+		// Minio uses a BufConn (wrapping a tls.Conn) so the type check within the http package
+		// will always fail -> r.TLS is always nil even for TLS requests.
+		return nil, errInsecureSSERequest
+	}
+	if header.Get(algorithmKey) != SSECustomerAlgorithmAES256 {
+		return nil, errInvalidSSEAlgorithm
+	}
+	if header.Get(keyKey) == "" {
+		return nil, errMissingSSEKey
+	}
+	if header.Get(keyMD5Key) == "" {
+		return nil, errMissingSSEKeyMD5
+	}
+
+	key, err = base64.StdEncoding.DecodeString(header.Get(keyKey))
+	if err != nil || len(key) != SSECustomerKeySize {
+		return nil, errInvalidSSEKey
+	}
+	keyMD5, err := base64.StdEncoding.DecodeString(header.Get(keyMD5Key))
+	if err != nil {
+		return nil, errSSEKeyMD5Mismatch
+	}
+	if md5Sum := md5.Sum(key); !bytes.Equal(md5Sum[:], keyMD5) {
+		return nil, errSSEKeyMD5Mismatch
+	}
+	return key, nil
+}
+
+// setSSECustomerResponseHeaders - sets the SSE-C response headers which
+// acknowledge the client key the object is encrypted with.
+func setSSECustomerResponseHeaders(w http.ResponseWriter, key []byte) {
+	keyMD5 := md5.Sum(key)
+	w.Header().Set(SSECustomerAlgorithm, SSECustomerAlgorithmAES256)
+	w.Header().Set(SSECustomerKeyMD5, base64.StdEncoding.EncodeToString(keyMD5[:]))
+}
+
+// setSSEResponseHeaders - sets the response headers of an encrypted
+// object, the metadata determines whether SSE-S3 or SSE-C is used.
+func setSSEResponseHeaders(w http.ResponseWriter, key []byte, metadata map[string]string) {
+	if isSSES3Object(metadata) {
+		w.Header().Set(SSEHeader, SSEAlgorithmAES256)
+		return
+	}
+	setSSECustomerResponseHeaders(w, key)
+}
+
+// isEncryptedObject returns true if the object metadata
+// indicates that the object is encrypted.
+func isEncryptedObject(metadata map[string]string) bool {
+	_, ok := metadata[ServerSideEncryptionSealedKey]
+	return ok
+}
+
+// isSSES3Object returns true if the object metadata indicates
+// that the object is encrypted with a server managed key.
+func isSSES3Object(metadata map[string]string) bool {
+	_, ok := metadata[ServerSideEncryptionKMSSealedKey]
+	return ok
+}
+
+// removeSSEMetadata - removes all server side encryption entries from
+// the metadata, used when an object is copied with a new key.
+func removeSSEMetadata(metadata map[string]string) {
+	delete(metadata, ServerSideEncryptionIV)
+	delete(metadata, ServerSideEncryptionSealAlgorithm)
+	delete(metadata, ServerSideEncryptionSealedKey)
+	delete(metadata, ServerSideEncryptionKeyMD5)
+	delete(metadata, ServerSideEncryptionKMSKeyID)
+	delete(metadata, ServerSideEncryptionKMSSealedKey)
+}
+
+// isReservedMetadata - returns true if the metadata key is for internal
+// use only and must never be sent to clients.
+func isReservedMetadata(key string) bool {
+	return strings.HasPrefix(key, ReservedMetadataPrefix)
+}
+
+// filterReservedMetadata - returns a copy of the metadata without
+// any reserved entries.
+func filterReservedMetadata(metadata map[string]string) map[string]string {
+	if metadata == nil {
+		return nil
+	}
+	filtered := make(map[string]string, len(metadata))
+	for k, v := range metadata {
+		if !isReservedMetadata(k) {
+			filtered[k] = v
+		}
+	}
+	return filtered
+}
+
+// deriveKeyEncryptionKey - derives the unique key encryption key from
+// the client provided key and the random IV.
+func deriveKeyEncryptionKey(clientKey, iv []byte) []byte {
+	mac := hmac.New(sha256.New, clientKey)
+	mac.Write(iv)
+	mac.Write([]byte(SSESealAlgorithmHmacGCM))
+	return mac.Sum(nil)
+}
+
+// newAEAD - returns AES-256-GCM for the given 32 byte key.
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// newSSEObjectKey - generates a new random object key and stores it, sealed
+// with the client provided key, in the object metadata.
+func newSSEObjectKey(clientKey []byte, metadata map[string]string) (objectKey []byte, err error) {
+	objectKey = make([]byte, 32)
+	if _, err = io.ReadFull(rand.Reader, objectKey); err != nil {
+		return nil, err
+	}
+	if err = sealSSEObjectKey(clientKey, objectKey, metadata); err != nil {
+		return nil, err
+	}
+	return objectKey, nil
+}
+
+// sealSSEObjectKey - seals the object key with the client provided key and
+// stores it in the object metadata. Sealing an existing object key with a
+// new client key changes the key of an object without rewriting its data.
+func sealSSEObjectKey(clientKey, objectKey []byte, metadata map[string]string) error {
+	iv := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, iv); err != nil {
+		return err
+	}
+
+	// The key encryption key is unique per IV, so a fixed nonce is safe.
+	aead, err := newAEAD(deriveKeyEncryptionKey(clientKey, iv))
+	if err != nil {
+		return err
+	}
+	sealedKey := aead.Seal(nil, make([]byte, aead.NonceSize()), objectKey, nil)
+
+	keyMD5 := md5.Sum(clientKey)
+	metadata[ServerSideEncryptionIV] = base64.StdEncoding.EncodeToString(iv)
+	metadata[ServerSideEncryptionSealAlgorithm] = SSESealAlgorithmHmacGCM
+	metadata[ServerSideEncryptionSealedKey] = base64.StdEncoding.EncodeToString(sealedKey)
+	metadata[ServerSideEncryptionKeyMD5] = base64.StdEncoding.EncodeToString(keyMD5[:])
+	return nil
+}
+
+// sseKMSContext - returns the context binding the data key of an
+// SSE-S3 object to the object.
+func sseKMSContext(bucket, object string) []byte {
+	return []byte(pathJoin(bucket, object))
+}
+
+// newSSES3Key - generates a new data key with the current master key of the
+// KMS and stores it sealed in the object metadata. The data key is used in
+// place of a client provided key to seal the object key.
+func newSSES3Key(bucket, object string, metadata map[string]string) (key []byte, err error) {
+	if globalKMS == nil {
+		return nil, errKMSNotConfigured
+	}
+	keyID := globalKMS.KeyID()
+	dataKey, sealedKey, err := globalKMS.GenerateKey(keyID, sseKMSContext(bucket, object))
+	if err != nil {
+		return nil, err
+	}
+	metadata[ServerSideEncryptionKMSKeyID] = keyID
+	metadata[ServerSideEncryptionKMSSealedKey] = base64.StdEncoding.EncodeToString(sealedKey)
+	return dataKey[:], nil
+}
+
+// unsealSSES3Key - unseals the data key of an SSE-S3 object with the KMS.
+func unsealSSES3Key(bucket, object string, metadata map[string]string) (key []byte, err error) {
+	if globalKMS == nil {
+		return nil, errKMSNotConfigured
+	}
+	sealedKey, err := base64.StdEncoding.DecodeString(metadata[ServerSideEncryptionKMSSealedKey])
+	if err != nil {
+		return nil, errObjectTampered
+	}
+	dataKey, err := globalKMS.UnsealKey(metadata[ServerSideEncryptionKMSKeyID], sealedKey, sseKMSContext(bucket, object))
+	if err != nil {
+		return nil, err
+	}
+	return dataKey[:], nil
+}
+
+// unsealSSEObjectKey - unseals the object key stored in the object
+// metadata with the client provided key.
+func unsealSSEObjectKey(clientKey []byte, metadata map[string]string) (objectKey []byte, err error) {
+	if metadata[ServerSideEncryptionSealAlgorithm] != SSESealAlgorithmHmacGCM {
+		return nil, errObjectTampered
+	}
+	keyMD5 := md5.Sum(clientKey)
+	if metadata[ServerSideEncryptionKeyMD5] != base64.StdEncoding.EncodeToString(keyMD5[:]) {
+		return nil, errSSEKeyMismatch
+	}
+	iv, err := base64.StdEncoding.DecodeString(metadata[ServerSideEncryptionIV])
+	if err != nil || len(iv) != 32 {
+		return nil, errObjectTampered
+	}
+	sealedKey, err := base64.StdEncoding.DecodeString(metadata[ServerSideEncryptionSealedKey])
+	if err != nil {
+		return nil, errObjectTampered
+	}
+
+	aead, err := newAEAD(deriveKeyEncryptionKey(clientKey, iv))
+	if err != nil {
+		return nil, err
+	}
+	objectKey, err = aead.Open(nil, make([]byte, aead.NonceSize()), sealedKey, nil)
+	if err != nil {
+		return nil, errSSEKeyMismatch
+	}
+	return objectKey, nil
+}
+
+// getSSEKey - validates the SSE-C fields of a request reading an object,
+// returns the key sealing the object key if the object is encrypted: the
+// client key for SSE-C objects and the data key for SSE-S3 objects. Objects
+// which are not encrypted with SSE-C must not be read with SSE-C fields.
+func getSSEKey(r *http.Request, bucket, object string, metadata map[string]string, copySource bool) (key []byte, err error) {
+	hasSSEHeader, parse := hasSSECustomerHeader, parseSSECustomerRequest
+	if copySource {
+		hasSSEHeader, parse = hasSSECopyCustomerHeader, parseSSECopyCustomerRequest
+	}
+	if isSSES3Object(metadata) {
+		if hasSSEHeader(r.Header) {
+			return nil, errEncryptionParamsNotApplicable
+		}
+		return unsealSSES3Key(bucket, object, metadata)
+	}
+	if !isEncryptedObject(metadata) {
+		if hasSSEHeader(r.Header) {
+			return nil, errEncryptionParamsNotApplicable
+		}
+		return nil, nil
+	}
+	if !hasSSEHeader(r.Header) {
+		return nil, errEncryptedObject
+	}
+	if key, err = parse(r); err != nil {
+		return nil, err
+	}
+	if _, err = unsealSSEObjectKey(key, metadata); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// getSSEPutKey - returns the key sealing the object key of a new object:
+// the client key of SSE-C requests, or a new data key generated by the
+// KMS if SSE-S3 is requested or enabled by default for the bucket. The
+// data key is stored sealed in the metadata. It returns nil if the new
+// object is not to be encrypted.
+func getSSEPutKey(r *http.Request, bucket, object string, metadata map[string]string) (key []byte, err error) {
+	if hasSSECustomerHeader(r.Header) {
+		if hasSSES3Header(r.Header) {
+			return nil, errIncompatibleEncryptionMethod
+		}
+		return parseSSECustomerRequest(r)
+	}
+	if hasSSES3Header(r.Header) {
+		if r.Header.Get(SSEHeader) != SSEAlgorithmAES256 {
+			return nil, errInvalidEncryptionMethod
+		}
+	} else if !isBucketEncryptionEnabled(bucket) {
+		return nil, nil
+	}
+	return newSSES3Key(bucket, object, metadata)
+}
+
+// getSSEUploadObjectKey - validates the SSE-C fields of a part request
+// against the multipart upload, returns the key sealing the object key,
+// the object key and the metadata of the upload if it is encrypted.
+func getSSEUploadObjectKey(objectAPI ObjectLayer, r *http.Request, bucket, object, uploadID string) (key, objectKey []byte, metadata map[string]string, err error) {
+	listPartsInfo, err := objectAPI.ListObjectParts(bucket, object, uploadID, 0, 1)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	metadata = listPartsInfo.UserDefined
+	if isSSES3Object(metadata) {
+		if hasSSECustomerHeader(r.Header) {
+			return nil, nil, nil, errEncryptionParamsNotApplicable
+		}
+		key, err = unsealSSES3Key(bucket, object, metadata)
+	} else {
+		if !isEncryptedObject(metadata) {
+			if hasSSECustomerHeader(r.Header) {
+				return nil, nil, nil, errEncryptionParamsNotApplicable
+			}
+			return nil, nil, nil, nil
+		}
+		if !hasSSECustomerHeader(r.Header) {
+			return nil, nil, nil, errEncryptedMultipartUpload
+		}
+		key, err = parseSSECustomerRequest(r)
+	}
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if objectKey, err = unsealSSEObjectKey(key, metadata); err != nil {
+		return nil, nil, nil, err
+	}
+	return key, objectKey, metadata, nil
+}
+
+// sseEncryptedSize - returns the size of the encrypted data
+// for a plaintext of the given size.
+func sseEncryptedSize(size int64) int64 {
+	if size == 0 {
+		return ssePartIVSize + ssePackageOverhead
+	}
+	packages := (size + ssePackageSize - 1) / ssePackageSize
+	return ssePartIVSize + size + packages*ssePackageOverhead
+}
+
+// sseDecryptedSize - returns the size of the plaintext for
+// encrypted data of the given size.
+func sseDecryptedSize(size int64) (int64, error) {
+	return sseDecryptedPackagesSize(size - ssePartIVSize)
+}
+
+// sseDecryptedPackagesSize - returns the size of the plaintext
+// of a sequence of encrypted packages of the given size.
+func sseDecryptedPackagesSize(size int64) (int64, error) {
+	if size < ssePackageOverhead {
+		return 0, errObjectTampered
+	}
+	packages := (size + sseEncryptedPackageSize - 1) / sseEncryptedPackageSize
+	if lastPackage := size - (packages-1)*sseEncryptedPackageSize; packages > 1 && lastPackage <= ssePackageOverhead {
+		return 0, errObjectTampered
+	}
+	return size - packages*ssePackageOverhead, nil
+}
+
+// sseObjectParts - returns the encrypted parts of an object, objects
+// which were not uploaded in parts consist of a single part.
+func sseObjectParts(objInfo ObjectInfo) []objectPartInfo {
+	if len(objInfo.Parts) == 0 {
+		return []objectPartInfo{{Number: 1, Size: objInfo.Size}}
+	}
+	return objInfo.Parts
+}
+
+// sseDecryptedObjectSize - returns the plaintext size of an encrypted object.
+func sseDecryptedObjectSize(objInfo ObjectInfo) (size int64, err error) {
+	for _, part := range sseObjectParts(objInfo) {
+		partSize, err := sseDecryptedSize(part.Size)
+		if err != nil {
+			return 0, err
+		}
+		size += partSize
+	}
+	return size, nil
+}
+
+// ssePartKey - derives the unique key of an object part from the
+// object key and the random IV of the part, parts uploaded again
+// with the same number are hence encrypted with a different key.
+func ssePartKey(objectKey []byte, partID int, iv []byte) []byte {
+	var partIDBin [4]byte
+	binary.BigEndian.PutUint32(partIDBin[:], uint32(partID))
+	mac := hmac.New(sha256.New, objectKey)
+	mac.Write(partIDBin[:])
+	mac.Write(iv)
+	return mac.Sum(nil)
+}
+
+// ssePackageNonce - returns the nonce of a package from its sequence
+// number, the final package of a part is flagged to detect truncation.
+func ssePackageNonce(nonce []byte, sequence uint64, final bool) []byte {
+	for i := range nonce {
+		nonce[i] = 0
+	}
+	binary.BigEndian.PutUint64(nonce, sequence)
+	if final {
+		nonce[8] = 0x80
+	}
+	return nonce
+}
+
+// sseEncryptReader - encrypts data read from the underlying reader
+// as a sequence of authenticated packages.
+type sseEncryptReader struct {
+	src      io.Reader
+	aead     cipher.AEAD
+	nonce    []byte
+	sequence uint64
+
+	// Plaintext read ahead, always one byte more than a package
+	// to know whether a package is the final one.
+	plaintext []byte
+	buffered  int
+
+	ciphertext []byte
+	offset     int
+	done       bool
+}
+
+// newSSEEncryptReader - returns a reader encrypting the data of the
+// given object part with a key derived from the object key and a
+// random IV, the IV is written ahead of the encrypted packages.
+func newSSEEncryptReader(src io.Reader, objectKey []byte, partID int) (io.Reader, error) {
+	iv := make([]byte, ssePartIVSize, sseEncryptedPackageSize)
+	if _, err := io.ReadFull(rand.Reader, iv); err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(ssePartKey(objectKey, partID, iv))
+	if err != nil {
+		return nil, err
+	}
+	return &sseEncryptReader{
+		src:        src,
+		aead:       aead,
+		nonce:      make([]byte, aead.NonceSize()),
+		plaintext:  make([]byte, ssePackageSize+1),
+		ciphertext: iv,
+	}, nil
+}
+
+// sealPackage - fills the plaintext buffer and seals the next package.
+func (r *sseEncryptReader) sealPackage() error {
+	n, err := io.ReadFull(r.src, r.plaintext[r.buffered:])
+	r.buffered += n
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		r.done = true
+	} else if err != nil {
+		return err
+	}
+
+	size := r.buffered
+	if !r.done {
+		size = ssePackageSize
+	}
+	nonce := ssePackageNonce(r.nonce, r.sequence, r.done)
+	r.ciphertext = r.aead.Seal(r.ciphertext[:0], nonce, r.plaintext[:size], nil)
+	r.offset = 0
+	r.sequence++
+
+	// Keep the read ahead byte for the next package.
+	r.buffered = copy(r.plaintext, r.plaintext[size:r.buffered])
+	return nil
+}
+
+func (r *sseEncryptReader) Read(p []byte) (n int, err error) {
+	if r.offset == len(r.ciphertext) {
+		if r.done {
+			return 0, io.EOF
+		}
+		if err = r.sealPackage(); err != nil {
+			return 0, err
+		}
+	}
+	n = copy(p, r.ciphertext[r.offset:])
+	r.offset += n
+	return n, nil
+}
+
+// sseDecryptWriter - decrypts the packages of an encrypted object range
+// and writes the requested plaintext to the underlying writer.
+type sseDecryptWriter struct {
+	dst       io.Writer
+	objectKey []byte
+	parts     []objectPartInfo
+
+	aead      cipher.AEAD // nil until the IV of the current part is read.
+	nonce     []byte
+	sequence  uint64
+	remaining int64 // encrypted bytes left in the current part.
+
+	ciphertext []byte
+	skip       int64 // plaintext bytes to skip before writing.
+	length     int64 // plaintext bytes left to write.
+}
+
+// sseReadFunc - reads the range [offset, offset+length) of an
+// encrypted object into writer.
+type sseReadFunc func(offset, length int64, writer io.Writer) error
+
+// newSSEDecryptWriter - returns a writer decrypting the plaintext range
+// [offset, offset+length) of an encrypted object into dst, along with
+// the range of the encrypted object which has to be written to it.
+// Ranges starting after the first package of a part read the IV of
+// the part with read, which may be nil for ranges starting at 0.
+func newSSEDecryptWriter(dst io.Writer, clientKey []byte, objInfo ObjectInfo, offset, length int64, read sseReadFunc) (w io.Writer, encOffset, encLength int64, err error) {
+	objectKey, err := unsealSSEObjectKey(clientKey, objInfo.UserDefined)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	parts := sseObjectParts(objInfo)
+	writer := &sseDecryptWriter{
+		dst:       dst,
+		objectKey: objectKey,
+		length:    length,
+	}
+
+	// Find the first package holding the requested range.
+	var partOffset int64
+	for i, part := range parts {
+		var partSize int64
+		if partSize, err = sseDecryptedSize(part.Size); err != nil {
+			return nil, 0, 0, err
+		}
+		if offset < partSize || i == len(parts)-1 {
+			writer.parts = parts[i:]
+			writer.sequence = uint64(offset / ssePackageSize)
+			writer.skip = offset % ssePackageSize
+			encOffset = partOffset + ssePartIVSize + int64(writer.sequence)*sseEncryptedPackageSize
+			writer.remaining = part.Size - ssePartIVSize - int64(writer.sequence)*sseEncryptedPackageSize
+			break
+		}
+		offset -= partSize
+		partOffset += part.Size
+	}
+	if writer.sequence == 0 {
+		// The IV is read along with the first package.
+		encOffset = partOffset
+	} else {
+		var iv bytes.Buffer
+		if err = read(partOffset, ssePartIVSize, &iv); err != nil {
+			return nil, 0, 0, err
+		}
+		if err = writer.initPart(iv.Bytes()); err != nil {
+			return nil, 0, 0, err
+		}
+	}
+
+	// Read up to the end of the package holding the last requested byte.
+	end := writer.skip + length
+	encEnd := encOffset
+	for i, part := range writer.parts {
+		ivSize, remaining := int64(ssePartIVSize), part.Size-ssePartIVSize
+		if i == 0 {
+			remaining = writer.remaining
+			if writer.aead != nil {
+				ivSize = 0
+			}
+		}
+		var partSize int64
+		if partSize, err = sseDecryptedPackagesSize(remaining); err != nil {
+			return nil, 0, 0, err
+		}
+		if end <= partSize {
+			packages := (end + ssePackageSize - 1) / ssePackageSize
+			encEnd += ivSize + minInt64(packages*sseEncryptedPackageSize, remaining)
+			break
+		}
+		end -= partSize
+		encEnd += ivSize + remaining
+	}
+	return writer, encOffset, encEnd - encOffset, nil
+}
+
+// minInt64 - returns the smaller of two int64 values.
+func minInt64(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// initPart - initializes the cipher of the current part with its IV.
+func (w *sseDecryptWriter) initPart(iv []byte) (err error) {
+	if len(iv) != ssePartIVSize {
+		return errObjectTampered
+	}
+	w.aead, err = newAEAD(ssePartKey(w.objectKey, w.parts[0].Number, iv))
+	if err != nil {
+		return err
+	}
+	w.nonce = make([]byte, w.aead.NonceSize())
+	return nil
+}
+
+func (w *sseDecryptWriter) Write(p []byte) (n int, err error) {
+	w.ciphertext = append(w.ciphertext, p...)
+	for w.remaining > 0 {
+		if w.aead == nil {
+			if len(w.ciphertext) < ssePartIVSize {
+				break
+			}
+			if err = w.initPart(w.ciphertext[:ssePartIVSize]); err != nil {
+				return 0, err
+			}
+			w.ciphertext = append(w.ciphertext[:0], w.ciphertext[ssePartIVSize:]...)
+		}
+		packageSize := minInt64(sseEncryptedPackageSize, w.remaining)
+		if int64(len(w.ciphertext)) < packageSize {
+			break
+		}
+		final := packageSize == w.remaining
+		nonce := ssePackageNonce(w.nonce, w.sequence, final)
+		plaintext, err := w.aead.Open(nil, nonce, w.ciphertext[:packageSize], nil)
+		if err != nil {
+			return 0, errObjectTampered
+		}
+		w.ciphertext = append(w.ciphertext[:0], w.ciphertext[packageSize:]...)
+		w.remaining -= packageSize
+		w.sequence++
+
+		if err = w.writePlaintext(plaintext); err != nil {
+			return 0, err
+		}
+
+		if final && len(w.parts) > 1 {
+			w.parts = w.parts[1:]
+			w.sequence = 0
+			w.remaining = w.parts[0].Size - ssePartIVSize
+			w.aead = nil
+		}
+	}
+	return len(p), nil
+}
+
+// writePlaintext - writes the requested range of a decrypted package.
+func (w *sseDecryptWriter) writePlaintext(plaintext []byte) error {
+	if w.skip >= int64(len(plaintext)) {
+		w.skip -= int64(len(plaintext))
+		return nil
+	}
+	plaintext = plaintext[w.skip:]
+	w.skip = 0
+	if int64(len(plaintext)) > w.length {
+		plaintext = plaintext[:w.length]
+	}
+	if len(plaintext) == 0 {
+		return nil
+	}
+	w.length -= int64(len(plaintext))
+	_, err := w.dst.Write(plaintext)
+	return err
+}
+
+// hashVerifyReader - verifies the MD5 and SHA256 sums sent by the client
+// over the plaintext, since the object layer only sees encrypted data.
+type hashVerifyReader struct {
+	src          io.Reader
+	md5Hash      hash.Hash
+	sha256Hash   hash.Hash
+	md5Hex       string
+	sha256sumHex string
+}
+
+// newHashVerifyReader - returns a reader which fails at EOF if the data
+// read does not match the given hex encoded sums, empty sums are ignored.
+func newHashVerifyReader(src io.Reader, md5Hex, sha256sumHex string) io.Reader {
+	return &hashVerifyReader{
+		src:          src,
+		md5Hash:      md5.New(),
+		sha256Hash:   sha256.New(),
+		md5Hex:       md5Hex,
+		sha256sumHex: sha256sumHex,
+	}
+}
+
+func (r *hashVerifyReader) Read(p []byte) (n int, err error) {
+	n, err = r.src.Read(p)
+	r.md5Hash.Write(p[:n])
+	r.sha256Hash.Write(p[:n])
+	if err == io.EOF {
+		if calculatedMD5 := hex.EncodeToString(r.md5Hash.Sum(nil)); r.md5Hex != "" && r.md5Hex != calculatedMD5 {
+			return n, traceError(BadDigest{r.md5Hex, calculatedMD5})
+		}
+		if r.sha256sumHex != "" && r.sha256sumHex != hex.EncodeToString(r.sha256Hash.Sum(nil)) {
+			return n, traceError(SHA256Mismatch{})
+		}
+	}
+	return n, err
+}
@@ -0,0 +1,493 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/base64"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	humanize "github.com/dustin/go-humanize"
+)
+
+// Returns the SSE-C header fields for the given key.
+func newSSECustomerHeader(key []byte) http.Header {
+	keyMD5 := md5.Sum(key)
+	header := make(http.Header)
+	header.Set(SSECustomerAlgorithm, SSECustomerAlgorithmAES256)
+	header.Set(SSECustomerKey, base64.StdEncoding.EncodeToString(key))
+	header.Set(SSECustomerKeyMD5, base64.StdEncoding.EncodeToString(keyMD5[:]))
+	return header
+}
+
+// Tests validation of the SSE-C header fields.
+func TestParseSSECustomerRequest(t *testing.T) {
+	defer func(isSSL bool) { globalIsSSL = isSSL }(globalIsSSL)
+
+	key := bytes.Repeat([]byte{1}, SSECustomerKeySize)
+	testCases := []struct {
+		isSSL       bool
+		header      func(http.Header)
+		expectedErr error
+	}{
+		// Valid request.
+		{true, func(http.Header) {}, nil},
+		// Insecure connection.
+		{false, func(http.Header) {}, errInsecureSSERequest},
+		// Invalid algorithm.
+		{true, func(h http.Header) { h.Set(SSECustomerAlgorithm, "AES128") }, errInvalidSSEAlgorithm},
+		// Missing key.
+		{true, func(h http.Header) { h.Del(SSECustomerKey) }, errMissingSSEKey},
+		// Missing key MD5.
+		{true, func(h http.Header) { h.Del(SSECustomerKeyMD5) }, errMissingSSEKeyMD5},
+		// Key too short.
+		{true, func(h http.Header) { h.Set(SSECustomerKey, base64.StdEncoding.EncodeToString(key[1:])) }, errInvalidSSEKey},
+		// Key MD5 of another key.
+		{true, func(h http.Header) { h.Set(SSECustomerKeyMD5, base64.StdEncoding.EncodeToString(key[:16])) }, errSSEKeyMD5Mismatch},
+	}
+
+	for i, testCase := range testCases {
+		globalIsSSL = testCase.isSSL
+		header := newSSECustomerHeader(key)
+		testCase.header(header)
+		if !hasSSECustomerHeader(header) {
+			t.Fatalf("Test %d: Expected SSE-C header fields to be detected", i+1)
+		}
+		parsedKey, err := parseSSECustomerRequest(&http.Request{Header: header})
+		if err != testCase.expectedErr {
+			t.Errorf("Test %d: Expected error %v, got %v", i+1, testCase.expectedErr, err)
+		}
+		if err == nil && !bytes.Equal(parsedKey, key) {
+			t.Errorf("Test %d: Parsed key does not match the client key", i+1)
+		}
+	}
+}
+
+// Tests sealing and unsealing the object key.
+func TestSSEObjectKey(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, SSECustomerKeySize)
+	metadata := make(map[string]string)
+	objectKey, err := newSSEObjectKey(key, metadata)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isEncryptedObject(metadata) {
+		t.Fatal("Expected metadata of an encrypted object")
+	}
+
+	unsealedKey, err := unsealSSEObjectKey(key, metadata)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(unsealedKey, objectKey) {
+		t.Fatal("Unsealed object key does not match the generated key")
+	}
+
+	wrongKey := bytes.Repeat([]byte{2}, SSECustomerKeySize)
+	if _, err = unsealSSEObjectKey(wrongKey, metadata); err != errSSEKeyMismatch {
+		t.Fatalf("Expected %v, got %v", errSSEKeyMismatch, err)
+	}
+}
+
+// Tests the encrypted and decrypted sizes of data.
+func TestSSESizes(t *testing.T) {
+	for _, size := range []int64{0, 1, ssePackageSize - 1, ssePackageSize, ssePackageSize + 1, 5*ssePackageSize + 17} {
+		decSize, err := sseDecryptedSize(sseEncryptedSize(size))
+		if err != nil {
+			t.Fatalf("Size %d: %s", size, err)
+		}
+		if decSize != size {
+			t.Errorf("Size %d: Expected decrypted size %d, got %d", size, size, decSize)
+		}
+	}
+	if _, err := sseDecryptedSize(ssePackageOverhead - 1); err != errObjectTampered {
+		t.Errorf("Expected %v, got %v", errObjectTampered, err)
+	}
+	if _, err := sseDecryptedSize(ssePartIVSize + sseEncryptedPackageSize + ssePackageOverhead); err != errObjectTampered {
+		t.Errorf("Expected %v, got %v", errObjectTampered, err)
+	}
+}
+
+// Tests decryption of ranges of encrypted single and multipart data.
+func TestSSEEncryptDecrypt(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, SSECustomerKeySize)
+	metadata := make(map[string]string)
+	objectKey, err := newSSEObjectKey(key, metadata)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := bytes.Repeat([]byte("abcdefghijklmnopqrstuvwxyz"), 3*ssePackageSize/26+100)
+	testCases := []struct {
+		partSizes []int
+	}{
+		{[]int{0}},
+		{[]int{1}},
+		{[]int{ssePackageSize}},
+		{[]int{len(data)}},
+		{[]int{ssePackageSize, ssePackageSize + 1, len(data) - 2*ssePackageSize - 1}},
+	}
+
+	for i, testCase := range testCases {
+		var encrypted []byte
+		objInfo := ObjectInfo{UserDefined: metadata}
+		offset := 0
+		for j, partSize := range testCase.partSizes {
+			reader, err := newSSEEncryptReader(bytes.NewReader(data[offset:offset+partSize]), objectKey, j+1)
+			if err != nil {
+				t.Fatalf("Test %d: %s", i+1, err)
+			}
+			part, err := ioutil.ReadAll(reader)
+			if err != nil {
+				t.Fatalf("Test %d: %s", i+1, err)
+			}
+			if int64(len(part)) != sseEncryptedSize(int64(partSize)) {
+				t.Fatalf("Test %d: Expected encrypted size %d, got %d", i+1, sseEncryptedSize(int64(partSize)), len(part))
+			}
+			encrypted = append(encrypted, part...)
+			objInfo.Parts = append(objInfo.Parts, objectPartInfo{Number: j + 1, Size: int64(len(part))})
+			offset += partSize
+		}
+		plaintext := data[:offset]
+
+		size, err := sseDecryptedObjectSize(objInfo)
+		if err != nil || size != int64(len(plaintext)) {
+			t.Fatalf("Test %d: Expected size %d, got %d (%v)", i+1, len(plaintext), size, err)
+		}
+
+		read := func(offset, length int64, writer io.Writer) error {
+			_, err := writer.Write(encrypted[offset : offset+length])
+			return err
+		}
+		ranges := [][2]int64{{0, size}, {0, size / 2}, {size / 3, size - size/3}, {size - 1, 1}, {ssePackageSize - 1, 2}}
+		for _, rng := range ranges {
+			start, length := rng[0], rng[1]
+			if start < 0 || length < 0 || start+length > size {
+				continue
+			}
+			var buffer bytes.Buffer
+			writer, encOffset, encLength, err := newSSEDecryptWriter(&buffer, key, objInfo, start, length, read)
+			if err != nil {
+				t.Fatalf("Test %d: %s", i+1, err)
+			}
+			if _, err = writer.Write(encrypted[encOffset : encOffset+encLength]); err != nil {
+				t.Fatalf("Test %d: %s", i+1, err)
+			}
+			if !bytes.Equal(buffer.Bytes(), plaintext[start:start+length]) {
+				t.Errorf("Test %d: Range %d-%d does not match the plaintext", i+1, start, start+length)
+			}
+		}
+
+		// Modified data must be detected, empty reads decrypt nothing.
+		if size > 0 {
+			encrypted[len(encrypted)-1] ^= 0x01
+			writer, encOffset, encLength, err := newSSEDecryptWriter(ioutil.Discard, key, objInfo, 0, size, read)
+			if err != nil {
+				t.Fatalf("Test %d: %s", i+1, err)
+			}
+			if _, err = writer.Write(encrypted[encOffset : encOffset+encLength]); err != errObjectTampered {
+				t.Errorf("Test %d: Expected %v, got %v", i+1, errObjectTampered, err)
+			}
+		}
+	}
+}
+
+// Tests that a part uploaded again is encrypted with a different key.
+func TestSSEEncryptPartTwice(t *testing.T) {
+	objectKey, err := newSSEObjectKey(bytes.Repeat([]byte{1}, SSECustomerKeySize), make(map[string]string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := bytes.Repeat([]byte("a"), ssePackageSize+1)
+	var parts [2][]byte
+	for i := range parts {
+		reader, err := newSSEEncryptReader(bytes.NewReader(data), objectKey, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if parts[i], err = ioutil.ReadAll(reader); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if bytes.Equal(parts[0][:ssePartIVSize], parts[1][:ssePartIVSize]) {
+		t.Fatal("Expected the parts to be encrypted with different IVs")
+	}
+	for offset := ssePartIVSize; offset < len(parts[0]); offset += sseEncryptedPackageSize {
+		if bytes.Equal(parts[0][offset:offset+ssePackageOverhead], parts[1][offset:offset+ssePackageOverhead]) {
+			t.Fatalf("Expected the packages at offset %d to differ", offset)
+		}
+	}
+}
+
+// Wrapper for calling encrypted object tests for both XL and FS.
+func TestSSEObjectLayer(t *testing.T) {
+	initNSLock(false)
+	ExecObjectLayerTest(t, testSSEObjectLayer)
+}
+
+// Tests reading ranges of encrypted single and multipart objects.
+func testSSEObjectLayer(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	bucket := "sse-bucket"
+	if err := obj.MakeBucketWithLocation(bucket, ""); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	key := bytes.Repeat([]byte{1}, SSECustomerKeySize)
+	data := bytes.Repeat([]byte("a"), 6*humanize.MiByte)
+
+	// Single encrypted object.
+	metadata := make(map[string]string)
+	objectKey, err := newSSEObjectKey(key, metadata)
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	reader, err := newSSEEncryptReader(bytes.NewReader(data[:100]), objectKey, 1)
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if _, err = obj.PutObject(bucket, "single", sseEncryptedSize(100), reader, metadata, ""); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+
+	// Encrypted multipart object.
+	metadata = make(map[string]string)
+	if objectKey, err = newSSEObjectKey(key, metadata); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	uploadID, err := obj.NewMultipartUpload(bucket, "multipart", metadata)
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	var parts []completePart
+	for i, partData := range [][]byte{data[:5*humanize.MiByte], data[5*humanize.MiByte:]} {
+		if reader, err = newSSEEncryptReader(bytes.NewReader(partData), objectKey, i+1); err != nil {
+			t.Fatalf("%s: %s", instanceType, err)
+		}
+		partInfo, perr := obj.PutObjectPart(bucket, "multipart", uploadID, i+1, sseEncryptedSize(int64(len(partData))), reader, "", "")
+		if perr != nil {
+			t.Fatalf("%s: %s", instanceType, perr)
+		}
+		parts = append(parts, completePart{PartNumber: i + 1, ETag: partInfo.ETag})
+	}
+	if _, err = obj.CompleteMultipartUpload(bucket, "multipart", uploadID, parts); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+
+	for object, plaintext := range map[string][]byte{"single": data[:100], "multipart": data} {
+		objInfo, err := obj.GetObjectInfo(bucket, object)
+		if err != nil {
+			t.Fatalf("%s: %s", instanceType, err)
+		}
+		size, err := sseDecryptedObjectSize(objInfo)
+		if err != nil || size != int64(len(plaintext)) {
+			t.Fatalf("%s: %s: Expected size %d, got %d (%v)", instanceType, object, len(plaintext), size, err)
+		}
+		start, length := size/2-10, size/2
+		read := func(offset, length int64, writer io.Writer) error {
+			return obj.GetObject(bucket, object, offset, length, writer)
+		}
+		var buffer bytes.Buffer
+		writer, encOffset, encLength, err := newSSEDecryptWriter(&buffer, key, objInfo, start, length, read)
+		if err != nil {
+			t.Fatalf("%s: %s", instanceType, err)
+		}
+		if err = obj.GetObject(bucket, object, encOffset, encLength, writer); err != nil {
+			t.Fatalf("%s: %s", instanceType, err)
+		}
+		if !bytes.Equal(buffer.Bytes(), plaintext[start:start+length]) {
+			t.Errorf("%s: %s: Decrypted range does not match the plaintext", instanceType, object)
+		}
+	}
+}
+
+// Sets a local KMS with the given master key as the global KMS, returns
+// a function restoring the previous global KMS.
+func setTestKMS(t TestErrHandler, keyID string, keys map[string][32]byte) func() {
+	kms, err := newLocalKMS(keyID, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prevKMS := globalKMS
+	globalKMS = kms
+	return func() { globalKMS = prevKMS }
+}
+
+// Tests selecting the key of new objects from the request headers.
+func TestGetSSEPutKey(t *testing.T) {
+	defer setTestKMS(t, "key-1", map[string][32]byte{"key-1": {1}})()
+	defer func(isSSL bool) { globalIsSSL = isSSL }(globalIsSSL)
+	globalIsSSL = true
+
+	clientKey := bytes.Repeat([]byte{1}, SSECustomerKeySize)
+	sseS3Header := make(http.Header)
+	sseS3Header.Set(SSEHeader, SSEAlgorithmAES256)
+	invalidHeader := make(http.Header)
+	invalidHeader.Set(SSEHeader, "aws:kms")
+	bothHeader := newSSECustomerHeader(clientKey)
+	bothHeader.Set(SSEHeader, SSEAlgorithmAES256)
+
+	testCases := []struct {
+		header      http.Header
+		sseS3       bool
+		expectedErr error
+	}{
+		{http.Header{}, false, nil},
+		{newSSECustomerHeader(clientKey), false, nil},
+		{sseS3Header, true, nil},
+		{invalidHeader, false, errInvalidEncryptionMethod},
+		{bothHeader, false, errIncompatibleEncryptionMethod},
+	}
+
+	for i, testCase := range testCases {
+		metadata := make(map[string]string)
+		key, err := getSSEPutKey(&http.Request{Header: testCase.header}, "bucket", "object", metadata)
+		if err != testCase.expectedErr {
+			t.Errorf("Test %d: Expected error %v, got %v", i+1, testCase.expectedErr, err)
+			continue
+		}
+		if _, ok := metadata[ServerSideEncryptionKMSSealedKey]; ok != testCase.sseS3 {
+			t.Errorf("Test %d: Expected sealed data key %v, got %v", i+1, testCase.sseS3, ok)
+		}
+		if testCase.sseS3 {
+			dataKey, uerr := unsealSSES3Key("bucket", "object", metadata)
+			if uerr != nil || !bytes.Equal(dataKey, key) {
+				t.Errorf("Test %d: Unable to unseal data key: %v", i+1, uerr)
+			}
+			// Data keys are bound to the object.
+			if _, uerr = unsealSSES3Key("bucket", "other-object", metadata); uerr == nil {
+				t.Errorf("Test %d: Expected unsealing for a different object to fail", i+1)
+			}
+		}
+	}
+}
+
+// Wrapper for calling SSE-S3 key rotation tests for both XL and FS.
+func TestSSES3KeyRotation(t *testing.T) {
+	ExecObjectLayerTest(t, testSSES3KeyRotation)
+}
+
+// Tests re-sealing the object keys of SSE-S3 objects with a new master key.
+func testSSES3KeyRotation(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	keys := map[string][32]byte{"key-1": {1}, "key-2": {2}}
+	defer setTestKMS(t, "key-1", keys)()
+
+	bucket := "sse-bucket"
+	if err := obj.MakeBucketWithLocation(bucket, ""); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	data := bytes.Repeat([]byte("a"), 6*humanize.MiByte)
+
+	// Single SSE-S3 object.
+	metadata := make(map[string]string)
+	key, err := newSSES3Key(bucket, "single", metadata)
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	objectKey, err := newSSEObjectKey(key, metadata)
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	reader, err := newSSEEncryptReader(bytes.NewReader(data[:100]), objectKey, 1)
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if _, err = obj.PutObject(bucket, "single", sseEncryptedSize(100), reader, metadata, ""); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+
+	// SSE-S3 multipart object.
+	metadata = make(map[string]string)
+	if key, err = newSSES3Key(bucket, "multipart", metadata); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if objectKey, err = newSSEObjectKey(key, metadata); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	uploadID, err := obj.NewMultipartUpload(bucket, "multipart", metadata)
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	var parts []completePart
+	for i, partData := range [][]byte{data[:5*humanize.MiByte], data[5*humanize.MiByte:]} {
+		if reader, err = newSSEEncryptReader(bytes.NewReader(partData), objectKey, i+1); err != nil {
+			t.Fatalf("%s: %s", instanceType, err)
+		}
+		partInfo, perr := obj.PutObjectPart(bucket, "multipart", uploadID, i+1, sseEncryptedSize(int64(len(partData))), reader, "", "")
+		if perr != nil {
+			t.Fatalf("%s: %s", instanceType, perr)
+		}
+		parts = append(parts, completePart{PartNumber: i + 1, ETag: partInfo.ETag})
+	}
+	if _, err = obj.CompleteMultipartUpload(bucket, "multipart", uploadID, parts); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+
+	// Rotate the master key.
+	globalKMS, _ = newLocalKMS("key-2", keys)
+
+	for object, plaintext := range map[string][]byte{"single": data[:100], "multipart": data} {
+		objInfo, err := obj.GetObjectInfo(bucket, object)
+		if err != nil {
+			t.Fatalf("%s: %s", instanceType, err)
+		}
+		srcKey, err := unsealSSES3Key(bucket, object, objInfo.UserDefined)
+		if err != nil {
+			t.Fatalf("%s: %s", instanceType, err)
+		}
+		metadata := make(map[string]string)
+		for k, v := range objInfo.UserDefined {
+			metadata[k] = v
+		}
+		dstKey, err := newSSES3Key(bucket, object, metadata)
+		if err != nil {
+			t.Fatalf("%s: %s", instanceType, err)
+		}
+		if _, err = rotateSSEObjectKey(obj, objInfo, srcKey, dstKey, metadata); err != nil {
+			t.Fatalf("%s: %s", instanceType, err)
+		}
+
+		rotatedInfo, err := obj.GetObjectInfo(bucket, object)
+		if err != nil {
+			t.Fatalf("%s: %s", instanceType, err)
+		}
+		if rotatedInfo.UserDefined[ServerSideEncryptionKMSKeyID] != "key-2" {
+			t.Fatalf("%s: %s: Expected key-2, got %s", instanceType, object, rotatedInfo.UserDefined[ServerSideEncryptionKMSKeyID])
+		}
+		if rotatedInfo.ETag != objInfo.ETag {
+			t.Errorf("%s: %s: Expected etag %s, got %s", instanceType, object, objInfo.ETag, rotatedInfo.ETag)
+		}
+
+		key, err := unsealSSES3Key(bucket, object, rotatedInfo.UserDefined)
+		if err != nil {
+			t.Fatalf("%s: %s", instanceType, err)
+		}
+		var buffer bytes.Buffer
+		writer, encOffset, encLength, err := newSSEDecryptWriter(&buffer, key, rotatedInfo, 0, int64(len(plaintext)), nil)
+		if err != nil {
+			t.Fatalf("%s: %s", instanceType, err)
+		}
+		if err = obj.GetObject(bucket, object, encOffset, encLength, writer); err != nil {
+			t.Fatalf("%s: %s", instanceType, err)
+		}
+		if !bytes.Equal(buffer.Bytes(), plaintext) {
+			t.Errorf("%s: %s: Decrypted object does not match the plaintext", instanceType, object)
+		}
+	}
+}
@@ -176,14 +176,9 @@ func (endpoints EndpointList) SetHTTP() {
 
 // NewEndpointList - returns new endpoint list based on input args.
 func NewEndpointList(args ...string) (endpoints EndpointList, err error) {
-	// isValidDistribution - checks whether given count is a valid distribution for erasure coding.
-	isValidDistribution := func(count int) bool {
-		return (count >= minErasureBlocks && count <= maxErasureBlocks && count%2 == 0)
-	}
-
-	// Check whether no. of args are valid for XL distribution.
-	if !isValidDistribution(len(args)) {
-		return nil, fmt.Errorf("A total of %d endpoints were found. For erasure mode it should be an even number between %d and %d", len(args), minErasureBlocks, maxErasureBlocks)
+	// Check whether no. of args can be divided into erasure sets.
+	if getSetDriveCount(len(args)) == 0 {
+		return nil, fmt.Errorf("A total of %d endpoints were found. For erasure mode it should be divisible into sets of an even number between %d and %d", len(args), minErasureBlocks, maxErasureBlocks)
 	}
 
 	var endpointType EndpointType
@@ -382,6 +377,17 @@ func CreateEndpoints(serverAddr string, args ...string) (string, EndpointList, S
 		}
 	}
 
+	// Every server needs a lock server of its own, check whether
+	// the number of servers is supported by distributed locking.
+	uniqueHosts := set.NewStringSet()
+	for _, endpoint := range endpoints {
+		uniqueHosts.Add(endpoint.Host)
+	}
+	if len(uniqueHosts) > dsyncMaxNodes {
+		err = fmt.Errorf("A total of %d servers were found. Distributed setup supports at most %d servers", len(uniqueHosts), dsyncMaxNodes)
+		return serverAddr, endpoints, setupType, err
+	}
+
 	setupType = DistXLSetupType
 	return serverAddr, endpoints, setupType, nil
 }
@@ -406,3 +412,17 @@ func GetRemotePeers(endpoints EndpointList) []string {
 
 	return peerSet.ToSlice()
 }
+
+// GetLocalPeer - returns the host information of this minio service
+// in distributed setups, localhost with the service port otherwise.
+func GetLocalPeer(endpoints EndpointList) string {
+	for _, endpoint := range endpoints {
+		if endpoint.Type() != URLEndpointType || !endpoint.IsLocal {
+			continue
+		}
+		if _, port := mustSplitHostPort(endpoint.Host); port == globalMinioPort {
+			return endpoint.Host
+		}
+	}
+	return net.JoinHostPort("localhost", globalMinioPort)
+}
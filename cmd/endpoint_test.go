@@ -122,7 +122,7 @@ func TestNewEndpointList(t *testing.T) {
 		{[]string{"d1", "d2", "d3", "d1"}, fmt.Errorf("duplicate endpoints found")},
 		{[]string{"d1", "d2", "d3", "./d1"}, fmt.Errorf("duplicate endpoints found")},
 		{[]string{"http://localhost/d1", "http://localhost/d2", "http://localhost/d1", "http://localhost/d4"}, fmt.Errorf("duplicate endpoints found")},
-		{[]string{"d1", "d2", "d3", "d4", "d5"}, fmt.Errorf("A total of 5 endpoints were found. For erasure mode it should be an even number between 4 and 16")},
+		{[]string{"d1", "d2", "d3", "d4", "d5"}, fmt.Errorf("A total of 5 endpoints were found. For erasure mode it should be divisible into sets of an even number between 4 and 16")},
 		{[]string{"ftp://server/d1", "http://server/d2", "http://server/d3", "http://server/d4"}, fmt.Errorf("'ftp://server/d1': invalid URL endpoint format")},
 		{[]string{"d1", "http://localhost/d2", "d3", "d4"}, fmt.Errorf("mixed style endpoints are not supported")},
 		{[]string{"http://example.org/d1", "https://example.com/d1", "http://example.net/d1", "https://example.edut/d1"}, fmt.Errorf("mixed scheme is not supported")},
@@ -224,6 +224,12 @@ func TestCreateEndpoints(t *testing.T) {
 	}
 	case6URLs, case6LocalFlags := getExpectedEndpoints(args, "http://"+nonLoopBackIP+":9003/")
 
+	// 18 servers with 2 disks each.
+	case7Args := []string{"http://" + nonLoopBackIP + ":9000/d1", "http://" + nonLoopBackIP + ":9000/d2"}
+	for i := 1; i < 18; i++ {
+		case7Args = append(case7Args, fmt.Sprintf("http://10.0.1.%d:9000/d1", i), fmt.Sprintf("http://10.0.1.%d:9000/d2", i))
+	}
+
 	testCases := []struct {
 		serverAddr         string
 		args               []string
@@ -316,6 +322,9 @@ func TestCreateEndpoints(t *testing.T) {
 			Endpoint{URL: case6URLs[2], IsLocal: case6LocalFlags[2]},
 			Endpoint{URL: case6URLs[3], IsLocal: case6LocalFlags[3]},
 		}, DistXLSetupType, nil},
+
+		// DistXL Setup with more servers than distributed locking supports.
+		{":9000", case7Args, "", EndpointList{}, -1, fmt.Errorf("A total of 18 servers were found. Distributed setup supports at most 16 servers")},
 	}
 
 	for _, testCase := range testCases {
@@ -0,0 +1,140 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strconv"
+
+	"github.com/minio/highwayhash"
+)
+
+// magicHighwayHash256Key - fixed key of the HighwayHash checksums.
+// HighwayHash is used for bit-rot detection and not as a MAC, the key
+// is not secret but must never change.
+var magicHighwayHash256Key = []byte("\x4b\xe7\x34\xfa\x8e\x23\x8a\xcd\x26\x3e\x83\xe6\xbb\x96\x85\x52\x04\x0f\x93\x5d\xa3\x9f\x44\x14\x97\xe0\x9d\x13\x22\xde\x36\xa0")
+
+// streamingBitrotHashSize - size of the checksum stored in front of
+// every erasure block by streaming bit-rot algos.
+const streamingBitrotHashSize = highwayhash.Size
+
+// isStreamingBitrotAlgo - tells if a bit-rot algo checksums every
+// erasure block of a part file instead of the entire file.
+func isStreamingBitrotAlgo(algo HashAlgo) bool {
+	return algo == HashHighwayHash256S
+}
+
+// bitrotHashSize - returns the size of the checksum in front of every
+// erasure block in part files written with the given bit-rot algo.
+func bitrotHashSize(algo HashAlgo) int64 {
+	if isStreamingBitrotAlgo(algo) {
+		return streamingBitrotHashSize
+	}
+	return 0
+}
+
+// bitrotSumBlock - returns an encoded block with its checksum in front
+// of it, as written to the disks.
+func bitrotSumBlock(block []byte, algo HashAlgo) []byte {
+	h := newHash(algo)
+	h.Write(block)
+	return append(h.Sum(make([]byte, 0, streamingBitrotHashSize+len(block))), block...)
+}
+
+// bitrotSumBlocks - returns the encoded blocks with their checksum in
+// front of them.
+func bitrotSumBlocks(enBlocks [][]byte, algo HashAlgo) [][]byte {
+	sumBlocks := make([][]byte, len(enBlocks))
+	for index, block := range enBlocks {
+		sumBlocks[index] = bitrotSumBlock(block, algo)
+	}
+	return sumBlocks
+}
+
+// bitrotVerifyBlock - verifies an erasure block read along with the
+// checksum in front of it, returns the block without its checksum.
+func bitrotVerifyBlock(buf []byte, algo HashAlgo) ([]byte, error) {
+	if len(buf) < streamingBitrotHashSize {
+		return nil, errUnexpected
+	}
+	h := newHash(algo)
+	h.Write(buf[streamingBitrotHashSize:])
+	if sum := h.Sum(nil); !bytes.Equal(sum, buf[:streamingBitrotHashSize]) {
+		return nil, hashMismatchError{
+			hex.EncodeToString(buf[:streamingBitrotHashSize]),
+			hex.EncodeToString(sum),
+		}
+	}
+	return buf[streamingBitrotHashSize:], nil
+}
+
+// bitrotVerifyFile - verifies a part file of size bytes on a disk,
+// either the checksum of the entire file or the checksums of all its
+// erasure blocks with streaming bit-rot algos. Reads are limited to
+// the rate of the limiter, if any. Returns the number of bytes read
+// and hashMismatchError if the file is corrupted.
+func bitrotVerifyFile(disk StorageAPI, volume, path string, size int64, erasure erasureInfo,
+	ckSumInfo checkSumInfo, limiter *scrubRateLimiter) (int64, error) {
+
+	if !isStreamingBitrotAlgo(ckSumInfo.Algorithm) {
+		writer := &scrubHashWriter{Hash: newHash(ckSumInfo.Algorithm), limiter: limiter}
+		sum, err := hashSum(disk, volume, path, writer)
+		if err != nil {
+			return writer.n, err
+		}
+		if computed := hex.EncodeToString(sum); computed != ckSumInfo.Hash {
+			return writer.n, hashMismatchError{ckSumInfo.Hash, computed}
+		}
+		return writer.n, nil
+	}
+
+	// Truncated or extended part files are corrupted as well.
+	fi, err := disk.StatFile(volume, path)
+	if err != nil {
+		return 0, err
+	}
+	if expected := sizeOnDisk(size, erasure.BlockSize, erasure.DataBlocks, ckSumInfo.Algorithm); fi.Size != expected {
+		return 0, hashMismatchError{
+			"size " + strconv.FormatInt(expected, 10),
+			"size " + strconv.FormatInt(fi.Size, 10),
+		}
+	}
+
+	chunkSize := getChunkSize(erasure.BlockSize, erasure.DataBlocks)
+	buf := make([]byte, streamingBitrotHashSize+chunkSize)
+
+	var offset, bytesRead int64
+	for remaining := size; remaining > 0; remaining -= erasure.BlockSize {
+		curBlockSize := erasure.BlockSize
+		if remaining < curBlockSize {
+			curBlockSize = remaining
+		}
+		blockBuf := buf[:streamingBitrotHashSize+getChunkSize(curBlockSize, erasure.DataBlocks)]
+		limiter.wait(len(blockBuf))
+		n, err := disk.ReadFile(volume, path, offset, blockBuf)
+		bytesRead += n
+		if err != nil {
+			return bytesRead, err
+		}
+		if _, err = bitrotVerifyBlock(blockBuf, ckSumInfo.Algorithm); err != nil {
+			return bytesRead, err
+		}
+		offset += n
+	}
+	return bytesRead, nil
+}
@@ -0,0 +1,216 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+
+	humanize "github.com/dustin/go-humanize"
+	"github.com/minio/minio/pkg/bpool"
+)
+
+// Flips a byte of a file on a disk at the given offset.
+func corruptTestFile(t *testing.T, disk StorageAPI, volume, path string, offset int64) {
+	buf, err := disk.ReadAll(volume, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf[offset] ^= 0xff
+	if err = disk.DeleteFile(volume, path); err != nil {
+		t.Fatal(err)
+	}
+	if err = disk.AppendFile(volume, path, buf); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Tests the size of part files with checksums of every erasure block.
+func TestSizeOnDisk(t *testing.T) {
+	testCases := []struct {
+		size     int64
+		algo     HashAlgo
+		expected int64
+	}{
+		{0, HashHighwayHash256S, 0},
+		{0, HashBlake2b, 0},
+		{10 * humanize.MiByte, HashBlake2b, 10 * humanize.MiByte / 8},
+		{10 * humanize.MiByte, HashHighwayHash256S, 10*humanize.MiByte/8 + 10*streamingBitrotHashSize},
+		{10*humanize.MiByte + 1, HashHighwayHash256S, 10*humanize.MiByte/8 + 1 + 11*streamingBitrotHashSize},
+	}
+	for i, testCase := range testCases {
+		if size := sizeOnDisk(testCase.size, humanize.MiByte, 8, testCase.algo); size != testCase.expected {
+			t.Errorf("Test %d: Expected %d, got %d", i+1, testCase.expected, size)
+		}
+	}
+}
+
+// Tests that range reads only verify the erasure blocks they read.
+func TestErasureReadFileBitrotRange(t *testing.T) {
+	dataBlocks, parityBlocks := 4, 4
+	blockSize := int64(64 * humanize.KiByte)
+	setup, err := newErasureTestSetup(dataBlocks, parityBlocks, blockSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer setup.Remove()
+
+	data := make([]byte, 5*blockSize)
+	if _, err = rand.Read(data); err != nil {
+		t.Fatal(err)
+	}
+	length := int64(len(data))
+	_, _, checkSums, err := erasureCreateFile(setup.disks, "testbucket", "testobject", bytes.NewReader(data), true, blockSize, dataBlocks, parityBlocks, HashHighwayHash256S, dataBlocks+1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, sum := range checkSums {
+		if sum != "" {
+			t.Fatalf("Expected no checksums of entire files, got %s", sum)
+		}
+	}
+
+	// Corrupt the fourth block of the first data disk.
+	chunkSize := getChunkSize(blockSize, dataBlocks)
+	corruptTestFile(t, setup.disks[0], "testbucket", "testobject", 3*(streamingBitrotHashSize+chunkSize)+streamingBitrotHashSize+10)
+
+	pool := bpool.NewBytePool(chunkSize+streamingBitrotHashSize, len(setup.disks))
+	read := func(disks []StorageAPI, offset, readLen int64) {
+		buf := &bytes.Buffer{}
+		if _, err = erasureReadFile(buf, disks, "testbucket", "testobject", offset, readLen, length, blockSize, dataBlocks, parityBlocks, checkSums, HashHighwayHash256S, pool); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(buf.Bytes(), data[offset:offset+readLen]) {
+			t.Fatalf("Read of %d bytes at %d differs", readLen, offset)
+		}
+	}
+
+	// Reads of other blocks keep reading from the disk.
+	disks := append([]StorageAPI{}, setup.disks...)
+	read(disks, blockSize+100, blockSize)
+	read(disks, 4*blockSize, blockSize)
+	if disks[0] == nil {
+		t.Fatal("Expected the disk not to be verified beyond the blocks read")
+	}
+
+	// Reads of the corrupted block are reconstructed from the others.
+	read(disks, 3*blockSize+10, 100)
+	if disks[0] != nil {
+		t.Fatal("Expected the corrupted disk to be skipped")
+	}
+}
+
+// Tests reading files checksummed as a whole, as written before
+// erasure blocks carried their own checksum.
+func TestErasureReadFileLegacyBitrot(t *testing.T) {
+	dataBlocks, parityBlocks := 4, 4
+	blockSize := int64(64 * humanize.KiByte)
+	setup, err := newErasureTestSetup(dataBlocks, parityBlocks, blockSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer setup.Remove()
+
+	data := make([]byte, 3*blockSize+1)
+	if _, err = rand.Read(data); err != nil {
+		t.Fatal(err)
+	}
+	length := int64(len(data))
+	_, _, checkSums, err := erasureCreateFile(setup.disks, "testbucket", "testobject", bytes.NewReader(data), true, blockSize, dataBlocks, parityBlocks, legacyBitRotAlgo, dataBlocks+1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	corruptTestFile(t, setup.disks[1], "testbucket", "testobject", 0)
+
+	chunkSize := getChunkSize(blockSize, dataBlocks)
+	pool := bpool.NewBytePool(chunkSize+streamingBitrotHashSize, len(setup.disks))
+	buf := &bytes.Buffer{}
+	if _, err = erasureReadFile(buf, setup.disks, "testbucket", "testobject", blockSize, length-blockSize, length, blockSize, dataBlocks, parityBlocks, checkSums, legacyBitRotAlgo, pool); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), data[blockSize:]) {
+		t.Fatal("Contents of the erasure coded file differs")
+	}
+	if setup.disks[1] != nil {
+		t.Fatal("Expected the corrupted disk to be skipped")
+	}
+}
+
+// Tests healing and verifying files with checksums of every erasure
+// block.
+func TestErasureHealFileBitrot(t *testing.T) {
+	dataBlocks, parityBlocks := 4, 4
+	blockSize := int64(64 * humanize.KiByte)
+	setup, err := newErasureTestSetup(dataBlocks, parityBlocks, blockSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer setup.Remove()
+
+	data := make([]byte, 2*blockSize+100)
+	if _, err = rand.Read(data); err != nil {
+		t.Fatal(err)
+	}
+	size := int64(len(data))
+	if _, _, _, err = erasureCreateFile(setup.disks, "testbucket", "testobject", bytes.NewReader(data), true, blockSize, dataBlocks, parityBlocks, HashHighwayHash256S, dataBlocks+1); err != nil {
+		t.Fatal(err)
+	}
+
+	erasure := erasureInfo{DataBlocks: dataBlocks, ParityBlocks: parityBlocks, BlockSize: blockSize}
+	ckSumInfo := checkSumInfo{Algorithm: HashHighwayHash256S}
+	verify := func(disk StorageAPI, path string) error {
+		_, err := bitrotVerifyFile(disk, "testbucket", path, size, erasure, ckSumInfo, nil)
+		return err
+	}
+	for index, disk := range setup.disks {
+		if err = verify(disk, "testobject"); err != nil {
+			t.Fatalf("Disk %d: unexpected error %v", index, err)
+		}
+	}
+
+	// Corrupted and truncated files fail verification.
+	corruptTestFile(t, setup.disks[1], "testbucket", "testobject", 2*(streamingBitrotHashSize+getChunkSize(blockSize, dataBlocks))+5)
+	if _, ok := verify(setup.disks[1], "testobject").(hashMismatchError); !ok {
+		t.Fatal("Expected the corrupted file to fail verification")
+	}
+	buf, err := setup.disks[2].ReadAll("testbucket", "testobject")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = setup.disks[2].AppendFile("testbucket", "truncated", buf[:len(buf)-1]); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := verify(setup.disks[2], "truncated").(hashMismatchError); !ok {
+		t.Fatal("Expected the truncated file to fail verification")
+	}
+
+	// The file of the first disk is lost, the corrupted block of the
+	// second disk is reconstructed while healing.
+	latest := append([]StorageAPI{}, setup.disks...)
+	latest[0] = nil
+	outDated := make([]StorageAPI, len(setup.disks))
+	outDated[0], outDated[1] = setup.disks[0], setup.disks[1]
+	if _, err = erasureHealFile(latest, outDated, "testbucket", "testobject", "testbucket", "healed", size, blockSize, dataBlocks, parityBlocks, HashHighwayHash256S); err != nil {
+		t.Fatal(err)
+	}
+	for index := 0; index < 2; index++ {
+		if err = verify(setup.disks[index], "healed"); err != nil {
+			t.Errorf("Disk %d: unexpected error %v", index, err)
+		}
+	}
+}
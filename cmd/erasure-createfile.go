@@ -27,14 +27,19 @@ import (
 
 // erasureCreateFile - writes an entire stream by erasure coding to
 // all the disks, writes also calculate individual block's checksum
-// for future bit-rot protection.
+// for future bit-rot protection. With streaming bit-rot algos the
+// checksum of every block is written in front of it and the returned
+// checksums are empty.
 func erasureCreateFile(disks []StorageAPI, volume, path string, reader io.Reader, allowEmpty bool, blockSize int64,
 	dataBlocks, parityBlocks int, algo HashAlgo, writeQuorum int) (newDisks []StorageAPI, bytesWritten int64, checkSums []string, err error) {
 
 	// Allocated blockSized buffer for reading from incoming stream.
 	buf := make([]byte, blockSize)
 
-	hashWriters := newHashWriters(len(disks), algo)
+	var hashWriters []hash.Hash
+	if !isStreamingBitrotAlgo(algo) {
+		hashWriters = newHashWriters(len(disks), algo)
+	}
 
 	// Read until io.EOF, erasure codes data and writes to all disks.
 	for {
@@ -69,6 +74,9 @@ func erasureCreateFile(disks []StorageAPI, volume, path string, reader io.Reader
 			if enErr != nil {
 				return nil, 0, nil, enErr
 			}
+			if isStreamingBitrotAlgo(algo) {
+				blocks = bitrotSumBlocks(blocks, algo)
+			}
 
 			// Write to all disks.
 			if newDisks, err = appendFile(disks, volume, path, blocks, hashWriters, writeQuorum); err != nil {
@@ -79,7 +87,7 @@ func erasureCreateFile(disks []StorageAPI, volume, path string, reader io.Reader
 	}
 
 	checkSums = make([]string, len(disks))
-	for i := range checkSums {
+	for i := range hashWriters {
 		checkSums[i] = hex.EncodeToString(hashWriters[i].Sum(nil))
 	}
 	return newDisks, bytesWritten, checkSums, nil
@@ -129,8 +137,11 @@ func appendFile(disks []StorageAPI, volume, path string, enBlocks [][]byte, hash
 				return
 			}
 
-			// Calculate hash for each blocks.
-			hashWriters[index].Write(enBlocks[index])
+			// Calculate hash for each blocks, unless they carry
+			// their own checksum.
+			if hashWriters != nil {
+				hashWriters[index].Write(enBlocks[index])
+			}
 
 			// Successfully wrote.
 			wErrs[index] = nil
@@ -16,9 +16,14 @@
 
 package cmd
 
-import "encoding/hex"
+import (
+	"encoding/hex"
+	"hash"
+)
 
 // Heals the erasure coded file. reedsolomon.Reconstruct() is used to reconstruct the missing parts.
+// The healed file is written with the bit-rot algo of the erasure coded file, blocks failing
+// verification of files written with streaming bit-rot algos are reconstructed as well.
 func erasureHealFile(latestDisks []StorageAPI, outDatedDisks []StorageAPI, volume, path, healBucket, healPath string,
 	size, blockSize int64, dataBlocks, parityBlocks int, algo HashAlgo) (checkSums []string, err error) {
 
@@ -26,7 +31,13 @@ func erasureHealFile(latestDisks []StorageAPI, outDatedDisks []StorageAPI, volum
 	remainingSize := size
 
 	// Hash for bitrot protection.
-	hashWriters := newHashWriters(len(outDatedDisks), bitRotAlgo)
+	var hashWriters []hash.Hash
+	if !isStreamingBitrotAlgo(algo) {
+		hashWriters = newHashWriters(len(outDatedDisks), algo)
+	}
+
+	// Size of the checksum in front of every block on the disks.
+	hashSize := bitrotHashSize(algo)
 
 	for remainingSize > 0 {
 		curBlockSize := blockSize
@@ -46,11 +57,15 @@ func erasureHealFile(latestDisks []StorageAPI, outDatedDisks []StorageAPI, volum
 			if disk == nil {
 				continue
 			}
-			enBlocks[index] = make([]byte, curEncBlockSize)
-			_, err := disk.ReadFile(volume, path, offset, enBlocks[index])
+			buf := make([]byte, hashSize+curEncBlockSize)
+			_, err := disk.ReadFile(volume, path, offset, buf)
+			if err == nil && hashSize > 0 {
+				buf, err = bitrotVerifyBlock(buf, algo)
+			}
 			if err != nil {
-				enBlocks[index] = nil
+				continue
 			}
+			enBlocks[index] = buf
 		}
 
 		// Reconstruct missing data.
@@ -64,20 +79,26 @@ func erasureHealFile(latestDisks []StorageAPI, outDatedDisks []StorageAPI, volum
 			if disk == nil {
 				continue
 			}
-			err := disk.AppendFile(healBucket, healPath, enBlocks[index])
+			block := enBlocks[index]
+			if hashSize > 0 {
+				block = bitrotSumBlock(block, algo)
+			}
+			err := disk.AppendFile(healBucket, healPath, block)
 			if err != nil {
 				return nil, traceError(err)
 			}
-			hashWriters[index].Write(enBlocks[index])
+			if hashWriters != nil {
+				hashWriters[index].Write(enBlocks[index])
+			}
 		}
 		remainingSize -= curBlockSize
-		offset += curEncBlockSize
+		offset += hashSize + curEncBlockSize
 	}
 
 	// Checksums for the bit rot.
 	checkSums = make([]string, len(outDatedDisks))
 	for index, disk := range outDatedDisks {
-		if disk == nil {
+		if disk == nil || hashWriters == nil {
 			continue
 		}
 		checkSums[index] = hex.EncodeToString(hashWriters[index].Sum(nil))
@@ -143,6 +143,22 @@ func parallelRead(volume, path string, readDisks, orderedDisks []StorageAPI, enB
 				orderedDisks[index] = nil
 				return
 			}
+			if isStreamingBitrotAlgo(brVerifiers[index].algo) {
+				// Only the block read is verified, against the
+				// checksum in front of it.
+				buf = buf[:streamingBitrotHashSize+curChunkSize]
+				_, err = readDisks[index].ReadFile(volume, path, blockOffset, buf)
+				if err == nil {
+					buf, err = bitrotVerifyBlock(buf, brVerifiers[index].algo)
+				}
+				if err != nil {
+					orderedDisks[index] = nil
+					return
+				}
+				enBlocks[index] = buf
+				return
+			}
+
 			buf = buf[:curChunkSize]
 
 			if needBitRotVerification {
@@ -182,6 +198,8 @@ func parallelRead(volume, path string, readDisks, orderedDisks []StorageAPI, enB
 // block. Data block is trimmed for given offset and length, then
 // written to given writer. This function also supports bit-rot
 // detection by verifying checksum of individual block's checksum.
+// Files written with streaming bit-rot algos only have the blocks
+// being read verified.
 func erasureReadFile(writer io.Writer, disks []StorageAPI, volume, path string,
 	offset, length, totalLength, blockSize int64, dataBlocks, parityBlocks int,
 	checkSums []string, algo HashAlgo, pool *bpool.BytePool) (int64, error) {
@@ -196,10 +214,18 @@ func erasureReadFile(writer io.Writer, disks []StorageAPI, volume, path string,
 		return 0, traceError(errUnexpected)
 	}
 
+	// Nothing to read, blocks past the end of the file do not exist.
+	if length == 0 {
+		return 0, nil
+	}
+
 	// chunkSize is the amount of data that needs to be read from
 	// each disk at a time.
 	chunkSize := getChunkSize(blockSize, dataBlocks)
 
+	// Size of the checksum in front of every block on the disks.
+	hashSize := bitrotHashSize(algo)
+
 	brVerifiers := make([]bitRotVerifier, len(disks))
 	for i := range brVerifiers {
 		brVerifiers[i].algo = algo
@@ -240,7 +266,7 @@ func erasureReadFile(writer io.Writer, disks []StorageAPI, volume, path string,
 		// NOTE: That for the offset calculation we have to use chunkSize and
 		// not curChunkSize. If we use curChunkSize for offset calculation
 		// then it can result in wrong offset for the last block.
-		blockOffset := block * chunkSize
+		blockOffset := block * (chunkSize + hashSize)
 
 		// nextIndex - index from which next set of parallel reads
 		// should happen.
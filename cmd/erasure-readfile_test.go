@@ -253,7 +253,7 @@ func TestErasureReadFileDiskFail(t *testing.T) {
 	// create byte pool which will be used by erasureReadFile for
 	// reading from disks and erasure decoding.
 	chunkSize := getChunkSize(blockSize, dataBlocks)
-	pool := bpool.NewBytePool(chunkSize, len(disks))
+	pool := bpool.NewBytePool(chunkSize+streamingBitrotHashSize, len(disks))
 
 	buf := &bytes.Buffer{}
 	_, err = erasureReadFile(buf, disks, "testbucket", "testobject", 0, length, length, blockSize, dataBlocks, parityBlocks, checkSums, bitRotAlgo, pool)
@@ -355,7 +355,7 @@ func TestErasureReadFileOffsetLength(t *testing.T) {
 		{length - blockSize - 1, blockSize + 1},
 	}
 	chunkSize := getChunkSize(blockSize, dataBlocks)
-	pool := bpool.NewBytePool(chunkSize, len(disks))
+	pool := bpool.NewBytePool(chunkSize+streamingBitrotHashSize, len(disks))
 
 	// Compare the data read from file with "data" byte array.
 	for i, testCase := range testCases {
@@ -418,7 +418,7 @@ func TestErasureReadFileRandomOffsetLength(t *testing.T) {
 	// create pool buffer which will be used by erasureReadFile for
 	// reading from disks and erasure decoding.
 	chunkSize := getChunkSize(blockSize, dataBlocks)
-	pool := bpool.NewBytePool(chunkSize, len(disks))
+	pool := bpool.NewBytePool(chunkSize+streamingBitrotHashSize, len(disks))
 
 	buf := &bytes.Buffer{}
 
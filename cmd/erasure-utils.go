@@ -24,6 +24,7 @@ import (
 	"sync"
 
 	"github.com/klauspost/reedsolomon"
+	"github.com/minio/highwayhash"
 	"github.com/minio/sha256-simd"
 	"golang.org/x/crypto/blake2b"
 )
@@ -49,6 +50,10 @@ func newHash(algo HashAlgo) (h hash.Hash) {
 		// New512 only returns a non-nil error, if the length of the passed
 		// key > 64 bytes - but we use blake2b as hash function (no key)
 		h, _ = blake2b.New512(nil)
+	case HashHighwayHash256S:
+		// ignore the error, because New never fails with a key of
+		// 32 bytes.
+		h, _ = highwayhash.New(magicHighwayHash256Key)
 	// Add new hashes here.
 	default:
 		// Default to blake2b.
@@ -209,7 +214,8 @@ func copyBuffer(writer io.Writer, disk StorageAPI, volume string, path string, b
 }
 
 // bitRotVerifier - type representing bit-rot verification process for
-// a single under-lying object (currently whole files)
+// a single under-lying object, whole files or the erasure blocks of
+// files written with a streaming bit-rot algo.
 type bitRotVerifier struct {
 	// has the bit-rot verification been done?
 	isVerified bool
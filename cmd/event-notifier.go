@@ -175,7 +175,7 @@ func newNotificationEvent(event eventData) NotificationEvent {
 		ETag:        event.ObjInfo.ETag,
 		Size:        event.ObjInfo.Size,
 		ContentType: event.ObjInfo.ContentType,
-		UserDefined: event.ObjInfo.UserDefined,
+		UserDefined: filterReservedMetadata(event.ObjInfo.UserDefined),
 		VersionID:   "1",
 		Sequencer:   uniqueID,
 	}
@@ -297,11 +297,13 @@ func eventNotifyForBucketNotifications(eventType, objectName, bucketName string,
 		if eventMatch && ruleMatch {
 			targetLog := globalEventNotifier.GetExternalTarget(qConfig.QueueARN)
 			if targetLog != nil {
+				globalNotifyInflightEvents.inc(qConfig.QueueARN)
 				targetLog.WithFields(logrus.Fields{
 					"Key":       path.Join(bucketName, objectName),
 					"EventType": eventType,
 					"Records":   nEvent,
 				}).Info()
+				globalNotifyInflightEvents.dec(qConfig.QueueARN)
 			}
 		}
 	}
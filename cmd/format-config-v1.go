@@ -49,6 +49,56 @@ type xlFormat struct {
 	// JBOD field carries the input disk order generated the first
 	// time when fresh disks were supplied.
 	JBOD []string `json:"jbod"`
+	// Sets field carries the JBOD divided into erasure sets of an
+	// equal number of disks, present from version '2' onwards.
+	Sets [][]string `json:"sets,omitempty"`
+}
+
+// setIndexes - returns the JBOD positions of the disks of each erasure
+// set, formats older than version '2' have all disks in a single set.
+func (f *xlFormat) setIndexes() [][]int {
+	if len(f.Sets) == 0 {
+		indexes := make([]int, len(f.JBOD))
+		for i := range indexes {
+			indexes[i] = i
+		}
+		return [][]int{indexes}
+	}
+	setIndexes := make([][]int, len(f.Sets))
+	for i, set := range f.Sets {
+		setIndexes[i] = make([]int, len(set))
+		for j, disk := range set {
+			setIndexes[i][j] = findDiskIndex(disk, f.JBOD)
+		}
+	}
+	return setIndexes
+}
+
+// newXLFormat - returns the xl format of a disk, from version '2'
+// onwards the JBOD is divided into the erasure sets of setIndexes.
+func newXLFormat(version, disk string, jbod []string, setIndexes [][]int) *xlFormat {
+	format := &xlFormat{
+		Version: version,
+		Disk:    disk,
+		JBOD:    jbod,
+	}
+	if version != xlFormatBackendV1 {
+		format.Sets = formatXLSets(jbod, setIndexes)
+	}
+	return format
+}
+
+// formatXLSets - returns the disks of each erasure set, given the JBOD
+// positions of the disks of each set.
+func formatXLSets(jbod []string, setIndexes [][]int) [][]string {
+	sets := make([][]string, len(setIndexes))
+	for i, indexes := range setIndexes {
+		sets[i] = make([]string, len(indexes))
+		for j, index := range indexes {
+			sets[i][j] = jbod[index]
+		}
+	}
+	return sets
 }
 
 // XL format version strings.
@@ -58,6 +108,10 @@ const (
 
 	// formatConfigV1.xlFormat.Version
 	xlFormatBackendV1 = "1"
+
+	// Version '2' adds the erasure sets, disks of version '1'
+	// formats belong to a single set.
+	xlFormatBackendV2 = "2"
 )
 
 // formatConfigV1 - structure holds format config version '1'.
@@ -439,12 +493,14 @@ func checkDisksConsistency(formatConfigs []*formatConfigV1) error {
 // checkJBODConsistency - validate xl jbod order if they are consistent.
 func checkJBODConsistency(formatConfigs []*formatConfigV1) error {
 	var sentinelJBOD []string
+	var sentinelSets [][]string
 	// Extract first valid JBOD.
 	for _, format := range formatConfigs {
 		if format == nil {
 			continue
 		}
 		sentinelJBOD = format.XL.JBOD
+		sentinelSets = format.XL.Sets
 		break
 	}
 	for _, format := range formatConfigs {
@@ -455,6 +511,9 @@ func checkJBODConsistency(formatConfigs []*formatConfigV1) error {
 		if !reflect.DeepEqual(sentinelJBOD, currentJBOD) {
 			return errors.New("Inconsistent JBOD found")
 		}
+		if !reflect.DeepEqual(sentinelSets, format.XL.Sets) {
+			return errors.New("Inconsistent erasure sets found")
+		}
 	}
 	return nil
 }
@@ -553,7 +612,7 @@ func isFormatFound(formats []*formatConfigV1) bool {
 // Heals any missing format.json on the drives. Returns error only for unexpected errors
 // as regular errors can be ignored since there might be enough quorum to be operational.
 // Heals only fresh disks.
-func healFormatXLFreshDisks(storageDisks []StorageAPI) error {
+func healFormatXLFreshDisks(endpoints EndpointList, storageDisks []StorageAPI) error {
 	formatConfigs := make([]*formatConfigV1, len(storageDisks))
 	var referenceConfig *formatConfigV1
 	// Loads `format.json` from all disks.
@@ -586,7 +645,7 @@ func healFormatXLFreshDisks(storageDisks []StorageAPI) error {
 
 	// All disks are fresh, format.json will be written by initFormatXL()
 	if isFormatNotFound(formatConfigs) {
-		return initFormatXL(storageDisks)
+		return initFormatXL(endpoints, storageDisks)
 	}
 
 	// Validate format configs for consistency in JBOD and disks.
@@ -605,7 +664,9 @@ func healFormatXLFreshDisks(storageDisks []StorageAPI) error {
 		}
 	}
 
-	// Collect new JBOD.
+	// Collect new JBOD, fresh disks keep the erasure set of the
+	// disk they replace.
+	setIndexes := referenceConfig.XL.setIndexes()
 	newJBOD := referenceConfig.XL.JBOD
 
 	// Reorder the disks based on the JBOD order.
@@ -631,11 +692,8 @@ func healFormatXLFreshDisks(storageDisks []StorageAPI) error {
 		config := &formatConfigV1{
 			Version: referenceConfig.Version,
 			Format:  referenceConfig.Format,
-			XL: &xlFormat{
-				Version: referenceConfig.XL.Version,
-				Disk:    newJBOD[index],
-				JBOD:    newJBOD,
-			},
+			XL: newXLFormat(referenceConfig.XL.Version, newJBOD[index], newJBOD,
+				setIndexes),
 		}
 		newFormatConfigs[index] = config
 	}
@@ -644,6 +702,7 @@ func healFormatXLFreshDisks(storageDisks []StorageAPI) error {
 	// We need to make sure we have kept the previous order
 	// and allowed fresh disks to be arranged anywhere.
 	// Following block facilitates to put fresh disks.
+	var freshDisks []int
 	for index, format := range formatConfigs {
 		// Format is missing so we go through ordered disks.
 		if format == nil {
@@ -652,6 +711,7 @@ func healFormatXLFreshDisks(storageDisks []StorageAPI) error {
 			for oIndex, disk := range orderedDisks {
 				if disk == nil {
 					orderedDisks[oIndex] = storageDisks[index]
+					freshDisks = append(freshDisks, oIndex)
 					break
 				}
 			}
@@ -664,7 +724,12 @@ func healFormatXLFreshDisks(storageDisks []StorageAPI) error {
 	}
 
 	// Save new `format.json` across all disks, in JBOD order.
-	return saveFormatXL(orderedDisks, newFormatConfigs)
+	if err := saveFormatXL(orderedDisks, newFormatConfigs); err != nil {
+		return err
+	}
+
+	// Fresh disks are healed in background.
+	return markDisksForHealing(orderedDisks, newJBOD, freshDisks)
 }
 
 // Disks from storageDiks are put in assignedDisks if found in orderedDisks and in unAssignedDisks otherwise
@@ -742,7 +807,7 @@ func reorderDisksByInspection(orderedDisks, storageDisks []StorageAPI, formatCon
 }
 
 // Heals corrupted format json in all disks
-func healFormatXLCorruptedDisks(storageDisks []StorageAPI) error {
+func healFormatXLCorruptedDisks(endpoints EndpointList, storageDisks []StorageAPI) error {
 	formatConfigs := make([]*formatConfigV1, len(storageDisks))
 	var referenceConfig *formatConfigV1
 
@@ -776,7 +841,7 @@ func healFormatXLCorruptedDisks(storageDisks []StorageAPI) error {
 
 	// All disks are fresh, format.json will be written by initFormatXL()
 	if isFormatNotFound(formatConfigs) {
-		return initFormatXL(storageDisks)
+		return initFormatXL(endpoints, storageDisks)
 	}
 
 	// Validate format configs for consistency in JBOD and disks.
@@ -795,7 +860,9 @@ func healFormatXLCorruptedDisks(storageDisks []StorageAPI) error {
 		}
 	}
 
-	// Collect new JBOD.
+	// Collect new JBOD, fresh disks keep the erasure set of the
+	// disk they replace.
+	setIndexes := referenceConfig.XL.setIndexes()
 	newJBOD := referenceConfig.XL.JBOD
 
 	// Reorder the disks based on the JBOD order.
@@ -822,10 +889,12 @@ func healFormatXLCorruptedDisks(storageDisks []StorageAPI) error {
 	_, unAssignedDisks := splitDisksByUse(storageDisks, orderedDisks)
 
 	// Assign unassigned disks to nil elements in orderedDisks
+	var freshDisks []int
 	for i, disk := range orderedDisks {
 		if disk == nil && len(unAssignedDisks) > 0 {
 			orderedDisks[i] = unAssignedDisks[0]
 			unAssignedDisks = unAssignedDisks[1:]
+			freshDisks = append(freshDisks, i)
 		}
 	}
 
@@ -839,22 +908,25 @@ func healFormatXLCorruptedDisks(storageDisks []StorageAPI) error {
 		config := &formatConfigV1{
 			Version: referenceConfig.Version,
 			Format:  referenceConfig.Format,
-			XL: &xlFormat{
-				Version: referenceConfig.XL.Version,
-				Disk:    newJBOD[index],
-				JBOD:    newJBOD,
-			},
+			XL: newXLFormat(referenceConfig.XL.Version, newJBOD[index], newJBOD,
+				setIndexes),
 		}
 		newFormatConfigs[index] = config
 	}
 
 	// Save new `format.json` across all disks, in JBOD order.
-	return saveFormatXL(orderedDisks, newFormatConfigs)
+	if err = saveFormatXL(orderedDisks, newFormatConfigs); err != nil {
+		return err
+	}
+
+	// Fresh disks are healed in background.
+	return markDisksForHealing(orderedDisks, newJBOD, freshDisks)
 }
 
 // loadFormatXL - loads XL `format.json` and returns back properly
-// ordered storage slice based on `format.json`.
-func loadFormatXL(bootstrapDisks []StorageAPI, readQuorum int) (disks []StorageAPI, err error) {
+// ordered storage slice based on `format.json`, along with the JBOD
+// positions of the disks of each erasure set.
+func loadFormatXL(bootstrapDisks []StorageAPI, readQuorum int) (disks []StorageAPI, setIndexes [][]int, err error) {
 	var unformattedDisksFoundCnt = 0
 	var diskNotFoundCount = 0
 	var corruptedDisksFoundCnt = 0
@@ -879,7 +951,7 @@ func loadFormatXL(bootstrapDisks []StorageAPI, readQuorum int) (disks []StorageA
 				corruptedDisksFoundCnt++
 				continue
 			}
-			return nil, err
+			return nil, nil, err
 		}
 		// Save valid formats.
 		formatConfigs[index] = formatXL
@@ -887,21 +959,31 @@ func loadFormatXL(bootstrapDisks []StorageAPI, readQuorum int) (disks []StorageA
 
 	// If all disks indicate that 'format.json' is not available return 'errUnformattedDisk'.
 	if unformattedDisksFoundCnt > len(bootstrapDisks)-readQuorum {
-		return nil, errUnformattedDisk
+		return nil, nil, errUnformattedDisk
 	} else if corruptedDisksFoundCnt > len(bootstrapDisks)-readQuorum {
-		return nil, errCorruptedFormat
+		return nil, nil, errCorruptedFormat
 	} else if diskNotFoundCount == len(bootstrapDisks) {
-		return nil, errDiskNotFound
+		return nil, nil, errDiskNotFound
 	} else if diskNotFoundCount > len(bootstrapDisks)-readQuorum {
-		return nil, errXLReadQuorum
+		return nil, nil, errXLReadQuorum
 	}
 
 	// Validate the format configs read are correct.
 	if err = checkFormatXL(formatConfigs); err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	for _, format := range formatConfigs {
+		if format != nil {
+			setIndexes = format.XL.setIndexes()
+			break
+		}
 	}
 	// Erasure code requires disks to be presented in the same order each time.
-	return reorderDisks(bootstrapDisks, formatConfigs)
+	disks, err = reorderDisks(bootstrapDisks, formatConfigs)
+	if err != nil {
+		return nil, nil, err
+	}
+	return disks, setIndexes, nil
 }
 
 func checkFormatXLValue(formatXL *formatConfigV1) error {
@@ -912,8 +994,42 @@ func checkFormatXLValue(formatXL *formatConfigV1) error {
 	if formatXL.Format != formatBackendXL {
 		return fmt.Errorf("Unsupported backend format [%s] found", formatXL.Format)
 	}
-	if formatXL.XL.Version != "1" {
-		return fmt.Errorf("Unsupported XL backend format found [%s]", formatXL.XL.Version)
+	switch formatXL.XL.Version {
+	case xlFormatBackendV1:
+		return nil
+	case xlFormatBackendV2:
+		return checkFormatXLSets(formatXL.XL)
+	}
+	return fmt.Errorf("Unsupported XL backend format found [%s]", formatXL.XL.Version)
+}
+
+// checkFormatXLSets - verifies that the erasure sets divide the JBOD
+// into sets of an equal number of disks.
+func checkFormatXLSets(formatXL *xlFormat) error {
+	if len(formatXL.Sets) == 0 {
+		return errors.New("Erasure sets not found in XL backend format")
+	}
+	setDriveCount := len(formatXL.Sets[0])
+	if setDriveCount == 0 {
+		return errors.New("Erasure sets without disks found in XL backend format")
+	}
+	setDisks := make(map[string]bool)
+	for _, set := range formatXL.Sets {
+		if len(set) != setDriveCount {
+			return errors.New("Erasure sets have a different number of disks")
+		}
+		for _, disk := range set {
+			setDisks[disk] = true
+		}
+	}
+	// Each disk of the JBOD is in exactly one set.
+	if len(setDisks) != len(formatXL.Sets)*setDriveCount || len(setDisks) != len(formatXL.JBOD) {
+		return errors.New("Erasure sets do not match the JBOD")
+	}
+	for _, disk := range formatXL.JBOD {
+		if !setDisks[disk] {
+			return errors.New("Erasure sets do not match the JBOD")
+		}
 	}
 	return nil
 }
@@ -997,13 +1113,31 @@ func saveFormatXL(storageDisks []StorageAPI, formats []*formatConfigV1) error {
 }
 
 // initFormatXL - save XL format configuration on all disks.
-func initFormatXL(storageDisks []StorageAPI) (err error) {
+func initFormatXL(endpoints EndpointList, storageDisks []StorageAPI) (err error) {
 	// Initialize jbods.
 	var jbod = make([]string, len(storageDisks))
+	for index, disk := range storageDisks {
+		if disk == nil {
+			continue
+		}
+		jbod[index] = mustGetUUID()
+	}
 
 	// Initialize formats.
 	var formats = make([]*formatConfigV1, len(storageDisks))
 
+	// Disks are divided into erasure sets spread across hosts, disks
+	// which can't be divided are kept in a single set.
+	setDriveCount := getSetDriveCount(len(storageDisks))
+	if setDriveCount == 0 {
+		setDriveCount = len(storageDisks)
+	}
+	hosts := make([]string, len(storageDisks))
+	for index, endpoint := range endpoints {
+		hosts[index] = endpoint.Host
+	}
+	setIndexes := getSetIndexes(hosts, setDriveCount)
+
 	// Initialize `format.json`.
 	for index, disk := range storageDisks {
 		if disk == nil {
@@ -1013,21 +1147,8 @@ func initFormatXL(storageDisks []StorageAPI) (err error) {
 		formats[index] = &formatConfigV1{
 			Version: formatFileV1,
 			Format:  formatBackendXL,
-			XL: &xlFormat{
-				Version: xlFormatBackendV1,
-				Disk:    mustGetUUID(),
-			},
-		}
-		jbod[index] = formats[index].XL.Disk
-	}
-
-	// Update the jbod entries.
-	for index, disk := range storageDisks {
-		if disk == nil {
-			continue
+			XL:      newXLFormat(xlFormatBackendV2, jbod[index], jbod, setIndexes),
 		}
-		// Save jbod.
-		formats[index].XL.JBOD = jbod
 	}
 
 	// Initialize meta volume, if volume already exists ignores it.
@@ -289,17 +289,37 @@ func TestFormatXLHealFreshDisks(t *testing.T) {
 		t.Fatal(err)
 	}
 	// Start healing disks
-	err = healFormatXLFreshDisks(storageDisks)
+	err = healFormatXLFreshDisks(nil, storageDisks)
 	if err != nil {
 		t.Fatal("healing corrupted disk failed: ", err)
 	}
 
 	// Load again XL format.json to validate it
-	_, err = loadFormatXL(storageDisks, 8)
+	_, _, err = loadFormatXL(storageDisks, 8)
 	if err != nil {
 		t.Fatal("loading healed disk failed: ", err)
 	}
 
+	// Only the fresh disks are marked for healing.
+	xl := obj.(*xlObjects)
+	for index, disk := range xl.storageDisks {
+		marker, err := loadHealingMarker(disk)
+		if index >= 3 && index <= 5 {
+			if err != nil {
+				t.Fatalf("Disk %d: expected a healing marker, got %v", index, err)
+			}
+			format, err := loadFormat(disk)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if marker.Disk != format.XL.Disk || marker.Started.IsZero() || marker.Token != "" {
+				t.Errorf("Disk %d: unexpected healing marker %+v", index, marker)
+			}
+		} else if err != errFileNotFound {
+			t.Errorf("Disk %d: expected no healing marker, got %v", index, err)
+		}
+	}
+
 	// Clean all
 	removeRoots(fsDirs)
 }
@@ -325,13 +345,13 @@ func TestFormatXLHealFreshDisksErrorExpected(t *testing.T) {
 	prepareNOfflineDisks(storageDisks, 16, t)
 
 	// Load again XL format.json to validate it
-	_, err = loadFormatXL(storageDisks, 8)
+	_, _, err = loadFormatXL(storageDisks, 8)
 	if err == nil {
 		t.Fatal("loading format disk error")
 	}
 
 	storageDisks[3] = nil
-	err = healFormatXLFreshDisks(storageDisks)
+	err = healFormatXLFreshDisks(nil, storageDisks)
 	if err != nil {
 		t.Fatal("didn't get nil when one disk is offline")
 	}
@@ -398,13 +418,13 @@ func TestFormatXLHealCorruptedDisks(t *testing.T) {
 		xl.storageDisks[5], xl.storageDisks[11]}
 
 	// Start healing disks
-	err = healFormatXLCorruptedDisks(permutedStorageDisks)
+	err = healFormatXLCorruptedDisks(nil, permutedStorageDisks)
 	if err != nil {
 		t.Fatal("healing corrupted disk failed: ", err)
 	}
 
 	// Load again XL format.json to validate it
-	_, err = loadFormatXL(permutedStorageDisks, 8)
+	_, _, err = loadFormatXL(permutedStorageDisks, 8)
 	if err != nil {
 		t.Fatal("loading healed disk failed: ", err)
 	}
@@ -607,7 +627,7 @@ func TestInitFormatXLErrors(t *testing.T) {
 		d := xl.storageDisks[i].(*retryStorage)
 		testStorageDisks[i] = &naughtyDisk{disk: d, defaultErr: errDiskNotFound}
 	}
-	if err := initFormatXL(testStorageDisks); err != errDiskNotFound {
+	if err := initFormatXL(nil, testStorageDisks); err != errDiskNotFound {
 		t.Fatal("Got a different error: ", err)
 	}
 
@@ -616,7 +636,7 @@ func TestInitFormatXLErrors(t *testing.T) {
 		d := xl.storageDisks[i].(*retryStorage)
 		testStorageDisks[i] = &naughtyDisk{disk: d, defaultErr: errDiskNotFound, errors: map[int]error{0: nil, 1: nil, 2: nil}}
 	}
-	if err := initFormatXL(testStorageDisks); err != errDiskNotFound {
+	if err := initFormatXL(nil, testStorageDisks); err != errDiskNotFound {
 		t.Fatal("Got a different error: ", err)
 	}
 
@@ -624,7 +644,7 @@ func TestInitFormatXLErrors(t *testing.T) {
 	for i := 0; i < 15; i++ {
 		testStorageDisks[i] = nil
 	}
-	if err := initFormatXL(testStorageDisks); err != errDiskNotFound {
+	if err := initFormatXL(nil, testStorageDisks); err != errDiskNotFound {
 		t.Fatal("Got a different error: ", err)
 	}
 }
@@ -867,7 +887,7 @@ func TestLoadFormatXLErrs(t *testing.T) {
 		t.Fatal("storage disk is not *retryStorage type")
 	}
 	xl.storageDisks[10] = newNaughtyDisk(posixDisk, nil, errFaultyDisk)
-	if _, err = loadFormatXL(xl.storageDisks, 8); err != errFaultyDisk {
+	if _, _, err = loadFormatXL(xl.storageDisks, 8); err != errFaultyDisk {
 		t.Fatal("Got an unexpected error: ", err)
 	}
 
@@ -893,7 +913,7 @@ func TestLoadFormatXLErrs(t *testing.T) {
 		}
 		xl.storageDisks[i] = newNaughtyDisk(posixDisk, nil, errDiskNotFound)
 	}
-	if _, err = loadFormatXL(xl.storageDisks, 8); err != errXLReadQuorum {
+	if _, _, err = loadFormatXL(xl.storageDisks, 8); err != errXLReadQuorum {
 		t.Fatal("Got an unexpected error: ", err)
 	}
 
@@ -915,7 +935,7 @@ func TestLoadFormatXLErrs(t *testing.T) {
 			t.Fatal(err)
 		}
 	}
-	if _, err = loadFormatXL(xl.storageDisks, 8); err != errUnformattedDisk {
+	if _, _, err = loadFormatXL(xl.storageDisks, 8); err != errUnformattedDisk {
 		t.Fatal("Got an unexpected error: ", err)
 	}
 
@@ -935,7 +955,7 @@ func TestLoadFormatXLErrs(t *testing.T) {
 	for i := 0; i < 16; i++ {
 		xl.storageDisks[i] = nil
 	}
-	if _, err := loadFormatXL(xl.storageDisks, 8); err != errDiskNotFound {
+	if _, _, err := loadFormatXL(xl.storageDisks, 8); err != errDiskNotFound {
 		t.Fatal("Got an unexpected error: ", err)
 	}
 }
@@ -960,7 +980,7 @@ func TestHealFormatXLCorruptedDisksErrs(t *testing.T) {
 		t.Fatal(err)
 	}
 	xl := obj.(*xlObjects)
-	if err = healFormatXLCorruptedDisks(xl.storageDisks); err != nil {
+	if err = healFormatXLCorruptedDisks(nil, xl.storageDisks); err != nil {
 		t.Fatal("Got an unexpected error: ", err)
 	}
 
@@ -980,7 +1000,7 @@ func TestHealFormatXLCorruptedDisksErrs(t *testing.T) {
 	for i := 0; i <= 15; i++ {
 		xl.storageDisks[i] = nil
 	}
-	if err = healFormatXLCorruptedDisks(xl.storageDisks); err != nil {
+	if err = healFormatXLCorruptedDisks(nil, xl.storageDisks); err != nil {
 		t.Fatal("Got an unexpected error: ", err)
 	}
 	removeRoots(fsDirs)
@@ -1001,7 +1021,7 @@ func TestHealFormatXLCorruptedDisksErrs(t *testing.T) {
 		t.Fatal("storage disk is not *retryStorage type")
 	}
 	xl.storageDisks[0] = newNaughtyDisk(posixDisk, nil, errFaultyDisk)
-	if err = healFormatXLCorruptedDisks(xl.storageDisks); err != errFaultyDisk {
+	if err = healFormatXLCorruptedDisks(nil, xl.storageDisks); err != errFaultyDisk {
 		t.Fatal("Got an unexpected error: ", err)
 	}
 	removeRoots(fsDirs)
@@ -1018,7 +1038,7 @@ func TestHealFormatXLCorruptedDisksErrs(t *testing.T) {
 	}
 	xl = obj.(*xlObjects)
 	xl.storageDisks[0] = nil
-	if err = healFormatXLCorruptedDisks(xl.storageDisks); err != nil {
+	if err = healFormatXLCorruptedDisks(nil, xl.storageDisks); err != nil {
 		t.Fatal("Got an unexpected error: ", err)
 	}
 	removeRoots(fsDirs)
@@ -1039,7 +1059,7 @@ func TestHealFormatXLCorruptedDisksErrs(t *testing.T) {
 			t.Fatal(err)
 		}
 	}
-	if err = healFormatXLCorruptedDisks(xl.storageDisks); err != nil {
+	if err = healFormatXLCorruptedDisks(nil, xl.storageDisks); err != nil {
 		t.Fatal("Got an unexpected error: ", err)
 	}
 	removeRoots(fsDirs)
@@ -1060,7 +1080,7 @@ func TestHealFormatXLCorruptedDisksErrs(t *testing.T) {
 			t.Fatal(err)
 		}
 	}
-	if err = healFormatXLCorruptedDisks(xl.storageDisks); err == nil {
+	if err = healFormatXLCorruptedDisks(nil, xl.storageDisks); err == nil {
 		t.Fatal("Should get a json parsing error, ")
 	}
 	removeRoots(fsDirs)
@@ -1086,7 +1106,7 @@ func TestHealFormatXLFreshDisksErrs(t *testing.T) {
 		t.Fatal(err)
 	}
 	xl := obj.(*xlObjects)
-	if err = healFormatXLFreshDisks(xl.storageDisks); err != nil {
+	if err = healFormatXLFreshDisks(nil, xl.storageDisks); err != nil {
 		t.Fatal("Got an unexpected error: ", err)
 	}
 	removeRoots(fsDirs)
@@ -1105,7 +1125,7 @@ func TestHealFormatXLFreshDisksErrs(t *testing.T) {
 	for i := 0; i <= 15; i++ {
 		xl.storageDisks[i] = nil
 	}
-	if err = healFormatXLFreshDisks(xl.storageDisks); err != nil {
+	if err = healFormatXLFreshDisks(nil, xl.storageDisks); err != nil {
 		t.Fatal("Got an unexpected error: ", err)
 	}
 	removeRoots(fsDirs)
@@ -1126,7 +1146,7 @@ func TestHealFormatXLFreshDisksErrs(t *testing.T) {
 		t.Fatal("storage disk is not *retryStorage type")
 	}
 	xl.storageDisks[0] = newNaughtyDisk(posixDisk, nil, errFaultyDisk)
-	if err = healFormatXLFreshDisks(xl.storageDisks); err != errFaultyDisk {
+	if err = healFormatXLFreshDisks(nil, xl.storageDisks); err != errFaultyDisk {
 		t.Fatal("Got an unexpected error: ", err)
 	}
 	removeRoots(fsDirs)
@@ -1143,7 +1163,7 @@ func TestHealFormatXLFreshDisksErrs(t *testing.T) {
 	}
 	xl = obj.(*xlObjects)
 	xl.storageDisks[0] = nil
-	if err = healFormatXLFreshDisks(xl.storageDisks); err != nil {
+	if err = healFormatXLFreshDisks(nil, xl.storageDisks); err != nil {
 		t.Fatal("Got an unexpected error: ", err)
 	}
 	removeRoots(fsDirs)
@@ -1164,7 +1184,7 @@ func TestHealFormatXLFreshDisksErrs(t *testing.T) {
 			t.Fatal(err)
 		}
 	}
-	if err = healFormatXLFreshDisks(xl.storageDisks); err != nil {
+	if err = healFormatXLFreshDisks(nil, xl.storageDisks); err != nil {
 		t.Fatal("Got an unexpected error: ", err)
 	}
 	removeRoots(fsDirs)
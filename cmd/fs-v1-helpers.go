@@ -230,6 +230,37 @@ func fsOpenFile(readPath string, offset int64) (io.ReadCloser, int64, error) {
 	return fr, st.Size(), nil
 }
 
+// Reads length bytes of the file at readPath starting at offset into
+// the writer, negative length reads till the end of the file.
+func fsReadFile(readPath string, offset int64, length int64, writer io.Writer) error {
+	reader, size, err := fsOpenFile(readPath, offset)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	bufSize := int64(readSizeV1)
+	if length > 0 && bufSize > length {
+		bufSize = length
+	}
+
+	// For negative length we read everything.
+	if length < 0 {
+		length = size - offset
+	}
+
+	// Reply back invalid range if the input offset and length fall out of range.
+	if offset > size || offset+length > size {
+		return traceError(InvalidRange{offset, length, size})
+	}
+
+	// Allocate a staging buffer.
+	buf := make([]byte, int(bufSize))
+
+	_, err = io.CopyBuffer(writer, io.LimitReader(reader, length), buf)
+	return traceError(err)
+}
+
 // Creates a file and copies data from incoming reader. Staging buffer is used by io.CopyBuffer.
 func fsCreateFile(filePath string, reader io.Reader, buf []byte, fallocSize int64) (int64, error) {
 	if filePath == "" || reader == nil {
@@ -61,6 +61,10 @@ type fsMetaV1 struct {
 	// Metadata map for current object `fs.json`.
 	Meta  map[string]string `json:"meta,omitempty"`
 	Parts []objectPartInfo  `json:"parts,omitempty"`
+	// Version ID of the object, empty for the `null` version.
+	VersionID string `json:"versionId,omitempty"`
+	// Set if this `fs.json` represents a delete marker.
+	DeleteMarker bool `json:"deleteMarker,omitempty"`
 }
 
 // IsValid - tells if the format is sane by validating the version
@@ -92,8 +96,11 @@ func (m fsMetaV1) ToObjectInfo(bucket, object string, fi os.FileInfo) ObjectInfo
 	}
 
 	objInfo := ObjectInfo{
-		Bucket: bucket,
-		Name:   object,
+		Bucket:       bucket,
+		Name:         object,
+		VersionID:    m.VersionID,
+		DeleteMarker: m.DeleteMarker,
+		Parts:        m.Parts,
 	}
 
 	// We set file info only if its valid.
@@ -184,6 +191,13 @@ func parseFSRelease(fsMetaBuf []byte) string {
 	return gjson.GetBytes(fsMetaBuf, "minio.release").String()
 }
 
+func parseFSVersionInfo(fsMetaBuf []byte) versionInfo {
+	return versionInfo{
+		VersionID:    gjson.GetBytes(fsMetaBuf, "versionId").Str,
+		DeleteMarker: gjson.GetBytes(fsMetaBuf, "deleteMarker").Bool(),
+	}
+}
+
 func parseFSMetaMap(fsMetaBuf []byte) map[string]string {
 	// Get xlMetaV1.Meta map.
 	metaMapResult := gjson.GetBytes(fsMetaBuf, "meta").Map()
@@ -225,29 +239,46 @@ func (m *fsMetaV1) ReadFrom(lk *lock.LockedFile) (n int64, err error) {
 		return 0, traceError(io.EOF)
 	}
 
+	fsMeta, err := fsMetaV1UnmarshalJSON(fsMetaBuf)
+	if err != nil {
+		return 0, traceError(err)
+	}
+	*m = fsMeta
+
+	// Success.
+	return int64(len(fsMetaBuf)), nil
+}
+
+// Constructs fsMetaV1 using `gjson` lib to retrieve each field.
+func fsMetaV1UnmarshalJSON(fsMetaBuf []byte) (fsMeta fsMetaV1, err error) {
 	// obtain version.
-	m.Version = parseFSVersion(fsMetaBuf)
+	fsMeta.Version = parseFSVersion(fsMetaBuf)
 
 	// obtain format.
-	m.Format = parseFSFormat(fsMetaBuf)
+	fsMeta.Format = parseFSFormat(fsMetaBuf)
 
 	// Verify if the format is valid, return corrupted format
 	// for unrecognized formats.
-	if !isFSMetaValid(m.Version, m.Format) {
-		return 0, traceError(errCorruptedFormat)
+	if !isFSMetaValid(fsMeta.Version, fsMeta.Format) {
+		return fsMetaV1{}, errCorruptedFormat
 	}
 
 	// obtain metadata.
-	m.Meta = parseFSMetaMap(fsMetaBuf)
+	fsMeta.Meta = parseFSMetaMap(fsMetaBuf)
 
 	// obtain parts info list.
-	m.Parts = parseFSParts(fsMetaBuf)
+	fsMeta.Parts = parseFSParts(fsMetaBuf)
 
 	// obtain minio release date.
-	m.Minio.Release = parseFSRelease(fsMetaBuf)
+	fsMeta.Minio.Release = parseFSRelease(fsMetaBuf)
+
+	// obtain version info.
+	vInfo := parseFSVersionInfo(fsMetaBuf)
+	fsMeta.VersionID = vInfo.VersionID
+	fsMeta.DeleteMarker = vInfo.DeleteMarker
 
 	// Success.
-	return int64(len(fsMetaBuf)), nil
+	return fsMeta, nil
 }
 
 // newFSMetaV1 - initializes new fsMetaV1.
@@ -659,6 +659,7 @@ func (fs fsObjects) listObjectParts(bucket, object, uploadID string, partNumberM
 	result.Object = object
 	result.UploadID = uploadID
 	result.MaxParts = maxParts
+	result.UserDefined = fsMeta.Meta
 
 	// Success.
 	return result, nil
@@ -793,6 +794,13 @@ func (fs fsObjects) CompleteMultipartUpload(bucket string, object string, upload
 
 	fsNSObjPath := pathJoin(fs.fsPath, bucket, object)
 
+	// Retain the existing object as a previous version, if versioning
+	// is configured on the bucket.
+	if _, err = fs.archiveObject(bucket, object, metaFile); err != nil {
+		fs.rwPool.Close(fsMetaPathMultipart)
+		return oi, err
+	}
+
 	// This lock is held during rename of the appended tmp file to the actual
 	// location so that any competing GetObject/PutObject/DeleteObject do not race.
 	appendFallback := true // In case background-append did not append the required parts.
@@ -866,8 +874,13 @@ func (fs fsObjects) CompleteMultipartUpload(bucket string, object string, upload
 		}
 	}
 
-	// No need to save part info, since we have concatenated all parts.
-	fsMeta.Parts = nil
+	// Save the info of the completed parts only, since we have
+	// concatenated them. Encrypted objects are decrypted part by part.
+	completedParts := make([]objectPartInfo, len(parts))
+	for i, part := range parts {
+		completedParts[i] = fsMeta.Parts[fsMeta.ObjectPartIndex(part.PartNumber)]
+	}
+	fsMeta.Parts = completedParts
 
 	// Save additional metadata.
 	if len(fsMeta.Meta) == 0 {
@@ -875,6 +888,9 @@ func (fs fsObjects) CompleteMultipartUpload(bucket string, object string, upload
 	}
 	fsMeta.Meta["etag"] = s3MD5
 
+	// Version ID of the new object.
+	fsMeta.VersionID = newObjectVersionID(bucket)
+
 	// Write all the set metadata.
 	if _, err = fsMeta.WriteTo(metaFile); err != nil {
 		fs.rwPool.Close(fsMetaPathMultipart)
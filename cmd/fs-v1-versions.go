@@ -0,0 +1,523 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	pathutil "path"
+	"strings"
+
+	"github.com/minio/minio/pkg/lock"
+)
+
+// Name of the file holding the data of a previous object version,
+// alongside its `fs.json`.
+const fsVersionDataFile = "data"
+
+// fsVersionPath - returns the directory of a previous object version
+// inside `.minio.sys/versions`.
+func (fs fsObjects) fsVersionPath(bucket, object, versionID string) string {
+	return pathJoin(fs.fsPath, minioMetaVersionsBucket, bucket, object, versionsDirName, toVersionID(versionID))
+}
+
+// readCurrentMeta - reads `fs.json` of the current object, wlk is used
+// if the caller already holds a lock on `fs.json`.
+func (fs fsObjects) readCurrentMeta(bucket, object string, wlk *lock.LockedFile) (fsMeta fsMetaV1, err error) {
+	if wlk != nil {
+		_, err = fsMeta.ReadFrom(wlk)
+	} else {
+		fsMetaPath := pathJoin(fs.fsPath, minioMetaBucket, bucketMetaPrefix, bucket, object, fsMetaJSONFile)
+		var rlk *lock.RLockedFile
+		rlk, err = fs.rwPool.Open(fsMetaPath)
+		if err == nil {
+			defer fs.rwPool.Close(fsMetaPath)
+			_, err = fsMeta.ReadFrom(rlk.LockedFile)
+		} else if err != errFileNotFound {
+			return fsMeta, toObjectErr(traceError(err), bucket, object)
+		}
+	}
+
+	// `fs.json` can be empty due to previously failed PutObject()
+	// transaction or missing for pre-existing data, ignore both.
+	if err != nil && err != errFileNotFound && errorCause(err) != io.EOF {
+		return fsMeta, toObjectErr(err, bucket, object)
+	}
+	if !fsMeta.IsValid() {
+		fsMeta = newFSMetaV1()
+	}
+	return fsMeta, nil
+}
+
+// writeVersionMeta - writes `fs.json` of a previous object version.
+func writeVersionMeta(versionPath string, fsMeta fsMetaV1) error {
+	metadataBytes, err := json.Marshal(fsMeta)
+	if err != nil {
+		return traceError(err)
+	}
+	_, err = fsCreateFile(pathJoin(versionPath, fsMetaJSONFile), bytes.NewReader(metadataBytes), nil, 0)
+	return err
+}
+
+// readVersionMeta - reads `fs.json` of a previous object version.
+func readVersionMeta(versionPath string) (fsMetaV1, error) {
+	fsMetaBuf, err := ioutil.ReadFile(preparePath(pathJoin(versionPath, fsMetaJSONFile)))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fsMetaV1{}, traceError(errFileNotFound)
+		}
+		return fsMetaV1{}, traceError(err)
+	}
+	fsMeta, err := fsMetaV1UnmarshalJSON(fsMetaBuf)
+	if err != nil {
+		return fsMetaV1{}, traceError(err)
+	}
+	return fsMeta, nil
+}
+
+// archiveObject - moves the current object into the versions namespace,
+// if versioning is configured on the bucket. Returns false if the object
+// was not archived and should be overwritten as usual, this is the case
+// for unversioned buckets and `null` versions in suspended buckets.
+func (fs fsObjects) archiveObject(bucket, object string, wlk *lock.LockedFile) (bool, error) {
+	status := getBucketVersioningStatus(bucket)
+	if status == "" {
+		return false, nil
+	}
+
+	fsObjPath := pathJoin(fs.fsPath, bucket, object)
+	if _, err := fsStatFile(fsObjPath); err != nil {
+		if errorCause(err) == errFileNotFound {
+			return false, nil
+		}
+		return false, toObjectErr(err, bucket, object)
+	}
+
+	curMeta, err := fs.readCurrentMeta(bucket, object, wlk)
+	if err != nil {
+		return false, err
+	}
+
+	versionPath := fs.fsVersionPath(bucket, object, curMeta.VersionID)
+	if curMeta.VersionID == "" {
+		if status == versioningSuspended {
+			// The current `null` version is about to be overwritten.
+			if err = checkObjectLockRemoval(ObjectInfo{Bucket: bucket, Name: object, UserDefined: curMeta.Meta}, false); err != nil {
+				return false, err
+			}
+		}
+		// Only one `null` version is retained, purge the previous one.
+		if err = fs.purgeNullVersion(bucket, object); err != nil {
+			return false, err
+		}
+		if status == versioningSuspended {
+			return false, nil
+		}
+	}
+
+	if err = writeVersionMeta(versionPath, curMeta); err != nil {
+		return false, toObjectErr(err, bucket, object)
+	}
+	if err = fsRenameFile(fsObjPath, pathJoin(versionPath, fsVersionDataFile)); err != nil {
+		fsRemoveAll(versionPath)
+		return false, toObjectErr(err, bucket, object)
+	}
+
+	// Cleanup any empty parent directories of the archived object.
+	fsDeleteFile(pathJoin(fs.fsPath, bucket), pathutil.Dir(fsObjPath))
+	return true, nil
+}
+
+// purgeNullVersion - removes the previous `null` version of an object,
+// unless it is protected by object lock.
+func (fs fsObjects) purgeNullVersion(bucket, object string) error {
+	if objInfo, err := fs.getArchivedVersion(bucket, object, ""); err == nil {
+		if err = checkObjectLockRemoval(objInfo, false); err != nil {
+			return err
+		}
+	}
+	if err := fsRemoveAll(fs.fsVersionPath(bucket, object, "")); err != nil {
+		return toObjectErr(err, bucket, object)
+	}
+	return nil
+}
+
+// writeDeleteMarker - writes a new delete marker as the latest version
+// of an object.
+func (fs fsObjects) writeDeleteMarker(bucket, object string) (ObjectInfo, error) {
+	versionID := newObjectVersionID(bucket)
+	versionPath := fs.fsVersionPath(bucket, object, versionID)
+	if versionID == "" {
+		// Only one `null` version is retained, purge the previous one.
+		if err := fs.purgeNullVersion(bucket, object); err != nil {
+			return ObjectInfo{}, err
+		}
+	}
+
+	fsMeta := newFSMetaV1()
+	fsMeta.VersionID = versionID
+	fsMeta.DeleteMarker = true
+	if err := writeVersionMeta(versionPath, fsMeta); err != nil {
+		return ObjectInfo{}, toObjectErr(err, bucket, object)
+	}
+
+	fi, err := fsStatFile(pathJoin(versionPath, fsMetaJSONFile))
+	if err != nil {
+		return ObjectInfo{}, toObjectErr(err, bucket, object)
+	}
+
+	objInfo := fsMeta.ToObjectInfo(bucket, object, fi)
+	objInfo.Size = 0
+	objInfo.IsLatest = true
+	return objInfo, nil
+}
+
+// getArchivedVersion - returns a previous version of an object.
+func (fs fsObjects) getArchivedVersion(bucket, object, versionID string) (ObjectInfo, error) {
+	versionPath := fs.fsVersionPath(bucket, object, versionID)
+	fsMeta, err := readVersionMeta(versionPath)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+
+	if fsMeta.DeleteMarker {
+		fi, err := fsStatFile(pathJoin(versionPath, fsMetaJSONFile))
+		if err != nil {
+			return ObjectInfo{}, err
+		}
+		objInfo := fsMeta.ToObjectInfo(bucket, object, fi)
+		objInfo.Size = 0
+		return objInfo, nil
+	}
+
+	fi, err := fsStatFile(pathJoin(versionPath, fsVersionDataFile))
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	return fsMeta.ToObjectInfo(bucket, object, fi), nil
+}
+
+// hasArchivedVersions - isLeaf function for listing the versions
+// namespace, an entry is a leaf if it holds object versions.
+func (fs fsObjects) hasArchivedVersions(volume, entry string) bool {
+	entries, err := readDir(pathJoin(fs.fsPath, volume, entry))
+	if err != nil {
+		return false
+	}
+	for _, e := range entries {
+		if e == versionsDirName+slashSeparator {
+			return true
+		}
+	}
+	return false
+}
+
+// listArchivedVersions - returns all the previous versions of an object.
+func (fs fsObjects) listArchivedVersions(bucket, object string) ([]ObjectInfo, error) {
+	entries, err := readDir(pathJoin(fs.fsPath, minioMetaVersionsBucket, bucket, object, versionsDirName))
+	if err != nil {
+		if err == errFileNotFound {
+			return nil, nil
+		}
+		return nil, traceError(err)
+	}
+
+	var versions []ObjectInfo
+	for _, entry := range entries {
+		objInfo, err := fs.getArchivedVersion(bucket, object, strings.TrimSuffix(entry, slashSeparator))
+		if err != nil {
+			// Version might have been removed in the meantime.
+			if errorCause(err) == errFileNotFound {
+				continue
+			}
+			return nil, err
+		}
+		versions = append(versions, objInfo)
+	}
+	return versions, nil
+}
+
+// listVersionedObjects - lists names of objects having previous versions.
+func (fs fsObjects) listVersionedObjects(bucket, prefix, marker string, maxKeys int) (names []string, isTruncated bool, err error) {
+	endWalkCh := make(chan struct{})
+	defer close(endWalkCh)
+
+	walkMarker := ""
+	if marker != "" {
+		walkMarker = bucket + slashSeparator + marker
+	}
+	isLeaf := fs.hasArchivedVersions
+	listDir := fs.listDirFactory(isLeaf)
+	walkResultCh := startTreeWalk(minioMetaVersionsBucket, bucket+slashSeparator+prefix, walkMarker, true, listDir, isLeaf, endWalkCh)
+
+	for len(names) < maxKeys {
+		walkResult, ok := <-walkResultCh
+		if !ok {
+			return names, false, nil
+		}
+		if walkResult.err != nil {
+			// File not found is a valid case, no versions exist.
+			if errorCause(walkResult.err) == errFileNotFound {
+				return names, false, nil
+			}
+			return nil, false, toObjectErr(walkResult.err, bucket, prefix)
+		}
+		names = append(names, strings.TrimPrefix(walkResult.entry, bucket+slashSeparator))
+		if walkResult.end {
+			return names, false, nil
+		}
+	}
+	return names, true, nil
+}
+
+// removeArchivedVersion - removes a previous version of an object,
+// including any empty parent directories.
+func (fs fsObjects) removeArchivedVersion(bucket, object, versionID string) error {
+	versionPath := fs.fsVersionPath(bucket, object, versionID)
+	if err := fsRemoveAll(versionPath); err != nil {
+		return err
+	}
+	fsDeleteFile(pathJoin(fs.fsPath, minioMetaVersionsBucket), pathutil.Dir(versionPath))
+	return nil
+}
+
+// promoteLatestVersion - makes the newest previous version the current
+// object, unless it is a delete marker.
+func (fs fsObjects) promoteLatestVersion(bucket, object string) error {
+	versions, hasCurrent, err := getObjectVersions(fs, bucket, object)
+	if err != nil {
+		return err
+	}
+	if hasCurrent || len(versions) == 0 || versions[0].DeleteMarker {
+		return nil
+	}
+
+	versionPath := fs.fsVersionPath(bucket, object, versions[0].VersionID)
+	fsMeta, err := readVersionMeta(versionPath)
+	if err != nil {
+		return toObjectErr(err, bucket, object)
+	}
+
+	fsMetaPath := pathJoin(fs.fsPath, minioMetaBucket, bucketMetaPrefix, bucket, object, fsMetaJSONFile)
+	wlk, err := fs.rwPool.Create(fsMetaPath)
+	if err != nil {
+		return toObjectErr(traceError(err), bucket, object)
+	}
+	defer wlk.Close()
+
+	if err = fsRenameFile(pathJoin(versionPath, fsVersionDataFile), pathJoin(fs.fsPath, bucket, object)); err != nil {
+		return toObjectErr(err, bucket, object)
+	}
+	if _, err = fsMeta.WriteTo(wlk); err != nil {
+		return toObjectErr(err, bucket, object)
+	}
+	return toObjectErr(fs.removeArchivedVersion(bucket, object, versions[0].VersionID), bucket, object)
+}
+
+// GetObjectVersion - reads a specific version of an object.
+func (fs fsObjects) GetObjectVersion(bucket, object, versionID string, offset int64, length int64, writer io.Writer) error {
+	if err := checkGetObjArgs(bucket, object); err != nil {
+		return err
+	}
+
+	if _, err := fs.statBucketDir(bucket); err != nil {
+		return toObjectErr(err, bucket)
+	}
+
+	objInfo, index, hasCurrent, err := getObjectVersion(fs, bucket, object, versionID)
+	if err != nil {
+		return err
+	}
+	if objInfo.DeleteMarker {
+		return traceError(MethodNotAllowed{Bucket: bucket, Object: object})
+	}
+	if index == 0 && hasCurrent {
+		return fs.GetObject(bucket, object, offset, length, writer)
+	}
+
+	// Offset cannot be negative.
+	if offset < 0 {
+		return toObjectErr(traceError(errUnexpected), bucket, object)
+	}
+
+	versionPath := fs.fsVersionPath(bucket, object, objInfo.VersionID)
+	return toObjectErr(fsReadFile(pathJoin(versionPath, fsVersionDataFile), offset, length, writer), bucket, object)
+}
+
+// GetObjectVersionInfo - reads metadata of a specific version of an object.
+func (fs fsObjects) GetObjectVersionInfo(bucket, object, versionID string) (ObjectInfo, error) {
+	if err := checkGetObjArgs(bucket, object); err != nil {
+		return ObjectInfo{}, err
+	}
+
+	if _, err := fs.statBucketDir(bucket); err != nil {
+		return ObjectInfo{}, toObjectErr(err, bucket)
+	}
+
+	objInfo, _, _, err := getObjectVersion(fs, bucket, object, versionID)
+	return objInfo, err
+}
+
+// DeleteObjectVersion - deletes a specific version of an object, an
+// empty versionID deletes the current object. In versioned buckets
+// the current object is retained as a previous version and a delete
+// marker is added instead. Versions protected by object lock are not
+// deleted, unless their governance mode retention is bypassed.
+func (fs fsObjects) DeleteObjectVersion(bucket, object, versionID string, bypassGovernance bool) (ObjectInfo, error) {
+	if err := checkDelObjArgs(bucket, object); err != nil {
+		return ObjectInfo{}, err
+	}
+
+	if _, err := fs.statBucketDir(bucket); err != nil {
+		return ObjectInfo{}, toObjectErr(err, bucket)
+	}
+
+	if versionID == "" {
+		if bucket == minioMetaBucket || getBucketVersioningStatus(bucket) == "" {
+			return ObjectInfo{Bucket: bucket, Name: object}, fs.deleteCurrentObject(bucket, object)
+		}
+
+		archived, err := fs.archiveObject(bucket, object, nil)
+		if err != nil {
+			return ObjectInfo{}, err
+		}
+		if archived {
+			// Data was moved, remove the metadata of the current object.
+			fsMetaPath := pathJoin(fs.fsPath, minioMetaBucket, bucketMetaPrefix, bucket, object, fsMetaJSONFile)
+			err = fsDeleteFile(pathJoin(fs.fsPath, minioMetaBucket), fsMetaPath)
+			if err != nil && errorCause(err) != errFileNotFound {
+				return ObjectInfo{}, toObjectErr(err, bucket, object)
+			}
+		} else if _, err = fsStatFile(pathJoin(fs.fsPath, bucket, object)); err == nil {
+			if err = fs.deleteCurrentObject(bucket, object); err != nil {
+				return ObjectInfo{}, err
+			}
+		}
+		return fs.writeDeleteMarker(bucket, object)
+	}
+
+	objInfo, index, hasCurrent, err := getObjectVersion(fs, bucket, object, versionID)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	if err = checkObjectLockRemoval(objInfo, bypassGovernance); err != nil {
+		return ObjectInfo{}, err
+	}
+
+	if index == 0 && hasCurrent {
+		err = fs.deleteCurrentObject(bucket, object)
+	} else {
+		err = toObjectErr(fs.removeArchivedVersion(bucket, object, objInfo.VersionID), bucket, object)
+	}
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+
+	// Latest version was removed, previous version becomes the latest.
+	if index == 0 {
+		if err = fs.promoteLatestVersion(bucket, object); err != nil {
+			return ObjectInfo{}, err
+		}
+	}
+
+	return objInfo, nil
+}
+
+// PutObjectLock - replaces the object lock settings of a specific
+// version of an object. Retention in effect can not be weakened, unless
+// its governance mode retention is bypassed.
+func (fs fsObjects) PutObjectLock(bucket, object, versionID string, lock objectLock, bypassGovernance bool) (ObjectInfo, error) {
+	if err := checkGetObjArgs(bucket, object); err != nil {
+		return ObjectInfo{}, err
+	}
+
+	if _, err := fs.statBucketDir(bucket); err != nil {
+		return ObjectInfo{}, toObjectErr(err, bucket)
+	}
+
+	objInfo, index, hasCurrent, err := getObjectVersion(fs, bucket, object, versionID)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	if objInfo.DeleteMarker {
+		return ObjectInfo{}, traceError(MethodNotAllowed{Bucket: bucket, Object: object})
+	}
+	if err = checkObjectLockUpdate(objInfo, lock, bypassGovernance); err != nil {
+		return ObjectInfo{}, err
+	}
+
+	metadata := make(map[string]string, len(objInfo.UserDefined)+3)
+	for k, v := range objInfo.UserDefined {
+		metadata[k] = v
+	}
+	setObjectLock(metadata, lock)
+
+	if index == 0 && hasCurrent {
+		fsMetaPath := pathJoin(fs.fsPath, minioMetaBucket, bucketMetaPrefix, bucket, object, fsMetaJSONFile)
+		wlk, err := fs.rwPool.Write(fsMetaPath)
+		if err != nil {
+			return ObjectInfo{}, toObjectErr(traceError(err), bucket, object)
+		}
+		// This close will allow for locks to be synchronized on `fs.json`.
+		defer wlk.Close()
+
+		fsMeta, err := fs.readCurrentMeta(bucket, object, wlk)
+		if err != nil {
+			return ObjectInfo{}, err
+		}
+		fsMeta.Meta = metadata
+		if _, err = fsMeta.WriteTo(wlk); err != nil {
+			return ObjectInfo{}, toObjectErr(err, bucket, object)
+		}
+	} else {
+		versionPath := fs.fsVersionPath(bucket, object, objInfo.VersionID)
+		fsMeta, err := readVersionMeta(versionPath)
+		if err != nil {
+			return ObjectInfo{}, toObjectErr(err, bucket, object)
+		}
+		fsMeta.Meta = metadata
+
+		// `fs.json` of the version is replaced atomically.
+		tmpPath := pathJoin(fs.fsPath, minioMetaTmpBucket, fs.fsUUID, mustGetUUID())
+		defer fsRemoveAll(tmpPath)
+		if err = writeVersionMeta(tmpPath, fsMeta); err != nil {
+			return ObjectInfo{}, toObjectErr(err, bucket, object)
+		}
+		if err = fsRenameFile(pathJoin(tmpPath, fsMetaJSONFile), pathJoin(versionPath, fsMetaJSONFile)); err != nil {
+			return ObjectInfo{}, toObjectErr(err, bucket, object)
+		}
+	}
+
+	objInfo.UserDefined = metadata
+	return objInfo, nil
+}
+
+// ListObjectVersions - lists all versions of the objects at prefix.
+func (fs fsObjects) ListObjectVersions(bucket, prefix, keyMarker, versionIDMarker, delimiter string, maxKeys int) (ListObjectVersionsInfo, error) {
+	if err := checkListObjsArgs(bucket, prefix, keyMarker, delimiter, fs); err != nil {
+		return ListObjectVersionsInfo{}, err
+	}
+
+	if _, err := fs.statBucketDir(bucket); err != nil {
+		return ListObjectVersionsInfo{}, err
+	}
+
+	return listObjectVersions(fs, bucket, prefix, keyMarker, versionIDMarker, delimiter, maxKeys)
+}
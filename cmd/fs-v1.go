@@ -72,7 +72,12 @@ func initMetaVolumeFS(fsPath, fsUUID string) error {
 	}
 
 	metaMultipartPath := pathJoin(fsPath, minioMetaMultipartBucket)
-	return mkdirAll(metaMultipartPath, 0777)
+	if err := mkdirAll(metaMultipartPath, 0777); err != nil {
+		return err
+	}
+
+	metaVersionsPath := pathJoin(fsPath, minioMetaVersionsBucket)
+	return mkdirAll(metaVersionsPath, 0777)
 
 }
 
@@ -152,6 +157,51 @@ func newFSObjectLayer(fsPath string) (ObjectLayer, error) {
 		return nil, fmt.Errorf("Unable to load all bucket policies. %s", err)
 	}
 
+	// Initialize and load bucket versioning configs.
+	if err = initBucketVersioning(fs); err != nil {
+		return nil, fmt.Errorf("Unable to load all bucket versioning configs. %s", err)
+	}
+
+	// Initialize and load bucket object lock configs.
+	if err = initBucketObjectLock(fs); err != nil {
+		return nil, fmt.Errorf("Unable to load all bucket object lock configs. %s", err)
+	}
+
+	// Initialize and load bucket replication configs.
+	if err = initBucketReplication(fs); err != nil {
+		return nil, fmt.Errorf("Unable to load all bucket replication configs. %s", err)
+	}
+
+	// Initialize and load bucket logging configs.
+	if err = initBucketLogging(fs); err != nil {
+		return nil, fmt.Errorf("Unable to load all bucket logging configs. %s", err)
+	}
+
+	// Initialize and load bucket quota configs.
+	if err = initBucketQuota(fs); err != nil {
+		return nil, fmt.Errorf("Unable to load all bucket quota configs. %s", err)
+	}
+
+	// Initialize and load bucket encryption configs.
+	if err = initBucketEncryption(fs); err != nil {
+		return nil, fmt.Errorf("Unable to load all bucket encryption configs. %s", err)
+	}
+
+	// Initialize and load bucket CORS configs.
+	if err = initBucketCors(fs); err != nil {
+		return nil, fmt.Errorf("Unable to load all bucket CORS configs. %s", err)
+	}
+
+	// Initialize and load bucket website configs.
+	if err = initBucketWebsite(fs); err != nil {
+		return nil, fmt.Errorf("Unable to load all bucket website configs. %s", err)
+	}
+
+	// Initialize and load IAM users, groups and policies.
+	if err = initIAMSys(fs); err != nil {
+		return nil, fmt.Errorf("Unable to load IAM config. %s", err)
+	}
+
 	// Initialize a new event notifier.
 	if err = initEventNotifier(fs); err != nil {
 		return nil, fmt.Errorf("Unable to initialize event notification. %s", err)
@@ -287,6 +337,18 @@ func (fs fsObjects) DeleteBucket(bucket string) error {
 		return toObjectErr(err, bucket)
 	}
 
+	// Previous versions in buckets with object lock may be protected,
+	// such buckets can only be removed once all versions are removed.
+	if isBucketObjectLockEnabled(bucket) {
+		names, _, err := fs.listVersionedObjects(bucket, "", "", 1)
+		if err != nil {
+			return err
+		}
+		if len(names) > 0 {
+			return traceError(BucketNotEmpty{Bucket: bucket})
+		}
+	}
+
 	// Attempt to delete regular bucket.
 	if err = fsRemoveDir(bucketDir); err != nil {
 		return toObjectErr(err, bucket)
@@ -304,6 +366,12 @@ func (fs fsObjects) DeleteBucket(bucket string) error {
 		return toObjectErr(err, bucket)
 	}
 
+	// Cleanup all the previous object versions.
+	minioMetaVersionsBucketDir := pathJoin(fs.fsPath, minioMetaVersionsBucket, bucket)
+	if err = fsRemoveAll(minioMetaVersionsBucketDir); err != nil {
+		return toObjectErr(err, bucket)
+	}
+
 	return nil
 }
 
@@ -335,9 +403,20 @@ func (fs fsObjects) CopyObject(srcBucket, srcObject, dstBucket, dstObject string
 		// This close will allow for locks to be synchronized on `fs.json`.
 		defer wlk.Close()
 
+		// Retain the version ID and the parts of the object.
+		var curMeta fsMetaV1
+		if _, rerr := curMeta.ReadFrom(wlk); rerr != nil {
+			curMeta = fsMetaV1{}
+		}
+
+		// Object lock settings can only be changed with PutObjectLock.
+		setObjectLock(metadata, getObjectLock(curMeta.Meta))
+
 		// Save objects' metadata in `fs.json`.
 		fsMeta := newFSMetaV1()
 		fsMeta.Meta = metadata
+		fsMeta.VersionID = curMeta.VersionID
+		fsMeta.Parts = curMeta.Parts
 		if _, err = fsMeta.WriteTo(wlk); err != nil {
 			return oi, toObjectErr(err, srcBucket, srcObject)
 		}
@@ -409,33 +488,7 @@ func (fs fsObjects) GetObject(bucket, object string, offset int64, length int64,
 
 	// Read the object, doesn't exist returns an s3 compatible error.
 	fsObjPath := pathJoin(fs.fsPath, bucket, object)
-	reader, size, err := fsOpenFile(fsObjPath, offset)
-	if err != nil {
-		return toObjectErr(err, bucket, object)
-	}
-	defer reader.Close()
-
-	bufSize := int64(readSizeV1)
-	if length > 0 && bufSize > length {
-		bufSize = length
-	}
-
-	// For negative length we read everything.
-	if length < 0 {
-		length = size - offset
-	}
-
-	// Reply back invalid range if the input offset and length fall out of range.
-	if offset > size || offset+length > size {
-		return traceError(InvalidRange{offset, length, size})
-	}
-
-	// Allocate a staging buffer.
-	buf := make([]byte, int(bufSize))
-
-	_, err = io.CopyBuffer(writer, io.LimitReader(reader, length), buf)
-
-	return toObjectErr(traceError(err), bucket, object)
+	return toObjectErr(fsReadFile(fsObjPath, offset, length, writer), bucket, object)
 }
 
 // getObjectInfo - wrapper for reading object metadata and constructs ObjectInfo.
@@ -640,6 +693,15 @@ func (fs fsObjects) PutObject(bucket string, object string, size int64, data io.
 		}
 	}
 
+	if bucket != minioMetaBucket {
+		// Retain the existing object as a previous version, if versioning
+		// is configured on the bucket.
+		if _, err = fs.archiveObject(bucket, object, wlk); err != nil {
+			return ObjectInfo{}, err
+		}
+		fsMeta.VersionID = newObjectVersionID(bucket)
+	}
+
 	// Entire object was written to the temp location, now it's safe to rename it to the actual location.
 	fsNSObjPath := pathJoin(fs.fsPath, bucket, object)
 	if err = fsRenameFile(fsTmpObjPath, fsNSObjPath); err != nil {
@@ -664,16 +726,16 @@ func (fs fsObjects) PutObject(bucket string, object string, size int64, data io.
 }
 
 // DeleteObject - deletes an object from a bucket, this operation is destructive
-// and there are no rollbacks supported.
+// and there are no rollbacks supported unless versioning is configured on
+// the bucket.
 func (fs fsObjects) DeleteObject(bucket, object string) error {
-	if err := checkDelObjArgs(bucket, object); err != nil {
-		return err
-	}
-
-	if _, err := fs.statBucketDir(bucket); err != nil {
-		return toObjectErr(err, bucket)
-	}
+	_, err := fs.DeleteObjectVersion(bucket, object, "", false)
+	return err
+}
 
+// deleteCurrentObject - deletes the current object of a bucket, previous
+// versions of the object are not affected.
+func (fs fsObjects) deleteCurrentObject(bucket, object string) error {
 	minioMetaBucketDir := pathJoin(fs.fsPath, minioMetaBucket)
 	fsMetaPath := pathJoin(minioMetaBucketDir, bucketMetaPrefix, bucket, object, fsMetaJSONFile)
 	if bucket != minioMetaBucket {
@@ -910,6 +972,11 @@ func (fs fsObjects) HealObject(bucket, object string) (int, int, error) {
 	return 0, 0, traceError(NotImplemented{})
 }
 
+// HealObjectDisks - no-op for fs. Valid only for XL.
+func (fs fsObjects) HealObjectDisks(bucket, object string) (HealObjectResult, error) {
+	return HealObjectResult{}, traceError(NotImplemented{})
+}
+
 // HealBucket - no-op for fs, Valid only for XL.
 func (fs fsObjects) HealBucket(bucket string) error {
 	return traceError(NotImplemented{})
@@ -16,6 +16,8 @@
 
 package cmd
 
+import "io"
+
 // HealBucket - Not relevant.
 func (a *azureObjects) HealBucket(bucket string) error {
 	return traceError(NotImplemented{})
@@ -31,6 +33,11 @@ func (a *azureObjects) HealObject(bucket, object string) (int, int, error) {
 	return 0, 0, traceError(NotImplemented{})
 }
 
+// HealObjectDisks - Not relevant.
+func (a *azureObjects) HealObjectDisks(bucket, object string) (HealObjectResult, error) {
+	return HealObjectResult{}, traceError(NotImplemented{})
+}
+
 // ListObjectsHeal - Not relevant.
 func (a *azureObjects) ListObjectsHeal(bucket, prefix, marker, delimiter string, maxKeys int) (loi ListObjectsInfo, e error) {
 	return loi, traceError(NotImplemented{})
@@ -41,3 +48,28 @@ func (a *azureObjects) ListUploadsHeal(bucket, prefix, marker, uploadIDMarker,
 	delimiter string, maxUploads int) (lmi ListMultipartsInfo, e error) {
 	return lmi, traceError(NotImplemented{})
 }
+
+// GetObjectVersion - Not relevant.
+func (a *azureObjects) GetObjectVersion(bucket, object, versionID string, startOffset int64, length int64, writer io.Writer) error {
+	return traceError(NotImplemented{})
+}
+
+// GetObjectVersionInfo - Not relevant.
+func (a *azureObjects) GetObjectVersionInfo(bucket, object, versionID string) (objInfo ObjectInfo, err error) {
+	return objInfo, traceError(NotImplemented{})
+}
+
+// DeleteObjectVersion - Not relevant.
+func (a *azureObjects) DeleteObjectVersion(bucket, object, versionID string, bypassGovernance bool) (objInfo ObjectInfo, err error) {
+	return objInfo, traceError(NotImplemented{})
+}
+
+// ListObjectVersions - Not relevant.
+func (a *azureObjects) ListObjectVersions(bucket, prefix, keyMarker, versionIDMarker, delimiter string, maxKeys int) (result ListObjectVersionsInfo, err error) {
+	return result, traceError(NotImplemented{})
+}
+
+// PutObjectLock - Not relevant.
+func (a *azureObjects) PutObjectLock(bucket, object, versionID string, lock objectLock, bypassGovernance bool) (objInfo ObjectInfo, err error) {
+	return objInfo, traceError(NotImplemented{})
+}
@@ -0,0 +1,122 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Prefix of the user metadata keys holding reserved metadata on
+// gateway backends.
+const gatewayReservedMetadataPrefix = "X-Amz-Meta-" + ReservedMetadataPrefix
+
+// gatewayEncryptionLayer - wraps a gateway backend to save the reserved
+// metadata of encrypted objects as user metadata, which is the only
+// metadata gateway backends persist.
+type gatewayEncryptionLayer struct {
+	GatewayLayer
+}
+
+// newGatewayEncryptionLayer - returns the gateway backend wrapped to
+// support encrypted objects.
+func newGatewayEncryptionLayer(gw GatewayLayer) GatewayLayer {
+	return &gatewayEncryptionLayer{gw}
+}
+
+// toGatewayMetadata - converts reserved metadata into user metadata,
+// user metadata clashing with converted reserved metadata is dropped.
+func toGatewayMetadata(metadata map[string]string) map[string]string {
+	if metadata == nil {
+		return nil
+	}
+	gwMetadata := make(map[string]string, len(metadata))
+	for k, v := range metadata {
+		switch {
+		case strings.HasPrefix(http.CanonicalHeaderKey(k), gatewayReservedMetadataPrefix):
+			continue
+		case isReservedMetadata(k):
+			gwMetadata["X-Amz-Meta-"+k] = v
+		default:
+			gwMetadata[k] = v
+		}
+	}
+	return gwMetadata
+}
+
+// fromGatewayMetadata - converts user metadata saved by toGatewayMetadata
+// back into reserved metadata.
+func fromGatewayMetadata(gwMetadata map[string]string) map[string]string {
+	if gwMetadata == nil {
+		return nil
+	}
+	metadata := make(map[string]string, len(gwMetadata))
+	for k, v := range gwMetadata {
+		// Backends may return the metadata keys in any case.
+		if key := http.CanonicalHeaderKey(k); strings.HasPrefix(key, gatewayReservedMetadataPrefix) {
+			k = strings.TrimPrefix(key, "X-Amz-Meta-")
+		}
+		metadata[k] = v
+	}
+	return metadata
+}
+
+// PutObject - saves the object with its reserved metadata.
+func (l *gatewayEncryptionLayer) PutObject(bucket, object string, size int64, data io.Reader, metadata map[string]string, sha256sum string) (ObjectInfo, error) {
+	objInfo, err := l.GatewayLayer.PutObject(bucket, object, size, data, toGatewayMetadata(metadata), sha256sum)
+	objInfo.UserDefined = fromGatewayMetadata(objInfo.UserDefined)
+	return objInfo, err
+}
+
+// AnonPutObject - saves the object with its reserved metadata.
+func (l *gatewayEncryptionLayer) AnonPutObject(bucket, object string, size int64, data io.Reader, metadata map[string]string, sha256sum string) (ObjectInfo, error) {
+	objInfo, err := l.GatewayLayer.AnonPutObject(bucket, object, size, data, toGatewayMetadata(metadata), sha256sum)
+	objInfo.UserDefined = fromGatewayMetadata(objInfo.UserDefined)
+	return objInfo, err
+}
+
+// CopyObject - copies the object with its reserved metadata.
+func (l *gatewayEncryptionLayer) CopyObject(srcBucket, srcObject, dstBucket, dstObject string, metadata map[string]string) (ObjectInfo, error) {
+	objInfo, err := l.GatewayLayer.CopyObject(srcBucket, srcObject, dstBucket, dstObject, toGatewayMetadata(metadata))
+	objInfo.UserDefined = fromGatewayMetadata(objInfo.UserDefined)
+	return objInfo, err
+}
+
+// GetObjectInfo - returns the object info with its reserved metadata.
+func (l *gatewayEncryptionLayer) GetObjectInfo(bucket, object string) (ObjectInfo, error) {
+	objInfo, err := l.GatewayLayer.GetObjectInfo(bucket, object)
+	objInfo.UserDefined = fromGatewayMetadata(objInfo.UserDefined)
+	return objInfo, err
+}
+
+// AnonGetObjectInfo - returns the object info with its reserved metadata.
+func (l *gatewayEncryptionLayer) AnonGetObjectInfo(bucket, object string) (ObjectInfo, error) {
+	objInfo, err := l.GatewayLayer.AnonGetObjectInfo(bucket, object)
+	objInfo.UserDefined = fromGatewayMetadata(objInfo.UserDefined)
+	return objInfo, err
+}
+
+// NewMultipartUpload - encrypted multipart uploads are not supported,
+// as gateway backends do not report the sizes of the parts of an object
+// which are needed to decrypt it.
+func (l *gatewayEncryptionLayer) NewMultipartUpload(bucket, object string, metadata map[string]string) (string, error) {
+	if isEncryptedObject(metadata) {
+		return "", traceError(NotImplemented{})
+	}
+	return l.GatewayLayer.NewMultipartUpload(bucket, object, toGatewayMetadata(metadata))
+}
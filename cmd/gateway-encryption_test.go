@@ -0,0 +1,59 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Tests saving reserved metadata as user metadata of gateway backends.
+func TestGatewayMetadata(t *testing.T) {
+	metadata := map[string]string{
+		"content-type":                   "text/plain",
+		"X-Amz-Meta-Color":               "blue",
+		ServerSideEncryptionIV:           "iv",
+		ServerSideEncryptionSealedKey:    "sealed-key",
+		ServerSideEncryptionKMSKeyID:     "my-key",
+		ServerSideEncryptionKMSSealedKey: "sealed-data-key",
+	}
+
+	// User metadata must not be able to forge reserved metadata.
+	userMetadata := map[string]string{"X-Amz-Meta-" + ServerSideEncryptionIV: "forged"}
+	for k, v := range metadata {
+		userMetadata[k] = v
+	}
+	gwMetadata := toGatewayMetadata(userMetadata)
+	if gwMetadata["X-Amz-Meta-"+ServerSideEncryptionIV] != "iv" {
+		t.Fatalf("Expected reserved metadata to be saved as user metadata, got %v", gwMetadata)
+	}
+	for k := range gwMetadata {
+		if isReservedMetadata(k) {
+			t.Fatalf("Unexpected reserved metadata key %s", k)
+		}
+	}
+
+	if got := fromGatewayMetadata(gwMetadata); !reflect.DeepEqual(got, metadata) {
+		t.Fatalf("Expected %v, got %v", metadata, got)
+	}
+
+	// Backends may return lower case metadata keys.
+	got := fromGatewayMetadata(map[string]string{"x-amz-meta-x-minio-internal-server-side-encryption-iv": "iv"})
+	if got[ServerSideEncryptionIV] != "iv" {
+		t.Fatalf("Expected reserved metadata to be restored, got %v", got)
+	}
+}
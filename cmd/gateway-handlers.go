@@ -88,6 +88,22 @@ func (api gatewayAPIHandlers) GetObjectHandler(w http.ResponseWriter, r *http.Re
 		return
 	}
 
+	// Encrypted objects can only be read with the client provided key,
+	// or the server managed key, all sizes and ranges below refer to
+	// the decrypted object.
+	encInfo := objInfo
+	sseKey, err := getSSEKey(r, bucket, object, objInfo.UserDefined, false)
+	if err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+	if sseKey != nil {
+		if objInfo.Size, err = sseDecryptedObjectSize(encInfo); err != nil {
+			writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+			return
+		}
+	}
+
 	// Get request range.
 	var hrange *httpRange
 	rangeHeader := r.Header.Get("Range")
@@ -129,6 +145,10 @@ func (api gatewayAPIHandlers) GetObjectHandler(w http.ResponseWriter, r *http.Re
 			// Set any additional requested response headers.
 			setGetRespHeaders(w, r.URL.Query())
 
+			if sseKey != nil {
+				setSSEResponseHeaders(w, sseKey, encInfo.UserDefined)
+			}
+
 			dataWritten = true
 		}
 		return w.Write(p)
@@ -139,8 +159,22 @@ func (api gatewayAPIHandlers) GetObjectHandler(w http.ResponseWriter, r *http.Re
 		getObject = objectAPI.AnonGetObject
 	}
 
+	// Encrypted objects are read in whole packages, which are
+	// decrypted before the requested range is written.
+	var objectWriter io.Writer = writer
+	if sseKey != nil {
+		read := func(offset, length int64, writer io.Writer) error {
+			return getObject(bucket, object, offset, length, writer)
+		}
+		objectWriter, startOffset, length, err = newSSEDecryptWriter(writer, sseKey, encInfo, startOffset, length, read)
+		if err != nil {
+			writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+			return
+		}
+	}
+
 	// Reads the object at startOffset and writes to mw.
-	if err = getObject(bucket, object, startOffset, length, writer); err != nil {
+	if err = getObject(bucket, object, startOffset, length, objectWriter); err != nil {
 		errorIf(err, "Unable to write to client.")
 		if !dataWritten {
 			// Error response only if no data has been written to client yet. i.e if
@@ -265,20 +299,22 @@ func (api gatewayAPIHandlers) PutObjectHandler(w http.ResponseWriter, r *http.Re
 	objectLock.Lock()
 	defer objectLock.Unlock()
 
-	var objInfo ObjectInfo
+	var reader io.Reader = r.Body
+	sha256sum := ""
+	putObject := objectAPI.PutObject
 	switch reqAuthType {
 	case authTypeAnonymous:
 		// Create anonymous object.
-		objInfo, err = objectAPI.AnonPutObject(bucket, object, size, r.Body, metadata, "")
+		putObject = objectAPI.AnonPutObject
 	case authTypeStreamingSigned:
 		// Initialize stream signature verifier.
-		reader, s3Error := newSignV4ChunkedReader(r)
+		var s3Error APIErrorCode
+		reader, s3Error = newSignV4ChunkedReader(r)
 		if s3Error != ErrNone {
 			errorIf(errSignatureMismatch, "%s", dumpRequest(r))
 			writeErrorResponse(w, s3Error, r.URL)
 			return
 		}
-		objInfo, err = objectAPI.PutObject(bucket, object, size, reader, metadata, "")
 	case authTypeSignedV2, authTypePresignedV2:
 		s3Error := isReqAuthenticatedV2(r)
 		if s3Error != ErrNone {
@@ -286,7 +322,6 @@ func (api gatewayAPIHandlers) PutObjectHandler(w http.ResponseWriter, r *http.Re
 			writeErrorResponse(w, s3Error, r.URL)
 			return
 		}
-		objInfo, err = objectAPI.PutObject(bucket, object, size, r.Body, metadata, "")
 	case authTypePresigned, authTypeSigned:
 		if s3Error := reqSignatureV4Verify(r, serverConfig.GetRegion()); s3Error != ErrNone {
 			errorIf(errSignatureMismatch, "%s", dumpRequest(r))
@@ -294,19 +329,44 @@ func (api gatewayAPIHandlers) PutObjectHandler(w http.ResponseWriter, r *http.Re
 			return
 		}
 
-		sha256sum := ""
 		if !skipContentSha256Cksum(r) {
 			sha256sum = getContentSha256Cksum(r)
 		}
-
-		// Create object.
-		objInfo, err = objectAPI.PutObject(bucket, object, size, r.Body, metadata, sha256sum)
 	default:
 		// For all unknown auth types return error.
 		writeErrorResponse(w, ErrAccessDenied, r.URL)
 		return
 	}
 
+	// Encrypt the data before it reaches the backend, the checksums
+	// sent by the client are hence verified over the plaintext here.
+	sseKey, err := getSSEPutKey(r, bucket, object, metadata)
+	if err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+	putSize := size
+	if sseKey != nil {
+		reader = newHashVerifyReader(reader, metadata["etag"], sha256sum)
+		delete(metadata, "etag")
+		sha256sum = ""
+
+		objectKey, kerr := newSSEObjectKey(sseKey, metadata)
+		if kerr != nil {
+			errorIf(kerr, "Unable to generate object key.")
+			writeErrorResponse(w, ErrInternalError, r.URL)
+			return
+		}
+		if reader, err = newSSEEncryptReader(reader, objectKey, 1); err != nil {
+			errorIf(err, "Unable to initialize encryption.")
+			writeErrorResponse(w, ErrInternalError, r.URL)
+			return
+		}
+		putSize = sseEncryptedSize(size)
+	}
+
+	// Create object.
+	objInfo, err := putObject(bucket, object, putSize, reader, metadata, sha256sum)
 	if err != nil {
 		errorIf(err, "Unable to save an object %s", r.URL.Path)
 		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
@@ -314,6 +374,10 @@ func (api gatewayAPIHandlers) PutObjectHandler(w http.ResponseWriter, r *http.Re
 	}
 
 	w.Header().Set("ETag", "\""+objInfo.ETag+"\"")
+	if sseKey != nil {
+		setSSEResponseHeaders(w, sseKey, metadata)
+		objInfo.Size = size
+	}
 	writeSuccessResponseHeadersOnly(w)
 
 	// Get host and port from Request.RemoteAddr.
@@ -390,6 +454,21 @@ func (api gatewayAPIHandlers) HeadObjectHandler(w http.ResponseWriter, r *http.R
 		return
 	}
 
+	// Encrypted objects can only be read with the client provided key,
+	// or the server managed key.
+	sseKey, err := getSSEKey(r, bucket, object, objInfo.UserDefined, false)
+	if err != nil {
+		writeErrorResponseHeadersOnly(w, toAPIErrorCode(err))
+		return
+	}
+	if sseKey != nil {
+		if objInfo.Size, err = sseDecryptedObjectSize(objInfo); err != nil {
+			writeErrorResponseHeadersOnly(w, toAPIErrorCode(err))
+			return
+		}
+		setSSEResponseHeaders(w, sseKey, objInfo.UserDefined)
+	}
+
 	// Validate pre-conditions if any.
 	if checkPreconditions(w, r, objInfo) {
 		return
@@ -586,6 +665,108 @@ func (api gatewayAPIHandlers) ListenBucketNotificationHandler(w http.ResponseWri
 	writeErrorResponse(w, ErrNotImplemented, r.URL)
 }
 
+// PutBucketCorsHandler - CORS configurations are not supported
+// by gateway backends.
+func (api gatewayAPIHandlers) PutBucketCorsHandler(w http.ResponseWriter, r *http.Request) {
+	writeErrorResponse(w, ErrNotImplemented, r.URL)
+}
+
+// GetBucketCorsHandler - CORS configurations are not supported
+// by gateway backends.
+func (api gatewayAPIHandlers) GetBucketCorsHandler(w http.ResponseWriter, r *http.Request) {
+	writeErrorResponse(w, ErrNotImplemented, r.URL)
+}
+
+// DeleteBucketCorsHandler - CORS configurations are not supported
+// by gateway backends.
+func (api gatewayAPIHandlers) DeleteBucketCorsHandler(w http.ResponseWriter, r *http.Request) {
+	writeErrorResponse(w, ErrNotImplemented, r.URL)
+}
+
+// PutBucketWebsiteHandler - website configurations are not supported
+// by gateway backends.
+func (api gatewayAPIHandlers) PutBucketWebsiteHandler(w http.ResponseWriter, r *http.Request) {
+	writeErrorResponse(w, ErrNotImplemented, r.URL)
+}
+
+// GetBucketWebsiteHandler - website configurations are not supported
+// by gateway backends.
+func (api gatewayAPIHandlers) GetBucketWebsiteHandler(w http.ResponseWriter, r *http.Request) {
+	writeErrorResponse(w, ErrNotImplemented, r.URL)
+}
+
+// DeleteBucketWebsiteHandler - website configurations are not supported
+// by gateway backends.
+func (api gatewayAPIHandlers) DeleteBucketWebsiteHandler(w http.ResponseWriter, r *http.Request) {
+	writeErrorResponse(w, ErrNotImplemented, r.URL)
+}
+
+// PutBucketLoggingHandler - access logging is not supported by gateway
+// backends.
+func (api gatewayAPIHandlers) PutBucketLoggingHandler(w http.ResponseWriter, r *http.Request) {
+	writeErrorResponse(w, ErrNotImplemented, r.URL)
+}
+
+// GetBucketLoggingHandler - access logging is not supported by gateway
+// backends.
+func (api gatewayAPIHandlers) GetBucketLoggingHandler(w http.ResponseWriter, r *http.Request) {
+	writeErrorResponse(w, ErrNotImplemented, r.URL)
+}
+
+// PutBucketReplicationHandler - replication configurations are not
+// supported by gateway backends.
+func (api gatewayAPIHandlers) PutBucketReplicationHandler(w http.ResponseWriter, r *http.Request) {
+	writeErrorResponse(w, ErrNotImplemented, r.URL)
+}
+
+// GetBucketReplicationHandler - replication configurations are not
+// supported by gateway backends.
+func (api gatewayAPIHandlers) GetBucketReplicationHandler(w http.ResponseWriter, r *http.Request) {
+	writeErrorResponse(w, ErrNotImplemented, r.URL)
+}
+
+// DeleteBucketReplicationHandler - replication configurations are not
+// supported by gateway backends.
+func (api gatewayAPIHandlers) DeleteBucketReplicationHandler(w http.ResponseWriter, r *http.Request) {
+	writeErrorResponse(w, ErrNotImplemented, r.URL)
+}
+
+// PutBucketTaggingHandler - tags are not supported by gateway
+// backends.
+func (api gatewayAPIHandlers) PutBucketTaggingHandler(w http.ResponseWriter, r *http.Request) {
+	writeErrorResponse(w, ErrNotImplemented, r.URL)
+}
+
+// GetBucketTaggingHandler - tags are not supported by gateway
+// backends.
+func (api gatewayAPIHandlers) GetBucketTaggingHandler(w http.ResponseWriter, r *http.Request) {
+	writeErrorResponse(w, ErrNotImplemented, r.URL)
+}
+
+// DeleteBucketTaggingHandler - tags are not supported by gateway
+// backends.
+func (api gatewayAPIHandlers) DeleteBucketTaggingHandler(w http.ResponseWriter, r *http.Request) {
+	writeErrorResponse(w, ErrNotImplemented, r.URL)
+}
+
+// PutObjectTaggingHandler - tags are not supported by gateway
+// backends.
+func (api gatewayAPIHandlers) PutObjectTaggingHandler(w http.ResponseWriter, r *http.Request) {
+	writeErrorResponse(w, ErrNotImplemented, r.URL)
+}
+
+// GetObjectTaggingHandler - tags are not supported by gateway
+// backends.
+func (api gatewayAPIHandlers) GetObjectTaggingHandler(w http.ResponseWriter, r *http.Request) {
+	writeErrorResponse(w, ErrNotImplemented, r.URL)
+}
+
+// DeleteObjectTaggingHandler - tags are not supported by gateway
+// backends.
+func (api gatewayAPIHandlers) DeleteObjectTaggingHandler(w http.ResponseWriter, r *http.Request) {
+	writeErrorResponse(w, ErrNotImplemented, r.URL)
+}
+
 // PutBucketHandler - PUT Bucket
 // ----------
 // This implementation of the PUT operation creates a new bucket for authenticated request
@@ -51,6 +51,12 @@ ENVIRONMENT VARIABLES:
   BROWSER:
      MINIO_BROWSER: To disable web browser access, set this value to "off".
 
+  ENCRYPTION:
+     MINIO_SSE_MASTER_KEY: Master key for server side encryption as <key-id>:<64 hex characters>.
+     MINIO_SSE_VAULT_ENDPOINT: Vault server sealing the keys of server side encryption.
+     MINIO_SSE_VAULT_TOKEN: Token to access the Vault server.
+     MINIO_SSE_VAULT_KEY_ID: Name of the Vault transit key.
+
 EXAMPLES:
   1. Start minio gateway server for Azure Blob Storage backend.
       $ export MINIO_ACCESS_KEY=azureaccountname
@@ -83,6 +89,12 @@ ENVIRONMENT VARIABLES:
   BROWSER:
      MINIO_BROWSER: To disable web browser access, set this value to "off".
 
+  ENCRYPTION:
+     MINIO_SSE_MASTER_KEY: Master key for server side encryption as <key-id>:<64 hex characters>.
+     MINIO_SSE_VAULT_ENDPOINT: Vault server sealing the keys of server side encryption.
+     MINIO_SSE_VAULT_TOKEN: Token to access the Vault server.
+     MINIO_SSE_VAULT_KEY_ID: Name of the Vault transit key.
+
 EXAMPLES:
   1. Start minio gateway server for AWS S3 backend.
       $ export MINIO_ACCESS_KEY=accesskey
@@ -115,6 +127,12 @@ ENVIRONMENT VARIABLES:
   BROWSER:
      MINIO_BROWSER: To disable web browser access, set this value to "off".
 
+  ENCRYPTION:
+     MINIO_SSE_MASTER_KEY: Master key for server side encryption as <key-id>:<64 hex characters>.
+     MINIO_SSE_VAULT_ENDPOINT: Vault server sealing the keys of server side encryption.
+     MINIO_SSE_VAULT_TOKEN: Token to access the Vault server.
+     MINIO_SSE_VAULT_KEY_ID: Name of the Vault transit key.
+
 EXAMPLES:
   1. Start minio gateway server for GCS backend.
       $ export GOOGLE_APPLICATION_CREDENTIALS=/path/to/credentials.json
@@ -322,6 +340,9 @@ func gatewayMain(ctx *cli.Context, backendType gatewayBackend) {
 	// Init the error tracing module.
 	initError()
 
+	// Initialize the key management service for SSE-S3.
+	initKMS()
+
 	// Check and load SSL certificates.
 	var err error
 	globalPublicCerts, globalRootCAs, globalTLSCertificate, globalIsSSL, err = getSSLConfig()
@@ -332,6 +353,9 @@ func gatewayMain(ctx *cli.Context, backendType gatewayBackend) {
 	newObject, err := newGatewayLayer(backendType, ctx.Args().First())
 	fatalIf(err, "Unable to initialize gateway layer")
 
+	// Save the metadata of encrypted objects on the backend.
+	newObject = newGatewayEncryptionLayer(newObject)
+
 	router := mux.NewRouter().SkipClean(true)
 
 	// Register web router when its enabled.
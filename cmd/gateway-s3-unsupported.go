@@ -16,6 +16,8 @@
 
 package cmd
 
+import "io"
+
 // HealBucket - Not relevant.
 func (l *s3Objects) HealBucket(bucket string) error {
 	return traceError(NotImplemented{})
@@ -31,6 +33,11 @@ func (l *s3Objects) HealObject(bucket string, object string) (int, int, error) {
 	return 0, 0, traceError(NotImplemented{})
 }
 
+// HealObjectDisks - Not relevant.
+func (l *s3Objects) HealObjectDisks(bucket string, object string) (HealObjectResult, error) {
+	return HealObjectResult{}, traceError(NotImplemented{})
+}
+
 // ListObjectsHeal - Not relevant.
 func (l *s3Objects) ListObjectsHeal(bucket string, prefix string, marker string, delimiter string, maxKeys int) (loi ListObjectsInfo, e error) {
 	return loi, traceError(NotImplemented{})
@@ -40,3 +47,28 @@ func (l *s3Objects) ListObjectsHeal(bucket string, prefix string, marker string,
 func (l *s3Objects) ListUploadsHeal(bucket string, prefix string, marker string, uploadIDMarker string, delimiter string, maxUploads int) (lmi ListMultipartsInfo, e error) {
 	return lmi, traceError(NotImplemented{})
 }
+
+// GetObjectVersion - Not relevant.
+func (l *s3Objects) GetObjectVersion(bucket, object, versionID string, startOffset int64, length int64, writer io.Writer) error {
+	return traceError(NotImplemented{})
+}
+
+// GetObjectVersionInfo - Not relevant.
+func (l *s3Objects) GetObjectVersionInfo(bucket, object, versionID string) (objInfo ObjectInfo, err error) {
+	return objInfo, traceError(NotImplemented{})
+}
+
+// DeleteObjectVersion - Not relevant.
+func (l *s3Objects) DeleteObjectVersion(bucket, object, versionID string, bypassGovernance bool) (objInfo ObjectInfo, err error) {
+	return objInfo, traceError(NotImplemented{})
+}
+
+// ListObjectVersions - Not relevant.
+func (l *s3Objects) ListObjectVersions(bucket, prefix, keyMarker, versionIDMarker, delimiter string, maxKeys int) (result ListObjectVersionsInfo, err error) {
+	return result, traceError(NotImplemented{})
+}
+
+// PutObjectLock - Not relevant.
+func (l *s3Objects) PutObjectLock(bucket, object, versionID string, lock objectLock, bypassGovernance bool) (objInfo ObjectInfo, err error) {
+	return objInfo, traceError(NotImplemented{})
+}
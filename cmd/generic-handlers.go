@@ -18,14 +18,16 @@ package cmd
 
 import (
 	"bufio"
+	"bytes"
+	"io"
 	"net"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	humanize "github.com/dustin/go-humanize"
 	router "github.com/gorilla/mux"
-	"github.com/rs/cors"
 )
 
 // HandlerFunc - useful to chain different middleware http.Handler
@@ -115,8 +117,10 @@ func guessIsBrowserReq(req *http.Request) bool {
 
 func (h redirectHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	aType := getRequestAuthType(r)
-	// Re-direct only for JWT and anonymous requests from browser.
-	if aType == authTypeJWT || aType == authTypeAnonymous {
+	_, isVirtualHost := getVirtualHostBucket(r.Host)
+	// Re-direct only for JWT and anonymous requests from browser,
+	// virtual host style requests are always bucket requests.
+	if (aType == authTypeJWT || aType == authTypeAnonymous) && !isVirtualHost {
 		// Re-direction is handled specifically for browser requests.
 		if guessIsBrowserReq(r) && globalIsBrowserEnabled {
 			// Fetch the redirect location if any.
@@ -169,7 +173,7 @@ func setPrivateBucketHandler(h http.Handler) http.Handler {
 
 func (h minioPrivateBucketHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// For all non browser requests, reject access to 'minioReservedBucketPath'.
-	bucketName, _ := urlPath2BucketObjectName(r.URL)
+	bucketName, _ := getRequestBucketObject(r)
 	if !guessIsBrowserReq(r) && isMinioReservedBucket(bucketName) && isMinioMetaBucket(bucketName) {
 		writeErrorResponse(w, ErrAllAccessDisabled, r.URL)
 		return
@@ -261,26 +265,101 @@ const (
 	httpOPTIONS = "OPTIONS"
 )
 
-// List of default allowable HTTP methods.
-var defaultAllowableHTTPMethods = []string{
-	httpGET,
-	httpPUT,
-	httpHEAD,
-	httpPOST,
-	httpDELETE,
-	httpOPTIONS,
+// CORS request and response headers.
+const (
+	corsOrigin                 = "Origin"
+	corsRequestMethod          = "Access-Control-Request-Method"
+	corsRequestHeaders         = "Access-Control-Request-Headers"
+	corsAllowOrigin            = "Access-Control-Allow-Origin"
+	corsAllowMethods           = "Access-Control-Allow-Methods"
+	corsAllowHeaders           = "Access-Control-Allow-Headers"
+	corsAllowCredentials       = "Access-Control-Allow-Credentials"
+	corsExposeHeaders          = "Access-Control-Expose-Headers"
+	corsMaxAge                 = "Access-Control-Max-Age"
+	corsVary                   = "Vary"
+	corsVaryPreflightResponse  = "Origin, Access-Control-Request-Headers, Access-Control-Request-Method"
+	corsVaryResponse           = "Origin"
+	corsAllowCredentialsHeader = "true"
+)
+
+type corsHandler struct {
+	handler http.Handler
 }
 
-// setCorsHandler handler for CORS (Cross Origin Resource Sharing)
+// setCorsHandler handler for CORS (Cross Origin Resource Sharing),
+// cross-origin requests to a bucket are only allowed by the CORS
+// configuration of the bucket.
 func setCorsHandler(h http.Handler) http.Handler {
-	c := cors.New(cors.Options{
-		AllowedOrigins:   []string{"*"},
-		AllowedMethods:   defaultAllowableHTTPMethods,
-		AllowedHeaders:   []string{"*"},
-		ExposedHeaders:   []string{"ETag"},
-		AllowCredentials: true,
-	})
-	return c.Handler(h)
+	return corsHandler{h}
+}
+
+// setCorsResponseHeaders - sets the response headers allowing a
+// cross-origin request from origin, which matched the allowed origin
+// pattern of the rule.
+func setCorsResponseHeaders(w http.ResponseWriter, origin, pattern string) {
+	if pattern == "*" {
+		w.Header().Set(corsAllowOrigin, "*")
+		return
+	}
+	w.Header().Set(corsAllowOrigin, origin)
+	w.Header().Set(corsAllowCredentials, corsAllowCredentialsHeader)
+}
+
+// parseCorsRequestHeaders - returns the header names requested by
+// a preflight request.
+func parseCorsRequestHeaders(value string) (headers []string) {
+	for _, header := range strings.Split(value, ",") {
+		if header = strings.TrimSpace(header); header != "" {
+			headers = append(headers, header)
+		}
+	}
+	return headers
+}
+
+// CORS handler ServeHTTP() wrapper
+func (h corsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	origin := r.Header.Get(corsOrigin)
+	bucket, _ := getRequestBucketObject(r)
+	// Only requests to buckets are subject to CORS rules.
+	if origin == "" || bucket == "" || bucket == minioReservedBucket {
+		h.handler.ServeHTTP(w, r)
+		return
+	}
+	ccfg := globalBucketCors.GetBucketCors(bucket)
+
+	// Answer preflight requests with the matching rule of the bucket.
+	if method := r.Header.Get(corsRequestMethod); r.Method == httpOPTIONS && method != "" {
+		headers := parseCorsRequestHeaders(r.Header.Get(corsRequestHeaders))
+		rule, pattern := ccfg.findRule(origin, method, headers)
+		if rule == nil {
+			writeErrorResponse(w, ErrCORSNotAllowed, r.URL)
+			return
+		}
+		setCorsResponseHeaders(w, origin, pattern)
+		w.Header().Set(corsVary, corsVaryPreflightResponse)
+		w.Header().Set(corsAllowMethods, strings.Join(rule.AllowedMethods, ", "))
+		if len(headers) > 0 {
+			w.Header().Set(corsAllowHeaders, strings.Join(headers, ", "))
+		}
+		if rule.MaxAgeSeconds > 0 {
+			w.Header().Set(corsMaxAge, strconv.Itoa(rule.MaxAgeSeconds))
+		}
+		writeSuccessResponseHeadersOnly(w)
+		return
+	}
+
+	// Actual requests are served regardless, browsers only hand the
+	// response to the requesting page if it is allowed.
+	if ccfg != nil {
+		w.Header().Set(corsVary, corsVaryResponse)
+		if rule, pattern := ccfg.findRule(origin, r.Method, nil); rule != nil {
+			setCorsResponseHeaders(w, origin, pattern)
+			if len(rule.ExposeHeaders) > 0 {
+				w.Header().Set(corsExposeHeaders, strings.Join(rule.ExposeHeaders, ", "))
+			}
+		}
+	}
+	h.handler.ServeHTTP(w, r)
 }
 
 // setIgnoreResourcesHandler -
@@ -314,15 +393,7 @@ func ignoreNotImplementedObjectResources(req *http.Request) bool {
 // List of not implemented bucket queries
 var notimplementedBucketResourceNames = map[string]bool{
 	"acl":            true,
-	"cors":           true,
-	"lifecycle":      true,
-	"logging":        true,
-	"replication":    true,
-	"tagging":        true,
-	"versions":       true,
 	"requestPayment": true,
-	"versioning":     true,
-	"website":        true,
 }
 
 // List of not implemented object queries
@@ -334,7 +405,7 @@ var notimplementedObjectResourceNames = map[string]bool{
 
 // Resource handler ServeHTTP() wrapper
 func (h resourceHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	bucketName, objectName := urlPath2BucketObjectName(r.URL)
+	bucketName, objectName := getRequestBucketObject(r)
 
 	// If bucketName is present and not objectName check for bucket level resource queries.
 	if bucketName != "" && objectName == "" {
@@ -351,7 +422,7 @@ func (h resourceHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 	// A put method on path "/" doesn't make sense, ignore it.
-	if r.Method == httpPUT && r.URL.Path == "/" && r.Header.Get(minioAdminOpHeader) == "" {
+	if r.Method == httpPUT && bucketName == "" && r.Header.Get(minioAdminOpHeader) == "" {
 		writeErrorResponse(w, ErrNotImplemented, r.URL)
 		return
 	}
@@ -365,11 +436,19 @@ func (h resourceHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 type httpResponseRecorder struct {
 	http.ResponseWriter
 	respStatusCode int
+	bytesWritten   int64
+	// Records the start of the body when the request is traced
+	// with bodies.
+	traceBody *bytes.Buffer
 }
 
-// Wraps ResponseWriter's Write()
+// Wraps ResponseWriter's Write() and record the number of bytes
+// written
 func (rww *httpResponseRecorder) Write(b []byte) (int, error) {
-	return rww.ResponseWriter.Write(b)
+	n, err := rww.ResponseWriter.Write(b)
+	rww.bytesWritten += int64(n)
+	appendTraceBody(rww.traceBody, b[:n])
+	return n, err
 }
 
 // Wraps ResponseWriter's Flush()
@@ -388,6 +467,24 @@ func (rww *httpResponseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
 	return rww.ResponseWriter.(http.Hijacker).Hijack()
 }
 
+// httpRequestBodyRecorder wraps the body of an http.Request
+// to record the number of bytes read from it.
+type httpRequestBodyRecorder struct {
+	io.ReadCloser
+	bytesRead int64
+	// Records the start of the body when the request is traced
+	// with bodies.
+	traceBody *bytes.Buffer
+}
+
+// Wraps the body's Read() and record the number of bytes read.
+func (rbr *httpRequestBodyRecorder) Read(p []byte) (int, error) {
+	n, err := rbr.ReadCloser.Read(p)
+	rbr.bytesRead += int64(n)
+	appendTraceBody(rbr.traceBody, p[:n])
+	return n, err
+}
+
 // httpStatsHandler definition: gather HTTP statistics
 type httpStatsHandler struct {
 	handler http.Handler
@@ -402,6 +499,23 @@ func (h httpStatsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// Wraps w to record http response information
 	ww := &httpResponseRecorder{ResponseWriter: w}
 
+	// Wraps the request body to record the bytes received.
+	rbr := &httpRequestBodyRecorder{ReadCloser: r.Body}
+	if r.Body != nil {
+		r.Body = rbr
+	}
+
+	// Record the bodies when a trace client wants them, unless they
+	// may carry credentials.
+	traced, tracedWithBody := globalHTTPTracer.subscribed()
+	traced = traced && !isAuditIgnored(r)
+	if traced && tracedWithBody && !hasTraceSecretBody(r) {
+		ww.traceBody = &bytes.Buffer{}
+		if r.Body != nil {
+			rbr.traceBody = &bytes.Buffer{}
+		}
+	}
+
 	// Time start before the call is about to start.
 	tBefore := UTCNow()
 
@@ -419,6 +533,27 @@ func (h httpStatsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	// Update http statistics
 	globalHTTPStats.updateStats(r, ww, durationSecs)
+
+	// Update statistics of the called API.
+	if !isAuditIgnored(r) {
+		status := ww.respStatusCode
+		if status == 0 {
+			status = http.StatusOK
+		}
+		bucket, object := getRequestBucketObject(r)
+		globalAPIStats.updateStats(getAuditAPIName(r, bucket, object), status, durationSecs)
+	}
+
+	// Record the request in the access logs of its bucket.
+	globalAccessLogs.logRequest(r, ww, tBefore, tAfter.Sub(tBefore))
+
+	// Send the audit record of the request.
+	globalAuditLogger.logRequest(r, ww, rbr.bytesRead, tBefore, tAfter.Sub(tBefore))
+
+	// Send the trace of the request to the trace clients.
+	if traced {
+		globalHTTPTracer.publish(newTraceInfo(r, ww, rbr.traceBody, tBefore, tAfter.Sub(tBefore)))
+	}
 }
 
 // pathValidityHandler validates all the incoming paths for
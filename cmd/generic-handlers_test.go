@@ -18,6 +18,8 @@ package cmd
 
 import (
 	"net/http"
+	"net/http/httptest"
+	"sync"
 	"testing"
 )
 
@@ -88,3 +90,87 @@ func TestGuessIsBrowser(t *testing.T) {
 		t.Fatal("Test shouldn't report as browser for a non browser request.")
 	}
 }
+
+// Tests answering preflight and actual cross-origin requests.
+func TestCorsHandler(t *testing.T) {
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatalf("Failed to create test config - %v", err)
+	}
+	defer removeAll(rootPath)
+
+	defer func(bc *bucketCors) { globalBucketCors = bc }(globalBucketCors)
+	globalBucketCors = &bucketCors{
+		rwMutex: &sync.RWMutex{},
+		bucketCorsConfigs: map[string]*corsConfiguration{
+			"bucket": {
+				Rules: []corsRule{{
+					AllowedOrigins: []string{"https://www.example.com"},
+					AllowedMethods: []string{httpGET, httpPUT},
+					AllowedHeaders: []string{"*"},
+					ExposeHeaders:  []string{"ETag"},
+					MaxAgeSeconds:  3000,
+				}, {
+					AllowedOrigins: []string{"*"},
+					AllowedMethods: []string{httpGET},
+				}},
+			},
+		},
+	}
+	handler := setCorsHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	testCases := []struct {
+		method         string
+		path           string
+		header         map[string]string
+		expectedStatus int
+		expectedHeader map[string]string
+	}{
+		// Preflight request allowed by the first rule.
+		{httpOPTIONS, "/bucket/object", map[string]string{corsOrigin: "https://www.example.com", corsRequestMethod: httpPUT, corsRequestHeaders: "content-type, x-amz-date"}, http.StatusOK,
+			map[string]string{corsAllowOrigin: "https://www.example.com", corsAllowCredentials: "true", corsAllowMethods: "GET, PUT", corsAllowHeaders: "content-type, x-amz-date", corsMaxAge: "3000"}},
+		// Preflight request allowed by the wildcard rule.
+		{httpOPTIONS, "/bucket/object", map[string]string{corsOrigin: "https://www.example.org", corsRequestMethod: httpGET}, http.StatusOK,
+			map[string]string{corsAllowOrigin: "*", corsAllowCredentials: "", corsAllowMethods: "GET"}},
+		// Preflight request with a method which is not allowed.
+		{httpOPTIONS, "/bucket/object", map[string]string{corsOrigin: "https://www.example.org", corsRequestMethod: httpPUT}, http.StatusForbidden,
+			map[string]string{corsAllowOrigin: ""}},
+		// Preflight request to a bucket without CORS configuration.
+		{httpOPTIONS, "/other-bucket/object", map[string]string{corsOrigin: "https://www.example.com", corsRequestMethod: httpGET}, http.StatusForbidden,
+			map[string]string{corsAllowOrigin: ""}},
+		// Actual request allowed by the first rule.
+		{httpPUT, "/bucket/object", map[string]string{corsOrigin: "https://www.example.com"}, http.StatusOK,
+			map[string]string{corsAllowOrigin: "https://www.example.com", corsExposeHeaders: "ETag", corsVary: "Origin"}},
+		// Actual request which is not allowed is served without CORS headers.
+		{httpPUT, "/bucket/object", map[string]string{corsOrigin: "https://www.example.org"}, http.StatusOK,
+			map[string]string{corsAllowOrigin: "", corsVary: "Origin"}},
+		// Actual request to a bucket without CORS configuration.
+		{httpGET, "/other-bucket/object", map[string]string{corsOrigin: "https://www.example.com"}, http.StatusOK,
+			map[string]string{corsAllowOrigin: "", corsVary: ""}},
+		// Same origin request.
+		{httpGET, "/bucket/object", nil, http.StatusOK,
+			map[string]string{corsAllowOrigin: "", corsVary: ""}},
+	}
+
+	for i, testCase := range testCases {
+		req, err := http.NewRequest(testCase.method, "http://localhost:9000"+testCase.path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for k, v := range testCase.header {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != testCase.expectedStatus {
+			t.Errorf("Test %d: Expected status %d, got %d", i+1, testCase.expectedStatus, rec.Code)
+		}
+		for k, v := range testCase.expectedHeader {
+			if got := rec.Header().Get(k); got != v {
+				t.Errorf("Test %d: Expected %s %q, got %q", i+1, k, v, got)
+			}
+		}
+	}
+}
@@ -90,6 +90,22 @@ var (
 	// This flag is set to 'us-east-1' by default
 	globalServerRegion = globalMinioDefaultRegion
 
+	// This flag is set to 'true' when MINIO_DOMAIN env is set.
+	globalIsEnvDomains = false
+
+	// Domains of the server, buckets are addressed in virtual host
+	// style as `<bucket>.<domain>` for any of these domains.
+	globalDomains []string
+
+	// This flag is set to 'true' when MINIO_STORAGE_CLASS_STANDARD or
+	// MINIO_STORAGE_CLASS_RRS env is set.
+	globalIsEnvStorageClass = false
+
+	// Parity of the standard and reduced redundancy storage classes,
+	// a zero parity selects the default parity of the class.
+	globalStandardStorageClass storageClass
+	globalRRStorageClass       storageClass
+
 	// Maximum size of internal objects parts
 	globalPutPartSize = int64(64 * 1024 * 1024)
 
@@ -115,6 +131,14 @@ var (
 	globalHTTPServerErrorCh = make(chan error)
 	globalOSSignalCh        = make(chan os.Signal, 1)
 
+	// Address of the listener serving bucket websites, empty
+	// if bucket websites have no listener of their own.
+	globalWebsiteAddr   = ""
+	globalWebsiteServer *miniohttp.Server
+	// Domain under which bucket websites are served as
+	// `<bucket>.<domain>`, empty if not configured.
+	globalWebsiteDomain = ""
+
 	// List of admin peers.
 	globalAdminPeers = adminPeers{}
 
@@ -129,6 +153,15 @@ var (
 	// Global HTTP request statisitics
 	globalHTTPStats = newHTTPStats()
 
+	// Global request statistics per API
+	globalAPIStats = newAPIStats()
+
+	// Global healing statistics
+	globalHealStats = &healStats{}
+
+	// Authentication type of the prometheus metrics endpoint.
+	globalPrometheusAuthType = prometheusJWT
+
 	// Time when object layer was initialized on start up.
 	globalBootTime time.Time
 
@@ -162,8 +195,10 @@ func getGlobalInfo() (globalInfo map[string]interface{}) {
 		"isEnvBrowser":     globalIsEnvBrowser,
 		"isEnvCreds":       globalIsEnvCreds,
 		"isEnvRegion":      globalIsEnvRegion,
+		"isEnvDomains":     globalIsEnvDomains,
 		"isSSL":            globalIsSSL,
 		"serverRegion":     globalServerRegion,
+		"serverDomains":    globalDomains,
 		"serverUserAgent":  globalServerUserAgent,
 		// Add more relevant global settings here.
 	}
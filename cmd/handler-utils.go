@@ -19,9 +19,13 @@ package cmd
 import (
 	"io"
 	"mime/multipart"
+	"net"
 	"net/http"
 	"net/url"
+	"sort"
 	"strings"
+
+	router "github.com/gorilla/mux"
 )
 
 // Parses location constraint from the incoming reader.
@@ -97,6 +101,88 @@ func path2BucketAndObject(path string) (bucket, object string) {
 	return bucket, object
 }
 
+// isValidDomain - returns true if the domain is a valid host name
+// without port, e.g. `s3.example.com`.
+func isValidDomain(domain string) bool {
+	if domain == "" || len(domain) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(domain, ".") {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, c := range label {
+			if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '-' {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// byLongestDomain - sorts domains by decreasing length.
+type byLongestDomain []string
+
+func (d byLongestDomain) Len() int           { return len(d) }
+func (d byLongestDomain) Swap(i, j int)      { d[i], d[j] = d[j], d[i] }
+func (d byLongestDomain) Less(i, j int) bool { return len(d[i]) > len(d[j]) }
+
+// getServerDomains - returns the server domains, longest first so that
+// a request to a sub-domain of another server domain is matched by the
+// sub-domain.
+func getServerDomains() []string {
+	domains := append([]string{}, globalDomains...)
+	sort.Stable(byLongestDomain(domains))
+	return domains
+}
+
+// getVirtualHostBucket - returns the bucket of a virtual host style
+// request made to `<bucket>.<domain>` for one of the server domains.
+func getVirtualHostBucket(host string) (string, bool) {
+	if len(globalDomains) == 0 {
+		return "", false
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(host)
+	domains := getServerDomains()
+	// Requests to a server domain itself are path style.
+	for _, domain := range domains {
+		if host == domain {
+			return "", false
+		}
+	}
+	for _, domain := range domains {
+		if bucket := strings.TrimSuffix(host, "."+domain); bucket != host && bucket != "" {
+			return bucket, true
+		}
+	}
+	return "", false
+}
+
+// isVirtualHostRequest - matches virtual host style requests, which are
+// not requests to a server domain that is a sub-domain of another one.
+func isVirtualHostRequest(r *http.Request, rm *router.RouteMatch) bool {
+	_, ok := getVirtualHostBucket(r.Host)
+	return ok
+}
+
+// getResource - returns the path style resource of a request path, the
+// bucket of virtual host style requests is prepended to the path.
+func getResource(path, host string) string {
+	if bucket, ok := getVirtualHostBucket(host); ok {
+		return slashSeparator + bucket + path
+	}
+	return path
+}
+
+// getRequestBucketObject - returns the bucket and object of a request
+// addressed either in path or in virtual host style.
+func getRequestBucketObject(r *http.Request) (bucket, object string) {
+	return path2BucketAndObject(getResource(r.URL.Path, r.Host))
+}
+
 // extractMetadataFromHeader extracts metadata from HTTP header.
 func extractMetadataFromHeader(header http.Header) (map[string]string, error) {
 	if header == nil {
@@ -189,3 +189,52 @@ func TestExtractMetadataHeaders(t *testing.T) {
 		}
 	}
 }
+
+// Tests validation of server domains.
+func TestIsValidDomain(t *testing.T) {
+	testCases := []struct {
+		domain string
+		valid  bool
+	}{
+		{"s3.example.com", true},
+		{"localhost", true},
+		{"s3-1.example.com", true},
+		{"", false},
+		{"s3.example.com:9000", false},
+		{"S3.example.com", false},
+		{"s3..example.com", false},
+		{"-s3.example.com", false},
+		{"s3.example.com.", false},
+	}
+
+	for i, testCase := range testCases {
+		if valid := isValidDomain(testCase.domain); valid != testCase.valid {
+			t.Errorf("Test %d: Expected %v, got %v", i+1, testCase.valid, valid)
+		}
+	}
+}
+
+// Tests resolving the bucket and resource of virtual host style requests.
+func TestGetResource(t *testing.T) {
+	globalDomains = []string{"example.com", "s3.example.com"}
+	defer func() { globalDomains = nil }()
+
+	testCases := []struct {
+		path             string
+		host             string
+		expectedResource string
+	}{
+		{"/bucket/object", "s3.example.com", "/bucket/object"},
+		{"/object", "bucket.s3.example.com", "/bucket/object"},
+		{"/object", "bucket.s3.example.com:9000", "/bucket/object"},
+		{"/", "Bucket.S3.example.com", "/bucket/"},
+		{"/object", "my.bucket.example.com", "/my.bucket/object"},
+		{"/bucket/object", "localhost:9000", "/bucket/object"},
+	}
+
+	for i, testCase := range testCases {
+		if resource := getResource(testCase.path, testCase.host); resource != testCase.expectedResource {
+			t.Errorf("Test %d: Expected resource %s, got %s", i+1, testCase.expectedResource, resource)
+		}
+	}
+}
@@ -0,0 +1,573 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/skyrings/skyring-common/tools/uuid"
+)
+
+const (
+	// States of the heal sequences, saved in the minio meta bucket
+	// by token.
+	healSequencesPrefix = "heal/sequences"
+
+	// Interval between two saves of the state of a running heal
+	// sequence, a resumed sequence heals again the objects healed
+	// since the last save.
+	healSequenceSaveInterval = 10 * time.Second
+)
+
+var (
+	errNoSuchHealSequence  = errors.New("Heal sequence not found")
+	errHealSequenceRunning = errors.New("A heal sequence is already running on the bucket and prefix")
+	errHealSequenceStopped = errors.New("Heal sequence stopped")
+)
+
+// healSequenceStatus - status of a heal sequence.
+type healSequenceStatus string
+
+const (
+	healSequenceRunning  healSequenceStatus = "running"
+	healSequenceStopped  healSequenceStatus = "stopped"
+	healSequenceFinished healSequenceStatus = "finished"
+	healSequenceFailed   healSequenceStatus = "failed"
+)
+
+// HealDiskStats - number of objects of a heal sequence in each state
+// on a disk, before and after healing. Disks are identified by their
+// erasure set and their position in the set.
+type HealDiskStats struct {
+	Set      int                      `json:"set"`
+	Disk     int                      `json:"disk"`
+	Endpoint string                   `json:"endpoint"`
+	Before   map[healDiskState]uint64 `json:"before"`
+	After    map[healDiskState]uint64 `json:"after"`
+}
+
+// HealSequenceState - progress of a heal sequence, healing all
+// buckets or the objects of a bucket under a prefix.
+type HealSequenceState struct {
+	Token      string             `json:"token"`
+	Bucket     string             `json:"bucket,omitempty"`
+	Prefix     string             `json:"prefix,omitempty"`
+	Node       string             `json:"node"`
+	Status     healSequenceStatus `json:"status"`
+	Error      string             `json:"error,omitempty"`
+	StartTime  time.Time          `json:"startTime"`
+	LastUpdate time.Time          `json:"lastUpdate"`
+
+	// Pause after healing each object, throttles the sequence.
+	Delay time.Duration `json:"delay,omitempty"`
+
+	// Bucket being healed and the last object healed in it, a
+	// resumed sequence continues after them.
+	MarkerBucket string `json:"markerBucket,omitempty"`
+	MarkerObject string `json:"markerObject,omitempty"`
+
+	BucketsScanned uint64          `json:"bucketsScanned"`
+	BucketsFailed  uint64          `json:"bucketsFailed"`
+	ObjectsScanned uint64          `json:"objectsScanned"`
+	ObjectsHealed  uint64          `json:"objectsHealed"`
+	ObjectsFailed  uint64          `json:"objectsFailed"`
+	Disks          []HealDiskStats `json:"disks,omitempty"`
+}
+
+// addObject - counts an object scanned by the sequence.
+func (s *HealSequenceState) addObject(object string, result HealObjectResult, err error) {
+	s.MarkerObject = object
+	s.ObjectsScanned++
+	if err != nil {
+		s.ObjectsFailed++
+		return
+	}
+	if result.healedDisks() > 0 {
+		s.ObjectsHealed++
+	}
+	// Disks of all sets are listed set by set, all sets have the
+	// same number of disks.
+	offset := result.Set * len(result.Disks)
+	for len(s.Disks) < offset+len(result.Disks) {
+		index := len(s.Disks)
+		s.Disks = append(s.Disks, HealDiskStats{
+			Set:    index / len(result.Disks),
+			Disk:   index % len(result.Disks),
+			Before: make(map[healDiskState]uint64),
+			After:  make(map[healDiskState]uint64),
+		})
+	}
+	for index, disk := range result.Disks {
+		stats := &s.Disks[offset+index]
+		// Offline disks have no endpoint.
+		if disk != "" {
+			stats.Endpoint = disk
+		}
+		stats.Before[result.Before[index]]++
+		stats.After[result.After[index]]++
+	}
+}
+
+// healSequenceLayer - an object layer healed by heal sequences.
+type healSequenceLayer interface {
+	ObjectLayer
+	listObjectsHealWalk(bucket, prefix, marker, delimiter string, maxKeys int) (ListObjectsInfo, error)
+}
+
+// healSequence - a heal sequence running on this server.
+type healSequence struct {
+	sync.Mutex
+	state    HealSequenceState
+	lastSave time.Time
+
+	// Closed to stop the sequence, and by the sequence once it
+	// has saved its final state.
+	stopCh chan struct{}
+	doneCh chan struct{}
+}
+
+// getState - returns the current state of the sequence.
+func (seq *healSequence) getState() HealSequenceState {
+	seq.Lock()
+	defer seq.Unlock()
+	state := seq.state
+	state.Disks = make([]HealDiskStats, len(seq.state.Disks))
+	for index, disk := range seq.state.Disks {
+		state.Disks[index] = HealDiskStats{
+			Set:      disk.Set,
+			Disk:     disk.Disk,
+			Endpoint: disk.Endpoint,
+			Before:   make(map[healDiskState]uint64),
+			After:    make(map[healDiskState]uint64),
+		}
+		for diskState, count := range disk.Before {
+			state.Disks[index].Before[diskState] = count
+		}
+		for diskState, count := range disk.After {
+			state.Disks[index].After[diskState] = count
+		}
+	}
+	return state
+}
+
+// update - applies a change to the state of the sequence, the state
+// is saved once every save interval.
+func (seq *healSequence) update(objAPI ObjectLayer, change func(*HealSequenceState)) {
+	seq.Lock()
+	change(&seq.state)
+	now := UTCNow()
+	seq.state.LastUpdate = now
+	save := now.Sub(seq.lastSave) >= healSequenceSaveInterval
+	if save {
+		seq.lastSave = now
+	}
+	seq.Unlock()
+
+	if save {
+		errorIf(saveHealSequenceState(objAPI, seq.getState()), "Unable to save heal sequence %s.", seq.state.Token)
+	}
+}
+
+// stopped - returns whether the sequence was asked to stop.
+func (seq *healSequence) stopped() bool {
+	select {
+	case <-seq.stopCh:
+		return true
+	default:
+		return false
+	}
+}
+
+// run - heals the buckets and objects of the sequence, then saves
+// its final state.
+func (seq *healSequence) run(objAPI healSequenceLayer) {
+	defer close(seq.doneCh)
+
+	err := seq.heal(objAPI)
+	seq.update(objAPI, func(state *HealSequenceState) {
+		switch {
+		case err == errHealSequenceStopped:
+			state.Status = healSequenceStopped
+		case err != nil:
+			state.Status = healSequenceFailed
+			state.Error = err.Error()
+		default:
+			state.Status = healSequenceFinished
+		}
+	})
+	errorIf(saveHealSequenceState(objAPI, seq.getState()), "Unable to save heal sequence %s.", seq.state.Token)
+}
+
+// heal - heals the buckets of the sequence in order, starting with
+// its marker bucket, then the previous versions of their objects.
+func (seq *healSequence) heal(objAPI healSequenceLayer) error {
+	state := seq.getState()
+
+	var buckets []string
+	if state.Bucket != "" {
+		buckets = []string{state.Bucket}
+	} else {
+		bucketsInfo, err := objAPI.ListBuckets()
+		if err != nil {
+			return errorCause(err)
+		}
+		for _, bucketInfo := range bucketsInfo {
+			buckets = append(buckets, bucketInfo.Name)
+		}
+	}
+
+	// Sequences resumed while healing previous versions are done
+	// with the buckets.
+	healingVersions := state.MarkerBucket == minioMetaVersionsBucket
+	for _, bucket := range buckets {
+		if healingVersions || bucket < state.MarkerBucket {
+			continue
+		}
+		marker := ""
+		if bucket == state.MarkerBucket {
+			marker = state.MarkerObject
+		}
+		if err := seq.healBucket(objAPI, bucket, state.Prefix, marker, state.Delay); err != nil {
+			return err
+		}
+	}
+
+	// Previous versions are objects of `.minio.sys/versions`, under
+	// the bucket and object name they are a version of.
+	marker := ""
+	if healingVersions {
+		marker = state.MarkerObject
+	} else {
+		seq.update(objAPI, func(state *HealSequenceState) {
+			state.MarkerBucket = minioMetaVersionsBucket
+			state.MarkerObject = ""
+		})
+	}
+	prefix := ""
+	if state.Bucket != "" {
+		prefix = state.Bucket + slashSeparator + state.Prefix
+	}
+	return seq.healObjects(objAPI, minioMetaVersionsBucket, prefix, marker, state.Delay)
+}
+
+// healBucket - heals a bucket and its objects under prefix after
+// marker, pausing for delay after each object. The bucket itself is
+// only healed without marker.
+func (seq *healSequence) healBucket(objAPI healSequenceLayer, bucket, prefix, marker string, delay time.Duration) error {
+	if seq.stopped() {
+		return errHealSequenceStopped
+	}
+
+	if marker == "" {
+		err := objAPI.HealBucket(bucket)
+		if _, ok := errorCause(err).(BucketNotFound); ok {
+			// Buckets removed during the sequence are skipped.
+			return nil
+		}
+		seq.update(objAPI, func(state *HealSequenceState) {
+			state.MarkerBucket = bucket
+			state.MarkerObject = ""
+			if err != nil {
+				state.BucketsFailed++
+			}
+		})
+	}
+
+	if err := seq.healObjects(objAPI, bucket, prefix, marker, delay); err != nil {
+		return err
+	}
+
+	seq.update(objAPI, func(state *HealSequenceState) {
+		state.BucketsScanned++
+	})
+	return nil
+}
+
+// healObjects - heals the objects of a bucket under prefix after
+// marker, pausing for delay after each object.
+func (seq *healSequence) healObjects(objAPI healSequenceLayer, bucket, prefix, marker string, delay time.Duration) error {
+	for {
+		if seq.stopped() {
+			return errHealSequenceStopped
+		}
+		result, err := objAPI.listObjectsHealWalk(bucket, prefix, marker, "", maxObjectList)
+		if err != nil {
+			if _, ok := errorCause(err).(BucketNotFound); ok {
+				return nil
+			}
+			return errorCause(err)
+		}
+		for _, objInfo := range result.Objects {
+			if seq.stopped() {
+				return errHealSequenceStopped
+			}
+			healResult, err := objAPI.HealObjectDisks(bucket, objInfo.Name)
+			if isErrObjectNotFound(err) {
+				// Objects removed during the sequence are skipped.
+				continue
+			}
+			seq.update(objAPI, func(state *HealSequenceState) {
+				state.addObject(objInfo.Name, healResult, err)
+			})
+			if delay > 0 {
+				select {
+				case <-time.After(delay):
+				case <-seq.stopCh:
+					return errHealSequenceStopped
+				}
+			}
+		}
+		if !result.IsTruncated {
+			return nil
+		}
+		marker = result.NextMarker
+	}
+}
+
+// healSequences - heal sequences running on this server, by token.
+type healSequences struct {
+	sync.Mutex
+	sequences map[string]*healSequence
+}
+
+// newHealSequences - returns an empty set of heal sequences.
+func newHealSequences() *healSequences {
+	return &healSequences{sequences: make(map[string]*healSequence)}
+}
+
+// start - starts running a heal sequence from its state, fails if a
+// sequence on the same bucket and prefix is running already.
+func (hs *healSequences) start(objAPI healSequenceLayer, state HealSequenceState) error {
+	hs.Lock()
+	defer hs.Unlock()
+	for _, seq := range hs.sequences {
+		if seq.state.Bucket == state.Bucket && seq.state.Prefix == state.Prefix {
+			return errHealSequenceRunning
+		}
+	}
+
+	seq := &healSequence{
+		state:    state,
+		lastSave: UTCNow(),
+		stopCh:   make(chan struct{}),
+		doneCh:   make(chan struct{}),
+	}
+	hs.sequences[state.Token] = seq
+	go func() {
+		seq.run(objAPI)
+		hs.Lock()
+		delete(hs.sequences, state.Token)
+		hs.Unlock()
+	}()
+	return nil
+}
+
+// get - returns the running heal sequence with the given token.
+func (hs *healSequences) get(token string) (*healSequence, bool) {
+	hs.Lock()
+	defer hs.Unlock()
+	seq, ok := hs.sequences[token]
+	return seq, ok
+}
+
+// stop - stops the running heal sequence with the given token and
+// returns its final state.
+func (hs *healSequences) stop(token string) (HealSequenceState, bool) {
+	seq, ok := hs.get(token)
+	if !ok {
+		return HealSequenceState{}, false
+	}
+	seq.Lock()
+	if !seq.stopped() {
+		close(seq.stopCh)
+	}
+	seq.Unlock()
+	<-seq.doneCh
+	return seq.getState(), true
+}
+
+// Heal sequences running on this server.
+var globalHealSequences = newHealSequences()
+
+// getHealSequence - returns the state of a heal sequence running on
+// this server, the sequence is stopped first with stop.
+func getHealSequence(token string, stop bool) (HealSequenceState, bool) {
+	if stop {
+		return globalHealSequences.stop(token)
+	}
+	seq, ok := globalHealSequences.get(token)
+	if !ok {
+		return HealSequenceState{}, false
+	}
+	return seq.getState(), true
+}
+
+// isValidHealSequenceToken - returns whether a token was issued for a
+// heal sequence, tokens name the saved states of sequences.
+func isValidHealSequenceToken(token string) bool {
+	_, err := uuid.Parse(token)
+	return err == nil
+}
+
+// healSequenceStatePath - returns the path of the saved state of a
+// heal sequence in the minio meta bucket.
+func healSequenceStatePath(token string) string {
+	return pathJoin(healSequencesPrefix, token+".json")
+}
+
+// readHealSequenceState - reads the saved state of a heal sequence.
+func readHealSequenceState(objAPI ObjectLayer, token string) (HealSequenceState, error) {
+	var state HealSequenceState
+
+	// Acquire a read lock on the state before reading.
+	statePath := healSequenceStatePath(token)
+	objLock := globalNSMutex.NewNSLock(minioMetaBucket, statePath)
+	objLock.RLock()
+	defer objLock.RUnlock()
+
+	var buffer bytes.Buffer
+	if err := objAPI.GetObject(minioMetaBucket, statePath, 0, -1, &buffer); err != nil {
+		if isErrObjectNotFound(err) || isErrIncompleteBody(err) {
+			return state, errNoSuchHealSequence
+		}
+		return state, errorCause(err)
+	}
+
+	err := json.Unmarshal(buffer.Bytes(), &state)
+	return state, err
+}
+
+// saveHealSequenceState - saves the state of a heal sequence.
+func saveHealSequenceState(objAPI ObjectLayer, state HealSequenceState) error {
+	buf, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	// Acquire a write lock on the state before modifying.
+	statePath := healSequenceStatePath(state.Token)
+	objLock := globalNSMutex.NewNSLock(minioMetaBucket, statePath)
+	objLock.Lock()
+	defer objLock.Unlock()
+
+	if _, err = objAPI.PutObject(minioMetaBucket, statePath, int64(len(buf)), bytes.NewReader(buf), nil, ""); err != nil {
+		return errorCause(err)
+	}
+	return nil
+}
+
+// listHealSequenceStates - returns the saved states of all heal
+// sequences.
+func listHealSequenceStates(objAPI ObjectLayer) ([]HealSequenceState, error) {
+	var states []HealSequenceState
+	marker := ""
+	for {
+		result, err := objAPI.ListObjects(minioMetaBucket, healSequencesPrefix+slashSeparator, marker, "", maxObjectList)
+		if err != nil {
+			return nil, errorCause(err)
+		}
+		for _, objInfo := range result.Objects {
+			token := strings.TrimSuffix(strings.TrimPrefix(objInfo.Name, healSequencesPrefix+slashSeparator), ".json")
+			state, err := readHealSequenceState(objAPI, token)
+			if err == errNoSuchHealSequence {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			states = append(states, state)
+		}
+		if !result.IsTruncated {
+			return states, nil
+		}
+		marker = result.NextMarker
+	}
+}
+
+// newHealSequenceState - returns the initial state of a heal sequence
+// of the objects of a bucket under a prefix, or of all buckets without
+// bucket, run by this server.
+func newHealSequenceState(bucket, prefix string) HealSequenceState {
+	now := UTCNow()
+	return HealSequenceState{
+		Token:      mustGetUUID(),
+		Bucket:     bucket,
+		Prefix:     prefix,
+		Node:       GetLocalPeer(globalEndpoints),
+		Status:     healSequenceRunning,
+		StartTime:  now,
+		LastUpdate: now,
+	}
+}
+
+// startHealSequence - starts a heal sequence from its initial state on
+// this server. The state of the sequence is saved before it starts, so
+// that it is resumed when this server restarts.
+func startHealSequence(objAPI ObjectLayer, state HealSequenceState) (HealSequenceState, error) {
+	healer, ok := objAPI.(healSequenceLayer)
+	if !ok {
+		return HealSequenceState{}, traceError(NotImplemented{})
+	}
+
+	if err := saveHealSequenceState(objAPI, state); err != nil {
+		return state, err
+	}
+	if err := globalHealSequences.start(healer, state); err != nil {
+		state.Status = healSequenceFailed
+		state.Error = err.Error()
+		errorIf(saveHealSequenceState(objAPI, state), "Unable to save heal sequence %s.", state.Token)
+		return state, err
+	}
+	return state, nil
+}
+
+// resumeHealSequences - resumes the heal sequences of this server which
+// were running when it stopped.
+func resumeHealSequences(objAPI ObjectLayer) error {
+	healer, ok := objAPI.(healSequenceLayer)
+	if !ok {
+		return nil
+	}
+
+	states, err := listHealSequenceStates(objAPI)
+	if err != nil {
+		return err
+	}
+	node := GetLocalPeer(globalEndpoints)
+	for _, state := range states {
+		if state.Status != healSequenceRunning || state.Node != node {
+			continue
+		}
+		if err = globalHealSequences.start(healer, state); err != nil {
+			errorIf(err, "Unable to resume heal sequence %s.", state.Token)
+		}
+	}
+	return nil
+}
+
+// startHealSequences - starts the background routine which resumes the
+// heal sequences of this server.
+func startHealSequences(objAPI ObjectLayer) {
+	go func() {
+		errorIf(resumeHealSequences(objAPI), "Unable to resume heal sequences.")
+	}()
+}
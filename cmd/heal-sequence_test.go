@@ -0,0 +1,498 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	router "github.com/gorilla/mux"
+	"github.com/minio/minio/pkg/madmin"
+)
+
+// Prepares an XL object layer with objects obj1 and obj2 in buckets
+// bucket1 and bucket2.
+func prepareHealSequenceXL(t *testing.T) (*xlObjects, []string) {
+	obj, fsDirs, err := prepareXL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, bucket := range []string{"bucket1", "bucket2"} {
+		if err = obj.MakeBucketWithLocation(bucket, ""); err != nil {
+			t.Fatal(err)
+		}
+		for _, object := range []string{"obj1", "obj2"} {
+			data := []byte(fmt.Sprintf("%s/%s", bucket, object))
+			if _, err = obj.PutObject(bucket, object, int64(len(data)), bytes.NewReader(data), nil, ""); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	return obj.(*xlObjects), fsDirs
+}
+
+// Waits for the saved state of a heal sequence to be done.
+func waitHealSequence(t *testing.T, objAPI ObjectLayer, token string) HealSequenceState {
+	for i := 0; i < 500; i++ {
+		state, err := readHealSequenceState(objAPI, token)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if state.Status != healSequenceRunning {
+			return state
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("Timed out waiting for the heal sequence")
+	return HealSequenceState{}
+}
+
+// Tests the states of an object on each disk before and after healing.
+func TestHealObjectDisks(t *testing.T) {
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(rootPath)
+
+	xl, fsDirs := prepareHealSequenceXL(t)
+	defer removeRoots(fsDirs)
+
+	// Remove xl.json of the object on the first disk, its part on the
+	// second disk and take the third disk offline.
+	if err = xl.storageDisks[0].DeleteFile("bucket1", filepath.Join("obj1", xlMetaJSONFile)); err != nil {
+		t.Fatal(err)
+	}
+	if err = xl.storageDisks[1].DeleteFile("bucket1", filepath.Join("obj1", "part.1")); err != nil {
+		t.Fatal(err)
+	}
+	xl.storageDisks[2] = nil
+
+	result, err := xl.HealObjectDisks("bucket1", "obj1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []struct {
+		before, after healDiskState
+	}{
+		{healDiskMissing, healDiskOK},
+		{healDiskOutdated, healDiskOK},
+		{healDiskOffline, healDiskOffline},
+		{healDiskOK, healDiskOK},
+	}
+	for index, states := range expected {
+		if result.Before[index] != states.before || result.After[index] != states.after {
+			t.Errorf("Disk %d: Expected %s to %s, got %s to %s", index+1, states.before, states.after,
+				result.Before[index], result.After[index])
+		}
+	}
+	if result.Disks[0] != xl.storageDisks[0].String() || result.Disks[2] != "" {
+		t.Errorf("Unexpected disks %v", result.Disks)
+	}
+	if result.healedDisks() != 2 || result.offlineDisks() != 1 {
+		t.Errorf("Expected 2 healed and 1 offline disks, got %d and %d", result.healedDisks(), result.offlineDisks())
+	}
+
+	// Healthy objects are left as they are.
+	if result, err = xl.HealObjectDisks("bucket1", "obj1"); err != nil {
+		t.Fatal(err)
+	}
+	if result.healedDisks() != 0 || result.Before[0] != healDiskOK || result.After[1] != healDiskOK {
+		t.Errorf("Unexpected result %+v", result)
+	}
+}
+
+// Tests healing all buckets with a heal sequence.
+func TestHealSequence(t *testing.T) {
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(rootPath)
+
+	xl, fsDirs := prepareHealSequenceXL(t)
+	defer removeRoots(fsDirs)
+
+	for _, bucket := range []string{"bucket1", "bucket2"} {
+		if err = xl.storageDisks[0].DeleteFile(bucket, filepath.Join("obj2", xlMetaJSONFile)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	state, err := startHealSequence(xl, newHealSequenceState("", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	state = waitHealSequence(t, xl, state.Token)
+	if state.Status != healSequenceFinished || state.BucketsScanned != 2 || state.BucketsFailed != 0 ||
+		state.ObjectsScanned != 4 || state.ObjectsHealed != 2 || state.ObjectsFailed != 0 {
+		t.Fatalf("Unexpected heal sequence state %+v", state)
+	}
+	if state.MarkerBucket != minioMetaVersionsBucket || state.MarkerObject != "" {
+		t.Errorf("Expected marker at the start of the versions, got %s/%s", state.MarkerBucket, state.MarkerObject)
+	}
+	if len(state.Disks) != len(xl.storageDisks) {
+		t.Fatalf("Expected stats of %d disks, got %d", len(xl.storageDisks), len(state.Disks))
+	}
+	disk := state.Disks[0]
+	if disk.Endpoint != xl.storageDisks[0].String() || disk.Before[healDiskMissing] != 2 ||
+		disk.Before[healDiskOK] != 2 || disk.After[healDiskOK] != 4 {
+		t.Errorf("Unexpected stats of the first disk %+v", disk)
+	}
+	if disk = state.Disks[1]; disk.Before[healDiskOK] != 4 || disk.After[healDiskOK] != 4 {
+		t.Errorf("Unexpected stats of the second disk %+v", disk)
+	}
+	if _, err = xl.storageDisks[0].StatFile("bucket2", filepath.Join("obj2", xlMetaJSONFile)); err != nil {
+		t.Errorf("Expected xl.json to be healed, got %v", err)
+	}
+
+	// Sequences of a bucket under a prefix.
+	if state, err = startHealSequence(xl, newHealSequenceState("bucket1", "obj1")); err != nil {
+		t.Fatal(err)
+	}
+	state = waitHealSequence(t, xl, state.Token)
+	if state.Status != healSequenceFinished || state.BucketsScanned != 1 || state.ObjectsScanned != 1 {
+		t.Errorf("Unexpected heal sequence state %+v", state)
+	}
+}
+
+// Tests that heal sequences heal the previous versions of objects.
+func TestHealSequenceVersions(t *testing.T) {
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(rootPath)
+
+	xl, fsDirs := prepareHealSequenceXL(t)
+	defer removeRoots(fsDirs)
+
+	if err = initBucketVersioning(xl); err != nil {
+		t.Fatal(err)
+	}
+	globalBucketVersioning.SetBucketVersioning("bucket1", &versioningConfiguration{Status: versioningEnabled})
+	defer globalBucketVersioning.SetBucketVersioning("bucket1", nil)
+
+	// Archive the previous versions of obj1, removing xl.json of
+	// one of them from the first disk.
+	var versionIDs []string
+	for _, data := range []string{"v1", "v2"} {
+		objInfo, perr := xl.PutObject("bucket1", "obj1", int64(len(data)), bytes.NewReader([]byte(data)), nil, "")
+		if perr != nil {
+			t.Fatal(perr)
+		}
+		versionIDs = append(versionIDs, objInfo.VersionID)
+	}
+	versionPath := xlVersionPath("bucket1", "obj1", versionIDs[0])
+	if err = xl.storageDisks[0].DeleteFile(minioMetaVersionsBucket, pathJoin(versionPath, xlMetaJSONFile)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Sequences of another bucket leave the version alone.
+	state, err := startHealSequence(xl, newHealSequenceState("bucket2", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state = waitHealSequence(t, xl, state.Token); state.ObjectsHealed != 0 {
+		t.Fatalf("Unexpected heal sequence state %+v", state)
+	}
+
+	state, err = startHealSequence(xl, newHealSequenceState("bucket1", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	state = waitHealSequence(t, xl, state.Token)
+	if state.Status != healSequenceFinished || state.ObjectsHealed != 1 {
+		t.Fatalf("Unexpected heal sequence state %+v", state)
+	}
+	if state.MarkerBucket != minioMetaVersionsBucket || !hasPrefix(state.MarkerObject, "bucket1/obj1/") {
+		t.Errorf("Expected marker at a version of bucket1/obj1, got %s/%s", state.MarkerBucket, state.MarkerObject)
+	}
+	if _, err = xl.storageDisks[0].StatFile(minioMetaVersionsBucket, pathJoin(versionPath, xlMetaJSONFile)); err != nil {
+		t.Errorf("Expected xl.json of the version to be healed, got %v", err)
+	}
+}
+
+// Tests that a heal sequence over erasure sets counts the objects of
+// each disk of each set.
+func TestHealSequenceSets(t *testing.T) {
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(rootPath)
+
+	fsDirs, err := getRandomDisks(32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeRoots(fsDirs)
+	obj, _, err := initObjectLayer(mustGetNewEndpointList(fsDirs...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := obj.(*xlSets)
+
+	// An object in each set, missing xl.json on the first disk of
+	// its set.
+	if err = obj.MakeBucketWithLocation("bucket", ""); err != nil {
+		t.Fatal(err)
+	}
+	setObjects := make(map[int]string)
+	for i := 0; len(setObjects) < len(s.sets); i++ {
+		object := fmt.Sprintf("obj%d", i)
+		if _, ok := setObjects[s.getHashedSetIndex(object)]; !ok {
+			setObjects[s.getHashedSetIndex(object)] = object
+		}
+	}
+	for index, object := range setObjects {
+		if _, err = obj.PutObject("bucket", object, int64(len(object)), bytes.NewReader([]byte(object)), nil, ""); err != nil {
+			t.Fatal(err)
+		}
+		if err = s.sets[index].storageDisks[0].DeleteFile("bucket", filepath.Join(object, xlMetaJSONFile)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	state, err := startHealSequence(s, newHealSequenceState("", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	state = waitHealSequence(t, s, state.Token)
+	if state.Status != healSequenceFinished || state.ObjectsScanned != 2 || state.ObjectsHealed != 2 {
+		t.Fatalf("Unexpected heal sequence state %+v", state)
+	}
+	if len(state.Disks) != len(fsDirs) {
+		t.Fatalf("Expected stats of %d disks, got %d", len(fsDirs), len(state.Disks))
+	}
+	for index, disk := range state.Disks {
+		set, position := index/s.setDriveCount, index%s.setDriveCount
+		if disk.Set != set || disk.Disk != position ||
+			disk.Endpoint != s.sets[set].storageDisks[position].String() {
+			t.Fatalf("Disk %d: expected disk %d of set %d, got %+v", index, position, set, disk)
+		}
+		var missing uint64
+		if position == 0 {
+			missing = 1
+		}
+		if disk.Before[healDiskMissing] != missing || disk.Before[healDiskOK] != 1-missing ||
+			disk.After[healDiskOK] != 1 {
+			t.Errorf("Disk %d: unexpected stats %+v", index, disk)
+		}
+	}
+}
+
+// Tests resuming the heal sequences saved as running.
+func TestResumeHealSequences(t *testing.T) {
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(rootPath)
+
+	xl, fsDirs := prepareHealSequenceXL(t)
+	defer removeRoots(fsDirs)
+
+	node := GetLocalPeer(globalEndpoints)
+	states := []HealSequenceState{
+		{Token: mustGetUUID(), Node: node, Status: healSequenceRunning, MarkerBucket: "bucket1", MarkerObject: "obj1"},
+		{Token: mustGetUUID(), Node: node, Status: healSequenceStopped},
+		{Token: mustGetUUID(), Node: "other:9000", Status: healSequenceRunning, Bucket: "bucket2"},
+	}
+	for _, state := range states {
+		if err = saveHealSequenceState(xl, state); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err = resumeHealSequences(xl); err != nil {
+		t.Fatal(err)
+	}
+
+	// The running sequence continues after its marker, the bucket of
+	// the marker is not healed again.
+	state := waitHealSequence(t, xl, states[0].Token)
+	if state.Status != healSequenceFinished || state.ObjectsScanned != 3 || state.BucketsScanned != 2 {
+		t.Errorf("Unexpected heal sequence state %+v", state)
+	}
+
+	// Sequences which are stopped or run on other servers are not
+	// resumed.
+	if _, found := getHealSequence(states[2].Token, false); found {
+		t.Errorf("Expected the sequence of another server not to be resumed")
+	}
+	for _, s := range states[1:] {
+		if state, err = readHealSequenceState(xl, s.Token); err != nil {
+			t.Fatal(err)
+		}
+		if state.Status != s.Status || state.ObjectsScanned != 0 {
+			t.Errorf("Unexpected heal sequence state %+v", state)
+		}
+	}
+}
+
+// healSequenceTestLayer - an object layer whose listing blocks until
+// it is released.
+type healSequenceTestLayer struct {
+	healSequenceLayer
+	listedCh  chan struct{}
+	releaseCh chan struct{}
+}
+
+func (l healSequenceTestLayer) listObjectsHealWalk(bucket, prefix, marker, delimiter string, maxKeys int) (ListObjectsInfo, error) {
+	close(l.listedCh)
+	<-l.releaseCh
+	return l.healSequenceLayer.listObjectsHealWalk(bucket, prefix, marker, delimiter, maxKeys)
+}
+
+// Tests stopping a running heal sequence.
+func TestStopHealSequence(t *testing.T) {
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(rootPath)
+
+	xl, fsDirs := prepareHealSequenceXL(t)
+	defer removeRoots(fsDirs)
+
+	layer := healSequenceTestLayer{xl, make(chan struct{}), make(chan struct{})}
+	sequences := newHealSequences()
+	state := HealSequenceState{Token: mustGetUUID(), Bucket: "bucket1", Status: healSequenceRunning}
+	if err = sequences.start(layer, state); err != nil {
+		t.Fatal(err)
+	}
+	if err = sequences.start(layer, HealSequenceState{Token: mustGetUUID(), Bucket: "bucket1"}); err != errHealSequenceRunning {
+		t.Errorf("Expected %v, got %v", errHealSequenceRunning, err)
+	}
+
+	// Release the listing once the sequence is asked to stop.
+	<-layer.listedCh
+	seq, ok := sequences.get(state.Token)
+	if !ok {
+		t.Fatal("Expected the sequence to be running")
+	}
+	go func() {
+		<-seq.stopCh
+		close(layer.releaseCh)
+	}()
+	state, found := sequences.stop(state.Token)
+	if !found || state.Status != healSequenceStopped || state.ObjectsScanned != 0 || state.MarkerBucket != "bucket1" {
+		t.Fatalf("Unexpected heal sequence state %+v", state)
+	}
+	if saved, err := readHealSequenceState(xl, state.Token); err != nil || saved.Status != healSequenceStopped {
+		t.Errorf("Expected the stopped state to be saved, got %+v %v", saved, err)
+	}
+	if _, found = sequences.stop(state.Token); found {
+		t.Error("Expected the stopped sequence to be removed")
+	}
+}
+
+// Tests starting, polling and stopping heal sequences through the
+// admin API.
+func TestHealSequenceHandlers(t *testing.T) {
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(rootPath)
+
+	xl, fsDirs := prepareHealSequenceXL(t)
+	defer removeRoots(fsDirs)
+
+	globalObjLayerMutex.Lock()
+	globalObjectAPI = xl
+	globalObjLayerMutex.Unlock()
+	globalIsXL = true
+	defer resetGlobalObjectAPI()
+	defer resetGlobalIsXL()
+
+	initGlobalAdminPeers(mustGetNewEndpointList("http://127.0.0.1:9000/d1"))
+	adminRouter := router.NewRouter()
+	registerAdminRouter(adminRouter)
+
+	server := httptest.NewServer(adminRouter)
+	defer server.Close()
+
+	cred := serverConfig.GetCredential()
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	adminClient, err := madmin.New(u.Host, cred.AccessKey, cred.SecretKey, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state, err := adminClient.StartHealSequence("bucket2", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Token == "" || state.Bucket != "bucket2" {
+		t.Fatalf("Unexpected heal sequence state %+v", state)
+	}
+	for i := 0; state.Status == "running"; i++ {
+		if i == 500 {
+			t.Fatal("Timed out waiting for the heal sequence")
+		}
+		time.Sleep(10 * time.Millisecond)
+		if state, err = adminClient.HealSequenceStatus(state.Token); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if state.Status != "finished" || state.ObjectsScanned != 2 || len(state.Disks) != len(xl.storageDisks) ||
+		state.Disks[0].After["ok"] != 2 {
+		t.Errorf("Unexpected heal sequence state %+v", state)
+	}
+
+	// Stopping a finished sequence returns its final state.
+	if state, err = adminClient.StopHealSequence(state.Token); err != nil || state.Status != "finished" {
+		t.Errorf("Unexpected heal sequence state %+v %v", state, err)
+	}
+
+	// Sequences saved as running which don't run on any server are
+	// stopped by saving them as stopped.
+	orphan := HealSequenceState{Token: mustGetUUID(), Node: "other:9000", Status: healSequenceRunning}
+	if err = saveHealSequenceState(xl, orphan); err != nil {
+		t.Fatal(err)
+	}
+	if state, err = adminClient.StopHealSequence(orphan.Token); err != nil || state.Status != "stopped" {
+		t.Errorf("Unexpected heal sequence state %+v %v", state, err)
+	}
+	if saved, err := readHealSequenceState(xl, orphan.Token); err != nil || saved.Status != healSequenceStopped {
+		t.Errorf("Expected the stopped state to be saved, got %+v %v", saved, err)
+	}
+
+	// Invalid requests.
+	if _, err = adminClient.StartHealSequence("", "prefix"); err == nil {
+		t.Error("Expected an error for a prefix without bucket")
+	}
+	if _, err = adminClient.StartHealSequence("nosuchbucket", ""); err == nil {
+		t.Error("Expected an error for a missing bucket")
+	}
+	if _, err = adminClient.HealSequenceStatus("invalid"); err == nil {
+		t.Error("Expected an error for an invalid token")
+	}
+	if _, err = adminClient.HealSequenceStatus(mustGetUUID()); err == nil {
+		t.Error("Expected an error for an unknown token")
+	}
+}
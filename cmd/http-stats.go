@@ -19,6 +19,8 @@ package cmd
 import (
 	"fmt"
 	"net/http"
+	"sort"
+	"sync"
 	"time"
 
 	"go.uber.org/atomic"
@@ -194,3 +196,54 @@ func (st *HTTPStats) updateStats(r *http.Request, w *httpResponseRecorder, durat
 func newHTTPStats() *HTTPStats {
 	return &HTTPStats{}
 }
+
+// Upper bounds of the request latency histogram buckets, in seconds.
+var apiLatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// apiRequestKey - identifies the requests to an API with a given
+// response status code.
+type apiRequestKey struct {
+	api    string
+	status int
+}
+
+// apiLatency - latency histogram of the requests to an API, counts
+// holds the number of requests per bucket, not cumulated.
+type apiLatency struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// APIStats holds request counts and latencies per API name.
+type APIStats struct {
+	sync.Mutex
+	requests map[apiRequestKey]uint64
+	latency  map[string]*apiLatency
+}
+
+// Prepare new APIStats structure
+func newAPIStats() *APIStats {
+	return &APIStats{
+		requests: make(map[apiRequestKey]uint64),
+		latency:  make(map[string]*apiLatency),
+	}
+}
+
+// Update statistics of an API from the response status and the
+// duration of a request.
+func (st *APIStats) updateStats(api string, status int, durationSecs float64) {
+	st.Lock()
+	defer st.Unlock()
+
+	st.requests[apiRequestKey{api, status}]++
+	latency, ok := st.latency[api]
+	if !ok {
+		latency = &apiLatency{counts: make([]uint64, len(apiLatencyBuckets)+1)}
+		st.latency[api] = latency
+	}
+	i := sort.SearchFloat64s(apiLatencyBuckets, durationSecs)
+	latency.counts[i]++
+	latency.sum += durationSecs
+	latency.count++
+}
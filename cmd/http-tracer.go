@@ -0,0 +1,348 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sync"
+	"time"
+)
+
+const (
+	// Maximum number of bytes of a request or response body
+	// recorded in a trace.
+	traceBodyLimit = 64 * 1024
+
+	// Number of traces buffered for a subscriber, further traces
+	// are dropped until the subscriber catches up.
+	traceSubscriberBufferSize = 1000
+
+	// Time a trace poll waits for the first trace.
+	tracePollTimeout = time.Second
+
+	// Maximum number of traces returned by a trace poll.
+	tracePollMaxTraces = 1000
+
+	// Time after which a trace subscription which is not polled
+	// any more is removed.
+	traceSubscriptionExpiry = 30 * time.Second
+)
+
+// Value replacing redacted secrets in traces.
+const traceRedacted = "*REDACTED*"
+
+// Request headers and query parameters whose values are redacted in
+// traces, the `token` query parameter carries the JWT of browser
+// downloads.
+var (
+	traceRedactedHeaders = []string{"X-Amz-Security-Token", SSECustomerKey, SSECopyCustomerKey}
+	traceRedactedQueries = []string{"X-Amz-Credential", "X-Amz-Signature", "X-Amz-Security-Token", "Signature", "token"}
+)
+
+// Matches the signature of an AWS signature V2 or V4 authorization
+// header.
+var traceAuthSignatureRegexp = regexp.MustCompile(`(Signature=|^AWS [^:]+:)[^,]*`)
+
+// TraceRequestInfo - the request of a traced API call.
+type TraceRequestInfo struct {
+	Method   string      `json:"method"`
+	Path     string      `json:"path"`
+	RawQuery string      `json:"rawQuery,omitempty"`
+	Headers  http.Header `json:"headers"`
+	Body     []byte      `json:"body,omitempty"`
+	Client   string      `json:"client"`
+}
+
+// TraceResponseInfo - the response of a traced API call.
+type TraceResponseInfo struct {
+	StatusCode int         `json:"statusCode"`
+	Headers    http.Header `json:"headers"`
+	Body       []byte      `json:"body,omitempty"`
+}
+
+// TraceInfo - an API call handled by a server.
+type TraceInfo struct {
+	NodeName string            `json:"node"`
+	Time     time.Time         `json:"time"`
+	API      string            `json:"api"`
+	ReqInfo  TraceRequestInfo  `json:"request"`
+	RespInfo TraceResponseInfo `json:"response"`
+	Duration time.Duration     `json:"duration"`
+}
+
+// appendTraceBody - appends the bytes of a body to its trace buffer,
+// up to the body limit of traces.
+func appendTraceBody(buf *bytes.Buffer, p []byte) {
+	if buf == nil || buf.Len() >= traceBodyLimit {
+		return
+	}
+	if len(p) > traceBodyLimit-buf.Len() {
+		p = p[:traceBodyLimit-buf.Len()]
+	}
+	buf.Write(p)
+}
+
+// redactTraceHeaders - returns a copy of request headers with
+// credentials and signatures redacted.
+func redactTraceHeaders(header http.Header) http.Header {
+	redacted := cloneHeader(header)
+	if auth := redacted.Get("Authorization"); auth != "" {
+		if traceAuthSignatureRegexp.MatchString(auth) {
+			redacted.Set("Authorization", traceAuthSignatureRegexp.ReplaceAllString(auth, "${1}"+traceRedacted))
+		} else {
+			redacted.Set("Authorization", traceRedacted)
+		}
+	}
+	for _, key := range traceRedactedHeaders {
+		if redacted.Get(key) != "" {
+			redacted.Set(key, traceRedacted)
+		}
+	}
+	return redacted
+}
+
+// redactTraceQuery - returns a raw query with the credentials and
+// signatures of presigned requests and browser tokens redacted.
+func redactTraceQuery(rawQuery string) string {
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return ""
+	}
+	var redacted bool
+	for _, key := range traceRedactedQueries {
+		if query.Get(key) != "" {
+			query.Set(key, traceRedacted)
+			redacted = true
+		}
+	}
+	if !redacted {
+		return rawQuery
+	}
+	return query.Encode()
+}
+
+// hasTraceSecretBody - returns true for requests whose request or
+// response bodies may carry credentials: admin API calls (server
+// config, root and user credentials), STS calls (temporary
+// credentials), browser calls (login password) and bucket
+// replication configs (remote credentials).
+func hasTraceSecretBody(r *http.Request) bool {
+	if hasPrefix(r.URL.Path, minioReservedBucketPath) {
+		return true
+	}
+	if r.Method == httpPOST && isSTSRequest(r, nil) {
+		return true
+	}
+	query := r.URL.Query()
+	for _, resource := range auditAdminResources {
+		if _, ok := query[resource]; ok {
+			return true
+		}
+	}
+	_, ok := query["replication"]
+	return ok
+}
+
+// newTraceInfo - returns the trace of an API call. Bodies are only
+// recorded for S3 requests without credentials in their bodies.
+func newTraceInfo(r *http.Request, w *httpResponseRecorder, reqBody *bytes.Buffer, start time.Time, duration time.Duration) TraceInfo {
+	bucket, object := getRequestBucketObject(r)
+	api := getAuditAPIName(r, bucket, object)
+	status := w.respStatusCode
+	if status == 0 {
+		status = http.StatusOK
+	}
+	info := TraceInfo{
+		Time: start,
+		API:  api,
+		ReqInfo: TraceRequestInfo{
+			Method:   r.Method,
+			Path:     r.URL.Path,
+			RawQuery: redactTraceQuery(r.URL.RawQuery),
+			Headers:  redactTraceHeaders(r.Header),
+			Client:   r.RemoteAddr,
+		},
+		RespInfo: TraceResponseInfo{
+			StatusCode: status,
+			Headers:    cloneHeader(w.Header()),
+		},
+		Duration: duration,
+	}
+	if r.Host != "" {
+		info.ReqInfo.Headers.Set("Host", r.Host)
+	}
+	if !hasTraceSecretBody(r) {
+		if reqBody != nil {
+			info.ReqInfo.Body = reqBody.Bytes()
+		}
+		if w.traceBody != nil {
+			info.RespInfo.Body = w.traceBody.Bytes()
+		}
+	}
+	return info
+}
+
+// traceSubscriber - receives the traces of the API calls handled by
+// this server.
+type traceSubscriber struct {
+	traceCh  chan TraceInfo
+	withBody bool
+}
+
+// httpTracer - publishes the traces of the API calls handled by this
+// server to its subscribers.
+type httpTracer struct {
+	sync.RWMutex
+	subscribers map[*traceSubscriber]struct{}
+}
+
+// newHTTPTracer - returns a tracer without subscribers.
+func newHTTPTracer() *httpTracer {
+	return &httpTracer{subscribers: make(map[*traceSubscriber]struct{})}
+}
+
+// subscribe - returns a new subscriber of the traces.
+func (t *httpTracer) subscribe(withBody bool) *traceSubscriber {
+	s := &traceSubscriber{
+		traceCh:  make(chan TraceInfo, traceSubscriberBufferSize),
+		withBody: withBody,
+	}
+	t.Lock()
+	t.subscribers[s] = struct{}{}
+	t.Unlock()
+	return s
+}
+
+// unsubscribe - stops publishing traces to a subscriber.
+func (t *httpTracer) unsubscribe(s *traceSubscriber) {
+	t.Lock()
+	delete(t.subscribers, s)
+	t.Unlock()
+}
+
+// subscribed - returns whether the tracer has any subscribers and
+// whether any of them wants bodies.
+func (t *httpTracer) subscribed() (subscribed, withBody bool) {
+	t.RLock()
+	defer t.RUnlock()
+	for s := range t.subscribers {
+		if s.withBody {
+			return true, true
+		}
+	}
+	return len(t.subscribers) > 0, false
+}
+
+// publish - sends a trace to all subscribers, the trace is dropped
+// for subscribers which are not keeping up.
+func (t *httpTracer) publish(info TraceInfo) {
+	noBody := info
+	noBody.ReqInfo.Body = nil
+	noBody.RespInfo.Body = nil
+
+	t.RLock()
+	defer t.RUnlock()
+	for s := range t.subscribers {
+		trace := noBody
+		if s.withBody {
+			trace = info
+		}
+		select {
+		case s.traceCh <- trace:
+		default:
+		}
+	}
+}
+
+// traceSubscription - a subscription of a trace client which polls
+// the traces of this server, locally or via RPC.
+type traceSubscription struct {
+	subscriber *traceSubscriber
+	expiry     *time.Timer
+}
+
+// traceSubscriptions - subscriptions of the trace clients polling
+// this server, by subscription ID.
+type traceSubscriptions struct {
+	sync.Mutex
+	tracer        *httpTracer
+	subscriptions map[string]*traceSubscription
+}
+
+// newTraceSubscriptions - returns the subscriptions of clients to
+// the traces of a tracer.
+func newTraceSubscriptions(tracer *httpTracer) *traceSubscriptions {
+	return &traceSubscriptions{
+		tracer:        tracer,
+		subscriptions: make(map[string]*traceSubscription),
+	}
+}
+
+// get - returns the subscription with the given ID, which is created
+// if it doesn't exist. Subscriptions expire when they are not polled.
+func (ts *traceSubscriptions) get(id string, withBody bool) *traceSubscriber {
+	ts.Lock()
+	defer ts.Unlock()
+	if sub, ok := ts.subscriptions[id]; ok {
+		sub.expiry.Reset(traceSubscriptionExpiry)
+		return sub.subscriber
+	}
+	sub := &traceSubscription{subscriber: ts.tracer.subscribe(withBody)}
+	sub.expiry = time.AfterFunc(traceSubscriptionExpiry, func() {
+		ts.Lock()
+		defer ts.Unlock()
+		if ts.subscriptions[id] == sub {
+			delete(ts.subscriptions, id)
+			ts.tracer.unsubscribe(sub.subscriber)
+		}
+	})
+	ts.subscriptions[id] = sub
+	return sub.subscriber
+}
+
+// poll - returns the traces published since the last poll of a
+// subscription, waiting for the first trace up to the poll timeout.
+func (ts *traceSubscriptions) poll(id string, withBody bool) []TraceInfo {
+	s := ts.get(id, withBody)
+
+	var traces []TraceInfo
+	select {
+	case trace := <-s.traceCh:
+		traces = append(traces, trace)
+	case <-time.After(tracePollTimeout):
+		return nil
+	}
+	for len(traces) < tracePollMaxTraces {
+		select {
+		case trace := <-s.traceCh:
+			traces = append(traces, trace)
+		default:
+			return traces
+		}
+	}
+	return traces
+}
+
+// Variables holding the tracer of this server and the subscriptions
+// of the clients polling it.
+var (
+	globalHTTPTracer         = newHTTPTracer()
+	globalTraceSubscriptions = newTraceSubscriptions(globalHTTPTracer)
+)
@@ -0,0 +1,301 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	router "github.com/gorilla/mux"
+	"github.com/minio/minio/pkg/madmin"
+)
+
+// Tests redaction of credentials and signatures in traced requests.
+func TestRedactTraceRequest(t *testing.T) {
+	headerTestCases := []struct {
+		auth     string
+		expected string
+	}{
+		{
+			"AWS4-HMAC-SHA256 Credential=minio/20171106/us-east-1/s3/aws4_request, SignedHeaders=host;x-amz-date, Signature=0123456789abcdef",
+			"AWS4-HMAC-SHA256 Credential=minio/20171106/us-east-1/s3/aws4_request, SignedHeaders=host;x-amz-date, Signature=*REDACTED*",
+		},
+		{"AWS minio:c2lnbmF0dXJl", "AWS minio:*REDACTED*"},
+		{"Bearer eyJhbGciOiJIUzUxMiJ9", "*REDACTED*"},
+	}
+	for i, testCase := range headerTestCases {
+		header := http.Header{}
+		header.Set("Authorization", testCase.auth)
+		header.Set("X-Amz-Security-Token", "token")
+		header.Set(SSECustomerKey, "MzJieXRlc2xvbmdzZWNyZXRrZXltdXN0cHJvdmlkZWQ=")
+		header.Set(SSECopyCustomerKey, "MzJieXRlc2xvbmdzZWNyZXRrZXltdXN0cHJvdmlkZWQ=")
+		header.Set("Content-Type", "text/plain")
+		redacted := redactTraceHeaders(header)
+		if auth := redacted.Get("Authorization"); auth != testCase.expected {
+			t.Errorf("Test %d: Expected authorization %q, got %q", i+1, testCase.expected, auth)
+		}
+		if token := redacted.Get("X-Amz-Security-Token"); token != traceRedacted {
+			t.Errorf("Test %d: Expected redacted security token, got %q", i+1, token)
+		}
+		if key := redacted.Get(SSECustomerKey); key != traceRedacted {
+			t.Errorf("Test %d: Expected redacted SSE-C key, got %q", i+1, key)
+		}
+		if key := redacted.Get(SSECopyCustomerKey); key != traceRedacted {
+			t.Errorf("Test %d: Expected redacted SSE-C copy source key, got %q", i+1, key)
+		}
+		if contentType := redacted.Get("Content-Type"); contentType != "text/plain" {
+			t.Errorf("Test %d: Expected content type to be kept, got %q", i+1, contentType)
+		}
+		if header.Get("Authorization") != testCase.auth {
+			t.Errorf("Test %d: Expected the request headers to be unchanged", i+1)
+		}
+	}
+
+	if rawQuery := redactTraceQuery("prefix=photos&max-keys=10"); rawQuery != "prefix=photos&max-keys=10" {
+		t.Errorf("Expected query without credentials to be unchanged, got %q", rawQuery)
+	}
+	query, err := url.ParseQuery(redactTraceQuery("X-Amz-Credential=minio%2F20171106&X-Amz-Signature=0123456789abcdef&X-Amz-Expires=60"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query.Get("X-Amz-Credential") != traceRedacted || query.Get("X-Amz-Signature") != traceRedacted ||
+		query.Get("X-Amz-Expires") != "60" {
+		t.Errorf("Unexpected redacted query %v", query)
+	}
+	// Browser downloads are authenticated by a token in the query.
+	if query, err = url.ParseQuery(redactTraceQuery("token=eyJhbGciOiJIUzUxMiJ9.e30.c2lnbmF0dXJl")); err != nil {
+		t.Fatal(err)
+	}
+	if query.Get("token") != traceRedacted {
+		t.Errorf("Unexpected redacted query %v", query)
+	}
+}
+
+// Tests publishing traces of requests to subscribers.
+func TestHTTPTracer(t *testing.T) {
+	defer func(tracer *httpTracer) { globalHTTPTracer = tracer }(globalHTTPTracer)
+	globalHTTPTracer = newHTTPTracer()
+
+	handler := setHTTPStatsHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ioutil.ReadAll(r.Body)
+		w.Header().Set("ETag", "\"etag\"")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("hello, world"))
+	}))
+	sendRequest := func(urlStr string) {
+		req, err := http.NewRequest("PUT", urlStr, strings.NewReader("traced"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Authorization", "Bearer token")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	// Requests are not traced without subscribers.
+	if subscribed, _ := globalHTTPTracer.subscribed(); subscribed {
+		t.Fatal("Expected no subscribers")
+	}
+	withBody := globalHTTPTracer.subscribe(true)
+	noBody := globalHTTPTracer.subscribe(false)
+	if subscribed, wantsBody := globalHTTPTracer.subscribed(); !subscribed || !wantsBody {
+		t.Fatalf("Expected subscribers with bodies, got %v %v", subscribed, wantsBody)
+	}
+
+	sendRequest("http://localhost:9000/minio/lock/export")
+	sendRequest("http://localhost:9000/bucket/object")
+	if len(withBody.traceCh) != 1 || len(noBody.traceCh) != 1 {
+		t.Fatalf("Expected one trace per subscriber, got %d and %d", len(withBody.traceCh), len(noBody.traceCh))
+	}
+
+	trace := <-withBody.traceCh
+	if trace.API != "REST.PUT.OBJECT" || trace.ReqInfo.Method != "PUT" || trace.ReqInfo.Path != "/bucket/object" ||
+		trace.ReqInfo.Headers.Get("Authorization") != traceRedacted || trace.ReqInfo.Headers.Get("Host") != "localhost:9000" ||
+		string(trace.ReqInfo.Body) != "traced" || trace.RespInfo.StatusCode != http.StatusCreated ||
+		trace.RespInfo.Headers.Get("ETag") != "\"etag\"" || string(trace.RespInfo.Body) != "hello, world" {
+		t.Errorf("Unexpected trace %+v", trace)
+	}
+	trace = <-noBody.traceCh
+	if trace.API != "REST.PUT.OBJECT" || trace.ReqInfo.Body != nil || trace.RespInfo.Body != nil {
+		t.Errorf("Expected trace without bodies, got %+v", trace)
+	}
+
+	globalHTTPTracer.unsubscribe(withBody)
+	globalHTTPTracer.unsubscribe(noBody)
+	if subscribed, _ := globalHTTPTracer.subscribed(); subscribed {
+		t.Fatal("Expected no subscribers")
+	}
+}
+
+// Tests that bodies carrying credentials are not traced.
+func TestHTTPTracerSecretBodies(t *testing.T) {
+	defer func(tracer *httpTracer) { globalHTTPTracer = tracer }(globalHTTPTracer)
+	globalHTTPTracer = newHTTPTracer()
+	defer func(domains []string) { globalDomains = domains }(globalDomains)
+	globalDomains = []string{"minio.example.com"}
+
+	handler := setHTTPStatsHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ioutil.ReadAll(r.Body)
+		w.Write([]byte("secret"))
+	}))
+	withBody := globalHTTPTracer.subscribe(true)
+	defer globalHTTPTracer.unsubscribe(withBody)
+
+	testCases := []struct {
+		method      string
+		urlStr      string
+		header      http.Header
+		body        string
+		tracedBody  bool
+		description string
+	}{
+		{"GET", "http://localhost:9000/?config", http.Header{minioAdminOpHeader: {"get"}}, "", false, "get-config"},
+		{"PUT", "http://localhost:9000/?config", http.Header{minioAdminOpHeader: {"set"}}, `{"credential":{}}`, false, "set-config"},
+		{"POST", "http://localhost:9000/?service", http.Header{minioAdminOpHeader: {"set-credentials"}}, `{"secretKey":"s"}`, false, "set-credentials"},
+		{"PUT", "http://localhost:9000/?iam&user=u", http.Header{minioAdminOpHeader: {"add-user"}}, `{"secretKey":"s"}`, false, "add-user"},
+		{"GET", "http://bucket.minio.example.com/?config", http.Header{minioAdminOpHeader: {"get"}}, "", false, "virtual host get-config"},
+		{"POST", "http://localhost:9000/", http.Header{"Content-Type": {"application/x-www-form-urlencoded"}}, "Action=AssumeRole", false, "AssumeRole"},
+		{"POST", "http://localhost:9000/minio/webrpc", http.Header{"Content-Type": {"application/json"}}, `{"method":"Web.Login","params":{"password":"s"}}`, false, "browser login"},
+		{"PUT", "http://localhost:9000/bucket?replication", nil, "<SecretKey>s</SecretKey>", false, "put-bucket-replication"},
+		{"PUT", "http://localhost:9000/bucket/object", nil, "data", true, "put-object"},
+	}
+	for _, testCase := range testCases {
+		req, err := http.NewRequest(testCase.method, testCase.urlStr, strings.NewReader(testCase.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for k, v := range testCase.header {
+			req.Header[k] = v
+		}
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		if len(withBody.traceCh) != 1 {
+			t.Fatalf("%s: Expected one trace, got %d", testCase.description, len(withBody.traceCh))
+		}
+		trace := <-withBody.traceCh
+		if traced := trace.RespInfo.Body != nil; traced != testCase.tracedBody {
+			t.Errorf("%s: Expected traced response body %v, got %+v", testCase.description, testCase.tracedBody, trace)
+		}
+		if traced := trace.ReqInfo.Body != nil; testCase.body != "" && traced != testCase.tracedBody {
+			t.Errorf("%s: Expected traced request body %v, got %+v", testCase.description, testCase.tracedBody, trace)
+		}
+	}
+}
+
+// Tests polling the traces of a subscription.
+func TestTraceSubscriptions(t *testing.T) {
+	tracer := newHTTPTracer()
+	subscriptions := newTraceSubscriptions(tracer)
+
+	// The first poll subscribes and waits for traces.
+	if traces := subscriptions.poll("id", false); len(traces) != 0 {
+		t.Fatalf("Expected no traces, got %d", len(traces))
+	}
+	for i := 0; i < 3; i++ {
+		tracer.publish(TraceInfo{API: "REST.GET.OBJECT"})
+	}
+	if traces := subscriptions.poll("id", false); len(traces) != 3 {
+		t.Fatalf("Expected 3 traces, got %d", len(traces))
+	}
+	if len(tracer.subscribers) != 1 {
+		t.Fatalf("Expected one subscriber, got %d", len(tracer.subscribers))
+	}
+}
+
+// Tests streaming traces through the admin API.
+func TestTraceHandler(t *testing.T) {
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatalf("Init Test config failed")
+	}
+	defer removeAll(rootPath)
+	defer func(connAlive time.Duration) { globalSNSConnAlive = connAlive }(globalSNSConnAlive)
+	globalSNSConnAlive = 100 * time.Millisecond
+
+	initGlobalAdminPeers(mustGetNewEndpointList("http://127.0.0.1:9000/d1"))
+	adminRouter := router.NewRouter()
+	registerAdminRouter(adminRouter)
+
+	server := httptest.NewServer(setHTTPStatsHandler(adminRouter))
+	defer server.Close()
+
+	cred := serverConfig.GetCredential()
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	adminClient, err := madmin.New(u.Host, cred.AccessKey, cred.SecretKey, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doneCh := make(chan struct{})
+	traceCh := adminClient.TraceWithBody(doneCh)
+
+	// Wait for the trace to subscribe.
+	for i := 0; ; i++ {
+		if subscribed, _ := globalHTTPTracer.subscribed(); subscribed {
+			break
+		}
+		if i == 500 {
+			t.Fatal("Timed out waiting for the trace subscription")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// The admin router serves no S3 API, the request is answered with
+	// a page not found.
+	req, err := newTestSignedRequestV4("PUT", server.URL+"/bucket/object", 6, strings.NewReader("traced"),
+		cred.AccessKey, cred.SecretKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	select {
+	case trace := <-traceCh:
+		if trace.Err != nil {
+			t.Fatal(trace.Err)
+		}
+		auth := trace.ReqInfo.Headers.Get("Authorization")
+		if trace.NodeName != globalAdminPeers[0].addr || trace.API != "REST.PUT.OBJECT" ||
+			trace.RespInfo.StatusCode != http.StatusNotFound || len(trace.RespInfo.Body) == 0 ||
+			!strings.HasSuffix(auth, "Signature="+traceRedacted) {
+			t.Errorf("Unexpected trace %+v", trace)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the trace")
+	}
+
+	// The trace channel is closed once the client is done.
+	close(doneCh)
+	select {
+	case _, ok := <-traceCh:
+		if ok {
+			t.Error("Expected the trace channel to be closed")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the trace to end")
+	}
+}
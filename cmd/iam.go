@@ -0,0 +1,617 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/minio/minio-go/pkg/set"
+)
+
+const (
+	// IAM config prefix in the minio meta bucket.
+	iamConfigPrefix = "config/iam"
+
+	// IAM config file, holds all users, groups and policies.
+	iamConfigFile = "iam.json"
+
+	// Current version of the IAM config.
+	iamConfigVersion = "1"
+
+	// Maximum length of group and policy names.
+	iamNameMaxLen = 128
+)
+
+// Status of IAM users, only enabled users may sign requests.
+const (
+	iamUserEnabled  = "enabled"
+	iamUserDisabled = "disabled"
+)
+
+// IAM errors.
+var (
+	errNoSuchUser           = errors.New("The specified user does not exist")
+	errNoSuchGroup          = errors.New("The specified group does not exist")
+	errNoSuchPolicy         = errors.New("The specified policy does not exist")
+	errInvalidUserStatus    = errors.New("User status should be enabled or disabled")
+	errInvalidIAMName       = errors.New("Group and policy names should be 1 to 128 characters without slashes")
+	errIAMReservedAccessKey = errors.New("The access key is reserved for the server credential")
+	errCannedPolicy         = errors.New("Canned policies can not be modified")
+)
+
+// supportedIAMActionMap - actions which may be granted by IAM policies,
+// these are all bucket policy actions and the bucket owner actions.
+var supportedIAMActionMap = supportedActionMap.Union(set.CreateStringSet(
+	"s3:CreateBucket", "s3:DeleteBucket", "s3:ListAllMyBuckets",
+	"s3:GetBucketPolicy", "s3:PutBucketPolicy", "s3:DeleteBucketPolicy",
+	"s3:GetBucketNotification", "s3:PutBucketNotification", "s3:ListenBucketNotification",
+	"s3:GetBucketCORS", "s3:PutBucketCORS",
+	"s3:GetEncryptionConfiguration", "s3:PutEncryptionConfiguration",
+	"s3:GetLifecycleConfiguration", "s3:PutLifecycleConfiguration",
+	"s3:GetBucketTagging", "s3:PutBucketTagging",
+	"s3:GetBucketVersioning", "s3:PutBucketVersioning",
+	"s3:GetBucketWebsite", "s3:PutBucketWebsite", "s3:DeleteBucketWebsite",
+	"s3:GetBucketObjectLockConfiguration", "s3:PutBucketObjectLockConfiguration",
+	"s3:GetReplicationConfiguration", "s3:PutReplicationConfiguration",
+	"s3:GetBucketLogging", "s3:PutBucketLogging",
+))
+
+// iamPolicy - identity based policy attached to users and groups, its
+// statements have no principal.
+type iamPolicy struct {
+	Version    string            // date in YYYY-MM-DD format
+	Statements []policyStatement `json:"Statement"`
+}
+
+// Canned policies, always available and never stored.
+var cannedIAMPolicies = map[string]*iamPolicy{
+	// Read and write access to all buckets and objects.
+	"readwrite": {
+		Version: "2012-10-17",
+		Statements: []policyStatement{{
+			Effect:    "Allow",
+			Actions:   set.CreateStringSet("s3:*"),
+			Resources: set.CreateStringSet(bucketARNPrefix + "*"),
+		}},
+	},
+	// Read access to all buckets and objects.
+	"readonly": {
+		Version: "2012-10-17",
+		Statements: []policyStatement{{
+			Effect: "Allow",
+			Actions: set.CreateStringSet("s3:ListAllMyBuckets", "s3:GetBucketLocation",
+				"s3:ListBucket", "s3:GetObject"),
+			Resources: set.CreateStringSet(bucketARNPrefix + "*"),
+		}},
+	},
+	// Upload access to all buckets.
+	"writeonly": {
+		Version: "2012-10-17",
+		Statements: []policyStatement{{
+			Effect:    "Allow",
+			Actions:   set.CreateStringSet("s3:PutObject"),
+			Resources: set.CreateStringSet(bucketARNPrefix + "*"),
+		}},
+	},
+}
+
+// parseIAMPolicy - parses and validates an IAM policy, deny statements
+// are ordered first so that they are enforced once matched.
+func parseIAMPolicy(reader io.Reader) (*iamPolicy, error) {
+	policy := &iamPolicy{}
+	if err := json.NewDecoder(reader).Decode(policy); err != nil {
+		return nil, err
+	}
+
+	// Policy version cannot be empty.
+	if len(policy.Version) == 0 {
+		return nil, errors.New("Policy version cannot be empty")
+	}
+
+	// Policy statements cannot be empty.
+	if len(policy.Statements) == 0 {
+		return nil, errors.New("Policy statement cannot be empty")
+	}
+
+	var denyStatements, allowStatements []policyStatement
+	for _, statement := range policy.Statements {
+		if err := isValidEffect(statement.Effect); err != nil {
+			return nil, err
+		}
+		// Policies are attached to users and groups, which
+		// are their principals.
+		if statement.Principal != nil {
+			return nil, errors.New("Principal is not allowed in IAM policies, please validate your policy document")
+		}
+		if len(statement.Actions) == 0 {
+			return nil, errors.New("Action list cannot be empty")
+		}
+		if unsupportedActions := statement.Actions.Difference(supportedIAMActionMap); !unsupportedActions.IsEmpty() {
+			return nil, fmt.Errorf("Unsupported actions found: ‘%#v’, please validate your policy document", unsupportedActions)
+		}
+		if err := isValidResources(statement.Resources); err != nil {
+			return nil, err
+		}
+		if err := isValidConditions(statement.Actions, statement.Conditions); err != nil {
+			return nil, err
+		}
+		if statement.Effect == "Deny" {
+			denyStatements = append(denyStatements, statement)
+			continue
+		}
+		allowStatements = append(allowStatements, statement)
+	}
+	policy.Statements = append(denyStatements, allowStatements...)
+	return policy, nil
+}
+
+// isValidIAMName - returns true if the group or policy name is valid.
+func isValidIAMName(name string) bool {
+	return len(name) > 0 && len(name) <= iamNameMaxLen && !strings.Contains(name, slashSeparator)
+}
+
+// iamUser - secret key, status and attached policy of a user, the
+// access key of the user is the key of the users map.
+type iamUser struct {
+	SecretKey string `json:"secretKey"`
+	Status    string `json:"status"`
+	Policy    string `json:"policy,omitempty"`
+}
+
+// iamGroup - members and attached policy of a group.
+type iamGroup struct {
+	Members []string `json:"members"`
+	Policy  string   `json:"policy,omitempty"`
+}
+
+// iamConfig - all users, groups and custom policies, persisted
+// as a single config in the minio meta bucket.
+type iamConfig struct {
+	Version  string                `json:"version"`
+	Users    map[string]iamUser    `json:"users"`
+	Groups   map[string]iamGroup   `json:"groups"`
+	Policies map[string]*iamPolicy `json:"policies"`
+}
+
+func newIAMConfig() *iamConfig {
+	return &iamConfig{
+		Version:  iamConfigVersion,
+		Users:    make(map[string]iamUser),
+		Groups:   make(map[string]iamGroup),
+		Policies: make(map[string]*iamPolicy),
+	}
+}
+
+// getPolicy - returns the canned or custom policy with the name.
+func (config *iamConfig) getPolicy(name string) *iamPolicy {
+	if policy, ok := cannedIAMPolicies[name]; ok {
+		return policy
+	}
+	return config.Policies[name]
+}
+
+// iamSys - in-memory copy of the IAM config, consulted on each
+// signed request. The config is reloaded by all nodes whenever
+// it is changed on one of them.
+type iamSys struct {
+	sync.RWMutex
+	config *iamConfig
+}
+
+// Global IAM system, nil in gateway mode where only the
+// server credential is known.
+var globalIAMSys *iamSys
+
+// initIAMSys - loads the IAM config into the global IAM system.
+func initIAMSys(objAPI ObjectLayer) error {
+	if objAPI == nil {
+		return errInvalidArgument
+	}
+	// No changes are made before initialization, the IAM config is
+	// read without acquiring a lock.
+	config, err := readIAMConfig(objAPI)
+	if err != nil {
+		return err
+	}
+	globalIAMSys = &iamSys{config: config}
+	return nil
+}
+
+// readIAMConfig - reads the IAM config, an empty config is returned
+// if none was written yet. The caller is expected to lock the config.
+func readIAMConfig(objAPI ObjectLayer) (*iamConfig, error) {
+	var buffer bytes.Buffer
+	err := objAPI.GetObject(minioMetaBucket, pathJoin(iamConfigPrefix, iamConfigFile), 0, -1, &buffer)
+	if err != nil {
+		if isErrObjectNotFound(err) || isErrIncompleteBody(err) {
+			return newIAMConfig(), nil
+		}
+		errorIf(err, "Unable to load IAM config.")
+		return nil, errorCause(err)
+	}
+
+	config := newIAMConfig()
+	if err = json.NewDecoder(&buffer).Decode(config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// writeIAMConfig - saves the IAM config, the caller is expected to
+// lock the config.
+func writeIAMConfig(objAPI ObjectLayer, config *iamConfig) error {
+	buf, err := json.Marshal(config)
+	if err != nil {
+		errorIf(err, "Unable to marshal IAM config.")
+		return err
+	}
+	if _, err = objAPI.PutObject(minioMetaBucket, pathJoin(iamConfigPrefix, iamConfigFile),
+		int64(len(buf)), bytes.NewReader(buf), nil, ""); err != nil {
+		errorIf(err, "Unable to save IAM config.")
+		return errorCause(err)
+	}
+	return nil
+}
+
+// Load - reloads the IAM config from the object layer.
+func (sys *iamSys) Load(objAPI ObjectLayer) error {
+	// Acquire a read lock on IAM config before reading.
+	iamLock := globalNSMutex.NewNSLock(minioMetaBucket, pathJoin(iamConfigPrefix, iamConfigFile))
+	iamLock.RLock()
+	config, err := readIAMConfig(objAPI)
+	iamLock.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	sys.Lock()
+	sys.config = config
+	sys.Unlock()
+	return nil
+}
+
+// update - applies the change to the persisted IAM config and
+// notifies all nodes in the cluster to reload it.
+func (sys *iamSys) update(objAPI ObjectLayer, change func(config *iamConfig) error) error {
+	if sys == nil {
+		return errServerNotInitialized
+	}
+	if err := sys.persist(objAPI, change); err != nil {
+		return err
+	}
+
+	// Notify all peers (including self) to update in-memory state
+	S3PeersLoadIAM()
+	return nil
+}
+
+// persist - applies the change to the latest persisted IAM config
+// under a write lock, so that concurrent changes are not lost.
+func (sys *iamSys) persist(objAPI ObjectLayer, change func(config *iamConfig) error) error {
+	iamLock := globalNSMutex.NewNSLock(minioMetaBucket, pathJoin(iamConfigPrefix, iamConfigFile))
+	iamLock.Lock()
+	defer iamLock.Unlock()
+
+	config, err := readIAMConfig(objAPI)
+	if err != nil {
+		return err
+	}
+	if err = change(config); err != nil {
+		return err
+	}
+	return writeIAMConfig(objAPI, config)
+}
+
+// GetUserCredential - returns the credential of an enabled user.
+func (sys *iamSys) GetUserCredential(accessKey string) (credential, bool) {
+	if sys == nil {
+		return credential{}, false
+	}
+	sys.RLock()
+	defer sys.RUnlock()
+	user, ok := sys.config.Users[accessKey]
+	if !ok || user.Status != iamUserEnabled {
+		return credential{}, false
+	}
+	return credential{AccessKey: accessKey, SecretKey: user.SecretKey}, true
+}
+
+// IsAllowed - returns true if the policies attached to the user or
+// to its groups allow the action on the resource, which is given as
+// `/bucket/object`. Deny statements of any of the policies win.
+func (sys *iamSys) IsAllowed(accessKey, action, resource, referer string, queryParams url.Values, requestTags map[string]string) bool {
+	if sys == nil {
+		return false
+	}
+
+	sys.RLock()
+	user, ok := sys.config.Users[accessKey]
+	if !ok || user.Status != iamUserEnabled {
+		sys.RUnlock()
+		return false
+	}
+	policyNames := []string{user.Policy}
+	for _, group := range sys.config.Groups {
+		for _, member := range group.Members {
+			if member == accessKey {
+				policyNames = append(policyNames, group.Policy)
+				break
+			}
+		}
+	}
+	var denyStatements, allowStatements []policyStatement
+	for _, name := range policyNames {
+		policy := sys.config.getPolicy(name)
+		if policy == nil {
+			continue
+		}
+		for _, statement := range policy.Statements {
+			if statement.Effect == "Deny" {
+				denyStatements = append(denyStatements, statement)
+				continue
+			}
+			allowStatements = append(allowStatements, statement)
+		}
+	}
+	sys.RUnlock()
+
+	statements := append(denyStatements, allowStatements...)
+	return isIAMStatementsAllowed(statements, action, resource, referer, queryParams, requestTags)
+}
+
+// isIAMStatementsAllowed - returns true if the statements allow the
+// action on the resource, deny statements are expected to be first.
+func isIAMStatementsAllowed(statements []policyStatement, action, resource, referer string, queryParams url.Values, requestTags map[string]string) bool {
+	if len(statements) == 0 {
+		return false
+	}
+	policy := bucketPolicy{Statements: statements}
+
+	// Construct resource in 'arn:aws:s3:::examplebucket/object' format.
+	arn := bucketARNPrefix + strings.TrimSuffix(strings.TrimPrefix(resource, "/"), "/")
+	conditionKeyMap := getPolicyConditions(resource, referer, queryParams, requestTags, policy)
+	return bucketPolicyEvalStatements(action, arn, conditionKeyMap, policy.Statements)
+}
+
+// SetUser - adds a user or updates the secret key and status of an
+// existing user.
+func (sys *iamSys) SetUser(objAPI ObjectLayer, accessKey, secretKey, status string) error {
+	if !isAccessKeyValid(accessKey) {
+		return errInvalidAccessKeyLength
+	}
+	if !isSecretKeyValid(secretKey) {
+		return errInvalidSecretKeyLength
+	}
+	if accessKey == serverConfig.GetCredential().AccessKey {
+		return errIAMReservedAccessKey
+	}
+	if status == "" {
+		status = iamUserEnabled
+	}
+	if status != iamUserEnabled && status != iamUserDisabled {
+		return errInvalidUserStatus
+	}
+	return sys.update(objAPI, func(config *iamConfig) error {
+		user := config.Users[accessKey]
+		user.SecretKey = secretKey
+		user.Status = status
+		config.Users[accessKey] = user
+		return nil
+	})
+}
+
+// SetUserStatus - enables or disables a user.
+func (sys *iamSys) SetUserStatus(objAPI ObjectLayer, accessKey, status string) error {
+	if status != iamUserEnabled && status != iamUserDisabled {
+		return errInvalidUserStatus
+	}
+	return sys.update(objAPI, func(config *iamConfig) error {
+		user, ok := config.Users[accessKey]
+		if !ok {
+			return errNoSuchUser
+		}
+		user.Status = status
+		config.Users[accessKey] = user
+		return nil
+	})
+}
+
+// RemoveUser - removes a user along with its group memberships,
+// groups left without members are removed.
+func (sys *iamSys) RemoveUser(objAPI ObjectLayer, accessKey string) error {
+	return sys.update(objAPI, func(config *iamConfig) error {
+		if _, ok := config.Users[accessKey]; !ok {
+			return errNoSuchUser
+		}
+		delete(config.Users, accessKey)
+		for name, group := range config.Groups {
+			setGroupMembers(config, name, group, set.CreateStringSet(group.Members...).Difference(set.CreateStringSet(accessKey)))
+		}
+		return nil
+	})
+}
+
+// SetPolicy - adds or replaces a custom policy.
+func (sys *iamSys) SetPolicy(objAPI ObjectLayer, name string, policy *iamPolicy) error {
+	if !isValidIAMName(name) {
+		return errInvalidIAMName
+	}
+	if _, ok := cannedIAMPolicies[name]; ok {
+		return errCannedPolicy
+	}
+	return sys.update(objAPI, func(config *iamConfig) error {
+		config.Policies[name] = policy
+		return nil
+	})
+}
+
+// RemovePolicy - removes a custom policy and detaches it from all
+// users and groups.
+func (sys *iamSys) RemovePolicy(objAPI ObjectLayer, name string) error {
+	if _, ok := cannedIAMPolicies[name]; ok {
+		return errCannedPolicy
+	}
+	return sys.update(objAPI, func(config *iamConfig) error {
+		if _, ok := config.Policies[name]; !ok {
+			return errNoSuchPolicy
+		}
+		delete(config.Policies, name)
+		for accessKey, user := range config.Users {
+			if user.Policy == name {
+				user.Policy = ""
+				config.Users[accessKey] = user
+			}
+		}
+		for groupName, group := range config.Groups {
+			if group.Policy == name {
+				group.Policy = ""
+				config.Groups[groupName] = group
+			}
+		}
+		return nil
+	})
+}
+
+// SetUserPolicy - attaches the policy to the user, an empty policy
+// name detaches the current policy.
+func (sys *iamSys) SetUserPolicy(objAPI ObjectLayer, accessKey, name string) error {
+	return sys.update(objAPI, func(config *iamConfig) error {
+		user, ok := config.Users[accessKey]
+		if !ok {
+			return errNoSuchUser
+		}
+		if name != "" && config.getPolicy(name) == nil {
+			return errNoSuchPolicy
+		}
+		user.Policy = name
+		config.Users[accessKey] = user
+		return nil
+	})
+}
+
+// SetGroupPolicy - attaches the policy to the group, an empty policy
+// name detaches the current policy.
+func (sys *iamSys) SetGroupPolicy(objAPI ObjectLayer, groupName, name string) error {
+	return sys.update(objAPI, func(config *iamConfig) error {
+		group, ok := config.Groups[groupName]
+		if !ok {
+			return errNoSuchGroup
+		}
+		if name != "" && config.getPolicy(name) == nil {
+			return errNoSuchPolicy
+		}
+		group.Policy = name
+		config.Groups[groupName] = group
+		return nil
+	})
+}
+
+// UpdateGroupMembers - adds the users to the group, creating the group
+// if needed, or removes them from it. Groups left without members are
+// removed.
+func (sys *iamSys) UpdateGroupMembers(objAPI ObjectLayer, groupName string, members []string, isRemove bool) error {
+	if !isValidIAMName(groupName) {
+		return errInvalidIAMName
+	}
+	return sys.update(objAPI, func(config *iamConfig) error {
+		group, ok := config.Groups[groupName]
+		if !ok && isRemove {
+			return errNoSuchGroup
+		}
+		current := set.CreateStringSet(group.Members...)
+		if isRemove {
+			setGroupMembers(config, groupName, group, current.Difference(set.CreateStringSet(members...)))
+			return nil
+		}
+		for _, member := range members {
+			if _, ok := config.Users[member]; !ok {
+				return errNoSuchUser
+			}
+		}
+		setGroupMembers(config, groupName, group, current.Union(set.CreateStringSet(members...)))
+		return nil
+	})
+}
+
+// setGroupMembers - replaces the members of the group, a group
+// without members is removed.
+func setGroupMembers(config *iamConfig, groupName string, group iamGroup, members set.StringSet) {
+	if members.IsEmpty() {
+		delete(config.Groups, groupName)
+		return
+	}
+	group.Members = members.ToSlice()
+	config.Groups[groupName] = group
+}
+
+// iamUserInfo - user as listed by the admin API, without secret key.
+type iamUserInfo struct {
+	Status   string   `json:"status"`
+	Policy   string   `json:"policy,omitempty"`
+	MemberOf []string `json:"memberOf,omitempty"`
+}
+
+// ListUsers - returns all users by access key.
+func (sys *iamSys) ListUsers() map[string]iamUserInfo {
+	sys.RLock()
+	defer sys.RUnlock()
+	users := make(map[string]iamUserInfo, len(sys.config.Users))
+	for accessKey, user := range sys.config.Users {
+		users[accessKey] = iamUserInfo{Status: user.Status, Policy: user.Policy}
+	}
+	for groupName, group := range sys.config.Groups {
+		for _, member := range group.Members {
+			if info, ok := users[member]; ok {
+				info.MemberOf = append(info.MemberOf, groupName)
+				users[member] = info
+			}
+		}
+	}
+	return users
+}
+
+// ListGroups - returns all groups by name.
+func (sys *iamSys) ListGroups() map[string]iamGroup {
+	sys.RLock()
+	defer sys.RUnlock()
+	groups := make(map[string]iamGroup, len(sys.config.Groups))
+	for name, group := range sys.config.Groups {
+		groups[name] = group
+	}
+	return groups
+}
+
+// ListPolicies - returns all canned and custom policies by name.
+func (sys *iamSys) ListPolicies() map[string]*iamPolicy {
+	sys.RLock()
+	defer sys.RUnlock()
+	policies := make(map[string]*iamPolicy, len(cannedIAMPolicies)+len(sys.config.Policies))
+	for name, policy := range cannedIAMPolicies {
+		policies[name] = policy
+	}
+	for name, policy := range sys.config.Policies {
+		policies[name] = policy
+	}
+	return policies
+}
@@ -0,0 +1,463 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	router "github.com/gorilla/mux"
+)
+
+// Tests parsing and validation of IAM policies.
+func TestParseIAMPolicy(t *testing.T) {
+	testCases := []struct {
+		policy  string
+		success bool
+	}{
+		// Bucket owner actions on all buckets.
+		{`{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":["s3:CreateBucket","s3:PutBucketPolicy"],"Resource":["arn:aws:s3:::*"]}]}`, true},
+		// Object actions with a condition.
+		{`{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":["s3:ListBucket"],"Resource":["arn:aws:s3:::photos"],"Condition":{"StringLike":{"s3:prefix":["2017/*"]}}}]}`, true},
+		// Principals are not allowed.
+		{`{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":"*","Action":["s3:GetObject"],"Resource":["arn:aws:s3:::photos/*"]}]}`, false},
+		// Unsupported action.
+		{`{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":["s3:PutBucketAcl"],"Resource":["arn:aws:s3:::photos"]}]}`, false},
+		// Invalid resource.
+		{`{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":["s3:GetObject"],"Resource":["photos/*"]}]}`, false},
+		// Invalid effect.
+		{`{"Version":"2012-10-17","Statement":[{"Effect":"Maybe","Action":["s3:GetObject"],"Resource":["arn:aws:s3:::photos/*"]}]}`, false},
+		// No statements.
+		{`{"Version":"2012-10-17","Statement":[]}`, false},
+		// No version.
+		{`{"Statement":[{"Effect":"Allow","Action":["s3:GetObject"],"Resource":["arn:aws:s3:::photos/*"]}]}`, false},
+	}
+
+	for i, testCase := range testCases {
+		_, err := parseIAMPolicy(strings.NewReader(testCase.policy))
+		if (err == nil) != testCase.success {
+			t.Errorf("Test %d: Expected success %v, got error %v", i+1, testCase.success, err)
+		}
+	}
+
+	// Deny statements are ordered first.
+	policy, err := parseIAMPolicy(strings.NewReader(`{"Version":"2012-10-17","Statement":[` +
+		`{"Effect":"Allow","Action":["s3:*"],"Resource":["arn:aws:s3:::*"]},` +
+		`{"Effect":"Deny","Action":["s3:DeleteObject"],"Resource":["arn:aws:s3:::*"]}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if policy.Statements[0].Effect != "Deny" {
+		t.Fatalf("Expected deny statement first, got %s", policy.Statements[0].Effect)
+	}
+}
+
+// Tests evaluation of the policies of users and their groups.
+func TestIAMSysIsAllowed(t *testing.T) {
+	denyDelete, err := parseIAMPolicy(strings.NewReader(`{"Version":"2012-10-17","Statement":[` +
+		`{"Effect":"Deny","Action":["s3:DeleteObject"],"Resource":["arn:aws:s3:::*"]}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	photosPrefix, err := parseIAMPolicy(strings.NewReader(`{"Version":"2012-10-17","Statement":[` +
+		`{"Effect":"Allow","Action":["s3:ListBucket"],"Resource":["arn:aws:s3:::photos"],"Condition":{"StringEquals":{"s3:prefix":["2017/"]}}},` +
+		`{"Effect":"Allow","Action":["s3:PutObject"],"Resource":["arn:aws:s3:::photos/2017/*"]}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := newIAMConfig()
+	config.Users["reader"] = iamUser{SecretKey: "reader123", Status: iamUserEnabled, Policy: "readonly"}
+	config.Users["writer"] = iamUser{SecretKey: "writer123", Status: iamUserEnabled, Policy: "readwrite"}
+	config.Users["photographer"] = iamUser{SecretKey: "photographer123", Status: iamUserEnabled}
+	config.Users["disabled"] = iamUser{SecretKey: "disabled123", Status: iamUserDisabled, Policy: "readwrite"}
+	config.Policies["deny-delete"] = denyDelete
+	config.Policies["photos-prefix"] = photosPrefix
+	config.Groups["no-delete"] = iamGroup{Members: []string{"writer"}, Policy: "deny-delete"}
+	config.Groups["photographers"] = iamGroup{Members: []string{"photographer"}, Policy: "photos-prefix"}
+	sys := &iamSys{config: config}
+
+	testCases := []struct {
+		accessKey   string
+		action      string
+		resource    string
+		queryParams url.Values
+		allowed     bool
+	}{
+		// Canned read only policy.
+		{"reader", "s3:GetObject", "/photos/a.jpg", nil, true},
+		{"reader", "s3:ListAllMyBuckets", "/", nil, true},
+		{"reader", "s3:PutObject", "/photos/a.jpg", nil, false},
+		// Canned read write policy, deny of a group wins.
+		{"writer", "s3:PutBucketPolicy", "/photos", nil, true},
+		{"writer", "s3:DeleteObject", "/photos/a.jpg", nil, false},
+		// Policy of a group with conditions and resource prefixes.
+		{"photographer", "s3:ListBucket", "/photos", url.Values{"prefix": []string{"2017/"}}, true},
+		{"photographer", "s3:ListBucket", "/photos", url.Values{"prefix": []string{"2016/"}}, false},
+		{"photographer", "s3:PutObject", "/photos/2017/a.jpg", nil, true},
+		{"photographer", "s3:PutObject", "/photos/2016/a.jpg", nil, false},
+		// Disabled and unknown users are never allowed.
+		{"disabled", "s3:GetObject", "/photos/a.jpg", nil, false},
+		{"unknown", "s3:GetObject", "/photos/a.jpg", nil, false},
+	}
+
+	for i, testCase := range testCases {
+		allowed := sys.IsAllowed(testCase.accessKey, testCase.action, testCase.resource, "", testCase.queryParams, nil)
+		if allowed != testCase.allowed {
+			t.Errorf("Test %d: Expected allowed %v, got %v", i+1, testCase.allowed, allowed)
+		}
+	}
+
+	// Only enabled users have credentials.
+	if cred, ok := sys.GetUserCredential("reader"); !ok || cred.SecretKey != "reader123" {
+		t.Errorf("Expected credential of enabled user, got %v %v", cred, ok)
+	}
+	if _, ok := sys.GetUserCredential("disabled"); ok {
+		t.Error("Expected no credential for disabled user")
+	}
+}
+
+// Wrapper for calling IAM tests for both XL and FS.
+func TestIAMSys(t *testing.T) {
+	// initialize NSLock.
+	initNSLock(false)
+
+	ExecObjectLayerTest(t, testIAMSys)
+}
+
+// Tests persisting IAM changes and enforcing the policies of users
+// on signed S3 requests.
+func testIAMSys(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	globalObjLayerMutex.Lock()
+	globalObjectAPI = obj
+	globalObjLayerMutex.Unlock()
+	defer func() {
+		globalObjLayerMutex.Lock()
+		globalObjectAPI = nil
+		globalObjLayerMutex.Unlock()
+	}()
+
+	// Changes are loaded by all peers, here only the local node.
+	defer func(peers s3Peers) { globalS3Peers = peers }(globalS3Peers)
+	globalS3Peers = makeS3Peers(EndpointList{})
+
+	if err := initIAMSys(obj); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+
+	bucket := "iam-bucket"
+	if err := obj.MakeBucketWithLocation(bucket, ""); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	data := []byte("iam")
+	if _, err := obj.PutObject(bucket, "object", int64(len(data)), bytes.NewReader(data), nil, ""); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+
+	if err := globalIAMSys.SetUser(obj, serverConfig.GetCredential().AccessKey, "secret123", ""); err != errIAMReservedAccessKey {
+		t.Fatalf("%s: Expected %v, got %v", instanceType, errIAMReservedAccessKey, err)
+	}
+	if err := globalIAMSys.SetUser(obj, "reader", "reader123", ""); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if err := globalIAMSys.SetUserPolicy(obj, "reader", "no-such-policy"); err != errNoSuchPolicy {
+		t.Fatalf("%s: Expected %v, got %v", instanceType, errNoSuchPolicy, err)
+	}
+	if err := globalIAMSys.SetUserPolicy(obj, "reader", "readonly"); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if err := globalIAMSys.UpdateGroupMembers(obj, "readers", []string{"reader"}, false); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+
+	// Changes are persisted.
+	sys := &iamSys{}
+	if err := sys.Load(obj); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	expectedUsers := map[string]iamUserInfo{
+		"reader": {Status: iamUserEnabled, Policy: "readonly", MemberOf: []string{"readers"}},
+	}
+	if users := sys.ListUsers(); !reflect.DeepEqual(users, expectedUsers) {
+		t.Fatalf("%s: Expected users %v, got %v", instanceType, expectedUsers, users)
+	}
+
+	mux := router.NewRouter().SkipClean(true)
+	registerAPIRouter(mux)
+	sendRequest := func(method, urlStr, accessKey, secretKey string, data []byte) *httptest.ResponseRecorder {
+		req, err := newTestSignedRequestV4(method, urlStr, int64(len(data)), bytes.NewReader(data),
+			accessKey, secretKey)
+		if err != nil {
+			t.Fatalf("%s: %v", instanceType, err)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	testCases := []struct {
+		method    string
+		path      string
+		accessKey string
+		secretKey string
+		status    int
+	}{
+		// Read only user.
+		{"GET", "/" + bucket + "/object", "reader", "reader123", http.StatusOK},
+		{"GET", "/" + bucket, "reader", "reader123", http.StatusOK},
+		{"PUT", "/" + bucket + "/object", "reader", "reader123", http.StatusForbidden},
+		{"DELETE", "/" + bucket + "/object", "reader", "reader123", http.StatusForbidden},
+		{"GET", "/" + bucket + "?policy", "reader", "reader123", http.StatusForbidden},
+		// Wrong secret key.
+		{"GET", "/" + bucket + "/object", "reader", "reader456", http.StatusForbidden},
+		// Unknown user.
+		{"GET", "/" + bucket + "/object", "unknown", "unknown123", http.StatusForbidden},
+	}
+
+	for i, testCase := range testCases {
+		rec := sendRequest(testCase.method, "http://127.0.0.1:9000"+testCase.path,
+			testCase.accessKey, testCase.secretKey, nil)
+		if rec.Code != testCase.status {
+			t.Errorf("%s: Test %d: Expected status %d, got %d", instanceType, i+1, testCase.status, rec.Code)
+		}
+	}
+
+	// Multiple objects are only deleted if the user is allowed to
+	// delete each of them.
+	policy, err := parseIAMPolicy(strings.NewReader(`{"Version":"2012-10-17","Statement":[` +
+		`{"Effect":"Allow","Action":["s3:DeleteObject"],"Resource":["arn:aws:s3:::` + bucket + `/logs/*"]}]}`))
+	if err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if err = globalIAMSys.SetPolicy(obj, "deletelogs", policy); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if err = globalIAMSys.SetUser(obj, "deleter", "deleter123", ""); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if err = globalIAMSys.SetUserPolicy(obj, "deleter", "deletelogs"); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if _, err = obj.PutObject(bucket, "logs/a", int64(len(data)), bytes.NewReader(data), nil, ""); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	deleteXML := []byte(`<Delete><Object><Key>logs/a</Key></Object><Object><Key>object</Key></Object></Delete>`)
+	rec := sendRequest("POST", "http://127.0.0.1:9000/"+bucket+"?delete", "deleter", "deleter123", deleteXML)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), "<Deleted><Key>logs/a</Key>") ||
+		!strings.Contains(rec.Body.String(), "<Error><Code>AccessDenied</Code><Message>Access Denied.</Message><Key>object</Key></Error>") {
+		t.Fatalf("%s: Expected logs/a to be deleted and object to be denied, got %s", instanceType, rec.Body.String())
+	}
+	if _, err = obj.GetObjectInfo(bucket, "logs/a"); !isErrObjectNotFound(err) {
+		t.Fatalf("%s: Expected logs/a to be deleted, got %v", instanceType, err)
+	}
+	if _, err = obj.GetObjectInfo(bucket, "object"); err != nil {
+		t.Fatalf("%s: Expected object to be kept, got %v", instanceType, err)
+	}
+
+	// Copies need read access to the source, both for objects and
+	// for parts.
+	policy, err = parseIAMPolicy(strings.NewReader(`{"Version":"2012-10-17","Statement":[` +
+		`{"Effect":"Allow","Action":["s3:GetObject","s3:PutObject"],"Resource":["arn:aws:s3:::` + bucket + `/copier/*"]}]}`))
+	if err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if err = globalIAMSys.SetPolicy(obj, "copyown", policy); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if err = globalIAMSys.SetUser(obj, "copier", "copier123", ""); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if err = globalIAMSys.SetUserPolicy(obj, "copier", "copyown"); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if _, err = obj.PutObject(bucket, "copier/src", int64(len(data)), bytes.NewReader(data), nil, ""); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	uploadID, err := obj.NewMultipartUpload(bucket, "copier/parts", nil)
+	if err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	sendCopyRequest := func(urlStr, cpSrc string) *httptest.ResponseRecorder {
+		req, rerr := newTestSignedRequestV4("PUT", urlStr, 0, nil, "copier", "copier123")
+		if rerr != nil {
+			t.Fatalf("%s: %v", instanceType, rerr)
+		}
+		req.Header.Set("X-Amz-Copy-Source", url.QueryEscape(cpSrc))
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+	copyTestCases := []struct {
+		urlStr string
+		cpSrc  string
+		status int
+	}{
+		{getCopyObjectURL("http://127.0.0.1:9000", bucket, "copier/dst"), "/" + bucket + "/copier/src", http.StatusOK},
+		{getCopyObjectURL("http://127.0.0.1:9000", bucket, "copier/dst"), "/" + bucket + "/object", http.StatusForbidden},
+		{getCopyObjectPartURL("http://127.0.0.1:9000", bucket, "copier/parts", uploadID, "1"), "/" + bucket + "/copier/src", http.StatusOK},
+		{getCopyObjectPartURL("http://127.0.0.1:9000", bucket, "copier/parts", uploadID, "2"), "/" + bucket + "/object", http.StatusForbidden},
+	}
+	for i, testCase := range copyTestCases {
+		if rec = sendCopyRequest(testCase.urlStr, testCase.cpSrc); rec.Code != testCase.status {
+			t.Errorf("%s: Copy test %d: Expected status %d, got %d", instanceType, i+1, testCase.status, rec.Code)
+		}
+	}
+	partsInfo, err := obj.ListObjectParts(bucket, "copier/parts", uploadID, 0, 1000)
+	if err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if len(partsInfo.Parts) != 1 || partsInfo.Parts[0].PartNumber != 1 {
+		t.Fatalf("%s: Expected only part 1 to be copied, got %v", instanceType, partsInfo.Parts)
+	}
+
+	// Requests on a specific version of an object need the version
+	// actions.
+	if err = initBucketVersioning(obj); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	globalBucketVersioning.SetBucketVersioning(bucket, &versioningConfiguration{Status: versioningEnabled})
+	defer globalBucketVersioning.SetBucketVersioning(bucket, nil)
+	var versionIDs []string
+	for i := 0; i < 2; i++ {
+		objInfo, perr := obj.PutObject(bucket, "versions/object", int64(len(data)), bytes.NewReader(data), nil, "")
+		if perr != nil {
+			t.Fatalf("%s: %v", instanceType, perr)
+		}
+		versionIDs = append(versionIDs, objInfo.VersionID)
+	}
+	setVersionsPolicy := func(actions string) {
+		vpolicy, perr := parseIAMPolicy(strings.NewReader(`{"Version":"2012-10-17","Statement":[` +
+			`{"Effect":"Allow","Action":[` + actions + `],"Resource":["arn:aws:s3:::` + bucket + `/versions/*"]}]}`))
+		if perr != nil {
+			t.Fatalf("%s: %v", instanceType, perr)
+		}
+		if perr = globalIAMSys.SetPolicy(obj, "versions", vpolicy); perr != nil {
+			t.Fatalf("%s: %v", instanceType, perr)
+		}
+	}
+	setVersionsPolicy(`"s3:GetObject","s3:PutObject","s3:DeleteObject"`)
+	if err = globalIAMSys.SetUser(obj, "versioner", "versioner123", ""); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if err = globalIAMSys.SetUserPolicy(obj, "versioner", "versions"); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	objectURL := "http://127.0.0.1:9000/" + bucket + "/versions/object"
+	sendVersionRequests := func(expectedStatus int) {
+		for i, testCase := range []struct {
+			method string
+			urlStr string
+			status int
+		}{
+			{"GET", objectURL, http.StatusOK},
+			{"GET", objectURL + "?versionId=" + versionIDs[0], expectedStatus},
+			{"HEAD", objectURL + "?versionId=" + versionIDs[0], expectedStatus},
+		} {
+			if rec = sendRequest(testCase.method, testCase.urlStr, "versioner", "versioner123", nil); rec.Code != testCase.status {
+				t.Errorf("%s: Version test %d: Expected status %d, got %d", instanceType, i+1, testCase.status, rec.Code)
+			}
+		}
+		req, rerr := newTestSignedRequestV4("PUT", getCopyObjectURL("http://127.0.0.1:9000", bucket, "versions/copy"),
+			0, nil, "versioner", "versioner123")
+		if rerr != nil {
+			t.Fatalf("%s: %v", instanceType, rerr)
+		}
+		req.Header.Set("X-Amz-Copy-Source", "/"+bucket+"/versions/object?versionId="+versionIDs[0])
+		rec = httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		if rec.Code != expectedStatus {
+			t.Errorf("%s: Copy of version: Expected status %d, got %d", instanceType, expectedStatus, rec.Code)
+		}
+	}
+	sendVersionRequests(http.StatusForbidden)
+	rec = sendRequest("DELETE", objectURL+"?versionId="+versionIDs[0], "versioner", "versioner123", nil)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusForbidden, rec.Code)
+	}
+	deleteXML = []byte(`<Delete><Object><Key>versions/object</Key><VersionId>` + versionIDs[1] + `</VersionId></Object></Delete>`)
+	rec = sendRequest("POST", "http://127.0.0.1:9000/"+bucket+"?delete", "versioner", "versioner123", deleteXML)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "<Error><Code>AccessDenied</Code>") {
+		t.Fatalf("%s: Expected the version delete to be denied, got %d %s", instanceType, rec.Code, rec.Body.String())
+	}
+
+	// Bucket policies of anonymous requests need the version actions
+	// as well.
+	sendAnonymousRequest := func(actions string) *httptest.ResponseRecorder {
+		var bp bucketPolicy
+		if perr := parseBucketPolicy(strings.NewReader(`{"Version":"2012-10-17","Statement":[`+
+			`{"Effect":"Allow","Principal":{"AWS":["*"]},"Action":[`+actions+`],`+
+			`"Resource":["arn:aws:s3:::`+bucket+`/versions/*"]}]}`), &bp); perr != nil {
+			t.Fatalf("%s: %v", instanceType, perr)
+		}
+		globalBucketPolicies.SetBucketPolicy(bucket, policyChange{false, &bp})
+		defer globalBucketPolicies.SetBucketPolicy(bucket, policyChange{true, nil})
+		req, rerr := newTestRequest("GET", objectURL+"?versionId="+versionIDs[0], 0, nil)
+		if rerr != nil {
+			t.Fatalf("%s: %v", instanceType, rerr)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+	if rec = sendAnonymousRequest(`"s3:GetObject"`); rec.Code != http.StatusForbidden {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusForbidden, rec.Code)
+	}
+	if rec = sendAnonymousRequest(`"s3:GetObjectVersion"`); rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, rec.Code)
+	}
+
+	setVersionsPolicy(`"s3:GetObject","s3:PutObject","s3:DeleteObject","s3:GetObjectVersion","s3:DeleteObjectVersion"`)
+	sendVersionRequests(http.StatusOK)
+	rec = sendRequest("DELETE", objectURL+"?versionId="+versionIDs[0], "versioner", "versioner123", nil)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusNoContent, rec.Code)
+	}
+	rec = sendRequest("POST", "http://127.0.0.1:9000/"+bucket+"?delete", "versioner", "versioner123", deleteXML)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "<Deleted><Key>versions/object</Key><VersionId>"+versionIDs[1]+"</VersionId>") {
+		t.Fatalf("%s: Expected the version to be deleted, got %d %s", instanceType, rec.Code, rec.Body.String())
+	}
+	for _, versionID := range versionIDs {
+		if _, err = obj.GetObjectVersionInfo(bucket, "versions/object", versionID); err == nil {
+			t.Fatalf("%s: Expected version %s to be deleted", instanceType, versionID)
+		}
+	}
+
+	// Disabled users can not sign requests.
+	if err := globalIAMSys.SetUserStatus(obj, "reader", iamUserDisabled); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	rec = sendRequest("GET", "http://127.0.0.1:9000/"+bucket+"/object", "reader", "reader123", nil)
+	if rec.Code != http.StatusForbidden || !strings.Contains(rec.Body.String(), "InvalidAccessKeyId") {
+		t.Fatalf("%s: Expected disabled user to be rejected, got %d %q", instanceType, rec.Code, rec.Body.String())
+	}
+
+	// Removing the last member removes the group.
+	if err := globalIAMSys.RemoveUser(obj, "reader"); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if groups := globalIAMSys.ListGroups(); len(groups) != 0 {
+		t.Fatalf("%s: Expected no groups, got %v", instanceType, groups)
+	}
+}
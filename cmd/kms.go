@@ -0,0 +1,384 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+var (
+	errKMSNotConfigured    = errors.New("Server side encryption with server managed keys requires a key management service")
+	errKMSKeyNotFound      = errors.New("The master key is not known to the key management service")
+	errKMSInvalidMasterKey = errors.New("The master key must be specified as <key-id>:<64 hex characters>")
+	errKMSSealedKey        = errors.New("The sealed data key cannot be unsealed with the master key")
+	errKMSMultipleBackends = errors.New("Only one of the local and vault key management services can be configured")
+)
+
+// KMS is the interface of a key management service. It generates the
+// data keys which seal the object keys of objects encrypted with
+// server managed keys, data keys are only stored sealed with one of
+// the master keys of the KMS.
+type KMS interface {
+	// KeyID returns the ID of the master key new data keys are
+	// sealed with. Data keys sealed with an older master key can
+	// still be unsealed as long as the KMS knows that key.
+	KeyID() string
+
+	// GenerateKey generates a new random data key and returns it
+	// in plaintext and sealed with the master key identified by
+	// keyID. The context is bound to the sealed key and has to be
+	// provided again to unseal it.
+	GenerateKey(keyID string, context []byte) (key [32]byte, sealedKey []byte, err error)
+
+	// UnsealKey unseals a data key previously sealed with the
+	// master key identified by keyID and the given context.
+	UnsealKey(keyID string, sealedKey, context []byte) (key [32]byte, err error)
+}
+
+// globalKMS is the key management service used for SSE-S3, it is nil
+// if no key management service is configured.
+var globalKMS KMS
+
+// localKMSConfig - configures a KMS holding its master keys in a
+// local file, one `<key-id>:<64 hex characters>` entry per line.
+type localKMSConfig struct {
+	KeyFile string `json:"keyFile"`
+	KeyID   string `json:"keyID"`
+}
+
+// vaultKMSConfig - configures a KMS which seals data keys with the
+// transit secrets engine of a Vault compatible server.
+type vaultKMSConfig struct {
+	Endpoint string `json:"endpoint"`
+	Token    string `json:"token"`
+	KeyID    string `json:"keyID"`
+}
+
+// kmsConfig - key management service configuration, at most one of
+// the backends can be configured.
+type kmsConfig struct {
+	// Master key set via MINIO_SSE_MASTER_KEY, never saved in config.
+	masterKey string
+
+	Local localKMSConfig `json:"local"`
+	Vault vaultKMSConfig `json:"vault"`
+}
+
+// Validate - validates the key management service configuration.
+func (c kmsConfig) Validate() error {
+	configured := 0
+	for _, isSet := range []bool{c.masterKey != "", c.Local.KeyFile != "", c.Vault.Endpoint != ""} {
+		if isSet {
+			configured++
+		}
+	}
+	if configured > 1 {
+		return errKMSMultipleBackends
+	}
+	if c.Vault.Endpoint != "" && c.Vault.KeyID == "" {
+		return errors.New("Vault key management service requires a key ID")
+	}
+	return nil
+}
+
+// lookupKMSEnv - returns the key management service configured through
+// the environment, the second return value is false if none is set.
+func lookupKMSEnv() (kmsConfig, bool) {
+	cfg := kmsConfig{
+		masterKey: os.Getenv("MINIO_SSE_MASTER_KEY"),
+		Vault: vaultKMSConfig{
+			Endpoint: os.Getenv("MINIO_SSE_VAULT_ENDPOINT"),
+			Token:    os.Getenv("MINIO_SSE_VAULT_TOKEN"),
+			KeyID:    os.Getenv("MINIO_SSE_VAULT_KEY_ID"),
+		},
+	}
+	return cfg, cfg.masterKey != "" || cfg.Vault.Endpoint != ""
+}
+
+// newKMS - returns the key management service for the configuration,
+// nil is returned if no key management service is configured.
+func newKMS(cfg kmsConfig) (KMS, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	switch {
+	case cfg.masterKey != "":
+		keyID, key, err := parseMasterKey(cfg.masterKey)
+		if err != nil {
+			return nil, err
+		}
+		return newLocalKMS(keyID, map[string][32]byte{keyID: key})
+	case cfg.Local.KeyFile != "":
+		return newLocalKMSFromFile(cfg.Local.KeyFile, cfg.Local.KeyID)
+	case cfg.Vault.Endpoint != "":
+		return newVaultKMS(cfg.Vault), nil
+	}
+	return nil, nil
+}
+
+// initKMS - initializes the key management service, the environment
+// takes precedence over the server config.
+func initKMS() {
+	cfg, ok := lookupKMSEnv()
+	if !ok {
+		cfg = serverConfig.KMS
+	}
+	kms, err := newKMS(cfg)
+	fatalIf(err, "Unable to initialize the key management service.")
+	globalKMS = kms
+}
+
+// parseMasterKey - parses a master key in the `<key-id>:<hex>` format.
+func parseMasterKey(s string) (keyID string, key [32]byte, err error) {
+	i := strings.LastIndex(s, ":")
+	if i <= 0 {
+		return "", key, errKMSInvalidMasterKey
+	}
+	b, err := hex.DecodeString(strings.TrimSpace(s[i+1:]))
+	if err != nil || len(b) != len(key) {
+		return "", key, errKMSInvalidMasterKey
+	}
+	copy(key[:], b)
+	return strings.TrimSpace(s[:i]), key, nil
+}
+
+// localKMS - KMS which holds all master keys in memory.
+type localKMS struct {
+	keyID string
+	keys  map[string][32]byte
+}
+
+// newLocalKMS - returns a KMS sealing new data keys with the master key
+// keyID, all given keys can be used to unseal data keys.
+func newLocalKMS(keyID string, keys map[string][32]byte) (KMS, error) {
+	if _, ok := keys[keyID]; !ok {
+		return nil, errKMSKeyNotFound
+	}
+	return &localKMS{keyID: keyID, keys: keys}, nil
+}
+
+// newLocalKMSFromFile - returns a KMS with the master keys read from the
+// key file, new data keys are sealed with the master key keyID or, if
+// empty, with the last key of the file.
+func newLocalKMSFromFile(keyFile, keyID string) (KMS, error) {
+	file, err := os.Open(keyFile)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	keys := make(map[string][32]byte)
+	var lastKeyID string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		id, key, perr := parseMasterKey(line)
+		if perr != nil {
+			return nil, perr
+		}
+		keys[id] = key
+		lastKeyID = id
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+	if keyID == "" {
+		keyID = lastKeyID
+	}
+	return newLocalKMS(keyID, keys)
+}
+
+// KeyID - returns the ID of the current master key.
+func (kms *localKMS) KeyID() string {
+	return kms.keyID
+}
+
+// deriveKey - derives an unique key encryption key from the master key,
+// the random IV and the context.
+func (kms *localKMS) deriveKey(keyID string, iv, context []byte) ([]byte, error) {
+	masterKey, ok := kms.keys[keyID]
+	if !ok {
+		return nil, errKMSKeyNotFound
+	}
+	mac := hmac.New(sha256.New, masterKey[:])
+	mac.Write(iv)
+	mac.Write(context)
+	return mac.Sum(nil), nil
+}
+
+// GenerateKey - generates a data key and seals it with the master key,
+// the sealed key is the random IV followed by the encrypted data key.
+func (kms *localKMS) GenerateKey(keyID string, context []byte) (key [32]byte, sealedKey []byte, err error) {
+	if _, err = io.ReadFull(rand.Reader, key[:]); err != nil {
+		return key, nil, err
+	}
+	iv := make([]byte, 32)
+	if _, err = io.ReadFull(rand.Reader, iv); err != nil {
+		return key, nil, err
+	}
+	kek, err := kms.deriveKey(keyID, iv, context)
+	if err != nil {
+		return key, nil, err
+	}
+
+	// The key encryption key is unique per IV, so a fixed nonce is safe.
+	aead, err := newAEAD(kek)
+	if err != nil {
+		return key, nil, err
+	}
+	sealedKey = aead.Seal(iv, make([]byte, aead.NonceSize()), key[:], nil)
+	return key, sealedKey, nil
+}
+
+// UnsealKey - unseals a data key sealed by GenerateKey.
+func (kms *localKMS) UnsealKey(keyID string, sealedKey, context []byte) (key [32]byte, err error) {
+	if len(sealedKey) <= 32 {
+		return key, errKMSSealedKey
+	}
+	kek, err := kms.deriveKey(keyID, sealedKey[:32], context)
+	if err != nil {
+		return key, err
+	}
+	aead, err := newAEAD(kek)
+	if err != nil {
+		return key, err
+	}
+	plaintext, err := aead.Open(nil, make([]byte, aead.NonceSize()), sealedKey[32:], nil)
+	if err != nil || len(plaintext) != len(key) {
+		return key, errKMSSealedKey
+	}
+	copy(key[:], plaintext)
+	return key, nil
+}
+
+// vaultKMS - KMS client for the transit secrets engine of a Vault
+// compatible server. Master keys never leave the server, rotating
+// a transit key makes new data keys use its latest version.
+type vaultKMS struct {
+	endpoint string
+	token    string
+	keyID    string
+	client   *http.Client
+}
+
+// newVaultKMS - returns a KMS client for the configured server.
+func newVaultKMS(cfg vaultKMSConfig) KMS {
+	return &vaultKMS{
+		endpoint: strings.TrimSuffix(cfg.Endpoint, "/"),
+		token:    cfg.Token,
+		keyID:    cfg.KeyID,
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// vaultResponse - response body of the transit secrets engine.
+type vaultResponse struct {
+	Data struct {
+		Plaintext  string `json:"plaintext"`
+		Ciphertext string `json:"ciphertext"`
+	} `json:"data"`
+	Errors []string `json:"errors"`
+}
+
+// KeyID - returns the name of the transit key.
+func (kms *vaultKMS) KeyID() string {
+	return kms.keyID
+}
+
+// post - sends a request to the transit secrets engine.
+func (kms *vaultKMS) post(path string, request interface{}) (*vaultResponse, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest("POST", kms.endpoint+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", kms.token)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := kms.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	response := &vaultResponse{}
+	if err = json.NewDecoder(resp.Body).Decode(response); err != nil && resp.StatusCode == http.StatusOK {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		if len(response.Errors) > 0 {
+			return nil, fmt.Errorf("vault: %s", strings.Join(response.Errors, ", "))
+		}
+		return nil, fmt.Errorf("vault: %s", resp.Status)
+	}
+	return response, nil
+}
+
+// decodeVaultKey - decodes a base64 encoded data key.
+func decodeVaultKey(plaintext string) (key [32]byte, err error) {
+	b, err := base64.StdEncoding.DecodeString(plaintext)
+	if err != nil || len(b) != len(key) {
+		return key, errKMSSealedKey
+	}
+	copy(key[:], b)
+	return key, nil
+}
+
+// GenerateKey - generates a data key with the transit key keyID.
+func (kms *vaultKMS) GenerateKey(keyID string, context []byte) (key [32]byte, sealedKey []byte, err error) {
+	response, err := kms.post("/v1/transit/datakey/plaintext/"+keyID, map[string]string{
+		"context": base64.StdEncoding.EncodeToString(context),
+	})
+	if err != nil {
+		return key, nil, err
+	}
+	if key, err = decodeVaultKey(response.Data.Plaintext); err != nil {
+		return key, nil, err
+	}
+	return key, []byte(response.Data.Ciphertext), nil
+}
+
+// UnsealKey - unseals a data key with the transit key keyID.
+func (kms *vaultKMS) UnsealKey(keyID string, sealedKey, context []byte) (key [32]byte, err error) {
+	response, err := kms.post("/v1/transit/decrypt/"+keyID, map[string]string{
+		"ciphertext": string(sealedKey),
+		"context":    base64.StdEncoding.EncodeToString(context),
+	})
+	if err != nil {
+		return key, err
+	}
+	return decodeVaultKey(response.Data.Plaintext)
+}
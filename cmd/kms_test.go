@@ -0,0 +1,234 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Hex encoded master keys used by the KMS tests.
+const (
+	testMasterKey1 = "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"
+	testMasterKey2 = "1f1e1d1c1b1a191817161514131211100f0e0d0c0b0a09080706050403020100"
+)
+
+// Tests parsing of master keys.
+func TestParseMasterKey(t *testing.T) {
+	testCases := []struct {
+		masterKey   string
+		keyID       string
+		expectedErr error
+	}{
+		{"my-key:" + testMasterKey1, "my-key", nil},
+		{" my-key : " + testMasterKey1, "my-key", nil},
+		{testMasterKey1, "", errKMSInvalidMasterKey},
+		{":" + testMasterKey1, "", errKMSInvalidMasterKey},
+		{"my-key:" + testMasterKey1[2:], "", errKMSInvalidMasterKey},
+		{"my-key:" + strings.Replace(testMasterKey1, "0", "x", 1), "", errKMSInvalidMasterKey},
+	}
+
+	for i, testCase := range testCases {
+		keyID, _, err := parseMasterKey(testCase.masterKey)
+		if err != testCase.expectedErr {
+			t.Errorf("Test %d: Expected error %v, got %v", i+1, testCase.expectedErr, err)
+		}
+		if keyID != testCase.keyID {
+			t.Errorf("Test %d: Expected key ID %s, got %s", i+1, testCase.keyID, keyID)
+		}
+	}
+}
+
+// Tests validation of the KMS configuration.
+func TestKMSConfigValidate(t *testing.T) {
+	testCases := []struct {
+		config      kmsConfig
+		expectedErr error
+	}{
+		{kmsConfig{}, nil},
+		{kmsConfig{masterKey: "my-key:" + testMasterKey1}, nil},
+		{kmsConfig{Local: localKMSConfig{KeyFile: "keys"}}, nil},
+		{kmsConfig{Vault: vaultKMSConfig{Endpoint: "http://vault:8200", KeyID: "minio"}}, nil},
+		{kmsConfig{masterKey: "my-key:" + testMasterKey1, Local: localKMSConfig{KeyFile: "keys"}}, errKMSMultipleBackends},
+		{kmsConfig{Local: localKMSConfig{KeyFile: "keys"}, Vault: vaultKMSConfig{Endpoint: "http://vault:8200"}}, errKMSMultipleBackends},
+	}
+
+	for i, testCase := range testCases {
+		if err := testCase.config.Validate(); err != testCase.expectedErr {
+			t.Errorf("Test %d: Expected error %v, got %v", i+1, testCase.expectedErr, err)
+		}
+	}
+
+	// A vault KMS needs a transit key.
+	if err := (kmsConfig{Vault: vaultKMSConfig{Endpoint: "http://vault:8200"}}).Validate(); err == nil {
+		t.Error("Expected vault config without key ID to fail")
+	}
+}
+
+// Tests generating and unsealing data keys with a KMS.
+func testKMS(t *testing.T, kms KMS) {
+	context := []byte("bucket/object")
+	key, sealedKey, err := kms.GenerateKey(kms.KeyID(), context)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unsealedKey, err := kms.UnsealKey(kms.KeyID(), sealedKey, context)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unsealedKey != key {
+		t.Fatal("Unsealed key does not match the generated key")
+	}
+
+	// Sealed keys are bound to the context.
+	if _, err = kms.UnsealKey(kms.KeyID(), sealedKey, []byte("bucket/other-object")); err == nil {
+		t.Fatal("Expected unsealing with a different context to fail")
+	}
+}
+
+// Tests the local KMS including the rotation of master keys.
+func TestLocalKMS(t *testing.T) {
+	keyFile := filepath.Join(os.TempDir(), "minio-kms-"+mustGetUUID())
+	defer os.Remove(keyFile)
+
+	// The last key of the key file is used for new data keys.
+	keys := "# master keys\nkey-1:" + testMasterKey1 + "\n\nkey-2:" + testMasterKey2 + "\n"
+	if err := ioutil.WriteFile(keyFile, []byte(keys), 0600); err != nil {
+		t.Fatal(err)
+	}
+	kms, err := newKMS(kmsConfig{Local: localKMSConfig{KeyFile: keyFile}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kms.KeyID() != "key-2" {
+		t.Fatalf("Expected current key key-2, got %s", kms.KeyID())
+	}
+	testKMS(t, kms)
+
+	// Data keys sealed with an older master key can still be unsealed.
+	key, sealedKey, err := kms.GenerateKey("key-1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unsealedKey, uerr := kms.UnsealKey("key-1", sealedKey, nil); uerr != nil || unsealedKey != key {
+		t.Fatalf("Unable to unseal key sealed with an older master key: %v", uerr)
+	}
+	if _, err = kms.UnsealKey("key-2", sealedKey, nil); err != errKMSSealedKey {
+		t.Fatalf("Expected %v, got %v", errKMSSealedKey, err)
+	}
+	if _, err = kms.UnsealKey("key-3", sealedKey, nil); err != errKMSKeyNotFound {
+		t.Fatalf("Expected %v, got %v", errKMSKeyNotFound, err)
+	}
+
+	// The current key has to be in the key file.
+	if _, err = newKMS(kmsConfig{Local: localKMSConfig{KeyFile: keyFile, KeyID: "key-3"}}); err != errKMSKeyNotFound {
+		t.Fatalf("Expected %v, got %v", errKMSKeyNotFound, err)
+	}
+
+	// Single master key from the environment.
+	if kms, err = newKMS(kmsConfig{masterKey: "env-key:" + testMasterKey1}); err != nil {
+		t.Fatal(err)
+	}
+	testKMS(t, kms)
+}
+
+// newTestVaultServer - returns a stub of the transit secrets engine of a
+// Vault server, sealing data keys with a local KMS.
+func newTestVaultServer(t *testing.T, token string) *httptest.Server {
+	kms, err := newLocalKMS("minio", map[string][32]byte{"minio": {1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeError := func(status int, message string) {
+			w.WriteHeader(status)
+			json.NewEncoder(w).Encode(map[string][]string{"errors": {message}})
+		}
+		if r.Header.Get("X-Vault-Token") != token {
+			writeError(http.StatusForbidden, "permission denied")
+			return
+		}
+
+		var request map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			writeError(http.StatusBadRequest, err.Error())
+			return
+		}
+		context, err := base64.StdEncoding.DecodeString(request["context"])
+		if err != nil {
+			writeError(http.StatusBadRequest, err.Error())
+			return
+		}
+
+		response := vaultResponse{}
+		switch r.URL.Path {
+		case "/v1/transit/datakey/plaintext/minio":
+			key, sealedKey, gerr := kms.GenerateKey("minio", context)
+			if gerr != nil {
+				writeError(http.StatusInternalServerError, gerr.Error())
+				return
+			}
+			response.Data.Plaintext = base64.StdEncoding.EncodeToString(key[:])
+			response.Data.Ciphertext = "vault:v1:" + base64.StdEncoding.EncodeToString(sealedKey)
+		case "/v1/transit/decrypt/minio":
+			sealedKey, derr := base64.StdEncoding.DecodeString(strings.TrimPrefix(request["ciphertext"], "vault:v1:"))
+			if derr != nil {
+				writeError(http.StatusBadRequest, derr.Error())
+				return
+			}
+			key, uerr := kms.UnsealKey("minio", sealedKey, context)
+			if uerr != nil {
+				writeError(http.StatusBadRequest, "cipher: message authentication failed")
+				return
+			}
+			response.Data.Plaintext = base64.StdEncoding.EncodeToString(key[:])
+		default:
+			writeError(http.StatusNotFound, "no handler for route")
+			return
+		}
+		json.NewEncoder(w).Encode(response)
+	}))
+}
+
+// Tests the vault KMS client against a local stub.
+func TestVaultKMS(t *testing.T) {
+	server := newTestVaultServer(t, "secret-token")
+	defer server.Close()
+
+	kms, err := newKMS(kmsConfig{Vault: vaultKMSConfig{Endpoint: server.URL + "/", Token: "secret-token", KeyID: "minio"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	testKMS(t, kms)
+
+	// Errors of the server are returned.
+	kms = newVaultKMS(vaultKMSConfig{Endpoint: server.URL, Token: "wrong-token", KeyID: "minio"})
+	if _, _, err = kms.GenerateKey("minio", nil); err == nil || !strings.Contains(err.Error(), "permission denied") {
+		t.Fatalf("Expected permission denied error, got %v", err)
+	}
+	kms = newVaultKMS(vaultKMSConfig{Endpoint: server.URL, Token: "secret-token", KeyID: "unknown"})
+	if _, _, err = kms.GenerateKey("unknown", nil); err == nil {
+		t.Fatal("Expected unknown transit key to fail")
+	}
+}
@@ -17,6 +17,7 @@
 package cmd
 
 import (
+	"fmt"
 	"runtime"
 	"sync"
 	"testing"
@@ -489,3 +490,58 @@ func TestLockServers(t *testing.T) {
 		}
 	}
 }
+
+// Test initialization of distributed locking with more endpoints than
+// lock clients supported by dsync.
+func TestNewDsyncNodes(t *testing.T) {
+	if runtime.GOOS == globalWindowsOSName {
+		return
+	}
+
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatalf("Init Test config failed")
+	}
+	defer removeAll(rootPath)
+
+	// 4 servers with 8 disks each.
+	var args []string
+	for _, host := range []string{"localhost:9000", "1.1.1.2:9000", "1.1.2.1:9000", "1.1.2.2:9000"} {
+		for i := 1; i <= 8; i++ {
+			args = append(args, fmt.Sprintf("http://%s/mnt/disk%d", host, i))
+		}
+	}
+	endpoints := mustGetNewEndpointList(args...)
+	for i := range endpoints {
+		if endpoints[i].Host == "localhost:9000" {
+			endpoints[i].IsLocal = true
+		}
+	}
+
+	globalLockServers = nil
+	clnts, myNode := newDsyncNodes(endpoints)
+	if len(clnts) != dsyncMaxNodes {
+		t.Fatalf("Expected %d lock clients, got %d", dsyncMaxNodes, len(clnts))
+	}
+	if myNode == -1 || clnts[myNode] != &globalLockServers[0].ll {
+		t.Fatalf("Expected node %d to be a local lock server", myNode)
+	}
+
+	// Lock clients are spread over all servers.
+	hostClnts := make(map[string]int)
+	for _, clnt := range clnts {
+		hostClnts[clnt.ServerAddr()]++
+	}
+	for host, count := range hostClnts {
+		if count != 4 {
+			t.Fatalf("Expected 4 lock clients on %s, got %d", host, count)
+		}
+	}
+	if len(hostClnts) != 4 || len(globalLockServers) != 4 {
+		t.Fatalf("Expected lock clients on 4 servers with 4 local lock servers, got %d servers with %d local lock servers", len(hostClnts), len(globalLockServers))
+	}
+
+	if err = dsync.Init(clnts, myNode); err != nil {
+		t.Fatalf("Unable to initialize distributed locking: %s", err)
+	}
+}
@@ -0,0 +1,180 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+)
+
+const (
+	// Number of recent log entries kept in memory.
+	logBufferSize = 2000
+
+	// Time a log poll waits for new log entries.
+	logPollTimeout = time.Second
+)
+
+// LogEntry - a log entry of a server.
+type LogEntry struct {
+	// Sequence number of the entry on its server, not exposed to
+	// clients.
+	Seq uint64 `json:"-"`
+	// Level of the entry, used to filter entries on their server.
+	level logrus.Level
+
+	Node    string    `json:"node"`
+	Time    time.Time `json:"time"`
+	Level   string    `json:"level"`
+	Message string    `json:"message"`
+	Source  string    `json:"source,omitempty"`
+	Cause   string    `json:"cause,omitempty"`
+	Stack   string    `json:"stack,omitempty"`
+}
+
+// newLogEntry - returns the log entry of a logrus entry.
+func newLogEntry(entry *logrus.Entry) LogEntry {
+	logEntry := LogEntry{
+		Time:    entry.Time.UTC(),
+		Level:   entry.Level.String(),
+		Message: entry.Message,
+		level:   entry.Level,
+	}
+	if source, ok := entry.Data["source"]; ok {
+		logEntry.Source = fmt.Sprint(source)
+	}
+	if cause, ok := entry.Data["cause"]; ok {
+		logEntry.Cause = fmt.Sprint(cause)
+	}
+	if stack, ok := entry.Data["stack"]; ok {
+		logEntry.Stack = fmt.Sprint(stack)
+	}
+	return logEntry
+}
+
+// logBuffer - a ring buffer of the recent log entries of this server.
+// Entries are numbered in sequence, which lets clients poll for the
+// entries added since their last poll.
+type logBuffer struct {
+	sync.Mutex
+	entries []LogEntry
+	// Index of the oldest entry.
+	start int
+	// Sequence number of the newest entry.
+	lastSeq uint64
+	// Closed and replaced when an entry is added.
+	addedCh chan struct{}
+}
+
+// newLogBuffer - returns an empty log buffer keeping up to size
+// entries.
+func newLogBuffer(size int) *logBuffer {
+	return &logBuffer{
+		entries: make([]LogEntry, 0, size),
+		addedCh: make(chan struct{}),
+	}
+}
+
+// Fire - adds a logrus entry to the buffer, replacing the oldest entry
+// once the buffer is full.
+func (b *logBuffer) Fire(entry *logrus.Entry) error {
+	logEntry := newLogEntry(entry)
+
+	b.Lock()
+	defer b.Unlock()
+	b.lastSeq++
+	logEntry.Seq = b.lastSeq
+	if len(b.entries) < cap(b.entries) {
+		b.entries = append(b.entries, logEntry)
+	} else {
+		b.entries[b.start] = logEntry
+		b.start = (b.start + 1) % len(b.entries)
+	}
+	close(b.addedCh)
+	b.addedCh = make(chan struct{})
+	return nil
+}
+
+// String - represents the log buffer as string.
+func (b *logBuffer) String() string {
+	return "memory"
+}
+
+// since - returns the buffered entries at or above level added after
+// the entry with sequence number seq, along with the sequence number
+// of the newest entry and a channel closed when an entry is added. A
+// negative seq returns no entries and a seq beyond the newest entry,
+// left over from before a restart of the server, returns all entries.
+func (b *logBuffer) since(seq int64, level logrus.Level) ([]LogEntry, uint64, <-chan struct{}) {
+	b.Lock()
+	defer b.Unlock()
+
+	var entries []LogEntry
+	if seq < 0 {
+		return entries, b.lastSeq, b.addedCh
+	}
+	if uint64(seq) > b.lastSeq {
+		seq = 0
+	}
+	for i := range b.entries {
+		entry := b.entries[(b.start+i)%len(b.entries)]
+		if entry.Seq <= uint64(seq) {
+			continue
+		}
+		// Lower logrus levels are more severe.
+		if entry.level > level {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, b.lastSeq, b.addedCh
+}
+
+// poll - returns the entries at or above level added after the entry
+// with sequence number seq, waiting up to the poll timeout for new
+// entries when there are none.
+func (b *logBuffer) poll(seq int64, level logrus.Level) ([]LogEntry, uint64) {
+	timer := time.NewTimer(logPollTimeout)
+	defer timer.Stop()
+	for {
+		entries, lastSeq, addedCh := b.since(seq, level)
+		if len(entries) > 0 {
+			return entries, lastSeq
+		}
+		select {
+		case <-addedCh:
+			seq = int64(lastSeq)
+		case <-timer.C:
+			return nil, lastSeq
+		}
+	}
+}
+
+// getLogs - returns the entries of the log buffer of this server at or
+// above level added after the entry with sequence number seq, along
+// with the sequence number of the newest entry. With wait, it waits
+// up to the poll timeout for new entries when there are none.
+func getLogs(seq int64, level logrus.Level, wait bool) ([]LogEntry, uint64) {
+	if wait {
+		return log.buffer.poll(seq, level)
+	}
+	entries, lastSeq, _ := log.buffer.since(seq, level)
+	return entries, lastSeq
+}
@@ -0,0 +1,218 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	router "github.com/gorilla/mux"
+	"github.com/minio/minio/pkg/madmin"
+)
+
+// Adds an entry with the given level and message to a log buffer.
+func addLogEntry(b *logBuffer, level logrus.Level, msg string) {
+	b.Fire(&logrus.Entry{
+		Time:    time.Now(),
+		Level:   level,
+		Message: msg,
+		Data:    logrus.Fields{"source": "[log-buffer_test.go:1:addLogEntry()]", "cause": "cause"},
+	})
+}
+
+// Tests the ring buffer of log entries.
+func TestLogBuffer(t *testing.T) {
+	b := newLogBuffer(3)
+	for i := 1; i <= 5; i++ {
+		level := logrus.ErrorLevel
+		if i%2 == 0 {
+			level = logrus.InfoLevel
+		}
+		addLogEntry(b, level, fmt.Sprintf("entry %d", i))
+	}
+
+	// Only the 3 most recent entries are kept.
+	entries, lastSeq, _ := b.since(0, logrus.DebugLevel)
+	if lastSeq != 5 || len(entries) != 3 {
+		t.Fatalf("Expected 3 entries up to 5, got %d entries up to %d", len(entries), lastSeq)
+	}
+	for i, entry := range entries {
+		if expected := fmt.Sprintf("entry %d", i+3); entry.Message != expected {
+			t.Errorf("Expected entry %q, got %q", expected, entry.Message)
+		}
+	}
+	if entries[0].Level != "error" || entries[0].Source == "" || entries[0].Cause != "cause" {
+		t.Errorf("Unexpected entry %+v", entries[0])
+	}
+
+	testCases := []struct {
+		seq      int64
+		level    logrus.Level
+		expected []string
+	}{
+		// Entries at or above a level.
+		{0, logrus.ErrorLevel, []string{"entry 3", "entry 5"}},
+		// Entries added after a sequence number.
+		{4, logrus.DebugLevel, []string{"entry 5"}},
+		{5, logrus.DebugLevel, nil},
+		// Entries added from now on.
+		{-1, logrus.DebugLevel, nil},
+		// Sequence numbers of a restarted server return all entries.
+		{10, logrus.DebugLevel, []string{"entry 3", "entry 4", "entry 5"}},
+	}
+	for i, testCase := range testCases {
+		entries, _, _ = b.since(testCase.seq, testCase.level)
+		var messages []string
+		for _, entry := range entries {
+			messages = append(messages, entry.Message)
+		}
+		if fmt.Sprint(messages) != fmt.Sprint(testCase.expected) {
+			t.Errorf("Test %d: Expected %v, got %v", i+1, testCase.expected, messages)
+		}
+	}
+}
+
+// Tests the log entries of errors logged by a logger.
+func TestLoggerBuffer(t *testing.T) {
+	l := NewLogger()
+	l.SetConsoleTarget(ConsoleLogger{})
+	l.logger.WithFields(logrus.Fields{
+		"source": "[log-buffer_test.go:1:TestLoggerBuffer()]",
+		"cause":  errors.New("disk not found").Error(),
+	}).Errorf("Unable to heal object %s.", "bucket/object")
+
+	entries, _, _ := l.buffer.since(0, logrus.DebugLevel)
+	if len(entries) != 1 || entries[0].Message != "Unable to heal object bucket/object." ||
+		entries[0].Level != "error" || entries[0].Cause != "disk not found" {
+		t.Fatalf("Unexpected entries %+v", entries)
+	}
+}
+
+// Tests waiting for new log entries.
+func TestLogBufferPoll(t *testing.T) {
+	b := newLogBuffer(10)
+	addLogEntry(b, logrus.ErrorLevel, "old")
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		addLogEntry(b, logrus.InfoLevel, "filtered")
+		addLogEntry(b, logrus.ErrorLevel, "new")
+	}()
+	entries, lastSeq := b.poll(-1, logrus.ErrorLevel)
+	if len(entries) != 1 || entries[0].Message != "new" || lastSeq != 3 {
+		t.Fatalf("Expected the new entry, got %+v up to %d", entries, lastSeq)
+	}
+
+	// Polls without new entries time out.
+	if entries, lastSeq = b.poll(int64(lastSeq), logrus.DebugLevel); len(entries) != 0 || lastSeq != 3 {
+		t.Fatalf("Expected no entries, got %+v up to %d", entries, lastSeq)
+	}
+}
+
+// Tests fetching and streaming logs through the admin API.
+func TestLogsHandler(t *testing.T) {
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatalf("Init Test config failed")
+	}
+	defer removeAll(rootPath)
+	defer func(connAlive time.Duration) { globalSNSConnAlive = connAlive }(globalSNSConnAlive)
+	globalSNSConnAlive = 100 * time.Millisecond
+
+	initGlobalAdminPeers(mustGetNewEndpointList("http://127.0.0.1:9000/d1"))
+	adminRouter := router.NewRouter()
+	registerAdminRouter(adminRouter)
+
+	server := httptest.NewServer(adminRouter)
+	defer server.Close()
+
+	cred := serverConfig.GetCredential()
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	adminClient, err := madmin.New(u.Host, cred.AccessKey, cred.SecretKey, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Logging is disabled in tests, entries are added to the buffer
+	// of the logger directly.
+	addLogEntry(log.buffer, logrus.ErrorLevel, "Unable to heal object bucket/object.")
+	serversLogs, err := adminClient.GetLogs(globalAdminPeers[0].addr, "error", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(serversLogs) != 1 || len(serversLogs[0].Entries) != 1 {
+		t.Fatalf("Expected one entry of one server, got %+v", serversLogs)
+	}
+	entry := serversLogs[0].Entries[0]
+	if entry.Node != globalAdminPeers[0].addr || entry.Level != "error" ||
+		entry.Message != "Unable to heal object bucket/object." || entry.Cause != "cause" {
+		t.Errorf("Unexpected entry %+v", entry)
+	}
+
+	// Unknown nodes and levels are rejected.
+	if _, err = adminClient.GetLogs("unknown:9000", "", 0); err == nil {
+		t.Error("Expected an error for an unknown node")
+	}
+	if _, err = adminClient.GetLogs("", "unknown", 0); err == nil {
+		t.Error("Expected an error for an unknown level")
+	}
+
+	// Log until the stream has picked up an entry, entries logged
+	// before the stream started are not streamed.
+	doneCh := make(chan struct{})
+	logCh := adminClient.StreamLogs("", "error", doneCh)
+	timeout := time.After(5 * time.Second)
+	func() {
+		for {
+			addLogEntry(log.buffer, logrus.ErrorLevel, "Unable to heal bucket.")
+			select {
+			case entry := <-logCh:
+				if entry.Err != nil {
+					t.Fatal(entry.Err)
+				}
+				if entry.Node != globalAdminPeers[0].addr || entry.Message != "Unable to heal bucket." {
+					t.Errorf("Unexpected entry %+v", entry)
+				}
+				return
+			case <-time.After(100 * time.Millisecond):
+			case <-timeout:
+				t.Fatal("Timed out waiting for the log entry")
+			}
+		}
+	}()
+
+	// The log channel is closed once the client is done.
+	close(doneCh)
+	for {
+		select {
+		case _, ok := <-logCh:
+			if !ok {
+				return
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Timed out waiting for the log stream to end")
+		}
+	}
+}
@@ -95,6 +95,8 @@ type Logger struct {
 	consoleTarget ConsoleLogger
 	targets       []LogTarget
 	quiet         bool
+	// Keeps the recent entries for the admin logs API.
+	buffer *logBuffer
 }
 
 // AddTarget - add logger to this hook.
@@ -109,6 +111,8 @@ func (log *Logger) SetConsoleTarget(consoleTarget ConsoleLogger) {
 
 // Fire - log entry handler to save logs.
 func (log *Logger) Fire(entry *logrus.Entry) (err error) {
+	log.buffer.Fire(entry)
+
 	if err = log.consoleTarget.Fire(entry); err != nil {
 		log.Printf("Unable to log to console target. %s\n", err)
 	}
@@ -162,6 +166,7 @@ func NewLogger() *Logger {
 	l := &Logger{
 		logger:        logger,
 		consoleTarget: NewConsoleLogger(),
+		buffer:        newLogBuffer(logBufferSize),
 	}
 
 	// Adds a console logger.
@@ -0,0 +1,31 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import router "github.com/gorilla/mux"
+
+const (
+	prometheusMetricsPath = "/prometheus/metrics"
+)
+
+// registerMetricsRouter - add handler function for the prometheus
+// metrics endpoint.
+func registerMetricsRouter(mux *router.Router) {
+	// metrics router
+	metricsRouter := mux.NewRoute().PathPrefix(minioReservedBucketPath).Subrouter()
+	metricsRouter.Methods("GET").Path(prometheusMetricsPath).HandlerFunc(metricsHandler)
+}
@@ -0,0 +1,348 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go.uber.org/atomic"
+)
+
+// Authentication types of the prometheus metrics endpoint.
+const (
+	// Requests need a JWT bearer token signed with the server
+	// credentials.
+	prometheusJWT = "jwt"
+
+	// Requests are not authenticated.
+	prometheusPublic = "public"
+)
+
+// Content type of the prometheus text format.
+const prometheusContentType = "text/plain; version=0.0.4"
+
+// healStats - counts the buckets and objects healed since the server
+// started.
+type healStats struct {
+	bucketsHealed atomic.Uint64
+	bucketsFailed atomic.Uint64
+	objectsHealed atomic.Uint64
+	objectsFailed atomic.Uint64
+
+	// Number of disks objects were healed on.
+	objectDisksHealed atomic.Uint64
+}
+
+// updateBucket - records the outcome of healing a bucket.
+func (s *healStats) updateBucket(err error) {
+	if err != nil {
+		s.bucketsFailed.Inc()
+		return
+	}
+	s.bucketsHealed.Inc()
+}
+
+// updateObject - records the outcome of healing an object.
+func (s *healStats) updateObject(healedDisks int, err error) {
+	if err != nil {
+		s.objectsFailed.Inc()
+		return
+	}
+	s.objectsHealed.Inc()
+	s.objectDisksHealed.Add(uint64(healedDisks))
+}
+
+// Variable represents the events being sent to each notification
+// target.
+var globalNotifyInflightEvents = &notifyInflightEvents{inflight: make(map[string]int64)}
+
+// notifyInflightEvents - number of events being sent to notification
+// targets, by target ARN. Targets have no queue, events are sent
+// synchronously by the requests which caused them, so this counts
+// the requests waiting on a target.
+type notifyInflightEvents struct {
+	sync.Mutex
+	inflight map[string]int64
+}
+
+// inc - records an event being sent to a target.
+func (n *notifyInflightEvents) inc(arn string) {
+	n.Lock()
+	defer n.Unlock()
+	n.inflight[arn]++
+}
+
+// dec - records an event sent to a target.
+func (n *notifyInflightEvents) dec(arn string) {
+	n.Lock()
+	defer n.Unlock()
+	n.inflight[arn]--
+}
+
+// get - returns the events being sent to a target.
+func (n *notifyInflightEvents) get(arn string) int64 {
+	n.Lock()
+	defer n.Unlock()
+	return n.inflight[arn]
+}
+
+// metricsWriter - formats metrics in the prometheus text format.
+type metricsWriter struct {
+	bytes.Buffer
+}
+
+// describe - writes the help and type lines of a metric.
+func (m *metricsWriter) describe(name, help, metricType string) {
+	fmt.Fprintf(m, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, metricType)
+}
+
+// sample - writes a sample of a metric, labels are name and value
+// pairs.
+func (m *metricsWriter) sample(name string, value float64, labels ...string) {
+	m.WriteString(name)
+	if len(labels) > 0 {
+		m.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				m.WriteByte(',')
+			}
+			m.WriteString(labels[i] + `="` + escapeMetricLabel(labels[i+1]) + `"`)
+		}
+		m.WriteByte('}')
+	}
+	m.WriteByte(' ')
+	m.WriteString(formatMetricValue(value))
+	m.WriteByte('\n')
+}
+
+// Escapes backslashes, double quotes and newlines of label values.
+var metricLabelReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// escapeMetricLabel - escapes a label value of the text format.
+func escapeMetricLabel(value string) string {
+	return metricLabelReplacer.Replace(value)
+}
+
+// formatMetricValue - formats a sample value of the text format.
+func formatMetricValue(value float64) string {
+	if math.IsInf(value, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// byAPIRequestKey - sorts request keys by API and status.
+type byAPIRequestKey []apiRequestKey
+
+func (k byAPIRequestKey) Len() int      { return len(k) }
+func (k byAPIRequestKey) Swap(i, j int) { k[i], k[j] = k[j], k[i] }
+func (k byAPIRequestKey) Less(i, j int) bool {
+	if k[i].api != k[j].api {
+		return k[i].api < k[j].api
+	}
+	return k[i].status < k[j].status
+}
+
+// writeAPIMetrics - writes request counts and latency histograms per
+// API.
+func writeAPIMetrics(m *metricsWriter, st *APIStats) {
+	st.Lock()
+	defer st.Unlock()
+
+	keys := make([]apiRequestKey, 0, len(st.requests))
+	for key := range st.requests {
+		keys = append(keys, key)
+	}
+	sort.Sort(byAPIRequestKey(keys))
+	m.describe("minio_http_requests_total", "Total number of requests by API and response status.", "counter")
+	for _, key := range keys {
+		m.sample("minio_http_requests_total", float64(st.requests[key]),
+			"api", key.api, "status", strconv.Itoa(key.status))
+	}
+
+	apis := make([]string, 0, len(st.latency))
+	for api := range st.latency {
+		apis = append(apis, api)
+	}
+	sort.Strings(apis)
+	m.describe("minio_http_request_duration_seconds", "Time taken to serve requests by API.", "histogram")
+	for _, api := range apis {
+		latency := st.latency[api]
+		var cumulative uint64
+		for i, count := range latency.counts {
+			cumulative += count
+			le := math.Inf(1)
+			if i < len(apiLatencyBuckets) {
+				le = apiLatencyBuckets[i]
+			}
+			m.sample("minio_http_request_duration_seconds_bucket", float64(cumulative),
+				"api", api, "le", formatMetricValue(le))
+		}
+		m.sample("minio_http_request_duration_seconds_sum", latency.sum, "api", api)
+		m.sample("minio_http_request_duration_seconds_count", float64(latency.count), "api", api)
+	}
+}
+
+// writeNetworkMetrics - writes the bytes received and sent.
+func writeNetworkMetrics(m *metricsWriter, st *ConnStats) {
+	m.describe("minio_network_received_bytes_total", "Total number of bytes received.", "counter")
+	m.sample("minio_network_received_bytes_total", float64(st.getTotalInputBytes()))
+	m.describe("minio_network_sent_bytes_total", "Total number of bytes sent.", "counter")
+	m.sample("minio_network_sent_bytes_total", float64(st.getTotalOutputBytes()))
+}
+
+// writeDiskMetrics - writes the state and space of the disks of the
+// object layer, disks which were not found when the server started
+// are only counted as offline.
+func writeDiskMetrics(m *metricsWriter, objAPI ObjectLayer) {
+	type diskState struct {
+		name        string
+		online      bool
+		total, free int64
+	}
+	var disks []diskState
+	var offline int
+	addStorageDisks := func(storageDisks []StorageAPI) {
+		for _, storageDisk := range storageDisks {
+			if storageDisk == nil {
+				offline++
+				continue
+			}
+			info, err := storageDisk.DiskInfo()
+			disks = append(disks, diskState{storageDisk.String(), err == nil, info.Total, info.Free})
+		}
+	}
+	switch layer := objAPI.(type) {
+	case *xlObjects:
+		addStorageDisks(layer.storageDisks)
+	case *xlSets:
+		for _, set := range layer.sets {
+			addStorageDisks(set.storageDisks)
+		}
+	case *fsObjects:
+		info, err := getDiskInfo(preparePath(layer.fsPath))
+		disks = append(disks, diskState{layer.fsPath, err == nil, info.Total, info.Free})
+	default:
+		// Gateways have no local disks.
+		return
+	}
+
+	for _, disk := range disks {
+		if !disk.online {
+			offline++
+		}
+	}
+	m.describe("minio_disks_offline", "Number of offline disks.", "gauge")
+	m.sample("minio_disks_offline", float64(offline))
+	m.describe("minio_disk_online", "Whether a disk is online (1) or offline (0).", "gauge")
+	for _, disk := range disks {
+		online := 0.0
+		if disk.online {
+			online = 1
+		}
+		m.sample("minio_disk_online", online, "disk", disk.name)
+	}
+	m.describe("minio_disk_total_bytes", "Total space of a disk.", "gauge")
+	for _, disk := range disks {
+		if disk.online {
+			m.sample("minio_disk_total_bytes", float64(disk.total), "disk", disk.name)
+		}
+	}
+	m.describe("minio_disk_free_bytes", "Free space of a disk.", "gauge")
+	for _, disk := range disks {
+		if disk.online {
+			m.sample("minio_disk_free_bytes", float64(disk.free), "disk", disk.name)
+		}
+	}
+}
+
+// writeHealMetrics - writes the healed buckets and objects.
+func writeHealMetrics(m *metricsWriter, st *healStats) {
+	m.describe("minio_heal_buckets_total", "Total number of buckets healed by result.", "counter")
+	m.sample("minio_heal_buckets_total", float64(st.bucketsHealed.Load()), "result", "success")
+	m.sample("minio_heal_buckets_total", float64(st.bucketsFailed.Load()), "result", "failure")
+	m.describe("minio_heal_objects_total", "Total number of objects healed by result.", "counter")
+	m.sample("minio_heal_objects_total", float64(st.objectsHealed.Load()), "result", "success")
+	m.sample("minio_heal_objects_total", float64(st.objectsFailed.Load()), "result", "failure")
+	m.describe("minio_heal_object_disks_total", "Total number of disks objects were healed on.", "counter")
+	m.sample("minio_heal_object_disks_total", float64(st.objectDisksHealed.Load()))
+}
+
+// writeLockMetrics - writes the namespace lock counts.
+func writeLockMetrics(m *metricsWriter, nsMutex *nsLockMap) {
+	if nsMutex == nil {
+		return
+	}
+	nsMutex.lockMapMutex.Lock()
+	counters := *nsMutex.counters
+	nsMutex.lockMapMutex.Unlock()
+
+	m.describe("minio_locks_waiting", "Number of namespace locks waiting to be granted.", "gauge")
+	m.sample("minio_locks_waiting", float64(counters.blocked))
+	m.describe("minio_locks_granted", "Number of namespace locks held.", "gauge")
+	m.sample("minio_locks_granted", float64(counters.granted))
+	m.describe("minio_locks_total", "Number of namespace locks held or waiting.", "gauge")
+	m.sample("minio_locks_total", float64(counters.total))
+}
+
+// writeNotifyMetrics - writes the events being sent to each
+// notification target.
+func writeNotifyMetrics(m *metricsWriter, en *eventNotifier) {
+	if en == nil {
+		return
+	}
+	var arns []string
+	for arn := range en.GetAllExternalTargets() {
+		arns = append(arns, arn)
+	}
+	sort.Strings(arns)
+	m.describe("minio_notify_target_inflight_events", "Number of events being sent to a notification target.", "gauge")
+	for _, arn := range arns {
+		m.sample("minio_notify_target_inflight_events", float64(globalNotifyInflightEvents.get(arn)), "target", arn)
+	}
+}
+
+// metricsHandler - serves the metrics of this server in the
+// prometheus text format.
+func metricsHandler(w http.ResponseWriter, r *http.Request) {
+	// JWT bearer tokens are validated by the auth handler, only
+	// their presence is checked here.
+	if globalPrometheusAuthType == prometheusJWT && getRequestAuthType(r) != authTypeJWT {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	m := &metricsWriter{}
+	writeAPIMetrics(m, globalAPIStats)
+	writeNetworkMetrics(m, globalConnStats)
+	if objAPI := newObjectLayerFn(); objAPI != nil {
+		writeDiskMetrics(m, objAPI)
+	}
+	writeHealMetrics(m, globalHealStats)
+	writeLockMetrics(m, globalNSMutex)
+	writeNotifyMetrics(m, globalEventNotifier)
+
+	w.Header().Set("Content-Type", prometheusContentType)
+	w.Write(m.Bytes())
+}
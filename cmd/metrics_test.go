@@ -0,0 +1,185 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	router "github.com/gorilla/mux"
+)
+
+// Tests formatting of samples in the prometheus text format.
+func TestMetricsWriter(t *testing.T) {
+	m := &metricsWriter{}
+	m.describe("minio_test", "Test metric.", "gauge")
+	m.sample("minio_test", 1.5)
+	m.sample("minio_test", 2, "a", "x", "b", "say \"hi\"\\\n")
+	expected := "# HELP minio_test Test metric.\n# TYPE minio_test gauge\n" +
+		"minio_test 1.5\n" +
+		"minio_test{a=\"x\",b=\"say \\\"hi\\\"\\\\\\n\"} 2\n"
+	if m.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, m.String())
+	}
+}
+
+// Tests request counts and latency histograms per API.
+func TestAPIMetrics(t *testing.T) {
+	st := newAPIStats()
+	st.updateStats("REST.GET.OBJECT", http.StatusOK, 0.003)
+	st.updateStats("REST.GET.OBJECT", http.StatusOK, 0.2)
+	st.updateStats("REST.GET.OBJECT", http.StatusNotFound, 20)
+	st.updateStats("REST.PUT.OBJECT", http.StatusOK, 0.01)
+
+	m := &metricsWriter{}
+	writeAPIMetrics(m, st)
+	for _, sample := range []string{
+		`minio_http_requests_total{api="REST.GET.OBJECT",status="200"} 2`,
+		`minio_http_requests_total{api="REST.GET.OBJECT",status="404"} 1`,
+		`minio_http_requests_total{api="REST.PUT.OBJECT",status="200"} 1`,
+		`minio_http_request_duration_seconds_bucket{api="REST.GET.OBJECT",le="0.005"} 1`,
+		`minio_http_request_duration_seconds_bucket{api="REST.GET.OBJECT",le="0.1"} 1`,
+		`minio_http_request_duration_seconds_bucket{api="REST.GET.OBJECT",le="0.25"} 2`,
+		`minio_http_request_duration_seconds_bucket{api="REST.GET.OBJECT",le="10"} 2`,
+		`minio_http_request_duration_seconds_bucket{api="REST.GET.OBJECT",le="+Inf"} 3`,
+		`minio_http_request_duration_seconds_count{api="REST.GET.OBJECT"} 3`,
+		`minio_http_request_duration_seconds_bucket{api="REST.PUT.OBJECT",le="0.01"} 1`,
+		`minio_http_request_duration_seconds_count{api="REST.PUT.OBJECT"} 1`,
+	} {
+		if !strings.Contains(m.String(), sample+"\n") {
+			t.Errorf("Expected sample %s in\n%s", sample, m.String())
+		}
+	}
+}
+
+// Tests heal counters.
+func TestHealMetrics(t *testing.T) {
+	st := &healStats{}
+	st.updateBucket(nil)
+	st.updateBucket(errors.New("failed"))
+	st.updateObject(2, nil)
+	st.updateObject(1, nil)
+	st.updateObject(0, errors.New("failed"))
+
+	m := &metricsWriter{}
+	writeHealMetrics(m, st)
+	for _, sample := range []string{
+		`minio_heal_buckets_total{result="success"} 1`,
+		`minio_heal_buckets_total{result="failure"} 1`,
+		`minio_heal_objects_total{result="success"} 2`,
+		`minio_heal_objects_total{result="failure"} 1`,
+		`minio_heal_object_disks_total 3`,
+	} {
+		if !strings.Contains(m.String(), sample+"\n") {
+			t.Errorf("Expected sample %s in\n%s", sample, m.String())
+		}
+	}
+}
+
+// Tests the authentication and content of the metrics endpoint.
+func TestMetricsHandler(t *testing.T) {
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatalf("Init Test config failed")
+	}
+	defer removeAll(rootPath)
+
+	for _, prepare := range []func() (ObjectLayer, []string, error){
+		func() (ObjectLayer, []string, error) {
+			obj, dir, perr := prepareFS()
+			return obj, []string{dir}, perr
+		},
+		prepareXL,
+	} {
+		obj, dirs, perr := prepare()
+		if perr != nil {
+			t.Fatal(perr)
+		}
+		testMetricsHandler(t, obj, len(dirs))
+		removeRoots(dirs)
+	}
+}
+
+func testMetricsHandler(t *testing.T, obj ObjectLayer, numDisks int) {
+	initNSLock(false)
+	globalObjLayerMutex.Lock()
+	globalObjectAPI = obj
+	globalObjLayerMutex.Unlock()
+	defer resetGlobalObjectAPI()
+	defer func(authType string) { globalPrometheusAuthType = authType }(globalPrometheusAuthType)
+
+	mux := router.NewRouter()
+	registerMetricsRouter(mux)
+	handler := setAuthHandler(mux)
+
+	sendRequest := func(token string) *httptest.ResponseRecorder {
+		req, rerr := http.NewRequest("GET", "http://localhost:9000/minio/prometheus/metrics", nil)
+		if rerr != nil {
+			t.Fatal(rerr)
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// JWT bearer tokens are required by default.
+	globalPrometheusAuthType = prometheusJWT
+	if rec := sendRequest(""); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("Expected status %d, got %d", http.StatusUnauthorized, rec.Code)
+	}
+	if rec := sendRequest("invalid"); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("Expected status %d, got %d", http.StatusUnauthorized, rec.Code)
+	}
+	cred := serverConfig.GetCredential()
+	token, err := authenticateWeb(cred.AccessKey, cred.SecretKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := sendRequest(token)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+	if contentType := rec.Header().Get("Content-Type"); contentType != prometheusContentType {
+		t.Errorf("Expected content type %s, got %s", prometheusContentType, contentType)
+	}
+	body := rec.Body.String()
+	for _, sample := range []string{
+		"minio_disks_offline 0",
+		"minio_network_received_bytes_total ",
+		"minio_locks_waiting 0",
+		"minio_heal_objects_total{result=\"success\"} ",
+	} {
+		if !strings.Contains(body, sample) {
+			t.Errorf("Expected sample %s in\n%s", sample, body)
+		}
+	}
+	if count := strings.Count(body, "minio_disk_online{"); count != numDisks {
+		t.Errorf("Expected %d disks, got %d", numDisks, count)
+	}
+
+	// Public metrics need no authentication.
+	globalPrometheusAuthType = prometheusPublic
+	if rec = sendRequest(""); rec.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+}
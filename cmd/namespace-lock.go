@@ -38,10 +38,43 @@ type RWLocker interface {
 	RUnlock()
 }
 
+// Maximum number of lock clients supported by dsync.
+const dsyncMaxNodes = 16
+
+// getLockEndpoints - returns the endpoints serving distributed locks.
+// Dsync supports at most 16 lock clients, with more endpoints they are
+// picked from every server in turn, so that each server keeps at least
+// one local lock server.
+func getLockEndpoints(endpoints EndpointList) EndpointList {
+	if len(endpoints) <= dsyncMaxNodes {
+		return endpoints
+	}
+
+	var hosts []string
+	hostEndpoints := make(map[string]EndpointList)
+	for _, endpoint := range endpoints {
+		if _, ok := hostEndpoints[endpoint.Host]; !ok {
+			hosts = append(hosts, endpoint.Host)
+		}
+		hostEndpoints[endpoint.Host] = append(hostEndpoints[endpoint.Host], endpoint)
+	}
+
+	var lockEndpoints EndpointList
+	for i := 0; len(lockEndpoints) < dsyncMaxNodes; i++ {
+		for _, host := range hosts {
+			if i < len(hostEndpoints[host]) && len(lockEndpoints) < dsyncMaxNodes {
+				lockEndpoints = append(lockEndpoints, hostEndpoints[host][i])
+			}
+		}
+	}
+	return lockEndpoints
+}
+
 // Initialize distributed locking only in case of distributed setup.
 // Returns lock clients and the node index for the current server.
 func newDsyncNodes(endpoints EndpointList) (clnts []dsync.NetLocker, myNode int) {
 	cred := serverConfig.GetCredential()
+	endpoints = getLockEndpoints(endpoints)
 	clnts = make([]dsync.NetLocker, len(endpoints))
 	myNode = -1
 	for index, endpoint := range endpoints {
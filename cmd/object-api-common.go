@@ -183,6 +183,13 @@ func initMetaVolume(storageDisks []StorageAPI) error {
 					return
 				}
 			}
+			err = disk.MakeVol(minioMetaVersionsBucket)
+			if err != nil {
+				if !isErrIgnored(err, initMetaVolIgnoredErrs...) {
+					errs[index] = err
+					return
+				}
+			}
 		}(index, disk)
 	}
 
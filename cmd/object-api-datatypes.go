@@ -84,6 +84,49 @@ type HealObjectInfo struct {
 	MissingParityCount int
 }
 
+// healDiskState - state of an object on a disk.
+type healDiskState string
+
+const (
+	healDiskOK        healDiskState = "ok"        // Object is healthy on the disk
+	healDiskOffline   healDiskState = "offline"   // Disk is offline or faulty
+	healDiskMissing   healDiskState = "missing"   // Object is missing on the disk
+	healDiskOutdated  healDiskState = "outdated"  // Object is stale or has missing or corrupted parts
+	healDiskCorrupted healDiskState = "corrupted" // Object metadata can't be read from the disk
+)
+
+// HealObjectResult - represents the state of an object on each disk
+// of its erasure set before and after healing it.
+type HealObjectResult struct {
+	// Index of the erasure set of the object.
+	Set    int
+	Disks  []string
+	Before []healDiskState
+	After  []healDiskState
+}
+
+// offlineDisks - returns the number of disks which were offline.
+func (r HealObjectResult) offlineDisks() int {
+	var n int
+	for _, state := range r.Before {
+		if state == healDiskOffline {
+			n++
+		}
+	}
+	return n
+}
+
+// healedDisks - returns the number of disks the object was healed on.
+func (r HealObjectResult) healedDisks() int {
+	var n int
+	for index, state := range r.Before {
+		if state != healDiskOK && r.After[index] == healDiskOK {
+			n++
+		}
+	}
+	return n
+}
+
 // ObjectInfo - represents object metadata.
 type ObjectInfo struct {
 	// Name of the bucket.
@@ -112,6 +155,19 @@ type ObjectInfo struct {
 	// by the Content-Type header field.
 	ContentEncoding string
 
+	// Version ID of the object, empty for objects which were
+	// written before versioning was enabled on the bucket.
+	VersionID string
+
+	// IsLatest indicates if this is the current version of the object.
+	IsLatest bool
+
+	// DeleteMarker indicates if this version is a delete marker.
+	DeleteMarker bool
+
+	// List of individual parts, maximum size of upto 10,000
+	Parts []objectPartInfo `xml:"-"`
+
 	// User-Defined metadata
 	UserDefined    map[string]string
 	HealObjectInfo *HealObjectInfo `xml:"HealObjectInfo,omitempty"`
@@ -149,6 +205,9 @@ type ListPartsInfo struct {
 	Parts []PartInfo
 
 	EncodingType string // Not supported yet.
+
+	// User-Defined metadata of the multipart upload.
+	UserDefined map[string]string `xml:"-"`
 }
 
 // ListMultipartsInfo - represnets bucket resources for incomplete multipart uploads.
@@ -221,6 +280,25 @@ type ListObjectsInfo struct {
 	Prefixes []string
 }
 
+// ListObjectVersionsInfo - container for list object versions.
+type ListObjectVersionsInfo struct {
+	// Indicates whether the returned list is truncated, the list
+	// can be truncated if the number of versions exceeds max keys.
+	IsTruncated bool
+
+	// When response is truncated, use these as key-marker and
+	// version-id-marker in the subsequent request.
+	NextKeyMarker       string
+	NextVersionIDMarker string
+
+	// List of object versions for this request, latest first
+	// for each object name.
+	Objects []ObjectInfo
+
+	// List of prefixes for this request.
+	Prefixes []string
+}
+
 // ListObjectsV2Info - container for list objects version 2.
 type ListObjectsV2Info struct {
 	// Indicates whether the returned list objects response is truncated. A
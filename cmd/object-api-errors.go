@@ -378,6 +378,32 @@ func (e PolicyNotFound) Error() string {
 	return "Policy not found"
 }
 
+// VersionNotFound object version does not exist.
+type VersionNotFound struct {
+	GenericError
+	VersionID string
+}
+
+func (e VersionNotFound) Error() string {
+	return "Version not found: " + e.Bucket + "#" + e.Object + " (" + e.VersionID + ")"
+}
+
+// MethodNotAllowed method is not allowed on the object version,
+// e.g. reading a delete marker.
+type MethodNotAllowed GenericError
+
+func (e MethodNotAllowed) Error() string {
+	return "Method not allowed: " + e.Bucket + "#" + e.Object
+}
+
+// ObjectLocked object version is protected by object lock, it is
+// retained or under legal hold.
+type ObjectLocked GenericError
+
+func (e ObjectLocked) Error() string {
+	return "Object is locked: " + e.Bucket + "#" + e.Object
+}
+
 // Check if error type is IncompleteBody.
 func isErrIncompleteBody(err error) bool {
 	err = errorCause(err)
@@ -407,3 +433,13 @@ func isErrObjectNotFound(err error) bool {
 	}
 	return false
 }
+
+// Check if error type is VersionNotFound.
+func isErrVersionNotFound(err error) bool {
+	err = errorCause(err)
+	switch err.(type) {
+	case VersionNotFound:
+		return true
+	}
+	return false
+}
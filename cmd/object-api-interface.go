@@ -38,6 +38,15 @@ type ObjectLayer interface {
 	CopyObject(srcBucket, srcObject, destBucket, destObject string, metadata map[string]string) (objInfo ObjectInfo, err error)
 	DeleteObject(bucket, object string) error
 
+	// Object version operations.
+	GetObjectVersion(bucket, object, versionID string, startOffset int64, length int64, writer io.Writer) (err error)
+	GetObjectVersionInfo(bucket, object, versionID string) (objInfo ObjectInfo, err error)
+	DeleteObjectVersion(bucket, object, versionID string, bypassGovernance bool) (objInfo ObjectInfo, err error)
+	ListObjectVersions(bucket, prefix, keyMarker, versionIDMarker, delimiter string, maxKeys int) (result ListObjectVersionsInfo, err error)
+
+	// Object lock operations.
+	PutObjectLock(bucket, object, versionID string, lock objectLock, bypassGovernance bool) (objInfo ObjectInfo, err error)
+
 	// Multipart operations.
 	ListMultipartUploads(bucket, prefix, keyMarker, uploadIDMarker, delimiter string, maxUploads int) (result ListMultipartsInfo, err error)
 	NewMultipartUpload(bucket, object string, metadata map[string]string) (uploadID string, err error)
@@ -51,6 +60,7 @@ type ObjectLayer interface {
 	HealBucket(bucket string) error
 	ListBucketsHeal() (buckets []BucketInfo, err error)
 	HealObject(bucket, object string) (int, int, error)
+	HealObjectDisks(bucket, object string) (HealObjectResult, error)
 	ListObjectsHeal(bucket, prefix, marker, delimiter string, maxKeys int) (ListObjectsInfo, error)
 	ListUploadsHeal(bucket, prefix, marker, uploadIDMarker,
 		delimiter string, maxUploads int) (ListMultipartsInfo, error)
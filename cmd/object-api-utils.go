@@ -37,6 +37,8 @@ const (
 	minioMetaMultipartBucket = minioMetaBucket + "/" + mpartMetaPrefix
 	// Minio Tmp meta prefix.
 	minioMetaTmpBucket = minioMetaBucket + "/tmp"
+	// Minio versions meta prefix, holds all non-current object versions.
+	minioMetaVersionsBucket = minioMetaBucket + "/versions"
 	// DNS separator (period), used for bucket name validation.
 	dnsDelimiter = "."
 )
@@ -46,7 +48,8 @@ const (
 func isMinioMetaBucketName(bucket string) bool {
 	return bucket == minioMetaBucket ||
 		bucket == minioMetaMultipartBucket ||
-		bucket == minioMetaTmpBucket
+		bucket == minioMetaTmpBucket ||
+		bucket == minioMetaVersionsBucket
 }
 
 // IsValidBucketName verifies that a bucket name is in accordance with
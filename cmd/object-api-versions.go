@@ -0,0 +1,287 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"sort"
+	"strings"
+)
+
+// versionedObjects - implemented by object layers which support
+// object versioning, these primitives are used to implement the
+// version listing and lookups common to all such object layers.
+type versionedObjects interface {
+	// Returns the current version of an object.
+	getObjectInfo(bucket, object string) (ObjectInfo, error)
+
+	// Returns all the previous versions of an object, in any order.
+	listArchivedVersions(bucket, object string) ([]ObjectInfo, error)
+
+	// Lists names of objects having previous versions in sorted
+	// order, starting after marker.
+	listVersionedObjects(bucket, prefix, marker string, maxKeys int) (names []string, isTruncated bool, err error)
+
+	// Lists current objects.
+	ListObjects(bucket, prefix, marker, delimiter string, maxKeys int) (result ListObjectsInfo, err error)
+}
+
+// toVersionID - returns the version ID as reported to clients,
+// empty version ID represents the `null` version.
+func toVersionID(versionID string) string {
+	if versionID == "" {
+		return nullVersionID
+	}
+	return versionID
+}
+
+// fromVersionID - converts a version ID sent by the client to its
+// stored representation.
+func fromVersionID(versionID string) string {
+	if versionID == nullVersionID {
+		return ""
+	}
+	return versionID
+}
+
+// Sorts object versions, newest version first.
+type byVersionModTime []ObjectInfo
+
+func (v byVersionModTime) Len() int           { return len(v) }
+func (v byVersionModTime) Swap(i, j int)      { v[i], v[j] = v[j], v[i] }
+func (v byVersionModTime) Less(i, j int) bool { return v[i].ModTime.After(v[j].ModTime) }
+
+// getObjectVersions - returns all the versions of an object newest
+// version first, hasCurrent is set if the first version is the
+// current object and not an archived version.
+func getObjectVersions(vo versionedObjects, bucket, object string) (versions []ObjectInfo, hasCurrent bool, err error) {
+	objInfo, err := vo.getObjectInfo(bucket, object)
+	if err != nil {
+		if !isErrObjectNotFound(toObjectErr(err, bucket, object)) {
+			return nil, false, toObjectErr(err, bucket, object)
+		}
+	} else {
+		versions = append(versions, objInfo)
+		hasCurrent = true
+	}
+
+	archived, err := vo.listArchivedVersions(bucket, object)
+	if err != nil {
+		return nil, false, toObjectErr(err, bucket, object)
+	}
+	sort.Sort(byVersionModTime(archived))
+	versions = append(versions, archived...)
+
+	if len(versions) > 0 {
+		versions[0].IsLatest = true
+	}
+	return versions, hasCurrent, nil
+}
+
+// getObjectVersion - looks up a specific version of an object, index
+// is the position of the version as returned by getObjectVersions.
+func getObjectVersion(vo versionedObjects, bucket, object, versionID string) (objInfo ObjectInfo, index int, hasCurrent bool, err error) {
+	versions, hasCurrent, err := getObjectVersions(vo, bucket, object)
+	if err != nil {
+		return objInfo, -1, false, err
+	}
+	versionID = fromVersionID(versionID)
+	for i, version := range versions {
+		if version.VersionID == versionID {
+			return version, i, hasCurrent, nil
+		}
+	}
+	return objInfo, -1, hasCurrent, traceError(VersionNotFound{
+		GenericError: GenericError{Bucket: bucket, Object: object},
+		VersionID:    toVersionID(versionID),
+	})
+}
+
+// objectNameIter - iterates over sorted object names returned
+// page by page by its fetch function.
+type objectNameIter struct {
+	fetch  func(marker string) (names []string, isTruncated bool, err error)
+	marker string
+	names  []string
+	eof    bool
+}
+
+// peek - returns the next object name without consuming it.
+func (it *objectNameIter) peek() (string, bool, error) {
+	for len(it.names) == 0 {
+		if it.eof {
+			return "", false, nil
+		}
+		names, isTruncated, err := it.fetch(it.marker)
+		if err != nil {
+			return "", false, err
+		}
+		it.names = names
+		it.eof = !isTruncated || len(names) == 0
+		if len(names) > 0 {
+			it.marker = names[len(names)-1]
+		}
+	}
+	return it.names[0], true, nil
+}
+
+// next - consumes the next object name.
+func (it *objectNameIter) next() {
+	if len(it.names) > 0 {
+		it.names = it.names[1:]
+	}
+}
+
+// nextObjectName - merges names from both iterators returning the
+// lexically smallest name, names present in both are returned once.
+func nextObjectName(current, versioned *objectNameIter) (string, bool, error) {
+	cName, cOK, err := current.peek()
+	if err != nil {
+		return "", false, err
+	}
+	vName, vOK, err := versioned.peek()
+	if err != nil {
+		return "", false, err
+	}
+	switch {
+	case !cOK && !vOK:
+		return "", false, nil
+	case cOK && (!vOK || cName < vName):
+		current.next()
+		return cName, true, nil
+	case vOK && (!cOK || vName < cName):
+		versioned.next()
+		return vName, true, nil
+	}
+	current.next()
+	versioned.next()
+	return cName, true, nil
+}
+
+// listObjectVersions - lists all versions of the objects at prefix
+// upto maxKeys, optionally delimited by delimiter. Listing resumes
+// after keyMarker and versionIDMarker. Common prefixes are counted
+// towards maxKeys.
+func listObjectVersions(vo versionedObjects, bucket, prefix, keyMarker, versionIDMarker, delimiter string, maxKeys int) (result ListObjectVersionsInfo, err error) {
+	if maxKeys == 0 {
+		return result, nil
+	}
+	if maxKeys < 0 || maxKeys > maxObjectList {
+		maxKeys = maxObjectList
+	}
+
+	var count int
+	var lastName, lastVersionID string
+
+	// Adds versions of an object to the result, returns false if
+	// the result was truncated.
+	addVersions := func(name string, versions []ObjectInfo) bool {
+		for _, version := range versions {
+			if count == maxKeys {
+				result.IsTruncated = true
+				result.NextKeyMarker = lastName
+				result.NextVersionIDMarker = lastVersionID
+				return false
+			}
+			result.Objects = append(result.Objects, version)
+			lastName, lastVersionID = name, toVersionID(version.VersionID)
+			count++
+		}
+		return true
+	}
+
+	// Versions of the object at keyMarker which are older than
+	// versionIDMarker are yet to be listed.
+	if keyMarker != "" && versionIDMarker != "" && hasPrefix(keyMarker, prefix) {
+		versions, _, vErr := getObjectVersions(vo, bucket, keyMarker)
+		if vErr != nil {
+			return result, vErr
+		}
+		for i, version := range versions {
+			if toVersionID(version.VersionID) == versionIDMarker {
+				if !addVersions(keyMarker, versions[i+1:]) {
+					return result, nil
+				}
+				break
+			}
+		}
+	}
+
+	current := &objectNameIter{
+		marker: keyMarker,
+		fetch: func(marker string) ([]string, bool, error) {
+			loi, lErr := vo.ListObjects(bucket, prefix, marker, "", maxObjectList)
+			if lErr != nil {
+				return nil, false, lErr
+			}
+			names := make([]string, 0, len(loi.Objects))
+			for _, objInfo := range loi.Objects {
+				names = append(names, objInfo.Name)
+			}
+			return names, loi.IsTruncated, nil
+		},
+	}
+	versioned := &objectNameIter{
+		marker: keyMarker,
+		fetch: func(marker string) ([]string, bool, error) {
+			return vo.listVersionedObjects(bucket, prefix, marker, maxObjectList)
+		},
+	}
+
+	var lastPrefix string
+	for {
+		name, ok, nErr := nextObjectName(current, versioned)
+		if nErr != nil {
+			return result, nErr
+		}
+		if !ok {
+			break
+		}
+		// Directories are not objects.
+		if hasSuffix(name, slashSeparator) {
+			continue
+		}
+		if delimiter != "" {
+			if i := strings.Index(name[len(prefix):], delimiter); i >= 0 {
+				commonPrefix := name[:len(prefix)+i+len(delimiter)]
+				// Skip prefixes already listed in this or a previous listing.
+				if commonPrefix == lastPrefix || hasPrefix(keyMarker, commonPrefix) {
+					continue
+				}
+				if count == maxKeys {
+					result.IsTruncated = true
+					result.NextKeyMarker = lastName
+					result.NextVersionIDMarker = lastVersionID
+					return result, nil
+				}
+				result.Prefixes = append(result.Prefixes, commonPrefix)
+				lastPrefix = commonPrefix
+				lastName, lastVersionID = commonPrefix, ""
+				count++
+				continue
+			}
+		}
+		versions, _, vErr := getObjectVersions(vo, bucket, name)
+		if vErr != nil {
+			return result, vErr
+		}
+		if !addVersions(name, versions) {
+			return result, nil
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,196 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"testing"
+)
+
+// Wrapper for calling versioned object tests for both XL and FS.
+func TestObjectVersions(t *testing.T) {
+	ExecObjectLayerTest(t, testObjectVersions)
+}
+
+// Tests overwrite, delete and version listing in a versioned bucket.
+func testObjectVersions(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	bucket, object := "versioned-bucket", "object"
+	if err := obj.MakeBucketWithLocation(bucket, ""); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if err := initBucketVersioning(obj); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	globalBucketVersioning.SetBucketVersioning(bucket, &versioningConfiguration{Status: versioningEnabled})
+	defer globalBucketVersioning.SetBucketVersioning(bucket, nil)
+
+	var versionIDs []string
+	for _, data := range []string{"hello", "world"} {
+		objInfo, err := obj.PutObject(bucket, object, int64(len(data)), bytes.NewReader([]byte(data)), nil, "")
+		if err != nil {
+			t.Fatalf("%s: %s", instanceType, err)
+		}
+		if objInfo.VersionID == "" {
+			t.Fatalf("%s: Expected a version ID for objects in versioned buckets", instanceType)
+		}
+		versionIDs = append(versionIDs, objInfo.VersionID)
+	}
+
+	// Previous version is retained.
+	var buffer bytes.Buffer
+	if err := obj.GetObjectVersion(bucket, object, versionIDs[0], 0, 5, &buffer); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if buffer.String() != "hello" {
+		t.Fatalf("%s: Expected `hello`, got `%s`", instanceType, buffer.String())
+	}
+
+	result, err := obj.ListObjectVersions(bucket, "", "", "", "", 1000)
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if len(result.Objects) != 2 {
+		t.Fatalf("%s: Expected 2 versions, got %d", instanceType, len(result.Objects))
+	}
+	if result.Objects[0].VersionID != versionIDs[1] || !result.Objects[0].IsLatest {
+		t.Fatalf("%s: Expected latest version %s, got %#v", instanceType, versionIDs[1], result.Objects[0])
+	}
+
+	// Delete without version ID adds a delete marker.
+	marker, err := obj.DeleteObjectVersion(bucket, object, "", false)
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if !marker.DeleteMarker {
+		t.Fatalf("%s: Expected a delete marker", instanceType)
+	}
+	if _, err = obj.GetObjectInfo(bucket, object); !isErrObjectNotFound(err) {
+		t.Fatalf("%s: Expected ObjectNotFound, got %v", instanceType, err)
+	}
+	if err = obj.GetObjectVersion(bucket, object, marker.VersionID, 0, 0, &buffer); err == nil {
+		t.Fatalf("%s: Expected delete markers not to be readable", instanceType)
+	}
+
+	// Removing the delete marker restores the previous version.
+	if _, err = obj.DeleteObjectVersion(bucket, object, marker.VersionID, false); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	buffer.Reset()
+	if err = obj.GetObject(bucket, object, 0, 5, &buffer); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if buffer.String() != "world" {
+		t.Fatalf("%s: Expected `world`, got `%s`", instanceType, buffer.String())
+	}
+
+	// Remove a previous version permanently.
+	if _, err = obj.DeleteObjectVersion(bucket, object, versionIDs[0], false); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if _, err = obj.GetObjectVersionInfo(bucket, object, versionIDs[0]); !isErrVersionNotFound(err) {
+		t.Fatalf("%s: Expected VersionNotFound, got %v", instanceType, err)
+	}
+	result, err = obj.ListObjectVersions(bucket, "", "", "", "", 1000)
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if len(result.Objects) != 1 {
+		t.Fatalf("%s: Expected 1 version, got %d", instanceType, len(result.Objects))
+	}
+}
+
+// Wrapper for calling suspended versioning tests for both XL and FS.
+func TestObjectVersionsSuspended(t *testing.T) {
+	ExecObjectLayerTest(t, testObjectVersionsSuspended)
+}
+
+// Tests that only one `null` version is retained in suspended buckets.
+func testObjectVersionsSuspended(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	bucket, object := "suspended-bucket", "dir/object"
+	if err := obj.MakeBucketWithLocation(bucket, ""); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if err := initBucketVersioning(obj); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+
+	// Object written before versioning was enabled is the `null` version.
+	if _, err := obj.PutObject(bucket, object, 1, bytes.NewReader([]byte("a")), nil, ""); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	globalBucketVersioning.SetBucketVersioning(bucket, &versioningConfiguration{Status: versioningEnabled})
+	defer globalBucketVersioning.SetBucketVersioning(bucket, nil)
+	if _, err := obj.PutObject(bucket, object, 1, bytes.NewReader([]byte("b")), nil, ""); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+
+	globalBucketVersioning.SetBucketVersioning(bucket, &versioningConfiguration{Status: versioningSuspended})
+	for _, data := range []string{"c", "d"} {
+		objInfo, err := obj.PutObject(bucket, object, 1, bytes.NewReader([]byte(data)), nil, "")
+		if err != nil {
+			t.Fatalf("%s: %s", instanceType, err)
+		}
+		if objInfo.VersionID != "" {
+			t.Fatalf("%s: Expected `null` version, got %s", instanceType, objInfo.VersionID)
+		}
+	}
+
+	result, err := obj.ListObjectVersions(bucket, "dir/", "", "", "/", 1000)
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	// Current `null` version and the version written while enabled.
+	if len(result.Objects) != 2 {
+		t.Fatalf("%s: Expected 2 versions, got %d", instanceType, len(result.Objects))
+	}
+
+	var buffer bytes.Buffer
+	if err = obj.GetObjectVersion(bucket, object, nullVersionID, 0, 1, &buffer); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if buffer.String() != "d" {
+		t.Fatalf("%s: Expected `d`, got `%s`", instanceType, buffer.String())
+	}
+
+	// Delimited listing at bucket root reports the common prefix.
+	result, err = obj.ListObjectVersions(bucket, "", "", "", "/", 1000)
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if len(result.Prefixes) != 1 || result.Prefixes[0] != "dir/" {
+		t.Fatalf("%s: Expected prefix `dir/`, got %v", instanceType, result.Prefixes)
+	}
+}
+
+// Tests the object owning the entries of `.minio.sys/versions`.
+func TestVersionOwner(t *testing.T) {
+	testCases := []struct {
+		bucket, object           string
+		ownerBucket, ownerObject string
+	}{
+		{"bucket", "object", "bucket", "object"},
+		{minioMetaVersionsBucket, xlVersionPath("bucket", "object", "id"), "bucket", "object"},
+		{minioMetaVersionsBucket, xlVersionPath("bucket", "dir/.versions/object", ""), "bucket", "dir/.versions/object"},
+		{minioMetaVersionsBucket, "bucket", minioMetaVersionsBucket, "bucket"},
+	}
+	for i, testCase := range testCases {
+		bucket, object := versionOwner(testCase.bucket, testCase.object)
+		if bucket != testCase.ownerBucket || object != testCase.ownerObject {
+			t.Errorf("Test %d: expected %s/%s, got %s/%s", i+1, testCase.ownerBucket, testCase.ownerObject, bucket, object)
+		}
+	}
+}
@@ -236,9 +236,12 @@ func deleteObject(obj ObjectLayer, bucket, object string, r *http.Request) (err
 	defer objectLock.Unlock()
 
 	// Proceed to delete the object.
+	removed := removedObjectVersion(obj, bucket, object, "")
 	if err = obj.DeleteObject(bucket, object); err != nil {
 		return err
 	}
+	updateBucketUsage(obj, bucket, removed, ObjectInfo{})
+	scheduleDeleteReplication(bucket, object, "")
 
 	// Get host and port from Request.RemoteAddr.
 	host, port, _ := net.SplitHostPort(r.RemoteAddr)
@@ -258,3 +261,36 @@ func deleteObject(obj ObjectLayer, bucket, object string, r *http.Request) (err
 
 	return nil
 }
+
+// deleteObjectVersion - deletes a specific version of an object, or
+// adds a delete marker for versioned buckets when versionID is empty.
+func deleteObjectVersion(obj ObjectLayer, bucket, object, versionID string, bypassGovernance bool, r *http.Request) (objInfo ObjectInfo, err error) {
+	// Acquire a write lock before deleting the object.
+	objectLock := globalNSMutex.NewNSLock(bucket, object)
+	objectLock.Lock()
+	defer objectLock.Unlock()
+
+	// Proceed to delete the object version.
+	removed := removedObjectVersion(obj, bucket, object, versionID)
+	if objInfo, err = obj.DeleteObjectVersion(bucket, object, versionID, bypassGovernance); err != nil {
+		return objInfo, err
+	}
+	updateBucketUsage(obj, bucket, removed, ObjectInfo{})
+	scheduleDeleteReplication(bucket, object, versionID)
+
+	// Get host and port from Request.RemoteAddr.
+	host, port, _ := net.SplitHostPort(r.RemoteAddr)
+
+	// Notify object deleted event.
+	eventNotify(eventData{
+		Type:      ObjectRemovedDelete,
+		Bucket:    bucket,
+		ObjInfo:   objInfo,
+		ReqParams: extractReqParams(r),
+		UserAgent: r.UserAgent(),
+		Host:      host,
+		Port:      port,
+	})
+
+	return objInfo, nil
+}
@@ -19,12 +19,14 @@ package cmd
 import (
 	"encoding/hex"
 	"encoding/xml"
+	"io"
 	"io/ioutil"
 	"net"
 	"net/http"
 	"net/url"
 	"sort"
 	"strconv"
+	"strings"
 
 	mux "github.com/gorilla/mux"
 )
@@ -57,13 +59,103 @@ func errAllowableObjectNotFound(bucket string, r *http.Request) APIErrorCode {
 		//we care about the bucket as a whole, not a particular resource
 		resource := "/" + bucket
 		if s3Error := enforceBucketPolicy(bucket, "s3:ListBucket", resource,
-			r.Referer(), r.URL.Query()); s3Error != ErrNone {
+			r.Referer(), r.URL.Query(), nil); s3Error != ErrNone {
 			return ErrAccessDenied
 		}
 	}
 	return ErrNoSuchKey
 }
 
+// getObjectVersionInfo - returns the object info of the requested
+// version, or of the current object when no version was requested.
+func getObjectVersionInfo(objectAPI ObjectLayer, bucket, object, versionID string) (ObjectInfo, error) {
+	if versionID != "" {
+		return objectAPI.GetObjectVersionInfo(bucket, object, versionID)
+	}
+	return objectAPI.GetObjectInfo(bucket, object)
+}
+
+// getObjectReader - returns a reader streaming a range of the given
+// object version, encrypted objects are decrypted with the client key.
+func getObjectReader(objectAPI ObjectLayer, objInfo ObjectInfo, versionID string, key []byte, offset, length int64) io.ReadCloser {
+	pipeReader, pipeWriter := io.Pipe()
+	read := func(offset, length int64, writer io.Writer) error {
+		if versionID != "" {
+			return objectAPI.GetObjectVersion(objInfo.Bucket, objInfo.Name, versionID, offset, length, writer)
+		}
+		return objectAPI.GetObject(objInfo.Bucket, objInfo.Name, offset, length, writer)
+	}
+	go func() {
+		var writer io.Writer = pipeWriter
+		if key != nil {
+			var err error
+			writer, offset, length, err = newSSEDecryptWriter(pipeWriter, key, objInfo, offset, length, read)
+			if err != nil {
+				pipeWriter.CloseWithError(err)
+				return
+			}
+		}
+		if gerr := read(offset, length, writer); gerr != nil {
+			errorIf(gerr, "Unable to read object `%s/%s`.", objInfo.Bucket, objInfo.Name)
+			pipeWriter.CloseWithError(gerr)
+			return
+		}
+		pipeWriter.Close() // Close writer explicitly signalling we wrote all data.
+	}()
+	return pipeReader
+}
+
+// copyObjectStream - copies the source object to destination by
+// streaming its data into a new object, used to copy a specific
+// version of the source or to change the encryption of the data.
+func copyObjectStream(objectAPI ObjectLayer, srcInfo ObjectInfo, srcVersionID string, srcKey []byte, dstBucket, dstObject string, dstKey []byte, metadata map[string]string) (ObjectInfo, error) {
+	size := srcInfo.Size
+	if srcKey != nil {
+		var err error
+		if size, err = sseDecryptedObjectSize(srcInfo); err != nil {
+			return ObjectInfo{}, err
+		}
+	}
+
+	reader := getObjectReader(objectAPI, srcInfo, srcVersionID, srcKey, 0, size)
+	defer reader.Close()
+
+	var data io.Reader = reader
+	if dstKey != nil {
+		objectKey, err := newSSEObjectKey(dstKey, metadata)
+		if err != nil {
+			return ObjectInfo{}, err
+		}
+		if data, err = newSSEEncryptReader(reader, objectKey, 1); err != nil {
+			return ObjectInfo{}, err
+		}
+		size = sseEncryptedSize(size)
+	}
+
+	return objectAPI.PutObject(dstBucket, dstObject, size, data, metadata, "")
+}
+
+// rotateSSEObjectKey - seals the object key of an encrypted object with a
+// new key, only the object metadata is updated and the data is kept as is.
+func rotateSSEObjectKey(objectAPI ObjectLayer, objInfo ObjectInfo, srcKey, dstKey []byte, metadata map[string]string) (ObjectInfo, error) {
+	objectKey, err := unsealSSEObjectKey(srcKey, objInfo.UserDefined)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	if err = sealSSEObjectKey(dstKey, objectKey, metadata); err != nil {
+		return ObjectInfo{}, err
+	}
+	return replaceObjectMetadata(objectAPI, objInfo, metadata)
+}
+
+// replaceObjectMetadata - replaces the metadata of an object without
+// rewriting its data.
+func replaceObjectMetadata(objectAPI ObjectLayer, objInfo ObjectInfo, metadata map[string]string) (ObjectInfo, error) {
+	// The data is not rewritten, so its etag stays the same.
+	metadata["etag"] = objInfo.ETag
+	return objectAPI.CopyObject(objInfo.Bucket, objInfo.Name, objInfo.Bucket, objInfo.Name, metadata)
+}
+
 // Simple way to convert a func to io.Writer type.
 type funcToWriter func([]byte) (int, error)
 
@@ -88,7 +180,8 @@ func (api objectAPIHandlers) GetObjectHandler(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	if s3Error := checkRequestAuthType(r, bucket, "s3:GetObject", serverConfig.GetRegion()); s3Error != ErrNone {
+	versionID := r.URL.Query().Get("versionId")
+	if s3Error := checkRequestAuthType(r, bucket, getVersionAction("s3:GetObject", versionID), serverConfig.GetRegion()); s3Error != ErrNone {
 		writeErrorResponse(w, s3Error, r.URL)
 		return
 	}
@@ -98,7 +191,7 @@ func (api objectAPIHandlers) GetObjectHandler(w http.ResponseWriter, r *http.Req
 	objectLock.RLock()
 	defer objectLock.RUnlock()
 
-	objInfo, err := objectAPI.GetObjectInfo(bucket, object)
+	objInfo, err := getObjectVersionInfo(objectAPI, bucket, object, versionID)
 	if err != nil {
 		errorIf(err, "Unable to fetch object info.")
 		apiErr := toAPIErrorCode(err)
@@ -109,6 +202,29 @@ func (api objectAPIHandlers) GetObjectHandler(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	// Delete markers cannot be read.
+	if objInfo.DeleteMarker {
+		setVersionHeaders(w, objInfo)
+		writeErrorResponse(w, ErrMethodNotAllowed, r.URL)
+		return
+	}
+
+	// Encrypted objects can only be read with the client provided key,
+	// or the server managed key, all sizes and ranges below refer to
+	// the decrypted object.
+	encInfo := objInfo
+	sseKey, err := getSSEKey(r, bucket, object, objInfo.UserDefined, false)
+	if err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+	if sseKey != nil {
+		if objInfo.Size, err = sseDecryptedObjectSize(encInfo); err != nil {
+			writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+			return
+		}
+	}
+
 	// Get request range.
 	var hrange *httpRange
 	rangeHeader := r.Header.Get("Range")
@@ -151,13 +267,35 @@ func (api objectAPIHandlers) GetObjectHandler(w http.ResponseWriter, r *http.Req
 			// Set any additional requested response headers.
 			setGetRespHeaders(w, r.URL.Query())
 
+			if sseKey != nil {
+				setSSEResponseHeaders(w, sseKey, encInfo.UserDefined)
+			}
+
 			dataWritten = true
 		}
 		return w.Write(p)
 	})
 
+	read := func(offset, length int64, writer io.Writer) error {
+		if versionID != "" {
+			return objectAPI.GetObjectVersion(bucket, object, versionID, offset, length, writer)
+		}
+		return objectAPI.GetObject(bucket, object, offset, length, writer)
+	}
+
+	// Encrypted objects are read in whole packages, which are
+	// decrypted before the requested range is written.
+	var objectWriter io.Writer = writer
+	if sseKey != nil {
+		objectWriter, startOffset, length, err = newSSEDecryptWriter(writer, sseKey, encInfo, startOffset, length, read)
+		if err != nil {
+			writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+			return
+		}
+	}
+
 	// Reads the object at startOffset and writes to mw.
-	if err = objectAPI.GetObject(bucket, object, startOffset, length, writer); err != nil {
+	if err = read(startOffset, length, objectWriter); err != nil {
 		errorIf(err, "Unable to write to client.")
 		if !dataWritten {
 			// Error response only if no data has been written to client yet. i.e if
@@ -208,7 +346,8 @@ func (api objectAPIHandlers) HeadObjectHandler(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	if s3Error := checkRequestAuthType(r, bucket, "s3:GetObject", serverConfig.GetRegion()); s3Error != ErrNone {
+	versionID := r.URL.Query().Get("versionId")
+	if s3Error := checkRequestAuthType(r, bucket, getVersionAction("s3:GetObject", versionID), serverConfig.GetRegion()); s3Error != ErrNone {
 		writeErrorResponseHeadersOnly(w, s3Error)
 		return
 	}
@@ -218,7 +357,7 @@ func (api objectAPIHandlers) HeadObjectHandler(w http.ResponseWriter, r *http.Re
 	objectLock.RLock()
 	defer objectLock.RUnlock()
 
-	objInfo, err := objectAPI.GetObjectInfo(bucket, object)
+	objInfo, err := getObjectVersionInfo(objectAPI, bucket, object, versionID)
 	if err != nil {
 		errorIf(err, "Unable to fetch object info.")
 		apiErr := toAPIErrorCode(err)
@@ -229,6 +368,28 @@ func (api objectAPIHandlers) HeadObjectHandler(w http.ResponseWriter, r *http.Re
 		return
 	}
 
+	// Delete markers cannot be read.
+	if objInfo.DeleteMarker {
+		setVersionHeaders(w, objInfo)
+		writeErrorResponseHeadersOnly(w, ErrMethodNotAllowed)
+		return
+	}
+
+	// Encrypted objects can only be read with the client provided key,
+	// or the server managed key.
+	sseKey, err := getSSEKey(r, bucket, object, objInfo.UserDefined, false)
+	if err != nil {
+		writeErrorResponseHeadersOnly(w, toAPIErrorCode(err))
+		return
+	}
+	if sseKey != nil {
+		if objInfo.Size, err = sseDecryptedObjectSize(objInfo); err != nil {
+			writeErrorResponseHeadersOnly(w, toAPIErrorCode(err))
+			return
+		}
+		setSSEResponseHeaders(w, sseKey, objInfo.UserDefined)
+	}
+
 	// Validate pre-conditions if any.
 	if checkPreconditions(w, r, objInfo) {
 		return
@@ -277,6 +438,28 @@ func getCpObjMetadataFromHeader(header http.Header, defaultMeta map[string]strin
 	return defaultMeta, nil
 }
 
+// getCopySource - returns the source bucket, object and version of the
+// X-Amz-Copy-Source header value. The query is split off the escaped
+// value so that an escaped '?' remains part of the object name.
+func getCopySource(cpSrc string) (bucket, object, versionID string, err error) {
+	cpSrcPath, cpSrcQuery := cpSrc, ""
+	if i := strings.Index(cpSrc, "?"); i >= 0 {
+		cpSrcPath, cpSrcQuery = cpSrc[:i], cpSrc[i+1:]
+	}
+
+	query, err := url.ParseQuery(cpSrcQuery)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	// Keep the path as is if it can not be unescaped.
+	if unescapedPath, uerr := url.QueryUnescape(cpSrcPath); uerr == nil {
+		cpSrcPath = unescapedPath
+	}
+	bucket, object = path2BucketAndObject(cpSrcPath)
+	return bucket, object, query.Get("versionId"), nil
+}
+
 // CopyObjectHandler - Copy Object
 // ----------
 // This implementation of the PUT operation adds an object to a bucket
@@ -299,26 +482,33 @@ func (api objectAPIHandlers) CopyObjectHandler(w http.ResponseWriter, r *http.Re
 
 	// TODO: Reject requests where body/payload is present, for now we don't even read it.
 
-	// Copy source path.
-	cpSrcPath, err := url.QueryUnescape(r.Header.Get("X-Amz-Copy-Source"))
-	if err != nil {
-		// Save unescaped string as is.
-		cpSrcPath = r.Header.Get("X-Amz-Copy-Source")
-	}
-
-	srcBucket, srcObject := path2BucketAndObject(cpSrcPath)
+	// Copy source path, may refer to a specific version of the object.
+	srcBucket, srcObject, srcVersionID, err := getCopySource(r.Header.Get("X-Amz-Copy-Source"))
 	// If source object is empty or bucket is empty, reply back invalid copy source.
-	if srcObject == "" || srcBucket == "" {
+	if err != nil || srcObject == "" || srcBucket == "" {
 		writeErrorResponse(w, ErrInvalidCopySource, r.URL)
 		return
 	}
 
+	// The requester must be allowed to read the copy source.
+	if s3Error := isCopySourceAllowed(r, srcBucket, srcObject, srcVersionID); s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
 	// Check if metadata directive is valid.
 	if !isMetadataDirectiveValid(r.Header) {
 		writeErrorResponse(w, ErrInvalidMetadataDirective, r.URL)
 		return
 	}
 
+	// Check if tagging directive is valid.
+	replaceTags, err := isTaggingReplace(r.Header)
+	if err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
 	cpSrcDstSame := srcBucket == dstBucket && srcObject == dstObject
 	// Hold write lock on destination since in both cases
 	// - if source and destination are same
@@ -340,54 +530,160 @@ func (api objectAPIHandlers) CopyObjectHandler(w http.ResponseWriter, r *http.Re
 
 	}
 
-	objInfo, err := objectAPI.GetObjectInfo(srcBucket, srcObject)
+	objInfo, err := getObjectVersionInfo(objectAPI, srcBucket, srcObject, srcVersionID)
 	if err != nil {
 		errorIf(err, "Unable to fetch object info.")
 		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
 		return
 	}
 
+	// Delete markers cannot be copied.
+	if objInfo.DeleteMarker {
+		writeErrorResponse(w, ErrInvalidCopySource, r.URL)
+		return
+	}
+
+	// Encrypted sources are decrypted with the copy source key or
+	// the server managed key.
+	srcKey, err := getSSEKey(r, srcBucket, srcObject, objInfo.UserDefined, true)
+	if err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+	srcSize := objInfo.Size
+	if srcKey != nil {
+		if srcSize, err = sseDecryptedObjectSize(objInfo); err != nil {
+			writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+			return
+		}
+	}
+
 	// Verify before x-amz-copy-source preconditions before continuing with CopyObject.
 	if checkCopyObjectPreconditions(w, r, objInfo) {
 		return
 	}
 
 	/// maximum Upload size for object in a single CopyObject operation.
-	if isMaxObjectSize(objInfo.Size) {
+	if isMaxObjectSize(srcSize) {
 		writeErrorResponse(w, ErrEntityTooLarge, r.URL)
 		return
 	}
 
-	defaultMeta := objInfo.UserDefined
+	defaultMeta := make(map[string]string, len(objInfo.UserDefined))
+	for k, v := range objInfo.UserDefined {
+		defaultMeta[k] = v
+	}
 
 	// Make sure to remove saved etag, CopyObject calculates a new one.
 	delete(defaultMeta, "etag")
 
+	// The destination is encrypted with a new object key, if at all.
+	removeSSEMetadata(defaultMeta)
+
 	newMetadata, err := getCpObjMetadataFromHeader(r.Header, defaultMeta)
 	if err != nil {
 		errorIf(err, "found invalid http request header")
 		writeErrorResponse(w, ErrInternalError, r.URL)
 	}
+
+	// The tags of the source object are copied unless the request
+	// replaces them, also when the metadata is replaced.
+	if replaceTags {
+		delete(newMetadata, ObjectTagging)
+		if err = extractTagsFromHeader(r.Header, newMetadata); err != nil {
+			writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+			return
+		}
+	} else {
+		setObjectTags(newMetadata, getObjectTags(objInfo.UserDefined))
+	}
+
+	// Copies do not inherit the object lock settings of the source,
+	// an object copied onto itself retains its settings though.
+	removeObjectLockMetadata(newMetadata)
+	if err = extractObjectLockFromHeader(r.Header, dstBucket, newMetadata); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	// The destination is encrypted if the request provides a key for
+	// it or asks for, or the bucket defaults to, server managed keys.
+	dstKey, err := getSSEPutKey(r, dstBucket, dstObject, newMetadata)
+	if err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	// Mark the copy for replication if the bucket is replicated, the
+	// object key of a destination encrypted with a customer provided
+	// key is only sealed when the copy is written, so it is skipped.
+	setReplicationMetadata(dstBucket, dstObject, newMetadata)
+	if hasSSECustomerHeader(r.Header) {
+		delete(newMetadata, ObjectReplicationStatus)
+	}
 	// Check if x-amz-metadata-directive was not set to REPLACE and source,
-	// desination are same objects.
-	if !isMetadataReplace(r.Header) && cpSrcDstSame {
+	// desination are same objects, copying a previous version onto the
+	// same object restores that version and copying an encrypted object
+	// onto itself changes its key.
+	isEncryptedCopy := srcKey != nil || dstKey != nil
+	if !isMetadataReplace(r.Header) && cpSrcDstSame && srcVersionID == "" && !isEncryptedCopy {
 		// If x-amz-metadata-directive is not set to REPLACE then we need
 		// to error out if source and destination are same.
 		writeErrorResponse(w, ErrInvalidCopyDest, r.URL)
 		return
 	}
 
-	// Copy source object to destination, if source and destination
-	// object is same then only metadata is updated.
-	objInfo, err = objectAPI.CopyObject(srcBucket, srcObject, dstBucket, dstObject, newMetadata)
+	// Copying an object onto itself only updates its metadata, other
+	// copies write a new object which is subject to the bucket quota.
+	metadataOnly := cpSrcDstSame && srcVersionID == "" && (srcKey == nil) == (dstKey == nil)
+	var quota *bucketQuotaReservation
+	if !metadataOnly {
+		// The source is written decrypted, or encrypted anew.
+		dstSize := srcSize
+		if dstKey != nil {
+			dstSize = sseEncryptedSize(srcSize)
+		}
+		var s3Error APIErrorCode
+		if quota, s3Error = enforceBucketQuota(objectAPI, dstBucket, dstObject, dstSize); s3Error != ErrNone {
+			writeErrorResponse(w, s3Error, r.URL)
+			return
+		}
+		defer quota.release()
+	}
+
+	if cpSrcDstSame && srcVersionID == "" && srcKey != nil && dstKey != nil {
+		// Changing the key of an encrypted object only re-seals its
+		// object key, which also rotates server managed keys.
+		objInfo, err = rotateSSEObjectKey(objectAPI, objInfo, srcKey, dstKey, newMetadata)
+	} else if srcVersionID != "" || isEncryptedCopy {
+		// Copy the requested source version or decrypted data to destination.
+		objInfo, err = copyObjectStream(objectAPI, objInfo, srcVersionID, srcKey, dstBucket, dstObject, dstKey, newMetadata)
+	} else {
+		// Copy source object to destination, if source and destination
+		// object is same then only metadata is updated.
+		objInfo, err = objectAPI.CopyObject(srcBucket, srcObject, dstBucket, dstObject, newMetadata)
+	}
 	if err != nil {
 		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
 		return
 	}
+	if !metadataOnly {
+		updateBucketUsage(objectAPI, dstBucket, quota.removed, objInfo)
+	}
+	scheduleObjectReplication(objInfo)
 
 	response := generateCopyObjectResponse(objInfo.ETag, objInfo.ModTime)
 	encodedSuccessResponse := encodeResponse(response)
 
+	if srcVersionID != "" {
+		w.Header().Set("x-amz-copy-source-version-id", srcVersionID)
+	}
+	setVersionHeaders(w, objInfo)
+	if dstKey != nil {
+		setSSEResponseHeaders(w, dstKey, newMetadata)
+		objInfo.Size = srcSize
+	}
+
 	// Write success response.
 	writeSuccessResponseXML(w, encodedSuccessResponse)
 
@@ -467,6 +763,18 @@ func (api objectAPIHandlers) PutObjectHandler(w http.ResponseWriter, r *http.Req
 		writeErrorResponse(w, ErrInternalError, r.URL)
 		return
 	}
+	if err = extractTagsFromHeader(r.Header, metadata); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+	if err = extractStorageClassFromHeader(r.Header, metadata); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+	if err = extractObjectLockFromHeader(r.Header, bucket, metadata); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
 	if rAuthType == authTypeStreamingSigned {
 		if contentEncoding, ok := metadata["content-encoding"]; ok {
 			contentEncoding = trimAwsChunkedContentEncoding(contentEncoding)
@@ -497,7 +805,7 @@ func (api objectAPIHandlers) PutObjectHandler(w http.ResponseWriter, r *http.Req
 	objectLock.Lock()
 	defer objectLock.Unlock()
 
-	var objInfo ObjectInfo
+	var reader io.Reader = r.Body
 	switch rAuthType {
 	default:
 		// For all unknown auth types return error.
@@ -505,22 +813,20 @@ func (api objectAPIHandlers) PutObjectHandler(w http.ResponseWriter, r *http.Req
 		return
 	case authTypeAnonymous:
 		// http://docs.aws.amazon.com/AmazonS3/latest/dev/using-with-s3-actions.html
-		if s3Error := enforceBucketPolicy(bucket, "s3:PutObject", r.URL.Path,
-			r.Referer(), r.URL.Query()); s3Error != ErrNone {
+		if s3Error := enforceBucketPolicy(bucket, "s3:PutObject", getResource(r.URL.Path, r.Host),
+			r.Referer(), r.URL.Query(), getObjectTags(metadata)); s3Error != ErrNone {
 			writeErrorResponse(w, s3Error, r.URL)
 			return
 		}
-		// Create anonymous object.
-		objInfo, err = objectAPI.PutObject(bucket, object, size, r.Body, metadata, sha256sum)
 	case authTypeStreamingSigned:
 		// Initialize stream signature verifier.
-		reader, s3Error := newSignV4ChunkedReader(r)
+		var s3Error APIErrorCode
+		reader, s3Error = newSignV4ChunkedReader(r)
 		if s3Error != ErrNone {
 			errorIf(errSignatureMismatch, "%s", dumpRequest(r))
 			writeErrorResponse(w, s3Error, r.URL)
 			return
 		}
-		objInfo, err = objectAPI.PutObject(bucket, object, size, reader, metadata, sha256sum)
 	case authTypeSignedV2, authTypePresignedV2:
 		s3Error := isReqAuthenticatedV2(r)
 		if s3Error != ErrNone {
@@ -528,7 +834,6 @@ func (api objectAPIHandlers) PutObjectHandler(w http.ResponseWriter, r *http.Req
 			writeErrorResponse(w, s3Error, r.URL)
 			return
 		}
-		objInfo, err = objectAPI.PutObject(bucket, object, size, r.Body, metadata, sha256sum)
 	case authTypePresigned, authTypeSigned:
 		if s3Error := reqSignatureV4Verify(r, serverConfig.GetRegion()); s3Error != ErrNone {
 			errorIf(errSignatureMismatch, "%s", dumpRequest(r))
@@ -538,15 +843,74 @@ func (api objectAPIHandlers) PutObjectHandler(w http.ResponseWriter, r *http.Req
 		if !skipContentSha256Cksum(r) {
 			sha256sum = r.Header.Get("X-Amz-Content-Sha256")
 		}
-		// Create object.
-		objInfo, err = objectAPI.PutObject(bucket, object, size, r.Body, metadata, sha256sum)
 	}
+
+	// Signed requests are allowed by the policies of the signer.
+	if rAuthType != authTypeAnonymous {
+		if s3Error := isReqActionAllowed(r, "s3:PutObject"); s3Error != ErrNone {
+			writeErrorResponse(w, s3Error, r.URL)
+			return
+		}
+	}
+
+	// Encrypt the data before it reaches the object layer, which
+	// then only sees the encrypted data. The checksums sent by the
+	// client are hence verified over the plaintext here.
+	sseKey, err := getSSEPutKey(r, bucket, object, metadata)
+	if err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+	putSize := size
+	if sseKey != nil {
+		putSize = sseEncryptedSize(size)
+	}
+
+	// Writes exceeding the hard quota of the bucket are rejected,
+	// objects are accounted at their stored size.
+	quota, s3Error := enforceBucketQuota(objectAPI, bucket, object, putSize)
+	if s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+	defer quota.release()
+
+	if sseKey != nil {
+		reader = newHashVerifyReader(reader, metadata["etag"], sha256sum)
+		delete(metadata, "etag")
+		sha256sum = ""
+
+		objectKey, kerr := newSSEObjectKey(sseKey, metadata)
+		if kerr != nil {
+			errorIf(kerr, "Unable to generate object key.")
+			writeErrorResponse(w, ErrInternalError, r.URL)
+			return
+		}
+		if reader, err = newSSEEncryptReader(reader, objectKey, 1); err != nil {
+			errorIf(err, "Unable to initialize encryption.")
+			writeErrorResponse(w, ErrInternalError, r.URL)
+			return
+		}
+	}
+
+	// Mark the object for replication if the bucket is replicated.
+	setReplicationMetadata(bucket, object, metadata)
+
+	// Create object.
+	objInfo, err := objectAPI.PutObject(bucket, object, putSize, reader, metadata, sha256sum)
 	if err != nil {
 		errorIf(err, "Unable to create an object. %s", r.URL.Path)
 		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
 		return
 	}
+	updateBucketUsage(objectAPI, bucket, quota.removed, objInfo)
+	scheduleObjectReplication(objInfo)
 	w.Header().Set("ETag", "\""+objInfo.ETag+"\"")
+	setVersionHeaders(w, objInfo)
+	if sseKey != nil {
+		setSSEResponseHeaders(w, sseKey, metadata)
+		objInfo.Size = size
+	}
 	writeSuccessResponseHeadersOnly(w)
 
 	// Get host and port from Request.RemoteAddr.
@@ -594,6 +958,37 @@ func (api objectAPIHandlers) NewMultipartUploadHandler(w http.ResponseWriter, r
 		writeErrorResponse(w, ErrInternalError, r.URL)
 		return
 	}
+	if err = extractTagsFromHeader(r.Header, metadata); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+	if err = extractStorageClassFromHeader(r.Header, metadata); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+	if err = extractObjectLockFromHeader(r.Header, bucket, metadata); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	// The object key of an encrypted upload is generated once and
+	// saved sealed with the upload, all parts are encrypted with
+	// keys derived from it.
+	sseKey, err := getSSEPutKey(r, bucket, object, metadata)
+	if err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+	if sseKey != nil {
+		if _, err = newSSEObjectKey(sseKey, metadata); err != nil {
+			errorIf(err, "Unable to generate object key.")
+			writeErrorResponse(w, ErrInternalError, r.URL)
+			return
+		}
+	}
+
+	// Mark the object for replication if the bucket is replicated.
+	setReplicationMetadata(bucket, object, metadata)
 
 	uploadID, err := objectAPI.NewMultipartUpload(bucket, object, metadata)
 	if err != nil {
@@ -605,6 +1000,10 @@ func (api objectAPIHandlers) NewMultipartUploadHandler(w http.ResponseWriter, r
 	response := generateInitiateMultipartUploadResponse(bucket, object, uploadID)
 	encodedSuccessResponse := encodeResponse(response)
 
+	if sseKey != nil {
+		setSSEResponseHeaders(w, sseKey, metadata)
+	}
+
 	// Write success response.
 	writeSuccessResponseXML(w, encodedSuccessResponse)
 }
@@ -640,6 +1039,12 @@ func (api objectAPIHandlers) CopyObjectPartHandler(w http.ResponseWriter, r *htt
 		return
 	}
 
+	// The requester must be allowed to read the copy source.
+	if s3Error := isCopySourceAllowed(r, srcBucket, srcObject, ""); s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
 	uploadID := r.URL.Query().Get("uploadId")
 	partIDString := r.URL.Query().Get("partNumber")
 
@@ -668,11 +1073,32 @@ func (api objectAPIHandlers) CopyObjectPartHandler(w http.ResponseWriter, r *htt
 		return
 	}
 
+	// Encrypted sources are decrypted with the copy source key, the
+	// part is encrypted if the upload is encrypted.
+	srcKey, err := getSSEKey(r, srcBucket, srcObject, objInfo.UserDefined, true)
+	if err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+	srcSize := objInfo.Size
+	if srcKey != nil {
+		if srcSize, err = sseDecryptedObjectSize(objInfo); err != nil {
+			writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+			return
+		}
+	}
+	dstKey, dstObjectKey, dstMetadata, err := getSSEUploadObjectKey(objectAPI, r, dstBucket, dstObject, uploadID)
+	if err != nil {
+		errorIf(err, "Unable to validate encryption of the upload.")
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
 	// Get request range.
 	var hrange *httpRange
 	rangeHeader := r.Header.Get("x-amz-copy-source-range")
 	if rangeHeader != "" {
-		if hrange, err = parseCopyPartRange(rangeHeader, objInfo.Size); err != nil {
+		if hrange, err = parseCopyPartRange(rangeHeader, srcSize); err != nil {
 			// Handle only errInvalidRange
 			// Ignore other parse error and treat it as regular Get request like Amazon S3.
 			errorIf(err, "Unable to extract range %s", rangeHeader)
@@ -688,7 +1114,7 @@ func (api objectAPIHandlers) CopyObjectPartHandler(w http.ResponseWriter, r *htt
 
 	// Get the object.
 	var startOffset int64
-	length := objInfo.Size
+	length := srcSize
 	if hrange != nil {
 		length = hrange.getLength()
 		startOffset = hrange.offsetBegin
@@ -700,9 +1126,29 @@ func (api objectAPIHandlers) CopyObjectPartHandler(w http.ResponseWriter, r *htt
 		return
 	}
 
-	// Copy source object to destination, if source and destination
-	// object is same then only metadata is updated.
-	partInfo, err := objectAPI.CopyObjectPart(srcBucket, srcObject, dstBucket, dstObject, uploadID, partID, startOffset, length)
+	var partInfo PartInfo
+	if srcKey != nil || dstObjectKey != nil {
+		// Stream the decrypted source range into the part, which is
+		// encrypted if the upload is encrypted.
+		reader := getObjectReader(objectAPI, objInfo, "", srcKey, startOffset, length)
+		defer reader.Close()
+
+		var data io.Reader = reader
+		size := length
+		if dstObjectKey != nil {
+			if data, err = newSSEEncryptReader(reader, dstObjectKey, partID); err != nil {
+				errorIf(err, "Unable to initialize encryption.")
+				writeErrorResponse(w, ErrInternalError, r.URL)
+				return
+			}
+			size = sseEncryptedSize(length)
+		}
+		partInfo, err = objectAPI.PutObjectPart(dstBucket, dstObject, uploadID, partID, size, data, "", "")
+	} else {
+		// Copy source object to destination, if source and destination
+		// object is same then only metadata is updated.
+		partInfo, err = objectAPI.CopyObjectPart(srcBucket, srcObject, dstBucket, dstObject, uploadID, partID, startOffset, length)
+	}
 	if err != nil {
 		errorIf(err, "Unable to perform CopyObjectPart %s/%s", srcBucket, srcObject)
 		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
@@ -712,6 +1158,10 @@ func (api objectAPIHandlers) CopyObjectPartHandler(w http.ResponseWriter, r *htt
 	response := generateCopyObjectPartResponse(partInfo.ETag, partInfo.LastModified)
 	encodedSuccessResponse := encodeResponse(response)
 
+	if dstKey != nil {
+		setSSEResponseHeaders(w, dstKey, dstMetadata)
+	}
+
 	// Write success response.
 	writeSuccessResponseXML(w, encodedSuccessResponse)
 }
@@ -781,9 +1231,9 @@ func (api objectAPIHandlers) PutObjectPartHandler(w http.ResponseWriter, r *http
 		return
 	}
 
-	var partInfo PartInfo
 	incomingMD5 := hex.EncodeToString(md5Bytes)
 	sha256sum := ""
+	var reader io.Reader = r.Body
 	switch rAuthType {
 	default:
 		// For all unknown auth types return error.
@@ -791,22 +1241,21 @@ func (api objectAPIHandlers) PutObjectPartHandler(w http.ResponseWriter, r *http
 		return
 	case authTypeAnonymous:
 		// http://docs.aws.amazon.com/AmazonS3/latest/dev/mpuAndPermissions.html
-		if s3Error := enforceBucketPolicy(bucket, "s3:PutObject", r.URL.Path,
-			r.Referer(), r.URL.Query()); s3Error != ErrNone {
+		if s3Error := enforceBucketPolicy(bucket, "s3:PutObject", getResource(r.URL.Path, r.Host),
+			r.Referer(), r.URL.Query(), nil); s3Error != ErrNone {
 			writeErrorResponse(w, s3Error, r.URL)
 			return
 		}
 		// No need to verify signature, anonymous request access is already allowed.
-		partInfo, err = objectAPI.PutObjectPart(bucket, object, uploadID, partID, size, r.Body, incomingMD5, sha256sum)
 	case authTypeStreamingSigned:
 		// Initialize stream signature verifier.
-		reader, s3Error := newSignV4ChunkedReader(r)
+		var s3Error APIErrorCode
+		reader, s3Error = newSignV4ChunkedReader(r)
 		if s3Error != ErrNone {
 			errorIf(errSignatureMismatch, "%s", dumpRequest(r))
 			writeErrorResponse(w, s3Error, r.URL)
 			return
 		}
-		partInfo, err = objectAPI.PutObjectPart(bucket, object, uploadID, partID, size, reader, incomingMD5, sha256sum)
 	case authTypeSignedV2, authTypePresignedV2:
 		s3Error := isReqAuthenticatedV2(r)
 		if s3Error != ErrNone {
@@ -814,7 +1263,6 @@ func (api objectAPIHandlers) PutObjectPartHandler(w http.ResponseWriter, r *http
 			writeErrorResponse(w, s3Error, r.URL)
 			return
 		}
-		partInfo, err = objectAPI.PutObjectPart(bucket, object, uploadID, partID, size, r.Body, incomingMD5, sha256sum)
 	case authTypePresigned, authTypeSigned:
 		if s3Error := reqSignatureV4Verify(r, serverConfig.GetRegion()); s3Error != ErrNone {
 			errorIf(errSignatureMismatch, "%s", dumpRequest(r))
@@ -825,8 +1273,36 @@ func (api objectAPIHandlers) PutObjectPartHandler(w http.ResponseWriter, r *http
 		if !skipContentSha256Cksum(r) {
 			sha256sum = r.Header.Get("X-Amz-Content-Sha256")
 		}
-		partInfo, err = objectAPI.PutObjectPart(bucket, object, uploadID, partID, size, r.Body, incomingMD5, sha256sum)
 	}
+
+	// Signed requests are allowed by the policies of the signer.
+	if rAuthType != authTypeAnonymous {
+		if s3Error := isReqActionAllowed(r, "s3:PutObject"); s3Error != ErrNone {
+			writeErrorResponse(w, s3Error, r.URL)
+			return
+		}
+	}
+
+	// Parts of an encrypted upload are encrypted before they reach the
+	// object layer, the checksums are hence verified over the plaintext.
+	sseKey, objectKey, uploadMetadata, err := getSSEUploadObjectKey(objectAPI, r, bucket, object, uploadID)
+	if err != nil {
+		errorIf(err, "Unable to validate encryption of the upload.")
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+	if objectKey != nil {
+		reader = newHashVerifyReader(reader, incomingMD5, sha256sum)
+		incomingMD5, sha256sum = "", ""
+		if reader, err = newSSEEncryptReader(reader, objectKey, partID); err != nil {
+			errorIf(err, "Unable to initialize encryption.")
+			writeErrorResponse(w, ErrInternalError, r.URL)
+			return
+		}
+		size = sseEncryptedSize(size)
+	}
+
+	partInfo, err := objectAPI.PutObjectPart(bucket, object, uploadID, partID, size, reader, incomingMD5, sha256sum)
 	if err != nil {
 		errorIf(err, "Unable to create object part.")
 		// Verify if the underlying error is signature mismatch.
@@ -836,6 +1312,9 @@ func (api objectAPIHandlers) PutObjectPartHandler(w http.ResponseWriter, r *http
 	if partInfo.ETag != "" {
 		w.Header().Set("ETag", "\""+partInfo.ETag+"\"")
 	}
+	if sseKey != nil {
+		setSSEResponseHeaders(w, sseKey, uploadMetadata)
+	}
 
 	writeSuccessResponseHeadersOnly(w)
 }
@@ -958,6 +1437,23 @@ func (api objectAPIHandlers) CompleteMultipartUploadHandler(w http.ResponseWrite
 	destLock.Lock()
 	defer destLock.Unlock()
 
+	// Writes exceeding the hard quota of the bucket are rejected, the
+	// size of the object is only looked up for buckets with a quota.
+	var size int64
+	if isBucketQuotaEnabled(bucket) {
+		if size, err = getCompletedPartsSize(objectAPI, bucket, object, uploadID, completeParts); err != nil {
+			errorIf(err, "Unable to list object parts.")
+			writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+			return
+		}
+	}
+	quota, s3Error := enforceBucketQuota(objectAPI, bucket, object, size)
+	if s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+	defer quota.release()
+
 	objInfo, err := objectAPI.CompleteMultipartUpload(bucket, object, uploadID, completeParts)
 	if err != nil {
 		errorIf(err, "Unable to complete multipart upload.")
@@ -973,6 +1469,9 @@ func (api objectAPIHandlers) CompleteMultipartUploadHandler(w http.ResponseWrite
 		return
 	}
 
+	updateBucketUsage(objectAPI, bucket, quota.removed, objInfo)
+	scheduleObjectReplication(objInfo)
+
 	// Get object location.
 	location := getLocation(r)
 	// Generate complete multipart response.
@@ -987,6 +1486,9 @@ func (api objectAPIHandlers) CompleteMultipartUploadHandler(w http.ResponseWrite
 	// Set etag.
 	w.Header().Set("ETag", "\""+objInfo.ETag+"\"")
 
+	// Set version ID, if any.
+	setVersionHeaders(w, objInfo)
+
 	// Write success response.
 	writeSuccessResponseXML(w, encodedSuccessResponse)
 
@@ -1022,11 +1524,33 @@ func (api objectAPIHandlers) DeleteObjectHandler(w http.ResponseWriter, r *http.
 		return
 	}
 
-	if s3Error := checkRequestAuthType(r, bucket, "s3:DeleteObject", serverConfig.GetRegion()); s3Error != ErrNone {
+	versionID := r.URL.Query().Get("versionId")
+	if s3Error := checkRequestAuthType(r, bucket, getVersionAction("s3:DeleteObject", versionID), serverConfig.GetRegion()); s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	// Governance mode retention may only be bypassed with permission.
+	bypassGovernance, s3Error := checkBypassGovernance(r, bucket)
+	if s3Error != ErrNone {
 		writeErrorResponse(w, s3Error, r.URL)
 		return
 	}
 
+	// Deleting a specific version or deleting in a versioned bucket
+	// reports back the version which was removed or added.
+	if versionID != "" || getBucketVersioningStatus(bucket) != "" {
+		objInfo, err := deleteObjectVersion(objectAPI, bucket, object, versionID, bypassGovernance, r)
+		if err != nil {
+			errorIf(err, "Unable to delete an object version %s", pathJoin(bucket, object))
+			writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+			return
+		}
+		setVersionHeaders(w, objInfo)
+		writeSuccessNoContent(w)
+		return
+	}
+
 	// http://docs.aws.amazon.com/AmazonS3/latest/API/RESTObjectDELETE.html
 	// Ignore delete object errors while replying to client, since we are
 	// suppposed to reply only 204. Additionally log the error for
@@ -1854,9 +1854,28 @@ func testAPICopyObjectHandler(obj ObjectLayer, instanceType, bucketName string,
 	// Its necessary to set the "X-Amz-Copy-Source" header for the request to be accepted by the handler.
 	anonReq.Header.Set("X-Amz-Copy-Source", url.QueryEscape("/"+bucketName+"/"+anonObject))
 	// ExecObjectLayerAPIAnonTest - Calls the HTTP API handler using the anonymous request, validates the ErrAccessDeniedResponse,
-	// sets the bucket policy using the policy statement generated from `getWriteOnlyObjectStatement` so that the
-	// unsigned request goes through and its validated again.
-	ExecObjectLayerAPIAnonTest(t, "TestAPICopyObjectHandler", bucketName, newCopyAnonObject, instanceType, apiRouter, anonReq, getWriteOnlyObjectStatement)
+	// sets the bucket policy using the policy statement generated from `getReadWriteObjectStatement` so that the
+	// unsigned request goes through and its validated again, copies need read access to the source too.
+	ExecObjectLayerAPIAnonTest(t, "TestAPICopyObjectHandler", bucketName, newCopyAnonObject, instanceType, apiRouter, anonReq, getReadWriteObjectStatement)
+
+	// Anonymous copies are denied if the bucket policy does not allow
+	// reading the source.
+	writeOnlyPolicy := bucketPolicy{
+		Version:    "1.0",
+		Statements: []policyStatement{getWriteOnlyObjectStatement(bucketName, "")},
+	}
+	globalBucketPolicies.SetBucketPolicy(bucketName, policyChange{false, &writeOnlyPolicy})
+	anonReq, err = newTestRequest("PUT", getCopyObjectURL("", bucketName, newCopyAnonObject), 0, nil)
+	if err != nil {
+		t.Fatalf("Minio %s: Failed to create an anonymous request for %s/%s: <ERROR> %v",
+			instanceType, bucketName, newCopyAnonObject, err)
+	}
+	anonReq.Header.Set("X-Amz-Copy-Source", url.QueryEscape("/"+bucketName+"/"+anonObject))
+	rec := httptest.NewRecorder()
+	apiRouter.ServeHTTP(rec, anonReq)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("Minio %s: Expected anonymous copy of an unreadable source to be denied, got %d", instanceType, rec.Code)
+	}
 
 	// HTTP request to test the case of `objectLayer` being set to `nil`.
 	// There is no need to use an existing bucket or valid input for creating the request,
@@ -3552,3 +3571,34 @@ func testAPIListObjectPartsHandler(obj ObjectLayer, instanceType, bucketName str
 	// `ExecObjectLayerAPINilTest` sets the Object Layer to `nil` and calls the handler.
 	ExecObjectLayerAPINilTest(t, nilBucket, nilObject, instanceType, apiRouter, nilReq)
 }
+
+// Tests parsing of the bucket, object and version of X-Amz-Copy-Source.
+func TestGetCopySource(t *testing.T) {
+	testCases := []struct {
+		cpSrc     string
+		bucket    string
+		object    string
+		versionID string
+		shouldErr bool
+	}{
+		{"/bucket/object", "bucket", "object", "", false},
+		{"bucket/object", "bucket", "object", "", false},
+		{"bucket%2Fobject", "bucket", "object", "", false},
+		{"/bucket/object?versionId=abc", "bucket", "object", "abc", false},
+		{"/bucket/dir%2Fobject?versionId=abc", "bucket", "dir/object", "abc", false},
+		// An escaped query belongs to the object name.
+		{"/bucket/object%3FversionId%3Dabc", "bucket", "object?versionId=abc", "", false},
+		{"/bucket/object%3FversionId%3Dabc?versionId=def", "bucket", "object?versionId=abc", "def", false},
+		{"/bucket/object?versionId=%zz", "", "", "", true},
+	}
+	for i, testCase := range testCases {
+		bucket, object, versionID, err := getCopySource(testCase.cpSrc)
+		if testCase.shouldErr != (err != nil) {
+			t.Fatalf("Test %d: Expected error %v, got %v", i+1, testCase.shouldErr, err)
+		}
+		if bucket != testCase.bucket || object != testCase.object || versionID != testCase.versionID {
+			t.Errorf("Test %d: Expected %s/%s@%s, got %s/%s@%s", i+1, testCase.bucket, testCase.object,
+				testCase.versionID, bucket, object, versionID)
+		}
+	}
+}
@@ -0,0 +1,319 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"encoding/xml"
+	"io"
+	"net/http"
+	"time"
+
+	mux "github.com/gorilla/mux"
+)
+
+// checkBypassGovernance - returns true if the request asks to bypass
+// governance mode retention, which requires the s3:BypassGovernanceRetention
+// permission. The request is expected to be authenticated already.
+func checkBypassGovernance(r *http.Request, bucket string) (bool, APIErrorCode) {
+	if !isBypassGovernanceRequested(r.Header) {
+		return false, ErrNone
+	}
+	var s3Error APIErrorCode
+	if getRequestAuthType(r) == authTypeAnonymous {
+		s3Error = enforceBucketPolicy(bucket, "s3:BypassGovernanceRetention", getResource(r.URL.Path, r.Host),
+			r.Referer(), r.URL.Query(), getRequestTags(r.Header))
+	} else {
+		s3Error = isReqActionAllowed(r, "s3:BypassGovernanceRetention")
+	}
+	return s3Error == ErrNone, s3Error
+}
+
+// getLockableObjectInfo - returns the object info of the requested
+// object version, object lock settings are only kept by objects in
+// buckets with object lock.
+func getLockableObjectInfo(objectAPI ObjectLayer, bucket, object, versionID string) (ObjectInfo, APIErrorCode) {
+	if !isBucketObjectLockEnabled(bucket) {
+		return ObjectInfo{}, ErrObjectLockNotConfigured
+	}
+	objInfo, err := getObjectVersionInfo(objectAPI, bucket, object, versionID)
+	if err != nil {
+		errorIf(err, "Unable to fetch object info.")
+		return objInfo, toAPIErrorCode(err)
+	}
+	if objInfo.DeleteMarker {
+		return objInfo, ErrNoSuchKey
+	}
+	return objInfo, ErrNone
+}
+
+// readObjectLockRequest - reads and decodes the XML body of the
+// PutObjectRetention and PutObjectLegalHold APIs.
+func readObjectLockRequest(r *http.Request, v interface{}) APIErrorCode {
+	// If Content-Length is unknown or zero, deny the request.
+	if r.ContentLength == -1 || r.ContentLength == 0 {
+		return ErrMissingContentLength
+	}
+
+	// If Content-Length is greater than maximum allowed request size.
+	if r.ContentLength > maxObjectLockSize {
+		return ErrEntityTooLarge
+	}
+
+	if err := xml.NewDecoder(io.LimitReader(r.Body, r.ContentLength)).Decode(v); err != nil {
+		errorIf(err, "Unable to parse object lock XML.")
+		return ErrMalformedXML
+	}
+	return ErrNone
+}
+
+// GetObjectRetentionHandler - GET Object retention
+// ----------
+// This implementation of the GET operation uses the retention
+// subresource to return the retention settings of an object.
+func (api objectAPIHandlers) GetObjectRetentionHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+	object := vars["object"]
+
+	objectAPI := api.ObjectAPI()
+	if objectAPI == nil {
+		writeErrorResponse(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	if s3Error := checkRequestAuthType(r, bucket, "s3:GetObjectRetention", serverConfig.GetRegion()); s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	// Lock the object before reading.
+	objectLock := globalNSMutex.NewNSLock(bucket, object)
+	objectLock.RLock()
+	defer objectLock.RUnlock()
+
+	objInfo, s3Error := getLockableObjectInfo(objectAPI, bucket, object, r.URL.Query().Get("versionId"))
+	if s3Error != ErrNone {
+		setVersionHeaders(w, objInfo)
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	lock := getObjectLock(objInfo.UserDefined)
+	if lock.Mode == "" {
+		writeErrorResponse(w, ErrNoSuchObjectRetention, r.URL)
+		return
+	}
+
+	retentionBytes, err := xml.Marshal(objectRetention{
+		Mode:            lock.Mode,
+		RetainUntilDate: lock.RetainUntilDate.UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		errorIf(err, "Unable to marshal retention into XML.")
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	// Success.
+	setVersionHeaders(w, objInfo)
+	writeSuccessResponseXML(w, retentionBytes)
+}
+
+// PutObjectRetentionHandler - PUT Object retention
+// ----------
+// This implementation of the PUT operation uses the retention
+// subresource to set the retention settings of an existing object.
+// Retention can always be extended, governance mode retention can be
+// shortened or removed only when bypassed with permission.
+func (api objectAPIHandlers) PutObjectRetentionHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+	object := vars["object"]
+
+	objectAPI := api.ObjectAPI()
+	if objectAPI == nil {
+		writeErrorResponse(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	if s3Error := checkRequestAuthType(r, bucket, "s3:PutObjectRetention", serverConfig.GetRegion()); s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	// Governance mode retention may only be bypassed with permission.
+	bypassGovernance, s3Error := checkBypassGovernance(r, bucket)
+	if s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	var retention objectRetention
+	if s3Error = readObjectLockRequest(r, &retention); s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	// An empty retention removes the retention of the object.
+	var newLock objectLock
+	if retention.Mode != "" || retention.RetainUntilDate != "" {
+		if retention.Mode == "" || retention.RetainUntilDate == "" {
+			writeErrorResponse(w, ErrIncompleteRetention, r.URL)
+			return
+		}
+		if !isValidRetentionMode(retention.Mode) {
+			writeErrorResponse(w, ErrInvalidRetentionMode, r.URL)
+			return
+		}
+		until, err := parseRetainUntilDate(retention.RetainUntilDate)
+		if err != nil {
+			writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+			return
+		}
+		newLock.Mode, newLock.RetainUntilDate = retention.Mode, until
+	}
+
+	// Lock the object before updating its metadata.
+	objectLock := globalNSMutex.NewNSLock(bucket, object)
+	objectLock.Lock()
+	defer objectLock.Unlock()
+
+	objInfo, s3Error := getLockableObjectInfo(objectAPI, bucket, object, r.URL.Query().Get("versionId"))
+	if s3Error != ErrNone {
+		setVersionHeaders(w, objInfo)
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	// The legal hold of the object is unaffected.
+	newLock.LegalHold = getObjectLock(objInfo.UserDefined).LegalHold
+	objInfo, err := objectAPI.PutObjectLock(bucket, object, toVersionID(objInfo.VersionID), newLock, bypassGovernance)
+	if err != nil {
+		errorIf(err, "Unable to set object retention.")
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	// Success.
+	setVersionHeaders(w, objInfo)
+	writeSuccessResponseHeadersOnly(w)
+}
+
+// GetObjectLegalHoldHandler - GET Object legal hold
+// ----------
+// This implementation of the GET operation uses the legal-hold
+// subresource to return the legal hold status of an object.
+func (api objectAPIHandlers) GetObjectLegalHoldHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+	object := vars["object"]
+
+	objectAPI := api.ObjectAPI()
+	if objectAPI == nil {
+		writeErrorResponse(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	if s3Error := checkRequestAuthType(r, bucket, "s3:GetObjectLegalHold", serverConfig.GetRegion()); s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	// Lock the object before reading.
+	objectLock := globalNSMutex.NewNSLock(bucket, object)
+	objectLock.RLock()
+	defer objectLock.RUnlock()
+
+	objInfo, s3Error := getLockableObjectInfo(objectAPI, bucket, object, r.URL.Query().Get("versionId"))
+	if s3Error != ErrNone {
+		setVersionHeaders(w, objInfo)
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	legalHold := objectLegalHold{Status: legalHoldOff}
+	if getObjectLock(objInfo.UserDefined).LegalHold {
+		legalHold.Status = legalHoldOn
+	}
+	legalHoldBytes, err := xml.Marshal(legalHold)
+	if err != nil {
+		errorIf(err, "Unable to marshal legal hold into XML.")
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	// Success.
+	setVersionHeaders(w, objInfo)
+	writeSuccessResponseXML(w, legalHoldBytes)
+}
+
+// PutObjectLegalHoldHandler - PUT Object legal hold
+// ----------
+// This implementation of the PUT operation uses the legal-hold
+// subresource to place or lift the legal hold of an existing object.
+func (api objectAPIHandlers) PutObjectLegalHoldHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+	object := vars["object"]
+
+	objectAPI := api.ObjectAPI()
+	if objectAPI == nil {
+		writeErrorResponse(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	if s3Error := checkRequestAuthType(r, bucket, "s3:PutObjectLegalHold", serverConfig.GetRegion()); s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	var legalHold objectLegalHold
+	if s3Error := readObjectLockRequest(r, &legalHold); s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+	onHold, err := parseLegalHoldStatus(legalHold.Status)
+	if err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	// Lock the object before updating its metadata.
+	objectLock := globalNSMutex.NewNSLock(bucket, object)
+	objectLock.Lock()
+	defer objectLock.Unlock()
+
+	objInfo, s3Error := getLockableObjectInfo(objectAPI, bucket, object, r.URL.Query().Get("versionId"))
+	if s3Error != ErrNone {
+		setVersionHeaders(w, objInfo)
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	// The retention of the object is unaffected.
+	newLock := getObjectLock(objInfo.UserDefined)
+	newLock.LegalHold = onHold
+	if objInfo, err = objectAPI.PutObjectLock(bucket, object, toVersionID(objInfo.VersionID), newLock, false); err != nil {
+		errorIf(err, "Unable to set object legal hold.")
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	// Success.
+	setVersionHeaders(w, objInfo)
+	writeSuccessResponseHeadersOnly(w)
+}
@@ -0,0 +1,259 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// AmzObjectLockMode carries the retention mode of an object.
+	AmzObjectLockMode = "X-Amz-Object-Lock-Mode"
+	// AmzObjectLockRetainUntilDate carries the date until which an
+	// object is retained, in ISO 8601 format.
+	AmzObjectLockRetainUntilDate = "X-Amz-Object-Lock-Retain-Until-Date"
+	// AmzObjectLockLegalHold carries the legal hold status of an object.
+	AmzObjectLockLegalHold = "X-Amz-Object-Lock-Legal-Hold"
+	// AmzBypassGovernanceRetention requests to remove or shorten the
+	// governance mode retention of an object.
+	AmzBypassGovernanceRetention = "X-Amz-Bypass-Governance-Retention"
+	// AmzBucketObjectLockEnabled requests to enable object lock on a
+	// new bucket.
+	AmzBucketObjectLockEnabled = "X-Amz-Bucket-Object-Lock-Enabled"
+
+	// Metadata keys of the object lock settings of an object.
+	ObjectLockMode            = ReservedMetadataPrefix + "Object-Lock-Mode"
+	ObjectLockRetainUntilDate = ReservedMetadataPrefix + "Object-Lock-Retain-Until-Date"
+	ObjectLockLegalHold       = ReservedMetadataPrefix + "Object-Lock-Legal-Hold"
+
+	// Retention modes, governance mode retention can be bypassed with
+	// the s3:BypassGovernanceRetention permission while compliance mode
+	// retention can neither be bypassed nor shortened.
+	retentionGovernance = "GOVERNANCE"
+	retentionCompliance = "COMPLIANCE"
+
+	// Legal hold status values.
+	legalHoldOn  = "ON"
+	legalHoldOff = "OFF"
+
+	// Maximum supported size of object lock requests.
+	maxObjectLockSize = 1024
+)
+
+var (
+	errInvalidRetentionMode    = errors.New("Unknown retention mode")
+	errInvalidRetainUntilDate  = errors.New("Retain until date must be a future date in ISO 8601 format")
+	errIncompleteRetention     = errors.New("Retention mode and retain until date must both be supplied")
+	errInvalidLegalHoldStatus  = errors.New("Legal hold status must be either ON or OFF")
+	errObjectLockNotConfigured = errors.New("Object lock is not enabled on the bucket")
+)
+
+// isValidRetentionMode - returns true for the supported retention modes.
+func isValidRetentionMode(mode string) bool {
+	return mode == retentionGovernance || mode == retentionCompliance
+}
+
+// objectLock - object lock settings of an object version, the version
+// may not be removed while it is retained or under legal hold.
+type objectLock struct {
+	Mode            string
+	RetainUntilDate time.Time
+	LegalHold       bool
+}
+
+// isRetained - returns true if the retention of the object is in
+// effect at the given time.
+func (l objectLock) isRetained(now time.Time) bool {
+	return l.Mode != "" && l.RetainUntilDate.After(now)
+}
+
+// getObjectLock - returns the object lock settings saved in the object
+// metadata.
+func getObjectLock(metadata map[string]string) (lock objectLock) {
+	if mode := metadata[ObjectLockMode]; mode != "" {
+		if until, err := time.Parse(time.RFC3339, metadata[ObjectLockRetainUntilDate]); err == nil {
+			lock.Mode = mode
+			lock.RetainUntilDate = until
+		}
+	}
+	lock.LegalHold = metadata[ObjectLockLegalHold] == legalHoldOn
+	return lock
+}
+
+// setObjectLock - saves the object lock settings in the object metadata,
+// unset settings remove any previous ones.
+func setObjectLock(metadata map[string]string, lock objectLock) {
+	if lock.Mode != "" {
+		metadata[ObjectLockMode] = lock.Mode
+		metadata[ObjectLockRetainUntilDate] = lock.RetainUntilDate.UTC().Format(time.RFC3339)
+	} else {
+		delete(metadata, ObjectLockMode)
+		delete(metadata, ObjectLockRetainUntilDate)
+	}
+	if lock.LegalHold {
+		metadata[ObjectLockLegalHold] = legalHoldOn
+	} else {
+		delete(metadata, ObjectLockLegalHold)
+	}
+}
+
+// setObjectLockHeaders - reports the object lock settings of an object.
+func setObjectLockHeaders(w http.ResponseWriter, metadata map[string]string) {
+	lock := getObjectLock(metadata)
+	if lock.Mode != "" {
+		w.Header().Set(AmzObjectLockMode, lock.Mode)
+		w.Header().Set(AmzObjectLockRetainUntilDate, lock.RetainUntilDate.UTC().Format(time.RFC3339))
+	}
+	if lock.LegalHold {
+		w.Header().Set(AmzObjectLockLegalHold, legalHoldOn)
+	}
+}
+
+// parseRetainUntilDate - parses a retain until date, which has to be
+// in the future.
+func parseRetainUntilDate(date string) (time.Time, error) {
+	until, err := time.Parse(time.RFC3339, date)
+	if err != nil || !until.After(UTCNow()) {
+		return time.Time{}, errInvalidRetainUntilDate
+	}
+	return until.UTC(), nil
+}
+
+// parseLegalHoldStatus - parses a legal hold status.
+func parseLegalHoldStatus(status string) (bool, error) {
+	switch status {
+	case legalHoldOn:
+		return true, nil
+	case legalHoldOff:
+		return false, nil
+	}
+	return false, errInvalidLegalHoldStatus
+}
+
+// extractObjectLockFromHeader - saves the object lock settings of a new
+// object in its metadata. Retention is taken from the request or else
+// from the default retention of the bucket.
+func extractObjectLockFromHeader(header http.Header, bucket string, metadata map[string]string) error {
+	mode := header.Get(AmzObjectLockMode)
+	date := header.Get(AmzObjectLockRetainUntilDate)
+	legalHold := header.Get(AmzObjectLockLegalHold)
+
+	lcfg := globalBucketObjectLock.GetBucketObjectLock(bucket)
+	if lcfg == nil {
+		if mode != "" || date != "" || legalHold != "" {
+			return errObjectLockNotConfigured
+		}
+		return nil
+	}
+
+	var lock objectLock
+	if mode != "" || date != "" {
+		if mode == "" || date == "" {
+			return errIncompleteRetention
+		}
+		if !isValidRetentionMode(mode) {
+			return errInvalidRetentionMode
+		}
+		until, err := parseRetainUntilDate(date)
+		if err != nil {
+			return err
+		}
+		lock.Mode, lock.RetainUntilDate = mode, until
+	} else {
+		lock = lcfg.defaultObjectLock(UTCNow())
+	}
+
+	if legalHold != "" {
+		var err error
+		if lock.LegalHold, err = parseLegalHoldStatus(legalHold); err != nil {
+			return err
+		}
+	}
+
+	setObjectLock(metadata, lock)
+	return nil
+}
+
+// removeObjectLockMetadata - removes the object lock settings from the
+// metadata, copies of an object do not inherit them.
+func removeObjectLockMetadata(metadata map[string]string) {
+	setObjectLock(metadata, objectLock{})
+}
+
+// isBypassGovernanceRequested - returns true if the request asks to
+// bypass governance mode retention.
+func isBypassGovernanceRequested(header http.Header) bool {
+	return strings.EqualFold(header.Get(AmzBypassGovernanceRetention), "true")
+}
+
+// checkObjectLockRemoval - returns ObjectLocked if the object version
+// is retained or under legal hold. Governance mode retention is
+// bypassed if requested by a client allowed to do so.
+func checkObjectLockRemoval(objInfo ObjectInfo, bypassGovernance bool) error {
+	lock := getObjectLock(objInfo.UserDefined)
+	if lock.LegalHold {
+		return traceError(ObjectLocked{Bucket: objInfo.Bucket, Object: objInfo.Name})
+	}
+	if lock.isRetained(UTCNow()) && (lock.Mode == retentionCompliance || !bypassGovernance) {
+		return traceError(ObjectLocked{Bucket: objInfo.Bucket, Object: objInfo.Name})
+	}
+	return nil
+}
+
+// isObjectLocked - returns true if err is an ObjectLocked error.
+func isObjectLocked(err error) bool {
+	_, ok := errorCause(err).(ObjectLocked)
+	return ok
+}
+
+// checkObjectLockUpdate - returns ObjectLocked if the new object lock
+// settings weaken the retention in effect. Retention may always be
+// extended, governance mode retention may be shortened or removed
+// only if bypassed. Legal holds may always be placed and lifted.
+func checkObjectLockUpdate(objInfo ObjectInfo, lock objectLock, bypassGovernance bool) error {
+	cur := getObjectLock(objInfo.UserDefined)
+	if !cur.isRetained(UTCNow()) {
+		return nil
+	}
+	if cur.Mode == retentionGovernance && bypassGovernance {
+		return nil
+	}
+	if lock.Mode == "" || lock.RetainUntilDate.Before(cur.RetainUntilDate) ||
+		(cur.Mode == retentionCompliance && lock.Mode != retentionCompliance) {
+		return traceError(ObjectLocked{Bucket: objInfo.Bucket, Object: objInfo.Name})
+	}
+	return nil
+}
+
+// objectRetention - retention of an object as sent and returned by
+// the PutObjectRetention and GetObjectRetention APIs.
+type objectRetention struct {
+	XMLName         xml.Name `xml:"Retention"`
+	Mode            string   `xml:"Mode,omitempty"`
+	RetainUntilDate string   `xml:"RetainUntilDate,omitempty"`
+}
+
+// objectLegalHold - legal hold status of an object as sent and returned
+// by the PutObjectLegalHold and GetObjectLegalHold APIs.
+type objectLegalHold struct {
+	XMLName xml.Name `xml:"LegalHold"`
+	Status  string   `xml:"Status"`
+}
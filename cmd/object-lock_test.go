@@ -0,0 +1,264 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Tests deciding whether an object version may be removed.
+func TestCheckObjectLockRemoval(t *testing.T) {
+	future := UTCNow().Add(time.Hour)
+	past := UTCNow().Add(-time.Hour)
+	testCases := []struct {
+		lock             objectLock
+		bypassGovernance bool
+		locked           bool
+	}{
+		{objectLock{}, false, false},
+		{objectLock{LegalHold: true}, true, true},
+		{objectLock{Mode: retentionGovernance, RetainUntilDate: future}, false, true},
+		{objectLock{Mode: retentionGovernance, RetainUntilDate: future}, true, false},
+		{objectLock{Mode: retentionCompliance, RetainUntilDate: future}, true, true},
+		{objectLock{Mode: retentionCompliance, RetainUntilDate: past}, false, false},
+	}
+	for i, testCase := range testCases {
+		metadata := make(map[string]string)
+		setObjectLock(metadata, testCase.lock)
+		err := checkObjectLockRemoval(ObjectInfo{UserDefined: metadata}, testCase.bypassGovernance)
+		if _, locked := errorCause(err).(ObjectLocked); locked != testCase.locked {
+			t.Errorf("Test %d: Expected locked to be %v, got %v", i+1, testCase.locked, err)
+		}
+	}
+}
+
+// Tests deciding whether object lock settings may be changed.
+func TestCheckObjectLockUpdate(t *testing.T) {
+	until := UTCNow().Add(time.Hour).Truncate(time.Second)
+	governance := objectLock{Mode: retentionGovernance, RetainUntilDate: until}
+	compliance := objectLock{Mode: retentionCompliance, RetainUntilDate: until}
+	testCases := []struct {
+		cur, lock        objectLock
+		bypassGovernance bool
+		locked           bool
+	}{
+		// Extending retention is always allowed.
+		{governance, objectLock{Mode: retentionGovernance, RetainUntilDate: until.Add(time.Hour)}, false, false},
+		{compliance, objectLock{Mode: retentionCompliance, RetainUntilDate: until.Add(time.Hour)}, false, false},
+		// Governance mode may be upgraded to compliance mode.
+		{governance, compliance, false, false},
+		// Shortening or removing retention.
+		{governance, objectLock{Mode: retentionGovernance, RetainUntilDate: until.Add(-time.Minute)}, false, true},
+		{governance, objectLock{}, false, true},
+		{governance, objectLock{}, true, false},
+		{compliance, objectLock{}, true, true},
+		{compliance, governance, true, true},
+		// Legal holds are independent of retention.
+		{compliance, objectLock{Mode: retentionCompliance, RetainUntilDate: until, LegalHold: true}, false, false},
+		{objectLock{}, objectLock{LegalHold: true}, false, false},
+	}
+	for i, testCase := range testCases {
+		metadata := make(map[string]string)
+		setObjectLock(metadata, testCase.cur)
+		err := checkObjectLockUpdate(ObjectInfo{UserDefined: metadata}, testCase.lock, testCase.bypassGovernance)
+		if _, locked := errorCause(err).(ObjectLocked); locked != testCase.locked {
+			t.Errorf("Test %d: Expected locked to be %v, got %v", i+1, testCase.locked, err)
+		}
+	}
+}
+
+// Tests object lock settings and the default retention of new objects.
+func TestExtractObjectLockFromHeader(t *testing.T) {
+	bucket := "locked-bucket"
+	globalBucketObjectLock = &bucketObjectLock{
+		rwMutex:                 &sync.RWMutex{},
+		bucketObjectLockConfigs: make(map[string]*objectLockConfiguration),
+	}
+	defer func() { globalBucketObjectLock = nil }()
+
+	header := http.Header{}
+	header.Set(AmzObjectLockLegalHold, legalHoldOn)
+	if err := extractObjectLockFromHeader(header, bucket, map[string]string{}); err != errObjectLockNotConfigured {
+		t.Fatalf("Expected %v, got %v", errObjectLockNotConfigured, err)
+	}
+
+	globalBucketObjectLock.SetBucketObjectLock(bucket, &objectLockConfiguration{
+		ObjectLockEnabled: objectLockEnabled,
+		Rule:              &objectLockRule{DefaultRetention: defaultRetention{Mode: retentionGovernance, Days: 1}},
+	})
+
+	until := UTCNow().Add(time.Hour).Format(time.RFC3339)
+	testCases := []struct {
+		mode, date, legalHold string
+		expectedErr           error
+		expectedMode          string
+		expectedLegalHold     bool
+	}{
+		{"", "", "", nil, retentionGovernance, false},
+		{retentionCompliance, until, legalHoldOn, nil, retentionCompliance, true},
+		{retentionCompliance, "", "", errIncompleteRetention, "", false},
+		{"FOREVER", until, "", errInvalidRetentionMode, "", false},
+		{retentionCompliance, "2000-01-01T00:00:00Z", "", errInvalidRetainUntilDate, "", false},
+		{"", "", "MAYBE", errInvalidLegalHoldStatus, "", false},
+	}
+	for i, testCase := range testCases {
+		header = http.Header{}
+		if testCase.mode != "" {
+			header.Set(AmzObjectLockMode, testCase.mode)
+		}
+		if testCase.date != "" {
+			header.Set(AmzObjectLockRetainUntilDate, testCase.date)
+		}
+		if testCase.legalHold != "" {
+			header.Set(AmzObjectLockLegalHold, testCase.legalHold)
+		}
+		metadata := make(map[string]string)
+		err := extractObjectLockFromHeader(header, bucket, metadata)
+		if err != testCase.expectedErr {
+			t.Fatalf("Test %d: Expected %v, got %v", i+1, testCase.expectedErr, err)
+		}
+		lock := getObjectLock(metadata)
+		if lock.Mode != testCase.expectedMode || lock.LegalHold != testCase.expectedLegalHold {
+			t.Errorf("Test %d: Expected %s/%v, got %#v", i+1, testCase.expectedMode, testCase.expectedLegalHold, lock)
+		}
+	}
+}
+
+// Wrapper for calling object lock tests for both XL and FS.
+func TestObjectLock(t *testing.T) {
+	initNSLock(false)
+	ExecObjectLayerTest(t, testObjectLock)
+}
+
+// Tests that the object layer refuses to remove locked object versions.
+func testObjectLock(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	bucket, object := "locked-bucket", "object"
+	if err := obj.MakeBucketWithLocation(bucket, ""); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if err := initBucketVersioning(obj); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if err := initBucketObjectLock(obj); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	globalBucketVersioning.SetBucketVersioning(bucket, &versioningConfiguration{Status: versioningEnabled})
+	defer globalBucketVersioning.SetBucketVersioning(bucket, nil)
+	globalBucketObjectLock.SetBucketObjectLock(bucket, &objectLockConfiguration{ObjectLockEnabled: objectLockEnabled})
+	defer globalBucketObjectLock.SetBucketObjectLock(bucket, nil)
+
+	until := UTCNow().Add(time.Hour)
+	metadata := make(map[string]string)
+	setObjectLock(metadata, objectLock{Mode: retentionGovernance, RetainUntilDate: until})
+	objInfo, err := obj.PutObject(bucket, object, 5, bytes.NewReader([]byte("hello")), metadata, "")
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	versionID := objInfo.VersionID
+
+	// Overwriting and deleting without version ID keep the locked version.
+	if _, err = obj.PutObject(bucket, object, 5, bytes.NewReader([]byte("world")), nil, ""); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if _, err = obj.DeleteObjectVersion(bucket, object, "", false); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+
+	// Locked version can not be removed without bypassing governance.
+	if _, err = obj.DeleteObjectVersion(bucket, object, versionID, false); !isObjectLocked(err) {
+		t.Fatalf("%s: Expected ObjectLocked, got %v", instanceType, err)
+	}
+
+	// Retention can be extended but not shortened.
+	if _, err = obj.PutObjectLock(bucket, object, versionID, objectLock{
+		Mode: retentionGovernance, RetainUntilDate: until.Add(-time.Minute),
+	}, false); !isObjectLocked(err) {
+		t.Fatalf("%s: Expected ObjectLocked, got %v", instanceType, err)
+	}
+	objInfo, err = obj.PutObjectLock(bucket, object, versionID, objectLock{
+		Mode: retentionCompliance, RetainUntilDate: until, LegalHold: true,
+	}, false)
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if lock := getObjectLock(objInfo.UserDefined); lock.Mode != retentionCompliance || !lock.LegalHold {
+		t.Fatalf("%s: Expected compliance mode under legal hold, got %#v", instanceType, lock)
+	}
+
+	// Compliance mode can not be bypassed, not even after lifting the legal hold.
+	if _, err = obj.PutObjectLock(bucket, object, versionID, objectLock{
+		Mode: retentionCompliance, RetainUntilDate: until,
+	}, false); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if _, err = obj.DeleteObjectVersion(bucket, object, versionID, true); !isObjectLocked(err) {
+		t.Fatalf("%s: Expected ObjectLocked, got %v", instanceType, err)
+	}
+
+	// Lock enabled buckets with versions can not be removed.
+	if err = obj.DeleteBucket(bucket); err == nil {
+		t.Fatalf("%s: Expected bucket removal to fail", instanceType)
+	}
+}
+
+// Wrapper for calling governance bypass tests for both XL and FS.
+func TestObjectLockBypassGovernance(t *testing.T) {
+	ExecObjectLayerTest(t, testObjectLockBypassGovernance)
+}
+
+// Tests governance mode retention is only lifted when bypassed and
+// preserved by metadata only copies.
+func testObjectLockBypassGovernance(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	bucket, object := "locked-bucket", "object"
+	if err := obj.MakeBucketWithLocation(bucket, ""); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if err := initBucketVersioning(obj); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if err := initBucketObjectLock(obj); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	globalBucketVersioning.SetBucketVersioning(bucket, &versioningConfiguration{Status: versioningEnabled})
+	defer globalBucketVersioning.SetBucketVersioning(bucket, nil)
+	globalBucketObjectLock.SetBucketObjectLock(bucket, &objectLockConfiguration{ObjectLockEnabled: objectLockEnabled})
+	defer globalBucketObjectLock.SetBucketObjectLock(bucket, nil)
+
+	metadata := make(map[string]string)
+	setObjectLock(metadata, objectLock{Mode: retentionGovernance, RetainUntilDate: UTCNow().Add(time.Hour)})
+	objInfo, err := obj.PutObject(bucket, object, 5, bytes.NewReader([]byte("hello")), metadata, "")
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+
+	// Metadata only copy onto itself does not drop the retention.
+	objInfo, err = obj.CopyObject(bucket, object, bucket, object, map[string]string{"content-type": "text/plain"})
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if lock := getObjectLock(objInfo.UserDefined); lock.Mode != retentionGovernance {
+		t.Fatalf("%s: Expected governance mode to be kept, got %#v", instanceType, lock)
+	}
+
+	if _, err = obj.DeleteObjectVersion(bucket, object, objInfo.VersionID, true); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+}
@@ -0,0 +1,589 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// AmzReplicationStatus reports the replication status of an object.
+	AmzReplicationStatus = "X-Amz-Replication-Status"
+
+	// ObjectReplicationStatus is the metadata key of the replication
+	// status of an object.
+	ObjectReplicationStatus = ReservedMetadataPrefix + "Replication-Status"
+
+	// Replication status values, objects are pending until they are
+	// replicated or all attempts to replicate them have failed.
+	replicationPending   = "PENDING"
+	replicationCompleted = "COMPLETED"
+	replicationFailed    = "FAILED"
+
+	// Maximum number of replication tasks waiting for a worker.
+	replicationQueueSize = 10000
+
+	// Number of workers replicating objects concurrently.
+	replicationWorkers = 4
+
+	// Maximum number of attempts to replicate an object or a delete,
+	// attempts are retried with exponential backoff.
+	replicationMaxAttempts = 5
+
+	// Interval between two scans for objects and deletes pending
+	// replication, which re-queue the tasks dropped from a full queue
+	// or lost by a restart.
+	replicationScanInterval = time.Hour
+
+	// Lock held by the node scanning for pending replication, so
+	// that only one node in a distributed setup scans at a time.
+	replicationScanLock = "replication.lock"
+
+	// Prefix of the deletes pending replication in the minio meta
+	// bucket, deletes are saved until they are replicated.
+	replicationDeletesPrefix = "replication/deletes"
+)
+
+// errReplicationQueueFull - replication task dropped as all workers
+// are busy and the queue is full, the task is queued again by the
+// next scan for pending replication.
+var errReplicationQueueFull = errors.New("Replication queue is full")
+
+// Replication task operations.
+type replicationOp int
+
+const (
+	// Replicate the current version of an object.
+	replicationOpPut replicationOp = iota
+	// Replicate the removal of an object.
+	replicationOpDelete
+)
+
+// replicationTask - an object write or delete to be replicated.
+type replicationTask struct {
+	Op     replicationOp
+	Bucket string
+	Object string
+}
+
+// Variable represents the queue of replication tasks.
+var globalReplicationQueue *replicationQueue
+
+// replicationQueue - replication tasks processed in background by a
+// pool of workers.
+type replicationQueue struct {
+	objAPI  ObjectLayer
+	tasksCh chan replicationTask
+
+	// Tasks waiting in the queue, a task is queued only once.
+	mutex  *sync.Mutex
+	queued map[replicationTask]bool
+
+	// Backoff between failed attempts of a task.
+	retryUnit time.Duration
+	retryCap  time.Duration
+}
+
+// startReplicationWorkers - starts the background workers replicating
+// object writes and deletes to the destinations of the buckets.
+func startReplicationWorkers(objAPI ObjectLayer) {
+	globalReplicationQueue = newReplicationQueue(objAPI)
+	for i := 0; i < replicationWorkers; i++ {
+		go globalReplicationQueue.run()
+	}
+	go globalReplicationQueue.scanLoop()
+}
+
+// newReplicationQueue - returns an empty replication queue.
+func newReplicationQueue(objAPI ObjectLayer) *replicationQueue {
+	return &replicationQueue{
+		objAPI:    objAPI,
+		tasksCh:   make(chan replicationTask, replicationQueueSize),
+		mutex:     &sync.Mutex{},
+		queued:    make(map[replicationTask]bool),
+		retryUnit: defaultRetryUnit,
+		retryCap:  defaultRetryCap,
+	}
+}
+
+// run - processes replication tasks until the server stops.
+func (q *replicationQueue) run() {
+	for {
+		select {
+		case task := <-q.tasksCh:
+			q.process(task)
+		case <-globalServiceDoneCh:
+			return
+		}
+	}
+}
+
+// markQueued - marks a task as queued, returns false if the task is
+// already waiting in the queue.
+func (q *replicationQueue) markQueued(task replicationTask) bool {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if q.queued[task] {
+		return false
+	}
+	q.queued[task] = true
+	return true
+}
+
+// unmarkQueued - marks a task as no longer waiting in the queue.
+func (q *replicationQueue) unmarkQueued(task replicationTask) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	delete(q.queued, task)
+}
+
+// queueTask - queues a replication task without blocking, the task is
+// dropped if the queue is full. Dropped tasks are pending replication
+// still and are queued again by the next scan.
+func (q *replicationQueue) queueTask(task replicationTask) {
+	if q == nil || !q.markQueued(task) {
+		return
+	}
+	select {
+	case q.tasksCh <- task:
+	default:
+		q.unmarkQueued(task)
+		errorIf(errReplicationQueueFull, "Unable to queue replication of %s.", pathJoin(task.Bucket, task.Object))
+	}
+}
+
+// waitQueueTask - queues a replication task, waits for room in the
+// queue if it is full. Returns false if the server stops meanwhile.
+func (q *replicationQueue) waitQueueTask(task replicationTask) bool {
+	if !q.markQueued(task) {
+		return true
+	}
+	select {
+	case q.tasksCh <- task:
+		return true
+	case <-globalServiceDoneCh:
+		q.unmarkQueued(task)
+		return false
+	}
+}
+
+// scanLoop - scans for pending replication when the server starts and
+// once every interval until the server stops.
+func (q *replicationQueue) scanLoop() {
+	ticker := time.NewTicker(replicationScanInterval)
+	defer ticker.Stop()
+
+	// Start with random sleep time, so as to avoid "synchronous checks" between servers
+	time.Sleep(time.Duration(rand.Float64() * float64(time.Minute)))
+	for {
+		q.scan()
+		select {
+		case <-ticker.C:
+		case <-globalServiceDoneCh:
+			return
+		}
+	}
+}
+
+// scan - queues the replication of all objects and deletes which are
+// pending replication.
+func (q *replicationQueue) scan() {
+	scanLock := globalNSMutex.NewNSLock(minioMetaBucket, replicationScanLock)
+	scanLock.Lock()
+	defer scanLock.Unlock()
+
+	errorIf(q.scanPendingDeletes(), "Unable to scan for deletes pending replication.")
+
+	buckets, err := q.objAPI.ListBuckets()
+	if err != nil {
+		errorIf(err, "Unable to list buckets for replication.")
+		return
+	}
+	for _, bucket := range buckets {
+		rcfg := globalBucketReplication.GetBucketReplication(bucket.Name)
+		if rcfg == nil {
+			continue
+		}
+		for _, rule := range rcfg.Rules {
+			if rule.Status != replicationRuleEnabled {
+				continue
+			}
+			errorIf(q.scanPendingObjects(bucket.Name, rule.Prefix),
+				"Unable to scan for objects pending replication in the bucket %s.", bucket.Name)
+		}
+	}
+}
+
+// scanPendingObjects - queues the replication of the objects under the
+// prefix which are pending replication.
+func (q *replicationQueue) scanPendingObjects(bucket, prefix string) error {
+	marker := ""
+	for {
+		result, err := q.objAPI.ListObjects(bucket, prefix, marker, "", maxObjectList)
+		if err != nil {
+			return err
+		}
+		for _, objInfo := range result.Objects {
+			if objInfo.IsDir {
+				continue
+			}
+			// Listings have no user metadata, the replication
+			// status is looked up for each object.
+			info, err := q.objAPI.GetObjectInfo(bucket, objInfo.Name)
+			if err != nil {
+				if !isErrObjectNotFound(err) {
+					errorIf(err, "Unable to fetch object info of %s.", pathJoin(bucket, objInfo.Name))
+				}
+				continue
+			}
+			if info.DeleteMarker || info.UserDefined[ObjectReplicationStatus] != replicationPending {
+				continue
+			}
+			if !q.waitQueueTask(replicationTask{Op: replicationOpPut, Bucket: bucket, Object: objInfo.Name}) {
+				return nil
+			}
+		}
+		if !result.IsTruncated {
+			return nil
+		}
+		marker = result.NextMarker
+	}
+}
+
+// scanPendingDeletes - queues the replication of the saved deletes.
+func (q *replicationQueue) scanPendingDeletes() error {
+	marker := ""
+	for {
+		result, err := q.objAPI.ListObjects(minioMetaBucket, replicationDeletesPrefix+slashSeparator, marker, "", maxObjectList)
+		if err != nil {
+			return errorCause(err)
+		}
+		for _, objInfo := range result.Objects {
+			task, err := readPendingDelete(q.objAPI, objInfo.Name)
+			if err != nil {
+				if !isErrObjectNotFound(err) {
+					errorIf(err, "Unable to read pending replication delete %s.", objInfo.Name)
+				}
+				continue
+			}
+			if !q.waitQueueTask(task) {
+				return nil
+			}
+		}
+		if !result.IsTruncated {
+			return nil
+		}
+		marker = result.NextMarker
+	}
+}
+
+// pendingDeletePath - returns the path of a delete pending replication
+// in the minio meta bucket, named by the hash of the object path as
+// object names may be prefixes of each other.
+func pendingDeletePath(bucket, object string) string {
+	sum := sha256.Sum256([]byte(pathJoin(bucket, object)))
+	return pathJoin(replicationDeletesPrefix, hex.EncodeToString(sum[:])+".json")
+}
+
+// savePendingDelete - saves a delete pending replication, so that it
+// is replicated even if the server restarts before.
+func savePendingDelete(objAPI ObjectLayer, task replicationTask) error {
+	buf, err := json.Marshal(task)
+	if err != nil {
+		return err
+	}
+
+	// Acquire a write lock on the delete before modifying.
+	deletePath := pendingDeletePath(task.Bucket, task.Object)
+	objLock := globalNSMutex.NewNSLock(minioMetaBucket, deletePath)
+	objLock.Lock()
+	defer objLock.Unlock()
+
+	if _, err = objAPI.PutObject(minioMetaBucket, deletePath, int64(len(buf)), bytes.NewReader(buf), nil, ""); err != nil {
+		return errorCause(err)
+	}
+	return nil
+}
+
+// readPendingDelete - reads a saved delete pending replication.
+func readPendingDelete(objAPI ObjectLayer, deletePath string) (task replicationTask, err error) {
+	// Acquire a read lock on the delete before reading.
+	objLock := globalNSMutex.NewNSLock(minioMetaBucket, deletePath)
+	objLock.RLock()
+	defer objLock.RUnlock()
+
+	var buffer bytes.Buffer
+	if err = objAPI.GetObject(minioMetaBucket, deletePath, 0, -1, &buffer); err != nil {
+		return task, err
+	}
+	err = json.Unmarshal(buffer.Bytes(), &task)
+	return task, err
+}
+
+// removePendingDelete - removes a saved delete once it is replicated.
+func removePendingDelete(objAPI ObjectLayer, task replicationTask) error {
+	// Acquire a write lock on the delete before removing.
+	deletePath := pendingDeletePath(task.Bucket, task.Object)
+	objLock := globalNSMutex.NewNSLock(minioMetaBucket, deletePath)
+	objLock.Lock()
+	defer objLock.Unlock()
+
+	if err := objAPI.DeleteObject(minioMetaBucket, deletePath); err != nil && !isErrObjectNotFound(err) {
+		return errorCause(err)
+	}
+	return nil
+}
+
+// setReplicationMetadata - marks a new object as pending replication
+// if it is replicated by the bucket replication config. Objects
+// encrypted with customer provided keys can not be read by the server
+// and are not replicated.
+func setReplicationMetadata(bucket, object string, metadata map[string]string) {
+	delete(metadata, ObjectReplicationStatus)
+	if isEncryptedObject(metadata) && !isSSES3Object(metadata) {
+		return
+	}
+	if isObjectReplicated(bucket, object) {
+		metadata[ObjectReplicationStatus] = replicationPending
+	}
+}
+
+// setReplicationStatusHeader - sets the replication status header of
+// an object which is replicated.
+func setReplicationStatusHeader(w http.ResponseWriter, metadata map[string]string) {
+	if status := metadata[ObjectReplicationStatus]; status != "" {
+		w.Header().Set(AmzReplicationStatus, status)
+	}
+}
+
+// scheduleObjectReplication - queues the replication of a new object
+// which is pending replication.
+func scheduleObjectReplication(objInfo ObjectInfo) {
+	if objInfo.UserDefined[ObjectReplicationStatus] != replicationPending {
+		return
+	}
+	globalReplicationQueue.queueTask(replicationTask{
+		Op:     replicationOpPut,
+		Bucket: objInfo.Bucket,
+		Object: objInfo.Name,
+	})
+}
+
+// scheduleDeleteReplication - saves and queues the replication of the
+// removal of an object, deletes of specific object versions are not
+// replicated.
+func scheduleDeleteReplication(bucket, object, versionID string) {
+	q := globalReplicationQueue
+	if q == nil || versionID != "" || !isObjectReplicated(bucket, object) {
+		return
+	}
+	task := replicationTask{
+		Op:     replicationOpDelete,
+		Bucket: bucket,
+		Object: object,
+	}
+	errorIf(savePendingDelete(q.objAPI, task), "Unable to save replication of the removal of %s.", pathJoin(bucket, object))
+	q.queueTask(task)
+}
+
+// process - replicates an object write or delete, failed attempts
+// are retried with exponential backoff. The outcome of replicating a
+// write is recorded in the object metadata.
+func (q *replicationQueue) process(task replicationTask) {
+	// The task is queued again by writes during its processing.
+	q.unmarkQueued(task)
+
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+
+	var objInfo ObjectInfo
+	var err error
+	for attempt := range newRetryTimer(q.retryUnit, q.retryCap, doneCh) {
+		if task.Op == replicationOpDelete {
+			err = replicateDelete(q.objAPI, task.Bucket, task.Object)
+		} else {
+			objInfo, err = replicateObject(q.objAPI, task.Bucket, task.Object)
+		}
+		if err == nil || attempt+1 >= replicationMaxAttempts {
+			break
+		}
+	}
+	errorIf(err, "Unable to replicate %s.", pathJoin(task.Bucket, task.Object))
+
+	// Failed deletes are kept to be retried by the next scan.
+	if task.Op == replicationOpDelete && err == nil {
+		errorIf(removePendingDelete(q.objAPI, task),
+			"Unable to remove replicated delete of %s.", pathJoin(task.Bucket, task.Object))
+	}
+
+	if task.Op == replicationOpPut && objInfo.Name != "" {
+		status := replicationCompleted
+		if err != nil {
+			status = replicationFailed
+		}
+		errorIf(setReplicationStatus(q.objAPI, objInfo, status),
+			"Unable to set replication status of %s.", pathJoin(task.Bucket, task.Object))
+	}
+}
+
+// sizedReader - reports the size of the data it reads, lets the client
+// pick the upload strategy for the size.
+type sizedReader struct {
+	io.Reader
+	size int64
+}
+
+// Size - returns the size of the data.
+func (r sizedReader) Size() int64 {
+	return r.size
+}
+
+// getReplicaMetadata - returns the metadata sent along with the data of
+// a replicated object, encrypted objects are replicated decrypted and
+// encrypted again by the remote server.
+func getReplicaMetadata(objInfo ObjectInfo) map[string][]string {
+	metadata := make(map[string][]string)
+	for k, v := range filterReservedMetadata(objInfo.UserDefined) {
+		if k == "etag" {
+			continue
+		}
+		metadata[k] = []string{v}
+	}
+	if tags := getObjectTags(objInfo.UserDefined); len(tags) > 0 {
+		metadata[AmzTagging] = []string{encodeTags(tags)}
+	}
+	if isSSES3Object(objInfo.UserDefined) {
+		metadata[SSEHeader] = []string{SSEAlgorithmAES256}
+	}
+	return metadata
+}
+
+// replicateObject - copies the current version of an object to the
+// remote server, returns the object info of the replicated version. An
+// empty object info is returned if the object no longer needs to be
+// replicated, as it was removed or replaced in the meantime. The object
+// is not locked during the upload, data of an object replaced meanwhile
+// fails to verify and the upload is retried.
+func replicateObject(objAPI ObjectLayer, bucket, object string) (ObjectInfo, error) {
+	// Lock the object while reading its info.
+	objectLock := globalNSMutex.NewNSLock(bucket, object)
+	objectLock.RLock()
+	objInfo, err := objAPI.GetObjectInfo(bucket, object)
+	objectLock.RUnlock()
+	if err != nil {
+		if isErrObjectNotFound(err) {
+			return ObjectInfo{}, nil
+		}
+		return ObjectInfo{}, err
+	}
+	if objInfo.DeleteMarker || objInfo.UserDefined[ObjectReplicationStatus] != replicationPending {
+		return ObjectInfo{}, nil
+	}
+
+	dest, client, err := globalBucketReplication.GetReplicationTarget(bucket, object)
+	if err != nil {
+		return objInfo, err
+	}
+	if dest == nil {
+		// Replication was disabled in the meantime.
+		return ObjectInfo{}, nil
+	}
+
+	var key []byte
+	size := objInfo.Size
+	if isSSES3Object(objInfo.UserDefined) {
+		if key, err = unsealSSES3Key(bucket, object, objInfo.UserDefined); err != nil {
+			return objInfo, err
+		}
+		if size, err = sseDecryptedObjectSize(objInfo); err != nil {
+			return objInfo, err
+		}
+	}
+
+	reader := getObjectReader(objAPI, objInfo, "", key, 0, size)
+	defer reader.Close()
+
+	_, err = client.PutObjectWithMetadata(dest.BucketName(), object, sizedReader{reader, size}, getReplicaMetadata(objInfo), nil)
+	return objInfo, err
+}
+
+// replicateDelete - removes an object from the remote server, unless
+// the object was written again in the meantime.
+func replicateDelete(objAPI ObjectLayer, bucket, object string) error {
+	// Lock the object before reading.
+	objectLock := globalNSMutex.NewNSLock(bucket, object)
+	objectLock.RLock()
+	defer objectLock.RUnlock()
+
+	objInfo, err := objAPI.GetObjectInfo(bucket, object)
+	if err == nil && !objInfo.DeleteMarker {
+		return nil
+	}
+	if err != nil && !isErrObjectNotFound(err) {
+		return err
+	}
+
+	dest, client, err := globalBucketReplication.GetReplicationTarget(bucket, object)
+	if err != nil || dest == nil {
+		return err
+	}
+	return client.RemoveObject(dest.BucketName(), object)
+}
+
+// setReplicationStatus - records the replication status of a replicated
+// object version, unless the object was replaced in the meantime.
+func setReplicationStatus(objAPI ObjectLayer, replicated ObjectInfo, status string) error {
+	// Acquire a write lock before updating the object metadata.
+	objectLock := globalNSMutex.NewNSLock(replicated.Bucket, replicated.Name)
+	objectLock.Lock()
+	defer objectLock.Unlock()
+
+	objInfo, err := objAPI.GetObjectInfo(replicated.Bucket, replicated.Name)
+	if err != nil {
+		if isErrObjectNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if objInfo.UserDefined[ObjectReplicationStatus] != replicationPending {
+		return nil
+	}
+	if objInfo.VersionID != replicated.VersionID || objInfo.ETag != replicated.ETag ||
+		!objInfo.ModTime.Equal(replicated.ModTime) {
+		// The object was replaced during the upload, which may have
+		// completed after the replication of the new object.
+		scheduleObjectReplication(objInfo)
+		return nil
+	}
+
+	metadata := make(map[string]string, len(objInfo.UserDefined))
+	for k, v := range objInfo.UserDefined {
+		metadata[k] = v
+	}
+	metadata[ObjectReplicationStatus] = status
+	_, err = replaceObjectMetadata(objAPI, objInfo, metadata)
+	return err
+}
@@ -0,0 +1,233 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	mux "github.com/gorilla/mux"
+)
+
+// getTaggableObjectInfo - returns the object info of the current version
+// of an object, only the tags of the current version can be changed.
+func getTaggableObjectInfo(objectAPI ObjectLayer, bucket, object, versionID string) (ObjectInfo, APIErrorCode) {
+	objInfo, err := objectAPI.GetObjectInfo(bucket, object)
+	if err != nil {
+		errorIf(err, "Unable to fetch object info.")
+		return objInfo, toAPIErrorCode(err)
+	}
+	if objInfo.DeleteMarker {
+		return objInfo, ErrNoSuchKey
+	}
+	if versionID != "" && versionID != toVersionID(objInfo.VersionID) {
+		return objInfo, ErrNotImplemented
+	}
+	return objInfo, ErrNone
+}
+
+// setObjectTagging - replaces the tags of an object, without
+// rewriting its data.
+func setObjectTagging(objectAPI ObjectLayer, objInfo ObjectInfo, tags map[string]string) (ObjectInfo, error) {
+	metadata := make(map[string]string, len(objInfo.UserDefined)+1)
+	for k, v := range objInfo.UserDefined {
+		metadata[k] = v
+	}
+	setObjectTags(metadata, tags)
+	return replaceObjectMetadata(objectAPI, objInfo, metadata)
+}
+
+// GetObjectTaggingHandler - GET Object tagging
+// ----------
+// This implementation of the GET operation uses the tagging
+// subresource to return the tag set of an object.
+func (api objectAPIHandlers) GetObjectTaggingHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+	object := vars["object"]
+
+	objectAPI := api.ObjectAPI()
+	if objectAPI == nil {
+		writeErrorResponse(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	if s3Error := checkRequestAuthType(r, bucket, "s3:GetObjectTagging", serverConfig.GetRegion()); s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	// Lock the object before reading.
+	objectLock := globalNSMutex.NewNSLock(bucket, object)
+	objectLock.RLock()
+	defer objectLock.RUnlock()
+
+	objInfo, err := getObjectVersionInfo(objectAPI, bucket, object, r.URL.Query().Get("versionId"))
+	if err != nil {
+		errorIf(err, "Unable to fetch object info.")
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+	if objInfo.DeleteMarker {
+		setVersionHeaders(w, objInfo)
+		writeErrorResponse(w, ErrNoSuchKey, r.URL)
+		return
+	}
+
+	taggingBytes, err := xml.Marshal(toTagging(getObjectTags(objInfo.UserDefined)))
+	if err != nil {
+		errorIf(err, "Unable to marshal tagging into XML.")
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	// Success.
+	setVersionHeaders(w, objInfo)
+	writeSuccessResponseXML(w, taggingBytes)
+}
+
+// PutObjectTaggingHandler - PUT Object tagging
+// ----------
+// This implementation of the PUT operation uses the tagging
+// subresource to replace the tag set of an existing object.
+func (api objectAPIHandlers) PutObjectTaggingHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+	object := vars["object"]
+
+	objectAPI := api.ObjectAPI()
+	if objectAPI == nil {
+		writeErrorResponse(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	// If Content-Length is unknown or zero, deny the request.
+	if r.ContentLength == -1 || r.ContentLength == 0 {
+		writeErrorResponse(w, ErrMissingContentLength, r.URL)
+		return
+	}
+
+	// If Content-Length is greater than maximum allowed tagging size.
+	if r.ContentLength > maxTaggingSize {
+		writeErrorResponse(w, ErrEntityTooLarge, r.URL)
+		return
+	}
+
+	// The tags are read before authentication, since bucket policies
+	// may have conditions on the tags of the request. The body is put
+	// back for signature verification.
+	taggingBytes, err := ioutil.ReadAll(io.LimitReader(r.Body, r.ContentLength))
+	if err != nil {
+		errorIf(err, "Unable to read tagging body.")
+		writeErrorResponse(w, ErrInternalError, r.URL)
+		return
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(taggingBytes))
+
+	t, err := parseTagging(bytes.NewReader(taggingBytes), maxObjectTags)
+	if err != nil {
+		apiErr := toAPIErrorCode(err)
+		if apiErr == ErrInternalError {
+			apiErr = ErrMalformedXML
+		}
+		writeErrorResponse(w, apiErr, r.URL)
+		return
+	}
+	tags := t.Map()
+
+	var s3Error APIErrorCode
+	if getRequestAuthType(r) == authTypeAnonymous {
+		s3Error = enforceBucketPolicy(bucket, "s3:PutObjectTagging", getResource(r.URL.Path, r.Host),
+			r.Referer(), r.URL.Query(), tags)
+	} else {
+		s3Error = checkRequestAuthType(r, bucket, "s3:PutObjectTagging", serverConfig.GetRegion())
+	}
+	if s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	// Lock the object before updating its metadata.
+	objectLock := globalNSMutex.NewNSLock(bucket, object)
+	objectLock.Lock()
+	defer objectLock.Unlock()
+
+	objInfo, s3Error := getTaggableObjectInfo(objectAPI, bucket, object, r.URL.Query().Get("versionId"))
+	if s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	if objInfo, err = setObjectTagging(objectAPI, objInfo, tags); err != nil {
+		errorIf(err, "Unable to set object tagging.")
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	// Success.
+	setVersionHeaders(w, objInfo)
+	writeSuccessResponseHeadersOnly(w)
+}
+
+// DeleteObjectTaggingHandler - DELETE Object tagging
+// ----------
+// This implementation of the DELETE operation uses the tagging
+// subresource to remove the tag set of an existing object.
+func (api objectAPIHandlers) DeleteObjectTaggingHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+	object := vars["object"]
+
+	objectAPI := api.ObjectAPI()
+	if objectAPI == nil {
+		writeErrorResponse(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	if s3Error := checkRequestAuthType(r, bucket, "s3:DeleteObjectTagging", serverConfig.GetRegion()); s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	// Lock the object before updating its metadata.
+	objectLock := globalNSMutex.NewNSLock(bucket, object)
+	objectLock.Lock()
+	defer objectLock.Unlock()
+
+	objInfo, s3Error := getTaggableObjectInfo(objectAPI, bucket, object, r.URL.Query().Get("versionId"))
+	if s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	// Objects without tags are left as is.
+	if objInfo.UserDefined[ObjectTagging] != "" {
+		var err error
+		if objInfo, err = setObjectTagging(objectAPI, objInfo, nil); err != nil {
+			errorIf(err, "Unable to remove object tagging.")
+			writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+			return
+		}
+	}
+
+	// Success.
+	setVersionHeaders(w, objInfo)
+	writeSuccessNoContent(w)
+}
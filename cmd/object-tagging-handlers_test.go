@@ -0,0 +1,169 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Wrapper for calling object tagging handler tests for both XL and FS.
+func TestObjectTaggingHandlers(t *testing.T) {
+	ExecObjectLayerAPITest(t, testAPIObjectTaggingHandlers,
+		[]string{"ObjectTagging", "BucketTagging", "HeadObject", "GetObject", "CopyObject", "PutObject"})
+}
+
+// Tests setting, reading and removing the tags of objects and buckets,
+// and bucket policies with conditions on the tags.
+func testAPIObjectTaggingHandlers(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials credential, t *testing.T) {
+	// sendRequest - sends a signed request, or an anonymous request
+	// if anonymous is true, and returns the response.
+	sendRequest := func(method, urlStr, body string, header http.Header, anonymous bool) *httptest.ResponseRecorder {
+		var req *http.Request
+		var err error
+		if anonymous {
+			req, err = newTestRequest(method, urlStr, int64(len(body)), strings.NewReader(body))
+		} else {
+			req, err = newTestSignedRequestV4(method, urlStr, int64(len(body)), strings.NewReader(body),
+				credentials.AccessKey, credentials.SecretKey)
+		}
+		if err != nil {
+			t.Fatalf("%s: Failed to create HTTP request: %v", instanceType, err)
+		}
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		rec := httptest.NewRecorder()
+		apiRouter.ServeHTTP(rec, req)
+		return rec
+	}
+	// getTags - returns the tags of an object.
+	getTags := func(object string) map[string]string {
+		rec := sendRequest("GET", getTaggingURL("", bucketName, object), "", nil, false)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, rec.Code)
+		}
+		var tagging tagging
+		if err := xml.Unmarshal(rec.Body.Bytes(), &tagging); err != nil {
+			t.Fatalf("%s: %v", instanceType, err)
+		}
+		return tagging.Map()
+	}
+
+	// Objects are tagged with the x-amz-tagging header.
+	header := http.Header{AmzTagging: []string{"project=alpha&retention=short"}}
+	if rec := sendRequest("PUT", getPutObjectURL("", bucketName, "tagged"), "data", header, false); rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, rec.Code)
+	}
+	expectedTags := map[string]string{"project": "alpha", "retention": "short"}
+	if tags := getTags("tagged"); !reflect.DeepEqual(tags, expectedTags) {
+		t.Fatalf("%s: Expected tags %v, got %v", instanceType, expectedTags, tags)
+	}
+	rec := sendRequest("HEAD", getGetObjectURL("", bucketName, "tagged"), "", nil, false)
+	if count := rec.Header().Get(AmzTaggingCount); count != "2" {
+		t.Fatalf("%s: Expected tagging count 2, got %s", instanceType, count)
+	}
+
+	// Invalid tags are rejected.
+	header = http.Header{AmzTagging: []string{"aws:project=alpha"}}
+	if rec = sendRequest("PUT", getPutObjectURL("", bucketName, "invalid"), "data", header, false); rec.Code != http.StatusBadRequest {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusBadRequest, rec.Code)
+	}
+
+	// Tags are copied unless replaced.
+	header = http.Header{"X-Amz-Copy-Source": []string{"/" + bucketName + "/tagged"}}
+	if rec = sendRequest("PUT", getPutObjectURL("", bucketName, "copied"), "", header, false); rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, rec.Code)
+	}
+	if tags := getTags("copied"); !reflect.DeepEqual(tags, expectedTags) {
+		t.Fatalf("%s: Expected tags %v, got %v", instanceType, expectedTags, tags)
+	}
+	header.Set(AmzTaggingDirective, "REPLACE")
+	header.Set(AmzTagging, "project=beta")
+	if rec = sendRequest("PUT", getPutObjectURL("", bucketName, "copied"), "", header, false); rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, rec.Code)
+	}
+	if tags := getTags("copied"); !reflect.DeepEqual(tags, map[string]string{"project": "beta"}) {
+		t.Fatalf("%s: Expected tags to be replaced, got %v", instanceType, tags)
+	}
+
+	// Tags are replaced and removed through the tagging subresource.
+	tagging := `<Tagging><TagSet><Tag><Key>project</Key><Value>gamma</Value></Tag></TagSet></Tagging>`
+	if rec = sendRequest("PUT", getTaggingURL("", bucketName, "copied"), tagging, nil, false); rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, rec.Code)
+	}
+	if tags := getTags("copied"); !reflect.DeepEqual(tags, map[string]string{"project": "gamma"}) {
+		t.Fatalf("%s: Expected tags to be replaced, got %v", instanceType, tags)
+	}
+	rec = sendRequest("GET", getGetObjectURL("", bucketName, "copied"), "", nil, false)
+	if rec.Code != http.StatusOK || !bytes.Equal(rec.Body.Bytes(), []byte("data")) {
+		t.Fatalf("%s: Expected object data to be kept, got %d %q", instanceType, rec.Code, rec.Body.String())
+	}
+	if rec = sendRequest("DELETE", getTaggingURL("", bucketName, "copied"), "", nil, false); rec.Code != http.StatusNoContent {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusNoContent, rec.Code)
+	}
+	if tags := getTags("copied"); len(tags) != 0 {
+		t.Fatalf("%s: Expected tags to be removed, got %v", instanceType, tags)
+	}
+
+	// Anonymous requests are allowed by the tags of the object.
+	policy := `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"AWS":["*"]},` +
+		`"Action":["s3:GetObject"],"Resource":["arn:aws:s3:::` + bucketName + `/*"],` +
+		`"Condition":{"StringEquals":{"s3:ExistingObjectTag/project":["alpha"]}}}]}`
+	var bp bucketPolicy
+	if err := parseBucketPolicy(strings.NewReader(policy), &bp); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	globalBucketPolicies.SetBucketPolicy(bucketName, policyChange{false, &bp})
+	defer globalBucketPolicies.SetBucketPolicy(bucketName, policyChange{true, nil})
+	if rec = sendRequest("GET", getGetObjectURL("", bucketName, "tagged"), "", nil, true); rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, rec.Code)
+	}
+	if rec = sendRequest("GET", getGetObjectURL("", bucketName, "copied"), "", nil, true); rec.Code != http.StatusForbidden {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusForbidden, rec.Code)
+	}
+	// Tags in the query are not the tags of the object.
+	urlStr := getGetObjectURL("", bucketName, "copied") + "?ExistingObjectTag/project=alpha"
+	if rec = sendRequest("GET", urlStr, "", nil, true); rec.Code != http.StatusForbidden {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusForbidden, rec.Code)
+	}
+
+	// Buckets are tagged through the tagging subresource.
+	if rec = sendRequest("GET", getTaggingURL("", bucketName, ""), "", nil, false); rec.Code != http.StatusNotFound {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusNotFound, rec.Code)
+	}
+	tagging = `<Tagging><TagSet><Tag><Key>cost-center</Key><Value>42</Value></Tag></TagSet></Tagging>`
+	if rec = sendRequest("PUT", getTaggingURL("", bucketName, ""), tagging, nil, false); rec.Code != http.StatusNoContent {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusNoContent, rec.Code)
+	}
+	rec = sendRequest("GET", getTaggingURL("", bucketName, ""), "", nil, false)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "<Key>cost-center</Key><Value>42</Value>") {
+		t.Fatalf("%s: Expected bucket tags, got %d %q", instanceType, rec.Code, rec.Body.String())
+	}
+	if rec = sendRequest("DELETE", getTaggingURL("", bucketName, ""), "", nil, false); rec.Code != http.StatusNoContent {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusNoContent, rec.Code)
+	}
+	if rec = sendRequest("GET", getTaggingURL("", bucketName, ""), "", nil, false); rec.Code != http.StatusNotFound {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusNotFound, rec.Code)
+	}
+}
@@ -271,7 +271,7 @@ func retryFormattingXLDisks(firstDisk bool, endpoints EndpointList, storageDisks
 			case FormatDisks:
 				console.Eraseline()
 				printFormatMsg(endpoints, storageDisks, printOnceFn())
-				return initFormatXL(storageDisks)
+				return initFormatXL(endpoints, storageDisks)
 			case InitObjectLayer:
 				console.Eraseline()
 				// Validate formats loaded before proceeding forward.
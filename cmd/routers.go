@@ -70,6 +70,10 @@ func configureServerHandler(endpoints EndpointList) (http.Handler, error) {
 		return nil, err
 	}
 
+	// Add prometheus metrics router, before the web router which
+	// serves all other paths under the reserved bucket.
+	registerMetricsRouter(mux)
+
 	// Register web router when its enabled.
 	if globalIsBrowserEnabled {
 		if err := registerWebRouter(mux); err != nil {
@@ -80,6 +84,10 @@ func configureServerHandler(endpoints EndpointList) (http.Handler, error) {
 	// Add Admin router.
 	registerAdminRouter(mux)
 
+	// Add STS router, before the API router which would take its
+	// requests as post policy uploads.
+	registerSTSRouter(mux)
+
 	// Add API router.
 	registerAPIRouter(mux)
 
@@ -110,6 +118,9 @@ func configureServerHandler(endpoints EndpointList) (http.Handler, error) {
 		// routes them accordingly. Client receives a HTTP error for
 		// invalid/unsupported signatures.
 		setAuthHandler,
+		// Serves requests to the website domain as bucket websites,
+		// these skip all other handlers including authentication.
+		setWebsiteDomainHandler,
 		// Add new handlers here.
 	}
 
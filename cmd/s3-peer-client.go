@@ -185,3 +185,151 @@ func S3PeersUpdateBucketPolicy(bucket string, pCh policyChange) {
 		)
 	}
 }
+
+// S3PeersUpdateBucketVersioning - Sends update bucket versioning request
+// to all peers. Currently we log an error and continue.
+func S3PeersUpdateBucketVersioning(bucket string, vcfg *versioningConfiguration) {
+	setBVPArgs := &SetBucketVersioningPeerArgs{Bucket: bucket, VCfg: vcfg}
+	errs := globalS3Peers.SendUpdate(nil, setBVPArgs)
+	for idx, err := range errs {
+		errorIf(
+			err,
+			"Error sending update bucket versioning to %s - %v",
+			globalS3Peers[idx].addr, err,
+		)
+	}
+}
+
+// S3PeersUpdateBucketEncryption - Sends update bucket encryption request
+// to all peers. Currently we log an error and continue.
+func S3PeersUpdateBucketEncryption(bucket string, ecfg *bucketEncryptionConfiguration) {
+	setBEPArgs := &SetBucketEncryptionPeerArgs{Bucket: bucket, ECfg: ecfg}
+	errs := globalS3Peers.SendUpdate(nil, setBEPArgs)
+	for idx, err := range errs {
+		errorIf(
+			err,
+			"Error sending update bucket encryption to %s - %v",
+			globalS3Peers[idx].addr, err,
+		)
+	}
+}
+
+// S3PeersUpdateBucketCors - Sends update bucket CORS request to all
+// peers. Currently we log an error and continue.
+func S3PeersUpdateBucketCors(bucket string, ccfg *corsConfiguration) {
+	setBCPArgs := &SetBucketCorsPeerArgs{Bucket: bucket, CCfg: ccfg}
+	errs := globalS3Peers.SendUpdate(nil, setBCPArgs)
+	for idx, err := range errs {
+		errorIf(
+			err,
+			"Error sending update bucket CORS to %s - %v",
+			globalS3Peers[idx].addr, err,
+		)
+	}
+}
+
+// S3PeersUpdateBucketWebsite - Sends update bucket website request to
+// all peers. Currently we log an error and continue.
+func S3PeersUpdateBucketWebsite(bucket string, wcfg *websiteConfiguration) {
+	setBWPArgs := &SetBucketWebsitePeerArgs{Bucket: bucket, WCfg: wcfg}
+	errs := globalS3Peers.SendUpdate(nil, setBWPArgs)
+	for idx, err := range errs {
+		errorIf(
+			err,
+			"Error sending update bucket website to %s - %v",
+			globalS3Peers[idx].addr, err,
+		)
+	}
+}
+
+// S3PeersUpdateBucketObjectLock - Sends update bucket object lock
+// request to all peers. Currently we log an error and continue.
+func S3PeersUpdateBucketObjectLock(bucket string, lcfg *objectLockConfiguration) {
+	setBOLPArgs := &SetBucketObjectLockPeerArgs{Bucket: bucket, LCfg: lcfg}
+	errs := globalS3Peers.SendUpdate(nil, setBOLPArgs)
+	for idx, err := range errs {
+		errorIf(
+			err,
+			"Error sending update bucket object lock to %s - %v",
+			globalS3Peers[idx].addr, err,
+		)
+	}
+}
+
+// S3PeersUpdateBucketQuota - Sends update bucket quota request to all
+// peers. Currently we log an error and continue.
+func S3PeersUpdateBucketQuota(bucket string, qcfg *bucketQuotaConfiguration) {
+	setBQPArgs := &SetBucketQuotaPeerArgs{Bucket: bucket, QCfg: qcfg}
+	errs := globalS3Peers.SendUpdate(nil, setBQPArgs)
+	for idx, err := range errs {
+		errorIf(
+			err,
+			"Error sending update bucket quota to %s - %v",
+			globalS3Peers[idx].addr, err,
+		)
+	}
+}
+
+// S3PeersUpdateBucketUsage - Sends a bucket usage change to all remote
+// peers, the local usage is already updated by the caller. Currently we
+// log an error and continue.
+func S3PeersUpdateBucketUsage(bucket string, size, objects int64) {
+	var peerIndex []int
+	// The local peer is always the first.
+	for idx := 1; idx < len(globalS3Peers); idx++ {
+		peerIndex = append(peerIndex, idx)
+	}
+	if len(peerIndex) == 0 {
+		return
+	}
+	setBUPArgs := &SetBucketUsagePeerArgs{Bucket: bucket, Size: size, Objects: objects}
+	errs := globalS3Peers.SendUpdate(peerIndex, setBUPArgs)
+	for idx, err := range errs {
+		errorIf(
+			err,
+			"Error sending update bucket usage to %s - %v",
+			globalS3Peers[idx].addr, err,
+		)
+	}
+}
+
+// S3PeersUpdateBucketReplication - Sends update bucket replication
+// request to all peers. Currently we log an error and continue.
+func S3PeersUpdateBucketReplication(bucket string, rcfg *replicationConfiguration) {
+	setBRPArgs := &SetBucketReplicationPeerArgs{Bucket: bucket, RCfg: rcfg}
+	errs := globalS3Peers.SendUpdate(nil, setBRPArgs)
+	for idx, err := range errs {
+		errorIf(
+			err,
+			"Error sending update bucket replication to %s - %v",
+			globalS3Peers[idx].addr, err,
+		)
+	}
+}
+
+// S3PeersUpdateBucketLogging - Sends update bucket logging request to
+// all peers. Currently we log an error and continue.
+func S3PeersUpdateBucketLogging(bucket string, lcfg *loggingEnabled) {
+	setBLPArgs := &SetBucketLoggingPeerArgs{Bucket: bucket, LCfg: lcfg}
+	errs := globalS3Peers.SendUpdate(nil, setBLPArgs)
+	for idx, err := range errs {
+		errorIf(
+			err,
+			"Error sending update bucket logging to %s - %v",
+			globalS3Peers[idx].addr, err,
+		)
+	}
+}
+
+// S3PeersLoadIAM - Sends reload IAM config request to all peers.
+// Currently we log an error and continue.
+func S3PeersLoadIAM() {
+	errs := globalS3Peers.SendUpdate(nil, &LoadIAMPeerArgs{})
+	for idx, err := range errs {
+		errorIf(
+			err,
+			"Error sending reload IAM config to %s - %v",
+			globalS3Peers[idx].addr, err,
+		)
+	}
+}
@@ -116,3 +116,269 @@ func (s3 *s3PeerAPIHandlers) SetBucketPolicyPeer(args *SetBucketPolicyPeerArgs,
 
 	return s3.bms.UpdateBucketPolicy(args)
 }
+
+// SetBucketVersioningPeerArgs - Arguments collection for SetBucketVersioningPeer RPC call
+type SetBucketVersioningPeerArgs struct {
+	// For Auth
+	AuthRPCArgs
+
+	Bucket string
+
+	// Versioning config for the given bucket.
+	VCfg *versioningConfiguration
+}
+
+// BucketUpdate - implements bucket versioning updates,
+// the underlying operation is a network call updates all
+// the peers participating in versioned object operations.
+func (s *SetBucketVersioningPeerArgs) BucketUpdate(client BucketMetaState) error {
+	return client.UpdateBucketVersioning(s)
+}
+
+// tell receiving server to update a bucket versioning config
+func (s3 *s3PeerAPIHandlers) SetBucketVersioningPeer(args *SetBucketVersioningPeerArgs, reply *AuthRPCReply) error {
+	if err := args.IsAuthenticated(); err != nil {
+		return err
+	}
+
+	return s3.bms.UpdateBucketVersioning(args)
+}
+
+// SetBucketEncryptionPeerArgs - Arguments collection for SetBucketEncryptionPeer RPC call
+type SetBucketEncryptionPeerArgs struct {
+	// For Auth
+	AuthRPCArgs
+
+	Bucket string
+
+	// Encryption config for the given bucket.
+	ECfg *bucketEncryptionConfiguration
+}
+
+// BucketUpdate - implements bucket encryption updates,
+// the underlying operation is a network call updates all
+// the peers participating in encrypted object uploads.
+func (s *SetBucketEncryptionPeerArgs) BucketUpdate(client BucketMetaState) error {
+	return client.UpdateBucketEncryption(s)
+}
+
+// tell receiving server to update a bucket encryption config
+func (s3 *s3PeerAPIHandlers) SetBucketEncryptionPeer(args *SetBucketEncryptionPeerArgs, reply *AuthRPCReply) error {
+	if err := args.IsAuthenticated(); err != nil {
+		return err
+	}
+
+	return s3.bms.UpdateBucketEncryption(args)
+}
+
+// SetBucketCorsPeerArgs - Arguments collection for SetBucketCorsPeer RPC call
+type SetBucketCorsPeerArgs struct {
+	// For Auth
+	AuthRPCArgs
+
+	Bucket string
+
+	// CORS config for the given bucket.
+	CCfg *corsConfiguration
+}
+
+// BucketUpdate - implements bucket CORS updates,
+// the underlying operation is a network call updates all
+// the peers participating in answering cross-origin requests.
+func (s *SetBucketCorsPeerArgs) BucketUpdate(client BucketMetaState) error {
+	return client.UpdateBucketCors(s)
+}
+
+// tell receiving server to update a bucket CORS config
+func (s3 *s3PeerAPIHandlers) SetBucketCorsPeer(args *SetBucketCorsPeerArgs, reply *AuthRPCReply) error {
+	if err := args.IsAuthenticated(); err != nil {
+		return err
+	}
+
+	return s3.bms.UpdateBucketCors(args)
+}
+
+// SetBucketWebsitePeerArgs - Arguments collection for SetBucketWebsitePeer RPC call
+type SetBucketWebsitePeerArgs struct {
+	// For Auth
+	AuthRPCArgs
+
+	Bucket string
+
+	// Website config for the given bucket.
+	WCfg *websiteConfiguration
+}
+
+// BucketUpdate - implements bucket website updates,
+// the underlying operation is a network call updates all
+// the peers serving website requests.
+func (s *SetBucketWebsitePeerArgs) BucketUpdate(client BucketMetaState) error {
+	return client.UpdateBucketWebsite(s)
+}
+
+// tell receiving server to update a bucket website config
+func (s3 *s3PeerAPIHandlers) SetBucketWebsitePeer(args *SetBucketWebsitePeerArgs, reply *AuthRPCReply) error {
+	if err := args.IsAuthenticated(); err != nil {
+		return err
+	}
+
+	return s3.bms.UpdateBucketWebsite(args)
+}
+
+// SetBucketObjectLockPeerArgs - Arguments collection for SetBucketObjectLockPeer RPC call
+type SetBucketObjectLockPeerArgs struct {
+	// For Auth
+	AuthRPCArgs
+
+	Bucket string
+
+	// Object lock config for the given bucket.
+	LCfg *objectLockConfiguration
+}
+
+// BucketUpdate - implements bucket object lock updates,
+// the underlying operation is a network call updates all
+// the peers writing objects into the bucket.
+func (s *SetBucketObjectLockPeerArgs) BucketUpdate(client BucketMetaState) error {
+	return client.UpdateBucketObjectLock(s)
+}
+
+// tell receiving server to update a bucket object lock config
+func (s3 *s3PeerAPIHandlers) SetBucketObjectLockPeer(args *SetBucketObjectLockPeerArgs, reply *AuthRPCReply) error {
+	if err := args.IsAuthenticated(); err != nil {
+		return err
+	}
+
+	return s3.bms.UpdateBucketObjectLock(args)
+}
+
+// SetBucketQuotaPeerArgs - Arguments collection for SetBucketQuotaPeer RPC call
+type SetBucketQuotaPeerArgs struct {
+	// For Auth
+	AuthRPCArgs
+
+	Bucket string
+
+	// Quota config for the given bucket.
+	QCfg *bucketQuotaConfiguration
+}
+
+// BucketUpdate - implements bucket quota updates,
+// the underlying operation is a network call updates all
+// the peers writing objects into the bucket.
+func (s *SetBucketQuotaPeerArgs) BucketUpdate(client BucketMetaState) error {
+	return client.UpdateBucketQuota(s)
+}
+
+// tell receiving server to update a bucket quota config
+func (s3 *s3PeerAPIHandlers) SetBucketQuotaPeer(args *SetBucketQuotaPeerArgs, reply *AuthRPCReply) error {
+	if err := args.IsAuthenticated(); err != nil {
+		return err
+	}
+
+	return s3.bms.UpdateBucketQuota(args)
+}
+
+// SetBucketUsagePeerArgs - Arguments collection for SetBucketUsagePeer RPC call
+type SetBucketUsagePeerArgs struct {
+	// For Auth
+	AuthRPCArgs
+
+	Bucket string
+
+	// Change of the size and number of object versions of the bucket.
+	Size    int64
+	Objects int64
+}
+
+// BucketUpdate - implements bucket usage updates,
+// the underlying operation is a network call updates all
+// the peers writing objects into the bucket.
+func (s *SetBucketUsagePeerArgs) BucketUpdate(client BucketMetaState) error {
+	return client.UpdateBucketUsage(s)
+}
+
+// tell receiving server to add to a bucket usage
+func (s3 *s3PeerAPIHandlers) SetBucketUsagePeer(args *SetBucketUsagePeerArgs, reply *AuthRPCReply) error {
+	if err := args.IsAuthenticated(); err != nil {
+		return err
+	}
+
+	return s3.bms.UpdateBucketUsage(args)
+}
+
+// SetBucketReplicationPeerArgs - Arguments collection for SetBucketReplicationPeer RPC call
+type SetBucketReplicationPeerArgs struct {
+	// For Auth
+	AuthRPCArgs
+
+	Bucket string
+
+	// Replication config for the given bucket.
+	RCfg *replicationConfiguration
+}
+
+// BucketUpdate - implements bucket replication updates,
+// the underlying operation is a network call updates all
+// the peers replicating object writes and deletes.
+func (s *SetBucketReplicationPeerArgs) BucketUpdate(client BucketMetaState) error {
+	return client.UpdateBucketReplication(s)
+}
+
+// tell receiving server to update a bucket replication config
+func (s3 *s3PeerAPIHandlers) SetBucketReplicationPeer(args *SetBucketReplicationPeerArgs, reply *AuthRPCReply) error {
+	if err := args.IsAuthenticated(); err != nil {
+		return err
+	}
+
+	return s3.bms.UpdateBucketReplication(args)
+}
+
+// SetBucketLoggingPeerArgs - Arguments collection for SetBucketLoggingPeer RPC call
+type SetBucketLoggingPeerArgs struct {
+	// For Auth
+	AuthRPCArgs
+
+	Bucket string
+
+	// Logging config for the given bucket, nil if logging is disabled.
+	LCfg *loggingEnabled
+}
+
+// BucketUpdate - implements bucket logging updates,
+// the underlying operation is a network call updates all
+// the peers recording access logs.
+func (s *SetBucketLoggingPeerArgs) BucketUpdate(client BucketMetaState) error {
+	return client.UpdateBucketLogging(s)
+}
+
+// tell receiving server to update a bucket logging config
+func (s3 *s3PeerAPIHandlers) SetBucketLoggingPeer(args *SetBucketLoggingPeerArgs, reply *AuthRPCReply) error {
+	if err := args.IsAuthenticated(); err != nil {
+		return err
+	}
+
+	return s3.bms.UpdateBucketLogging(args)
+}
+
+// LoadIAMPeerArgs - Arguments collection for LoadIAMPeer RPC call
+type LoadIAMPeerArgs struct {
+	// For Auth
+	AuthRPCArgs
+}
+
+// BucketUpdate - implements IAM config reloads, the underlying
+// operation is a network call which makes all the peers reload
+// users, groups and policies.
+func (s *LoadIAMPeerArgs) BucketUpdate(client BucketMetaState) error {
+	return client.LoadIAM(s)
+}
+
+// tell receiving server to reload the IAM config
+func (s3 *s3PeerAPIHandlers) LoadIAMPeer(args *LoadIAMPeerArgs, reply *AuthRPCReply) error {
+	if err := args.IsAuthenticated(); err != nil {
+		return err
+	}
+
+	return s3.bms.LoadIAM(args)
+}
@@ -19,6 +19,7 @@ package cmd
 import (
 	"encoding/json"
 	"path"
+	"sync"
 	"testing"
 )
 
@@ -99,6 +100,25 @@ func (s *TestRPCS3PeerSuite) testS3PeerRPC(t *testing.T) {
 		t.Fatal(err)
 	}
 
+	// Check bucket usage update call works.
+	prevBucketQuota := globalBucketQuota
+	defer func() { globalBucketQuota = prevBucketQuota }()
+	globalBucketQuota = &bucketQuotas{
+		rwMutex:            &sync.RWMutex{},
+		bucketQuotaConfigs: map[string]*bucketQuotaConfiguration{"bucket": {Quota: 100}},
+		bucketUsages:       map[string]bucketUsage{"bucket": {Size: 10, Objects: 1}},
+		reservedUsages:     make(map[string]bucketUsage),
+		evicting:           make(map[string]bool),
+	}
+	BUPArgs := SetBucketUsagePeerArgs{Bucket: "bucket", Size: 5, Objects: 1}
+	err = client.Call("S3.SetBucketUsagePeer", &BUPArgs, &AuthRPCReply{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage := globalBucketQuota.GetBucketUsage("bucket"); usage != (bucketUsage{Size: 15, Objects: 2}) {
+		t.Fatalf("Expected bucket usage {15 2}, got %v", usage)
+	}
+
 	// Check event send event call works.
 	evArgs := EventArgs{Event: nil, Arn: "localhost:9000"}
 	err = client.Call("S3.Event", &evArgs, &AuthRPCReply{})
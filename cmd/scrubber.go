@@ -0,0 +1,430 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"strings"
+	"time"
+
+	humanize "github.com/dustin/go-humanize"
+)
+
+const (
+	// Interval between two checks whether a scrub cycle is due.
+	scrubCheckInterval = 10 * time.Minute
+
+	// Interval between two saves of the scrub stats of a running
+	// cycle.
+	scrubSaveInterval = 10 * time.Second
+
+	// A cycle whose stats were not saved for this long is taken over
+	// by another server, as its server is considered gone.
+	scrubStaleInterval = 5 * time.Minute
+
+	// Default interval between the starts of two scrub cycles.
+	defaultScrubInterval = 7 * 24 * time.Hour
+
+	// Default maximum rate at which the scrubber reads from disks.
+	defaultScrubMaxIO = 10 * humanize.MiByte
+
+	// Lock held while claiming a scrub cycle.
+	scrubLockPath = "scrub.lock"
+
+	// Stats of the running or last scrub cycle, saved in the minio
+	// meta bucket.
+	scrubStatsPath = "scrub.json"
+)
+
+var (
+	errScrubStopped   = errors.New("Scrub cycle stopped")
+	errScrubCycleLost = errors.New("Scrub cycle taken over by another server")
+)
+
+// scrubberConfig - configures the background scrubber.
+type scrubberConfig struct {
+	Enabled bool
+
+	// Interval between the starts of two scrub cycles.
+	Interval time.Duration
+
+	// Maximum rate at which a server reads from disks while
+	// scrubbing, zero is unlimited.
+	MaxBytesPerSec int64
+}
+
+// Scrubber configuration, set through the environment.
+var globalScrubberConfig = scrubberConfig{
+	Enabled:        true,
+	Interval:       defaultScrubInterval,
+	MaxBytesPerSec: defaultScrubMaxIO,
+}
+
+// lookupScrubberEnv - returns the scrubber configuration overridden by
+// MINIO_SCRUBBER, MINIO_SCRUBBER_INTERVAL and MINIO_SCRUBBER_MAX_IO.
+func lookupScrubberEnv(cfg scrubberConfig) (scrubberConfig, error) {
+	if scrubber := os.Getenv("MINIO_SCRUBBER"); scrubber != "" {
+		switch strings.ToLower(scrubber) {
+		case "on":
+			cfg.Enabled = true
+		case "off":
+			cfg.Enabled = false
+		default:
+			return cfg, fmt.Errorf("Unknown value ‘%s’ in MINIO_SCRUBBER environment variable", scrubber)
+		}
+	}
+	if interval := os.Getenv("MINIO_SCRUBBER_INTERVAL"); interval != "" {
+		d, err := time.ParseDuration(interval)
+		if err != nil || d <= 0 {
+			return cfg, fmt.Errorf("Invalid value ‘%s’ in MINIO_SCRUBBER_INTERVAL environment variable", interval)
+		}
+		cfg.Interval = d
+	}
+	if maxIO := os.Getenv("MINIO_SCRUBBER_MAX_IO"); maxIO != "" {
+		n, err := humanize.ParseBytes(maxIO)
+		if err != nil {
+			return cfg, fmt.Errorf("Invalid value ‘%s’ in MINIO_SCRUBBER_MAX_IO environment variable", maxIO)
+		}
+		cfg.MaxBytesPerSec = int64(n)
+	}
+	return cfg, nil
+}
+
+// scrubStatus - status of the scrubber.
+type scrubStatus string
+
+const (
+	scrubIdle    scrubStatus = "idle"
+	scrubRunning scrubStatus = "running"
+)
+
+// ScrubStats - progress of the running scrub cycle, or stats of the
+// last cycle once idle. A shard is the erasure coded part of an object
+// on a disk, verified shards were read and checksummed, corrupted
+// shards failed verification.
+type ScrubStats struct {
+	Status       scrubStatus `json:"status"`
+	Node         string      `json:"node,omitempty"`
+	Cycles       uint64      `json:"cycles"`
+	CycleStart   time.Time   `json:"cycleStart"`
+	LastUpdate   time.Time   `json:"lastUpdate"`
+	LastCycleEnd time.Time   `json:"lastCycleEnd"`
+
+	MarkerBucket string `json:"markerBucket,omitempty"`
+	MarkerObject string `json:"markerObject,omitempty"`
+
+	ObjectsScanned  uint64 `json:"objectsScanned"`
+	ObjectsHealed   uint64 `json:"objectsHealed"`
+	ObjectsFailed   uint64 `json:"objectsFailed"`
+	ShardsVerified  uint64 `json:"shardsVerified"`
+	BytesVerified   uint64 `json:"bytesVerified"`
+	ShardsCorrupted uint64 `json:"shardsCorrupted"`
+	ShardsMissing   uint64 `json:"shardsMissing"`
+	ShardsHealed    uint64 `json:"shardsHealed"`
+}
+
+// addObject - accounts a scrubbed object and moves the marker to it.
+func (s *ScrubStats) addObject(bucket, object string, result scrubObjectResult, err error) {
+	s.MarkerBucket = bucket
+	s.MarkerObject = object
+	s.ObjectsScanned++
+	s.ShardsVerified += result.ShardsVerified
+	s.BytesVerified += result.BytesVerified
+	s.ShardsCorrupted += result.ShardsCorrupted
+	s.ShardsMissing += result.ShardsMissing
+	s.ShardsHealed += result.ShardsHealed
+	switch {
+	case err != nil:
+		s.ObjectsFailed++
+	case result.ShardsHealed > 0:
+		s.ObjectsHealed++
+	}
+}
+
+// readScrubStats - reads the saved scrub stats, stats of a scrubber
+// which never ran are idle.
+func readScrubStats(objAPI ObjectLayer) (ScrubStats, error) {
+	stats := ScrubStats{Status: scrubIdle}
+
+	// Acquire a read lock on the stats before reading.
+	objLock := globalNSMutex.NewNSLock(minioMetaBucket, scrubStatsPath)
+	objLock.RLock()
+	defer objLock.RUnlock()
+
+	var buffer bytes.Buffer
+	if err := objAPI.GetObject(minioMetaBucket, scrubStatsPath, 0, -1, &buffer); err != nil {
+		if isErrObjectNotFound(err) || isErrIncompleteBody(err) {
+			return stats, nil
+		}
+		return stats, errorCause(err)
+	}
+
+	err := json.Unmarshal(buffer.Bytes(), &stats)
+	return stats, err
+}
+
+// saveScrubStats - saves the scrub stats.
+func saveScrubStats(objAPI ObjectLayer, stats ScrubStats) error {
+	buf, err := json.Marshal(stats)
+	if err != nil {
+		return err
+	}
+
+	// Acquire a write lock on the stats before modifying.
+	objLock := globalNSMutex.NewNSLock(minioMetaBucket, scrubStatsPath)
+	objLock.Lock()
+	defer objLock.Unlock()
+
+	if _, err = objAPI.PutObject(minioMetaBucket, scrubStatsPath, int64(len(buf)), bytes.NewReader(buf), nil, ""); err != nil {
+		return errorCause(err)
+	}
+	return nil
+}
+
+// claimScrubCycle - claims the scrub cycle for the server node when a
+// cycle is due, or when the running cycle belongs to this server or to
+// a server which is gone. The second return value is false when the
+// server has nothing to scrub.
+func claimScrubCycle(objAPI ObjectLayer, node string, interval time.Duration) (ScrubStats, bool, error) {
+	scrubLock := globalNSMutex.NewNSLock(minioMetaBucket, scrubLockPath)
+	scrubLock.Lock()
+	defer scrubLock.Unlock()
+
+	stats, err := readScrubStats(objAPI)
+	if err != nil {
+		return stats, false, err
+	}
+
+	now := UTCNow()
+	switch {
+	case stats.Status == scrubRunning && stats.Node != node && now.Sub(stats.LastUpdate) < scrubStaleInterval:
+		// Scrubbed by another server.
+		return stats, false, nil
+	case stats.Status == scrubRunning:
+		// Resumed after its marker.
+	case now.Sub(stats.CycleStart) < interval:
+		return stats, false, nil
+	default:
+		stats = ScrubStats{
+			Cycles:       stats.Cycles,
+			CycleStart:   now,
+			LastCycleEnd: stats.LastCycleEnd,
+		}
+	}
+	stats.Status = scrubRunning
+	stats.Node = node
+	stats.LastUpdate = now
+	if err = saveScrubStats(objAPI, stats); err != nil {
+		return stats, false, err
+	}
+	return stats, true, nil
+}
+
+// updateScrubStats - saves the stats of a cycle scrubbed by this
+// server, unless another server took the cycle over.
+func updateScrubStats(objAPI ObjectLayer, stats ScrubStats) error {
+	scrubLock := globalNSMutex.NewNSLock(minioMetaBucket, scrubLockPath)
+	scrubLock.Lock()
+	defer scrubLock.Unlock()
+
+	saved, err := readScrubStats(objAPI)
+	if err != nil {
+		return err
+	}
+	if saved.Node != stats.Node || !saved.CycleStart.Equal(stats.CycleStart) {
+		return errScrubCycleLost
+	}
+	return saveScrubStats(objAPI, stats)
+}
+
+// scrubLayer - an object layer whose objects are scrubbed.
+type scrubLayer interface {
+	healSequenceLayer
+	scrubObject(bucket, object string, limiter *scrubRateLimiter) (scrubObjectResult, error)
+}
+
+// scrubber - a scrub cycle running on this server.
+type scrubber struct {
+	objAPI   scrubLayer
+	limiter  *scrubRateLimiter
+	stats    ScrubStats
+	lastSave time.Time
+
+	// Closed to stop the cycle.
+	doneCh <-chan struct{}
+}
+
+// update - applies a change to the stats of the cycle, the stats are
+// saved once every save interval.
+func (s *scrubber) update(change func(*ScrubStats)) error {
+	change(&s.stats)
+	now := UTCNow()
+	s.stats.LastUpdate = now
+	if now.Sub(s.lastSave) < scrubSaveInterval {
+		return nil
+	}
+	s.lastSave = now
+	return updateScrubStats(s.objAPI, s.stats)
+}
+
+// stopped - returns whether the cycle was asked to stop.
+func (s *scrubber) stopped() bool {
+	select {
+	case <-s.doneCh:
+		return true
+	default:
+		return false
+	}
+}
+
+// run - scrubs all buckets in order, starting with the marker bucket,
+// and the previous versions of their objects, then saves the stats of
+// the finished cycle.
+func (s *scrubber) run() error {
+	bucketsInfo, err := s.objAPI.ListBuckets()
+	if err != nil {
+		return errorCause(err)
+	}
+	// Cycles resumed while scrubbing previous versions are done with
+	// the buckets.
+	scrubbingVersions := s.stats.MarkerBucket == minioMetaVersionsBucket
+	for _, bucketInfo := range bucketsInfo {
+		if scrubbingVersions || bucketInfo.Name < s.stats.MarkerBucket {
+			continue
+		}
+		marker := ""
+		if bucketInfo.Name == s.stats.MarkerBucket {
+			marker = s.stats.MarkerObject
+		}
+		if err = s.scrubBucket(bucketInfo.Name, marker); err != nil {
+			return err
+		}
+	}
+
+	// Previous versions are objects of `.minio.sys/versions`, under
+	// the bucket and object name they are a version of.
+	marker := ""
+	if scrubbingVersions {
+		marker = s.stats.MarkerObject
+	}
+	if err = s.scrubBucket(minioMetaVersionsBucket, marker); err != nil {
+		return err
+	}
+
+	s.stats.Status = scrubIdle
+	s.stats.Cycles++
+	s.stats.LastCycleEnd = UTCNow()
+	s.stats.LastUpdate = s.stats.LastCycleEnd
+	s.stats.MarkerBucket = ""
+	s.stats.MarkerObject = ""
+	return updateScrubStats(s.objAPI, s.stats)
+}
+
+// scrubBucket - scrubs the objects of a bucket after marker.
+func (s *scrubber) scrubBucket(bucket, marker string) error {
+	for {
+		result, err := s.objAPI.listObjectsHealWalk(bucket, "", marker, "", maxObjectList)
+		if err != nil {
+			if _, ok := errorCause(err).(BucketNotFound); ok {
+				// Buckets removed during the cycle are skipped.
+				return nil
+			}
+			return errorCause(err)
+		}
+		for _, objInfo := range result.Objects {
+			if s.stopped() {
+				return errScrubStopped
+			}
+			scrubResult, scrubErr := s.objAPI.scrubObject(bucket, objInfo.Name, s.limiter)
+			if isErrObjectNotFound(scrubErr) {
+				// Objects removed during the cycle are skipped.
+				continue
+			}
+			errorIf(scrubErr, "Unable to scrub %s/%s.", bucket, objInfo.Name)
+			if err = s.update(func(stats *ScrubStats) {
+				stats.addObject(bucket, objInfo.Name, scrubResult, scrubErr)
+			}); err != nil {
+				return err
+			}
+		}
+		if !result.IsTruncated {
+			return nil
+		}
+		marker = result.NextMarker
+	}
+}
+
+// runScrubCycle - scrubs all objects on this server if a scrub cycle
+// is due, or resumes the running cycle if it is scrubbed by no other
+// server. The cycle stops when doneCh is closed.
+func runScrubCycle(objAPI ObjectLayer, cfg scrubberConfig, doneCh <-chan struct{}) error {
+	scrubObjAPI, ok := objAPI.(scrubLayer)
+	if !ok {
+		return nil
+	}
+
+	stats, claimed, err := claimScrubCycle(objAPI, GetLocalPeer(globalEndpoints), cfg.Interval)
+	if err != nil || !claimed {
+		return err
+	}
+
+	s := &scrubber{
+		objAPI:   scrubObjAPI,
+		limiter:  newScrubRateLimiter(cfg.MaxBytesPerSec),
+		stats:    stats,
+		lastSave: UTCNow(),
+		doneCh:   doneCh,
+	}
+	err = s.run()
+	if err == errScrubStopped || err == errScrubCycleLost {
+		return nil
+	}
+	return err
+}
+
+// startScrubber - starts the background routine which verifies the
+// checksums of all objects once every scrub interval, and heals the
+// objects failing verification.
+func startScrubber() {
+	if !globalIsXL || !globalScrubberConfig.Enabled {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(scrubCheckInterval)
+		defer ticker.Stop()
+
+		// Start with random sleep time, so as to avoid "synchronous checks" between servers
+		time.Sleep(time.Duration(rand.Float64() * float64(time.Minute)))
+		for {
+			// The object layer is looked up on every check, as healing
+			// the format of the disks replaces it.
+			if objAPI := newObjectLayerFn(); objAPI != nil {
+				errorIf(runScrubCycle(objAPI, globalScrubberConfig, globalServiceDoneCh), "Unable to scrub objects.")
+			}
+			select {
+			case <-ticker.C:
+			case <-globalServiceDoneCh:
+				return
+			}
+		}
+	}()
+}
@@ -0,0 +1,376 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	humanize "github.com/dustin/go-humanize"
+	router "github.com/gorilla/mux"
+	"github.com/minio/minio/pkg/madmin"
+)
+
+// Tests the scrubber configuration set through the environment.
+func TestLookupScrubberEnv(t *testing.T) {
+	defaultCfg := scrubberConfig{Enabled: true, Interval: time.Hour, MaxBytesPerSec: humanize.MiByte}
+	testCases := []struct {
+		scrubber, interval, maxIO string
+		expected                  scrubberConfig
+		shouldFail                bool
+	}{
+		{"", "", "", defaultCfg, false},
+		{"off", "", "", scrubberConfig{Interval: time.Hour, MaxBytesPerSec: humanize.MiByte}, false},
+		{"ON", "24h", "50MiB", scrubberConfig{Enabled: true, Interval: 24 * time.Hour, MaxBytesPerSec: 50 * humanize.MiByte}, false},
+		{"", "", "0", scrubberConfig{Enabled: true, Interval: time.Hour}, false},
+		{"maybe", "", "", defaultCfg, true},
+		{"", "-1h", "", defaultCfg, true},
+		{"", "week", "", defaultCfg, true},
+		{"", "", "fast", defaultCfg, true},
+	}
+	defer os.Unsetenv("MINIO_SCRUBBER")
+	defer os.Unsetenv("MINIO_SCRUBBER_INTERVAL")
+	defer os.Unsetenv("MINIO_SCRUBBER_MAX_IO")
+	for i, testCase := range testCases {
+		os.Setenv("MINIO_SCRUBBER", testCase.scrubber)
+		os.Setenv("MINIO_SCRUBBER_INTERVAL", testCase.interval)
+		os.Setenv("MINIO_SCRUBBER_MAX_IO", testCase.maxIO)
+		cfg, err := lookupScrubberEnv(defaultCfg)
+		if testCase.shouldFail {
+			if err == nil {
+				t.Errorf("Test %d: Expected an error", i+1)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: Unexpected error %v", i+1, err)
+			continue
+		}
+		if cfg != testCase.expected {
+			t.Errorf("Test %d: Expected %+v, got %+v", i+1, testCase.expected, cfg)
+		}
+	}
+}
+
+// Tests limiting the read rate of the scrubber.
+func TestScrubRateLimiter(t *testing.T) {
+	limiter := newScrubRateLimiter(100 * humanize.KiByte)
+	start := time.Now()
+	for i := 0; i < 6; i++ {
+		limiter.wait(10 * humanize.KiByte)
+	}
+	if elapsed := time.Since(start); elapsed < 500*time.Millisecond {
+		t.Errorf("Expected reads to take at least 500ms, took %v", elapsed)
+	}
+
+	// Unlimited rates never wait.
+	start = time.Now()
+	newScrubRateLimiter(0).wait(humanize.GiByte)
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("Expected unlimited reads not to wait, took %v", elapsed)
+	}
+}
+
+// Corrupts the first part of an object on a disk by flipping a byte.
+func corruptTestPart(t *testing.T, disk StorageAPI, bucket, object string) {
+	partPath := pathJoin(object, "part.1")
+	buf, err := disk.ReadAll(bucket, partPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf[0] ^= 0xff
+	if err = disk.DeleteFile(bucket, partPath); err != nil {
+		t.Fatal(err)
+	}
+	if err = disk.AppendFile(bucket, partPath, buf); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Tests verifying and healing the shards of an object.
+func TestScrubObject(t *testing.T) {
+	initNSLock(false)
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(rootPath)
+
+	xl, fsDirs := prepareHealSequenceXL(t)
+	defer removeRoots(fsDirs)
+
+	corruptTestPart(t, xl.storageDisks[0], "bucket1", "obj1")
+	if err = xl.storageDisks[1].DeleteFile("bucket1", pathJoin("obj1", "part.1")); err != nil {
+		t.Fatal(err)
+	}
+	xl.storageDisks[2] = nil
+
+	result, err := xl.scrubObject("bucket1", "obj1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := scrubObjectResult{ShardsVerified: 14, ShardsCorrupted: 1, ShardsMissing: 1, ShardsHealed: 2}
+	if result.BytesVerified == 0 {
+		t.Errorf("Expected bytes to be verified")
+	}
+	result.BytesVerified = 0
+	if result != expected {
+		t.Errorf("Expected %+v, got %+v", expected, result)
+	}
+
+	// Healed objects verify.
+	if result, err = xl.scrubObject("bucket1", "obj1", nil); err != nil {
+		t.Fatal(err)
+	}
+	if result.ShardsVerified != 15 || result.ShardsCorrupted != 0 || result.ShardsMissing != 0 || result.ShardsHealed != 0 {
+		t.Errorf("Unexpected result %+v", result)
+	}
+
+	if _, err = xl.scrubObject("bucket1", "nosuchobject", nil); !isErrObjectNotFound(err) {
+		t.Errorf("Expected object not found, got %v", err)
+	}
+
+	// Objects overwritten while their shards are verified are not
+	// corrupted.
+	corruptTestPart(t, xl.storageDisks[0], "bucket1", "obj2")
+	result, xlMeta, err := xl.verifyObject("bucket1", "obj2", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.ShardsCorrupted != 1 {
+		t.Fatalf("Expected a corrupted shard, got %+v", result)
+	}
+	data := []byte("overwritten")
+	if _, err = xl.PutObject("bucket1", "obj2", int64(len(data)), bytes.NewReader(data), nil, ""); err != nil {
+		t.Fatal(err)
+	}
+	if result, err = xl.healVerifiedObject("bucket1", "obj2", xlMeta, result); err != nil {
+		t.Fatal(err)
+	}
+	if result.ShardsCorrupted != 0 || result.ShardsMissing != 0 || result.ShardsHealed != 0 {
+		t.Errorf("Unexpected result of an overwritten object %+v", result)
+	}
+}
+
+// Tests running, resuming and claiming scrub cycles.
+func TestRunScrubCycle(t *testing.T) {
+	initNSLock(false)
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(rootPath)
+
+	xl, fsDirs := prepareHealSequenceXL(t)
+	defer removeRoots(fsDirs)
+
+	corruptTestPart(t, xl.storageDisks[3], "bucket2", "obj1")
+
+	cfg := scrubberConfig{Enabled: true, Interval: time.Hour}
+	doneCh := make(chan struct{})
+	if err = runScrubCycle(xl, cfg, doneCh); err != nil {
+		t.Fatal(err)
+	}
+	stats, err := readScrubStats(xl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Status != scrubIdle || stats.Cycles != 1 || stats.LastCycleEnd.IsZero() || stats.MarkerBucket != "" ||
+		stats.ObjectsScanned != 4 || stats.ObjectsHealed != 1 || stats.ObjectsFailed != 0 ||
+		stats.ShardsVerified != 64 || stats.ShardsCorrupted != 1 || stats.ShardsHealed != 1 {
+		t.Fatalf("Unexpected scrub stats %+v", stats)
+	}
+
+	// Cycles are not started again before the interval.
+	if err = runScrubCycle(xl, cfg, doneCh); err != nil {
+		t.Fatal(err)
+	}
+	if stats, err = readScrubStats(xl); err != nil || stats.Cycles != 1 {
+		t.Fatalf("Unexpected scrub stats %+v %v", stats, err)
+	}
+
+	// Cycles scrubbed by another server are left alone, unless the
+	// server stopped saving its progress.
+	node := GetLocalPeer(globalEndpoints)
+	other := ScrubStats{Status: scrubRunning, Node: "other:9000", Cycles: 1, CycleStart: UTCNow(), LastUpdate: UTCNow()}
+	if err = saveScrubStats(xl, other); err != nil {
+		t.Fatal(err)
+	}
+	if _, claimed, err := claimScrubCycle(xl, node, time.Hour); err != nil || claimed {
+		t.Errorf("Expected the cycle of another server not to be claimed, got %v %v", claimed, err)
+	}
+	if err = updateScrubStats(xl, ScrubStats{Node: node, CycleStart: other.CycleStart}); err != errScrubCycleLost {
+		t.Errorf("Expected %v, got %v", errScrubCycleLost, err)
+	}
+
+	// Stale cycles are resumed after their marker.
+	other.LastUpdate = UTCNow().Add(-scrubStaleInterval)
+	other.MarkerBucket, other.MarkerObject = "bucket1", "obj2"
+	other.ObjectsScanned = 2
+	if err = saveScrubStats(xl, other); err != nil {
+		t.Fatal(err)
+	}
+	if err = runScrubCycle(xl, cfg, doneCh); err != nil {
+		t.Fatal(err)
+	}
+	if stats, err = readScrubStats(xl); err != nil {
+		t.Fatal(err)
+	}
+	if stats.Status != scrubIdle || stats.Node != node || stats.Cycles != 2 || stats.ObjectsScanned != 4 || stats.ShardsVerified != 32 {
+		t.Errorf("Unexpected scrub stats %+v", stats)
+	}
+
+	// Stopped cycles are left running, to be resumed.
+	stats.CycleStart = UTCNow().Add(-time.Hour)
+	if err = saveScrubStats(xl, stats); err != nil {
+		t.Fatal(err)
+	}
+	close(doneCh)
+	if err = runScrubCycle(xl, cfg, doneCh); err != nil {
+		t.Fatal(err)
+	}
+	if stats, err = readScrubStats(xl); err != nil {
+		t.Fatal(err)
+	}
+	if stats.Status != scrubRunning || stats.Cycles != 2 || stats.ObjectsScanned != 0 {
+		t.Errorf("Unexpected scrub stats %+v", stats)
+	}
+}
+
+// Tests that scrub cycles verify and heal the previous versions of
+// objects.
+func TestScrubVersions(t *testing.T) {
+	initNSLock(false)
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(rootPath)
+
+	xl, fsDirs := prepareHealSequenceXL(t)
+	defer removeRoots(fsDirs)
+
+	if err = initBucketVersioning(xl); err != nil {
+		t.Fatal(err)
+	}
+	globalBucketVersioning.SetBucketVersioning("bucket1", &versioningConfiguration{Status: versioningEnabled})
+	defer globalBucketVersioning.SetBucketVersioning("bucket1", nil)
+
+	// Archive the `null` version of obj1 and corrupt its part on the
+	// first disk.
+	data := []byte("v2")
+	if _, err = xl.PutObject("bucket1", "obj1", int64(len(data)), bytes.NewReader(data), nil, ""); err != nil {
+		t.Fatal(err)
+	}
+	versionPath := xlVersionPath("bucket1", "obj1", "")
+	corruptTestPart(t, xl.storageDisks[0], minioMetaVersionsBucket, versionPath)
+
+	cfg := scrubberConfig{Enabled: true, Interval: time.Hour}
+	if err = runScrubCycle(xl, cfg, make(chan struct{})); err != nil {
+		t.Fatal(err)
+	}
+	stats, err := readScrubStats(xl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Status != scrubIdle || stats.ObjectsScanned != 5 || stats.ObjectsHealed != 1 ||
+		stats.ShardsCorrupted != 1 || stats.ShardsHealed != 1 {
+		t.Fatalf("Unexpected scrub stats %+v", stats)
+	}
+	result, err := xl.scrubObject(minioMetaVersionsBucket, versionPath, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.ShardsVerified != 16 || result.ShardsCorrupted != 0 {
+		t.Errorf("Expected the version to be healed, got %+v", result)
+	}
+
+	// Stale cycles resumed in the versions scrub only the versions.
+	stale := ScrubStats{Status: scrubRunning, Node: "other:9000", Cycles: 1, CycleStart: UTCNow(),
+		LastUpdate: UTCNow().Add(-scrubStaleInterval), MarkerBucket: minioMetaVersionsBucket}
+	if err = saveScrubStats(xl, stale); err != nil {
+		t.Fatal(err)
+	}
+	if err = runScrubCycle(xl, cfg, make(chan struct{})); err != nil {
+		t.Fatal(err)
+	}
+	if stats, err = readScrubStats(xl); err != nil {
+		t.Fatal(err)
+	}
+	if stats.Status != scrubIdle || stats.Cycles != 2 || stats.ObjectsScanned != 1 {
+		t.Errorf("Unexpected scrub stats %+v", stats)
+	}
+}
+
+// Tests fetching the scrub stats through the admin API.
+func TestScrubStatsHandler(t *testing.T) {
+	initNSLock(false)
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(rootPath)
+
+	xl, fsDirs := prepareHealSequenceXL(t)
+	defer removeRoots(fsDirs)
+
+	globalObjLayerMutex.Lock()
+	globalObjectAPI = xl
+	globalObjLayerMutex.Unlock()
+	globalIsXL = true
+	defer resetGlobalObjectAPI()
+	defer resetGlobalIsXL()
+
+	adminRouter := router.NewRouter()
+	registerAdminRouter(adminRouter)
+
+	server := httptest.NewServer(adminRouter)
+	defer server.Close()
+
+	cred := serverConfig.GetCredential()
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	adminClient, err := madmin.New(u.Host, cred.AccessKey, cred.SecretKey, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stats, err := adminClient.ScrubStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Status != "idle" || stats.Cycles != 0 {
+		t.Errorf("Unexpected scrub stats %+v", stats)
+	}
+
+	saved := ScrubStats{Status: scrubRunning, Node: "other:9000", CycleStart: UTCNow(), ObjectsScanned: 3, ShardsCorrupted: 2}
+	if err = saveScrubStats(xl, saved); err != nil {
+		t.Fatal(err)
+	}
+	if stats, err = adminClient.ScrubStats(); err != nil {
+		t.Fatal(err)
+	}
+	if stats.Status != "running" || stats.Node != saved.Node || stats.ObjectsScanned != 3 || stats.ShardsCorrupted != 2 {
+		t.Errorf("Unexpected scrub stats %+v", stats)
+	}
+}
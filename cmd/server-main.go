@@ -21,6 +21,7 @@ import (
 	"os"
 	"os/signal"
 	"runtime"
+	"strings"
 	"syscall"
 
 	"github.com/minio/cli"
@@ -61,6 +62,28 @@ ENVIRONMENT VARIABLES:
   REGION:
      MINIO_REGION: To set custom region. By default it is "us-east-1".
 
+  DOMAIN:
+     MINIO_DOMAIN: To enable virtual host style requests, comma separated list of server domains.
+
+  WEBSITE:
+     MINIO_WEBSITE_ADDRESS: Address of a separate listener serving bucket websites, e.g. ":9080".
+     MINIO_WEBSITE_DOMAIN: Domain serving bucket websites as <bucket>.<domain>.
+
+  ENCRYPTION:
+     MINIO_SSE_MASTER_KEY: Master key for server side encryption as <key-id>:<64 hex characters>.
+     MINIO_SSE_VAULT_ENDPOINT: Vault server sealing the keys of server side encryption.
+     MINIO_SSE_VAULT_TOKEN: Token to access the Vault server.
+     MINIO_SSE_VAULT_KEY_ID: Name of the Vault transit key.
+
+  STORAGE CLASS:
+     MINIO_STORAGE_CLASS_STANDARD: Parity of standard objects as "EC:<parity>". By default it is half the disks.
+     MINIO_STORAGE_CLASS_RRS: Parity of reduced redundancy objects as "EC:<parity>". By default it is "EC:2".
+
+  SCRUBBER:
+     MINIO_SCRUBBER: To disable verifying the checksums of all objects in background, set this value to "off".
+     MINIO_SCRUBBER_INTERVAL: Interval between two scrubs of all objects. By default it is "168h".
+     MINIO_SCRUBBER_MAX_IO: Maximum rate at which a server reads while scrubbing, "0" is unlimited. By default it is "10MiB".
+
 EXAMPLES:
   1. Start minio server on "/home/shared" directory.
       $ {{.HelpName}} /home/shared
@@ -119,6 +142,25 @@ func serverHandleEnvVars() {
 		globalServerRegion = serverRegion
 	}
 
+	if websiteAddr := os.Getenv("MINIO_WEBSITE_ADDRESS"); websiteAddr != "" {
+		fatalIf(CheckLocalServerAddr(websiteAddr), "Invalid MINIO_WEBSITE_ADDRESS ‘%s’ environment variable.", websiteAddr)
+		globalWebsiteAddr = websiteAddr
+	}
+
+	if websiteDomain := os.Getenv("MINIO_WEBSITE_DOMAIN"); websiteDomain != "" {
+		globalWebsiteDomain = strings.ToLower(strings.Trim(websiteDomain, "."))
+	}
+
+	scrubCfg, err := lookupScrubberEnv(globalScrubberConfig)
+	fatalIf(err, "Invalid scrubber configuration.")
+	globalScrubberConfig = scrubCfg
+
+	storageClassCfg, isEnvStorageClass, err := lookupStorageClassEnv(storageClassConfig{})
+	fatalIf(err, "Invalid storage class configuration.")
+	if isEnvStorageClass {
+		globalIsEnvStorageClass = true
+		globalStandardStorageClass, globalRRStorageClass = storageClassCfg.Standard, storageClassCfg.RRS
+	}
 }
 
 // serverMain handler called for 'minio server' command.
@@ -145,12 +187,20 @@ func serverMain(ctx *cli.Context) {
 	// Initialize server config.
 	initConfig()
 
+	// Parity of the storage classes is limited by the number of disks.
+	if globalIsXL {
+		fatalIf(validateParity(getSetDriveCount(len(globalEndpoints)), globalStandardStorageClass, globalRRStorageClass), "Invalid storage class configuration.")
+	}
+
 	// Enable loggers as per configuration file.
 	enableLoggers()
 
 	// Init the error tracing module.
 	initError()
 
+	// Initialize the key management service for SSE-S3.
+	initKMS()
+
 	// Check and load SSL certificates.
 	var err error
 	globalPublicCerts, globalRootCAs, globalTLSCertificate, globalIsSSL, err = getSSLConfig()
@@ -199,6 +249,15 @@ func serverMain(ctx *cli.Context) {
 		globalHTTPServerErrorCh <- globalHTTPServer.Start()
 	}()
 
+	// Serve bucket websites on a listener of their own if configured.
+	if globalWebsiteAddr != "" {
+		globalWebsiteServer = miniohttp.NewServer([]string{globalWebsiteAddr}, newWebsiteHandler(), globalTLSCertificate)
+		globalWebsiteServer.ErrorLogFunc = errorIf
+		go func() {
+			globalHTTPServerErrorCh <- globalWebsiteServer.Start()
+		}()
+	}
+
 	signal.Notify(globalOSSignalCh, os.Interrupt, syscall.SIGTERM)
 
 	newObject, err := newObjectLayer(globalEndpoints)
@@ -213,6 +272,27 @@ func serverMain(ctx *cli.Context) {
 	globalObjectAPI = newObject
 	globalObjLayerMutex.Unlock()
 
+	// Start applying bucket lifecycle rules in background.
+	startLifecycleEnforcer(newObject)
+
+	// Start crawling the data usage of all buckets in background.
+	startDataUsageCrawler(newObject)
+
+	// Resume the heal sequences which were running before a restart.
+	startHealSequences(newObject)
+
+	// Start healing freshly formatted disks in background.
+	startDiskHealMonitor()
+
+	// Start verifying the checksums of all objects in background.
+	startScrubber()
+
+	// Start replicating object writes and deletes to remote servers.
+	startReplicationWorkers(newObject)
+
+	// Start writing the server access logs of buckets.
+	startAccessLogger(newObject)
+
 	// Prints the formatted startup message once object layer is initialized.
 	apiEndpoints := getAPIEndpoints(globalMinioAddr)
 	printStartupMessage(apiEndpoints)
@@ -40,6 +40,7 @@ func handleSignals() {
 
 		err = globalHTTPServer.Shutdown()
 		errorIf(err, "Unable to shutdown http server")
+		shutdownWebsiteServer()
 
 		if objAPI := newObjectLayerFn(); objAPI != nil {
 			oerr = objAPI.Shutdown()
@@ -71,6 +72,7 @@ func handleSignals() {
 				log.Println("Restarting on service signal")
 				err := globalHTTPServer.Shutdown()
 				errorIf(err, "Unable to shutdown http server")
+				shutdownWebsiteServer()
 				rerr := restartProcess()
 				errorIf(rerr, "Unable to restart the server")
 
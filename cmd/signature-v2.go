@@ -64,10 +64,10 @@ var resourceList = []string{
 }
 
 func doesPolicySignatureV2Match(formValues http.Header) APIErrorCode {
-	cred := serverConfig.GetCredential()
 	accessKey := formValues.Get("AWSAccessKeyId")
-	if accessKey != cred.AccessKey {
-		return ErrInvalidAccessKeyID
+	cred, s3Err := checkKeyValid(accessKey)
+	if s3Err != ErrNone {
+		return s3Err
 	}
 	policy := formValues.Get("Policy")
 	signature := formValues.Get("Signature")
@@ -81,12 +81,10 @@ func doesPolicySignatureV2Match(formValues http.Header) APIErrorCode {
 //     - http://docs.aws.amazon.com/AmazonS3/latest/dev/RESTAuthentication.html#RESTAuthenticationQueryStringAuth
 // returns ErrNone if matches. S3 errors otherwise.
 func doesPresignV2SignatureMatch(r *http.Request) APIErrorCode {
-	// Access credentials.
-	cred := serverConfig.GetCredential()
-
-	// r.RequestURI will have raw encoded URI as sent by the client.
+	// r.RequestURI will have raw encoded URI as sent by the client,
+	// virtual host style requests sign the bucket as part of the path.
 	tokens := strings.SplitN(r.RequestURI, "?", 2)
-	encodedResource := tokens[0]
+	encodedResource := getResource(tokens[0], r.Host)
 	encodedQuery := ""
 	if len(tokens) == 2 {
 		encodedQuery = tokens[1]
@@ -127,9 +125,10 @@ func doesPresignV2SignatureMatch(r *http.Request) APIErrorCode {
 		return ErrInvalidQueryParams
 	}
 
-	// Validate if access key id same.
-	if accessKey != cred.AccessKey {
-		return ErrInvalidAccessKeyID
+	// Validate if access key id is known.
+	cred, s3Err := checkKeyValid(accessKey)
+	if s3Err != ErrNone {
+		return s3Err
 	}
 
 	// Make sure the request has not expired.
@@ -143,7 +142,7 @@ func doesPresignV2SignatureMatch(r *http.Request) APIErrorCode {
 		return ErrExpiredPresignRequest
 	}
 
-	expectedSignature := preSignatureV2(r.Method, encodedResource, strings.Join(filteredQueries, "&"), r.Header, expires)
+	expectedSignature := preSignatureV2(cred, r.Method, encodedResource, strings.Join(filteredQueries, "&"), r.Header, expires)
 	if gotSignature != expectedSignature {
 		return ErrSignatureDoesNotMatch
 	}
@@ -171,53 +170,50 @@ func doesPresignV2SignatureMatch(r *http.Request) APIErrorCode {
 //     - http://docs.aws.amazon.com/AmazonS3/latest/dev/auth-request-sig-v2.html
 // returns true if matches, false otherwise. if error is not nil then it is always false
 
-func validateV2AuthHeader(v2Auth string) APIErrorCode {
+func validateV2AuthHeader(v2Auth string) (credential, APIErrorCode) {
 	if v2Auth == "" {
-		return ErrAuthHeaderEmpty
+		return credential{}, ErrAuthHeaderEmpty
 	}
 	// Verify if the header algorithm is supported or not.
 	if !strings.HasPrefix(v2Auth, signV2Algorithm) {
-		return ErrSignatureVersionNotSupported
+		return credential{}, ErrSignatureVersionNotSupported
 	}
 
 	// below is V2 Signed Auth header format, splitting on `space` (after the `AWS` string).
 	// Authorization = "AWS" + " " + AWSAccessKeyId + ":" + Signature
 	authFields := strings.Split(v2Auth, " ")
 	if len(authFields) != 2 {
-		return ErrMissingFields
+		return credential{}, ErrMissingFields
 	}
 
 	// Then will be splitting on ":", this will seprate `AWSAccessKeyId` and `Signature` string.
 	keySignFields := strings.Split(strings.TrimSpace(authFields[1]), ":")
 	if len(keySignFields) != 2 {
-		return ErrMissingFields
+		return credential{}, ErrMissingFields
 	}
 
 	// Access credentials.
-	cred := serverConfig.GetCredential()
-	if keySignFields[0] != cred.AccessKey {
-		return ErrInvalidAccessKeyID
-	}
-
-	return ErrNone
+	return checkKeyValid(keySignFields[0])
 }
 
 func doesSignV2Match(r *http.Request) APIErrorCode {
 	v2Auth := r.Header.Get("Authorization")
 
-	if apiError := validateV2AuthHeader(v2Auth); apiError != ErrNone {
+	cred, apiError := validateV2AuthHeader(v2Auth)
+	if apiError != ErrNone {
 		return apiError
 	}
 
-	// r.RequestURI will have raw encoded URI as sent by the client.
+	// r.RequestURI will have raw encoded URI as sent by the client,
+	// virtual host style requests sign the bucket as part of the path.
 	tokens := strings.SplitN(r.RequestURI, "?", 2)
-	encodedResource := tokens[0]
+	encodedResource := getResource(tokens[0], r.Host)
 	encodedQuery := ""
 	if len(tokens) == 2 {
 		encodedQuery = tokens[1]
 	}
 
-	expectedAuth := signatureV2(r.Method, encodedResource, encodedQuery, r.Header)
+	expectedAuth := signatureV2(cred, r.Method, encodedResource, encodedQuery, r.Header)
 	if v2Auth != expectedAuth {
 		return ErrSignatureDoesNotMatch
 	}
@@ -232,15 +228,13 @@ func calculateSignatureV2(stringToSign string, secret string) string {
 }
 
 // Return signature-v2 for the presigned request.
-func preSignatureV2(method string, encodedResource string, encodedQuery string, headers http.Header, expires string) string {
-	cred := serverConfig.GetCredential()
+func preSignatureV2(cred credential, method string, encodedResource string, encodedQuery string, headers http.Header, expires string) string {
 	stringToSign := presignV2STS(method, encodedResource, encodedQuery, headers, expires)
 	return calculateSignatureV2(stringToSign, cred.SecretKey)
 }
 
 // Return signature-v2 authrization header.
-func signatureV2(method string, encodedResource string, encodedQuery string, headers http.Header) string {
-	cred := serverConfig.GetCredential()
+func signatureV2(cred credential, method string, encodedResource string, encodedQuery string, headers http.Header) string {
 	stringToSign := signV2STS(method, encodedResource, encodedQuery, headers)
 	signature := calculateSignatureV2(stringToSign, cred.SecretKey)
 	return fmt.Sprintf("%s %s:%s", signV2Algorithm, cred.AccessKey, signature)
@@ -194,7 +194,7 @@ func TestValidateV2AuthHeader(t *testing.T) {
 	for i, testCase := range testCases {
 		t.Run(fmt.Sprintf("Case %d AuthStr \"%s\".", i+1, testCase.authString), func(t *testing.T) {
 
-			actualErrCode := validateV2AuthHeader(testCase.authString)
+			_, actualErrCode := validateV2AuthHeader(testCase.authString)
 
 			if testCase.expectedError != actualErrCode {
 				t.Errorf("Expected the error code to be %v, got %v.", testCase.expectedError, actualErrCode)
@@ -233,3 +233,52 @@ func TestDoesPolicySignatureV2Match(t *testing.T) {
 		}
 	}
 }
+
+// Tests signature v2 of virtual host style requests, which sign the
+// bucket as part of the resource.
+func TestDoesSignV2MatchVirtualHost(t *testing.T) {
+	root, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatal("Unable to initialize test config.")
+	}
+	defer removeAll(root)
+
+	globalDomains = []string{"s3.example.com"}
+	defer func() { globalDomains = nil }()
+
+	testCases := []struct {
+		signedResource string
+		expected       APIErrorCode
+	}{
+		{"/bucket/object", ErrNone},
+		{"/object", ErrSignatureDoesNotMatch},
+	}
+
+	for i, testCase := range testCases {
+		req, e := http.NewRequest(http.MethodGet, "http://bucket.s3.example.com/object", nil)
+		if e != nil {
+			t.Fatalf("(%d) failed to create http.Request, got %v", i, e)
+		}
+		req.RequestURI = req.URL.RequestURI()
+		req.Header.Set("Date", UTCNow().Format(http.TimeFormat))
+		req.Header.Set("Authorization", signatureV2(serverConfig.GetCredential(), req.Method, testCase.signedResource, "", req.Header))
+		if err := doesSignV2Match(req); err != testCase.expected {
+			t.Errorf("(%d) expected to get %s, instead got %s", i, niceError(testCase.expected), niceError(err))
+		}
+
+		// Presigned requests sign the same resource.
+		expires := fmt.Sprintf("%d", UTCNow().Unix()+60)
+		query := url.Values{}
+		query.Set("AWSAccessKeyId", serverConfig.GetCredential().AccessKey)
+		query.Set("Expires", expires)
+		query.Set("Signature", preSignatureV2(serverConfig.GetCredential(), http.MethodGet, testCase.signedResource, "", http.Header{}, expires))
+		req, e = http.NewRequest(http.MethodGet, "http://bucket.s3.example.com/object?"+query.Encode(), nil)
+		if e != nil {
+			t.Fatalf("(%d) failed to create http.Request, got %v", i, e)
+		}
+		req.RequestURI = req.URL.RequestURI()
+		if err := doesPresignV2SignatureMatch(req); err != testCase.expected {
+			t.Errorf("(%d) expected to get %s for presigned request, instead got %s", i, niceError(testCase.expected), niceError(err))
+		}
+	}
+}
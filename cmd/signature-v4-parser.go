@@ -70,7 +70,7 @@ func parseCredentialHeader(credElement string) (ch credentialHeader, aec APIErro
 		return ch, ErrMalformedCredentialDate
 	}
 	cred.scope.region = credElements[2]
-	if credElements[3] != "s3" {
+	if credElements[3] != string(serviceS3) && credElements[3] != string(serviceSTS) {
 		return ch, ErrInvalidService
 	}
 	cred.scope.service = credElements[3]
@@ -82,6 +82,37 @@ func isValidRegion(reqRegion string, confRegion string) bool {
 	return reqRegion == confRegion
 }
 
+// checkKeyValid - returns the credential of the access key, which is
+// either the server credential or the credential of an enabled IAM
+// user, ErrInvalidAccessKeyID is returned for unknown access keys.
+func checkKeyValid(accessKey string) (credential, APIErrorCode) {
+	cred := serverConfig.GetCredential()
+	if accessKey == cred.AccessKey {
+		return cred, ErrNone
+	}
+	cred, ok := globalIAMSys.GetUserCredential(accessKey)
+	if !ok {
+		return credential{}, ErrInvalidAccessKeyID
+	}
+	return cred, ErrNone
+}
+
+// checkSessionKeyValid - returns the credential of the access key like
+// checkKeyValid, temporary credentials are only valid along with their
+// session token.
+func checkSessionKeyValid(accessKey, sessionToken string) (credential, APIErrorCode) {
+	if sessionToken == "" {
+		return checkKeyValid(accessKey)
+	}
+	if _, s3Err := parseSessionToken(accessKey, sessionToken); s3Err != ErrNone {
+		return credential{}, s3Err
+	}
+	return credential{
+		AccessKey: accessKey,
+		SecretKey: getSessionSecretKey(sessionToken),
+	}, ErrNone
+}
+
 // sumHMAC calculate hmac between two input byte array.
 func sumHMAC(key []byte, data []byte) []byte {
 	hash := hmac.New(sha256.New, key)
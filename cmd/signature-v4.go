@@ -44,6 +44,15 @@ const (
 	yyyymmdd        = "20060102"
 )
 
+// serviceType - service of the credential scope of signature V4.
+type serviceType string
+
+// Services signed requests are accepted for.
+const (
+	serviceS3  serviceType = "s3"
+	serviceSTS serviceType = "sts"
+)
+
 // getCanonicalHeaders generate a list of request headers with their values
 func getCanonicalHeaders(signedHeaders http.Header) string {
 	var headers []string
@@ -108,7 +117,7 @@ func getScope(t time.Time, region string) string {
 	scope := strings.Join([]string{
 		t.Format(yyyymmdd),
 		region,
-		"s3",
+		string(serviceS3),
 		"aws4_request",
 	}, "/")
 	return scope
@@ -124,10 +133,10 @@ func getStringToSign(canonicalRequest string, t time.Time, scope string) string
 }
 
 // getSigningKey hmac seed to calculate final signature.
-func getSigningKey(secretKey string, t time.Time, region string) []byte {
+func getSigningKey(secretKey string, t time.Time, region string, stype serviceType) []byte {
 	date := sumHMAC([]byte("AWS4"+secretKey), []byte(t.Format(yyyymmdd)))
 	regionBytes := sumHMAC(date, []byte(region))
-	service := sumHMAC(regionBytes, []byte("s3"))
+	service := sumHMAC(regionBytes, []byte(stype))
 	signingKey := sumHMAC(service, []byte("aws4_request"))
 	return signingKey
 }
@@ -146,13 +155,32 @@ func doesPolicySignatureMatch(formValues http.Header) APIErrorCode {
 	return doesPolicySignatureV4Match(formValues)
 }
 
+// getPolicyAccessKey - returns the access key which signed the post
+// policy, the signature is expected to be verified already.
+func getPolicyAccessKey(formValues http.Header) string {
+	if _, ok := formValues["Signature"]; ok {
+		return formValues.Get("AWSAccessKeyId")
+	}
+	credHeader, err := parseCredentialHeader("Credential=" + formValues.Get("X-Amz-Credential"))
+	if err != ErrNone {
+		return ""
+	}
+	return credHeader.accessKey
+}
+
+// getPolicySessionToken - returns the session token of a post policy
+// signed with temporary credentials, only signature V4 supports them.
+func getPolicySessionToken(formValues http.Header) string {
+	if _, ok := formValues["Signature"]; ok {
+		return ""
+	}
+	return formValues.Get(amzSecurityToken)
+}
+
 // doesPolicySignatureMatch - Verify query headers with post policy
 //     - http://docs.aws.amazon.com/AmazonS3/latest/API/sigv4-HTTPPOSTConstructPolicy.html
 // returns ErrNone if the signature matches.
 func doesPolicySignatureV4Match(formValues http.Header) APIErrorCode {
-	// Access credentials.
-	cred := serverConfig.GetCredential()
-
 	// Server region.
 	region := serverConfig.GetRegion()
 
@@ -161,10 +189,15 @@ func doesPolicySignatureV4Match(formValues http.Header) APIErrorCode {
 	if err != ErrNone {
 		return ErrMissingFields
 	}
+	if credHeader.scope.service != string(serviceS3) {
+		return ErrInvalidService
+	}
 
-	// Verify if the access key id matches.
-	if credHeader.accessKey != cred.AccessKey {
-		return ErrInvalidAccessKeyID
+	// Verify if the access key id is known, temporary credentials
+	// send their session token.
+	cred, s3Err := checkSessionKeyValid(credHeader.accessKey, formValues.Get(amzSecurityToken))
+	if s3Err != ErrNone {
+		return s3Err
 	}
 
 	// Verify if the region is valid.
@@ -174,7 +207,7 @@ func doesPolicySignatureV4Match(formValues http.Header) APIErrorCode {
 	}
 
 	// Get signing key.
-	signingKey := getSigningKey(cred.SecretKey, credHeader.scope.date, sRegion)
+	signingKey := getSigningKey(cred.SecretKey, credHeader.scope.date, sRegion, serviceS3)
 
 	// Get signature.
 	newSignature := getSignature(signingKey, formValues.Get("Policy"))
@@ -192,9 +225,6 @@ func doesPolicySignatureV4Match(formValues http.Header) APIErrorCode {
 //     - http://docs.aws.amazon.com/AmazonS3/latest/API/sigv4-query-string-auth.html
 // returns ErrNone if the signature matches.
 func doesPresignedSignatureMatch(hashedPayload string, r *http.Request, region string) APIErrorCode {
-	// Access credentials.
-	cred := serverConfig.GetCredential()
-
 	// Copy request
 	req := *r
 
@@ -203,10 +233,16 @@ func doesPresignedSignatureMatch(hashedPayload string, r *http.Request, region s
 	if err != ErrNone {
 		return err
 	}
+	if pSignValues.Credential.scope.service != string(serviceS3) {
+		return ErrInvalidService
+	}
 
-	// Verify if the access key id matches.
-	if pSignValues.Credential.accessKey != cred.AccessKey {
-		return ErrInvalidAccessKeyID
+	// Verify if the access key id is known, temporary credentials
+	// send their session token.
+	sessionToken := req.URL.Query().Get(amzSecurityToken)
+	cred, s3Err := checkSessionKeyValid(pSignValues.Credential.accessKey, sessionToken)
+	if s3Err != ErrNone {
+		return s3Err
 	}
 
 	// Verify if region is valid.
@@ -251,6 +287,9 @@ func doesPresignedSignatureMatch(hashedPayload string, r *http.Request, region s
 	query.Set("X-Amz-Expires", strconv.Itoa(expireSeconds))
 	query.Set("X-Amz-SignedHeaders", getSignedHeaders(extractedSignedHeaders))
 	query.Set("X-Amz-Credential", cred.AccessKey+"/"+getScope(t, sRegion))
+	if sessionToken != "" {
+		query.Set(amzSecurityToken, sessionToken)
+	}
 
 	// Save other headers available in the request parameters.
 	for k, v := range req.URL.Query() {
@@ -295,7 +334,7 @@ func doesPresignedSignatureMatch(hashedPayload string, r *http.Request, region s
 	presignedStringToSign := getStringToSign(presignedCanonicalReq, t, pSignValues.Credential.getScope())
 
 	// Get hmac presigned signing key.
-	presignedSigningKey := getSigningKey(cred.SecretKey, pSignValues.Credential.scope.date, region)
+	presignedSigningKey := getSigningKey(cred.SecretKey, pSignValues.Credential.scope.date, region, serviceS3)
 
 	// Get new signature.
 	newSignature := getSignature(presignedSigningKey, presignedStringToSign)
@@ -309,11 +348,9 @@ func doesPresignedSignatureMatch(hashedPayload string, r *http.Request, region s
 
 // doesSignatureMatch - Verify authorization header with calculated header in accordance with
 //     - http://docs.aws.amazon.com/AmazonS3/latest/API/sig-v4-authenticating-requests.html
-// returns ErrNone if signature matches.
-func doesSignatureMatch(hashedPayload string, r *http.Request, region string) APIErrorCode {
-	// Access credentials.
-	cred := serverConfig.GetCredential()
-
+// returns ErrNone if signature matches. The request must be signed
+// for the service of the API, `s3` or `sts`.
+func doesSignatureMatch(hashedPayload string, r *http.Request, region string, stype serviceType) APIErrorCode {
 	// Copy request.
 	req := *r
 
@@ -325,6 +362,9 @@ func doesSignatureMatch(hashedPayload string, r *http.Request, region string) AP
 	if err != ErrNone {
 		return err
 	}
+	if signV4Values.Credential.scope.service != string(stype) {
+		return ErrInvalidService
+	}
 
 	// Extract all the signed headers along with its values.
 	extractedSignedHeaders, errCode := extractSignedHeaders(signV4Values.SignedHeaders, r)
@@ -332,9 +372,11 @@ func doesSignatureMatch(hashedPayload string, r *http.Request, region string) AP
 		return errCode
 	}
 
-	// Verify if the access key id matches.
-	if signV4Values.Credential.accessKey != cred.AccessKey {
-		return ErrInvalidAccessKeyID
+	// Verify if the access key id is known, temporary credentials
+	// send their session token.
+	cred, s3Err := checkSessionKeyValid(signV4Values.Credential.accessKey, req.Header.Get(amzSecurityToken))
+	if s3Err != ErrNone {
+		return s3Err
 	}
 
 	// Verify if region is valid.
@@ -374,7 +416,7 @@ func doesSignatureMatch(hashedPayload string, r *http.Request, region string) AP
 	stringToSign := getStringToSign(canonicalRequest, t, signV4Values.Credential.getScope())
 
 	// Get hmac signing key.
-	signingKey := getSigningKey(cred.SecretKey, signV4Values.Credential.scope.date, region)
+	signingKey := getSigningKey(cred.SecretKey, signV4Values.Credential.scope.date, region, stype)
 
 	// Calculate signature.
 	newSignature := getSignature(signingKey, stringToSign)
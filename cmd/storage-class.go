@@ -0,0 +1,233 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	// AmzStorageClass selects the storage class of a new object.
+	AmzStorageClass = "X-Amz-Storage-Class"
+
+	// Supported storage classes.
+	standardStorageClass          = "STANDARD"
+	reducedRedundancyStorageClass = "REDUCED_REDUNDANCY"
+
+	// Scheme of the storage class parity, set as `EC:<parity>`.
+	storageClassSchemeEC = "EC"
+
+	// Minimum parity of a storage class.
+	minimumParity = 2
+
+	// Parity of the reduced redundancy storage class unless configured.
+	defaultRRSParity = 2
+)
+
+// errInvalidStorageClass - the storage class of a request is unknown.
+var errInvalidStorageClass = errors.New("Invalid storage class")
+
+// storageClass - parity of the objects of a storage class, a zero
+// parity selects the default parity of the class.
+type storageClass struct {
+	Scheme string
+	Parity int
+}
+
+// String - returns the storage class as `EC:<parity>`, or an empty
+// string for the default parity.
+func (sc storageClass) String() string {
+	if sc.Parity == 0 {
+		return ""
+	}
+	return sc.Scheme + ":" + strconv.Itoa(sc.Parity)
+}
+
+// MarshalJSON - encodes the storage class as `EC:<parity>`.
+func (sc storageClass) MarshalJSON() ([]byte, error) {
+	return json.Marshal(sc.String())
+}
+
+// UnmarshalJSON - decodes a storage class set as `EC:<parity>`.
+func (sc *storageClass) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	parsed, err := parseStorageClass(s)
+	if err != nil {
+		return err
+	}
+	*sc = parsed
+	return nil
+}
+
+// parseStorageClass - parses the parity of a storage class set as
+// `EC:<parity>`, an empty string selects the default parity.
+func parseStorageClass(s string) (storageClass, error) {
+	if s == "" {
+		return storageClass{}, nil
+	}
+	fields := strings.Split(s, ":")
+	if len(fields) != 2 || fields[0] != storageClassSchemeEC {
+		return storageClass{}, fmt.Errorf("Unsupported storage class ‘%s’, expected ‘%s:<parity>’", s, storageClassSchemeEC)
+	}
+	parity, err := strconv.Atoi(fields[1])
+	if err != nil || parity < minimumParity {
+		return storageClass{}, fmt.Errorf("Invalid parity ‘%s’ of storage class ‘%s’, at least %d parity disks are required", fields[1], s, minimumParity)
+	}
+	return storageClass{Scheme: storageClassSchemeEC, Parity: parity}, nil
+}
+
+// storageClassConfig - parity of the standard and reduced redundancy
+// storage classes of erasure coded setups.
+type storageClassConfig struct {
+	Standard storageClass `json:"standard"`
+	RRS      storageClass `json:"rrs"`
+}
+
+// Validate - checks that reduced redundancy objects don't have more
+// parity than standard objects. Parity limited by the number of disks
+// is checked by validateParity once the disks are known.
+func (c storageClassConfig) Validate() error {
+	if c.Standard.Parity != 0 && c.RRS.Parity > c.Standard.Parity {
+		return fmt.Errorf("Reduced redundancy storage class parity %d is more than standard storage class parity %d", c.RRS.Parity, c.Standard.Parity)
+	}
+	return nil
+}
+
+// lookupStorageClassEnv - returns the storage classes overridden by
+// MINIO_STORAGE_CLASS_STANDARD and MINIO_STORAGE_CLASS_RRS, the last
+// return value is false if neither is set.
+func lookupStorageClassEnv(cfg storageClassConfig) (storageClassConfig, bool, error) {
+	standard, rrs := os.Getenv("MINIO_STORAGE_CLASS_STANDARD"), os.Getenv("MINIO_STORAGE_CLASS_RRS")
+	if standard == "" && rrs == "" {
+		return cfg, false, nil
+	}
+	var err error
+	if standard != "" {
+		if cfg.Standard, err = parseStorageClass(standard); err != nil {
+			return cfg, false, err
+		}
+	}
+	if rrs != "" {
+		if cfg.RRS, err = parseStorageClass(rrs); err != nil {
+			return cfg, false, err
+		}
+	}
+	return cfg, true, cfg.Validate()
+}
+
+// getParity - returns the parity of objects of the storage class on
+// the given number of disks, standard objects default to half the
+// disks as parity.
+func getParity(class string, disks int, standard, rrs storageClass) int {
+	if class == reducedRedundancyStorageClass {
+		if rrs.Parity != 0 {
+			return rrs.Parity
+		}
+		return defaultRRSParity
+	}
+	if standard.Parity != 0 {
+		return standard.Parity
+	}
+	return disks / 2
+}
+
+// validateParity - checks the parity of the storage classes on the
+// given number of disks, parity may not be more than half the disks
+// and reduced redundancy parity not more than standard parity.
+func validateParity(disks int, standard, rrs storageClass) error {
+	standardParity := getParity(standardStorageClass, disks, standard, rrs)
+	rrsParity := getParity(reducedRedundancyStorageClass, disks, standard, rrs)
+	if standardParity > disks/2 {
+		return fmt.Errorf("Standard storage class parity %d should be less than or equal to %d", standardParity, disks/2)
+	}
+	if rrsParity > standardParity {
+		return fmt.Errorf("Reduced redundancy storage class parity %d should be less than or equal to %d", rrsParity, standardParity)
+	}
+	return nil
+}
+
+// getRedundancyCount - returns the number of data and parity blocks of
+// a new object of the storage class on the given number of disks.
+func getRedundancyCount(class string, disks int) (dataBlocks, parityBlocks int) {
+	parityBlocks = getParity(class, disks, globalStandardStorageClass, globalRRStorageClass)
+	return disks - parityBlocks, parityBlocks
+}
+
+// getObjectQuorum - returns the read and write quorum of an object
+// erasure coded in the given number of data and parity blocks. Reads
+// need any data blocks, writes need one more disk when data and parity
+// blocks are even to never have two versions with read quorum.
+func getObjectQuorum(dataBlocks, parityBlocks int) (readQuorum, writeQuorum int) {
+	readQuorum, writeQuorum = dataBlocks, dataBlocks
+	if dataBlocks == parityBlocks {
+		writeQuorum++
+	}
+	return readQuorum, writeQuorum
+}
+
+// objectQuorumFromMeta - returns the read and write quorum of an object
+// from the data and parity blocks in its latest metadata.
+func objectQuorumFromMeta(xl xlObjects, partsMetadata []xlMetaV1, errs []error) (readQuorum, writeQuorum int, err error) {
+	// Metadata of at least half the disks is needed to trust it.
+	if err = reduceReadQuorumErrs(errs, objectOpIgnoredErrs, xl.readQuorum); err != nil {
+		return 0, 0, err
+	}
+	modTime, _ := commonTime(listObjectModtimes(partsMetadata, errs))
+	xlMeta, err := pickValidXLMeta(partsMetadata, modTime)
+	if err != nil {
+		return 0, 0, err
+	}
+	readQuorum, writeQuorum = getObjectQuorum(xlMeta.Erasure.DataBlocks, xlMeta.Erasure.ParityBlocks)
+	return readQuorum, writeQuorum, nil
+}
+
+// isValidStorageClass - tells if the storage class is supported.
+func isValidStorageClass(class string) bool {
+	return class == standardStorageClass || class == reducedRedundancyStorageClass
+}
+
+// extractStorageClassFromHeader - saves the storage class of the
+// x-amz-storage-class header in the object metadata.
+func extractStorageClassFromHeader(header http.Header, metadata map[string]string) error {
+	if _, ok := header[AmzStorageClass]; !ok {
+		return nil
+	}
+	class := header.Get(AmzStorageClass)
+	if !isValidStorageClass(class) {
+		return errInvalidStorageClass
+	}
+	metadata[AmzStorageClass] = class
+	return nil
+}
+
+// getStorageClass - returns the storage class saved in the object
+// metadata, objects saved without one are standard objects.
+func getStorageClass(metadata map[string]string) string {
+	if class, ok := metadata[AmzStorageClass]; ok {
+		return class
+	}
+	return globalMinioDefaultStorageClass
+}
@@ -0,0 +1,329 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"os"
+	"testing"
+
+	humanize "github.com/dustin/go-humanize"
+)
+
+// Tests parsing storage classes set as `EC:<parity>`.
+func TestParseStorageClass(t *testing.T) {
+	testCases := []struct {
+		value      string
+		parity     int
+		shouldPass bool
+	}{
+		{"", 0, true},
+		{"EC:2", 2, true},
+		{"EC:8", 8, true},
+		{"EC:1", 0, false},
+		{"EC:0", 0, false},
+		{"EC:two", 0, false},
+		{"RS:2", 0, false},
+		{"EC:2:2", 0, false},
+		{"2", 0, false},
+	}
+	for i, testCase := range testCases {
+		sc, err := parseStorageClass(testCase.value)
+		if testCase.shouldPass && err != nil {
+			t.Errorf("Test %d: unexpected error %v", i+1, err)
+		}
+		if !testCase.shouldPass && err == nil {
+			t.Errorf("Test %d: expected an error", i+1)
+		}
+		if sc.Parity != testCase.parity {
+			t.Errorf("Test %d: expected parity %d, got %d", i+1, testCase.parity, sc.Parity)
+		}
+		if testCase.shouldPass && sc.String() != testCase.value {
+			t.Errorf("Test %d: expected %s, got %s", i+1, testCase.value, sc.String())
+		}
+	}
+}
+
+// Tests the storage class configuration in JSON.
+func TestStorageClassConfigJSON(t *testing.T) {
+	var cfg storageClassConfig
+	if err := json.Unmarshal([]byte(`{"standard":"EC:6","rrs":""}`), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Standard.Parity != 6 || cfg.RRS.Parity != 0 {
+		t.Fatalf("Unexpected storage classes %+v", cfg)
+	}
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"standard":"EC:6","rrs":""}` {
+		t.Fatalf("Unexpected storage classes %s", data)
+	}
+	if err = json.Unmarshal([]byte(`{"standard":"EC:1"}`), &cfg); err == nil {
+		t.Fatal("Expected an error for parity below the minimum")
+	}
+
+	cfg = storageClassConfig{Standard: storageClass{storageClassSchemeEC, 2}, RRS: storageClass{storageClassSchemeEC, 3}}
+	if err = cfg.Validate(); err == nil {
+		t.Fatal("Expected an error for reduced redundancy parity above standard parity")
+	}
+}
+
+// Tests the storage classes set in the environment.
+func TestLookupStorageClassEnv(t *testing.T) {
+	defer os.Unsetenv("MINIO_STORAGE_CLASS_STANDARD")
+	defer os.Unsetenv("MINIO_STORAGE_CLASS_RRS")
+
+	cfg, isEnv, err := lookupStorageClassEnv(storageClassConfig{})
+	if err != nil || isEnv {
+		t.Fatalf("Expected no storage classes in the environment, got %v %v", isEnv, err)
+	}
+
+	os.Setenv("MINIO_STORAGE_CLASS_STANDARD", "EC:4")
+	os.Setenv("MINIO_STORAGE_CLASS_RRS", "EC:2")
+	cfg, isEnv, err = lookupStorageClassEnv(storageClassConfig{})
+	if err != nil || !isEnv {
+		t.Fatalf("Expected storage classes in the environment, got %v %v", isEnv, err)
+	}
+	if cfg.Standard.Parity != 4 || cfg.RRS.Parity != 2 {
+		t.Fatalf("Unexpected storage classes %+v", cfg)
+	}
+
+	os.Setenv("MINIO_STORAGE_CLASS_RRS", "EC:6")
+	if _, _, err = lookupStorageClassEnv(storageClassConfig{}); err == nil {
+		t.Fatal("Expected an error for reduced redundancy parity above standard parity")
+	}
+	os.Setenv("MINIO_STORAGE_CLASS_RRS", "RRS")
+	if _, _, err = lookupStorageClassEnv(storageClassConfig{}); err == nil {
+		t.Fatal("Expected an error for an invalid storage class")
+	}
+}
+
+// Tests the parity of storage classes limited by the number of disks.
+func TestValidateParity(t *testing.T) {
+	ec := func(parity int) storageClass {
+		return storageClass{Scheme: storageClassSchemeEC, Parity: parity}
+	}
+	testCases := []struct {
+		disks         int
+		standard, rrs storageClass
+		shouldPass    bool
+	}{
+		{4, storageClass{}, storageClass{}, true},
+		{16, storageClass{}, storageClass{}, true},
+		{16, ec(8), ec(2), true},
+		{16, ec(4), storageClass{}, true},
+		{16, ec(2), storageClass{}, true},
+		{16, ec(9), storageClass{}, false},
+		{16, ec(4), ec(6), false},
+		{16, storageClass{}, ec(10), false},
+		{4, storageClass{}, ec(3), false},
+	}
+	for i, testCase := range testCases {
+		err := validateParity(testCase.disks, testCase.standard, testCase.rrs)
+		if testCase.shouldPass && err != nil {
+			t.Errorf("Test %d: unexpected error %v", i+1, err)
+		}
+		if !testCase.shouldPass && err == nil {
+			t.Errorf("Test %d: expected an error", i+1)
+		}
+	}
+}
+
+// Tests the data and parity blocks of new objects of each storage
+// class.
+func TestGetRedundancyCount(t *testing.T) {
+	defer func(standard, rrs storageClass) {
+		globalStandardStorageClass, globalRRStorageClass = standard, rrs
+	}(globalStandardStorageClass, globalRRStorageClass)
+
+	testCases := []struct {
+		standard, rrs      int
+		class              string
+		disks              int
+		data, parity       int
+		readQuorum, writeQ int
+	}{
+		{0, 0, "", 16, 8, 8, 8, 9},
+		{0, 0, standardStorageClass, 16, 8, 8, 8, 9},
+		{0, 0, reducedRedundancyStorageClass, 16, 14, 2, 14, 14},
+		{6, 0, standardStorageClass, 16, 10, 6, 10, 10},
+		{6, 4, reducedRedundancyStorageClass, 16, 12, 4, 12, 12},
+		{0, 0, reducedRedundancyStorageClass, 4, 2, 2, 2, 3},
+	}
+	for i, testCase := range testCases {
+		globalStandardStorageClass = storageClass{Scheme: storageClassSchemeEC, Parity: testCase.standard}
+		globalRRStorageClass = storageClass{Scheme: storageClassSchemeEC, Parity: testCase.rrs}
+		data, parity := getRedundancyCount(testCase.class, testCase.disks)
+		if data != testCase.data || parity != testCase.parity {
+			t.Errorf("Test %d: expected %d data and %d parity blocks, got %d and %d", i+1, testCase.data, testCase.parity, data, parity)
+		}
+		readQuorum, writeQuorum := getObjectQuorum(data, parity)
+		if readQuorum != testCase.readQuorum || writeQuorum != testCase.writeQ {
+			t.Errorf("Test %d: expected read quorum %d and write quorum %d, got %d and %d", i+1, testCase.readQuorum, testCase.writeQ, readQuorum, writeQuorum)
+		}
+	}
+}
+
+// Tests saving the storage class of the x-amz-storage-class header.
+func TestExtractStorageClassFromHeader(t *testing.T) {
+	testCases := []struct {
+		header     http.Header
+		class      string
+		shouldPass bool
+	}{
+		{http.Header{}, "", true},
+		{http.Header{AmzStorageClass: []string{standardStorageClass}}, standardStorageClass, true},
+		{http.Header{AmzStorageClass: []string{reducedRedundancyStorageClass}}, reducedRedundancyStorageClass, true},
+		{http.Header{AmzStorageClass: []string{"GLACIER"}}, "", false},
+		{http.Header{AmzStorageClass: []string{""}}, "", false},
+	}
+	for i, testCase := range testCases {
+		metadata := make(map[string]string)
+		err := extractStorageClassFromHeader(testCase.header, metadata)
+		if testCase.shouldPass && err != nil {
+			t.Errorf("Test %d: unexpected error %v", i+1, err)
+		}
+		if !testCase.shouldPass && err != errInvalidStorageClass {
+			t.Errorf("Test %d: expected %v, got %v", i+1, errInvalidStorageClass, err)
+		}
+		if metadata[AmzStorageClass] != testCase.class {
+			t.Errorf("Test %d: expected storage class %s, got %s", i+1, testCase.class, metadata[AmzStorageClass])
+		}
+	}
+}
+
+// Tests that objects are erasure coded with the parity of their
+// storage class, saved in `xl.json`.
+func TestXLStorageClass(t *testing.T) {
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatalf("Failed to initialize test config %v", err)
+	}
+	defer removeAll(rootPath)
+
+	defer func(standard, rrs storageClass) {
+		globalStandardStorageClass, globalRRStorageClass = standard, rrs
+	}(globalStandardStorageClass, globalRRStorageClass)
+	globalStandardStorageClass = storageClass{Scheme: storageClassSchemeEC, Parity: 6}
+	globalRRStorageClass = storageClass{}
+
+	obj, fsDirs, err := prepareXL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeRoots(fsDirs)
+	xl := obj.(*xlObjects)
+
+	bucket := "bucket"
+	if err = obj.MakeBucketWithLocation(bucket, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	data := make([]byte, 6*humanize.MiByte)
+	if _, err = rand.Read(data); err != nil {
+		t.Fatal(err)
+	}
+
+	// Verifies the erasure coding in `xl.json` and reads the object back.
+	checkObject := func(object, class string, dataBlocks, parityBlocks int) {
+		xlMeta, rErr := readXLMeta(xl.storageDisks[0], bucket, object)
+		if rErr != nil {
+			t.Fatal(rErr)
+		}
+		if xlMeta.Erasure.DataBlocks != dataBlocks || xlMeta.Erasure.ParityBlocks != parityBlocks {
+			t.Fatalf("%s: expected %d data and %d parity blocks, got %d and %d", object, dataBlocks, parityBlocks, xlMeta.Erasure.DataBlocks, xlMeta.Erasure.ParityBlocks)
+		}
+		if xlMeta.Meta[AmzStorageClass] != class {
+			t.Fatalf("%s: expected storage class %s, got %s", object, class, xlMeta.Meta[AmzStorageClass])
+		}
+		buf := &bytes.Buffer{}
+		if rErr = obj.GetObject(bucket, object, 0, int64(len(data)), buf); rErr != nil {
+			t.Fatal(rErr)
+		}
+		if !bytes.Equal(buf.Bytes(), data) {
+			t.Fatalf("%s: contents differ", object)
+		}
+	}
+
+	if _, err = obj.PutObject(bucket, "standard", int64(len(data)), bytes.NewReader(data), map[string]string{}, ""); err != nil {
+		t.Fatal(err)
+	}
+	checkObject("standard", "", 10, 6)
+
+	rrsMeta := map[string]string{AmzStorageClass: reducedRedundancyStorageClass}
+	if _, err = obj.PutObject(bucket, "rrs", int64(len(data)), bytes.NewReader(data), rrsMeta, ""); err != nil {
+		t.Fatal(err)
+	}
+	checkObject("rrs", reducedRedundancyStorageClass, 14, 2)
+
+	// Reduced redundancy objects still read with their parity disks offline.
+	for index := range xl.storageDisks[:2] {
+		xl.storageDisks[index] = newNaughtyDisk(xl.storageDisks[index].(*retryStorage), nil, errFaultyDisk)
+	}
+	buf := &bytes.Buffer{}
+	if err = obj.GetObject(bucket, "rrs", 0, int64(len(data)), buf); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), data) {
+		t.Fatal("Contents of the reduced redundancy object differ")
+	}
+	for index := range xl.storageDisks[:2] {
+		xl.storageDisks[index] = xl.storageDisks[index].(*naughtyDisk).disk
+	}
+
+	// Parts of multipart uploads are erasure coded like the upload.
+	uploadID, err := obj.NewMultipartUpload(bucket, "multipart", map[string]string{AmzStorageClass: reducedRedundancyStorageClass})
+	if err != nil {
+		t.Fatal(err)
+	}
+	partInfo, err := obj.PutObjectPart(bucket, "multipart", uploadID, 1, int64(len(data)), bytes.NewReader(data), "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	partsInfo, err := obj.ListObjectParts(bucket, "multipart", uploadID, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if class := generateListPartsResponse(partsInfo).StorageClass; class != reducedRedundancyStorageClass {
+		t.Fatalf("Expected storage class %s of the upload, got %s", reducedRedundancyStorageClass, class)
+	}
+	if _, err = obj.CompleteMultipartUpload(bucket, "multipart", uploadID, []completePart{{PartNumber: 1, ETag: partInfo.ETag}}); err != nil {
+		t.Fatal(err)
+	}
+	checkObject("multipart", reducedRedundancyStorageClass, 14, 2)
+
+	// Listings report the storage class of each object.
+	result, err := obj.ListObjects(bucket, "", "", "", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{
+		"multipart": reducedRedundancyStorageClass,
+		"rrs":       reducedRedundancyStorageClass,
+		"standard":  standardStorageClass,
+	}
+	for _, content := range generateListObjectsV1Response(bucket, "", "", "", 10, result).Contents {
+		if content.StorageClass != expected[content.Key] {
+			t.Errorf("%s: expected storage class %s, got %s", content.Key, expected[content.Key], content.StorageClass)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	router "github.com/gorilla/mux"
+)
+
+const (
+	// STS API version and actions.
+	stsAPIVersion = "2011-06-15"
+	stsAssumeRole = "AssumeRole"
+
+	// Maximum size of STS request bodies, which carry the session policy.
+	maxSTSRequestSize = maxAccessPolicySize
+)
+
+// assumeRoleResponse - response of AssumeRole.
+type assumeRoleResponse struct {
+	XMLName xml.Name `xml:"https://sts.amazonaws.com/doc/2011-06-15/ AssumeRoleResponse" json:"-"`
+
+	Result struct {
+		Credentials stsCredentials `xml:"Credentials"`
+	} `xml:"AssumeRoleResult"`
+	ResponseMetadata struct {
+		RequestID string `xml:"RequestId"`
+	} `xml:"ResponseMetadata"`
+}
+
+// stsErrorResponse - error response of the STS API, which differs from
+// the error response of the S3 API.
+type stsErrorResponse struct {
+	XMLName xml.Name `xml:"https://sts.amazonaws.com/doc/2011-06-15/ ErrorResponse" json:"-"`
+
+	Error struct {
+		Type    string `xml:"Type"`
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	} `xml:"Error"`
+	RequestID string `xml:"RequestId"`
+}
+
+// writeSTSErrorResponse - writes the STS error response of the error code.
+func writeSTSErrorResponse(w http.ResponseWriter, errorCode APIErrorCode) {
+	apiError := getAPIError(errorCode)
+	var errorResponse stsErrorResponse
+	errorResponse.Error.Type = "Sender"
+	if apiError.HTTPStatusCode >= http.StatusInternalServerError {
+		errorResponse.Error.Type = "Receiver"
+	}
+	errorResponse.Error.Code = apiError.Code
+	errorResponse.Error.Message = apiError.Description
+	errorResponse.RequestID = "3L137"
+	writeResponse(w, apiError.HTTPStatusCode, encodeResponse(errorResponse), mimeXML)
+}
+
+// stsAPIHandlers implements the STS API which issues temporary
+// credentials for IAM users.
+type stsAPIHandlers struct{}
+
+// isSTSRequest - STS requests are form encoded POST requests to `/`.
+func isSTSRequest(r *http.Request, rm *router.RouteMatch) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && mediaType == "application/x-www-form-urlencoded"
+}
+
+// registerSTSRouter - registers the STS API.
+func registerSTSRouter(mux *router.Router) {
+	sts := stsAPIHandlers{}
+
+	stsRouter := mux.NewRoute().PathPrefix("/").Subrouter()
+
+	// AssumeRole
+	stsRouter.Methods("POST").Path("/").MatcherFunc(isSTSRequest).HandlerFunc(sts.AssumeRoleHandler)
+}
+
+// AssumeRoleHandler - POST /
+// ----------
+// Issues temporary credentials for the IAM user signing the request,
+// the request is signed with signature V4 for the `sts` service. The
+// credentials are allowed the policies of the user, limited by the
+// optional session policy of the request.
+func (sts stsAPIHandlers) AssumeRoleHandler(w http.ResponseWriter, r *http.Request) {
+	if globalIAMSys == nil {
+		writeSTSErrorResponse(w, ErrServerNotInitialized)
+		return
+	}
+
+	// Error out if Content-Length is beyond allowed size.
+	if r.ContentLength > maxSTSRequestSize {
+		writeSTSErrorResponse(w, ErrEntityTooLarge)
+		return
+	}
+
+	// The form is part of the signed payload.
+	payload, err := ioutil.ReadAll(io.LimitReader(r.Body, maxSTSRequestSize))
+	if err != nil {
+		errorIf(err, "Unable to read STS request body.")
+		writeSTSErrorResponse(w, ErrInternalError)
+		return
+	}
+
+	if getRequestAuthType(r) != authTypeSigned {
+		writeSTSErrorResponse(w, ErrAccessDenied)
+		return
+	}
+	s3Error := doesSignatureMatch(getSHA256Hash(payload), r, serverConfig.GetRegion(), serviceSTS)
+	if s3Error != ErrNone {
+		errorIf(errSignatureMismatch, "%s", dumpRequest(r))
+		writeSTSErrorResponse(w, s3Error)
+		return
+	}
+
+	form, err := url.ParseQuery(string(payload))
+	if err != nil {
+		writeSTSErrorResponse(w, ErrMalformedPOSTRequest)
+		return
+	}
+	if form.Get("Action") != stsAssumeRole || form.Get("Version") != stsAPIVersion {
+		writeSTSErrorResponse(w, ErrNotImplemented)
+		return
+	}
+
+	// Temporary credentials are issued for IAM users only, neither
+	// for the server credential nor for temporary credentials.
+	accessKey := getReqAccessKey(r)
+	if accessKey == serverConfig.GetCredential().AccessKey || getReqSessionToken(r) != "" {
+		writeSTSErrorResponse(w, ErrAccessDenied)
+		return
+	}
+
+	duration := defaultSTSDuration
+	if durationStr := form.Get("DurationSeconds"); durationStr != "" {
+		seconds, err := strconv.Atoi(durationStr)
+		if err != nil {
+			writeSTSErrorResponse(w, ErrInvalidDuration)
+			return
+		}
+		duration = time.Duration(seconds) * time.Second
+	}
+
+	var policy *iamPolicy
+	if policyStr := form.Get("Policy"); policyStr != "" {
+		if policy, err = parseIAMPolicy(strings.NewReader(policyStr)); err != nil {
+			writeSTSErrorResponse(w, ErrMalformedPolicy)
+			return
+		}
+	}
+
+	creds, err := newSTSCredentials(accessKey, duration, policy)
+	if err != nil {
+		if err == errInvalidSTSDuration {
+			writeSTSErrorResponse(w, ErrInvalidDuration)
+			return
+		}
+		errorIf(err, "Unable to issue temporary credentials.")
+		writeSTSErrorResponse(w, ErrInternalError)
+		return
+	}
+
+	var response assumeRoleResponse
+	response.Result.Credentials = creds
+	response.ResponseMetadata.RequestID = "3L137"
+	writeSuccessResponseXML(w, encodeResponse(response))
+}
@@ -0,0 +1,147 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	jwtgo "github.com/dgrijalva/jwt-go"
+)
+
+const (
+	// Header, query parameter and form field carrying the session
+	// token of temporary credentials.
+	amzSecurityToken = "X-Amz-Security-Token"
+
+	// Issuer of session tokens, also used to derive the key which
+	// signs them so that they can not be used as web tokens.
+	stsIssuer = "minio-sts"
+
+	// Temporary credentials are valid for one hour by default, for
+	// at least 15 minutes and at most 12 hours.
+	defaultSTSDuration = time.Hour
+	minSTSDuration     = 15 * time.Minute
+	maxSTSDuration     = 12 * time.Hour
+)
+
+var errInvalidSTSDuration = errors.New("Duration of temporary credentials should be between 15 minutes and 12 hours")
+
+// sessionClaims - claims of the session token of temporary credentials,
+// the subject is the access key of the user the credentials were issued
+// for.
+type sessionClaims struct {
+	jwtgo.StandardClaims
+	AccessKey string `json:"accessKey"`
+	// Optional session policy, limits the policies of the user.
+	Policy *iamPolicy `json:"policy,omitempty"`
+}
+
+// stsCredentials - temporary credentials issued by AssumeRole.
+type stsCredentials struct {
+	AccessKey    string    `xml:"AccessKeyId"`
+	SecretKey    string    `xml:"SecretAccessKey"`
+	SessionToken string    `xml:"SessionToken"`
+	Expiration   time.Time `xml:"Expiration"`
+}
+
+// getSTSSigningKey - returns the key signing session tokens, derived
+// from the server credential.
+func getSTSSigningKey() []byte {
+	return sumHMAC([]byte(serverConfig.GetCredential().SecretKey), []byte(stsIssuer))
+}
+
+// stsKeyFuncCallback - returns the key verifying session tokens.
+func stsKeyFuncCallback(jwtToken *jwtgo.Token) (interface{}, error) {
+	if _, ok := jwtToken.Method.(*jwtgo.SigningMethodHMAC); !ok {
+		return nil, fmt.Errorf("Unexpected signing method: %v", jwtToken.Header["alg"])
+	}
+	return getSTSSigningKey(), nil
+}
+
+// getSessionSecretKey - returns the secret key of temporary credentials,
+// derived from the server credential and the session token so that it
+// never needs to be stored.
+func getSessionSecretKey(sessionToken string) string {
+	key := sumHMAC([]byte(serverConfig.GetCredential().SecretKey), []byte(sessionToken))
+	return base64.RawURLEncoding.EncodeToString(key)[:secretKeyMaxLenMinio]
+}
+
+// newSTSCredentials - issues temporary credentials for the user, valid
+// for the duration and limited by the optional session policy.
+func newSTSCredentials(accessKey string, duration time.Duration, policy *iamPolicy) (stsCredentials, error) {
+	if duration < minSTSDuration || duration > maxSTSDuration {
+		return stsCredentials{}, errInvalidSTSDuration
+	}
+
+	// Generate access key of the temporary credentials.
+	keyBytes := make([]byte, accessKeyMaxLen)
+	if _, err := rand.Read(keyBytes); err != nil {
+		return stsCredentials{}, err
+	}
+	for i := range keyBytes {
+		keyBytes[i] = alphaNumericTable[keyBytes[i]%alphaNumericTableLen]
+	}
+
+	utcNow := UTCNow()
+	expiration := utcNow.Add(duration)
+	token := jwtgo.NewWithClaims(jwtgo.SigningMethodHS512, sessionClaims{
+		StandardClaims: jwtgo.StandardClaims{
+			ExpiresAt: expiration.Unix(),
+			IssuedAt:  utcNow.Unix(),
+			Issuer:    stsIssuer,
+			Subject:   accessKey,
+		},
+		AccessKey: string(keyBytes),
+		Policy:    policy,
+	})
+	sessionToken, err := token.SignedString(getSTSSigningKey())
+	if err != nil {
+		return stsCredentials{}, err
+	}
+
+	return stsCredentials{
+		AccessKey:    string(keyBytes),
+		SecretKey:    getSessionSecretKey(sessionToken),
+		SessionToken: sessionToken,
+		Expiration:   time.Unix(expiration.Unix(), 0).UTC(),
+	}, nil
+}
+
+// parseSessionToken - verifies the session token of the temporary
+// access key and returns its claims. Temporary credentials are only
+// valid as long as their user is enabled.
+func parseSessionToken(accessKey, sessionToken string) (*sessionClaims, APIErrorCode) {
+	claims := &sessionClaims{}
+	jwtToken, err := jwtgo.ParseWithClaims(sessionToken, claims, stsKeyFuncCallback)
+	if err != nil {
+		if vErr, ok := err.(*jwtgo.ValidationError); ok && vErr.Errors&jwtgo.ValidationErrorExpired != 0 {
+			return nil, ErrExpiredToken
+		}
+		return nil, ErrInvalidToken
+	}
+	if !jwtToken.Valid || claims.Issuer != stsIssuer || claims.AccessKey != accessKey {
+		return nil, ErrInvalidToken
+	}
+	if _, ok := globalIAMSys.GetUserCredential(claims.Subject); !ok {
+		return nil, ErrInvalidToken
+	}
+	return claims, ErrNone
+}
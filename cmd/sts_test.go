@@ -0,0 +1,291 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	jwtgo "github.com/dgrijalva/jwt-go"
+	router "github.com/gorilla/mux"
+)
+
+// Tests issuing and verifying temporary credentials.
+func TestSTSCredentials(t *testing.T) {
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatalf("Init Test config failed")
+	}
+	defer removeAll(rootPath)
+
+	defer func(sys *iamSys) { globalIAMSys = sys }(globalIAMSys)
+	config := newIAMConfig()
+	config.Users["ciuser"] = iamUser{SecretKey: "ciuser-secret", Status: iamUserEnabled, Policy: "readwrite"}
+	config.Users["disabled"] = iamUser{SecretKey: "disabled-secret", Status: iamUserDisabled, Policy: "readwrite"}
+	globalIAMSys = &iamSys{config: config}
+
+	// Durations out of range.
+	for _, duration := range []time.Duration{time.Minute, 13 * time.Hour} {
+		if _, err = newSTSCredentials("ciuser", duration, nil); err != errInvalidSTSDuration {
+			t.Fatalf("Expected %v for duration %s, got %v", errInvalidSTSDuration, duration, err)
+		}
+	}
+
+	creds, err := newSTSCredentials("ciuser", time.Hour, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cred, s3Err := checkSessionKeyValid(creds.AccessKey, creds.SessionToken)
+	if s3Err != ErrNone {
+		t.Fatalf("Expected valid temporary credentials, got %v", s3Err)
+	}
+	if cred.SecretKey != creds.SecretKey {
+		t.Fatalf("Expected secret key %s, got %s", creds.SecretKey, cred.SecretKey)
+	}
+
+	// Session tokens can not be used as web tokens.
+	if isAuthTokenValid(creds.SessionToken) {
+		t.Fatal("Expected session token to be invalid as web token")
+	}
+	webToken, err := authenticateWeb(serverConfig.GetCredential().AccessKey, serverConfig.GetCredential().SecretKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Session token expired an hour ago.
+	expiredToken, err := jwtgo.NewWithClaims(jwtgo.SigningMethodHS512, sessionClaims{
+		StandardClaims: jwtgo.StandardClaims{
+			ExpiresAt: UTCNow().Add(-time.Hour).Unix(),
+			Issuer:    stsIssuer,
+			Subject:   "ciuser",
+		},
+		AccessKey: creds.AccessKey,
+	}).SignedString(getSTSSigningKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	disabledCreds, err := newSTSCredentials("ciuser", time.Hour, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.Users["ciuser"] = iamUser{SecretKey: "ciuser-secret", Status: iamUserDisabled, Policy: "readwrite"}
+
+	testCases := []struct {
+		accessKey    string
+		sessionToken string
+		expectedErr  APIErrorCode
+	}{
+		// Token of other temporary credentials.
+		{"UNKNOWNACCESSKEY", creds.SessionToken, ErrInvalidToken},
+		// Tampered token.
+		{creds.AccessKey, creds.SessionToken + "x", ErrInvalidToken},
+		// Web token.
+		{creds.AccessKey, webToken, ErrInvalidToken},
+		// Expired token.
+		{creds.AccessKey, expiredToken, ErrExpiredToken},
+		// User was disabled.
+		{disabledCreds.AccessKey, disabledCreds.SessionToken, ErrInvalidToken},
+	}
+	for i, testCase := range testCases {
+		if _, s3Err = checkSessionKeyValid(testCase.accessKey, testCase.sessionToken); s3Err != testCase.expectedErr {
+			t.Errorf("Test %d: Expected %v, got %v", i+1, testCase.expectedErr, s3Err)
+		}
+	}
+}
+
+// Wrapper for calling AssumeRole tests for both XL and FS.
+func TestAssumeRoleHandler(t *testing.T) {
+	// initialize NSLock.
+	initNSLock(false)
+
+	ExecObjectLayerTest(t, testAssumeRoleHandler)
+}
+
+// Tests issuing temporary credentials and using them for S3 requests.
+func testAssumeRoleHandler(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	globalObjLayerMutex.Lock()
+	globalObjectAPI = obj
+	globalObjLayerMutex.Unlock()
+	defer func() {
+		globalObjLayerMutex.Lock()
+		globalObjectAPI = nil
+		globalObjLayerMutex.Unlock()
+	}()
+
+	defer func(peers s3Peers) { globalS3Peers = peers }(globalS3Peers)
+	globalS3Peers = makeS3Peers(EndpointList{})
+
+	if err := initIAMSys(obj); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if err := globalIAMSys.SetUser(obj, "ciuser", "ciuser-secret", ""); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if err := globalIAMSys.SetUserPolicy(obj, "ciuser", "readwrite"); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+
+	bucket := "sts-bucket"
+	if err := obj.MakeBucketWithLocation(bucket, ""); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	data := []byte("sts")
+	if _, err := obj.PutObject(bucket, "object", int64(len(data)), bytes.NewReader(data), nil, ""); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+
+	mux := router.NewRouter().SkipClean(true)
+	registerSTSRouter(mux)
+	registerAPIRouter(mux)
+
+	assumeRole := func(accessKey, secretKey string, stype serviceType, form url.Values) *httptest.ResponseRecorder {
+		body := []byte(form.Encode())
+		req, err := newTestRequest("POST", "http://127.0.0.1:9000/", int64(len(body)), bytes.NewReader(body))
+		if err != nil {
+			t.Fatalf("%s: %v", instanceType, err)
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if err = signRequestV4Service(req, accessKey, secretKey, stype); err != nil {
+			t.Fatalf("%s: %v", instanceType, err)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+	mkForm := func(params ...string) url.Values {
+		form := url.Values{"Action": {stsAssumeRole}, "Version": {stsAPIVersion}}
+		for i := 0; i+1 < len(params); i += 2 {
+			form.Set(params[i], params[i+1])
+		}
+		return form
+	}
+
+	rootCred := serverConfig.GetCredential()
+	testCases := []struct {
+		accessKey      string
+		secretKey      string
+		stype          serviceType
+		form           url.Values
+		expectedStatus int
+	}{
+		// Not issued for the server credential.
+		{rootCred.AccessKey, rootCred.SecretKey, serviceSTS, mkForm(), http.StatusForbidden},
+		// Signed for the wrong service.
+		{"ciuser", "ciuser-secret", serviceS3, mkForm(), http.StatusBadRequest},
+		// Wrong secret key.
+		{"ciuser", "ciuser-secret-wrong", serviceSTS, mkForm(), http.StatusForbidden},
+		// Unsupported action.
+		{"ciuser", "ciuser-secret", serviceSTS, url.Values{"Action": {"GetSessionToken"}, "Version": {stsAPIVersion}}, http.StatusNotImplemented},
+		// Invalid durations.
+		{"ciuser", "ciuser-secret", serviceSTS, mkForm("DurationSeconds", "60"), http.StatusBadRequest},
+		{"ciuser", "ciuser-secret", serviceSTS, mkForm("DurationSeconds", "one"), http.StatusBadRequest},
+		// Malformed session policy.
+		{"ciuser", "ciuser-secret", serviceSTS, mkForm("Policy", "{}"), http.StatusBadRequest},
+		// Valid request.
+		{"ciuser", "ciuser-secret", serviceSTS, mkForm("DurationSeconds", "900"), http.StatusOK},
+	}
+	for i, testCase := range testCases {
+		rec := assumeRole(testCase.accessKey, testCase.secretKey, testCase.stype, testCase.form)
+		if rec.Code != testCase.expectedStatus {
+			t.Errorf("%s: Test %d: Expected status %d, got %d: %s", instanceType, i+1,
+				testCase.expectedStatus, rec.Code, rec.Body.String())
+		}
+	}
+
+	// Issue credentials limited to reading objects.
+	sessionPolicy := `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":["s3:GetObject"],"Resource":["arn:aws:s3:::` + bucket + `/*"]}]}`
+	rec := assumeRole("ciuser", "ciuser-secret", serviceSTS, mkForm("Policy", sessionPolicy))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected to succeed but failed with %d: %s", instanceType, rec.Code, rec.Body.String())
+	}
+	var response assumeRoleResponse
+	if err := xml.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	creds := response.Result.Credentials
+	if creds.AccessKey == "" || creds.SecretKey == "" || creds.SessionToken == "" {
+		t.Fatalf("%s: Expected temporary credentials, got %v", instanceType, creds)
+	}
+	if creds.Expiration.Sub(UTCNow()) > defaultSTSDuration {
+		t.Fatalf("%s: Expected expiration within %s, got %s", instanceType, defaultSTSDuration, creds.Expiration)
+	}
+
+	sendRequest := func(method, path string, withToken, presign bool) *httptest.ResponseRecorder {
+		req, err := newTestRequest(method, "http://127.0.0.1:9000"+path, 0, nil)
+		if err != nil {
+			t.Fatalf("%s: %v", instanceType, err)
+		}
+		if presign {
+			if withToken {
+				req.URL.RawQuery = url.Values{amzSecurityToken: {creds.SessionToken}}.Encode()
+			}
+			err = preSignV4(req, creds.AccessKey, creds.SecretKey, 60)
+		} else {
+			if withToken {
+				req.Header.Set(amzSecurityToken, creds.SessionToken)
+			}
+			err = signRequestV4(req, creds.AccessKey, creds.SecretKey)
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", instanceType, err)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	s3TestCases := []struct {
+		method         string
+		path           string
+		withToken      bool
+		presign        bool
+		expectedStatus int
+	}{
+		// Allowed by the session policy.
+		{"GET", "/" + bucket + "/object", true, false, http.StatusOK},
+		{"GET", "/" + bucket + "/object", true, true, http.StatusOK},
+		// Allowed to the user, not by the session policy.
+		{"PUT", "/" + bucket + "/object", true, false, http.StatusForbidden},
+		{"GET", "/" + bucket, true, false, http.StatusForbidden},
+		// Temporary credentials without session token.
+		{"GET", "/" + bucket + "/object", false, false, http.StatusForbidden},
+		{"GET", "/" + bucket + "/object", false, true, http.StatusForbidden},
+	}
+	for i, testCase := range s3TestCases {
+		rec = sendRequest(testCase.method, testCase.path, testCase.withToken, testCase.presign)
+		if rec.Code != testCase.expectedStatus {
+			t.Errorf("%s: Test %d: Expected status %d, got %d: %s", instanceType, i+1,
+				testCase.expectedStatus, rec.Code, rec.Body.String())
+		}
+	}
+
+	// Temporary credentials are not valid once the user is disabled.
+	if err := globalIAMSys.SetUserStatus(obj, "ciuser", iamUserDisabled); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	rec = sendRequest("GET", "/"+bucket+"/object", true, false)
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "InvalidToken") {
+		t.Fatalf("%s: Expected InvalidToken, got %d: %s", instanceType, rec.Code, rec.Body.String())
+	}
+}
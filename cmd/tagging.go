@@ -0,0 +1,245 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	humanize "github.com/dustin/go-humanize"
+)
+
+const (
+	// AmzTagging carries the tags of a new object, URL query encoded.
+	AmzTagging = "X-Amz-Tagging"
+	// AmzTaggingDirective selects whether CopyObject copies the tags of
+	// the source object or replaces them with the tags of the request.
+	AmzTaggingDirective = "X-Amz-Tagging-Directive"
+	// AmzTaggingCount reports the number of tags of an object.
+	AmzTaggingCount = "X-Amz-Tagging-Count"
+
+	// ObjectTagging is the metadata key of the tags of an object,
+	// the tags are saved URL query encoded.
+	ObjectTagging = ReservedMetadataPrefix + "Tagging"
+
+	// Maximum number of tags of an object and of a bucket.
+	maxObjectTags = 10
+	maxBucketTags = 50
+
+	// Maximum length of a tag key and of a tag value.
+	maxTagKeyLength   = 128
+	maxTagValueLength = 256
+
+	// Maximum supported tagging request size.
+	maxTaggingSize = 20 * humanize.KiByte
+)
+
+// Tagging errors.
+var (
+	errTooManyTags             = errors.New("Too many tags")
+	errInvalidTagKey           = errors.New("The tag key is invalid")
+	errInvalidTagValue         = errors.New("The tag value is invalid")
+	errDuplicateTagKey         = errors.New("Cannot provide multiple tags with the same key")
+	errInvalidTaggingDirective = errors.New("Unknown tagging directive")
+)
+
+// tag - a single key value pair.
+type tag struct {
+	Key   string `xml:"Key"`
+	Value string `xml:"Value"`
+}
+
+// Validate - validates a tag, keys prefixed with `aws:` are
+// reserved.
+func (t tag) Validate() error {
+	if t.Key == "" || len(t.Key) > maxTagKeyLength || strings.HasPrefix(t.Key, "aws:") {
+		return errInvalidTagKey
+	}
+	if len(t.Value) > maxTagValueLength {
+		return errInvalidTagValue
+	}
+	return nil
+}
+
+// tagging - represents the tag set of an object or bucket as sent by
+// the PutObjectTagging and PutBucketTagging APIs.
+type tagging struct {
+	XMLName xml.Name `xml:"Tagging"`
+	TagSet  []tag    `xml:"TagSet>Tag"`
+}
+
+// Validate - validates a tag set of at most maxTags tags.
+func (t tagging) Validate(maxTags int) error {
+	return validateTags(t.TagSet, maxTags)
+}
+
+// Map - returns the tags as a map of keys to values.
+func (t tagging) Map() map[string]string {
+	tags := make(map[string]string, len(t.TagSet))
+	for _, tag := range t.TagSet {
+		tags[tag.Key] = tag.Value
+	}
+	return tags
+}
+
+// validateTags - validates a list of at most maxTags unique tags.
+func validateTags(tags []tag, maxTags int) error {
+	if len(tags) > maxTags {
+		return errTooManyTags
+	}
+	keys := make(map[string]struct{}, len(tags))
+	for _, tag := range tags {
+		if err := tag.Validate(); err != nil {
+			return err
+		}
+		if _, ok := keys[tag.Key]; ok {
+			return errDuplicateTagKey
+		}
+		keys[tag.Key] = struct{}{}
+	}
+	return nil
+}
+
+// parseTagging - parses and validates a tag set of at most maxTags tags.
+func parseTagging(reader io.Reader, maxTags int) (*tagging, error) {
+	t := &tagging{}
+	if err := xml.NewDecoder(reader).Decode(t); err != nil {
+		return nil, err
+	}
+	if err := t.Validate(maxTags); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// parseTaggingHeader - parses and validates the URL query encoded
+// tags of the x-amz-tagging header.
+func parseTaggingHeader(value string) (map[string]string, error) {
+	values, err := url.ParseQuery(value)
+	if err != nil {
+		return nil, errInvalidTagKey
+	}
+	tags := make([]tag, 0, len(values))
+	for key, vals := range values {
+		if len(vals) != 1 {
+			return nil, errDuplicateTagKey
+		}
+		tags = append(tags, tag{Key: key, Value: vals[0]})
+	}
+	if err = validateTags(tags, maxObjectTags); err != nil {
+		return nil, err
+	}
+	return tagging{TagSet: tags}.Map(), nil
+}
+
+// getRequestTags - returns the tags of the x-amz-tagging header, the
+// tags are nil if the header is not set or invalid.
+func getRequestTags(header http.Header) map[string]string {
+	if _, ok := header[AmzTagging]; !ok {
+		return nil
+	}
+	tags, err := parseTaggingHeader(header.Get(AmzTagging))
+	if err != nil {
+		return nil
+	}
+	return tags
+}
+
+// extractTagsFromHeader - saves the tags of the x-amz-tagging header in
+// the object metadata.
+func extractTagsFromHeader(header http.Header, metadata map[string]string) error {
+	if _, ok := header[AmzTagging]; !ok {
+		return nil
+	}
+	tags, err := parseTaggingHeader(header.Get(AmzTagging))
+	if err != nil {
+		return err
+	}
+	setObjectTags(metadata, tags)
+	return nil
+}
+
+// encodeTags - encodes tags in the format of the x-amz-tagging header.
+func encodeTags(tags map[string]string) string {
+	values := make(url.Values, len(tags))
+	for key, value := range tags {
+		values.Set(key, value)
+	}
+	return values.Encode()
+}
+
+// getObjectTags - returns the tags saved in the object metadata.
+func getObjectTags(metadata map[string]string) map[string]string {
+	values, err := url.ParseQuery(metadata[ObjectTagging])
+	if err != nil || len(values) == 0 {
+		return nil
+	}
+	tags := make(map[string]string, len(values))
+	for key := range values {
+		tags[key] = values.Get(key)
+	}
+	return tags
+}
+
+// setObjectTags - saves the tags in the object metadata, empty tags
+// remove any previous tags.
+func setObjectTags(metadata map[string]string, tags map[string]string) {
+	if len(tags) == 0 {
+		delete(metadata, ObjectTagging)
+		return
+	}
+	metadata[ObjectTagging] = encodeTags(tags)
+}
+
+// toTagging - converts tags into a tag set sorted by key.
+func toTagging(tags map[string]string) tagging {
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	t := tagging{TagSet: make([]tag, 0, len(keys))}
+	for _, key := range keys {
+		t.TagSet = append(t.TagSet, tag{Key: key, Value: tags[key]})
+	}
+	return t
+}
+
+// setTaggingCountHeader - reports the number of tags of an object.
+func setTaggingCountHeader(w http.ResponseWriter, metadata map[string]string) {
+	if tags := getObjectTags(metadata); len(tags) > 0 {
+		w.Header().Set(AmzTaggingCount, strconv.Itoa(len(tags)))
+	}
+}
+
+// isTaggingReplace - returns true if the tags of a copied object are
+// replaced with the tags of the request.
+func isTaggingReplace(header http.Header) (bool, error) {
+	switch header.Get(AmzTaggingDirective) {
+	case "", "COPY":
+		return false, nil
+	case "REPLACE":
+		return true, nil
+	}
+	return false, errInvalidTaggingDirective
+}
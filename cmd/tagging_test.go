@@ -0,0 +1,195 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Tests parsing and validation of tag sets.
+func TestParseTagging(t *testing.T) {
+	testCases := []struct {
+		tagging     string
+		maxTags     int
+		expectedErr error
+	}{
+		{`<Tagging><TagSet><Tag><Key>project</Key><Value>alpha</Value></Tag></TagSet></Tagging>`, maxObjectTags, nil},
+		{`<Tagging><TagSet><Tag><Key>project</Key><Value></Value></Tag></TagSet></Tagging>`, maxObjectTags, nil},
+		{`<Tagging><TagSet></TagSet></Tagging>`, maxObjectTags, nil},
+		{`<Tagging><TagSet><Tag><Key>a</Key><Value>1</Value></Tag><Tag><Key>b</Key><Value>2</Value></Tag></TagSet></Tagging>`, 1, errTooManyTags},
+		{`<Tagging><TagSet><Tag><Key></Key><Value>alpha</Value></Tag></TagSet></Tagging>`, maxObjectTags, errInvalidTagKey},
+		{`<Tagging><TagSet><Tag><Key>aws:project</Key><Value>alpha</Value></Tag></TagSet></Tagging>`, maxObjectTags, errInvalidTagKey},
+		{`<Tagging><TagSet><Tag><Key>` + strings.Repeat("k", maxTagKeyLength+1) + `</Key><Value>alpha</Value></Tag></TagSet></Tagging>`, maxObjectTags, errInvalidTagKey},
+		{`<Tagging><TagSet><Tag><Key>project</Key><Value>` + strings.Repeat("v", maxTagValueLength+1) + `</Value></Tag></TagSet></Tagging>`, maxObjectTags, errInvalidTagValue},
+		{`<Tagging><TagSet><Tag><Key>a</Key><Value>1</Value></Tag><Tag><Key>a</Key><Value>2</Value></Tag></TagSet></Tagging>`, maxObjectTags, errDuplicateTagKey},
+	}
+
+	for i, testCase := range testCases {
+		_, err := parseTagging(strings.NewReader(testCase.tagging), testCase.maxTags)
+		if err != testCase.expectedErr {
+			t.Errorf("Test %d: Expected error %v, got %v", i+1, testCase.expectedErr, err)
+		}
+	}
+}
+
+// Tests parsing of the x-amz-tagging header.
+func TestParseTaggingHeader(t *testing.T) {
+	testCases := []struct {
+		header       string
+		expectedTags map[string]string
+		expectedErr  error
+	}{
+		{"project=alpha&retention=short", map[string]string{"project": "alpha", "retention": "short"}, nil},
+		{"project=alpha%20beta", map[string]string{"project": "alpha beta"}, nil},
+		{"project=", map[string]string{"project": ""}, nil},
+		{"project=alpha&project=beta", nil, errDuplicateTagKey},
+		{"aws:project=alpha", nil, errInvalidTagKey},
+		{"a=1&b=2&c=3&d=4&e=5&f=6&g=7&h=8&i=9&j=10&k=11", nil, errTooManyTags},
+	}
+
+	for i, testCase := range testCases {
+		tags, err := parseTaggingHeader(testCase.header)
+		if err != testCase.expectedErr {
+			t.Errorf("Test %d: Expected error %v, got %v", i+1, testCase.expectedErr, err)
+			continue
+		}
+		if err == nil && !reflect.DeepEqual(tags, testCase.expectedTags) {
+			t.Errorf("Test %d: Expected tags %v, got %v", i+1, testCase.expectedTags, tags)
+		}
+	}
+}
+
+// Tests saving tags in object metadata.
+func TestObjectTagsMetadata(t *testing.T) {
+	metadata := map[string]string{"content-type": "text/plain"}
+	header := http.Header{}
+	header.Set(AmzTagging, "project=alpha&retention=short")
+	if err := extractTagsFromHeader(header, metadata); err != nil {
+		t.Fatal(err)
+	}
+	expectedTags := map[string]string{"project": "alpha", "retention": "short"}
+	if tags := getObjectTags(metadata); !reflect.DeepEqual(tags, expectedTags) {
+		t.Fatalf("Expected tags %v, got %v", expectedTags, tags)
+	}
+
+	// Tags are reported sorted by key.
+	expectedTagging := tagging{TagSet: []tag{{"project", "alpha"}, {"retention", "short"}}}
+	if tagging := toTagging(getObjectTags(metadata)); !reflect.DeepEqual(tagging, expectedTagging) {
+		t.Fatalf("Expected tagging %v, got %v", expectedTagging, tagging)
+	}
+
+	// Empty tags remove the tags.
+	setObjectTags(metadata, nil)
+	if _, ok := metadata[ObjectTagging]; ok {
+		t.Fatal("Expected tags to be removed")
+	}
+	if len(metadata) != 1 {
+		t.Fatalf("Expected other metadata to be kept, got %v", metadata)
+	}
+}
+
+// Tests validation of the x-amz-tagging-directive header.
+func TestIsTaggingReplace(t *testing.T) {
+	testCases := []struct {
+		directive       string
+		expectedReplace bool
+		expectedErr     error
+	}{
+		{"", false, nil},
+		{"COPY", false, nil},
+		{"REPLACE", true, nil},
+		{"replace", false, errInvalidTaggingDirective},
+	}
+
+	for i, testCase := range testCases {
+		header := http.Header{}
+		if testCase.directive != "" {
+			header.Set(AmzTaggingDirective, testCase.directive)
+		}
+		replace, err := isTaggingReplace(header)
+		if err != testCase.expectedErr {
+			t.Errorf("Test %d: Expected error %v, got %v", i+1, testCase.expectedErr, err)
+		}
+		if replace != testCase.expectedReplace {
+			t.Errorf("Test %d: Expected replace %v, got %v", i+1, testCase.expectedReplace, replace)
+		}
+	}
+}
+
+// Wrapper for calling object tagging tests for both XL and FS.
+func TestSetObjectTagging(t *testing.T) {
+	ExecObjectLayerTest(t, testSetObjectTagging)
+}
+
+// Tests that tags are replaced without changing the object data.
+func testSetObjectTagging(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	bucket, object := "tagging-bucket", "object"
+	if err := obj.MakeBucketWithLocation(bucket, ""); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	data := []byte("tagged data")
+	objInfo, err := obj.PutObject(bucket, object, int64(len(data)), bytes.NewReader(data),
+		map[string]string{"content-type": "text/plain", ObjectTagging: "project=alpha"}, "")
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+
+	tags := map[string]string{"project": "beta", "retention": "long"}
+	newInfo, err := setObjectTagging(obj, objInfo, tags)
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if newInfo.ETag != objInfo.ETag {
+		t.Fatalf("%s: Expected etag %s, got %s", instanceType, objInfo.ETag, newInfo.ETag)
+	}
+
+	objInfo, err = obj.GetObjectInfo(bucket, object)
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if got := getObjectTags(objInfo.UserDefined); !reflect.DeepEqual(got, tags) {
+		t.Fatalf("%s: Expected tags %v, got %v", instanceType, tags, got)
+	}
+	if objInfo.ContentType != "text/plain" {
+		t.Fatalf("%s: Expected content type to be kept, got %s", instanceType, objInfo.ContentType)
+	}
+	var buffer bytes.Buffer
+	if err = obj.GetObject(bucket, object, 0, objInfo.Size, &buffer); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if !bytes.Equal(buffer.Bytes(), data) {
+		t.Fatalf("%s: Object data changed by tagging", instanceType)
+	}
+
+	// Removing the tags keeps the other metadata.
+	if _, err = setObjectTagging(obj, objInfo, nil); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if objInfo, err = obj.GetObjectInfo(bucket, object); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if _, ok := objInfo.UserDefined[ObjectTagging]; ok {
+		t.Fatalf("%s: Expected tags to be removed", instanceType)
+	}
+	if objInfo.ContentType != "text/plain" {
+		t.Fatalf("%s: Expected content type to be kept, got %s", instanceType, objInfo.ContentType)
+	}
+}
@@ -457,6 +457,8 @@ func resetGlobalIsEnvs() {
 	globalIsEnvCreds = false
 	globalIsEnvBrowser = false
 	globalIsEnvRegion = false
+	globalIsEnvDomains = false
+	globalDomains = nil
 }
 
 // Resets all the globals used modified in tests.
@@ -863,7 +865,7 @@ func preSignV4(req *http.Request, accessKeyID, secretAccessKey string, expires i
 	queryStr := strings.Replace(query.Encode(), "+", "%20", -1)
 	canonicalRequest := getCanonicalRequest(extractedSignedHeaders, unsignedPayload, queryStr, req.URL.Path, req.Method)
 	stringToSign := getStringToSign(canonicalRequest, date, scope)
-	signingKey := getSigningKey(secretAccessKey, date, region)
+	signingKey := getSigningKey(secretAccessKey, date, region, serviceS3)
 	signature := getSignature(signingKey, stringToSign)
 
 	req.URL.RawQuery = query.Encode()
@@ -969,6 +971,11 @@ func signRequestV2(req *http.Request, accessKey, secretKey string) error {
 
 // Sign given request using Signature V4.
 func signRequestV4(req *http.Request, accessKey, secretKey string) error {
+	return signRequestV4Service(req, accessKey, secretKey, serviceS3)
+}
+
+// Sign given request using Signature V4 for the service.
+func signRequestV4Service(req *http.Request, accessKey, secretKey string, stype serviceType) error {
 	// Get hashed payload.
 	hashedPayload := req.Header.Get("x-amz-content-sha256")
 	if hashedPayload == "" {
@@ -1050,7 +1057,7 @@ func signRequestV4(req *http.Request, accessKey, secretKey string) error {
 	scope := strings.Join([]string{
 		currTime.Format(yyyymmdd),
 		region,
-		"s3",
+		string(stype),
 		"aws4_request",
 	}, "/")
 
@@ -1060,7 +1067,7 @@ func signRequestV4(req *http.Request, accessKey, secretKey string) error {
 
 	date := sumHMAC([]byte("AWS4"+secretKey), []byte(currTime.Format(yyyymmdd)))
 	regionHMAC := sumHMAC(date, []byte(region))
-	service := sumHMAC(regionHMAC, []byte("s3"))
+	service := sumHMAC(regionHMAC, []byte(stype))
 	signingKey := sumHMAC(service, []byte("aws4_request"))
 
 	signature := hex.EncodeToString(sumHMAC(signingKey, []byte(stringToSign)))
@@ -1402,6 +1409,13 @@ func getCopyObjectPartURL(endPoint, bucketName, objectName, uploadID, partNumber
 	return makeTestTargetURL(endPoint, bucketName, objectName, queryValues)
 }
 
+// return URL for the tagging subresource of an object or a bucket.
+func getTaggingURL(endPoint, bucketName, objectName string) string {
+	queryValues := url.Values{}
+	queryValues.Set("tagging", "")
+	return makeTestTargetURL(endPoint, bucketName, objectName, queryValues)
+}
+
 // return URL for fetching object from the bucket.
 func getGetObjectURL(endPoint, bucketName, objectName string) string {
 	return makeTestTargetURL(endPoint, bucketName, objectName, url.Values{})
@@ -1645,6 +1659,11 @@ func initObjectLayer(endpoints EndpointList) (ObjectLayer, []StorageAPI, error)
 	if xl, ok := objLayer.(*xlObjects); ok {
 		xl.objCacheEnabled = false
 	}
+	if s, ok := objLayer.(*xlSets); ok {
+		for _, xl := range s.sets {
+			xl.objCacheEnabled = false
+		}
+	}
 
 	// Success.
 	return objLayer, formattedDisks, nil
@@ -2056,6 +2075,25 @@ func registerBucketLevelFunc(bucket *router.Router, api objectAPIHandlers, apiFu
 		case "HeadObject":
 			// Register HeadObject handler.
 			bucket.Methods("Head").Path("/{object:.+}").HandlerFunc(api.HeadObjectHandler)
+		case "ObjectTagging":
+			// Register object tagging handlers.
+			bucket.Methods("GET").Path("/{object:.+}").HandlerFunc(api.GetObjectTaggingHandler).Queries("tagging", "")
+			bucket.Methods("PUT").Path("/{object:.+}").HandlerFunc(api.PutObjectTaggingHandler).Queries("tagging", "")
+			bucket.Methods("DELETE").Path("/{object:.+}").HandlerFunc(api.DeleteObjectTaggingHandler).Queries("tagging", "")
+		case "BucketTagging":
+			// Register bucket tagging handlers.
+			bucket.Methods("GET").HandlerFunc(api.GetBucketTaggingHandler).Queries("tagging", "")
+			bucket.Methods("PUT").HandlerFunc(api.PutBucketTaggingHandler).Queries("tagging", "")
+			bucket.Methods("DELETE").HandlerFunc(api.DeleteBucketTaggingHandler).Queries("tagging", "")
+		case "BucketLogging":
+			// Register bucket logging handlers.
+			bucket.Methods("GET").HandlerFunc(api.GetBucketLoggingHandler).Queries("logging", "")
+			bucket.Methods("PUT").HandlerFunc(api.PutBucketLoggingHandler).Queries("logging", "")
+		case "BucketReplication":
+			// Register bucket replication handlers.
+			bucket.Methods("GET").HandlerFunc(api.GetBucketReplicationHandler).Queries("replication", "")
+			bucket.Methods("PUT").HandlerFunc(api.PutBucketReplicationHandler).Queries("replication", "")
+			bucket.Methods("DELETE").HandlerFunc(api.DeleteBucketReplicationHandler).Queries("replication", "")
 		case "GetObject":
 			// Register GetObject handler.
 			bucket.Methods("GET").Path("/{object:.+}").HandlerFunc(api.GetObjectHandler)
@@ -558,17 +558,60 @@ func (web *webAPIHandlers) Upload(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Objects are retained as requested, or by the default retention
+	// of the bucket.
+	if err = extractObjectLockFromHeader(r.Header, bucket, metadata); err != nil {
+		writeWebErrorResponse(w, err)
+		return
+	}
+
 	// Lock the object.
 	objectLock := globalNSMutex.NewNSLock(bucket, object)
 	objectLock.Lock()
 	defer objectLock.Unlock()
 
+	// Objects are encrypted if the bucket defaults to server managed keys.
+	var reader io.Reader = r.Body
+	putSize := size
+	if isBucketEncryptionEnabled(bucket) {
+		sseKey, kerr := newSSES3Key(bucket, object, metadata)
+		if kerr != nil {
+			writeWebErrorResponse(w, kerr)
+			return
+		}
+		objectKey, kerr := newSSEObjectKey(sseKey, metadata)
+		if kerr != nil {
+			writeWebErrorResponse(w, kerr)
+			return
+		}
+		if reader, err = newSSEEncryptReader(reader, objectKey, 1); err != nil {
+			writeWebErrorResponse(w, err)
+			return
+		}
+		putSize = sseEncryptedSize(size)
+	}
+
+	// Writes exceeding the hard quota of the bucket are rejected,
+	// objects are accounted at their stored size.
+	quota, s3Error := enforceBucketQuota(objectAPI, bucket, object, putSize)
+	if s3Error != ErrNone {
+		writeWebErrorResponse(w, errBucketQuotaExceeded)
+		return
+	}
+	defer quota.release()
+
+	// Mark the object for replication if the bucket is replicated.
+	setReplicationMetadata(bucket, object, metadata)
+
 	sha256sum := ""
-	objInfo, err := objectAPI.PutObject(bucket, object, size, r.Body, metadata, sha256sum)
+	objInfo, err := objectAPI.PutObject(bucket, object, putSize, reader, metadata, sha256sum)
 	if err != nil {
 		writeWebErrorResponse(w, err)
 		return
 	}
+	updateBucketUsage(objectAPI, bucket, quota.removed, objInfo)
+	scheduleObjectReplication(objInfo)
+	objInfo.Size = size
 
 	// Notify object created event.
 	eventNotify(eventData{
@@ -597,20 +640,53 @@ func (web *webAPIHandlers) Download(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Add content disposition.
-	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", path.Base(object)))
-
 	// Lock the object before reading.
 	objectLock := globalNSMutex.NewNSLock(bucket, object)
 	objectLock.RLock()
 	defer objectLock.RUnlock()
 
-	if err := objectAPI.GetObject(bucket, object, 0, -1, w); err != nil {
+	// Objects encrypted with server managed keys are decrypted, objects
+	// encrypted with client provided keys cannot be downloaded.
+	objInfo, err := objectAPI.GetObjectInfo(bucket, object)
+	if err != nil {
+		writeWebErrorResponse(w, err)
+		return
+	}
+	var writer io.Writer = w
+	offset, length := int64(0), int64(-1)
+	if isSSES3Object(objInfo.UserDefined) {
+		if writer, offset, length, err = newWebSSEDecryptWriter(w, objInfo); err != nil {
+			writeWebErrorResponse(w, err)
+			return
+		}
+	} else if isEncryptedObject(objInfo.UserDefined) {
+		writeWebErrorResponse(w, errEncryptedObject)
+		return
+	}
+
+	// Add content disposition.
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", path.Base(object)))
+
+	if err := objectAPI.GetObject(bucket, object, offset, length, writer); err != nil {
 		/// No need to print error, response writer already written to.
 		return
 	}
 }
 
+// newWebSSEDecryptWriter - returns a writer decrypting a whole object
+// encrypted with a server managed key, along with the encrypted range.
+func newWebSSEDecryptWriter(w io.Writer, objInfo ObjectInfo) (writer io.Writer, offset, length int64, err error) {
+	key, err := unsealSSES3Key(objInfo.Bucket, objInfo.Name, objInfo.UserDefined)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	size, err := sseDecryptedObjectSize(objInfo)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	return newSSEDecryptWriter(w, key, objInfo, 0, size, nil)
+}
+
 // DownloadZipArgs - Argument for downloading a bunch of files as a zip file.
 // JSON will look like:
 // '{"bucketname":"testbucket","prefix":"john/pics/","objects":["hawaii/","maldives/","sanjose.jpg"]}'
@@ -658,18 +734,34 @@ func (web *webAPIHandlers) DownloadZip(w http.ResponseWriter, r *http.Request) {
 			if err != nil {
 				return err
 			}
+			// Encrypted objects can only be read with the client provided key,
+			// or the server managed key.
+			size := info.Size
+			if isSSES3Object(info.UserDefined) {
+				if size, err = sseDecryptedObjectSize(info); err != nil {
+					return err
+				}
+			} else if isEncryptedObject(info.UserDefined) {
+				return errEncryptedObject
+			}
 			header := &zip.FileHeader{
 				Name:               strings.TrimPrefix(objectName, args.Prefix),
 				Method:             zip.Deflate,
-				UncompressedSize64: uint64(info.Size),
-				UncompressedSize:   uint32(info.Size),
+				UncompressedSize64: uint64(size),
+				UncompressedSize:   uint32(size),
 			}
 			writer, err := archive.CreateHeader(header)
 			if err != nil {
 				writeWebErrorResponse(w, errUnexpected)
 				return err
 			}
-			return objectAPI.GetObject(args.BucketName, objectName, 0, info.Size, writer)
+			offset, length := int64(0), info.Size
+			if isSSES3Object(info.UserDefined) {
+				if writer, offset, length, err = newWebSSEDecryptWriter(writer, info); err != nil {
+					return err
+				}
+			}
+			return objectAPI.GetObject(args.BucketName, objectName, offset, length, writer)
 		}
 
 		if !hasSuffix(object, slashSeparator) {
@@ -1027,7 +1119,7 @@ func presignedGet(host, bucket, object string, expiry int64) string {
 	extractedSignedHeaders.Set("host", host)
 	canonicalRequest := getCanonicalRequest(extractedSignedHeaders, unsignedPayload, query, path, "GET")
 	stringToSign := getStringToSign(canonicalRequest, date, getScope(date, region))
-	signingKey := getSigningKey(secretKey, date, region)
+	signingKey := getSigningKey(secretKey, date, region, serviceS3)
 	signature := getSignature(signingKey, stringToSign)
 
 	// Construct the final presigned URL.
@@ -1132,6 +1224,11 @@ func toWebAPIError(err error) APIError {
 			HTTPStatusCode: http.StatusBadRequest,
 			Description:    err.Error(),
 		}
+	} else if err == errBucketQuotaExceeded {
+		return getAPIError(ErrBucketQuotaExceeded)
+	} else if err == errObjectLockNotConfigured || err == errIncompleteRetention || err == errInvalidRetentionMode ||
+		err == errInvalidRetainUntilDate || err == errInvalidLegalHoldStatus {
+		return getAPIError(toAPIErrorCode(err))
 	}
 	// Convert error type to api error code.
 	switch err.(type) {
@@ -1159,6 +1256,8 @@ func toWebAPIError(err error) APIError {
 		return getAPIError(ErrReadQuorum)
 	case PolicyNesting:
 		return getAPIError(ErrPolicyNesting)
+	case ObjectLocked:
+		return getAPIError(ErrObjectLocked)
 	case NotImplemented:
 		return APIError{
 			Code:           "NotImplemented",
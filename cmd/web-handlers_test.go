@@ -34,6 +34,7 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	humanize "github.com/dustin/go-humanize"
 	"github.com/minio/minio-go/pkg/policy"
@@ -894,6 +895,118 @@ func testUploadWebHandler(obj ObjectLayer, instanceType string, t TestErrHandler
 	if code != http.StatusOK {
 		t.Fatalf("Expected the response status to be 200, but instead found `%d`", code)
 	}
+
+	// Uploads to a replicated bucket are queued for replication.
+	defer func(br *bucketReplication) { globalBucketReplication = br }(globalBucketReplication)
+	defer func(q *replicationQueue) { globalReplicationQueue = q }(globalReplicationQueue)
+	if err = initBucketReplication(obj); err != nil {
+		t.Fatalf("%s : %s", instanceType, err)
+	}
+	config := replicationConfigXML("", "https://dr.example.com:9000", credentials.AccessKey, credentials.SecretKey)
+	rcfg, err := parseBucketReplication(strings.NewReader(config))
+	if err != nil {
+		t.Fatalf("%s : %s", instanceType, err)
+	}
+	globalBucketReplication.SetBucketReplication(bucketName, rcfg)
+	globalReplicationQueue = newReplicationQueue(obj)
+	objectName = "replicated.file"
+	code = test(authorization, true)
+	if code != http.StatusOK {
+		t.Fatalf("Expected the response status to be 200, but instead found `%d`", code)
+	}
+	objInfo, err := obj.GetObjectInfo(bucketName, objectName)
+	if err != nil {
+		t.Fatalf("%s : %s", instanceType, err)
+	}
+	if status := objInfo.UserDefined[ObjectReplicationStatus]; status != replicationPending {
+		t.Fatalf("Expected replication status %s, got %s", replicationPending, status)
+	}
+	if len(globalReplicationQueue.tasksCh) != 1 {
+		t.Fatalf("Expected 1 replication task, got %d", len(globalReplicationQueue.tasksCh))
+	}
+	globalBucketReplication.SetBucketReplication(bucketName, nil)
+
+	// Uploads are accounted for in the bucket usage and rejected
+	// once they exceed the hard quota of the bucket.
+	defer func(bq *bucketQuotas) { globalBucketQuota = bq }(globalBucketQuota)
+	if err = initBucketQuota(obj); err != nil {
+		t.Fatalf("%s : %s", instanceType, err)
+	}
+	usage := bucketUsage{Size: int64(len(content)), Objects: 1}
+	globalBucketQuota.SetBucketQuota(bucketName, &bucketQuotaConfiguration{Quota: 2 * int64(len(content)), Type: hardQuota}, usage)
+	objectName = "second.file"
+	code = test(authorization, true)
+	if code != http.StatusOK {
+		t.Fatalf("Expected the response status to be 200, but instead found `%d`", code)
+	}
+	if usage = globalBucketQuota.GetBucketUsage(bucketName); usage != (bucketUsage{Size: 2 * int64(len(content)), Objects: 2}) {
+		t.Fatalf("Expected usage of %d bytes in 2 objects, got %v", 2*len(content), usage)
+	}
+	objectName = "third.file"
+	code = test(authorization, true)
+	if code != http.StatusBadRequest {
+		t.Fatalf("Expected the response status to be 400, but instead found `%d`", code)
+	}
+	globalBucketQuota.SetBucketQuota(bucketName, nil, bucketUsage{})
+
+	// Uploads are retained as requested, or by the default retention
+	// of the bucket.
+	testWithLock := func(header http.Header) int {
+		rec := httptest.NewRecorder()
+		req, rErr := http.NewRequest("PUT", "/minio/upload/"+bucketName+"/"+objectName, bytes.NewReader(content))
+		if rErr != nil {
+			t.Fatalf("Cannot create upload request, %v", rErr)
+		}
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		req.Header.Set("Authorization", "Bearer "+authorization)
+		apiRouter.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	getLock := func() objectLock {
+		objInfo, oErr := obj.GetObjectInfo(bucketName, objectName)
+		if oErr != nil {
+			t.Fatalf("%s : %s", instanceType, oErr)
+		}
+		return getObjectLock(objInfo.UserDefined)
+	}
+	header := http.Header{}
+	header.Set(AmzObjectLockLegalHold, legalHoldOn)
+	objectName = "locked.file"
+	if code = testWithLock(header); code != http.StatusBadRequest {
+		t.Fatalf("Expected the response status to be 400, but instead found `%d`", code)
+	}
+	if err = initBucketVersioning(obj); err != nil {
+		t.Fatalf("%s : %s", instanceType, err)
+	}
+	globalBucketVersioning.SetBucketVersioning(bucketName, &versioningConfiguration{Status: versioningEnabled})
+	defer globalBucketVersioning.SetBucketVersioning(bucketName, nil)
+	globalBucketObjectLock.SetBucketObjectLock(bucketName, &objectLockConfiguration{
+		ObjectLockEnabled: objectLockEnabled,
+		Rule:              &objectLockRule{DefaultRetention: defaultRetention{Mode: retentionGovernance, Days: 1}},
+	})
+	defer globalBucketObjectLock.SetBucketObjectLock(bucketName, nil)
+	if code = testWithLock(nil); code != http.StatusOK {
+		t.Fatalf("Expected the response status to be 200, but instead found `%d`", code)
+	}
+	if lock := getLock(); lock.Mode != retentionGovernance || lock.LegalHold {
+		t.Fatalf("Expected default governance retention, got %v", lock)
+	}
+	header.Set(AmzObjectLockMode, retentionCompliance)
+	header.Set(AmzObjectLockRetainUntilDate, UTCNow().Add(time.Hour).Format(time.RFC3339))
+	objectName = "compliance.file"
+	if code = testWithLock(header); code != http.StatusOK {
+		t.Fatalf("Expected the response status to be 200, but instead found `%d`", code)
+	}
+	if lock := getLock(); lock.Mode != retentionCompliance || !lock.LegalHold {
+		t.Fatalf("Expected compliance retention with legal hold, got %v", lock)
+	}
+	header.Set(AmzObjectLockMode, "FOREVER")
+	objectName = "invalid.file"
+	if code = testWithLock(header); code != http.StatusBadRequest {
+		t.Fatalf("Expected the response status to be 400, but instead found `%d`", code)
+	}
 }
 
 // Wrapper for calling Download Handler
@@ -0,0 +1,322 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	router "github.com/gorilla/mux"
+)
+
+// HTML error page returned for failed website requests.
+const websiteErrorPage = `<html>
+<head><title>%d %s</title></head>
+<body>
+<h1>%d %s</h1>
+<ul>
+<li>Code: %s</li>
+<li>Message: %s</li>
+</ul>
+</body>
+</html>
+`
+
+// websiteRequest - bucket and object key of a website request.
+type websiteRequest struct {
+	bucket string
+	key    string
+	// Path of the website root, `/` for requests to the website
+	// domain and `/<bucket>/` for path style requests.
+	prefix string
+}
+
+// getWebsiteDomainBucket - returns the bucket of a request made to
+// `<bucket>.<domain>` where domain is the configured website domain.
+func getWebsiteDomainBucket(host string) (string, bool) {
+	if globalWebsiteDomain == "" {
+		return "", false
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(host)
+	suffix := "." + globalWebsiteDomain
+	if !strings.HasSuffix(host, suffix) {
+		return "", false
+	}
+	bucket := strings.TrimSuffix(host, suffix)
+	return bucket, bucket != ""
+}
+
+// parseWebsiteRequest - returns the bucket and object key of a website
+// request, either from the host or from the path of the request.
+func parseWebsiteRequest(r *http.Request) (websiteRequest, bool) {
+	if bucket, ok := getWebsiteDomainBucket(r.Host); ok {
+		return websiteRequest{
+			bucket: bucket,
+			key:    strings.TrimPrefix(r.URL.Path, slashSeparator),
+			prefix: slashSeparator,
+		}, true
+	}
+	bucket, key := path2BucketAndObject(r.URL.Path)
+	if bucket == "" {
+		return websiteRequest{}, false
+	}
+	return websiteRequest{
+		bucket: bucket,
+		key:    key,
+		prefix: slashSeparator + bucket + slashSeparator,
+	}, true
+}
+
+// websiteHandler - serves the objects of buckets with a website
+// configuration to anonymous clients, access to the objects is
+// granted by the bucket policy.
+type websiteHandler struct {
+	ObjectAPI func() ObjectLayer
+}
+
+// newWebsiteHandler - returns the handler of the website listener.
+func newWebsiteHandler() http.Handler {
+	mux := router.NewRouter().SkipClean(true)
+	mux.PathPrefix(slashSeparator).Handler(websiteHandler{ObjectAPI: newObjectLayerFn})
+	return registerHandlers(mux, setPathValidityHandler, setRequestSizeLimitHandler)
+}
+
+func (h websiteHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	req, ok := parseWebsiteRequest(r)
+	if !ok {
+		writeWebsiteError(w, r, ErrNoSuchBucket)
+		return
+	}
+	if r.Method != httpGET && r.Method != httpHEAD {
+		writeWebsiteError(w, r, ErrMethodNotAllowed)
+		return
+	}
+
+	objectAPI := h.ObjectAPI()
+	if objectAPI == nil {
+		writeWebsiteError(w, r, ErrServerNotInitialized)
+		return
+	}
+
+	wcfg := globalBucketWebsite.GetBucketWebsite(req.bucket)
+	if wcfg == nil {
+		if _, err := objectAPI.GetBucketInfo(req.bucket); err != nil {
+			writeWebsiteError(w, r, toAPIErrorCode(err))
+			return
+		}
+		writeWebsiteError(w, r, ErrNoSuchWebsiteConfiguration)
+		return
+	}
+
+	if redirect := wcfg.RedirectAllRequestsTo; redirect != nil {
+		writeWebsiteRedirect(w, r, redirect.Protocol, redirect.HostName,
+			slashSeparator+req.key, http.StatusMovedPermanently)
+		return
+	}
+
+	if rule := wcfg.findRoutingRule(req.key, 0); rule != nil {
+		writeWebsiteRuleRedirect(w, r, req, rule)
+		return
+	}
+
+	key := req.key
+	if key == "" || hasSuffix(key, slashSeparator) {
+		key += wcfg.IndexDocument.Suffix
+	}
+	s3Error := serveWebsiteObject(w, r, objectAPI, req.bucket, key, http.StatusOK)
+	if s3Error == ErrNone {
+		return
+	}
+
+	// Requests for a directory without the trailing slash are
+	// redirected to the directory, which serves its index. Reading
+	// a directory as an object fails with access denied on FS.
+	if (s3Error == ErrNoSuchKey || s3Error == ErrAccessDenied) && key == req.key {
+		index := key + slashSeparator + wcfg.IndexDocument.Suffix
+		if isWebsiteObjectReadable(r, objectAPI, req.bucket, index) {
+			writeWebsiteRedirect(w, r, "", "", req.prefix+req.key+slashSeparator, http.StatusFound)
+			return
+		}
+	}
+
+	status := getAPIError(s3Error).HTTPStatusCode
+	if rule := wcfg.findRoutingRule(req.key, status); rule != nil {
+		writeWebsiteRuleRedirect(w, r, req, rule)
+		return
+	}
+
+	// Client errors are answered with the error document if
+	// there is one, it is returned with the error status.
+	if wcfg.ErrorDocument != nil && status >= 400 && status < 500 {
+		if serveWebsiteObject(w, r, objectAPI, req.bucket, wcfg.ErrorDocument.Key, status) == ErrNone {
+			return
+		}
+	}
+	writeWebsiteError(w, r, s3Error)
+}
+
+// isWebsiteObjectReadable - returns true if the object exists and may
+// be read by website clients.
+func isWebsiteObjectReadable(r *http.Request, objectAPI ObjectLayer, bucket, object string) bool {
+	if enforceBucketPolicy(bucket, "s3:GetObject", slashSeparator+bucket+slashSeparator+object,
+		r.Referer(), r.URL.Query(), nil) != ErrNone {
+		return false
+	}
+	objInfo, err := objectAPI.GetObjectInfo(bucket, object)
+	return err == nil && !objInfo.DeleteMarker
+}
+
+// serveWebsiteObject - writes the object with the status code if the
+// bucket policy allows anonymous clients to read it, returns the error
+// code without writing a response otherwise.
+func serveWebsiteObject(w http.ResponseWriter, r *http.Request, objectAPI ObjectLayer, bucket, object string, status int) APIErrorCode {
+	if s3Error := enforceBucketPolicy(bucket, "s3:GetObject", slashSeparator+bucket+slashSeparator+object,
+		r.Referer(), r.URL.Query(), nil); s3Error != ErrNone {
+		return s3Error
+	}
+
+	// Lock the object before reading.
+	objectLock := globalNSMutex.NewNSLock(bucket, object)
+	objectLock.RLock()
+	defer objectLock.RUnlock()
+
+	objInfo, err := objectAPI.GetObjectInfo(bucket, object)
+	if err != nil {
+		return toAPIErrorCode(err)
+	}
+	if objInfo.DeleteMarker {
+		return ErrNoSuchKey
+	}
+
+	// Objects encrypted with SSE-S3 are decrypted by the server,
+	// objects encrypted with client keys can not be served.
+	key, err := getSSEKey(r, bucket, object, objInfo.UserDefined, false)
+	if err != nil {
+		return toAPIErrorCode(err)
+	}
+	encInfo := objInfo
+	if key != nil {
+		if objInfo.Size, err = sseDecryptedObjectSize(encInfo); err != nil {
+			return toAPIErrorCode(err)
+		}
+	}
+
+	if status == http.StatusOK && checkPreconditions(w, r, objInfo) {
+		return ErrNone
+	}
+
+	setObjectHeaders(w, objInfo, nil)
+	w.WriteHeader(status)
+	if r.Method == httpHEAD {
+		return ErrNone
+	}
+
+	reader := getObjectReader(objectAPI, encInfo, "", key, 0, objInfo.Size)
+	defer reader.Close()
+	if _, err = io.Copy(w, reader); err != nil {
+		errorIf(err, "Unable to write website object %s/%s.", bucket, object)
+	}
+	return ErrNone
+}
+
+// writeWebsiteRedirect - redirects the request, the protocol and the
+// host of the request are kept if they are not given.
+func writeWebsiteRedirect(w http.ResponseWriter, r *http.Request, protocol, host, path string, code int) {
+	if protocol == "" {
+		protocol = httpScheme
+		if globalIsSSL {
+			protocol = httpsScheme
+		}
+	}
+	if host == "" {
+		host = r.Host
+	}
+	location := &url.URL{Scheme: protocol, Host: host, Path: path}
+	setCommonHeaders(w)
+	http.Redirect(w, r, location.String(), code)
+}
+
+// writeWebsiteRuleRedirect - redirects the request as configured by
+// the routing rule.
+func writeWebsiteRuleRedirect(w http.ResponseWriter, r *http.Request, req websiteRequest, rule *websiteRoutingRule) {
+	code := http.StatusMovedPermanently
+	if rule.Redirect.HTTPRedirectCode != "" {
+		code, _ = strconv.Atoi(rule.Redirect.HTTPRedirectCode)
+	}
+	// Redirects to the same host stay below the website root.
+	path := slashSeparator + rule.RedirectKey(req.key)
+	if rule.Redirect.HostName == "" {
+		path = req.prefix + rule.RedirectKey(req.key)
+	}
+	writeWebsiteRedirect(w, r, rule.Redirect.Protocol, rule.Redirect.HostName, path, code)
+}
+
+// writeWebsiteError - writes an HTML error page, website clients
+// are browsers which do not understand S3 XML errors.
+func writeWebsiteError(w http.ResponseWriter, r *http.Request, errorCode APIErrorCode) {
+	apiError := getAPIError(errorCode)
+	setCommonHeaders(w)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(apiError.HTTPStatusCode)
+	if r.Method == httpHEAD {
+		return
+	}
+	statusText := http.StatusText(apiError.HTTPStatusCode)
+	fmt.Fprintf(w, websiteErrorPage, apiError.HTTPStatusCode, statusText,
+		apiError.HTTPStatusCode, statusText,
+		html.EscapeString(apiError.Code), html.EscapeString(apiError.Description))
+}
+
+// setWebsiteDomainHandler - serves requests to the website domain
+// as website requests, all other requests are passed through.
+func setWebsiteDomainHandler(h http.Handler) http.Handler {
+	return websiteDomainHandler{
+		handler: h,
+		website: websiteHandler{ObjectAPI: newObjectLayerFn},
+	}
+}
+
+type websiteDomainHandler struct {
+	handler http.Handler
+	website http.Handler
+}
+
+func (h websiteDomainHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if _, ok := getWebsiteDomainBucket(r.Host); ok {
+		h.website.ServeHTTP(w, r)
+		return
+	}
+	h.handler.ServeHTTP(w, r)
+}
+
+// shutdownWebsiteServer - stops the website listener if one was started.
+func shutdownWebsiteServer() {
+	if globalWebsiteServer == nil {
+		return
+	}
+	err := globalWebsiteServer.Shutdown()
+	errorIf(err, "Unable to shutdown website server")
+}
@@ -0,0 +1,185 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Tests resolving the bucket and key of website requests.
+func TestParseWebsiteRequest(t *testing.T) {
+	defer func(domain string) { globalWebsiteDomain = domain }(globalWebsiteDomain)
+	globalWebsiteDomain = "web.example.com"
+
+	testCases := []struct {
+		host     string
+		path     string
+		expected websiteRequest
+		ok       bool
+	}{
+		{"docs.web.example.com", "/guide/", websiteRequest{"docs", "guide/", "/"}, true},
+		{"docs.web.example.com:9080", "/", websiteRequest{"docs", "", "/"}, true},
+		{"DOCS.Web.Example.com", "/a.html", websiteRequest{"docs", "a.html", "/"}, true},
+		{"localhost:9080", "/docs/guide/", websiteRequest{"docs", "guide/", "/docs/"}, true},
+		{"localhost:9080", "/docs", websiteRequest{"docs", "", "/docs/"}, true},
+		{"web.example.com", "/", websiteRequest{}, false},
+	}
+
+	for i, testCase := range testCases {
+		r, err := http.NewRequest("GET", "http://"+testCase.host+testCase.path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req, ok := parseWebsiteRequest(r)
+		if ok != testCase.ok {
+			t.Errorf("Test %d: Expected %v, got %v", i+1, testCase.ok, ok)
+			continue
+		}
+		if req != testCase.expected {
+			t.Errorf("Test %d: Expected request %v, got %v", i+1, testCase.expected, req)
+		}
+	}
+}
+
+// Wrapper for calling website handler tests for both XL and FS.
+func TestWebsiteHandler(t *testing.T) {
+	// initialize NSLock.
+	initNSLock(false)
+
+	ExecObjectLayerTest(t, testWebsiteHandler)
+}
+
+// Tests serving bucket websites to anonymous clients.
+func testWebsiteHandler(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	bucket := "website-bucket"
+	if err := obj.MakeBucketWithLocation(bucket, ""); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	objects := map[string]string{
+		"site/index.html":      "home",
+		"site/docs/index.html": "docs",
+		"site/error.html":      "not found",
+		"private.html":         "private",
+	}
+	for object, data := range objects {
+		_, err := obj.PutObject(bucket, object, int64(len(data)), bytes.NewReader([]byte(data)),
+			map[string]string{"content-type": "text/html"}, "")
+		if err != nil {
+			t.Fatalf("%s: %s", instanceType, err)
+		}
+	}
+
+	// Only the objects below site/ are public.
+	policy := `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"AWS":["*"]},` +
+		`"Action":["s3:GetObject"],"Resource":["arn:aws:s3:::` + bucket + `/site/*"]}]}`
+	var bp bucketPolicy
+	if err := parseBucketPolicy(strings.NewReader(policy), &bp); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	globalBucketPolicies.SetBucketPolicy(bucket, policyChange{false, &bp})
+	defer globalBucketPolicies.SetBucketPolicy(bucket, policyChange{true, nil})
+
+	// Bucket policies are checked against the global object layer.
+	globalObjLayerMutex.Lock()
+	globalObjectAPI = obj
+	globalObjLayerMutex.Unlock()
+	defer func() {
+		globalObjLayerMutex.Lock()
+		globalObjectAPI = nil
+		globalObjLayerMutex.Unlock()
+	}()
+
+	handler := websiteHandler{ObjectAPI: newObjectLayerFn}
+	sendRequest := func(method, host, path string) *httptest.ResponseRecorder {
+		r, err := http.NewRequest(method, "http://"+host+path, nil)
+		if err != nil {
+			t.Fatalf("%s: %v", instanceType, err)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, r)
+		return rec
+	}
+
+	// Buckets without website configuration are not served.
+	if rec := sendRequest("GET", "localhost:9080", "/"+bucket+"/site/"); rec.Code != http.StatusNotFound ||
+		!strings.Contains(rec.Body.String(), "NoSuchWebsiteConfiguration") {
+		t.Fatalf("%s: Expected no website configuration, got %d %q", instanceType, rec.Code, rec.Body.String())
+	}
+
+	wcfg, err := parseBucketWebsite(strings.NewReader(`<WebsiteConfiguration>` +
+		`<IndexDocument><Suffix>index.html</Suffix></IndexDocument>` +
+		`<ErrorDocument><Key>site/error.html</Key></ErrorDocument>` +
+		`<RoutingRules><RoutingRule><Condition><KeyPrefixEquals>old/</KeyPrefixEquals></Condition>` +
+		`<Redirect><ReplaceKeyPrefixWith>site/</ReplaceKeyPrefixWith></Redirect></RoutingRule></RoutingRules>` +
+		`</WebsiteConfiguration>`))
+	if err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	globalBucketWebsite.SetBucketWebsite(bucket, wcfg)
+	defer globalBucketWebsite.SetBucketWebsite(bucket, nil)
+
+	testCases := []struct {
+		method   string
+		path     string
+		status   int
+		body     string
+		location string
+	}{
+		// Directories are resolved to their index document.
+		{"GET", "/site/", http.StatusOK, "home", ""},
+		{"GET", "/site/docs/", http.StatusOK, "docs", ""},
+		{"GET", "/site/index.html", http.StatusOK, "home", ""},
+		{"HEAD", "/site/docs/", http.StatusOK, "", ""},
+		// Directories without the trailing slash are redirected.
+		{"GET", "/site/docs", http.StatusFound, "", "http://localhost:9080/" + bucket + "/site/docs/"},
+		// Missing and private objects are answered with the error document.
+		{"GET", "/site/missing.html", http.StatusNotFound, "not found", ""},
+		{"GET", "/private.html", http.StatusForbidden, "not found", ""},
+		// Routing rules redirect by key prefix.
+		{"GET", "/old/docs/", http.StatusMovedPermanently, "", "http://localhost:9080/" + bucket + "/site/docs/"},
+		// Only reads are allowed.
+		{"PUT", "/site/index.html", http.StatusMethodNotAllowed, "", ""},
+	}
+
+	for i, testCase := range testCases {
+		rec := sendRequest(testCase.method, "localhost:9080", "/"+bucket+testCase.path)
+		if rec.Code != testCase.status {
+			t.Errorf("%s: Test %d: Expected status %d, got %d", instanceType, i+1, testCase.status, rec.Code)
+			continue
+		}
+		if testCase.body != "" && rec.Body.String() != testCase.body {
+			t.Errorf("%s: Test %d: Expected body %q, got %q", instanceType, i+1, testCase.body, rec.Body.String())
+		}
+		if location := rec.Header().Get("Location"); location != testCase.location {
+			t.Errorf("%s: Test %d: Expected location %q, got %q", instanceType, i+1, testCase.location, location)
+		}
+	}
+
+	// Requests to the website domain are served from the bucket root.
+	defer func(domain string) { globalWebsiteDomain = domain }(globalWebsiteDomain)
+	globalWebsiteDomain = "web.example.com"
+	if rec := sendRequest("GET", bucket+".web.example.com", "/site/docs/"); rec.Code != http.StatusOK || rec.Body.String() != "docs" {
+		t.Fatalf("%s: Expected website domain to be served, got %d %q", instanceType, rec.Code, rec.Body.String())
+	}
+	if rec := sendRequest("GET", bucket+".web.example.com", "/site/docs"); rec.Header().Get("Location") != "http://"+bucket+".web.example.com/site/docs/" {
+		t.Fatalf("%s: Expected redirect below website domain, got %q", instanceType, rec.Header().Get("Location"))
+	}
+}
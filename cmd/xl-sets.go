@@ -0,0 +1,683 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"hash/crc32"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/minio/minio/pkg/objcache"
+)
+
+// xlSets - implements the object layer over disks divided into erasure
+// sets, each set is an XL object layer of its own. Objects are placed
+// in a single set by the hash of their name, buckets are present in
+// all sets.
+type xlSets struct {
+	sets []*xlObjects
+
+	// Number of disks of each erasure set.
+	setDriveCount int
+}
+
+// getSetDriveCount - returns the number of disks of each erasure set
+// for the given number of disks, the largest even number between
+// minErasureBlocks and maxErasureBlocks dividing them. Returns 0 if
+// the disks can't be divided into erasure sets.
+func getSetDriveCount(totalDisks int) int {
+	for count := maxErasureBlocks; count >= minErasureBlocks; count -= 2 {
+		if totalDisks%count == 0 {
+			return count
+		}
+	}
+	return 0
+}
+
+// getSetIndexes - returns the JBOD positions of the disks of each
+// erasure set, given the host of each disk. Sets take a disk from each
+// host in turn, so that losing a host takes as few disks as possible
+// out of each set.
+func getSetIndexes(hosts []string, setDriveCount int) [][]int {
+	var hostOrder []string
+	hostDisks := make(map[string][]int)
+	for index, host := range hosts {
+		if _, ok := hostDisks[host]; !ok {
+			hostOrder = append(hostOrder, host)
+		}
+		hostDisks[host] = append(hostDisks[host], index)
+	}
+
+	var indexes []int
+	for len(indexes) < len(hosts) {
+		for _, host := range hostOrder {
+			if disks := hostDisks[host]; len(disks) > 0 {
+				indexes = append(indexes, disks[0])
+				hostDisks[host] = disks[1:]
+			}
+		}
+	}
+
+	setIndexes := make([][]int, len(hosts)/setDriveCount)
+	for i := range setIndexes {
+		setIndexes[i] = indexes[i*setDriveCount : (i+1)*setDriveCount]
+	}
+	return setIndexes
+}
+
+// newXLSets - initialize the erasure sets over disks in JBOD order,
+// setIndexes carries the JBOD positions of the disks of each set.
+func newXLSets(storageDisks []StorageAPI, setIndexes [][]int, objCache *objcache.Cache) (*xlSets, error) {
+	s := &xlSets{
+		sets:          make([]*xlObjects, len(setIndexes)),
+		setDriveCount: len(setIndexes[0]),
+	}
+	for i, indexes := range setIndexes {
+		setDisks := make([]StorageAPI, len(indexes))
+		for j, index := range indexes {
+			setDisks[j] = storageDisks[index]
+		}
+		xl, err := newXLSet(setDisks, objCache)
+		if err != nil {
+			return nil, err
+		}
+		s.sets[i] = xl
+	}
+	return s, nil
+}
+
+// getHashedSetIndex - returns the index of the erasure set of an
+// object, picked by the CRC of its name.
+func (s *xlSets) getHashedSetIndex(object string) int {
+	keyCrc := crc32.Checksum([]byte(object), crc32.IEEETable)
+	return int(keyCrc % uint32(len(s.sets)))
+}
+
+// getHashedSet - returns the erasure set of an object.
+func (s *xlSets) getHashedSet(object string) *xlObjects {
+	return s.sets[s.getHashedSetIndex(object)]
+}
+
+// forEachSet - runs fn on all erasure sets in parallel, returns the
+// error of each set.
+func (s *xlSets) forEachSet(fn func(index int, set *xlObjects) error) []error {
+	var wg = &sync.WaitGroup{}
+	var errs = make([]error, len(s.sets))
+	for index, set := range s.sets {
+		wg.Add(1)
+		go func(index int, set *xlObjects) {
+			defer wg.Done()
+			errs[index] = fn(index, set)
+		}(index, set)
+	}
+	wg.Wait()
+	return errs
+}
+
+// firstErr - returns the first non nil error.
+func firstErr(errs []error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Shutdown - shuts down all erasure sets.
+func (s *xlSets) Shutdown() error {
+	return firstErr(s.forEachSet(func(index int, set *xlObjects) error {
+		return set.Shutdown()
+	}))
+}
+
+// StorageInfo - returns storage statistics summed up over all sets,
+// quorums are those of a single set.
+func (s *xlSets) StorageInfo() StorageInfo {
+	var storageInfo StorageInfo
+	storageInfo.Backend.Type = Erasure
+	for _, set := range s.sets {
+		setInfo := set.StorageInfo()
+		if setInfo.Total > 0 {
+			storageInfo.Total += setInfo.Total
+			storageInfo.Free += setInfo.Free
+		}
+		storageInfo.Backend.OnlineDisks += setInfo.Backend.OnlineDisks
+		storageInfo.Backend.OfflineDisks += setInfo.Backend.OfflineDisks
+		storageInfo.Backend.ReadQuorum = setInfo.Backend.ReadQuorum
+		storageInfo.Backend.WriteQuorum = setInfo.Backend.WriteQuorum
+	}
+	if storageInfo.Total == 0 {
+		storageInfo.Total, storageInfo.Free = -1, -1
+	}
+	return storageInfo
+}
+
+/// Bucket operations
+
+// MakeBucketWithLocation - makes the bucket on all sets, the bucket is
+// removed again from all sets if any of them fails.
+func (s *xlSets) MakeBucketWithLocation(bucket, location string) error {
+	errs := s.forEachSet(func(index int, set *xlObjects) error {
+		return set.MakeBucketWithLocation(bucket, location)
+	})
+	err := firstErr(errs)
+	if err == nil {
+		return nil
+	}
+	for index, set := range s.sets {
+		if errs[index] == nil {
+			undoMakeBucket(set.storageDisks, bucket)
+		}
+	}
+	return err
+}
+
+// GetBucketInfo - returns bucket info from the first set.
+func (s *xlSets) GetBucketInfo(bucket string) (BucketInfo, error) {
+	return s.sets[0].GetBucketInfo(bucket)
+}
+
+// ListBuckets - lists buckets from the first set.
+func (s *xlSets) ListBuckets() ([]BucketInfo, error) {
+	return s.sets[0].ListBuckets()
+}
+
+// DeleteBucket - deletes the bucket from all sets once none of them
+// has objects in it, the bucket is made again on all sets if any of
+// them fails.
+func (s *xlSets) DeleteBucket(bucket string) error {
+	if !IsValidBucketName(bucket) {
+		return BucketNameInvalid{Bucket: bucket}
+	}
+	result, err := s.ListObjects(bucket, "", "", "", 1)
+	if err != nil {
+		return err
+	}
+	if len(result.Objects) > 0 || len(result.Prefixes) > 0 {
+		return traceError(BucketNotEmpty{Bucket: bucket})
+	}
+
+	errs := s.forEachSet(func(index int, set *xlObjects) error {
+		return set.DeleteBucket(bucket)
+	})
+	if err = firstErr(errs); err == nil {
+		return nil
+	}
+	for index, set := range s.sets {
+		if errs[index] == nil {
+			set.undoDeleteBucket(bucket)
+		}
+	}
+	return err
+}
+
+/// Object operations
+
+// GetObject - reads an object from its set.
+func (s *xlSets) GetObject(bucket, object string, startOffset int64, length int64, writer io.Writer) error {
+	return s.getHashedSet(object).GetObject(bucket, object, startOffset, length, writer)
+}
+
+// GetObjectInfo - returns object info from its set.
+func (s *xlSets) GetObjectInfo(bucket, object string) (ObjectInfo, error) {
+	return s.getHashedSet(object).GetObjectInfo(bucket, object)
+}
+
+// PutObject - writes an object to its set.
+func (s *xlSets) PutObject(bucket, object string, size int64, data io.Reader, metadata map[string]string, sha256sum string) (ObjectInfo, error) {
+	return s.getHashedSet(object).PutObject(bucket, object, size, data, metadata, sha256sum)
+}
+
+// CopyObject - copies an object within its set, objects of different
+// sets are read from the source set and written to the destination set.
+func (s *xlSets) CopyObject(srcBucket, srcObject, dstBucket, dstObject string, metadata map[string]string) (oi ObjectInfo, e error) {
+	srcSet, dstSet := s.getHashedSet(srcObject), s.getHashedSet(dstObject)
+	if srcSet == dstSet {
+		return srcSet.CopyObject(srcBucket, srcObject, dstBucket, dstObject, metadata)
+	}
+
+	srcInfo, err := srcSet.GetObjectInfo(srcBucket, srcObject)
+	if err != nil {
+		return oi, err
+	}
+
+	// Initialize pipe.
+	pipeReader, pipeWriter := io.Pipe()
+
+	go func() {
+		var startOffset int64 // Read the whole file.
+		if gerr := srcSet.GetObject(srcBucket, srcObject, startOffset, srcInfo.Size, pipeWriter); gerr != nil {
+			errorIf(gerr, "Unable to read the object `%s/%s`.", srcBucket, srcObject)
+			pipeWriter.CloseWithError(toObjectErr(gerr, srcBucket, srcObject))
+			return
+		}
+		pipeWriter.Close() // Close writer explicitly signalling we wrote all data.
+	}()
+
+	objInfo, err := dstSet.PutObject(dstBucket, dstObject, srcInfo.Size, pipeReader, metadata, "")
+	if err != nil {
+		return oi, toObjectErr(err, dstBucket, dstObject)
+	}
+
+	// Explicitly close the reader.
+	pipeReader.Close()
+
+	return objInfo, nil
+}
+
+// DeleteObject - deletes an object from its set.
+func (s *xlSets) DeleteObject(bucket, object string) error {
+	return s.getHashedSet(object).DeleteObject(bucket, object)
+}
+
+/// Object version operations
+
+// GetObjectVersion - reads a version of an object from its set.
+func (s *xlSets) GetObjectVersion(bucket, object, versionID string, startOffset int64, length int64, writer io.Writer) error {
+	return s.getHashedSet(object).GetObjectVersion(bucket, object, versionID, startOffset, length, writer)
+}
+
+// GetObjectVersionInfo - returns info of a version of an object from its set.
+func (s *xlSets) GetObjectVersionInfo(bucket, object, versionID string) (ObjectInfo, error) {
+	return s.getHashedSet(object).GetObjectVersionInfo(bucket, object, versionID)
+}
+
+// DeleteObjectVersion - deletes a version of an object from its set.
+func (s *xlSets) DeleteObjectVersion(bucket, object, versionID string, bypassGovernance bool) (ObjectInfo, error) {
+	return s.getHashedSet(object).DeleteObjectVersion(bucket, object, versionID, bypassGovernance)
+}
+
+// PutObjectLock - sets the object lock of a version of an object in its set.
+func (s *xlSets) PutObjectLock(bucket, object, versionID string, lock objectLock, bypassGovernance bool) (ObjectInfo, error) {
+	return s.getHashedSet(object).PutObjectLock(bucket, object, versionID, lock, bypassGovernance)
+}
+
+// getObjectInfo - returns the current version of an object from its set.
+func (s *xlSets) getObjectInfo(bucket, object string) (ObjectInfo, error) {
+	return s.getHashedSet(object).getObjectInfo(bucket, object)
+}
+
+// listArchivedVersions - returns the previous versions of an object from its set.
+func (s *xlSets) listArchivedVersions(bucket, object string) ([]ObjectInfo, error) {
+	return s.getHashedSet(object).listArchivedVersions(bucket, object)
+}
+
+// listVersionedObjects - lists names of objects having previous
+// versions in all sets, merged in sorted order.
+func (s *xlSets) listVersionedObjects(bucket, prefix, marker string, maxKeys int) (names []string, isTruncated bool, err error) {
+	results := make([][]string, len(s.sets))
+	truncated := make([]bool, len(s.sets))
+	errs := s.forEachSet(func(index int, set *xlObjects) (lerr error) {
+		results[index], truncated[index], lerr = set.listVersionedObjects(bucket, prefix, marker, maxKeys)
+		return lerr
+	})
+	if err = firstErr(errs); err != nil {
+		return nil, false, err
+	}
+
+	// A truncated set has listed no names past its last name.
+	var cutoff string
+	for index, setNames := range results {
+		names = append(names, setNames...)
+		if truncated[index] && len(setNames) > 0 {
+			last := setNames[len(setNames)-1]
+			if !isTruncated || last < cutoff {
+				cutoff = last
+			}
+			isTruncated = true
+		}
+	}
+	sort.Strings(names)
+	for i, name := range names {
+		if (isTruncated && name > cutoff) || i == maxKeys {
+			return names[:i], true, nil
+		}
+	}
+	return names, isTruncated, nil
+}
+
+// ListObjectVersions - lists all versions of the objects at prefix in all sets.
+func (s *xlSets) ListObjectVersions(bucket, prefix, keyMarker, versionIDMarker, delimiter string, maxKeys int) (ListObjectVersionsInfo, error) {
+	if err := checkListObjsArgs(bucket, prefix, keyMarker, delimiter, s); err != nil {
+		return ListObjectVersionsInfo{}, err
+	}
+	return listObjectVersions(s, bucket, prefix, keyMarker, versionIDMarker, delimiter, maxKeys)
+}
+
+/// Listing operations
+
+// byObjectInfoName is a collection satisfying sort.Interface.
+type byObjectInfoName []ObjectInfo
+
+func (d byObjectInfoName) Len() int           { return len(d) }
+func (d byObjectInfoName) Swap(i, j int)      { d[i], d[j] = d[j], d[i] }
+func (d byObjectInfoName) Less(i, j int) bool { return d[i].Name < d[j].Name }
+
+// mergeListObjects - merges the listings of all sets into a listing of
+// at most maxKeys entries in lexical order. Listing stops at the
+// earliest NextMarker of the truncated sets, entries past it may be
+// missing from those sets.
+func mergeListObjects(results []ListObjectsInfo, maxKeys int) (loi ListObjectsInfo) {
+	var entries []ObjectInfo
+	var cutoff string
+	prefixes := make(map[string]struct{})
+	for _, result := range results {
+		entries = append(entries, result.Objects...)
+		for _, prefix := range result.Prefixes {
+			// Directories are present in every set having objects in them.
+			if _, ok := prefixes[prefix]; ok {
+				continue
+			}
+			prefixes[prefix] = struct{}{}
+			entries = append(entries, ObjectInfo{Name: prefix, IsDir: true})
+		}
+		if result.IsTruncated {
+			if !loi.IsTruncated || result.NextMarker < cutoff {
+				cutoff = result.NextMarker
+			}
+			loi.IsTruncated = true
+		}
+	}
+	sort.Sort(byObjectInfoName(entries))
+
+	for i, entry := range entries {
+		if loi.IsTruncated && entry.Name > cutoff {
+			break
+		}
+		if i == maxKeys {
+			loi.IsTruncated = true
+			return loi
+		}
+		loi.NextMarker = entry.Name
+		if entry.IsDir {
+			loi.Prefixes = append(loi.Prefixes, entry.Name)
+			continue
+		}
+		loi.Objects = append(loi.Objects, entry)
+	}
+	if loi.IsTruncated {
+		// Listing stopped at a truncated set, continue from there.
+		loi.NextMarker = cutoff
+	}
+	return loi
+}
+
+// listSets - runs the listing on all sets and merges the results.
+func (s *xlSets) listSets(maxKeys int, list func(set *xlObjects) (ListObjectsInfo, error)) (ListObjectsInfo, error) {
+	results := make([]ListObjectsInfo, len(s.sets))
+	errs := s.forEachSet(func(index int, set *xlObjects) (err error) {
+		results[index], err = list(set)
+		return err
+	})
+	if err := firstErr(errs); err != nil {
+		return ListObjectsInfo{}, err
+	}
+	return mergeListObjects(results, maxKeys), nil
+}
+
+// ListObjects - list all objects at prefix in all sets, delimited by '/'.
+func (s *xlSets) ListObjects(bucket, prefix, marker, delimiter string, maxKeys int) (ListObjectsInfo, error) {
+	// Over flowing count - reset to maxObjectList.
+	if maxKeys < 0 || maxKeys > maxObjectList {
+		maxKeys = maxObjectList
+	}
+	return s.listSets(maxKeys, func(set *xlObjects) (ListObjectsInfo, error) {
+		return set.ListObjects(bucket, prefix, marker, delimiter, maxKeys)
+	})
+}
+
+// listObjectsHealWalk - lists the objects found on any of the disks of all sets.
+func (s *xlSets) listObjectsHealWalk(bucket, prefix, marker, delimiter string, maxKeys int) (ListObjectsInfo, error) {
+	return s.listSets(maxKeys, func(set *xlObjects) (ListObjectsInfo, error) {
+		return set.listObjectsHealWalk(bucket, prefix, marker, delimiter, maxKeys)
+	})
+}
+
+// ListObjectsHeal - lists the objects which need healing in all sets.
+func (s *xlSets) ListObjectsHeal(bucket, prefix, marker, delimiter string, maxKeys int) (ListObjectsInfo, error) {
+	// Over flowing count - reset to maxObjectList.
+	if maxKeys < 0 || maxKeys > maxObjectList {
+		maxKeys = maxObjectList
+	}
+	return s.listSets(maxKeys, func(set *xlObjects) (ListObjectsInfo, error) {
+		return set.ListObjectsHeal(bucket, prefix, marker, delimiter, maxKeys)
+	})
+}
+
+// byUploadObject is a collection satisfying sort.Interface, sorting
+// is stable to retain the order of the uploads of an object.
+type byUploadObject []uploadMetadata
+
+func (d byUploadObject) Len() int           { return len(d) }
+func (d byUploadObject) Swap(i, j int)      { d[i], d[j] = d[j], d[i] }
+func (d byUploadObject) Less(i, j int) bool { return d[i].Object < d[j].Object }
+
+// mergeListMultiparts - merges the multipart upload listings of all
+// sets into a listing of at most maxUploads entries, uploads of an
+// object are all in the same set. Listing stops at the earliest key
+// marker of the truncated sets.
+func mergeListMultiparts(results []ListMultipartsInfo, maxUploads int) (lmi ListMultipartsInfo) {
+	lmi = results[0]
+	lmi.IsTruncated, lmi.Uploads, lmi.CommonPrefixes = false, nil, nil
+	lmi.NextKeyMarker, lmi.NextUploadIDMarker = "", ""
+
+	var entries []uploadMetadata
+	var cutoff ListMultipartsInfo
+	prefixes := make(map[string]struct{})
+	for _, result := range results {
+		entries = append(entries, result.Uploads...)
+		for _, prefix := range result.CommonPrefixes {
+			if _, ok := prefixes[prefix]; ok {
+				continue
+			}
+			prefixes[prefix] = struct{}{}
+			entries = append(entries, uploadMetadata{Object: prefix})
+		}
+		if result.IsTruncated {
+			if !lmi.IsTruncated || result.NextKeyMarker < cutoff.NextKeyMarker {
+				cutoff = result
+			}
+			lmi.IsTruncated = true
+		}
+	}
+	sort.Stable(byUploadObject(entries))
+
+	for i, entry := range entries {
+		if lmi.IsTruncated && entry.Object > cutoff.NextKeyMarker {
+			break
+		}
+		if i == maxUploads {
+			lmi.IsTruncated = true
+			return lmi
+		}
+		lmi.NextKeyMarker, lmi.NextUploadIDMarker = entry.Object, entry.UploadID
+		if _, ok := prefixes[entry.Object]; ok && entry.UploadID == "" {
+			lmi.CommonPrefixes = append(lmi.CommonPrefixes, entry.Object)
+			continue
+		}
+		lmi.Uploads = append(lmi.Uploads, entry)
+	}
+	if !lmi.IsTruncated {
+		// Result is not truncated, reset the markers.
+		lmi.NextKeyMarker, lmi.NextUploadIDMarker = "", ""
+	} else {
+		// Listing stopped at a truncated set, continue from there.
+		lmi.NextKeyMarker, lmi.NextUploadIDMarker = cutoff.NextKeyMarker, cutoff.NextUploadIDMarker
+	}
+	return lmi
+}
+
+// getUploadIDMarker - returns the upload ID marker to list the uploads
+// of a set with, only the set of the key marker has the uploads of the
+// key marker, other sets list the uploads after the key marker.
+func (s *xlSets) getUploadIDMarker(set *xlObjects, keyMarker, uploadIDMarker string) string {
+	if set != s.getHashedSet(keyMarker) {
+		return ""
+	}
+	return uploadIDMarker
+}
+
+// listSetsMultiparts - runs the multipart upload listing on all sets
+// and merges the results.
+func (s *xlSets) listSetsMultiparts(maxUploads int, list func(set *xlObjects) (ListMultipartsInfo, error)) (ListMultipartsInfo, error) {
+	results := make([]ListMultipartsInfo, len(s.sets))
+	errs := s.forEachSet(func(index int, set *xlObjects) (err error) {
+		results[index], err = list(set)
+		return err
+	})
+	if err := firstErr(errs); err != nil {
+		return ListMultipartsInfo{}, err
+	}
+	return mergeListMultiparts(results, maxUploads), nil
+}
+
+/// Multipart operations
+
+// ListMultipartUploads - lists the pending multipart uploads of all sets.
+func (s *xlSets) ListMultipartUploads(bucket, prefix, keyMarker, uploadIDMarker, delimiter string, maxUploads int) (ListMultipartsInfo, error) {
+	return s.listSetsMultiparts(maxUploads, func(set *xlObjects) (ListMultipartsInfo, error) {
+		return set.ListMultipartUploads(bucket, prefix, keyMarker, s.getUploadIDMarker(set, keyMarker, uploadIDMarker), delimiter, maxUploads)
+	})
+}
+
+// NewMultipartUpload - initiates a multipart upload in the set of the object.
+func (s *xlSets) NewMultipartUpload(bucket, object string, metadata map[string]string) (string, error) {
+	return s.getHashedSet(object).NewMultipartUpload(bucket, object, metadata)
+}
+
+// CopyObjectPart - copies a part within the set of the objects, parts
+// of objects of different sets are read from the source set and written
+// to the destination set.
+func (s *xlSets) CopyObjectPart(srcBucket, srcObject, dstBucket, dstObject string, uploadID string, partID int, startOffset int64, length int64) (pi PartInfo, e error) {
+	srcSet, dstSet := s.getHashedSet(srcObject), s.getHashedSet(dstObject)
+	if srcSet == dstSet {
+		return srcSet.CopyObjectPart(srcBucket, srcObject, dstBucket, dstObject, uploadID, partID, startOffset, length)
+	}
+
+	if err := checkNewMultipartArgs(srcBucket, srcObject, s); err != nil {
+		return pi, err
+	}
+
+	// Initialize pipe.
+	pipeReader, pipeWriter := io.Pipe()
+
+	go func() {
+		if gerr := srcSet.GetObject(srcBucket, srcObject, startOffset, length, pipeWriter); gerr != nil {
+			errorIf(gerr, "Unable to read the object `%s/%s`.", srcBucket, srcObject)
+			pipeWriter.CloseWithError(toObjectErr(gerr, srcBucket, srcObject))
+			return
+		}
+		pipeWriter.Close() // Close writer explicitly signalling we wrote all data.
+	}()
+
+	partInfo, err := dstSet.PutObjectPart(dstBucket, dstObject, uploadID, partID, length, pipeReader, "", "")
+	if err != nil {
+		return pi, toObjectErr(err, dstBucket, dstObject)
+	}
+
+	// Explicitly close the reader.
+	pipeReader.Close()
+
+	// Success.
+	return partInfo, nil
+}
+
+// PutObjectPart - writes a part of a multipart upload to the set of the object.
+func (s *xlSets) PutObjectPart(bucket, object, uploadID string, partID int, size int64, data io.Reader, md5Hex string, sha256sum string) (PartInfo, error) {
+	return s.getHashedSet(object).PutObjectPart(bucket, object, uploadID, partID, size, data, md5Hex, sha256sum)
+}
+
+// ListObjectParts - lists the parts of a multipart upload in the set of the object.
+func (s *xlSets) ListObjectParts(bucket, object, uploadID string, partNumberMarker int, maxParts int) (ListPartsInfo, error) {
+	return s.getHashedSet(object).ListObjectParts(bucket, object, uploadID, partNumberMarker, maxParts)
+}
+
+// AbortMultipartUpload - aborts a multipart upload in the set of the object.
+func (s *xlSets) AbortMultipartUpload(bucket, object, uploadID string) error {
+	return s.getHashedSet(object).AbortMultipartUpload(bucket, object, uploadID)
+}
+
+// CompleteMultipartUpload - completes a multipart upload in the set of the object.
+func (s *xlSets) CompleteMultipartUpload(bucket, object, uploadID string, uploadedParts []completePart) (ObjectInfo, error) {
+	return s.getHashedSet(object).CompleteMultipartUpload(bucket, object, uploadID, uploadedParts)
+}
+
+/// Healing operations
+
+// HealBucket - heals the bucket in all sets.
+func (s *xlSets) HealBucket(bucket string) error {
+	return firstErr(s.forEachSet(func(index int, set *xlObjects) error {
+		return set.HealBucket(bucket)
+	}))
+}
+
+// ListBucketsHeal - lists the buckets which need healing in any set.
+func (s *xlSets) ListBucketsHeal() ([]BucketInfo, error) {
+	results := make([][]BucketInfo, len(s.sets))
+	errs := s.forEachSet(func(index int, set *xlObjects) (err error) {
+		results[index], err = set.ListBucketsHeal()
+		return err
+	})
+	if err := firstErr(errs); err != nil {
+		return nil, err
+	}
+	var buckets []BucketInfo
+	found := make(map[string]struct{})
+	for _, result := range results {
+		for _, bucket := range result {
+			if _, ok := found[bucket.Name]; ok {
+				continue
+			}
+			found[bucket.Name] = struct{}{}
+			buckets = append(buckets, bucket)
+		}
+	}
+	sort.Sort(byBucketName(buckets))
+	return buckets, nil
+}
+
+// HealObject - heals an object in its set.
+func (s *xlSets) HealObject(bucket, object string) (int, int, error) {
+	return s.getHashedSet(object).HealObject(bucket, object)
+}
+
+// HealObjectDisks - heals an object in its set, reporting the state
+// of each disk of the set. Previous versions are in the set of their
+// object.
+func (s *xlSets) HealObjectDisks(bucket, object string) (HealObjectResult, error) {
+	_, owner := versionOwner(bucket, object)
+	index := s.getHashedSetIndex(owner)
+	result, err := s.sets[index].HealObjectDisks(bucket, object)
+	result.Set = index
+	return result, err
+}
+
+// ListUploadsHeal - lists the multipart uploads which need healing in all sets.
+func (s *xlSets) ListUploadsHeal(bucket, prefix, marker, uploadIDMarker, delimiter string, maxUploads int) (ListMultipartsInfo, error) {
+	return s.listSetsMultiparts(maxUploads, func(set *xlObjects) (ListMultipartsInfo, error) {
+		return set.ListUploadsHeal(bucket, prefix, marker, s.getUploadIDMarker(set, marker, uploadIDMarker), delimiter, maxUploads)
+	})
+}
+
+// scrubObject - verifies an object in its set, previous versions are
+// in the set of their object.
+func (s *xlSets) scrubObject(bucket, object string, limiter *scrubRateLimiter) (scrubObjectResult, error) {
+	_, owner := versionOwner(bucket, object)
+	return s.getHashedSet(owner).scrubObject(bucket, object, limiter)
+}
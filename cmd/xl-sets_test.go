@@ -0,0 +1,405 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// Tests the number of disks of each erasure set.
+func TestGetSetDriveCount(t *testing.T) {
+	testCases := []struct {
+		totalDisks    int
+		setDriveCount int
+	}{
+		{1, 0},
+		{2, 0},
+		{4, 4},
+		{5, 0},
+		{12, 12},
+		{16, 16},
+		{18, 6},
+		{24, 12},
+		{32, 16},
+		{64, 16},
+		{17, 0},
+	}
+	for i, testCase := range testCases {
+		if got := getSetDriveCount(testCase.totalDisks); got != testCase.setDriveCount {
+			t.Errorf("Test %d: expected %d disks per set for %d disks, got %d", i+1, testCase.setDriveCount, testCase.totalDisks, got)
+		}
+	}
+}
+
+// Tests that the disks of each erasure set are spread across hosts.
+func TestGetSetIndexes(t *testing.T) {
+	testCases := []struct {
+		hosts         []string
+		setDriveCount int
+		setIndexes    [][]int
+	}{
+		// Disks of a single host are in JBOD order.
+		{make([]string, 8), 4, [][]int{{0, 1, 2, 3}, {4, 5, 6, 7}}},
+		{make([]string, 4), 4, [][]int{{0, 1, 2, 3}}},
+		// Each set takes a disk from each host in turn.
+		{
+			[]string{"a", "a", "a", "a", "b", "b", "b", "b"}, 4,
+			[][]int{{0, 4, 1, 5}, {2, 6, 3, 7}},
+		},
+		{
+			[]string{"a", "b", "c", "d", "a", "b", "c", "d"}, 4,
+			[][]int{{0, 1, 2, 3}, {4, 5, 6, 7}},
+		},
+		// Hosts with fewer disks run out first.
+		{
+			[]string{"a", "a", "a", "a", "a", "a", "b", "b"}, 4,
+			[][]int{{0, 6, 1, 7}, {2, 3, 4, 5}},
+		},
+	}
+	for i, testCase := range testCases {
+		setIndexes := getSetIndexes(testCase.hosts, testCase.setDriveCount)
+		if !reflect.DeepEqual(setIndexes, testCase.setIndexes) {
+			t.Errorf("Test %d: expected sets %v, got %v", i+1, testCase.setIndexes, setIndexes)
+		}
+	}
+}
+
+// Tests that fresh disks of several hosts are formatted into erasure
+// sets spread across the hosts, and healing keeps the layout.
+func TestFormatXLSetsAcrossHosts(t *testing.T) {
+	root, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(root)
+
+	fsDirs, err := getRandomDisks(32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeRoots(fsDirs)
+	storageDisks, err := initStorageDisks(mustGetNewEndpointList(fsDirs...))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 4 hosts of 8 disks each, listed host by host.
+	var args []string
+	for host := 1; host <= 4; host++ {
+		for disk := 1; disk <= 8; disk++ {
+			args = append(args, fmt.Sprintf("http://10.0.0.%d:9000/disk%d", host, disk))
+		}
+	}
+	endpoints := mustGetNewEndpointList(args...)
+
+	if err = initFormatXL(endpoints, storageDisks); err != nil {
+		t.Fatal(err)
+	}
+	_, setIndexes, err := loadFormatXL(storageDisks, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(setIndexes) != 2 {
+		t.Fatalf("Expected 2 sets, got %d", len(setIndexes))
+	}
+	for i, indexes := range setIndexes {
+		hostDisks := make(map[string]int)
+		for _, index := range indexes {
+			hostDisks[endpoints[index].Host]++
+		}
+		if len(hostDisks) != 4 {
+			t.Fatalf("Set %d: expected disks of 4 hosts, got %v", i, hostDisks)
+		}
+		for host, count := range hostDisks {
+			if count != 4 {
+				t.Fatalf("Set %d: expected 4 disks of host %s, got %d", i, host, count)
+			}
+		}
+	}
+
+	// The object layer serves each set from the disks of its layout.
+	obj, err := newXLObjects(storageDisks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := obj.(*xlSets)
+	for i, indexes := range setIndexes {
+		for j, index := range indexes {
+			if s.sets[i].storageDisks[j] != storageDisks[index] {
+				t.Fatalf("Set %d: expected disk %d at position %d", i, index, j)
+			}
+		}
+	}
+	obj.Shutdown()
+
+	// A fresh disk takes the place of the disk it replaces.
+	if err = storageDisks[9].DeleteFile(minioMetaBucket, formatConfigFile); err != nil {
+		t.Fatal(err)
+	}
+	if err = healFormatXLFreshDisks(endpoints, storageDisks); err != nil {
+		t.Fatal(err)
+	}
+	_, healedIndexes, err := loadFormatXL(storageDisks, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(healedIndexes, setIndexes) {
+		t.Fatalf("Expected sets %v after healing, got %v", setIndexes, healedIndexes)
+	}
+}
+
+// Tests validation of the erasure sets of a format.
+func TestCheckFormatXLSets(t *testing.T) {
+	jbod := make([]string, 8)
+	for i := range jbod {
+		jbod[i] = mustGetUUID()
+	}
+
+	testCases := []struct {
+		sets       [][]string
+		shouldPass bool
+	}{
+		// Valid sets.
+		{formatXLSets(jbod, getSetIndexes(make([]string, 8), 4)), true},
+		{formatXLSets(jbod, getSetIndexes(make([]string, 8), 8)), true},
+		// No sets.
+		{nil, false},
+		{formatXLSets(jbod, getSetIndexes(make([]string, 8), 2)), true},
+		// Sets without disks.
+		{[][]string{nil}, false},
+		// Sets of a different number of disks.
+		{[][]string{jbod[:4], jbod[4:6], jbod[6:]}, false},
+		// Sets not in JBOD order.
+		{[][]string{jbod[4:], jbod[:4]}, true},
+		{formatXLSets(jbod, [][]int{{0, 2, 4, 6}, {1, 3, 5, 7}}), true},
+		// Sets with a disk twice.
+		{formatXLSets(jbod, [][]int{{0, 2, 4, 6}, {1, 3, 5, 6}}), false},
+		// Sets missing disks of the JBOD.
+		{[][]string{jbod[:4]}, false},
+	}
+	for i, testCase := range testCases {
+		format := &formatConfigV1{
+			Version: formatFileV1,
+			Format:  formatBackendXL,
+			XL: &xlFormat{
+				Version: xlFormatBackendV2,
+				Disk:    jbod[0],
+				JBOD:    jbod,
+				Sets:    testCase.sets,
+			},
+		}
+		err := checkFormatXLValue(format)
+		if testCase.shouldPass && err != nil {
+			t.Errorf("Test %d: expected to pass, failed with %s", i+1, err)
+		}
+		if !testCase.shouldPass && err == nil {
+			t.Errorf("Test %d: expected to fail, passed", i+1)
+		}
+	}
+}
+
+// Tests the object layer over disks divided into erasure sets.
+func TestXLSets(t *testing.T) {
+	root, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(root)
+
+	fsDirs, err := getRandomDisks(32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeRoots(fsDirs)
+
+	obj, storageDisks, err := initObjectLayer(mustGetNewEndpointList(fsDirs...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, ok := obj.(*xlSets)
+	if !ok {
+		t.Fatalf("Expected erasure sets object layer, got %T", obj)
+	}
+	if len(s.sets) != 2 || s.setDriveCount != 16 {
+		t.Fatalf("Expected 2 sets of 16 disks, got %d sets of %d disks", len(s.sets), s.setDriveCount)
+	}
+
+	// The set layout is saved in `format.json`.
+	format, err := loadFormat(storageDisks[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if format.XL.Version != xlFormatBackendV2 || len(format.XL.Sets) != 2 {
+		t.Fatalf("Expected format version %s with 2 sets, got version %s with %d sets",
+			xlFormatBackendV2, format.XL.Version, len(format.XL.Sets))
+	}
+
+	bucket := "bucket"
+	if err = obj.MakeBucketWithLocation(bucket, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	// Objects are spread over both sets.
+	var names []string
+	setObjects := make(map[*xlObjects][]string)
+	for i := 0; i < 20; i++ {
+		name := fmt.Sprintf("dir%d/object%d", i%3, i)
+		if _, err = obj.PutObject(bucket, name, int64(len(name)), bytes.NewReader([]byte(name)), nil, ""); err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, name)
+		set := s.getHashedSet(name)
+		setObjects[set] = append(setObjects[set], name)
+		if _, err = set.GetObjectInfo(bucket, name); err != nil {
+			t.Fatalf("Object %s not found in its set: %s", name, err)
+		}
+	}
+	if len(setObjects) != 2 {
+		t.Fatalf("Expected objects in both sets, found objects in %d sets", len(setObjects))
+	}
+	sort.Strings(names)
+
+	// Listing in pages merges the sets in lexical order.
+	var listed []string
+	marker := ""
+	for {
+		result, lerr := obj.ListObjects(bucket, "", marker, "", 3)
+		if lerr != nil {
+			t.Fatal(lerr)
+		}
+		if len(result.Objects) > 3 {
+			t.Fatalf("Expected at most 3 objects, got %d", len(result.Objects))
+		}
+		for _, objInfo := range result.Objects {
+			listed = append(listed, objInfo.Name)
+		}
+		if !result.IsTruncated {
+			break
+		}
+		marker = result.NextMarker
+	}
+	if !reflect.DeepEqual(listed, names) {
+		t.Fatalf("Expected listing %v, got %v", names, listed)
+	}
+
+	// Directories of both sets are listed once.
+	result, err := obj.ListObjects(bucket, "", "", slashSeparator, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"dir0/", "dir1/", "dir2/"}; !reflect.DeepEqual(result.Prefixes, expected) {
+		t.Fatalf("Expected prefixes %v, got %v", expected, result.Prefixes)
+	}
+
+	// Copy an object to a name in the other set.
+	var srcObject, dstObject string
+	for set, setNames := range setObjects {
+		if set == s.sets[0] {
+			srcObject = setNames[0]
+		} else {
+			dstObject = setNames[0] + "-copy"
+		}
+	}
+	for s.getHashedSet(dstObject) == s.getHashedSet(srcObject) {
+		dstObject += "-copy"
+	}
+	if _, err = obj.CopyObject(bucket, srcObject, bucket, dstObject, map[string]string{}); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err = obj.GetObject(bucket, dstObject, 0, int64(len(srcObject)), &buf); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != srcObject {
+		t.Fatalf("Expected copied data %s, got %s", srcObject, buf.String())
+	}
+
+	// Multipart uploads of both sets are listed.
+	uploadObjects := []string{srcObject + "-upload", dstObject + "-upload"}
+	for s.getHashedSet(uploadObjects[1]) == s.getHashedSet(uploadObjects[0]) {
+		uploadObjects[1] += "-upload"
+	}
+	for _, object := range uploadObjects {
+		if _, err = obj.NewMultipartUpload(bucket, object, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sort.Strings(uploadObjects)
+	uploads, err := obj.ListMultipartUploads(bucket, "", "", "", "", 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var uploaded []string
+	for _, upload := range uploads.Uploads {
+		uploaded = append(uploaded, upload.Object)
+	}
+	if !reflect.DeepEqual(uploaded, uploadObjects) {
+		t.Fatalf("Expected uploads of %v, got %v", uploadObjects, uploaded)
+	}
+
+	// Listing uploads in pages continues in the other set.
+	uploaded = nil
+	keyMarker, uploadIDMarker := "", ""
+	for {
+		page, lerr := obj.ListMultipartUploads(bucket, "", keyMarker, uploadIDMarker, "", 1)
+		if lerr != nil {
+			t.Fatal(lerr)
+		}
+		if len(page.Uploads) > 1 {
+			t.Fatalf("Expected at most 1 upload, got %d", len(page.Uploads))
+		}
+		for _, upload := range page.Uploads {
+			uploaded = append(uploaded, upload.Object)
+		}
+		if !page.IsTruncated {
+			break
+		}
+		keyMarker, uploadIDMarker = page.NextKeyMarker, page.NextUploadIDMarker
+	}
+	if !reflect.DeepEqual(uploaded, uploadObjects) {
+		t.Fatalf("Expected uploads of %v, got %v", uploadObjects, uploaded)
+	}
+
+	// Complete an upload with a part copied from the other set.
+	object := uploads.Uploads[0].Object
+	uploadID := uploads.Uploads[0].UploadID
+	for set, setNames := range setObjects {
+		if set != s.getHashedSet(object) {
+			srcObject = setNames[0]
+		}
+	}
+	partInfo, err := obj.CopyObjectPart(bucket, srcObject, bucket, object, uploadID, 1, 0, int64(len(srcObject)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := []completePart{{PartNumber: 1, ETag: partInfo.ETag}}
+	if _, err = obj.CompleteMultipartUpload(bucket, object, uploadID, parts); err != nil {
+		t.Fatal(err)
+	}
+
+	// Buckets with objects in any set are not deleted.
+	if err = obj.DeleteBucket(bucket); err == nil {
+		t.Fatal("Expected deleting a bucket with objects to fail")
+	}
+	if _, err = obj.GetBucketInfo(bucket); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -215,6 +215,18 @@ func (xl xlObjects) DeleteBucket(bucket string) error {
 		return BucketNameInvalid{Bucket: bucket}
 	}
 
+	// Previous versions in buckets with object lock may be protected,
+	// such buckets can only be removed once all versions are removed.
+	if isBucketObjectLockEnabled(bucket) {
+		names, _, err := xl.listVersionedObjects(bucket, "", "", 1)
+		if err != nil {
+			return err
+		}
+		if len(names) > 0 {
+			return traceError(BucketNotEmpty{Bucket: bucket})
+		}
+	}
+
 	// Collect if all disks report volume not found.
 	var wg = &sync.WaitGroup{}
 	var dErrs = make([]error, len(xl.storageDisks))
@@ -237,6 +249,15 @@ func (xl xlObjects) DeleteBucket(bucket string) error {
 			}
 			// Cleanup all the previously incomplete multiparts.
 			err = cleanupDir(disk, minioMetaMultipartBucket, bucket)
+			if err != nil {
+				if errorCause(err) == errVolumeNotFound {
+					return
+				}
+				dErrs[index] = err
+				return
+			}
+			// Cleanup all the previous object versions.
+			err = cleanupDir(disk, minioMetaVersionsBucket, bucket)
 			if err != nil {
 				if errorCause(err) == errVolumeNotFound {
 					return
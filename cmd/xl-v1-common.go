@@ -67,14 +67,15 @@ func (xl xlObjects) isObject(bucket, prefix string) (ok bool) {
 	return false
 }
 
-// Calculate the space occupied by an object in a single disk
-func (xl xlObjects) sizeOnDisk(fileSize int64, blockSize int64, dataBlocks int) int64 {
+// Calculate the space occupied by an object in a single disk, along
+// with the checksums of its erasure blocks for streaming bit-rot algos.
+func sizeOnDisk(fileSize int64, blockSize int64, dataBlocks int, algo HashAlgo) int64 {
 	numBlocks := fileSize / blockSize
 	chunkSize := getChunkSize(blockSize, dataBlocks)
-	sizeInDisk := numBlocks * chunkSize
+	sizeInDisk := numBlocks * (chunkSize + bitrotHashSize(algo))
 	remaining := fileSize % blockSize
 	if remaining > 0 {
-		sizeInDisk += getChunkSize(remaining, dataBlocks)
+		sizeInDisk += getChunkSize(remaining, dataBlocks) + bitrotHashSize(algo)
 	}
 
 	return sizeInDisk
@@ -17,7 +17,6 @@
 package cmd
 
 import (
-	"encoding/hex"
 	"path/filepath"
 	"time"
 )
@@ -210,13 +209,13 @@ func xlHealStat(xl xlObjects, partsMetadata []xlMetaV1, errs []error) HealObject
 	for i, err := range errs {
 		// xl.json is not found, which implies the erasure
 		// coded blocks are unavailable in the corresponding disk.
-		// First half of the disks are data and the rest are parity.
+		// The first data blocks in the distribution are data, the rest are parity.
 		switch realErr := errorCause(err); realErr {
 		case errDiskNotFound:
 			disksMissing = true
 			fallthrough
 		case errFileNotFound:
-			if xlMeta.Erasure.Distribution[i]-1 < xl.dataBlocks {
+			if xlMeta.Erasure.Distribution[i]-1 < xlMeta.Erasure.DataBlocks {
 				missingDataCount++
 			} else {
 				missingParityCount++
@@ -260,30 +259,28 @@ func disksWithAllParts(onlineDisks []StorageAPI, partsMetadata []xlMetaV1, errs
 		// parts. This is considered an outdated disk, since
 		// it needs healing too.
 		for _, part := range partsMetadata[diskIndex].Parts {
-			// compute blake2b sum of part.
+			// verify the checksums of the part.
 			partPath := filepath.Join(object, part.Name)
 			checkSumInfo := partsMetadata[diskIndex].Erasure.GetCheckSumInfo(part.Name)
-			hash := newHash(checkSumInfo.Algorithm)
-			blakeBytes, hErr := hashSum(onlineDisk, bucket, partPath, hash)
+			_, hErr := bitrotVerifyFile(onlineDisk, bucket, partPath, part.Size, partsMetadata[diskIndex].Erasure, checkSumInfo, nil)
 			if hErr == errFileNotFound {
 				errs[diskIndex] = errFileNotFound
 				availableDisks[diskIndex] = nil
 				break
 			}
 
-			if hErr != nil && hErr != errFileNotFound {
-				return nil, nil, traceError(hErr)
-			}
-
-			blakeSum := hex.EncodeToString(blakeBytes)
-			// if blake2b sum doesn't match for a part
+			// if the checksums don't match for a part
 			// then this disk is outdated and needs
 			// healing.
-			if blakeSum != checkSumInfo.Hash {
+			if _, ok := hErr.(hashMismatchError); ok {
 				errs[diskIndex] = errFileNotFound
 				availableDisks[diskIndex] = nil
 				break
 			}
+
+			if hErr != nil {
+				return nil, nil, traceError(hErr)
+			}
 			availableDisks[diskIndex] = onlineDisk
 		}
 	}
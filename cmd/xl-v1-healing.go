@@ -24,8 +24,9 @@ import (
 )
 
 // healFormatXL - heals missing `format.json` on freshly or corrupted
-// disks (missing format.json but does have erasure coded data in it).
-func healFormatXL(storageDisks []StorageAPI) (err error) {
+// disks (missing format.json but does have erasure coded data in it),
+// storageDisks are the disks of endpoints.
+func healFormatXL(endpoints EndpointList, storageDisks []StorageAPI) (err error) {
 	// Attempt to load all `format.json`.
 	formatConfigs, sErrs := loadAllFormats(storageDisks)
 
@@ -39,12 +40,12 @@ func healFormatXL(storageDisks []StorageAPI) (err error) {
 	// Handles different cases properly.
 	switch reduceFormatErrs(sErrs, len(storageDisks)) {
 	case errCorruptedFormat:
-		if err = healFormatXLCorruptedDisks(storageDisks); err != nil {
+		if err = healFormatXLCorruptedDisks(endpoints, storageDisks); err != nil {
 			return fmt.Errorf("Unable to repair corrupted format, %s", err)
 		}
 	case errSomeDiskUnformatted:
 		// All drives online but some report missing format.json.
-		if err = healFormatXLFreshDisks(storageDisks); err != nil {
+		if err = healFormatXLFreshDisks(endpoints, storageDisks); err != nil {
 			// There was an unexpected unrecoverable error during healing.
 			return fmt.Errorf("Unable to heal backend %s", err)
 		}
@@ -64,12 +65,13 @@ func (xl xlObjects) HealBucket(bucket string) error {
 	}
 
 	// Heal bucket.
-	if err := healBucket(xl.storageDisks, bucket, xl.writeQuorum); err != nil {
-		return err
+	err := healBucket(xl.storageDisks, bucket, xl.writeQuorum)
+	if err == nil {
+		// Proceed to heal bucket metadata.
+		err = healBucketMetadata(xl.storageDisks, bucket, xl.readQuorum)
 	}
-
-	// Proceed to heal bucket metadata.
-	return healBucketMetadata(xl.storageDisks, bucket, xl.readQuorum)
+	globalHealStats.updateBucket(err)
+	return err
 }
 
 // Heal bucket - create buckets on disks where it does not exist.
@@ -125,7 +127,7 @@ func healBucketMetadata(storageDisks []StorageAPI, bucket string, readQuorum int
 		metaLock.RLock()
 		defer metaLock.RUnlock()
 		// Heals the given file at metaPath.
-		if _, _, err := healObject(storageDisks, minioMetaBucket, metaPath, readQuorum); err != nil && !isErrObjectNotFound(err) {
+		if _, err := healObject(storageDisks, minioMetaBucket, metaPath, readQuorum); err != nil && !isErrObjectNotFound(err) {
 			return err
 		} // Success.
 		return nil
@@ -312,19 +314,48 @@ func quickHeal(storageDisks []StorageAPI, writeQuorum int, readQuorum int) error
 	return nil
 }
 
-// Heals an object only the corrupted/missing erasure blocks.
-func healObject(storageDisks []StorageAPI, bucket string, object string, quorum int) (int, int, error) {
+// newHealObjectResult - returns the state of an object on each disk
+// given the errors reading its xl.json, nothing is healed yet.
+func newHealObjectResult(storageDisks []StorageAPI, errs []error) HealObjectResult {
+	result := HealObjectResult{
+		Disks:  make([]string, len(storageDisks)),
+		Before: make([]healDiskState, len(storageDisks)),
+	}
+	for index, disk := range storageDisks {
+		if disk != nil {
+			result.Disks[index] = disk.String()
+		}
+		err := errorCause(errs[index])
+		switch {
+		case err == nil:
+			result.Before[index] = healDiskOK
+		case isErrIgnored(err, baseErrs...):
+			result.Before[index] = healDiskOffline
+		case err == errFileNotFound, err == errVolumeNotFound:
+			result.Before[index] = healDiskMissing
+		default:
+			result.Before[index] = healDiskCorrupted
+		}
+	}
+	result.After = append([]healDiskState(nil), result.Before...)
+	return result
+}
+
+// Heals an object only the corrupted/missing erasure blocks, returns
+// the state of the object on each disk before and after healing.
+func healObject(storageDisks []StorageAPI, bucket string, object string, quorum int) (HealObjectResult, error) {
 	partsMetadata, errs := readAllXLMetadata(storageDisks, bucket, object)
+	result := newHealObjectResult(storageDisks, errs)
 	// readQuorum suffices for xl.json since we use monotonic
 	// system time to break the tie when a split-brain situation
 	// arises.
 	if reducedErr := reduceReadQuorumErrs(errs, nil, quorum); reducedErr != nil {
-		return 0, 0, toObjectErr(reducedErr, bucket, object)
+		return result, toObjectErr(reducedErr, bucket, object)
 	}
 
 	if !xlShouldHeal(storageDisks, partsMetadata, errs, bucket, object) {
 		// There is nothing to heal.
-		return 0, 0, nil
+		return result, nil
 	}
 
 	// List of disks having latest version of the object.
@@ -333,16 +364,7 @@ func healObject(storageDisks []StorageAPI, bucket string, object string, quorum
 	// List of disks having all parts as per latest xl.json.
 	availableDisks, errs, aErr := disksWithAllParts(latestDisks, partsMetadata, errs, bucket, object)
 	if aErr != nil {
-		return 0, 0, toObjectErr(aErr, bucket, object)
-	}
-
-	// Number of disks which don't serve data.
-	numOfflineDisks := 0
-	for index, disk := range storageDisks {
-		switch {
-		case disk == nil, errs[index] == errDiskNotFound:
-			numOfflineDisks++
-		}
+		return result, toObjectErr(aErr, bucket, object)
 	}
 
 	// Number of disks which have all parts of the given object.
@@ -354,30 +376,35 @@ func healObject(storageDisks []StorageAPI, bucket string, object string, quorum
 		}
 	}
 
-	// If less than read quorum number of disks have all the parts
+	// Latest xlMetaV1 for reference. If a valid metadata is not
+	// present, it is as good as object not found.
+	latestMeta, pErr := pickValidXLMeta(partsMetadata, modTime)
+	if pErr != nil {
+		return result, toObjectErr(pErr, bucket, object)
+	}
+
+	// If less than data blocks number of disks have all the parts
 	// of the data, we can't reconstruct the erasure-coded data.
-	if numAvailableDisks < quorum {
-		return 0, 0, toObjectErr(errXLReadQuorum, bucket, object)
+	if numAvailableDisks < latestMeta.Erasure.DataBlocks {
+		return result, toObjectErr(errXLReadQuorum, bucket, object)
 	}
 
 	// List of disks having outdated version of the object or missing object.
 	outDatedDisks := outDatedDisks(storageDisks, availableDisks, errs, partsMetadata,
 		bucket, object)
 
-	// Number of disks that had outdated content of the given
-	// object and are online to be healed.
-	numHealedDisks := 0
-	for _, disk := range outDatedDisks {
-		if disk != nil {
-			numHealedDisks++
+	// Disks to be healed, before outdated disks are shuffled. Disks
+	// with a stale xl.json or missing or corrupted parts are outdated.
+	healDisks := make([]bool, len(outDatedDisks))
+	for index, disk := range outDatedDisks {
+		if disk == nil {
+			continue
+		}
+		healDisks[index] = true
+		if result.Before[index] == healDiskOK {
+			result.Before[index] = healDiskOutdated
+			result.After[index] = healDiskOutdated
 		}
-	}
-
-	// Latest xlMetaV1 for reference. If a valid metadata is not
-	// present, it is as good as object not found.
-	latestMeta, pErr := pickValidXLMeta(partsMetadata, modTime)
-	if pErr != nil {
-		return 0, 0, toObjectErr(pErr, bucket, object)
 	}
 
 	for index, disk := range outDatedDisks {
@@ -409,14 +436,14 @@ func healObject(storageDisks []StorageAPI, bucket string, object string, quorum
 		for _, part := range outDatedMeta.Parts {
 			dErr := disk.DeleteFile(bucket, pathJoin(object, part.Name))
 			if dErr != nil && !isErr(dErr, errFileNotFound) {
-				return 0, 0, toObjectErr(traceError(dErr), bucket, object)
+				return result, toObjectErr(traceError(dErr), bucket, object)
 			}
 		}
 
 		// Delete xl.json file. Ignore if xl.json not found.
 		dErr := disk.DeleteFile(bucket, pathJoin(object, xlMetaJSONFile))
 		if dErr != nil && !isErr(dErr, errFileNotFound) {
-			return 0, 0, toObjectErr(traceError(dErr), bucket, object)
+			return result, toObjectErr(traceError(dErr), bucket, object)
 		}
 	}
 
@@ -445,7 +472,7 @@ func healObject(storageDisks []StorageAPI, bucket string, object string, quorum
 			minioMetaTmpBucket, pathJoin(tmpID, partName),
 			partSize, erasure.BlockSize, erasure.DataBlocks, erasure.ParityBlocks, sumInfo.Algorithm)
 		if hErr != nil {
-			return 0, 0, toObjectErr(hErr, bucket, object)
+			return result, toObjectErr(hErr, bucket, object)
 		}
 		for index, sum := range checkSums {
 			if outDatedDisks[index] != nil {
@@ -470,7 +497,7 @@ func healObject(storageDisks []StorageAPI, bucket string, object string, quorum
 	// Generate and write `xl.json` generated from other disks.
 	outDatedDisks, aErr = writeUniqueXLMetadata(outDatedDisks, minioMetaTmpBucket, tmpID, partsMetadata, diskCount(outDatedDisks))
 	if aErr != nil {
-		return 0, 0, toObjectErr(aErr, bucket, object)
+		return result, toObjectErr(aErr, bucket, object)
 	}
 
 	// Rename from tmp location to the actual location.
@@ -481,15 +508,20 @@ func healObject(storageDisks []StorageAPI, bucket string, object string, quorum
 		// Remove any lingering partial data from current namespace.
 		aErr = disk.DeleteFile(bucket, retainSlash(object))
 		if aErr != nil && aErr != errFileNotFound {
-			return 0, 0, toObjectErr(traceError(aErr), bucket, object)
+			return result, toObjectErr(traceError(aErr), bucket, object)
 		}
 		// Attempt a rename now from healed data to final location.
 		aErr = disk.RenameFile(minioMetaTmpBucket, retainSlash(tmpID), bucket, retainSlash(object))
 		if aErr != nil {
-			return 0, 0, toObjectErr(traceError(aErr), bucket, object)
+			return result, toObjectErr(traceError(aErr), bucket, object)
 		}
 	}
-	return numOfflineDisks, numHealedDisks, nil
+	for index, healed := range healDisks {
+		if healed {
+			result.After[index] = healDiskOK
+		}
+	}
+	return result, nil
 }
 
 // HealObject heals a given object for all its missing entries.
@@ -497,11 +529,28 @@ func healObject(storageDisks []StorageAPI, bucket string, object string, quorum
 // and later the disk comes back up again, heal on the object
 // should delete it.
 func (xl xlObjects) HealObject(bucket, object string) (int, int, error) {
+	result, err := xl.HealObjectDisks(bucket, object)
+	if err != nil {
+		return 0, 0, err
+	}
+	return result.offlineDisks(), result.healedDisks(), nil
+}
+
+// HealObjectDisks heals a given object like HealObject, returns the
+// state of the object on each disk before and after healing.
+func (xl xlObjects) HealObjectDisks(bucket, object string) (HealObjectResult, error) {
 	// Lock the object before healing.
-	objectLock := globalNSMutex.NewNSLock(bucket, object)
+	objectLock := globalNSMutex.NewNSLock(versionOwner(bucket, object))
 	objectLock.RLock()
 	defer objectLock.RUnlock()
 
-	// Heal the object.
-	return healObject(xl.storageDisks, bucket, object, xl.readQuorum)
+	return xl.healObjectDisks(bucket, object)
+}
+
+// healObjectDisks - heals an object like HealObjectDisks, the caller
+// holds the object lock.
+func (xl xlObjects) healObjectDisks(bucket, object string) (HealObjectResult, error) {
+	result, err := healObject(xl.storageDisks, bucket, object, xl.readQuorum)
+	globalHealStats.updateObject(result.healedDisks(), err)
+	return result, err
 }
@@ -43,7 +43,7 @@ func TestHealFormatXL(t *testing.T) {
 		t.Fatal(err)
 	}
 	xl := obj.(*xlObjects)
-	if err = healFormatXL(xl.storageDisks); err != nil {
+	if err = healFormatXL(nil, xl.storageDisks); err != nil {
 		t.Fatal("Got an unexpected error: ", err)
 	}
 
@@ -64,7 +64,7 @@ func TestHealFormatXL(t *testing.T) {
 		xl.storageDisks[i] = nil
 	}
 
-	if err = healFormatXL(xl.storageDisks); err != errXLReadQuorum {
+	if err = healFormatXL(nil, xl.storageDisks); err != errXLReadQuorum {
 		t.Fatal("Got an unexpected error: ", err)
 	}
 	removeRoots(fsDirs)
@@ -87,7 +87,7 @@ func TestHealFormatXL(t *testing.T) {
 		}
 		xl.storageDisks[i] = newNaughtyDisk(posixDisk, nil, errDiskFull)
 	}
-	if err = healFormatXL(xl.storageDisks); err != errXLReadQuorum {
+	if err = healFormatXL(nil, xl.storageDisks); err != errXLReadQuorum {
 		t.Fatal("Got an unexpected error: ", err)
 	}
 	removeRoots(fsDirs)
@@ -104,7 +104,7 @@ func TestHealFormatXL(t *testing.T) {
 	}
 	xl = obj.(*xlObjects)
 	xl.storageDisks[0] = nil
-	if err = healFormatXL(xl.storageDisks); err != nil {
+	if err = healFormatXL(nil, xl.storageDisks); err != nil {
 		t.Fatal("Got an unexpected error: ", err)
 	}
 	removeRoots(fsDirs)
@@ -125,7 +125,7 @@ func TestHealFormatXL(t *testing.T) {
 			t.Fatal(err)
 		}
 	}
-	if err = healFormatXL(xl.storageDisks); err != nil {
+	if err = healFormatXL(nil, xl.storageDisks); err != nil {
 		t.Fatal("Got an unexpected error: ", err)
 	}
 	removeRoots(fsDirs)
@@ -146,7 +146,7 @@ func TestHealFormatXL(t *testing.T) {
 			t.Fatal(err)
 		}
 	}
-	if err = healFormatXL(xl.storageDisks); err == nil {
+	if err = healFormatXL(nil, xl.storageDisks); err == nil {
 		t.Fatal("Should get a json parsing error, ")
 	}
 	removeRoots(fsDirs)
@@ -167,7 +167,7 @@ func TestHealFormatXL(t *testing.T) {
 			t.Fatal(err)
 		}
 	}
-	if err = healFormatXL(xl.storageDisks); err != nil {
+	if err = healFormatXL(nil, xl.storageDisks); err != nil {
 		t.Fatal("Got an unexpected error: ", err)
 	}
 	removeRoots(fsDirs)
@@ -194,7 +194,7 @@ func TestHealFormatXL(t *testing.T) {
 	}
 	xl.storageDisks[3] = newNaughtyDisk(posixDisk, nil, errDiskNotFound)
 	expectedErr := fmt.Errorf("Unable to initialize format %s and %s", errSomeDiskOffline, errSomeDiskUnformatted)
-	if err = healFormatXL(xl.storageDisks); err != nil {
+	if err = healFormatXL(nil, xl.storageDisks); err != nil {
 		if err.Error() != expectedErr.Error() {
 			t.Fatal("Got an unexpected error: ", err)
 		}
@@ -220,7 +220,7 @@ func TestHealFormatXL(t *testing.T) {
 			t.Fatal(err)
 		}
 	}
-	if err = healFormatXL(xl.storageDisks); err != nil {
+	if err = healFormatXL(nil, xl.storageDisks); err != nil {
 		t.Fatal("Got an unexpected error: ", err)
 	}
 	removeRoots(fsDirs)
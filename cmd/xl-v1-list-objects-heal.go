@@ -68,8 +68,9 @@ func listDirHealFactory(isLeaf isLeafFunc, disks ...StorageAPI) listDirFunc {
 	return listDir
 }
 
-// listObjectsHeal - wrapper function implemented over file tree walk.
-func (xl xlObjects) listObjectsHeal(bucket, prefix, marker, delimiter string, maxKeys int) (loi ListObjectsInfo, e error) {
+// listObjectsHealWalk - lists the objects found on any of the disks,
+// wrapper function implemented over file tree walk.
+func (xl xlObjects) listObjectsHealWalk(bucket, prefix, marker, delimiter string, maxKeys int) (loi ListObjectsInfo, e error) {
 	// Default is recursive, if delimiter is set then list non recursive.
 	recursive := true
 	if delimiter == slashSeparator {
@@ -139,7 +140,24 @@ func (xl xlObjects) listObjectsHeal(bucket, prefix, marker, delimiter string, ma
 			result.Prefixes = append(result.Prefixes, objInfo.Name)
 			continue
 		}
+		result.Objects = append(result.Objects, objInfo)
+	}
+	return result, nil
+}
+
+// listObjectsHeal - lists the objects which need healing.
+func (xl xlObjects) listObjectsHeal(bucket, prefix, marker, delimiter string, maxKeys int) (loi ListObjectsInfo, e error) {
+	walkResult, err := xl.listObjectsHealWalk(bucket, prefix, marker, delimiter, maxKeys)
+	if err != nil {
+		return loi, err
+	}
 
+	result := ListObjectsInfo{
+		IsTruncated: walkResult.IsTruncated,
+		NextMarker:  walkResult.NextMarker,
+		Prefixes:    walkResult.Prefixes,
+	}
+	for _, objInfo := range walkResult.Objects {
 		// Check if the current object needs healing
 		objectLock := globalNSMutex.NewNSLock(bucket, objInfo.Name)
 		objectLock.RLock()
@@ -63,6 +63,10 @@ const (
 	HashBlake2b HashAlgo = "blake2b"
 	// HashSha256 represents the SHA256 hashing algorithm
 	HashSha256 HashAlgo = "sha256"
+	// HashHighwayHash256S represents the HighwayHash-256 hashing
+	// algorithm applied to every erasure block, the checksum of each
+	// block is stored in front of the block in the part file.
+	HashHighwayHash256S HashAlgo = "highwayhash256S"
 )
 
 // isValidHashAlgo - function that checks if the hash algorithm is
@@ -77,13 +81,18 @@ func isValidHashAlgo(algo HashAlgo) bool {
 }
 
 // Constant indicates current bit-rot algo used when creating objects.
-// Depending on the architecture we are choosing a different checksum.
-var bitRotAlgo = getDefaultBitRotAlgo()
-
-// Get the default bit-rot algo depending on the architecture.
-// Currently this function defaults to "blake2b" as the preferred
-// checksum algorithm on all architectures except ARM64. On ARM64
-// we use sha256 (optimized using sha2 instructions of ARM NEON chip).
+// Every erasure block carries its own checksum, so that reading a range
+// of a part only verifies the blocks of the range.
+var bitRotAlgo = HashHighwayHash256S
+
+// Constant indicates the bit-rot algo of parts without checksum
+// information in `xl.json`, hashing the entire part file.
+var legacyBitRotAlgo = getDefaultBitRotAlgo()
+
+// Get the default bit-rot algo of entire part files depending on the
+// architecture. Currently this function defaults to "blake2b" as the
+// preferred checksum algorithm on all architectures except ARM64. On
+// ARM64 we use sha256 (optimized using sha2 instructions of ARM NEON chip).
 func getDefaultBitRotAlgo() HashAlgo {
 	switch runtime.GOARCH {
 	case "arm64":
@@ -131,7 +140,7 @@ func (e erasureInfo) GetCheckSumInfo(partName string) (ckSum checkSumInfo) {
 			return sum
 		}
 	}
-	return checkSumInfo{Algorithm: bitRotAlgo}
+	return checkSumInfo{Algorithm: legacyBitRotAlgo}
 }
 
 // statInfo - carries stat information of the object.
@@ -155,12 +164,26 @@ type xlMetaV1 struct {
 	Meta map[string]string `json:"meta,omitempty"`
 	// Captures all the individual object `xl.json`.
 	Parts []objectPartInfo `json:"parts,omitempty"`
+	// Version ID of the object, empty for the `null` version.
+	VersionID string `json:"versionId,omitempty"`
+	// Set if this `xl.json` represents a delete marker.
+	DeleteMarker bool `json:"deleteMarker,omitempty"`
+}
+
+// versionInfo - version related fields of `xl.json`.
+type versionInfo struct {
+	VersionID    string
+	DeleteMarker bool
 }
 
 // XL metadata constants.
 const (
+	// XL meta version, parts may carry checksums of every erasure
+	// block as per their bit-rot algo.
+	xlMetaVersion = "1.0.2"
+
 	// XL meta version.
-	xlMetaVersion = "1.0.1"
+	xlMetaVersion101 = "1.0.1"
 
 	// XL meta version.
 	xlMetaVersion100 = "1.0.0"
@@ -196,7 +219,7 @@ func (m xlMetaV1) IsValid() bool {
 // Verifies if the backend format metadata is sane by validating
 // the version string and format style.
 func isXLMetaValid(version, format string) bool {
-	return ((version == xlMetaVersion || version == xlMetaVersion100) &&
+	return ((version == xlMetaVersion || version == xlMetaVersion101 || version == xlMetaVersion100) &&
 		format == xlMetaFormat)
 }
 
@@ -210,6 +233,9 @@ func (m xlMetaV1) ToObjectInfo(bucket, object string) ObjectInfo {
 		ModTime:         m.Stat.ModTime,
 		ContentType:     m.Meta["content-type"],
 		ContentEncoding: m.Meta["content-encoding"],
+		VersionID:       m.VersionID,
+		DeleteMarker:    m.DeleteMarker,
+		Parts:           m.Parts,
 	}
 
 	// Extract etag from metadata.
@@ -321,18 +347,18 @@ func (xl xlObjects) readXLMetaParts(bucket, object string) (xlMetaParts []object
 	return nil, reduceReadQuorumErrs(ignoredErrs, nil, xl.readQuorum)
 }
 
-// readXLMetaStat - return xlMetaV1.Stat and xlMetaV1.Meta from  one of the disks picked at random.
-func (xl xlObjects) readXLMetaStat(bucket, object string) (xlStat statInfo, xlMeta map[string]string, err error) {
+// readXLMetaStat - return xlMetaV1.Stat, xlMetaV1.Meta and version info from one of the disks picked at random.
+func (xl xlObjects) readXLMetaStat(bucket, object string) (xlStat statInfo, xlMeta map[string]string, vInfo versionInfo, err error) {
 	var ignoredErrs []error
 	for _, disk := range xl.getLoadBalancedDisks() {
 		if disk == nil {
 			ignoredErrs = append(ignoredErrs, errDiskNotFound)
 			continue
 		}
-		// parses only xlMetaV1.Meta and xlMeta.Stat
-		xlStat, xlMeta, err = readXLMetaStat(disk, bucket, object)
+		// parses only xlMetaV1.Meta, xlMeta.Stat and version info.
+		xlStat, xlMeta, vInfo, err = readXLMetaStat(disk, bucket, object)
 		if err == nil {
-			return xlStat, xlMeta, nil
+			return xlStat, xlMeta, vInfo, nil
 		}
 		// For any reason disk or bucket is not available continue
 		// and read from other disks.
@@ -341,11 +367,11 @@ func (xl xlObjects) readXLMetaStat(bucket, object string) (xlStat statInfo, xlMe
 			continue
 		}
 		// Error is not ignored, return right here.
-		return statInfo{}, nil, err
+		return statInfo{}, nil, versionInfo{}, err
 	}
 	// If all errors were ignored, reduce to maximal occurrence
 	// based on the read quorum.
-	return statInfo{}, nil, reduceReadQuorumErrs(ignoredErrs, nil, xl.readQuorum)
+	return statInfo{}, nil, versionInfo{}, reduceReadQuorumErrs(ignoredErrs, nil, xl.readQuorum)
 }
 
 // deleteXLMetadata - deletes `xl.json` on a single disk.
@@ -74,7 +74,7 @@ func testXLReadStat(obj ObjectLayer, instanceType string, disks []string, t *tes
 		}
 	}
 
-	_, _, err = obj.(*xlObjects).readXLMetaStat(bucketName, objectName)
+	_, _, _, err = obj.(*xlObjects).readXLMetaStat(bucketName, objectName)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -83,7 +83,7 @@ func testXLReadStat(obj ObjectLayer, instanceType string, disks []string, t *tes
 	removeDiskN(disks, 7)
 
 	// Removing disk shouldn't affect reading object info.
-	_, _, err = obj.(*xlObjects).readXLMetaStat(bucketName, objectName)
+	_, _, _, err = obj.(*xlObjects).readXLMetaStat(bucketName, objectName)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -92,7 +92,7 @@ func testXLReadStat(obj ObjectLayer, instanceType string, disks []string, t *tes
 		removeAll(path.Join(disk, bucketName))
 	}
 
-	_, _, err = obj.(*xlObjects).readXLMetaStat(bucketName, objectName)
+	_, _, _, err = obj.(*xlObjects).readXLMetaStat(bucketName, objectName)
 	if errorCause(err) != errVolumeNotFound {
 		t.Fatal(err)
 	}
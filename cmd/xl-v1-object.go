@@ -36,10 +36,10 @@ import (
 var objectOpIgnoredErrs = append(baseIgnoredErrs, errDiskAccessDenied)
 
 // prepareFile hints the bottom layer to optimize the creation of a new object
-func (xl xlObjects) prepareFile(bucket, object string, size int64, onlineDisks []StorageAPI, blockSize int64, dataBlocks int) error {
+func (xl xlObjects) prepareFile(bucket, object string, size int64, onlineDisks []StorageAPI, blockSize int64, dataBlocks int, algo HashAlgo, writeQuorum int) error {
 	pErrs := make([]error, len(onlineDisks))
 	// Calculate the real size of the part in one disk.
-	actualSize := xl.sizeOnDisk(size, blockSize, dataBlocks)
+	actualSize := sizeOnDisk(size, blockSize, dataBlocks, algo)
 	// Prepare object creation in a all disks
 	for index, disk := range onlineDisks {
 		if disk != nil {
@@ -51,7 +51,7 @@ func (xl xlObjects) prepareFile(bucket, object string, size int64, onlineDisks [
 			}
 		}
 	}
-	return reduceWriteQuorumErrs(pErrs, objectOpIgnoredErrs, xl.writeQuorum)
+	return reduceWriteQuorumErrs(pErrs, objectOpIgnoredErrs, writeQuorum)
 }
 
 /// Object Operations
@@ -62,7 +62,13 @@ func (xl xlObjects) prepareFile(bucket, object string, size int64, onlineDisks [
 func (xl xlObjects) CopyObject(srcBucket, srcObject, dstBucket, dstObject string, metadata map[string]string) (oi ObjectInfo, e error) {
 	// Read metadata associated with the object from all disks.
 	metaArr, errs := readAllXLMetadata(xl.storageDisks, srcBucket, srcObject)
-	if reducedErr := reduceReadQuorumErrs(errs, objectOpIgnoredErrs, xl.readQuorum); reducedErr != nil {
+
+	// Get quorum for this object.
+	readQuorum, writeQuorum, err := objectQuorumFromMeta(xl, metaArr, errs)
+	if err != nil {
+		return oi, toObjectErr(err, srcBucket, srcObject)
+	}
+	if reducedErr := reduceReadQuorumErrs(errs, objectOpIgnoredErrs, readQuorum); reducedErr != nil {
 		return oi, toObjectErr(reducedErr, srcBucket, srcObject)
 	}
 
@@ -75,32 +81,24 @@ func (xl xlObjects) CopyObject(srcBucket, srcObject, dstBucket, dstObject string
 		return oi, toObjectErr(err, srcBucket, srcObject)
 	}
 
-	// Reorder online disks based on erasure distribution order.
-	onlineDisks = shuffleDisks(onlineDisks, xlMeta.Erasure.Distribution)
-
 	// Length of the file to read.
 	length := xlMeta.Stat.Size
 
 	// Check if this request is only metadata update.
 	cpMetadataOnly := isStringEqual(pathJoin(srcBucket, srcObject), pathJoin(dstBucket, dstObject))
 	if cpMetadataOnly {
-		xlMeta.Meta = metadata
-		partsMetadata := make([]xlMetaV1, len(xl.storageDisks))
-		// Update `xl.json` content on each disks.
-		for index := range partsMetadata {
-			partsMetadata[index] = xlMeta
+		// Object lock settings can only be changed with PutObjectLock.
+		setObjectLock(metadata, getObjectLock(xlMeta.Meta))
+		// The erasure coding of the object stays, so does its storage class.
+		if class, ok := xlMeta.Meta[AmzStorageClass]; ok {
+			metadata[AmzStorageClass] = class
+		} else {
+			delete(metadata, AmzStorageClass)
 		}
-
-		tempObj := mustGetUUID()
-
-		// Write unique `xl.json` for each disk.
-		if onlineDisks, err = writeUniqueXLMetadata(onlineDisks, minioMetaTmpBucket, tempObj, partsMetadata, xl.writeQuorum); err != nil {
-			return oi, toObjectErr(err, srcBucket, srcObject)
-		}
-		// Rename atomically `xl.json` from tmp location to destination for each disk.
-		if onlineDisks, err = renameXLMetadata(onlineDisks, minioMetaTmpBucket, tempObj, srcBucket, srcObject, xl.writeQuorum); err != nil {
+		if err = xl.writeObjectMeta(onlineDisks, metaArr, srcBucket, srcObject, metadata, writeQuorum); err != nil {
 			return oi, toObjectErr(err, srcBucket, srcObject)
 		}
+		xlMeta.Meta = metadata
 		return xlMeta.ToObjectInfo(srcBucket, srcObject), nil
 	}
 
@@ -128,6 +126,27 @@ func (xl xlObjects) CopyObject(srcBucket, srcObject, dstBucket, dstObject string
 	return objInfo, nil
 }
 
+// writeObjectMeta - replaces the metadata in `xl.json` of an object on
+// each disk, retaining the erasure index and checksums of each disk.
+func (xl xlObjects) writeObjectMeta(onlineDisks []StorageAPI, metaArr []xlMetaV1, bucket, object string, metadata map[string]string, writeQuorum int) error {
+	partsMetadata := make([]xlMetaV1, len(xl.storageDisks))
+	for index := range partsMetadata {
+		partsMetadata[index] = metaArr[index]
+		partsMetadata[index].Meta = metadata
+	}
+
+	tempObj := mustGetUUID()
+
+	// Write unique `xl.json` for each disk.
+	onlineDisks, err := writeUniqueXLMetadata(onlineDisks, minioMetaTmpBucket, tempObj, partsMetadata, writeQuorum)
+	if err != nil {
+		return err
+	}
+	// Rename atomically `xl.json` from tmp location to destination for each disk.
+	_, err = renameXLMetadata(onlineDisks, minioMetaTmpBucket, tempObj, bucket, object, writeQuorum)
+	return err
+}
+
 // GetObject - reads an object erasured coded across multiple
 // disks. Supports additional parameters like offset and length
 // which are synonymous with HTTP Range requests.
@@ -138,7 +157,12 @@ func (xl xlObjects) GetObject(bucket, object string, startOffset int64, length i
 	if err := checkGetObjArgs(bucket, object); err != nil {
 		return err
 	}
+	return xl.getObject(bucket, object, startOffset, length, writer)
+}
 
+// getObject - wrapper for reading an object, used by GetObject and
+// for reading previous versions of an object.
+func (xl xlObjects) getObject(bucket, object string, startOffset int64, length int64, writer io.Writer) error {
 	// Start offset cannot be negative.
 	if startOffset < 0 {
 		return traceError(errUnexpected)
@@ -151,7 +175,13 @@ func (xl xlObjects) GetObject(bucket, object string, startOffset int64, length i
 
 	// Read metadata associated with the object from all disks.
 	metaArr, errs := readAllXLMetadata(xl.storageDisks, bucket, object)
-	if reducedErr := reduceReadQuorumErrs(errs, objectOpIgnoredErrs, xl.readQuorum); reducedErr != nil {
+
+	// Get quorum for this object.
+	readQuorum, _, err := objectQuorumFromMeta(xl, metaArr, errs)
+	if err != nil {
+		return toObjectErr(err, bucket, object)
+	}
+	if reducedErr := reduceReadQuorumErrs(errs, objectOpIgnoredErrs, readQuorum); reducedErr != nil {
 		return toObjectErr(reducedErr, bucket, object)
 	}
 
@@ -246,8 +276,10 @@ func (xl xlObjects) GetObject(bucket, object string, startOffset int64, length i
 
 	var totalBytesRead int64
 
+	// Buffers leave room for the checksums of erasure blocks of parts
+	// written with streaming bit-rot algos.
 	chunkSize := getChunkSize(xlMeta.Erasure.BlockSize, xlMeta.Erasure.DataBlocks)
-	pool := bpool.NewBytePool(chunkSize, len(onlineDisks))
+	pool := bpool.NewBytePool(chunkSize+streamingBitrotHashSize, len(onlineDisks))
 
 	// Read from all parts.
 	for ; partIndex <= lastPartIndex; partIndex++ {
@@ -317,8 +349,8 @@ func (xl xlObjects) GetObjectInfo(bucket, object string) (oi ObjectInfo, e error
 
 // getObjectInfo - wrapper for reading object metadata and constructs ObjectInfo.
 func (xl xlObjects) getObjectInfo(bucket, object string) (objInfo ObjectInfo, err error) {
-	// Extracts xlStat and xlMetaMap.
-	xlStat, xlMetaMap, err := xl.readXLMetaStat(bucket, object)
+	// Extracts xlStat, xlMetaMap and version info.
+	xlStat, xlMetaMap, vInfo, err := xl.readXLMetaStat(bucket, object)
 	if err != nil {
 		return ObjectInfo{}, err
 	}
@@ -331,6 +363,8 @@ func (xl xlObjects) getObjectInfo(bucket, object string) (objInfo ObjectInfo, er
 		ModTime:         xlStat.ModTime,
 		ContentType:     xlMetaMap["content-type"],
 		ContentEncoding: xlMetaMap["content-encoding"],
+		VersionID:       vInfo.VersionID,
+		DeleteMarker:    vInfo.DeleteMarker,
 	}
 
 	// Extract etag.
@@ -341,6 +375,14 @@ func (xl xlObjects) getObjectInfo(bucket, object string) (objInfo ObjectInfo, er
 	// response headers. e.g, X-Minio-* or X-Amz-*.
 	objInfo.UserDefined = cleanMetaETag(xlMetaMap)
 
+	// Encrypted objects are decrypted part by part, which
+	// requires the size of each part.
+	if isEncryptedObject(objInfo.UserDefined) {
+		if objInfo.Parts, err = xl.readXLMetaParts(bucket, object); err != nil {
+			return ObjectInfo{}, err
+		}
+	}
+
 	// Success.
 	return objInfo, nil
 }
@@ -522,7 +564,12 @@ func (xl xlObjects) PutObject(bucket string, object string, size int64, data io.
 	// Initialize parts metadata
 	partsMetadata := make([]xlMetaV1, len(xl.storageDisks))
 
-	xlMeta := newXLMetaV1(object, xl.dataBlocks, xl.parityBlocks)
+	// Erasure code the object with the parity of its storage class.
+	dataBlocks, parityBlocks := getRedundancyCount(metadata[AmzStorageClass], len(xl.storageDisks))
+	xlMeta := newXLMetaV1(object, dataBlocks, parityBlocks)
+
+	// Get quorum for this object.
+	_, writeQuorum := getObjectQuorum(dataBlocks, parityBlocks)
 
 	// Initialize xl meta.
 	for index := range partsMetadata {
@@ -558,7 +605,7 @@ func (xl xlObjects) PutObject(bucket string, object string, size int64, data io.
 		// Hint the filesystem to pre-allocate one continuous large block.
 		// This is only an optimization.
 		if curPartSize > 0 {
-			pErr := xl.prepareFile(minioMetaTmpBucket, tempErasureObj, curPartSize, onlineDisks, xlMeta.Erasure.BlockSize, xlMeta.Erasure.DataBlocks)
+			pErr := xl.prepareFile(minioMetaTmpBucket, tempErasureObj, curPartSize, onlineDisks, xlMeta.Erasure.BlockSize, xlMeta.Erasure.DataBlocks, bitRotAlgo, writeQuorum)
 			if pErr != nil {
 				return ObjectInfo{}, toObjectErr(pErr, bucket, object)
 			}
@@ -576,7 +623,7 @@ func (xl xlObjects) PutObject(bucket string, object string, size int64, data io.
 		var erasureErr error
 
 		// Erasure code data and write across all disks.
-		onlineDisks, partSizeWritten, checkSums, erasureErr = erasureCreateFile(onlineDisks, minioMetaTmpBucket, tempErasureObj, partReader, allowEmptyPart, partsMetadata[0].Erasure.BlockSize, partsMetadata[0].Erasure.DataBlocks, partsMetadata[0].Erasure.ParityBlocks, bitRotAlgo, xl.writeQuorum)
+		onlineDisks, partSizeWritten, checkSums, erasureErr = erasureCreateFile(onlineDisks, minioMetaTmpBucket, tempErasureObj, partReader, allowEmptyPart, partsMetadata[0].Erasure.BlockSize, partsMetadata[0].Erasure.DataBlocks, partsMetadata[0].Erasure.ParityBlocks, bitRotAlgo, writeQuorum)
 		if erasureErr != nil {
 			return ObjectInfo{}, toObjectErr(erasureErr, minioMetaTmpBucket, tempErasureObj)
 		}
@@ -666,7 +713,14 @@ func (xl xlObjects) PutObject(bucket string, object string, size int64, data io.
 		}
 	}
 
-	if xl.isObject(bucket, object) {
+	// Retain the existing object as a previous version, if versioning
+	// is configured on the bucket.
+	archived, err := xl.archiveObject(bucket, object)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+
+	if !archived && xl.isObject(bucket, object) {
 		// Rename if an object already exists to temporary location.
 		newUniqueID := mustGetUUID()
 
@@ -684,19 +738,21 @@ func (xl xlObjects) PutObject(bucket string, object string, size int64, data io.
 
 	// Fill all the necessary metadata.
 	// Update `xl.json` content on each disks.
+	versionID := newObjectVersionID(bucket)
 	for index := range partsMetadata {
 		partsMetadata[index].Meta = metadata
 		partsMetadata[index].Stat.Size = size
 		partsMetadata[index].Stat.ModTime = modTime
+		partsMetadata[index].VersionID = versionID
 	}
 
 	// Write unique `xl.json` for each disk.
-	if onlineDisks, err = writeUniqueXLMetadata(onlineDisks, minioMetaTmpBucket, tempObj, partsMetadata, xl.writeQuorum); err != nil {
+	if onlineDisks, err = writeUniqueXLMetadata(onlineDisks, minioMetaTmpBucket, tempObj, partsMetadata, writeQuorum); err != nil {
 		return ObjectInfo{}, toObjectErr(err, bucket, object)
 	}
 
 	// Rename the successfully written temporary object to final location.
-	onlineDisks, err = renameObject(onlineDisks, minioMetaTmpBucket, tempObj, bucket, object, xl.writeQuorum)
+	onlineDisks, err = renameObject(onlineDisks, minioMetaTmpBucket, tempObj, bucket, object, writeQuorum)
 	if err != nil {
 		return ObjectInfo{}, toObjectErr(err, bucket, object)
 	}
@@ -720,6 +776,7 @@ func (xl xlObjects) PutObject(bucket string, object string, size int64, data io.
 		ETag:            xlMeta.Meta["etag"],
 		ContentType:     xlMeta.Meta["content-type"],
 		ContentEncoding: xlMeta.Meta["content-encoding"],
+		VersionID:       xlMeta.VersionID,
 		UserDefined:     xlMeta.Meta,
 	}
 
@@ -762,10 +819,13 @@ func (xl xlObjects) deleteObject(bucket, object string) error {
 // any error as it is not necessary for the handler to reply back a
 // response to the client request.
 func (xl xlObjects) DeleteObject(bucket, object string) (err error) {
-	if err = checkDelObjArgs(bucket, object); err != nil {
-		return err
-	}
+	_, err = xl.DeleteObjectVersion(bucket, object, "", false)
+	return err
+}
 
+// deleteCurrentObject - deletes the current object of a bucket,
+// previous versions of the object are not affected.
+func (xl xlObjects) deleteCurrentObject(bucket, object string) (err error) {
 	// Validate object exists.
 	if !xl.isObject(bucket, object) {
 		return traceError(ObjectNotFound{bucket, object})
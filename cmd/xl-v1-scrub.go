@@ -0,0 +1,188 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"hash"
+	"time"
+)
+
+// scrubRateLimiter - limits the rate at which the scrubber reads data
+// from disks. Reads idle for more than a second are not made up for
+// with a burst.
+type scrubRateLimiter struct {
+	bytesPerSec int64
+	start       time.Time
+	bytes       int64
+}
+
+// newScrubRateLimiter - returns a rate limiter reading at most
+// bytesPerSec, rates of zero or less are unlimited.
+func newScrubRateLimiter(bytesPerSec int64) *scrubRateLimiter {
+	return &scrubRateLimiter{bytesPerSec: bytesPerSec}
+}
+
+// wait - accounts n bytes about to be read, sleeping as long as reads
+// are ahead of the rate.
+func (l *scrubRateLimiter) wait(n int) {
+	if l == nil || l.bytesPerSec <= 0 {
+		return
+	}
+	now := time.Now()
+	if l.start.IsZero() || now.Sub(l.start.Add(l.duration())) > time.Second {
+		l.start, l.bytes = now, 0
+	}
+	l.bytes += int64(n)
+	if delay := l.start.Add(l.duration()).Sub(now); delay > 0 {
+		time.Sleep(delay)
+	}
+}
+
+// duration - returns the time reading the accounted bytes takes at
+// the rate of the limiter.
+func (l *scrubRateLimiter) duration() time.Duration {
+	return time.Duration(float64(l.bytes) / float64(l.bytesPerSec) * float64(time.Second))
+}
+
+// scrubHashWriter - hashes the data of a part read by the scrubber,
+// counting it and limiting the read rate.
+type scrubHashWriter struct {
+	hash.Hash
+	limiter *scrubRateLimiter
+	n       int64
+}
+
+func (w *scrubHashWriter) Write(p []byte) (int, error) {
+	w.limiter.wait(len(p))
+	w.n += int64(len(p))
+	return w.Hash.Write(p)
+}
+
+// scrubObjectResult - shards of an object verified by the scrubber, a
+// shard being the erasure coded part of an object on a disk.
+type scrubObjectResult struct {
+	ShardsVerified  uint64
+	BytesVerified   uint64
+	ShardsCorrupted uint64
+	ShardsMissing   uint64
+	ShardsHealed    uint64
+}
+
+// scrubObject - verifies the checksums of all parts of an object on
+// every online disk, reading at most at the rate of the limiter.
+// Objects with corrupted or missing shards are healed.
+func (xl xlObjects) scrubObject(bucket, object string, limiter *scrubRateLimiter) (scrubObjectResult, error) {
+	result, xlMeta, err := xl.verifyObject(bucket, object, limiter)
+	if err != nil {
+		return result, err
+	}
+	if result.ShardsCorrupted == 0 && result.ShardsMissing == 0 {
+		return result, nil
+	}
+	return xl.healVerifiedObject(bucket, object, xlMeta, result)
+}
+
+// healVerifiedObject - heals an object with corrupted or missing
+// shards found by verifyObject. Shards are verified without holding
+// the object lock, objects overwritten or deleted in the meantime are
+// not healed and their shards are not counted as corrupted or missing.
+func (xl xlObjects) healVerifiedObject(bucket, object string, xlMeta xlMetaV1, result scrubObjectResult) (scrubObjectResult, error) {
+	// Lock the object before healing.
+	objectLock := globalNSMutex.NewNSLock(versionOwner(bucket, object))
+	objectLock.RLock()
+	defer objectLock.RUnlock()
+
+	if !xl.isObjectUnchanged(bucket, object, xlMeta) {
+		result.ShardsCorrupted, result.ShardsMissing = 0, 0
+		return result, nil
+	}
+
+	healResult, err := xl.healObjectDisks(bucket, object)
+	if err != nil {
+		return result, err
+	}
+	result.ShardsHealed = uint64(healResult.healedDisks())
+	return result, nil
+}
+
+// isObjectUnchanged - returns true if the latest xl.json of an object
+// has the modification time and etag of xlMeta.
+func (xl xlObjects) isObjectUnchanged(bucket, object string, xlMeta xlMetaV1) bool {
+	partsMetadata, errs := readAllXLMetadata(xl.storageDisks, bucket, object)
+	if reduceReadQuorumErrs(errs, objectOpIgnoredErrs, xl.readQuorum) != nil {
+		return false
+	}
+	_, modTime := listOnlineDisks(xl.storageDisks, partsMetadata, errs)
+	latestMeta, err := pickValidXLMeta(partsMetadata, modTime)
+	return err == nil && latestMeta.Stat.ModTime.Equal(xlMeta.Stat.ModTime) &&
+		latestMeta.Meta["etag"] == xlMeta.Meta["etag"]
+}
+
+// verifyObject - verifies the checksums of all parts of an object on
+// every online disk, returns the xl.json of the verified object. Disks
+// with a missing or stale xl.json, or with missing parts, miss their
+// shard. Parts failing verification are corrupted shards.
+func (xl xlObjects) verifyObject(bucket, object string, limiter *scrubRateLimiter) (result scrubObjectResult, xlMeta xlMetaV1, err error) {
+	// Lock the object while reading xl.json only, the shards are
+	// verified unlocked so that writes don't wait on the scrubber.
+	objectLock := globalNSMutex.NewNSLock(versionOwner(bucket, object))
+	objectLock.RLock()
+	partsMetadata, errs := readAllXLMetadata(xl.storageDisks, bucket, object)
+	objectLock.RUnlock()
+	if reducedErr := reduceReadQuorumErrs(errs, objectOpIgnoredErrs, xl.readQuorum); reducedErr != nil {
+		return result, xlMeta, toObjectErr(reducedErr, bucket, object)
+	}
+
+	onlineDisks, modTime := listOnlineDisks(xl.storageDisks, partsMetadata, errs)
+	if xlMeta, err = pickValidXLMeta(partsMetadata, modTime); err != nil {
+		return result, xlMeta, toObjectErr(err, bucket, object)
+	}
+	for index, disk := range onlineDisks {
+		if disk == nil {
+			// Offline disks are not accounted for.
+			if xl.storageDisks[index] != nil && !isErr(errs[index], baseErrs...) {
+				result.ShardsMissing++
+			}
+			continue
+		}
+
+	parts:
+		for _, part := range partsMetadata[index].Parts {
+			checkSumInfo := partsMetadata[index].Erasure.GetCheckSumInfo(part.Name)
+			n, hErr := bitrotVerifyFile(disk, bucket, pathJoin(object, part.Name), part.Size,
+				partsMetadata[index].Erasure, checkSumInfo, limiter)
+			result.BytesVerified += uint64(n)
+			if _, ok := hErr.(hashMismatchError); ok {
+				result.ShardsVerified++
+				result.ShardsCorrupted++
+				continue
+			}
+			switch {
+			case isErr(hErr, baseErrs...):
+				// Disk went offline.
+				break parts
+			case hErr == errFileNotFound:
+				result.ShardsMissing++
+			case hErr != nil:
+				result.ShardsCorrupted++
+			default:
+				result.ShardsVerified++
+			}
+		}
+	}
+	return result, xlMeta, nil
+}
@@ -149,6 +149,13 @@ func parseXLRelease(xlMetaBuf []byte) string {
 	return gjson.GetBytes(xlMetaBuf, "minio.release").String()
 }
 
+func parseXLVersionInfo(xlMetaBuf []byte) versionInfo {
+	return versionInfo{
+		VersionID:    gjson.GetBytes(xlMetaBuf, "versionId").Str,
+		DeleteMarker: gjson.GetBytes(xlMetaBuf, "deleteMarker").Bool(),
+	}
+}
+
 func parseXLErasureInfo(xlMetaBuf []byte) erasureInfo {
 	erasure := erasureInfo{}
 	erasureResult := gjson.GetBytes(xlMetaBuf, "erasure")
@@ -229,6 +236,10 @@ func xlMetaV1UnmarshalJSON(xlMetaBuf []byte) (xmv xlMetaV1, e error) {
 	xlMeta.Minio.Release = parseXLRelease(xlMetaBuf)
 	// parse xlMetaV1.
 	xlMeta.Meta = parseXLMetaMap(xlMetaBuf)
+	// parse xlMetaV1 version info.
+	vInfo := parseXLVersionInfo(xlMetaBuf)
+	xlMeta.VersionID = vInfo.VersionID
+	xlMeta.DeleteMarker = vInfo.DeleteMarker
 
 	return xlMeta, nil
 }
@@ -246,12 +257,12 @@ func readXLMetaParts(disk StorageAPI, bucket string, object string) ([]objectPar
 	return xlMetaParts, nil
 }
 
-// read xl.json from the given disk and parse xlV1Meta.Stat and xlV1Meta.Meta using gjson.
-func readXLMetaStat(disk StorageAPI, bucket string, object string) (si statInfo, mp map[string]string, e error) {
+// read xl.json from the given disk and parse xlV1Meta.Stat, xlV1Meta.Meta and version info using gjson.
+func readXLMetaStat(disk StorageAPI, bucket string, object string) (si statInfo, mp map[string]string, vi versionInfo, e error) {
 	// Reads entire `xl.json`.
 	xlMetaBuf, err := disk.ReadAll(bucket, path.Join(object, xlMetaJSONFile))
 	if err != nil {
-		return si, nil, traceError(err)
+		return si, nil, vi, traceError(err)
 	}
 
 	// obtain version.
@@ -263,7 +274,7 @@ func readXLMetaStat(disk StorageAPI, bucket string, object string) (si statInfo,
 	// Validate if the xl.json we read is sane, return corrupted format.
 	if !isXLMetaValid(xlVersion, xlFormat) {
 		// For version mismatchs and unrecognized format, return corrupted format.
-		return si, nil, traceError(errCorruptedFormat)
+		return si, nil, vi, traceError(errCorruptedFormat)
 	}
 
 	// obtain xlMetaV1{}.Meta using `github.com/tidwall/gjson`.
@@ -272,11 +283,11 @@ func readXLMetaStat(disk StorageAPI, bucket string, object string) (si statInfo,
 	// obtain xlMetaV1{}.Stat using `github.com/tidwall/gjson`.
 	xlStat, err := parseXLStat(xlMetaBuf)
 	if err != nil {
-		return si, nil, traceError(err)
+		return si, nil, vi, traceError(err)
 	}
 
 	// Return structured `xl.json`.
-	return xlStat, xlMetaMap, nil
+	return xlStat, xlMetaMap, parseXLVersionInfo(xlMetaBuf), nil
 }
 
 // readXLMeta reads `xl.json` and returns back XL metadata structure.
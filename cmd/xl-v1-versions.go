@@ -0,0 +1,370 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"io"
+	"path"
+	"strings"
+)
+
+// xlVersionPath - returns the path of a previous object version
+// inside `.minio.sys/versions`, each version is a regular XL object.
+func xlVersionPath(bucket, object, versionID string) string {
+	return pathJoin(bucket, object, versionsDirName, toVersionID(versionID))
+}
+
+// versionOwner - returns the bucket and object an entry belongs to,
+// previous versions inside `.minio.sys/versions` belong to the object
+// they are a version of and are locked and placed like it.
+func versionOwner(bucket, object string) (string, string) {
+	if bucket != minioMetaVersionsBucket {
+		return bucket, object
+	}
+	owner := strings.SplitN(path.Dir(path.Dir(object)), slashSeparator, 2)
+	if len(owner) != 2 {
+		return bucket, object
+	}
+	return owner[0], owner[1]
+}
+
+// archiveObject - moves the current object into the versions namespace,
+// if versioning is configured on the bucket. Returns false if the object
+// was not archived and should be overwritten as usual, this is the case
+// for unversioned buckets and `null` versions in suspended buckets.
+func (xl xlObjects) archiveObject(bucket, object string) (bool, error) {
+	status := getBucketVersioningStatus(bucket)
+	if status == "" || !xl.isObject(bucket, object) {
+		return false, nil
+	}
+
+	_, meta, vInfo, err := xl.readXLMetaStat(bucket, object)
+	if err != nil {
+		return false, toObjectErr(err, bucket, object)
+	}
+
+	if vInfo.VersionID == "" {
+		if status == versioningSuspended {
+			// The current `null` version is about to be overwritten.
+			if err = checkObjectLockRemoval(ObjectInfo{Bucket: bucket, Name: object, UserDefined: meta}, false); err != nil {
+				return false, err
+			}
+		}
+		// Only one `null` version is retained, purge the previous one.
+		if err = xl.purgeNullVersion(bucket, object); err != nil {
+			return false, err
+		}
+		if status == versioningSuspended {
+			return false, nil
+		}
+	}
+
+	// NOTE: Do not use online disks slice here, existing object
+	// should be moved regardless of `xl.json` status.
+	_, err = renameObject(xl.storageDisks, bucket, object, minioMetaVersionsBucket, xlVersionPath(bucket, object, vInfo.VersionID), xl.writeQuorum)
+	if err != nil {
+		return false, toObjectErr(err, bucket, object)
+	}
+	return true, nil
+}
+
+// purgeNullVersion - removes the previous `null` version of an object,
+// unless it is protected by object lock.
+func (xl xlObjects) purgeNullVersion(bucket, object string) error {
+	versionPath := xlVersionPath(bucket, object, "")
+	if objInfo, err := xl.getObjectInfo(minioMetaVersionsBucket, versionPath); err == nil {
+		objInfo.Bucket, objInfo.Name = bucket, object
+		if err = checkObjectLockRemoval(objInfo, false); err != nil {
+			return err
+		}
+	}
+	if err := xl.deleteObject(minioMetaVersionsBucket, versionPath); err != nil {
+		return toObjectErr(err, bucket, object)
+	}
+	return nil
+}
+
+// writeDeleteMarker - writes a new delete marker as the latest version
+// of an object.
+func (xl xlObjects) writeDeleteMarker(bucket, object string) (ObjectInfo, error) {
+	versionID := newObjectVersionID(bucket)
+	if versionID == "" {
+		// Only one `null` version is retained, purge the previous one.
+		if err := xl.purgeNullVersion(bucket, object); err != nil {
+			return ObjectInfo{}, err
+		}
+	}
+
+	xlMeta := newXLMetaV1(object, xl.dataBlocks, xl.parityBlocks)
+	xlMeta.Stat.ModTime = UTCNow()
+	xlMeta.VersionID = versionID
+	xlMeta.DeleteMarker = true
+
+	if _, err := writeSameXLMetadata(xl.storageDisks, minioMetaVersionsBucket, xlVersionPath(bucket, object, versionID), xlMeta, xl.writeQuorum, xl.readQuorum); err != nil {
+		return ObjectInfo{}, toObjectErr(err, bucket, object)
+	}
+
+	objInfo := xlMeta.ToObjectInfo(bucket, object)
+	objInfo.IsLatest = true
+	return objInfo, nil
+}
+
+// hasArchivedVersions - isLeaf function for listing the versions
+// namespace, an entry is a leaf if it holds object versions.
+func (xl xlObjects) hasArchivedVersions(volume, entry string) bool {
+	for _, disk := range xl.getLoadBalancedDisks() {
+		if disk == nil {
+			continue
+		}
+		entries, err := disk.ListDir(volume, entry)
+		if err != nil {
+			if isErrIgnored(err, xlTreeWalkIgnoredErrs...) {
+				continue
+			}
+			return false
+		}
+		for _, e := range entries {
+			if e == versionsDirName+slashSeparator {
+				return true
+			}
+		}
+		return false
+	}
+	return false
+}
+
+// listArchivedVersions - returns all the previous versions of an object.
+func (xl xlObjects) listArchivedVersions(bucket, object string) ([]ObjectInfo, error) {
+	versionsDir := pathJoin(bucket, object, versionsDirName)
+
+	var entries []string
+	for _, disk := range xl.getLoadBalancedDisks() {
+		if disk == nil {
+			continue
+		}
+		var err error
+		entries, err = disk.ListDir(minioMetaVersionsBucket, versionsDir)
+		if err == nil {
+			break
+		}
+		if err == errFileNotFound {
+			return nil, nil
+		}
+		if !isErrIgnored(err, xlTreeWalkIgnoredErrs...) {
+			return nil, traceError(err)
+		}
+	}
+
+	var versions []ObjectInfo
+	for _, entry := range entries {
+		objInfo, err := xl.getObjectInfo(minioMetaVersionsBucket, pathJoin(versionsDir, entry))
+		if err != nil {
+			// Version might have been removed in the meantime.
+			if isErrObjectNotFound(toObjectErr(err, bucket, object)) {
+				continue
+			}
+			return nil, err
+		}
+		objInfo.Bucket = bucket
+		objInfo.Name = object
+		versions = append(versions, objInfo)
+	}
+	return versions, nil
+}
+
+// listVersionedObjects - lists names of objects having previous versions.
+func (xl xlObjects) listVersionedObjects(bucket, prefix, marker string, maxKeys int) (names []string, isTruncated bool, err error) {
+	endWalkCh := make(chan struct{})
+	defer close(endWalkCh)
+
+	walkMarker := ""
+	if marker != "" {
+		walkMarker = bucket + slashSeparator + marker
+	}
+	isLeaf := xl.hasArchivedVersions
+	listDir := listDirFactory(isLeaf, xlTreeWalkIgnoredErrs, xl.getLoadBalancedDisks()...)
+	walkResultCh := startTreeWalk(minioMetaVersionsBucket, bucket+slashSeparator+prefix, walkMarker, true, listDir, isLeaf, endWalkCh)
+
+	for len(names) < maxKeys {
+		walkResult, ok := <-walkResultCh
+		if !ok {
+			return names, false, nil
+		}
+		if walkResult.err != nil {
+			return nil, false, toObjectErr(walkResult.err, bucket, prefix)
+		}
+		names = append(names, strings.TrimPrefix(walkResult.entry, bucket+slashSeparator))
+		if walkResult.end {
+			return names, false, nil
+		}
+	}
+	return names, true, nil
+}
+
+// promoteLatestVersion - makes the newest previous version the current
+// object, unless it is a delete marker.
+func (xl xlObjects) promoteLatestVersion(bucket, object string) error {
+	versions, hasCurrent, err := getObjectVersions(xl, bucket, object)
+	if err != nil {
+		return err
+	}
+	if hasCurrent || len(versions) == 0 || versions[0].DeleteMarker {
+		return nil
+	}
+	_, err = renameObject(xl.storageDisks, minioMetaVersionsBucket, xlVersionPath(bucket, object, versions[0].VersionID), bucket, object, xl.writeQuorum)
+	return toObjectErr(err, bucket, object)
+}
+
+// GetObjectVersion - reads a specific version of an object.
+func (xl xlObjects) GetObjectVersion(bucket, object, versionID string, startOffset int64, length int64, writer io.Writer) error {
+	if err := checkGetObjArgs(bucket, object); err != nil {
+		return err
+	}
+
+	objInfo, index, hasCurrent, err := getObjectVersion(xl, bucket, object, versionID)
+	if err != nil {
+		return err
+	}
+	if objInfo.DeleteMarker {
+		return traceError(MethodNotAllowed{Bucket: bucket, Object: object})
+	}
+	if index == 0 && hasCurrent {
+		return xl.getObject(bucket, object, startOffset, length, writer)
+	}
+	err = xl.getObject(minioMetaVersionsBucket, xlVersionPath(bucket, object, objInfo.VersionID), startOffset, length, writer)
+	return toObjectErr(err, bucket, object)
+}
+
+// GetObjectVersionInfo - reads metadata of a specific version of an object.
+func (xl xlObjects) GetObjectVersionInfo(bucket, object, versionID string) (ObjectInfo, error) {
+	if err := checkGetObjArgs(bucket, object); err != nil {
+		return ObjectInfo{}, err
+	}
+
+	objInfo, _, _, err := getObjectVersion(xl, bucket, object, versionID)
+	return objInfo, err
+}
+
+// DeleteObjectVersion - deletes a specific version of an object, an
+// empty versionID deletes the current object. In versioned buckets
+// the current object is retained as a previous version and a delete
+// marker is added instead. Versions protected by object lock are not
+// deleted, unless their governance mode retention is bypassed.
+func (xl xlObjects) DeleteObjectVersion(bucket, object, versionID string, bypassGovernance bool) (ObjectInfo, error) {
+	if err := checkDelObjArgs(bucket, object); err != nil {
+		return ObjectInfo{}, err
+	}
+
+	if versionID == "" {
+		if getBucketVersioningStatus(bucket) == "" {
+			return ObjectInfo{Bucket: bucket, Name: object}, xl.deleteCurrentObject(bucket, object)
+		}
+
+		archived, err := xl.archiveObject(bucket, object)
+		if err != nil {
+			return ObjectInfo{}, err
+		}
+		if !archived && xl.isObject(bucket, object) {
+			if err = xl.deleteCurrentObject(bucket, object); err != nil {
+				return ObjectInfo{}, err
+			}
+		}
+		if xl.objCacheEnabled {
+			xl.objCache.Delete(pathJoin(bucket, object))
+		}
+		return xl.writeDeleteMarker(bucket, object)
+	}
+
+	objInfo, index, hasCurrent, err := getObjectVersion(xl, bucket, object, versionID)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	if err = checkObjectLockRemoval(objInfo, bypassGovernance); err != nil {
+		return ObjectInfo{}, err
+	}
+
+	if index == 0 && hasCurrent {
+		err = xl.deleteCurrentObject(bucket, object)
+	} else {
+		err = xl.deleteObject(minioMetaVersionsBucket, xlVersionPath(bucket, object, objInfo.VersionID))
+	}
+	if err != nil {
+		return ObjectInfo{}, toObjectErr(err, bucket, object)
+	}
+
+	// Latest version was removed, previous version becomes the latest.
+	if index == 0 {
+		if err = xl.promoteLatestVersion(bucket, object); err != nil {
+			return ObjectInfo{}, err
+		}
+	}
+
+	return objInfo, nil
+}
+
+// PutObjectLock - replaces the object lock settings of a specific
+// version of an object. Retention in effect can not be weakened, unless
+// its governance mode retention is bypassed.
+func (xl xlObjects) PutObjectLock(bucket, object, versionID string, lock objectLock, bypassGovernance bool) (ObjectInfo, error) {
+	if err := checkGetObjArgs(bucket, object); err != nil {
+		return ObjectInfo{}, err
+	}
+
+	objInfo, index, hasCurrent, err := getObjectVersion(xl, bucket, object, versionID)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	if objInfo.DeleteMarker {
+		return ObjectInfo{}, traceError(MethodNotAllowed{Bucket: bucket, Object: object})
+	}
+	if err = checkObjectLockUpdate(objInfo, lock, bypassGovernance); err != nil {
+		return ObjectInfo{}, err
+	}
+
+	metadata := make(map[string]string, len(objInfo.UserDefined)+3)
+	for k, v := range objInfo.UserDefined {
+		metadata[k] = v
+	}
+	setObjectLock(metadata, lock)
+
+	// Previous versions are regular XL objects in the versions namespace.
+	metaBucket, metaObject := bucket, object
+	if index != 0 || !hasCurrent {
+		metaBucket, metaObject = minioMetaVersionsBucket, xlVersionPath(bucket, object, objInfo.VersionID)
+	}
+
+	metaArr, errs := readAllXLMetadata(xl.storageDisks, metaBucket, metaObject)
+	_, writeQuorum, err := objectQuorumFromMeta(xl, metaArr, errs)
+	if err != nil {
+		return ObjectInfo{}, toObjectErr(err, bucket, object)
+	}
+	onlineDisks, _ := listOnlineDisks(xl.storageDisks, metaArr, errs)
+	if err = xl.writeObjectMeta(onlineDisks, metaArr, metaBucket, metaObject, metadata, writeQuorum); err != nil {
+		return ObjectInfo{}, toObjectErr(err, bucket, object)
+	}
+
+	objInfo.UserDefined = metadata
+	return objInfo, nil
+}
+
+// ListObjectVersions - lists all versions of the objects at prefix.
+func (xl xlObjects) ListObjectVersions(bucket, prefix, keyMarker, versionIDMarker, delimiter string, maxKeys int) (ListObjectVersionsInfo, error) {
+	if err := checkListObjsArgs(bucket, prefix, keyMarker, delimiter, xl); err != nil {
+		return ListObjectVersionsInfo{}, err
+	}
+	return listObjectVersions(xl, bucket, prefix, keyMarker, versionIDMarker, delimiter, maxKeys)
+}
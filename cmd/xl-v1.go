@@ -77,6 +77,42 @@ func newXLObjectLayer(storageDisks []StorageAPI) (ObjectLayer, error) {
 	err = initBucketPolicies(objAPI)
 	fatalIf(err, "Unable to load all bucket policies.")
 
+	// Initialize and load bucket versioning configs.
+	err = initBucketVersioning(objAPI)
+	fatalIf(err, "Unable to load all bucket versioning configs.")
+
+	// Initialize and load bucket object lock configs.
+	err = initBucketObjectLock(objAPI)
+	fatalIf(err, "Unable to load all bucket object lock configs.")
+
+	// Initialize and load bucket quota configs.
+	err = initBucketQuota(objAPI)
+	fatalIf(err, "Unable to load all bucket quota configs.")
+
+	// Initialize and load bucket encryption configs.
+	err = initBucketEncryption(objAPI)
+	fatalIf(err, "Unable to load all bucket encryption configs.")
+
+	// Initialize and load bucket CORS configs.
+	err = initBucketCors(objAPI)
+	fatalIf(err, "Unable to load all bucket CORS configs.")
+
+	// Initialize and load bucket website configs.
+	err = initBucketWebsite(objAPI)
+	fatalIf(err, "Unable to load all bucket website configs.")
+
+	// Initialize and load bucket replication configs.
+	err = initBucketReplication(objAPI)
+	fatalIf(err, "Unable to load all bucket replication configs.")
+
+	// Initialize and load bucket logging configs.
+	err = initBucketLogging(objAPI)
+	fatalIf(err, "Unable to load all bucket logging configs.")
+
+	// Initialize and load IAM users, groups and policies.
+	err = initIAMSys(objAPI)
+	fatalIf(err, "Unable to load IAM config.")
+
 	// Initialize a new event notifier.
 	err = initEventNotifier(objAPI)
 	fatalIf(err, "Unable to initialize event notification.")
@@ -85,76 +121,82 @@ func newXLObjectLayer(storageDisks []StorageAPI) (ObjectLayer, error) {
 	return objAPI, nil
 }
 
-// newXLObjects - initialize new xl object layer.
+// newXLObjects - initialize new xl object layer, disks divided into
+// more than one erasure set are served by a sets object layer.
 func newXLObjects(storageDisks []StorageAPI) (ObjectLayer, error) {
 	if storageDisks == nil {
 		return nil, errInvalidArgument
 	}
 
 	readQuorum := len(storageDisks) / 2
-	writeQuorum := len(storageDisks)/2 + 1
 
 	// Load saved XL format.json and validate.
-	newStorageDisks, err := loadFormatXL(storageDisks, readQuorum)
+	newStorageDisks, setIndexes, err := loadFormatXL(storageDisks, readQuorum)
 	if err != nil {
 		return nil, fmt.Errorf("Unable to recognize backend format, %s", err)
 	}
 
-	// Calculate data and parity blocks.
-	dataBlocks, parityBlocks := len(newStorageDisks)/2, len(newStorageDisks)/2
-
-	// Initialize list pool.
-	listPool := newTreeWalkPool(globalLookupTimeout)
-
-	// Initialize xl objects.
-	xl := &xlObjects{
-		mutex:        &sync.Mutex{},
-		storageDisks: newStorageDisks,
-		dataBlocks:   dataBlocks,
-		parityBlocks: parityBlocks,
-		listPool:     listPool,
-	}
-
 	// Get cache size if _MINIO_CACHE environment variable is set.
-	var maxCacheSize uint64
+	var objCache *objcache.Cache
 	if !globalXLObjCacheDisabled {
-		maxCacheSize, err = GetMaxCacheSize()
-		errorIf(err, "Unable to get maximum cache size")
+		maxCacheSize, cerr := GetMaxCacheSize()
+		errorIf(cerr, "Unable to get maximum cache size")
 
 		// Enable object cache if cache size is more than zero
-		xl.objCacheEnabled = maxCacheSize > 0
-	}
-
-	// Check if object cache is enabled.
-	if xl.objCacheEnabled {
-		// Initialize object cache.
-		objCache, oerr := objcache.New(maxCacheSize, objcache.DefaultExpiry)
-		if oerr != nil {
-			return nil, oerr
-		}
-		objCache.OnEviction = func(key string) {
-			debug.FreeOSMemory()
+		if maxCacheSize > 0 {
+			// Initialize object cache, shared by all erasure sets.
+			objCache, err = objcache.New(maxCacheSize, objcache.DefaultExpiry)
+			if err != nil {
+				return nil, err
+			}
+			objCache.OnEviction = func(key string) {
+				debug.FreeOSMemory()
+			}
 		}
-		xl.objCache = objCache
 	}
 
 	// Initialize meta volume, if volume already exists ignores it.
-	if err = initMetaVolume(xl.storageDisks); err != nil {
+	if err = initMetaVolume(newStorageDisks); err != nil {
 		return nil, fmt.Errorf("Unable to initialize '.minio.sys' meta volume, %s", err)
 	}
 
+	if len(setIndexes) == 1 {
+		return newXLSet(newStorageDisks, objCache)
+	}
+	return newXLSets(newStorageDisks, setIndexes, objCache)
+}
+
+// newXLSet - initialize xl objects over the disks of one erasure set.
+func newXLSet(storageDisks []StorageAPI, objCache *objcache.Cache) (*xlObjects, error) {
+	// Calculate data and parity blocks.
+	dataBlocks, parityBlocks := len(storageDisks)/2, len(storageDisks)/2
+
+	// Initialize list pool.
+	listPool := newTreeWalkPool(globalLookupTimeout)
+
+	// Initialize xl objects.
+	xl := &xlObjects{
+		mutex:           &sync.Mutex{},
+		storageDisks:    storageDisks,
+		dataBlocks:      dataBlocks,
+		parityBlocks:    parityBlocks,
+		listPool:        listPool,
+		objCache:        objCache,
+		objCacheEnabled: objCache != nil,
+	}
+
 	// Figure out read and write quorum based on number of storage disks.
 	// READ and WRITE quorum is always set to (N/2) number of disks.
-	xl.readQuorum = readQuorum
-	xl.writeQuorum = writeQuorum
+	xl.readQuorum = len(storageDisks) / 2
+	xl.writeQuorum = len(storageDisks)/2 + 1
 
 	// If the number of offline servers is equal to the readQuorum
 	// (i.e. the number of online servers also equals the
 	// readQuorum), we cannot perform quick-heal (no
 	// write-quorum). However reads may still be possible, so we
 	// skip quick-heal in this case, and continue.
-	offlineCount := len(newStorageDisks) - diskCount(newStorageDisks)
-	if offlineCount == readQuorum {
+	offlineCount := len(storageDisks) - diskCount(storageDisks)
+	if offlineCount == xl.readQuorum {
 		return xl, nil
 	}
 
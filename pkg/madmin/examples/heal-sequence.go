@@ -0,0 +1,57 @@
+// +build ignore
+
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package main
+
+import (
+	"log"
+	"time"
+
+	"github.com/minio/minio/pkg/madmin"
+)
+
+func main() {
+	// Note: YOUR-ACCESSKEYID, YOUR-SECRETACCESSKEY are
+	// dummy values, please replace them with original values.
+
+	// API requests are secure (HTTPS) if secure=true and insecure (HTTPS) otherwise.
+	// New returns an Minio Admin client object.
+	madmClnt, err := madmin.New("your-minio.example.com:9000", "YOUR-ACCESSKEYID", "YOUR-SECRETACCESSKEY", true)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	// Start healing all objects under myprefix in mybucket.
+	state, err := madmClnt.StartHealSequence("mybucket", "myprefix")
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	// Poll the progress of the heal sequence until it is done.
+	for state.Status == "running" {
+		time.Sleep(5 * time.Second)
+		if state, err = madmClnt.HealSequenceStatus(state.Token); err != nil {
+			log.Fatalln(err)
+		}
+		log.Printf("scanned %d objects, healed %d, failed %d\n",
+			state.ObjectsScanned, state.ObjectsHealed, state.ObjectsFailed)
+	}
+
+	log.Printf("heal sequence %s: %s\n", state.Token, state.Status)
+}
@@ -238,6 +238,7 @@ const (
 	healUploadIDMarker healQueryKey = "upload-id-marker"
 	healMaxUpload      healQueryKey = "max-uploads"
 	healUploadID       healQueryKey = "upload-id"
+	healToken          healQueryKey = "token"
 )
 
 // mkHealQueryVal - helper function to construct heal REST API query params.
@@ -727,3 +728,150 @@ func (adm *AdminClient) ListUploadsHeal(bucket, prefix string, recursive bool,
 	}(uploadStatCh)
 	return uploadStatCh, nil
 }
+
+// HealDiskStats - number of objects of a heal sequence in each state
+// on a disk, before and after healing. States are "ok", "offline",
+// "missing", "outdated" and "corrupted". Disks are identified by their
+// erasure set and their position in the set.
+type HealDiskStats struct {
+	Set      int               `json:"set"`
+	Disk     int               `json:"disk"`
+	Endpoint string            `json:"endpoint"`
+	Before   map[string]uint64 `json:"before"`
+	After    map[string]uint64 `json:"after"`
+}
+
+// HealSequenceState - progress of a heal sequence. Status is one of
+// "running", "stopped", "finished" and "failed".
+type HealSequenceState struct {
+	Token      string    `json:"token"`
+	Bucket     string    `json:"bucket,omitempty"`
+	Prefix     string    `json:"prefix,omitempty"`
+	Node       string    `json:"node"`
+	Status     string    `json:"status"`
+	Error      string    `json:"error,omitempty"`
+	StartTime  time.Time `json:"startTime"`
+	LastUpdate time.Time `json:"lastUpdate"`
+
+	// Pause after healing each object, throttles the sequence.
+	Delay time.Duration `json:"delay,omitempty"`
+
+	MarkerBucket string `json:"markerBucket,omitempty"`
+	MarkerObject string `json:"markerObject,omitempty"`
+
+	BucketsScanned uint64          `json:"bucketsScanned"`
+	BucketsFailed  uint64          `json:"bucketsFailed"`
+	ObjectsScanned uint64          `json:"objectsScanned"`
+	ObjectsHealed  uint64          `json:"objectsHealed"`
+	ObjectsFailed  uint64          `json:"objectsFailed"`
+	Disks          []HealDiskStats `json:"disks,omitempty"`
+}
+
+// healSequence - executes a heal sequence request and returns the
+// state of the sequence.
+func (adm *AdminClient) healSequence(method, operation string, queryVal url.Values) (HealSequenceState, error) {
+	queryVal.Set("heal", "")
+
+	hdrs := make(http.Header)
+	hdrs.Set(minioAdminOpHeader, operation)
+
+	reqData := requestData{
+		queryValues:   queryVal,
+		customHeaders: hdrs,
+	}
+
+	resp, err := adm.executeMethod(method, reqData)
+
+	defer closeResponse(resp)
+	if err != nil {
+		return HealSequenceState{}, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return HealSequenceState{}, httpRespToErrorResponse(resp)
+	}
+
+	state := HealSequenceState{}
+	if err = json.NewDecoder(resp.Body).Decode(&state); err != nil {
+		return HealSequenceState{}, err
+	}
+	return state, nil
+}
+
+// StartHealSequence - starts healing all objects of a bucket under a
+// prefix, or all buckets when bucket is empty, in background. The
+// returned state carries the token of the sequence.
+func (adm *AdminClient) StartHealSequence(bucket, prefix string) (HealSequenceState, error) {
+	queryVal := url.Values{}
+	if bucket != "" {
+		queryVal.Set(string(healBucket), bucket)
+	}
+	if prefix != "" {
+		queryVal.Set(string(healPrefix), prefix)
+	}
+
+	// Execute POST on /?heal&bucket=mybucket&prefix=myprefix to start a heal sequence.
+	return adm.healSequence("POST", "start-sequence", queryVal)
+}
+
+// HealSequenceStatus - returns the progress of a heal sequence.
+func (adm *AdminClient) HealSequenceStatus(token string) (HealSequenceState, error) {
+	queryVal := url.Values{}
+	queryVal.Set(string(healToken), token)
+
+	// Execute GET on /?heal&token=mytoken to get the heal sequence status.
+	return adm.healSequence("GET", "sequence-status", queryVal)
+}
+
+// StopHealSequence - stops a heal sequence and returns its final
+// progress.
+func (adm *AdminClient) StopHealSequence(token string) (HealSequenceState, error) {
+	queryVal := url.Values{}
+	queryVal.Set(string(healToken), token)
+
+	// Execute POST on /?heal&token=mytoken to stop a heal sequence.
+	return adm.healSequence("POST", "stop-sequence", queryVal)
+}
+
+// HealingDisk - a freshly formatted disk being healed in background,
+// Sequence is the progress of the heal sequence healing it once
+// started.
+type HealingDisk struct {
+	Endpoint string             `json:"endpoint"`
+	Disk     string             `json:"disk"`
+	Started  time.Time          `json:"started"`
+	Sequence *HealSequenceState `json:"sequence,omitempty"`
+}
+
+// HealingDisks - lists the freshly formatted disks which are being
+// healed in background.
+func (adm *AdminClient) HealingDisks() ([]HealingDisk, error) {
+	queryVal := url.Values{}
+	queryVal.Set("heal", "")
+
+	hdrs := make(http.Header)
+	hdrs.Set(minioAdminOpHeader, "disks")
+
+	reqData := requestData{
+		queryValues:   queryVal,
+		customHeaders: hdrs,
+	}
+
+	// Execute GET on /?heal to list disks being healed.
+	resp, err := adm.executeMethod("GET", reqData)
+
+	defer closeResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, httpRespToErrorResponse(resp)
+	}
+
+	var disks []HealingDisk
+	if err = json.NewDecoder(resp.Body).Decode(&disks); err != nil {
+		return nil, err
+	}
+	return disks, nil
+}
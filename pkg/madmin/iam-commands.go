@@ -0,0 +1,221 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package madmin
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+)
+
+const (
+	iamQueryParam = "iam"
+)
+
+// AccountStatus - status of a user, only enabled users may sign requests.
+type AccountStatus string
+
+// Account status values.
+const (
+	AccountEnabled  AccountStatus = "enabled"
+	AccountDisabled AccountStatus = "disabled"
+)
+
+// UserInfo - status, policy and groups of a user.
+type UserInfo struct {
+	Status   AccountStatus `json:"status"`
+	Policy   string        `json:"policy,omitempty"`
+	MemberOf []string      `json:"memberOf,omitempty"`
+}
+
+// GroupInfo - members and policy of a group.
+type GroupInfo struct {
+	Members []string `json:"members"`
+	Policy  string   `json:"policy,omitempty"`
+}
+
+// executeIAMMethod - executes an IAM operation on /?iam with the
+// query values and optional JSON body, returns the response body.
+func (adm *AdminClient) executeIAMMethod(method, operation string, queryVal url.Values, body []byte) ([]byte, error) {
+	if queryVal == nil {
+		queryVal = make(url.Values)
+	}
+	queryVal.Set(iamQueryParam, "")
+
+	hdrs := make(http.Header)
+	hdrs.Set(minioAdminOpHeader, operation)
+
+	reqData := requestData{
+		queryValues:   queryVal,
+		customHeaders: hdrs,
+	}
+	if body != nil {
+		reqData.contentBody = bytes.NewReader(body)
+		reqData.contentMD5Bytes = sumMD5(body)
+		reqData.contentSHA256Bytes = sum256(body)
+	}
+
+	resp, err := adm.executeMethod(method, reqData)
+
+	defer closeResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, httpRespToErrorResponse(resp)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+// AddUser - adds a user, or updates the secret key of an existing
+// user. New users are enabled.
+func (adm *AdminClient) AddUser(accessKey, secretKey string) error {
+	body, err := json.Marshal(struct {
+		SecretKey string `json:"secretKey"`
+		Status    string `json:"status"`
+	}{secretKey, string(AccountEnabled)})
+	if err != nil {
+		return err
+	}
+
+	queryVal := make(url.Values)
+	queryVal.Set("accessKey", accessKey)
+	_, err = adm.executeIAMMethod("PUT", "add-user", queryVal, body)
+	return err
+}
+
+// RemoveUser - removes a user along with its group memberships.
+func (adm *AdminClient) RemoveUser(accessKey string) error {
+	queryVal := make(url.Values)
+	queryVal.Set("accessKey", accessKey)
+	_, err := adm.executeIAMMethod("POST", "remove-user", queryVal, nil)
+	return err
+}
+
+// SetUserStatus - enables or disables a user.
+func (adm *AdminClient) SetUserStatus(accessKey string, status AccountStatus) error {
+	queryVal := make(url.Values)
+	queryVal.Set("accessKey", accessKey)
+	queryVal.Set("status", string(status))
+	_, err := adm.executeIAMMethod("POST", "set-user-status", queryVal, nil)
+	return err
+}
+
+// ListUsers - returns all users by access key.
+func (adm *AdminClient) ListUsers() (map[string]UserInfo, error) {
+	respBytes, err := adm.executeIAMMethod("GET", "list-users", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	users := make(map[string]UserInfo)
+	if err = json.Unmarshal(respBytes, &users); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// AddPolicy - adds or replaces a custom policy, the policy is a JSON
+// policy document without principal.
+func (adm *AdminClient) AddPolicy(policyName string, policy io.Reader) error {
+	body, err := ioutil.ReadAll(policy)
+	if err != nil {
+		return err
+	}
+
+	queryVal := make(url.Values)
+	queryVal.Set("policyName", policyName)
+	_, err = adm.executeIAMMethod("PUT", "add-policy", queryVal, body)
+	return err
+}
+
+// RemovePolicy - removes a custom policy and detaches it from all
+// users and groups.
+func (adm *AdminClient) RemovePolicy(policyName string) error {
+	queryVal := make(url.Values)
+	queryVal.Set("policyName", policyName)
+	_, err := adm.executeIAMMethod("POST", "remove-policy", queryVal, nil)
+	return err
+}
+
+// ListPolicies - returns all canned and custom policies by name.
+func (adm *AdminClient) ListPolicies() (map[string]json.RawMessage, error) {
+	respBytes, err := adm.executeIAMMethod("GET", "list-policies", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	policies := make(map[string]json.RawMessage)
+	if err = json.Unmarshal(respBytes, &policies); err != nil {
+		return nil, err
+	}
+	return policies, nil
+}
+
+// SetUserPolicy - attaches a policy to a user, an empty policy name
+// detaches the current policy.
+func (adm *AdminClient) SetUserPolicy(accessKey, policyName string) error {
+	queryVal := make(url.Values)
+	queryVal.Set("accessKey", accessKey)
+	queryVal.Set("policyName", policyName)
+	_, err := adm.executeIAMMethod("POST", "set-user-policy", queryVal, nil)
+	return err
+}
+
+// SetGroupPolicy - attaches a policy to a group, an empty policy name
+// detaches the current policy.
+func (adm *AdminClient) SetGroupPolicy(group, policyName string) error {
+	queryVal := make(url.Values)
+	queryVal.Set("group", group)
+	queryVal.Set("policyName", policyName)
+	_, err := adm.executeIAMMethod("POST", "set-group-policy", queryVal, nil)
+	return err
+}
+
+// UpdateGroupMembers - adds users to a group or removes them from it,
+// groups are created with their first member and removed with their
+// last member.
+func (adm *AdminClient) UpdateGroupMembers(group string, members []string, isRemove bool) error {
+	body, err := json.Marshal(struct {
+		Members  []string `json:"members"`
+		IsRemove bool     `json:"isRemove"`
+	}{members, isRemove})
+	if err != nil {
+		return err
+	}
+
+	queryVal := make(url.Values)
+	queryVal.Set("group", group)
+	_, err = adm.executeIAMMethod("PUT", "update-group-members", queryVal, body)
+	return err
+}
+
+// ListGroups - returns all groups by name.
+func (adm *AdminClient) ListGroups() (map[string]GroupInfo, error) {
+	respBytes, err := adm.executeIAMMethod("GET", "list-groups", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	groups := make(map[string]GroupInfo)
+	if err = json.Unmarshal(respBytes, &groups); err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
@@ -124,3 +124,112 @@ func (adm *AdminClient) ServerInfo() ([]ServerInfo, error) {
 
 	return serversInfo, nil
 }
+
+// BucketUsageInfo - data usage of a single bucket, sizes and histograms
+// account for all stored versions of the objects.
+type BucketUsageInfo struct {
+	Size                   uint64            `json:"size"`
+	ObjectsCount           uint64            `json:"objectsCount"`
+	VersionsCount          uint64            `json:"versionsCount"`
+	ObjectsSizesHistogram  map[string]uint64 `json:"objectsSizesHistogram"`
+	IncompleteUploadsCount uint64            `json:"incompleteUploadsCount"`
+}
+
+// DataUsageInfo - data usage of all buckets as of the last crawl of
+// the server's background data usage crawler.
+type DataUsageInfo struct {
+	LastUpdate             time.Time                  `json:"lastUpdate"`
+	ObjectsCount           uint64                     `json:"objectsCount"`
+	VersionsCount          uint64                     `json:"versionsCount"`
+	ObjectsTotalSize       uint64                     `json:"objectsTotalSize"`
+	ObjectsSizesHistogram  map[string]uint64          `json:"objectsSizesHistogram"`
+	IncompleteUploadsCount uint64                     `json:"incompleteUploadsCount"`
+	BucketsCount           uint64                     `json:"bucketsCount"`
+	BucketsUsage           map[string]BucketUsageInfo `json:"bucketsUsage"`
+}
+
+// DataUsageInfo - Connect to a minio server and call Data Usage Management API
+// to fetch the data usage of all buckets represented by DataUsageInfo structure
+func (adm *AdminClient) DataUsageInfo() (DataUsageInfo, error) {
+	var dataUsage DataUsageInfo
+
+	// Prepare web service request
+	reqData := requestData{}
+	reqData.queryValues = make(url.Values)
+	reqData.queryValues.Set("datausage", "")
+	reqData.customHeaders = make(http.Header)
+
+	resp, err := adm.executeMethod("GET", reqData)
+	defer closeResponse(resp)
+	if err != nil {
+		return dataUsage, err
+	}
+
+	// Check response http status code
+	if resp.StatusCode != http.StatusOK {
+		return dataUsage, httpRespToErrorResponse(resp)
+	}
+
+	respBytes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return dataUsage, err
+	}
+
+	err = json.Unmarshal(respBytes, &dataUsage)
+	return dataUsage, err
+}
+
+// ScrubStats - progress of the running cycle of the background
+// scrubber, or stats of its last cycle once idle. Status is one of
+// "idle" or "running".
+type ScrubStats struct {
+	Status       string    `json:"status"`
+	Node         string    `json:"node,omitempty"`
+	Cycles       uint64    `json:"cycles"`
+	CycleStart   time.Time `json:"cycleStart"`
+	LastUpdate   time.Time `json:"lastUpdate"`
+	LastCycleEnd time.Time `json:"lastCycleEnd"`
+
+	MarkerBucket string `json:"markerBucket,omitempty"`
+	MarkerObject string `json:"markerObject,omitempty"`
+
+	ObjectsScanned  uint64 `json:"objectsScanned"`
+	ObjectsHealed   uint64 `json:"objectsHealed"`
+	ObjectsFailed   uint64 `json:"objectsFailed"`
+	ShardsVerified  uint64 `json:"shardsVerified"`
+	BytesVerified   uint64 `json:"bytesVerified"`
+	ShardsCorrupted uint64 `json:"shardsCorrupted"`
+	ShardsMissing   uint64 `json:"shardsMissing"`
+	ShardsHealed    uint64 `json:"shardsHealed"`
+}
+
+// ScrubStats - Connect to a minio server and call Scrub Stats Management API
+// to fetch the stats of the background scrubber represented by ScrubStats structure
+func (adm *AdminClient) ScrubStats() (ScrubStats, error) {
+	var stats ScrubStats
+
+	// Prepare web service request
+	reqData := requestData{}
+	reqData.queryValues = make(url.Values)
+	reqData.queryValues.Set("scrub", "")
+	reqData.customHeaders = make(http.Header)
+
+	resp, err := adm.executeMethod("GET", reqData)
+	defer closeResponse(resp)
+	if err != nil {
+		return stats, err
+	}
+
+	// Check response http status code
+	if resp.StatusCode != http.StatusOK {
+		return stats, httpRespToErrorResponse(resp)
+	}
+
+	respBytes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return stats, err
+	}
+
+	err = json.Unmarshal(respBytes, &stats)
+	return stats, err
+}
@@ -0,0 +1,172 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package madmin
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// LogEntry - a log entry of a server.
+type LogEntry struct {
+	Node    string    `json:"node"`
+	Time    time.Time `json:"time"`
+	Level   string    `json:"level"`
+	Message string    `json:"message"`
+	Source  string    `json:"source,omitempty"`
+	Cause   string    `json:"cause,omitempty"`
+	Stack   string    `json:"stack,omitempty"`
+
+	// Error which ended the log stream, no further entries are
+	// sent after it.
+	Err error `json:"-"`
+}
+
+// ServerLogs - the recent log entries of a server.
+type ServerLogs struct {
+	Error   string     `json:"error,omitempty"`
+	Addr    string     `json:"addr"`
+	Entries []LogEntry `json:"entries"`
+}
+
+// logsQuery - returns the query values of the logs API, empty values
+// are left out.
+func logsQuery(node, level string) url.Values {
+	queryVal := make(url.Values)
+	queryVal.Set("logs", "")
+	if node != "" {
+		queryVal.Set("node", node)
+	}
+	if level != "" {
+		queryVal.Set("level", level)
+	}
+	return queryVal
+}
+
+// GetLogs - returns the recent log entries kept in memory by all
+// servers, or by node when it is not empty. Only entries at or above
+// level (panic, fatal, error, warning, info or debug) are returned, up
+// to limit most recent entries per server when limit is positive.
+func (adm *AdminClient) GetLogs(node, level string, limit int) ([]ServerLogs, error) {
+	queryVal := logsQuery(node, level)
+	if limit > 0 {
+		queryVal.Set("limit", strconv.Itoa(limit))
+	}
+	hdrs := make(http.Header)
+	hdrs.Set(minioAdminOpHeader, "get")
+
+	resp, err := adm.executeMethod("GET", requestData{
+		queryValues:   queryVal,
+		customHeaders: hdrs,
+	})
+	defer closeResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check response http status code
+	if resp.StatusCode != http.StatusOK {
+		return nil, httpRespToErrorResponse(resp)
+	}
+
+	respBytes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var serversLogs []ServerLogs
+	if err = json.Unmarshal(respBytes, &serversLogs); err != nil {
+		return nil, err
+	}
+	return serversLogs, nil
+}
+
+// StreamLogs - streams the log entries added on all servers, or on
+// node when it is not empty, until doneCh is closed. Only entries at
+// or above level are streamed.
+func (adm *AdminClient) StreamLogs(node, level string, doneCh <-chan struct{}) <-chan LogEntry {
+	logCh := make(chan LogEntry)
+
+	go func() {
+		defer close(logCh)
+
+		// Sends the error which ended the stream, unless the
+		// caller is done.
+		sendErr := func(err error) {
+			select {
+			case logCh <- LogEntry{Err: err}:
+			case <-doneCh:
+			}
+		}
+
+		hdrs := make(http.Header)
+		hdrs.Set(minioAdminOpHeader, "stream")
+		resp, err := adm.executeMethod("GET", requestData{
+			queryValues:   logsQuery(node, level),
+			customHeaders: hdrs,
+		})
+		if err != nil {
+			sendErr(err)
+			return
+		}
+		if resp.StatusCode != http.StatusOK {
+			sendErr(httpRespToErrorResponse(resp))
+			closeResponse(resp)
+			return
+		}
+		// The stream never ends by itself, close the body without
+		// draining it.
+		defer resp.Body.Close()
+
+		// Close the response body when the caller is done, which
+		// ends the decoding below.
+		decodeDoneCh := make(chan struct{})
+		defer close(decodeDoneCh)
+		go func() {
+			select {
+			case <-doneCh:
+				resp.Body.Close()
+			case <-decodeDoneCh:
+			}
+		}()
+
+		dec := json.NewDecoder(resp.Body)
+		for {
+			var entry LogEntry
+			if err = dec.Decode(&entry); err != nil {
+				select {
+				case <-doneCh:
+				default:
+					sendErr(err)
+				}
+				return
+			}
+			select {
+			case logCh <- entry:
+			case <-doneCh:
+				return
+			}
+		}
+	}()
+
+	return logCh
+}
@@ -0,0 +1,123 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package madmin
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+)
+
+const (
+	quotaQueryParam = "quota"
+)
+
+// QuotaType - type of a bucket quota.
+type QuotaType string
+
+// Quota types, writes exceeding a hard quota are rejected while a FIFO
+// quota evicts the oldest object versions of the bucket to make room.
+const (
+	HardQuota QuotaType = "hard"
+	FIFOQuota QuotaType = "fifo"
+)
+
+// BucketQuota - limits the total size in bytes and optionally the
+// number of object versions of a bucket.
+type BucketQuota struct {
+	Quota      int64     `json:"quota"`
+	MaxObjects int64     `json:"maxObjects,omitempty"`
+	Type       QuotaType `json:"quotatype"`
+}
+
+// BucketUsage - total size and number of the object versions stored
+// in a bucket.
+type BucketUsage struct {
+	Size    int64 `json:"size"`
+	Objects int64 `json:"objects"`
+}
+
+// BucketQuotaInfo - quota of a bucket along with its current usage.
+type BucketQuotaInfo struct {
+	BucketQuota
+	Usage BucketUsage `json:"usage"`
+}
+
+// executeQuotaMethod - executes a quota operation on /?quota for the
+// bucket with an optional JSON body, returns the response body.
+func (adm *AdminClient) executeQuotaMethod(method, operation, bucket string, body []byte) ([]byte, error) {
+	queryVal := make(url.Values)
+	queryVal.Set(quotaQueryParam, "")
+	queryVal.Set("bucket", bucket)
+
+	hdrs := make(http.Header)
+	hdrs.Set(minioAdminOpHeader, operation)
+
+	reqData := requestData{
+		queryValues:   queryVal,
+		customHeaders: hdrs,
+	}
+	if body != nil {
+		reqData.contentBody = bytes.NewReader(body)
+		reqData.contentMD5Bytes = sumMD5(body)
+		reqData.contentSHA256Bytes = sum256(body)
+	}
+
+	resp, err := adm.executeMethod(method, reqData)
+
+	defer closeResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, httpRespToErrorResponse(resp)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+// SetBucketQuota - sets the quota of a bucket, replacing any previous
+// quota of the bucket.
+func (adm *AdminClient) SetBucketQuota(bucket string, quota BucketQuota) error {
+	body, err := json.Marshal(quota)
+	if err != nil {
+		return err
+	}
+	_, err = adm.executeQuotaMethod("PUT", "set-bucket-quota", bucket, body)
+	return err
+}
+
+// GetBucketQuota - returns the quota of a bucket along with its
+// current usage.
+func (adm *AdminClient) GetBucketQuota(bucket string) (BucketQuotaInfo, error) {
+	var info BucketQuotaInfo
+	respBytes, err := adm.executeQuotaMethod("GET", "get-bucket-quota", bucket, nil)
+	if err != nil {
+		return info, err
+	}
+	err = json.Unmarshal(respBytes, &info)
+	return info, err
+}
+
+// RemoveBucketQuota - removes the quota of a bucket.
+func (adm *AdminClient) RemoveBucketQuota(bucket string) error {
+	_, err := adm.executeQuotaMethod("POST", "remove-bucket-quota", bucket, nil)
+	return err
+}
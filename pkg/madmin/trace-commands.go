@@ -0,0 +1,138 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package madmin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// TraceRequestInfo - the request of a traced API call, credentials
+// and signatures are redacted.
+type TraceRequestInfo struct {
+	Method   string      `json:"method"`
+	Path     string      `json:"path"`
+	RawQuery string      `json:"rawQuery,omitempty"`
+	Headers  http.Header `json:"headers"`
+	Body     []byte      `json:"body,omitempty"`
+	Client   string      `json:"client"`
+}
+
+// TraceResponseInfo - the response of a traced API call.
+type TraceResponseInfo struct {
+	StatusCode int         `json:"statusCode"`
+	Headers    http.Header `json:"headers"`
+	Body       []byte      `json:"body,omitempty"`
+}
+
+// TraceInfo - an API call handled by a server of the cluster.
+type TraceInfo struct {
+	NodeName string            `json:"node"`
+	Time     time.Time         `json:"time"`
+	API      string            `json:"api"`
+	ReqInfo  TraceRequestInfo  `json:"request"`
+	RespInfo TraceResponseInfo `json:"response"`
+	Duration time.Duration     `json:"duration"`
+
+	// Error which ended the trace, no further calls are sent
+	// after it.
+	Err error `json:"-"`
+}
+
+// Trace - streams the API calls handled by all servers of the cluster
+// until doneCh is closed.
+func (adm *AdminClient) Trace(doneCh <-chan struct{}) <-chan TraceInfo {
+	return adm.trace(false, doneCh)
+}
+
+// TraceWithBody - streams the API calls handled by all servers of the
+// cluster like Trace, with the start of the request and response
+// bodies of S3 calls.
+func (adm *AdminClient) TraceWithBody(doneCh <-chan struct{}) <-chan TraceInfo {
+	return adm.trace(true, doneCh)
+}
+
+func (adm *AdminClient) trace(withBody bool, doneCh <-chan struct{}) <-chan TraceInfo {
+	traceInfoCh := make(chan TraceInfo)
+
+	go func() {
+		defer close(traceInfoCh)
+
+		// Sends the error which ended the trace, unless the
+		// caller is done.
+		sendErr := func(err error) {
+			select {
+			case traceInfoCh <- TraceInfo{Err: err}:
+			case <-doneCh:
+			}
+		}
+
+		queryVal := make(url.Values)
+		queryVal.Set("trace", "")
+		if withBody {
+			queryVal.Set("body", "true")
+		}
+		resp, err := adm.executeMethod("GET", requestData{queryValues: queryVal})
+		if err != nil {
+			sendErr(err)
+			return
+		}
+		if resp.StatusCode != http.StatusOK {
+			sendErr(httpRespToErrorResponse(resp))
+			closeResponse(resp)
+			return
+		}
+		// The trace never ends by itself, close the body without
+		// draining it.
+		defer resp.Body.Close()
+
+		// Close the response body when the caller is done, which
+		// ends the decoding below.
+		decodeDoneCh := make(chan struct{})
+		defer close(decodeDoneCh)
+		go func() {
+			select {
+			case <-doneCh:
+				resp.Body.Close()
+			case <-decodeDoneCh:
+			}
+		}()
+
+		dec := json.NewDecoder(resp.Body)
+		for {
+			var info TraceInfo
+			if err = dec.Decode(&info); err != nil {
+				select {
+				case <-doneCh:
+				default:
+					sendErr(err)
+				}
+				return
+			}
+			select {
+			case traceInfoCh <- info:
+			case <-doneCh:
+				return
+			}
+		}
+	}()
+
+	return traceInfoCh
+}